@@ -0,0 +1,174 @@
+// Package auth implements role based authorization of admin APIs, separate
+// from the data path. Requests carry bearer tokens, an Authorizer maps them
+// to roles: read-only tokens may inspect a service, admin tokens may also
+// change it, e.g. mark nodes down or inject failures.
+//
+// Package auth реализует авторизацию API администратора на основе ролей,
+// отдельно от пути данных. Запросы несут bearer tokens, Authorizer
+// сопоставляет им роли: tokens только для чтения позволяют просматривать
+// сервис, tokens администратора -- также изменять его, например отмечать
+// node недоступными или вносить сбои.
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Role is a role of a caller of admin APIs, higher roles are allowed
+// everything lower ones are.
+//
+// Role -- роль вызывающего API администратора, более высоким ролям
+// разрешено все, что разрешено более низким.
+type Role int
+
+const (
+	// RoleNone is a role of unknown callers.
+	RoleNone Role = iota
+	// RoleReadOnly allows requests not changing the service.
+	RoleReadOnly
+	// RoleAdmin allows all requests.
+	RoleAdmin
+)
+
+// Authorizer is the common interface of authorizers of admin APIs.
+//
+// Authorizer это общий интерфейс авторизаторов API администратора.
+type Authorizer interface {
+	// Authorize returns the role of the caller presenting token.
+	// Authorize возвращает роль вызывающего, предъявившего token.
+	Authorize(token string) Role
+}
+
+// Config stores tokens of callers of admin APIs. Authorization is disabled
+// if no tokens are set.
+//
+// Config -- содержит tokens вызывающих API администратора. Авторизация
+// отключена, если tokens не заданы.
+type Config struct {
+	// AdminTokens are tokens allowed to call all admin endpoints.
+	// AdminTokens -- tokens, которым разрешено вызывать все методы API
+	// администратора.
+	AdminTokens []string `yaml:"admin_tokens"`
+	// ReadOnlyTokens are tokens allowed to call read-only admin endpoints.
+	// ReadOnlyTokens -- tokens, которым разрешено вызывать методы API
+	// администратора только для чтения.
+	ReadOnlyTokens []string `yaml:"read_only_tokens"`
+}
+
+// Tokens is an Authorizer with lists of tokens, which can be replaced
+// while it is used.
+//
+// Tokens -- Authorizer со списками tokens, которые можно заменить во время
+// его использования.
+type Tokens struct {
+	lock     sync.RWMutex
+	admin    []string
+	readOnly []string
+}
+
+// New creates an Authorizer with tokens of cfg. Returns nil if cfg has no
+// tokens, so authorization is disabled.
+//
+// New создает Authorizer с tokens из cfg. Возвращает nil, если в cfg нет
+// tokens, так что авторизация отключена.
+func New(cfg Config) Authorizer {
+	if len(cfg.AdminTokens) == 0 && len(cfg.ReadOnlyTokens) == 0 {
+		return nil
+	}
+	return &Tokens{admin: cfg.AdminTokens, readOnly: cfg.ReadOnlyTokens}
+}
+
+// Set replaces the tokens of t with the ones of cfg, e.g. rotated
+// in a secrets provider. Callers with removed tokens are denied from now on.
+//
+// Set заменяет tokens в t на tokens из cfg, например замененные
+// в хранилище секретов. Вызывающим с удаленными tokens с этого момента
+// отказывается в доступе.
+func (t *Tokens) Set(cfg Config) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.admin, t.readOnly = cfg.AdminTokens, cfg.ReadOnlyTokens
+}
+
+// Authorize returns RoleAdmin for admin tokens, RoleReadOnly for read-only
+// ones and RoleNone for others. Tokens are compared in constant time.
+//
+// Authorize возвращает RoleAdmin для tokens администратора, RoleReadOnly
+// для tokens только для чтения и RoleNone для остальных. Tokens
+// сравниваются за постоянное время.
+func (t *Tokens) Authorize(token string) Role {
+	if token == "" {
+		return RoleNone
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if contains(t.admin, token) {
+		return RoleAdmin
+	}
+	if contains(t.readOnly, token) {
+		return RoleReadOnly
+	}
+	return RoleNone
+}
+
+func contains(tokens []string, token string) bool {
+	found := 0
+	for _, t := range tokens {
+		found |= subtle.ConstantTimeCompare([]byte(t), []byte(token))
+	}
+	return found == 1
+}
+
+// Required returns the role required for r: RoleReadOnly for GET and HEAD
+// requests and RoleAdmin for others.
+//
+// Required возвращает роль, требуемую для r: RoleReadOnly для запросов GET
+// и HEAD и RoleAdmin для остальных.
+func Required(r *http.Request) Role {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return RoleReadOnly
+	}
+	return RoleAdmin
+}
+
+// Token returns the bearer token of r from the Authorization header or
+// the token query parameter, which browsers can't set headers of event
+// streams with.
+//
+// Token возвращает bearer token r из заголовка Authorization или параметра
+// запроса token, с которым браузеры не могут задать заголовки потоков
+// событий.
+func Token(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
+
+// HTTP wraps next, so it serves only requests with a token of a role
+// Required for them and responds with 401 Unauthorized or 403 Forbidden
+// to others. Returns next if a is nil.
+//
+// HTTP оборачивает next, так что он обслуживает только запросы с token
+// роли, требуемой для них Required, а на остальные отвечает 401
+// Unauthorized или 403 Forbidden. Возвращает next, если a равен nil.
+func HTTP(a Authorizer, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := a.Authorize(Token(r))
+		switch {
+		case role == RoleNone:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		case role < Required(r):
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
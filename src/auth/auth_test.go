@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := HTTP(New(Config{AdminTokens: []string{"admin"}, ReadOnlyTokens: []string{"viewer"}}), ok)
+
+	for _, tc := range []struct {
+		method, url, header string
+		want                int
+	}{
+		{"GET", "/", "", http.StatusUnauthorized},
+		{"GET", "/", "Bearer wrong", http.StatusUnauthorized},
+		{"GET", "/", "Bearer viewer", http.StatusNoContent},
+		{"GET", "/events?token=viewer", "", http.StatusNoContent},
+		{"POST", "/markdown", "Bearer viewer", http.StatusForbidden},
+		{"POST", "/markdown", "Bearer admin", http.StatusNoContent},
+		{"POST", "/markdown?token=admin", "", http.StatusNoContent},
+		{"GET", "/", "Basic admin", http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(tc.method, tc.url, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%v %v with %q: got status %d, want %d", tc.method, tc.url, tc.header, w.Code, tc.want)
+		}
+	}
+
+	if New(Config{}) != nil {
+		t.Errorf("New() of empty config should disable authorization")
+	}
+	if h := HTTP(nil, ok); h == nil {
+		t.Errorf("HTTP() with nil Authorizer returned nil")
+	}
+}
+
+func TestTokens_Set(t *testing.T) {
+	tokens := New(Config{AdminTokens: []string{"old"}}).(*Tokens)
+	tokens.Set(Config{AdminTokens: []string{"new"}, ReadOnlyTokens: []string{"viewer"}})
+	for token, want := range map[string]Role{"old": RoleNone, "new": RoleAdmin, "viewer": RoleReadOnly} {
+		if got := tokens.Authorize(token); got != want {
+			t.Errorf("Authorize(%q) after Set() = %v, want %v", token, got, want)
+		}
+	}
+}
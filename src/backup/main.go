@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"storage"
+)
+
+func usage() {
+	fmt.Println("backup -- exports a consistent snapshot of the distributed KV storage")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  backup [-h]")
+	fmt.Println("  backup -s=<addr> -o=<file> [-since=<snapshot>] [-drop=<snapshot>]")
+	fmt.Println("  backup -restore -s=<addr> [-r=<router>] [-rate=<records>] <file>...")
+	fmt.Println()
+	fmt.Println("A new snapshot is taken and its id is printed. With -since only records")
+	fmt.Println("changed after the given snapshot are exported, so the snapshot of")
+	fmt.Println("the previous backup should be kept until the next one is done.")
+	fmt.Println()
+	fmt.Println("With -restore the full backup and the following incremental ones are")
+	fmt.Println("replayed through the frontend in the given order, so the cluster may")
+	fmt.Println("have a different topology. With -r redundancy of every restored record")
+	fmt.Println("is validated afterwards. With -rate restore is throttled not to overload")
+	fmt.Println("the cluster.")
+
+	fmt.Println()
+	fmt.Println("List of available options:")
+	flag.PrintDefaults()
+}
+
+// FreezeTimeout is a maximal time writes are blocked while taking a snapshot.
+const FreezeTimeout = 10 * time.Second
+
+var (
+	addr  = flag.String("s", "", "address of frontend (e.g. localhost:7319) (REQUIRED)")
+	out   = flag.String("o", "", "file to write the backup to (REQUIRED)")
+	since = flag.Uint64("since", 0, "snapshot of the previous backup to export changes since")
+	drop  = flag.Uint64("drop", 0, "snapshot to drop after successful backup")
+	limit = flag.Int("n", 0, "number of records to request per page")
+
+	restore = flag.Bool("restore", false, "restore backup files instead of making a backup")
+	rtr     = flag.String("r", "", "address of router to validate redundancy of restored records with")
+	rate    = flag.Float64("rate", 0, "number of records restored per second at most, 0 means no limit")
+	help    = flag.Bool("h", false, "show this help message")
+)
+
+// header is the first line of a backup file.
+type header struct {
+	Snapshot uint64 `json:"snapshot"`
+	Since    uint64 `json:"since,omitempty"`
+}
+
+type record struct {
+	Key     storage.RecordID `json:"key"`
+	Data    []byte           `json:"data,omitempty"`
+	Deleted bool             `json:"deleted,omitempty"`
+}
+
+func takeSnapshot(client storage.Client, fe storage.ServiceAddr) (uint64, error) {
+	id := uint64(time.Now().UnixNano())
+	if err := client.Snapshot(fe, id, storage.SnapshotFreeze, FreezeTimeout); err != nil {
+		client.Snapshot(fe, id, storage.SnapshotAbort, 0)
+		return 0, err
+	}
+	if err := client.Snapshot(fe, id, storage.SnapshotTake, 0); err != nil {
+		client.Snapshot(fe, id, storage.SnapshotAbort, 0)
+		client.Snapshot(fe, id, storage.SnapshotDrop, 0)
+		return 0, err
+	}
+	return id, nil
+}
+
+func export(client storage.Client, fe storage.ServiceAddr, id uint64, enc *json.Encoder) (int, error) {
+	count := 0
+	var cursor storage.Cursor
+	for {
+		var records []storage.Record
+		var err error
+		if *since != 0 {
+			records, cursor, err = client.ScanChanges(fe, id, *since, cursor, *limit)
+		} else {
+			records, cursor, err = client.ScanSnapshot(fe, id, cursor, *limit)
+		}
+		if err != nil {
+			return count, err
+		}
+		for _, r := range records {
+			if err := enc.Encode(record{Key: r.Key, Data: r.Data, Deleted: r.Deleted}); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if cursor == nil {
+			return count, nil
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+	if *help {
+		usage()
+		os.Exit(0)
+	}
+	if *addr == "" {
+		fmt.Fprintln(os.Stderr, "-s cannot be empty")
+		os.Exit(2)
+	}
+
+	client := storage.NewClient()
+	fe := storage.ServiceAddr(*addr)
+
+	if *restore {
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "at least one backup file should be provided")
+			os.Exit(2)
+		}
+		if err := restoreFiles(client, fe, flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "-o cannot be empty")
+		os.Exit(2)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating backup file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	id, err := takeSnapshot(client, fe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error taking snapshot: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(f)
+	if err := enc.Encode(header{Snapshot: id, Since: *since}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing backup: %v\n", err)
+		os.Exit(1)
+	}
+	count, err := export(client, fe, id, enc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting snapshot %v: %v\n", id, err)
+		os.Exit(1)
+	}
+
+	if *drop != 0 {
+		if err := client.Snapshot(fe, *drop, storage.SnapshotDrop, 0); err != nil {
+			fmt.Fprintf(os.Stderr, "Error dropping snapshot %v: %v\n", *drop, err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d records\n", count)
+	fmt.Println(id)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"ratelimit"
+	rclient "router/client"
+	"storage"
+)
+
+// readBackup reads the header and records of a backup file.
+func readBackup(fname string) (header, []record, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return header{}, nil, fmt.Errorf("Error opening backup file: %v", err)
+	}
+	defer f.Close()
+
+	var h header
+	var records []record
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		if h.Snapshot == 0 {
+			if err := json.Unmarshal(s.Bytes(), &h); err != nil || h.Snapshot == 0 {
+				return header{}, nil, fmt.Errorf("Invalid header of backup file %q", fname)
+			}
+			continue
+		}
+		var r record
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			return header{}, nil, fmt.Errorf("Invalid record in backup file %q: %v", fname, err)
+		}
+		records = append(records, r)
+	}
+	if err := s.Err(); err != nil {
+		return header{}, nil, fmt.Errorf("Error reading backup file %q: %v", fname, err)
+	}
+	return h, records, nil
+}
+
+// restoreRecord writes r through the frontend replacing the existing record.
+func restoreRecord(client storage.Client, fe storage.ServiceAddr, r record) error {
+	if r.Deleted {
+		if err := client.Del(fe, r.Key); err != nil && err != storage.ErrRecordNotFound {
+			return err
+		}
+		return nil
+	}
+
+	err := client.Put(fe, r.Key, r.Data)
+	if err != storage.ErrRecordExists {
+		return err
+	}
+	if d, err := client.Get(fe, r.Key); err == nil && bytes.Equal(d, r.Data) {
+		return nil
+	}
+	if err := client.Del(fe, r.Key); err != nil && err != storage.ErrRecordNotFound {
+		return err
+	}
+	return client.Put(fe, r.Key, r.Data)
+}
+
+// validate checks that every record is stored on all nodes chosen by the router.
+func validate(client storage.Client, router storage.ServiceAddr, records map[storage.RecordID][]byte) (underReplicated int, err error) {
+	rc := rclient.New()
+	for k, d := range records {
+		nodes, err := rc.NodesFind(router, k)
+		if err != nil {
+			return underReplicated, fmt.Errorf("Error finding nodes of record %v: %v", k, err)
+		}
+		replicas := 0
+		for _, node := range nodes {
+			if got, err := client.Get(node, k); err == nil && bytes.Equal(got, d) {
+				replicas++
+			}
+		}
+		if replicas < storage.ReplicationFactor {
+			fmt.Fprintf(os.Stderr, "Record %v has %d of %d replicas\n", k, replicas, storage.ReplicationFactor)
+			underReplicated++
+		}
+	}
+	return underReplicated, nil
+}
+
+func restoreFiles(client storage.Client, fe storage.ServiceAddr, fnames []string) error {
+	restored := make(map[storage.RecordID][]byte)
+	limiter := ratelimit.Unlimited
+	if *rate > 0 {
+		limiter = ratelimit.NewTokenBucket(*rate, int(math.Ceil(*rate)))
+	}
+	var last uint64
+	for i, fname := range fnames {
+		h, records, err := readBackup(fname)
+		if err != nil {
+			return err
+		}
+		if i == 0 && h.Since != 0 {
+			return fmt.Errorf("Backup file %q is incremental, a full backup should go first", fname)
+		}
+		if i > 0 && h.Since != last {
+			return fmt.Errorf("Backup file %q follows snapshot %v, not %v", fname, h.Since, last)
+		}
+		last = h.Snapshot
+
+		for _, r := range records {
+			limiter.WaitN(context.Background(), 1)
+			if err := restoreRecord(client, fe, r); err != nil {
+				return fmt.Errorf("Error restoring record %v: %v", r.Key, err)
+			}
+			if r.Deleted {
+				delete(restored, r.Key)
+			} else {
+				restored[r.Key] = r.Data
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Restored %d records from snapshot %v\n", len(restored), last)
+
+	if *rtr == "" {
+		return nil
+	}
+	underReplicated, err := validate(client, storage.ServiceAddr(*rtr), restored)
+	if err != nil {
+		return err
+	}
+	if underReplicated > 0 {
+		return fmt.Errorf("%d records are under-replicated", underReplicated)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"frontend/frontend"
+	rclient "router/client"
+	"storage"
+)
+
+func FuzzReadBackup(f *testing.F) {
+	f.Add([]byte("{\"snapshot\":1}\n{\"key\":42,\"data\":\"dmFsdWU=\"}\n{\"key\":7,\"deleted\":true}\n"))
+	f.Add([]byte("{\"snapshot\":2,\"since\":1}\n"))
+	f.Add([]byte("{\"snapshot\":0}\n"))
+	f.Add([]byte("{\"snapshot\":1}\n{\"key\":-1}\n"))
+	f.Add([]byte("{\"snapshot\":1}\n{\"key\":1,\"data\":\"!\"}\n"))
+	f.Add([]byte(""))
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		f.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "backup.json")
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		if err := ioutil.WriteFile(fname, b, 0644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		h, records, err := readBackup(fname)
+		if err != nil || h.Snapshot == 0 {
+			return
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.Encode(h)
+		for _, r := range records {
+			enc.Encode(r)
+		}
+		if err := ioutil.WriteFile(fname, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		gotH, got, err := readBackup(fname)
+		if err != nil || gotH != h || len(got) != len(records) {
+			t.Fatalf("readBackup() of rewritten backup got %v, %v, %v, want %v, %v", gotH, got, err, h, records)
+		}
+		for i, r := range records {
+			if got[i].Key != r.Key || got[i].Deleted != r.Deleted || !bytes.Equal(got[i].Data, r.Data) {
+				t.Fatalf("readBackup() of rewritten backup got %v, %v, %v, want %v, %v", gotH, got, err, h, records)
+			}
+		}
+	})
+}
+
+// nodes stores records of the nodes of a cluster in memory.
+type nodes struct {
+	storage.Client
+	lock   sync.Mutex
+	stored map[storage.ServiceAddr]map[storage.RecordID][]byte
+}
+
+func (n *nodes) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if _, ok := n.stored[node][k]; ok {
+		return storage.ErrRecordExists
+	}
+	if n.stored[node] == nil {
+		n.stored[node] = make(map[storage.RecordID][]byte)
+	}
+	n.stored[node][k] = d
+	return nil
+}
+
+func (n *nodes) Get(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	d, ok := n.stored[node][k]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func (n *nodes) Del(node storage.ServiceAddr, k storage.RecordID) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if _, ok := n.stored[node][k]; !ok {
+		return storage.ErrRecordNotFound
+	}
+	delete(n.stored[node], k)
+	return nil
+}
+
+func (n *nodes) ScanSnapshot(node storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	after, ok, err := cursor.After()
+	if err != nil {
+		return nil, nil, err
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	var records []storage.Record
+	for k, d := range n.stored[node] {
+		if !ok || k > after {
+			records = append(records, storage.Record{Key: k, Data: d})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+	if len(records) > limit {
+		records = records[:limit]
+		return records, storage.CursorAfter(records[limit-1].Key), nil
+	}
+	return records, nil, nil
+}
+
+// router places every record on all nodes.
+type router struct {
+	rclient.Client
+	nodes []storage.ServiceAddr
+}
+
+func (r router) NodesFind(_ storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+	return r.nodes, nil
+}
+
+func (r router) List(_ storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+	return r.nodes, nil
+}
+
+// frontendClient serves requests to any frontend with fe.
+type frontendClient struct {
+	storage.Client
+	fe *frontend.Frontend
+}
+
+func (c frontendClient) Put(_ storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return c.fe.Put(k, d)
+}
+
+func (c frontendClient) Get(_ storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	return c.fe.Get(k)
+}
+
+func (c frontendClient) Del(_ storage.ServiceAddr, k storage.RecordID) error {
+	return c.fe.Del(k)
+}
+
+func (c frontendClient) ScanSnapshot(_ storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return c.fe.ScanSnapshot(id, cursor, limit)
+}
+
+func TestBackupRestore_ErasureCoded(t *testing.T) {
+	ec := storage.RecordID(0x80000000)
+	n := &nodes{stored: make(map[storage.ServiceAddr]map[storage.RecordID][]byte)}
+	fe := frontend.New(frontend.Config{
+		NC:     n,
+		RC:     router{nodes: []storage.ServiceAddr{"node1", "node2", "node3"}},
+		Router: "router",
+		Namespaces: storage.Namespaces{
+			{Prefix: storage.Prefix{Key: ec, Len: 1}, Class: storage.ClassErasureCoded},
+		},
+	})
+	client := frontendClient{fe: fe}
+	want := map[storage.RecordID][]byte{
+		1:      []byte("replicated value"),
+		ec:     []byte("erasure-coded value"),
+		ec + 1: []byte("another erasure-coded value"),
+	}
+	for k, d := range want {
+		if err := fe.Put(k, d); err != nil {
+			t.Fatalf("Put(%v) error: %v", k, err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.Encode(header{Snapshot: 1})
+	if _, err := export(client, "frontend", 1, enc); err != nil {
+		t.Fatalf("export() error: %v", err)
+	}
+	fname := filepath.Join(dir, "backup.json")
+	if err := ioutil.WriteFile(fname, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	n.stored = make(map[storage.ServiceAddr]map[storage.RecordID][]byte)
+	if err := restoreFiles(client, "frontend", []string{fname}); err != nil {
+		t.Fatalf("restoreFiles() error: %v", err)
+	}
+	for k, d := range want {
+		if got, err := fe.Get(k); err != nil || !bytes.Equal(got, d) {
+			t.Errorf("Get(%v) after restore got %q, %v, want %q", k, got, err, d)
+		}
+	}
+}
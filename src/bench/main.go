@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"frontend/frontend"
+	"storage"
+)
+
+func usage() {
+	fmt.Println("bench -- replays operations recorded by frontend against a cluster")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  bench [-h]")
+	fmt.Println("  bench -s=<addr> -r=<record file> [-speed=<factor>]")
+
+	fmt.Println()
+	fmt.Println("List of available options:")
+	flag.PrintDefaults()
+}
+
+var (
+	addr   = flag.String("s", "", "address of frontend to replay operations to (e.g. localhost:7319) (REQUIRED)")
+	record = flag.String("r", "", "file with operations recorded by frontend (REQUIRED)")
+	speed  = flag.Float64("speed", 1, "replay speed relative to the original one")
+	help   = flag.Bool("h", false, "show this help message")
+)
+
+type result struct {
+	op      string
+	latency time.Duration
+	err     error
+}
+
+func replay(client storage.Client, fe storage.ServiceAddr, op frontend.Operation) result {
+	start := time.Now()
+	var err error
+	switch op.Op {
+	case "put":
+		err = client.Put(fe, op.Key, make([]byte, op.Size))
+	case "get":
+		_, err = client.Get(fe, op.Key)
+	case "del":
+		err = client.Del(fe, op.Key)
+	case "update":
+		err = client.Update(fe, op.Key, make([]byte, op.Size))
+	case "upsert":
+		err = client.Upsert(fe, op.Key, make([]byte, op.Size))
+	default:
+		err = fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return result{op: op.Op, latency: time.Since(start), err: err}
+}
+
+func main() {
+	flag.Parse()
+	if *help {
+		usage()
+		os.Exit(0)
+	}
+	if *addr == "" || *record == "" {
+		fmt.Fprintln(os.Stderr, "-s and -r cannot be empty")
+		os.Exit(2)
+	}
+	if *speed <= 0 {
+		fmt.Fprintln(os.Stderr, "-speed should be positive")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*record)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening record file: %v\n", err)
+		os.Exit(1)
+	}
+	ops, err := frontend.ReadOperations(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading record file: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := storage.NewClient()
+	fe := storage.ServiceAddr(*addr)
+	results := make(chan result, len(ops))
+	var wg sync.WaitGroup
+	// last maps keys to channels closed once the last operation started
+	// with the key is done, so operations with the same key are applied
+	// in the recorded order.
+	last := make(map[storage.RecordID]chan struct{})
+
+	start := time.Now()
+	for _, op := range ops {
+		time.Sleep(time.Duration(float64(op.At)/(*speed)) - time.Since(start))
+		prev, done := last[op.Key], make(chan struct{})
+		last[op.Key] = done
+		wg.Add(1)
+		go func(op frontend.Operation) {
+			defer wg.Done()
+			defer close(done)
+			if prev != nil {
+				<-prev
+			}
+			results <- replay(client, fe, op)
+		}(op)
+	}
+	wg.Wait()
+	close(results)
+
+	type summary struct {
+		count, errors int
+		latency       time.Duration
+	}
+	summaries := make(map[string]*summary)
+	for r := range results {
+		s, ok := summaries[r.op]
+		if !ok {
+			s = &summary{}
+			summaries[r.op] = s
+		}
+		s.count++
+		s.latency += r.latency
+		if r.err != nil {
+			s.errors++
+		}
+	}
+
+	fmt.Printf("Replayed %d operations in %v\n", len(ops), time.Since(start))
+	for _, op := range []string{"put", "get", "del"} {
+		if s, ok := summaries[op]; ok {
+			fmt.Printf("%s: %d operations, %d errors, mean latency %v\n",
+				op, s.count, s.errors, s.latency/time.Duration(s.count))
+		}
+	}
+}
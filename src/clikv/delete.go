@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"storage"
+)
+
+// parsePrefix parses a prefix written as <key>/<bits>, e.g. 0x12000000/8.
+func parsePrefix(s string) (storage.Prefix, error) {
+	i := strings.LastIndex(s, "/")
+	if i < 0 {
+		return storage.Prefix{}, fmt.Errorf("prefix %q should be <key>/<bits>", s)
+	}
+	k, err := storage.ParseRecordID(s[:i])
+	if err != nil {
+		return storage.Prefix{}, err
+	}
+	n, err := strconv.ParseUint(s[i+1:], 10, 8)
+	if err != nil || n > 32 {
+		return storage.Prefix{}, fmt.Errorf("prefix length %q should be in [0, 32]", s[i+1:])
+	}
+	return storage.Prefix{Key: k, Len: uint(n)}, nil
+}
+
+// describeDel tells what a Del of the record with key k sent to node would
+// delete without deleting anything. If admin is set, the replicas holding
+// the record are asked through the router with the admin API at admin,
+// otherwise node is only asked whether the record exists.
+func describeDel(client storage.Client, node storage.ServiceAddr, admin string, k storage.RecordID) (string, error) {
+	if admin == "" {
+		_, err := client.Get(node, k)
+		switch err {
+		case nil, storage.ErrPossiblyStale:
+			return fmt.Sprintf("Would delete record %v", k), nil
+		case storage.ErrRecordNotFound:
+			return fmt.Sprintf("Record %v not found, nothing to delete", k), nil
+		default:
+			return "", err
+		}
+	}
+
+	placement, err := nodeStatuses(admin, fmt.Sprint(k))
+	if err != nil {
+		return "", err
+	}
+	var from, unknown []string
+	for _, r := range traceReplicas(client, k, placement, placement) {
+		switch {
+		case r.err != nil:
+			unknown = append(unknown, fmt.Sprintf("%v (%v)", r.status.Node, r.err))
+		case r.holds && r.status.Alive:
+			from = append(from, string(r.status.Node))
+		case r.holds:
+			unknown = append(unknown, fmt.Sprintf("%v (%v, skipped by Del)", r.status.Node, health(r.status)))
+		}
+	}
+	s := fmt.Sprintf("Would delete record %v from %v", k, strings.Join(from, ", "))
+	if len(from) == 0 {
+		s = fmt.Sprintf("Record %v not found, nothing to delete", k)
+	}
+	if len(unknown) > 0 {
+		s += fmt.Sprintf("; left alone or unknown on %v", strings.Join(unknown, ", "))
+	}
+	return s, nil
+}
+
+// runDelPrefix deletes records with keys having the prefix through node,
+// enumerating them with Scan pages of limit records. With dryRun it only
+// reports the records and replicas that would be deleted like describeDel.
+func runDelPrefix(client storage.Client, node storage.ServiceAddr, admin string, prefix storage.Prefix, limit int, dryRun bool) {
+	if dryRun {
+		// Clients log every request, which would bury the report.
+		log.SetOutput(ioutil.Discard)
+	}
+
+	var cursor storage.Cursor
+	if first := prefix.First(); first > 0 {
+		cursor = storage.CursorAfter(first - 1)
+	}
+	n := 0
+	for {
+		records, next, err := client.Scan(node, cursor, limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning records: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range records {
+			if r.Key > prefix.Last() {
+				next = nil
+				break
+			}
+			if dryRun {
+				s, err := describeDel(client, node, admin, r.Key)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error checking record %v: %v\n", r.Key, err)
+					os.Exit(1)
+				}
+				fmt.Println(s)
+				n++
+				continue
+			}
+			if err := client.Del(node, r.Key); err == nil {
+				n++
+			} else if err != storage.ErrRecordNotFound {
+				fmt.Fprintf(os.Stderr, "Error deleting record %v: %v\n", r.Key, err)
+				os.Exit(1)
+			}
+		}
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	if dryRun {
+		fmt.Printf("Would delete %d records, nothing was changed\n", n)
+	} else {
+		fmt.Printf("Deleted %d records\n", n)
+	}
+}
@@ -1,30 +1,55 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"math"
+	"io/ioutil"
+	"log"
 	"os"
+	"strings"
+	"time"
 
+	"frontend/frontend"
 	"storage"
 )
 
 const (
-	get = "get"
-	put = "put"
-	del = "del"
+	get   = "get"
+	put   = "put"
+	upd   = "update"
+	ups   = "upsert"
+	del   = "del"
+	delp  = "delprefix"
+	scan  = "scan"
+	stats = "stats"
+	top   = "top"
+	trace = "trace"
 )
 
 func usage() {
 	fmt.Println("Usage:")
 	fmt.Println("  clikv [-h]")
 	fmt.Println("  clikv <command> -s=<addr> -k=<key> [-v=<val>]")
+	fmt.Println("  clikv del -s=<addr> -k=<key> [-dry-run [-admin=<router admin addr>]]")
+	fmt.Println("  clikv delprefix -s=<addr> -p=<key>/<bits> [-n=<limit>] [-dry-run [-admin=<router admin addr>]]")
+	fmt.Println("  clikv scan -s=<addr> [-n=<limit>]")
+	fmt.Println("  clikv stats -s=<addr> [-admin=<frontend admin addr>]")
+	fmt.Println("  clikv top -s=<router admin addr> [-interval=<duration>]")
+	fmt.Println("  clikv trace -s=<router admin addr> -k=<key>")
 
 	fmt.Println()
 	fmt.Println("List of available commands:")
 	fmt.Printf("  %s\n", get)
 	fmt.Printf("  %s\n", put)
+	fmt.Printf("  %s -- replace the value of an existing record\n", upd)
+	fmt.Printf("  %s -- put a record or replace its value\n", ups)
 	fmt.Printf("  %s\n", del)
+	fmt.Printf("  %s -- delete all records with keys having a prefix\n", delp)
+	fmt.Printf("  %s\n", scan)
+	fmt.Printf("  %s -- records and bytes stored, by namespaces and with replication health if -admin is set\n", stats)
+	fmt.Printf("  %s -- live dashboard of nodes: QPS, latency, usage, heartbeats and replication health\n", top)
+	fmt.Printf("  %s -- placement of a key and state of its replicas, highlighting divergence\n", trace)
 
 	fmt.Println()
 	fmt.Println("List of available options:")
@@ -32,10 +57,19 @@ func usage() {
 }
 
 var (
-	addr = flag.String("s", "", "address to send request to (e.g. localhost:7319) (REQUIRED)")
-	key  = flag.Int64("k", -1, "key (REQUIRED)")
-	val  = flag.String("v", "", "value")
-	help = flag.Bool("h", false, "show this help message")
+	addr  = flag.String("s", "", "address to send request to (e.g. localhost:7319) (REQUIRED)")
+	key   = flag.String("k", "", "key in decimal or 0x hex (REQUIRED except for scan, stats, top and delprefix)")
+	pfx   = flag.String("p", "", "key prefix for delprefix as <key>/<bits>, e.g. 0x12000000/8")
+	val   = flag.String("v", "", "value")
+	help  = flag.Bool("h", false, "show this help message")
+	limit = flag.Int("n", 0, "number of records to request per page for scan")
+	tries = flag.Int("retries", 3, "times to retry requests rejected by an overloaded service after waiting the hinted time")
+	sums  = flag.String("checksum", "", "hash to store a checksum of put values with and verify it on get and scan (sha256, crc64 or fnv)")
+	every = flag.Duration("interval", 2*time.Second, "time between refreshes of top")
+	dry   = flag.Bool("dry-run", false, "report what del and delprefix would delete without changing anything")
+	admin = flag.String("admin", "", "router admin address to report replicas affected by -dry-run with, or frontend admin address to get stats of the cluster from")
+	token = flag.String("token", "", "bearer token to call the router admin API with if it is authorized")
+	sign  = flag.String("sign-key", "", "key to sign requests with as <key id>:<secret> if the service verifies signatures")
 )
 
 func main() {
@@ -48,20 +82,33 @@ func main() {
 		fmt.Fprintln(os.Stderr, "-s cannot be empty")
 		os.Exit(2)
 	}
-	if *key < 0 || *key > math.MaxUint32 {
-		fmt.Fprintln(os.Stderr, "-k should be set to a uint32 value")
-		os.Exit(2)
-	}
 	if flag.NArg() != 1 {
 		fmt.Fprintln(os.Stderr, "exactly one command should be provided")
 		os.Exit(2)
 
 	}
+	k, err := storage.ParseRecordID(*key)
+	if cmd := flag.Arg(0); cmd != scan && cmd != stats && cmd != top && cmd != delp && err != nil {
+		fmt.Fprintln(os.Stderr, "-k should be set to a uint32 value")
+		os.Exit(2)
+	}
 
-	client := storage.NewClient()
+	signer, err := parseSignKey(*sign)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	client := storage.NewBackoffClient(storage.NewSigningClient(nil, signer), *tries)
+	if *sums != "" {
+		h := storage.Hash(*sums)
+		if err := h.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		client = storage.NewChecksumClient(client, h)
+	}
 	node := storage.ServiceAddr(*addr)
 
-	k := storage.RecordID(*key)
 	data := []byte(*val)
 
 	switch flag.Arg(0) {
@@ -70,20 +117,98 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error putting record: %v\n", err)
 			os.Exit(1)
 		}
+	case upd:
+		if err := client.Update(node, k, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating record: %v\n", err)
+			os.Exit(1)
+		}
+	case ups:
+		if err := client.Upsert(node, k, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error upserting record: %v\n", err)
+			os.Exit(1)
+		}
 	case get:
 		b, err := client.Get(node, k)
-		if err != nil {
+		if err == storage.ErrPossiblyStale {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting record: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("Got record %q\n", b)
 	case del:
-		if err := client.Del(node, k); err != nil {
+		if *dry {
+			// Clients log every request, which would bury the report.
+			log.SetOutput(ioutil.Discard)
+			s, err := describeDel(client, node, *admin, k)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error checking record: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(s)
+		} else if err := client.Del(node, k); err != nil {
 			fmt.Fprintf(os.Stderr, "Error deleting record: %v\n", err)
 			os.Exit(1)
 		}
+	case delp:
+		prefix, err := parsePrefix(*pfx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		runDelPrefix(client, node, *admin, prefix, *limit, *dry)
+	case scan:
+		it := storage.ScanIterator(context.Background(), client, node, *limit)
+		for k, d := range it.All() {
+			fmt.Printf("%v: %q\n", k, d)
+		}
+		if err := it.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning records: %v\n", err)
+			os.Exit(1)
+		}
+	case stats:
+		if *admin != "" {
+			var cs frontend.ClusterStats
+			if err := adminGet(*admin, "/stats", &cs); err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting stats from %v: %v\n", *admin, err)
+				os.Exit(1)
+			}
+			renderClusterStats(os.Stdout, cs)
+			break
+		}
+		st, err := client.Stats(node)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting stats: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Records: %d\nBytes: %d\n", st.Records, st.Bytes)
+		renderNamespaces(os.Stdout, st.Namespaces)
+	case top:
+		if *every <= 0 {
+			fmt.Fprintln(os.Stderr, "-interval should be positive")
+			os.Exit(2)
+		}
+		runTop(client, *addr, *every)
+	case trace:
+		runTrace(client, *addr, k)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command %q", flag.Arg(0))
 		os.Exit(2)
 	}
 }
+
+// parseSignKey creates a Signer from a <key id>:<secret> pair or returns
+// nil if s is empty.
+func parseSignKey(s string) (*storage.Signer, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, secret := s, ""
+	if i := strings.Index(s, ":"); i >= 0 {
+		id, secret = s[:i], s[i+1:]
+	}
+	if id == "" || secret == "" {
+		return nil, fmt.Errorf("-sign-key should be <key id>:<secret>, got %q", s)
+	}
+	return storage.NewSigner(storage.SigningConfig{KeyID: id, Keys: map[string]string{id: secret}}, nil)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"frontend/frontend"
+	"storage"
+)
+
+// renderClusterStats writes stats of the cluster cs got from a frontend
+// admin API to w.
+func renderClusterStats(w io.Writer, cs frontend.ClusterStats) {
+	nodes := len(cs.Nodes) + len(cs.Unreachable)
+	fmt.Fprintf(w, "Records: %d (%d replicas)\nBytes: %d\n", cs.Records, cs.Total.Records, cs.Total.Bytes)
+	renderNamespaces(w, cs.Namespaces)
+	switch h := cs.Replication; {
+	case h.Healthy:
+		fmt.Fprintf(w, "Replication: healthy, %d/%d nodes reachable\n", len(cs.Nodes), nodes)
+	case h.Unavailable > 0:
+		fmt.Fprintf(w, "Replication: BROKEN, %d/%d nodes reachable, ~%d records can't reach quorum of %d\n",
+			len(cs.Nodes), nodes, h.Unavailable, storage.MinRedundancy)
+	default:
+		fmt.Fprintf(w, "Replication: at risk, %d/%d nodes reachable, ~%d records have less than %d replicas\n",
+			len(cs.Nodes), nodes, h.UnderReplicated, storage.ReplicationFactor)
+	}
+	for _, node := range cs.Unreachable {
+		fmt.Fprintf(w, "Unreachable: %v\n", node)
+	}
+}
+
+// renderNamespaces writes stats of namespaces ns to w.
+func renderNamespaces(w io.Writer, ns []storage.NamespaceStats) {
+	if len(ns) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Namespaces:")
+	for _, n := range ns {
+		fmt.Fprintf(w, "  0x%08x/%d: %d records, %s\n", uint32(n.Prefix.Key), n.Prefix.Len, n.Records, formatBytes(n.Bytes))
+	}
+}
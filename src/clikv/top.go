@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"router/router"
+	"storage"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// sample is the stats of a node taken at some time.
+type sample struct {
+	stats   storage.Stats
+	at      time.Time
+	latency time.Duration
+	err     error
+}
+
+// nodeRow is a line of the dashboard describing a node.
+type nodeRow struct {
+	status router.NodeStatus
+	cur    sample
+	// qps is a rate of requests since the previous sample, negative
+	// if unknown.
+	qps float64
+}
+
+// runTop redraws the dashboard of the cluster served by the router with
+// the admin API at admin every interval until interrupted.
+func runTop(client storage.Client, admin string, interval time.Duration) {
+	// Clients log every request, which would scroll the dashboard away.
+	log.SetOutput(ioutil.Discard)
+
+	prev := make(map[storage.ServiceAddr]sample)
+	for {
+		statuses, err := nodeStatuses(admin, "")
+		fmt.Print(clearScreen)
+		if err != nil {
+			fmt.Printf("Error getting nodes from %v: %v\n", admin, err)
+		} else {
+			rows := sampleNodes(client, statuses, prev)
+			renderTop(os.Stdout, admin, time.Now(), rows)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// nodeStatuses gets statuses of nodes from the router admin API at admin,
+// of all nodes or of the ones placing key if it is set, presenting -token.
+func nodeStatuses(admin, key string) ([]router.NodeStatus, error) {
+	path := "/nodes"
+	if key != "" {
+		path += "?key=" + url.QueryEscape(key)
+	}
+	var statuses []router.NodeStatus
+	if err := adminGet(admin, path, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// adminGet decodes the JSON reply to a GET of path from the admin API at
+// admin into v, presenting -token.
+func adminGet(admin, path string, v interface{}) error {
+	if !strings.Contains(admin, "://") {
+		admin = "http://" + admin
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(admin, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	c := http.Client{Timeout: storage.Timeout}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// sampleNodes takes stats of all nodes concurrently and computes request
+// rates against the previous samples, which are replaced with new ones.
+func sampleNodes(client storage.Client, statuses []router.NodeStatus, prev map[storage.ServiceAddr]sample) []nodeRow {
+	samples := make([]chan sample, len(statuses))
+	for i, st := range statuses {
+		samples[i] = make(chan sample, 1)
+		go func(node storage.ServiceAddr, ch chan<- sample) {
+			start := time.Now()
+			stats, err := client.Stats(node)
+			ch <- sample{stats: stats, at: start, latency: time.Since(start), err: err}
+		}(st.Node, samples[i])
+	}
+
+	rows := make([]nodeRow, len(statuses))
+	for i, st := range statuses {
+		cur := <-samples[i]
+		row := nodeRow{status: st, cur: cur, qps: -1}
+		if p, ok := prev[st.Node]; ok && p.err == nil && cur.err == nil && cur.stats.Requests >= p.stats.Requests {
+			if elapsed := cur.at.Sub(p.at).Seconds(); elapsed > 0 {
+				row.qps = float64(cur.stats.Requests-p.stats.Requests) / elapsed
+			}
+		}
+		if cur.err == nil {
+			prev[st.Node] = cur
+		} else {
+			delete(prev, st.Node)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// health describes the state of a node as seen by the router in a word.
+func health(st router.NodeStatus) string {
+	switch {
+	case st.Down:
+		return "down"
+	case st.Degraded:
+		return "degraded"
+	case !st.Alive:
+		return "lost"
+	default:
+		return "ok"
+	}
+}
+
+// health describes the state of a node in a word.
+func (row nodeRow) health() string {
+	if h := health(row.status); h != "ok" || row.cur.err == nil {
+		return h
+	}
+	return "unreachable"
+}
+
+// renderTop writes the dashboard of rows to w.
+func renderTop(w io.Writer, admin string, now time.Time, rows []nodeRow) {
+	fmt.Fprintf(w, "clikv top -- router admin %v, %v\n\n", admin, now.Format("15:04:05"))
+	fmt.Fprintf(w, "%-24s %-12s %8s %8s %9s %10s %10s\n", "NODE", "STATUS", "HB AGE", "QPS", "LATENCY", "RECORDS", "BYTES")
+
+	var total storage.Stats
+	available := 0
+	for _, row := range rows {
+		health := row.health()
+		if health == "ok" {
+			available++
+		}
+		qps, latency, records, bytes := "-", "-", "-", "-"
+		if row.qps >= 0 {
+			qps = fmt.Sprintf("%.1f", row.qps)
+		}
+		if row.cur.err == nil {
+			latency = row.cur.latency.Round(10 * time.Microsecond).String()
+			records = fmt.Sprint(row.cur.stats.Records)
+			bytes = formatBytes(row.cur.stats.Bytes)
+			total.Records += row.cur.stats.Records
+			total.Bytes += row.cur.stats.Bytes
+		}
+		fmt.Fprintf(w, "%-24s %-12s %8s %8s %9s %10s %10s\n", row.status.Node, health,
+			row.status.HeartbeatAge.Round(100*time.Millisecond), qps, latency, records, bytes)
+	}
+
+	fmt.Fprintf(w, "\nTotal: %d replicas, %s\n", total.Records, formatBytes(total.Bytes))
+	switch unavailable := len(rows) - available; {
+	case unavailable == 0:
+		fmt.Fprintf(w, "Replication: healthy, %d/%d nodes available\n", available, len(rows))
+	case available < storage.MinRedundancy:
+		fmt.Fprintf(w, "Replication: BROKEN, %d/%d nodes available, quorum of %d can't be reached\n", available, len(rows), storage.MinRedundancy)
+	default:
+		fmt.Fprintf(w, "Replication: at risk, %d/%d nodes available, their records have less than %d replicas\n", available, len(rows), storage.ReplicationFactor)
+	}
+}
+
+// formatBytes formats n bytes with a binary unit.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+
+	"router/router"
+	"storage"
+)
+
+// replica is the state of the record on a node.
+type replica struct {
+	status   router.NodeStatus
+	selected bool
+	holds    bool
+	sum      storage.Checksum
+	versions []uint64
+	err      error
+}
+
+// runTrace prints where the record with key k should be stored according
+// to the router with the admin API at admin, where it is actually stored
+// and whether the replicas diverge. Exits with 1 if they do.
+func runTrace(client storage.Client, admin string, k storage.RecordID) {
+	// Clients log every request, which would bury the report.
+	log.SetOutput(ioutil.Discard)
+
+	placement, err := nodeStatuses(admin, fmt.Sprint(k))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting placement from %v: %v\n", admin, err)
+		os.Exit(1)
+	}
+	all, err := nodeStatuses(admin, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting nodes from %v: %v\n", admin, err)
+		os.Exit(1)
+	}
+
+	replicas := traceReplicas(client, k, placement, all)
+	if problems := renderTrace(os.Stdout, k, replicas); problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// traceReplicas asks all nodes for the checksum and versions of the record
+// with key k concurrently. Nodes of placement come first in its order.
+func traceReplicas(client storage.Client, k storage.RecordID, placement, all []router.NodeStatus) []replica {
+	selected := make(map[storage.ServiceAddr]bool, len(placement))
+	replicas := make([]replica, 0, len(all))
+	for _, st := range placement {
+		selected[st.Node] = true
+		replicas = append(replicas, replica{status: st, selected: true})
+	}
+	var others []replica
+	for _, st := range all {
+		if !selected[st.Node] {
+			others = append(others, replica{status: st})
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].status.Node < others[j].status.Node
+	})
+	replicas = append(replicas, others...)
+
+	done := make(chan struct{}, len(replicas))
+	for i := range replicas {
+		go func(r *replica) {
+			defer func() { done <- struct{}{} }()
+			r.sum, r.err = client.Checksum(r.status.Node, k, storage.HashSHA256)
+			if r.err == storage.ErrRecordNotFound {
+				r.err = nil
+				return
+			}
+			if r.err != nil {
+				return
+			}
+			r.holds = true
+			// Nodes without versioning have no versions to show.
+			r.versions, _ = client.ListVersions(r.status.Node, k)
+		}(&replicas[i])
+	}
+	for range replicas {
+		<-done
+	}
+	return replicas
+}
+
+// renderTrace writes the report on replicas of the record with key k to w
+// and returns the number of problems found.
+func renderTrace(w io.Writer, k storage.RecordID, replicas []replica) int {
+	fmt.Fprintf(w, "Key %v\n\n", k)
+	fmt.Fprintf(w, "%-24s %-9s %-12s %-6s %-18s %s\n", "NODE", "SELECTED", "STATUS", "HOLDS", "CHECKSUM", "VERSION")
+
+	// Replicas are grouped by values to tell the majority from the rest.
+	values := make(map[storage.Checksum]int)
+	for _, r := range replicas {
+		if r.holds {
+			values[r.sum]++
+		}
+	}
+	var majority storage.Checksum
+	for sum, n := range values {
+		if n > values[majority] || (n == values[majority] && bytes.Compare(sum[:], majority[:]) < 0) {
+			majority = sum
+		}
+	}
+
+	var problems []string
+	for _, r := range replicas {
+		selected, holds, sum, version := "-", "no", "-", "-"
+		if r.selected {
+			selected = "yes"
+		}
+		if r.holds {
+			holds = "yes"
+			sum = fmt.Sprintf("%x", r.sum[:8])
+			if len(values) > 1 && r.sum != majority {
+				sum += " !"
+			}
+		}
+		if r.err != nil {
+			holds = "?"
+		}
+		if n := len(r.versions); n > 0 {
+			version = fmt.Sprintf("%v (%d kept)", r.versions[n-1], n)
+		}
+		fmt.Fprintf(w, "%-24s %-9s %-12s %-6s %-18s %s\n", r.status.Node, selected, health(r.status), holds, sum, version)
+
+		switch {
+		case r.err != nil:
+			problems = append(problems, fmt.Sprintf("%v didn't answer: %v", r.status.Node, r.err))
+		case r.selected && !r.holds && len(values) > 0:
+			problems = append(problems, fmt.Sprintf("%v should hold the record but doesn't", r.status.Node))
+		case !r.selected && r.holds:
+			problems = append(problems, fmt.Sprintf("%v holds a stray copy of the record", r.status.Node))
+		}
+		if r.selected && !r.status.Alive {
+			problems = append(problems, fmt.Sprintf("%v is selected but %v, NodesFind skips it", r.status.Node, health(r.status)))
+		}
+	}
+	if len(values) > 1 {
+		problems = append(problems, fmt.Sprintf("replicas diverge: %d distinct values, differing ones are marked with !", len(values)))
+	}
+
+	fmt.Fprintln(w)
+	if len(values) == 0 {
+		fmt.Fprintln(w, "No node holds the record.")
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "Replicas are consistent.")
+	}
+	for _, p := range problems {
+		fmt.Fprintf(w, "Problem: %v\n", p)
+	}
+	return len(problems)
+}
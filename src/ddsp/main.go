@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gateway"
+	"inproc"
+	"storage"
+)
+
+const dev = "dev"
+
+func usage() {
+	fmt.Println("ddsp -- distributed KV storage")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  ddsp dev [--nodes=<n>] [--addr=<addr>] [--http=<addr>]")
+	fmt.Println()
+	fmt.Println("List of available commands:")
+	fmt.Printf("  %-6s%s\n", dev, "run a router, nodes and a frontend in one process for development")
+}
+
+func runDev(args []string) {
+	fs := flag.NewFlagSet(dev, flag.ExitOnError)
+	nodes := fs.Int("nodes", storage.ReplicationFactor, "number of nodes to start")
+	addr := fs.String("addr", "127.0.0.1:7319", "address to serve the frontend over gRPC at, empty to disable")
+	httpAddr := fs.String("http", "127.0.0.1:8080", "address to serve the HTTP gateway at")
+	fs.Parse(args)
+
+	c, err := inproc.NewCluster(inproc.Config{Nodes: *nodes})
+	if err != nil {
+		log.Fatalf("Failed to start cluster: %v", err)
+	}
+
+	errs := make(chan error, 2)
+	if *addr != "" {
+		srv := storage.NewServer(c, *addr)
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
+		log.Printf("Serving frontend over gRPC at %v", *addr)
+	}
+	go func() {
+		errs <- gateway.New(c).ListenAndServe(*httpAddr)
+	}()
+	log.Printf("Serving HTTP gateway at http://%v/records/<key> with %d nodes", *httpAddr, len(c.Nodes))
+
+	log.Fatal(<-errs)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case dev:
+		runDev(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
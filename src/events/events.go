@@ -0,0 +1,250 @@
+// Package events provides a bus of structured events inside a service, such
+// as a node going down or a failed quorum. Core logic publishes events to
+// a Bus, and subscribers plugged into it log them, count them as metrics,
+// post them to webhooks or stream them to admins, so new observability
+// features don't touch the core logic.
+//
+// Package events предоставляет шину структурированных событий внутри
+// сервиса, например отказа node или недостигнутого кворума. Основная
+// логика публикует события в Bus, а подключенные к ней подписчики пишут
+// их в лог, считают в метриках, отправляют в webhooks или транслируют
+// администраторам, так что новые средства наблюдения не затрагивают
+// основную логику.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"metrics"
+	"storage"
+)
+
+// QueueSize is a number of events queued for a subscriber. Events are
+// dropped if the queue is full.
+//
+// QueueSize -- количество событий в очереди подписчика. Если очередь
+// заполнена, события отбрасываются.
+const QueueSize = 1024
+
+// Kind is a kind of events.
+//
+// Kind -- вид событий.
+type Kind string
+
+const (
+	// NodeJoined is published by Router when a node is added to the nodes
+	// it serves.
+	NodeJoined Kind = "node_joined"
+	// NodeLeft is published by Router when a node is removed from the nodes
+	// it serves.
+	NodeLeft Kind = "node_left"
+	// NodeDown is published by Router when a node stops being returned by
+	// NodesFind, Reason tells why.
+	NodeDown Kind = "node_down"
+	// NodeUp is published by Router when a node is returned by NodesFind
+	// again.
+	NodeUp Kind = "node_up"
+	// QuorumFailed is published by Frontend when replicas of a record
+	// don't reach quorum.
+	QuorumFailed Kind = "quorum_failed"
+	// RepairCompleted is published when divergent replicas are repaired:
+	// by Frontend after writing back a resolved record and by a node after
+	// catching up with its peers.
+	RepairCompleted Kind = "repair_completed"
+	// RecordChanged is published by Frontend to the bus returned by its
+	// Changes after a record is put or deleted, Reason is the operation.
+	RecordChanged Kind = "record_changed"
+)
+
+// Event is a structured event happened in a service.
+//
+// Event -- структурированное событие, произошедшее в сервисе.
+type Event struct {
+	// Kind is a kind of the event.
+	// Kind -- вид события.
+	Kind Kind
+	// Time is a time the event happened at, the time it is published
+	// by default.
+	// Time -- время события, по умолчанию время его публикации.
+	Time time.Time
+	// Source is an address of the service publishing the event, set
+	// by Bus.
+	// Source -- адрес сервиса, публикующего событие, задается Bus.
+	Source storage.ServiceAddr `json:",omitempty"`
+	// Node is an address of the node the event is about, if any.
+	// Node -- адрес node, к которой относится событие, если есть.
+	Node storage.ServiceAddr `json:",omitempty"`
+	// Key is a key of the record the event is about, if any.
+	// Key -- ключ записи, к которой относится событие, если есть.
+	Key *storage.RecordID `json:",omitempty"`
+	// Reason describes the cause or the outcome of the event.
+	// Reason -- описание причины или результата события.
+	Reason string `json:",omitempty"`
+}
+
+// KeyOf returns a pointer to k to set Event.Key with.
+//
+// KeyOf возвращает указатель на k для задания Event.Key.
+func KeyOf(k storage.RecordID) *storage.RecordID {
+	return &k
+}
+
+// Subscriber is the common interface of event subscribers. Subscribers
+// are notified asynchronously in the order events are published, their
+// panics are recovered and logged.
+//
+// Subscriber это общий интерфейс подписчиков на события. Подписчики
+// уведомляются асинхронно в порядке публикации событий, их паники
+// перехватываются и логируются.
+type Subscriber interface {
+	// Notify is called for every event published after subscribing.
+	// Notify вызывается для каждого события, опубликованного после подписки.
+	Notify(e Event)
+}
+
+// SubscriberFunc is a function notified of events.
+//
+// SubscriberFunc -- функция, уведомляемая о событиях.
+type SubscriberFunc func(e Event)
+
+// Notify calls f(e).
+//
+// Notify вызывает f(e).
+func (f SubscriberFunc) Notify(e Event) {
+	f(e)
+}
+
+// Config stores configuration of subscribers of a service.
+//
+// Config -- содержит конфигурацию подписчиков сервиса.
+type Config struct {
+	// Log makes events written to the log of the service.
+	// Log -- события записываются в лог сервиса.
+	Log bool
+	// Webhooks are URLs each event is POSTed to as JSON.
+	// Webhooks -- адреса, на которые отправляется (POST) каждое событие
+	// в формате JSON.
+	Webhooks []string
+}
+
+type subscription struct {
+	sub    Subscriber
+	events chan Event
+}
+
+// Bus delivers events published by a service to its subscribers.
+// A nil Bus drops all events.
+//
+// Bus доставляет события, опубликованные сервисом, его подписчикам.
+// Nil Bus отбрасывает все события.
+type Bus struct {
+	source storage.ServiceAddr
+	logger *log.Logger
+	clock  storage.Clock
+
+	lock sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+// NewBus creates a Bus of the service at source without subscribers.
+//
+// NewBus создает Bus сервиса с адресом source без подписчиков.
+func NewBus(source storage.ServiceAddr, logger *log.Logger, clock storage.Clock) *Bus {
+	if logger == nil {
+		logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	if clock == nil {
+		clock = storage.SystemClock
+	}
+	return &Bus{
+		source: source,
+		logger: logger,
+		clock:  clock,
+		subs:   make(map[*subscription]struct{}),
+	}
+}
+
+// New creates a Bus of the service at source with subscribers described by
+// cfg, counting events in sink as events.<kind> if it is not nil.
+//
+// New создает Bus сервиса с адресом source с подписчиками, описанными cfg,
+// считающую события в sink как events.<kind>, если он не nil.
+func New(cfg Config, source storage.ServiceAddr, sink metrics.Sink, logger *log.Logger, clock storage.Clock) *Bus {
+	b := NewBus(source, logger, clock)
+	if sink != nil {
+		b.Subscribe(Metrics(sink))
+	}
+	if cfg.Log {
+		b.Subscribe(Log(b.logger))
+	}
+	for _, url := range cfg.Webhooks {
+		b.Subscribe(NewWebhook(url, b.logger))
+	}
+	return b
+}
+
+// Subscribe makes s notified of events published from now on until
+// the returned function is called.
+//
+// Subscribe подписывает s на события, публикуемые с этого момента, пока
+// не будет вызвана возвращенная функция.
+func (b *Bus) Subscribe(s Subscriber) (unsubscribe func()) {
+	sub := &subscription{sub: s, events: make(chan Event, QueueSize)}
+	b.lock.Lock()
+	b.subs[sub] = struct{}{}
+	b.lock.Unlock()
+	go b.run(sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.lock.Lock()
+			delete(b.subs, sub)
+			close(sub.events)
+			b.lock.Unlock()
+		})
+	}
+}
+
+func (b *Bus) run(sub *subscription) {
+	for e := range sub.events {
+		b.notify(sub.sub, e)
+	}
+}
+
+func (b *Bus) notify(s Subscriber, e Event) {
+	defer func() {
+		if err := recover(); err != nil {
+			b.logger.Printf("Event subscriber panicked on %v: %v", e.Kind, err)
+		}
+	}()
+	s.Notify(e)
+}
+
+// Publish delivers e to all subscribers without waiting for them, setting
+// its Source and its Time if it is zero. Events are dropped for
+// subscribers with a full queue.
+//
+// Publish доставляет e всем подписчикам, не дожидаясь их, задавая его
+// Source и его Time, если оно нулевое. Для подписчиков с заполненной
+// очередью события отбрасываются.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	e.Source = b.source
+	if e.Time.IsZero() {
+		e.Time = b.clock.Now()
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for sub := range b.subs {
+		select {
+		case sub.events <- e:
+		default:
+			b.logger.Printf("Event queue is full, dropping %v", e.Kind)
+		}
+	}
+}
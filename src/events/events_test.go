@@ -0,0 +1,161 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"storage"
+)
+
+func receive(t *testing.T, c <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-c:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("No event received")
+		return Event{}
+	}
+}
+
+func TestBus(t *testing.T) {
+	b := NewBus("node1", log.New(ioutil.Discard, "", 0), nil)
+	got := make(chan Event, 2)
+	unsubscribe := b.Subscribe(SubscriberFunc(func(e Event) {
+		got <- e
+	}))
+	b.Subscribe(SubscriberFunc(func(e Event) {
+		panic("broken subscriber")
+	}))
+
+	b.Publish(Event{Kind: QuorumFailed, Key: KeyOf(42)})
+	e := receive(t, got)
+	if e.Kind != QuorumFailed || *e.Key != 42 || e.Source != "node1" || e.Time.IsZero() {
+		t.Errorf("Got event %+v, want %v of key 42 from node1 with time", e, QuorumFailed)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	b.Publish(Event{Kind: NodeDown})
+	select {
+	case e := <-got:
+		t.Errorf("Got event %+v after unsubscribing", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Kind: NodeUp})
+}
+
+func TestFormat(t *testing.T) {
+	e := Event{Kind: NodeDown, Node: "node1", Key: KeyOf(7), Reason: "marked down"}
+	if got, want := Format(e), `Event node_down, node = "node1", key = 7: marked down`; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	got := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		got <- e
+	}))
+	defer srv.Close()
+
+	b := New(Config{Webhooks: []string{srv.URL}}, "router", nil, log.New(ioutil.Discard, "", 0), nil)
+	b.Publish(Event{Kind: NodeJoined, Node: "node4"})
+	if e := receive(t, got); e.Kind != NodeJoined || e.Node != "node4" || e.Source != "router" {
+		t.Errorf("Got event %+v, want %v of node4 from router", e, NodeJoined)
+	}
+}
+
+func TestStream(t *testing.T) {
+	b := NewBus("router", nil, nil)
+	s := NewStream()
+	b.Subscribe(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?kind=" + string(NodeDown))
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := make(chan Event)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var e Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Errorf("Failed to decode event: %v", err)
+			}
+			lines <- e
+		}
+		close(lines)
+	}()
+
+	// The client is registered once the headers are received.
+	b.Publish(Event{Kind: NodeUp, Node: "node1"})
+	b.Publish(Event{Kind: NodeDown, Node: storage.ServiceAddr("node2")})
+	if e := receive(t, lines); e.Kind != NodeDown || e.Node != "node2" {
+		t.Errorf("Got event %+v, want %v of node2", e, NodeDown)
+	}
+}
+
+func TestStream_SSE(t *testing.T) {
+	b := NewBus("frontend", nil, nil)
+	got := make(chan Event, 2)
+	s := Handler(b)
+	b.Subscribe(SubscriberFunc(func(e Event) {
+		got <- e
+	}))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	b.Publish(Event{Kind: NodeUp, Node: "node1"})
+	b.Publish(Event{Kind: NodeDown, Node: "node2"})
+	receive(t, got)
+	receive(t, got)
+	// Subscribers are notified concurrently, give the stream time to
+	// number both events.
+	time.Sleep(10 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Got Content-Type %q, want text/event-stream", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	if lines[0] != "id: 2\n" || lines[1] != "event: node_down\n" || !strings.HasPrefix(lines[2], "data: {") {
+		t.Errorf("Got %q, want the missed node_down event with id 2", lines)
+	}
+}
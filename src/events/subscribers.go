@@ -0,0 +1,272 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metrics"
+	"storage"
+)
+
+// Log returns a Subscriber writing events to logger.
+//
+// Log возвращает Subscriber, записывающий события в logger.
+func Log(logger *log.Logger) Subscriber {
+	return SubscriberFunc(func(e Event) {
+		logger.Print(Format(e))
+	})
+}
+
+// Format describes e in a line of text.
+//
+// Format описывает e одной строкой текста.
+func Format(e Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Event %v", e.Kind)
+	if e.Node != "" {
+		fmt.Fprintf(&b, ", node = %q", e.Node)
+	}
+	if e.Key != nil {
+		fmt.Fprintf(&b, ", key = %v", *e.Key)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, ": %v", e.Reason)
+	}
+	return b.String()
+}
+
+// Metrics returns a Subscriber counting events in sink as events.<kind>.
+//
+// Metrics возвращает Subscriber, считающий события в sink как
+// events.<kind>.
+func Metrics(sink metrics.Sink) Subscriber {
+	return SubscriberFunc(func(e Event) {
+		sink.IncrCounter("events."+string(e.Kind), 1)
+	})
+}
+
+// Webhook is a Subscriber POSTing each event to a URL as JSON.
+//
+// Webhook -- Subscriber, отправляющий (POST) каждое событие на адрес
+// в формате JSON.
+type Webhook struct {
+	url    string
+	client *http.Client
+	logger *log.Logger
+}
+
+// NewWebhook creates a Webhook POSTing events to url and logging failures
+// to logger.
+//
+// NewWebhook создает Webhook, отправляющий события на url и записывающий
+// ошибки в logger.
+func NewWebhook(url string, logger *log.Logger) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: storage.Timeout},
+		logger: logger,
+	}
+}
+
+// Notify POSTs e to the URL of w.
+//
+// Notify отправляет e на адрес w.
+func (w *Webhook) Notify(e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		w.logger.Printf("Failed to encode event %v: %v", e.Kind, err)
+		return
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		w.logger.Printf("Failed to post event %v to %q: %v", e.Kind, w.url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		w.logger.Printf("Failed to post event %v to %q: %v", e.Kind, w.url, resp.Status)
+	}
+}
+
+// StreamBacklog is a number of the latest events kept by a Stream to replay
+// to SSE clients reconnecting with a Last-Event-ID.
+//
+// StreamBacklog -- количество последних событий, хранимых Stream, чтобы
+// повторить их SSE клиентам, переподключающимся с Last-Event-ID.
+const StreamBacklog = 256
+
+// StreamKeepAlive is an interval of comments sent to idle SSE clients, so
+// proxies don't close their connections.
+//
+// StreamKeepAlive -- интервал комментариев, отправляемых бездействующим SSE
+// клиентам, чтобы прокси не закрывали их соединения.
+const StreamKeepAlive = 15 * time.Second
+
+// streamEvent is an event numbered by a Stream.
+type streamEvent struct {
+	id uint64
+	e  Event
+}
+
+// Stream is a Subscriber streaming events to HTTP clients as they happen,
+// e.g. to dashboards and automation watching a service. Clients not
+// keeping up miss events.
+//
+// Stream -- Subscriber, транслирующий события HTTP клиентам по мере
+// их появления, например панелям мониторинга и автоматике, наблюдающим
+// за сервисом. Не успевающие клиенты пропускают события.
+type Stream struct {
+	lock    sync.Mutex
+	clients map[chan streamEvent]struct{}
+	seq     uint64
+	backlog []streamEvent
+}
+
+// NewStream creates a Stream without clients.
+//
+// NewStream создает Stream без клиентов.
+func NewStream() *Stream {
+	return &Stream{clients: make(map[chan streamEvent]struct{})}
+}
+
+// Handler returns a Stream subscribed to bus.
+//
+// Handler возвращает Stream, подписанный на bus.
+func Handler(bus *Bus) *Stream {
+	s := NewStream()
+	bus.Subscribe(s)
+	return s
+}
+
+// Notify numbers e and sends it to all clients of s.
+//
+// Notify нумерует e и отправляет его всем клиентам s.
+func (s *Stream) Notify(e Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.seq++
+	se := streamEvent{id: s.seq, e: e}
+	if len(s.backlog) == StreamBacklog {
+		s.backlog = append(s.backlog[:0], s.backlog[1:]...)
+	}
+	s.backlog = append(s.backlog, se)
+	for c := range s.clients {
+		select {
+		case c <- se:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams events until the client disconnects: as server-sent
+// events if the client accepts text/event-stream or format=sse is given,
+// otherwise as JSON lines. SSE clients reconnecting with a Last-Event-ID
+// header or a last_event_id query parameter, which browsers can't set
+// headers of the first request with, get the events they missed first if
+// they are still kept, e.g. the recent ones with 0. Only events
+// of kinds listed in kind query parameters are sent if any are given.
+//
+// ServeHTTP транслирует события, пока клиент не отключится: как
+// server-sent events, если клиент принимает text/event-stream или задан
+// format=sse, иначе строками JSON. SSE клиенты, переподключающиеся
+// с заголовком Last-Event-ID или параметром запроса last_event_id,
+// с которым браузеры не могут задать заголовки первого запроса, сначала
+// получают пропущенные события, если они еще хранятся, например последние
+// с 0. Если заданы параметры запроса kind, отправляются только
+// события перечисленных в них видов.
+func (s *Stream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	kinds := make(map[Kind]bool)
+	for _, kind := range query["kind"] {
+		kinds[Kind(kind)] = true
+	}
+	sse := query.Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("last_event_id")
+	}
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	replay := sse && err == nil
+
+	c := make(chan streamEvent, QueueSize)
+	var missed []streamEvent
+	s.lock.Lock()
+	if replay {
+		for _, se := range s.backlog {
+			if se.id > last {
+				missed = append(missed, se)
+			}
+		}
+	}
+	s.clients[c] = struct{}{}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.clients, c)
+		s.lock.Unlock()
+	}()
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	write := func(se streamEvent) error {
+		if len(kinds) > 0 && !kinds[se.e.Kind] {
+			return nil
+		}
+		data, err := json.Marshal(se.e)
+		if err != nil {
+			return err
+		}
+		if sse {
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", se.id, se.e.Kind, data)
+		} else {
+			_, err = fmt.Fprintf(w, "%s\n", data)
+		}
+		return err
+	}
+
+	for _, se := range missed {
+		if err := write(se); err != nil {
+			return
+		}
+	}
+	flush()
+
+	keepAlive := time.NewTicker(StreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case se := <-c:
+			if err := write(se); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if !sse {
+				continue
+			}
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flush()
+	}
+}
@@ -0,0 +1,219 @@
+// Package fault implements failure injection for game-day testing.
+// When enabled in config, an admin HTTP API allows to delay responses of
+// a service, drop a percentage of requests or blackhole specific peers.
+//
+// Package fault реализует внесение сбоев для тестирования на реальных
+// развертываниях. Если внесение сбоев разрешено в конфигурации, HTTP API
+// администратора позволяет задерживать ответы сервиса, отбрасывать часть
+// запросов или полностью игнорировать заданные узлы.
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Config stores configuration of failure injection.
+//
+// Config -- содержит конфигурацию внесения сбоев.
+type Config struct {
+	// Enabled enables the failure injection admin API.
+	// Enabled -- включает API администратора для внесения сбоев.
+	Enabled bool
+	// Addr is an address to serve the admin API at.
+	// Addr -- адрес, по которому доступно API администратора.
+	Addr string
+}
+
+// Injector injects failures into gRPC requests served by a service.
+//
+// Injector вносит сбои в gRPC запросы, обслуживаемые сервисом.
+type Injector struct {
+	lock      sync.RWMutex
+	delay     time.Duration
+	drop      int
+	blackhole map[string]bool
+}
+
+// New creates a new Injector without any failures.
+//
+// New создает новый Injector без сбоев.
+func New() *Injector {
+	return &Injector{blackhole: make(map[string]bool)}
+}
+
+// Serve creates an Injector and serves its admin API at cfg.Addr authorized
+// by a if failure injection is enabled. Returns an interceptor to pass to
+// gRPC server or nil if it is disabled, and an error if cfg.Addr can't be
+// listened at.
+//
+// Serve создает Injector и обслуживает его API по адресу cfg.Addr
+// с авторизацией a, если внесение сбоев разрешено. Возвращает перехватчик
+// для gRPC сервера или nil, если внесение сбоев запрещено, и ошибку, если
+// не удается слушать cfg.Addr.
+func Serve(cfg Config, a auth.Authorizer) (grpc.UnaryServerInterceptor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("Addr should be set to enable failure injection")
+	}
+	l, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen for failure injection API: %v", err)
+	}
+	inj := New()
+	go func() {
+		if err := http.Serve(l, auth.HTTP(a, inj)); err != nil {
+			log.Printf("Failed to serve failure injection API: %v", err)
+		}
+	}()
+	return inj.Intercept, nil
+}
+
+// SetDelay sets a delay added to every response.
+func (inj *Injector) SetDelay(d time.Duration) {
+	inj.lock.Lock()
+	defer inj.lock.Unlock()
+	inj.delay = d
+}
+
+// SetDrop sets a percentage of requests to fail with codes.Unavailable.
+func (inj *Injector) SetDrop(percent int) {
+	inj.lock.Lock()
+	defer inj.lock.Unlock()
+	inj.drop = percent
+}
+
+// Blackhole makes requests from the peer hang until their deadline.
+// The peer is either a host or a host:port address.
+func (inj *Injector) Blackhole(peer string, on bool) {
+	inj.lock.Lock()
+	defer inj.lock.Unlock()
+	if on {
+		inj.blackhole[peer] = true
+	} else {
+		delete(inj.blackhole, peer)
+	}
+}
+
+// Reset removes all injected failures.
+func (inj *Injector) Reset() {
+	inj.lock.Lock()
+	defer inj.lock.Unlock()
+	inj.delay, inj.drop = 0, 0
+	inj.blackhole = make(map[string]bool)
+}
+
+func (inj *Injector) blackholed(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	addr := p.Addr.String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return inj.blackhole[addr] || inj.blackhole[host]
+}
+
+// Intercept is a grpc.UnaryServerInterceptor applying the injected failures.
+func (inj *Injector) Intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	inj.lock.RLock()
+	delay, drop, blackholed := inj.delay, inj.drop, inj.blackholed(ctx)
+	inj.lock.RUnlock()
+
+	if blackholed {
+		<-ctx.Done()
+		return nil, status.Error(codes.DeadlineExceeded, "blackholed by failure injection")
+	}
+	if drop > 0 && rand.Intn(100) < drop {
+		return nil, status.Error(codes.Unavailable, "dropped by failure injection")
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, status.Error(codes.DeadlineExceeded, "delayed by failure injection")
+		}
+	}
+	return handler(ctx, req)
+}
+
+type state struct {
+	Delay     string   `json:"delay"`
+	Drop      int      `json:"drop"`
+	Blackhole []string `json:"blackhole"`
+}
+
+// ServeHTTP implements the admin API:
+//
+//	GET  /              -- current failures
+//	POST /delay?d=100ms -- delay every response
+//	POST /drop?percent=10 -- drop a percentage of requests
+//	POST /blackhole?peer=10.0.0.1 -- blackhole a peer
+//	POST /unblackhole?peer=10.0.0.1 -- stop blackholing a peer
+//	POST /reset         -- remove all failures
+func (inj *Injector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		inj.lock.RLock()
+		st := state{Delay: inj.delay.String(), Drop: inj.drop, Blackhole: []string{}}
+		for p := range inj.blackhole {
+			st.Blackhole = append(st.Blackhole, p)
+		}
+		inj.lock.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+		return
+	}
+
+	q := r.URL.Query()
+	switch r.URL.Path {
+	case "/delay":
+		d, err := time.ParseDuration(q.Get("d"))
+		if err != nil || d < 0 {
+			http.Error(w, fmt.Sprintf("Invalid delay %q", q.Get("d")), http.StatusBadRequest)
+			return
+		}
+		inj.SetDelay(d)
+	case "/drop":
+		percent, err := strconv.Atoi(q.Get("percent"))
+		if err != nil || percent < 0 || percent > 100 {
+			http.Error(w, fmt.Sprintf("Invalid percent %q", q.Get("percent")), http.StatusBadRequest)
+			return
+		}
+		inj.SetDrop(percent)
+	case "/blackhole", "/unblackhole":
+		p := q.Get("peer")
+		if p == "" {
+			http.Error(w, "Peer should be set", http.StatusBadRequest)
+			return
+		}
+		inj.Blackhole(p, r.URL.Path == "/blackhole")
+	case "/reset":
+		inj.Reset()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
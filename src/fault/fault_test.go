@@ -0,0 +1,84 @@
+package fault
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func call(inj *Injector, from string, timeout time.Duration) error {
+	addr, _ := net.ResolveTCPAddr("tcp", from)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	_, err := inj.Intercept(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	return err
+}
+
+func post(inj *Injector, url string) int {
+	w := httptest.NewRecorder()
+	inj.ServeHTTP(w, httptest.NewRequest("POST", url, nil))
+	return w.Code
+}
+
+func TestInjector(t *testing.T) {
+	inj := New()
+	if err := call(inj, "10.0.0.1:4000", time.Second); err != nil {
+		t.Errorf("Got %v, want no error without failures", err)
+	}
+
+	if code := post(inj, "/blackhole?peer=10.0.0.1"); code != 204 {
+		t.Fatalf("Got status %d, want 204", code)
+	}
+	if err := call(inj, "10.0.0.1:4000", 10*time.Millisecond); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Got %v, want DeadlineExceeded for blackholed peer", err)
+	}
+	if err := call(inj, "10.0.0.2:4000", time.Second); err != nil {
+		t.Errorf("Got %v, want no error for other peer", err)
+	}
+
+	post(inj, "/reset")
+	post(inj, "/drop?percent=100")
+	if err := call(inj, "10.0.0.1:4000", time.Second); status.Code(err) != codes.Unavailable {
+		t.Errorf("Got %v, want Unavailable for dropped request", err)
+	}
+
+	post(inj, "/reset")
+	post(inj, "/delay?d=20ms")
+	start := time.Now()
+	if err := call(inj, "10.0.0.1:4000", time.Second); err != nil {
+		t.Errorf("Got %v, want no error for delayed request", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Got response in %v, want delay of 20ms", d)
+	}
+}
+
+func TestInjector_BadRequest(t *testing.T) {
+	inj := New()
+	for _, url := range []string{"/delay?d=soon", "/drop?percent=101", "/blackhole"} {
+		if code := post(inj, url); code != 400 {
+			t.Errorf("Got status %d for %q, want 400", code, url)
+		}
+	}
+}
+
+func TestServe_AddrInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	defer l.Close()
+	if _, err := Serve(Config{Enabled: true, Addr: l.Addr().String()}, nil); err == nil {
+		t.Errorf("Serve() at an address in use got no error")
+	}
+}
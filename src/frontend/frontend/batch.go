@@ -0,0 +1,388 @@
+package frontend
+
+import (
+	"context"
+
+	"storage"
+	"storage/fanout"
+)
+
+// FindParallelism is a number of concurrent requests to the router made
+// by a batch write to find the replicas of its keys.
+//
+// FindParallelism -- количество одновременных запросов к router, которые
+// делает пакетная запись, чтобы найти реплики своих ключей.
+const FindParallelism = 16
+
+// mgetResult is a reply of a node to MGet for keys with the given indices.
+type mgetResult struct {
+	node    storage.ServiceAddr
+	indices []int
+	data    [][]byte
+	errs    []error
+	err     error
+}
+
+// MGet gets items for the given keys and returns a value and an error
+// of every key, as if Get was called for each of them. Keys are grouped by
+// node, so every node gets one MGet request carrying all its keys.
+//
+// MGet получает записи для данных ключей и возвращает значение и ошибку
+// для каждого ключа, как если бы Get вызывался для каждого. Ключи
+// группируются по node, так что каждая node получает один запрос MGet
+// со всеми своими ключами.
+func (fe *Frontend) MGet(keys []storage.RecordID) ([][]byte, []error) {
+	return fe.MGetContext(context.Background(), keys)
+}
+
+// MGetContext is MGet returning ctx.Err() for every key without waiting
+// for the replicas once ctx is done, requests to them are canceled then.
+// Returns the answer of as many replicas of every key as
+// storage.ConsistencyOf(ctx) requires like GetContext does.
+//
+// MGetContext -- MGet, возвращающий ctx.Err() для каждого ключа, не
+// дожидаясь реплик, когда ctx завершен, запросы к ним при этом
+// отменяются. Возвращает ответ стольких реплик каждого ключа, сколько
+// требует storage.ConsistencyOf(ctx), как и GetContext.
+func (fe *Frontend) MGetContext(ctx context.Context, keys []storage.RecordID) ([][]byte, []error) {
+	start := fe.conf.Clock.Now()
+	defer func() {
+		fe.conf.Sink.ObserveDuration("frontend.batch_get", fe.conf.Clock.Now().Sub(start))
+	}()
+
+	data := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	fail := func(err error) ([][]byte, []error) {
+		for i := range errs {
+			errs[i] = err
+		}
+		return data, errs
+	}
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+	if err := fe.allow(len(keys)); err != nil {
+		return fail(err)
+	}
+	if err := fe.initReads(); err != nil {
+		return fail(err)
+	}
+
+	byNode := make(map[storage.ServiceAddr][]int)
+	for i, k := range keys {
+		if errs[i] = fe.checkKey(k); errs[i] != nil {
+			continue
+		}
+		for _, node := range fe.conf.NF.NodesFind(k, fe.nodes()) {
+			byNode[node] = append(byNode[node], i)
+		}
+	}
+
+	nodes := make([]storage.ServiceAddr, 0, len(byNode))
+	for node := range byNode {
+		nodes = append(nodes, node)
+	}
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	results := fanout.Collect(ctx, 0, nodes, func(_ int, node storage.ServiceAddr) mgetResult {
+		indices := byNode[node]
+		nodeKeys := make([]storage.RecordID, 0, len(indices))
+		for _, i := range indices {
+			nodeKeys = append(nodeKeys, keys[i])
+		}
+		data, errs, err := nc.MGet(node, nodeKeys)
+		return mgetResult{node: node, indices: indices, data: data, errs: errs, err: err}
+	})
+
+	replicas := make([][]getResult, len(keys))
+	for range nodes {
+		var r mgetResult
+		select {
+		case r = <-results:
+		case <-ctx.Done():
+			return fail(ctx.Err())
+		}
+		for j, i := range r.indices {
+			if r.err != nil {
+				replicas[i] = append(replicas[i], getResult{node: r.node, err: r.err})
+			} else {
+				replicas[i] = append(replicas[i], getResult{node: r.node, data: r.data[j], err: r.errs[j]})
+			}
+		}
+	}
+	for i, k := range keys {
+		if errs[i] == nil {
+			data[i], errs[i] = fe.decide(ctx, k, replicas[i])
+		}
+	}
+	fe.conf.Sink.IncrCounter("frontend.batch_get.keys", int64(len(keys)))
+	return data, errs
+}
+
+// mwriteResult is a reply of a node to a batch write of keys with
+// the given indices.
+type mwriteResult struct {
+	node    storage.ServiceAddr
+	indices []int
+	errs    []error
+	err     error
+}
+
+// MPut puts the records and returns an error of every key, as if Put
+// was called for each of them. Keys are grouped by node, so every node
+// gets one MPut request carrying all its records instead of a quorum
+// round per key. Reserved and erasure-coded records are put one by one.
+//
+// MPut добавляет записи и возвращает ошибку для каждого ключа, как
+// если бы Put вызывался для каждой. Ключи группируются по node, так что
+// каждая node получает один запрос MPut со всеми своими записями вместо
+// отдельного кворума на каждый ключ. Резервируемые записи и записи
+// с erasure coding добавляются по одной.
+func (fe *Frontend) MPut(keys []storage.RecordID, data [][]byte) []error {
+	return fe.MPutContext(context.Background(), keys, data)
+}
+
+// MPutContext is MPut requiring as many replicas of every key as
+// storage.ConsistencyOf(ctx) requires to store it.
+//
+// MPutContext -- MPut, требующий, чтобы каждый ключ сохранило столько
+// реплик, сколько требует storage.ConsistencyOf(ctx).
+func (fe *Frontend) MPutContext(ctx context.Context, keys []storage.RecordID, data [][]byte) []error {
+	start := fe.conf.Clock.Now()
+	defer func() {
+		fe.conf.Sink.ObserveDuration("frontend.batch_put", fe.conf.Clock.Now().Sub(start))
+	}()
+
+	errs := make([]error, len(keys))
+	if err := fe.startBatch(errs); err != nil {
+		return errs
+	}
+	var batch []int
+	for i, k := range keys {
+		if errs[i] = fe.checkKey(k); errs[i] != nil {
+			continue
+		}
+		if errs[i] = fe.checkValue(k, data[i]); errs[i] != nil {
+			continue
+		}
+		if fe.conf.ReservePuts || fe.erasureCoded(k) {
+			if errs[i] = fe.put(ctx, k, data[i]); errs[i] == nil {
+				fe.notify("put", k, data[i])
+			}
+			continue
+		}
+		batch = append(batch, i)
+	}
+	ctx = fe.versioned(ctx)
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	acked := fe.applyBatch(ctx, keys, batch, errs, func(node storage.ServiceAddr, indices []int) ([]error, error) {
+		nodeKeys := make([]storage.RecordID, len(indices))
+		nodeData := make([][]byte, len(indices))
+		for j, i := range indices {
+			nodeKeys[j], nodeData[j] = keys[i], data[i]
+		}
+		return nc.MPut(node, nodeKeys, nodeData)
+	})
+	// Replicas may still be putting the records if ctx is done.
+	if ctx.Err() == nil {
+		fe.rollbackBatch(storage.VersionOf(ctx), keys, errs, acked)
+	}
+	for _, i := range batch {
+		if errs[i] == nil {
+			fe.notify("put", keys[i], data[i])
+		}
+	}
+	fe.conf.Sink.IncrCounter("frontend.batch_put.keys", int64(len(keys)))
+	return errs
+}
+
+// MDel deletes records with the given keys and returns an error of
+// every key, as if Del was called for each of them. Keys are grouped by
+// node, so every node gets one MDel request carrying all its keys.
+//
+// MDel удаляет записи с данными ключами и возвращает ошибку для
+// каждого ключа, как если бы Del вызывался для каждого. Ключи
+// группируются по node, так что каждая node получает один запрос MDel
+// со всеми своими ключами.
+func (fe *Frontend) MDel(keys []storage.RecordID) []error {
+	return fe.MDelContext(context.Background(), keys)
+}
+
+// MDelContext is MDel requiring as many replicas of every key as
+// storage.ConsistencyOf(ctx) requires to delete it. Requests to the
+// replicas are canceled once ctx is done.
+//
+// MDelContext -- MDel, требующий, чтобы каждый ключ удалило столько
+// реплик, сколько требует storage.ConsistencyOf(ctx). Запросы к репликам
+// отменяются, когда ctx завершен.
+func (fe *Frontend) MDelContext(ctx context.Context, keys []storage.RecordID) []error {
+	start := fe.conf.Clock.Now()
+	defer func() {
+		fe.conf.Sink.ObserveDuration("frontend.batch_del", fe.conf.Clock.Now().Sub(start))
+	}()
+
+	errs := make([]error, len(keys))
+	if err := fe.startBatch(errs); err != nil {
+		return errs
+	}
+	var batch []int
+	for i, k := range keys {
+		if errs[i] = fe.checkKey(k); errs[i] == nil {
+			batch = append(batch, i)
+		}
+	}
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	fe.applyBatch(ctx, keys, batch, errs, func(node storage.ServiceAddr, indices []int) ([]error, error) {
+		nodeKeys := make([]storage.RecordID, len(indices))
+		for j, i := range indices {
+			nodeKeys[j] = keys[i]
+		}
+		return nc.MDel(node, nodeKeys)
+	})
+	for _, i := range batch {
+		if errs[i] == nil {
+			fe.notify("del", keys[i], nil)
+		}
+	}
+	fe.conf.Sink.IncrCounter("frontend.batch_del.keys", int64(len(keys)))
+	return errs
+}
+
+// startBatch admits a batch of len(errs) keys and fetches the list of
+// nodes to place them with. Sets all errs to the error if it fails.
+func (fe *Frontend) startBatch(errs []error) error {
+	err := fe.allow(len(errs))
+	if err == nil {
+		err = fe.initReads()
+	}
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return err
+}
+
+// applyBatch calls method once for every node replicating keys with the
+// given indices, passing it the indices of its keys, and sets errs of the
+// keys to the result as many of their replicas as storage.ConsistencyOf(ctx)
+// requires agree on like applyPutDel does. Writes of the keys are serialized
+// with single-key ones. Returns the indices of keys every node wrote.
+func (fe *Frontend) applyBatch(ctx context.Context, keys []storage.RecordID, indices []int, errs []error, method func(node storage.ServiceAddr, indices []int) ([]error, error)) map[storage.ServiceAddr][]int {
+	required := requiredBy(ctx)
+	// Replicas are found like single-key writes find them, so nodes
+	// the router considers down are skipped.
+	replicas := make([][]storage.ServiceAddr, len(keys))
+	found := make([]error, len(keys))
+	err := fanout.Each(ctx, FindParallelism, indices, func(ctx context.Context, i int) error {
+		replicas[i], found[i] = fe.replicas(ctx, keys[i])
+		return nil
+	})
+	if err != nil {
+		for _, i := range indices {
+			errs[i] = err
+		}
+		return nil
+	}
+	byNode := make(map[storage.ServiceAddr][]int)
+	locked := make([]storage.RecordID, 0, len(indices))
+	for _, i := range indices {
+		if found[i] != nil {
+			errs[i] = found[i]
+			continue
+		}
+		nodes := replicas[i]
+		if len(nodes) < required {
+			errs[i] = storage.ErrNotEnoughDaemons
+			continue
+		}
+		for _, node := range nodes {
+			byNode[node] = append(byNode[node], i)
+		}
+		locked = append(locked, keys[i])
+	}
+	defer fe.lockKeys(locked)()
+	for _, k := range locked {
+		fe.dropReadLease(k)
+	}
+
+	nodes := make([]storage.ServiceAddr, 0, len(byNode))
+	for node := range byNode {
+		nodes = append(nodes, node)
+	}
+	results := fanout.Collect(ctx, 0, nodes, func(_ int, node storage.ServiceAddr) mwriteResult {
+		indices := byNode[node]
+		errs, err := method(node, indices)
+		return mwriteResult{node: node, indices: indices, errs: errs, err: err}
+	})
+
+	okCounts := make(map[int]int, len(indices))
+	errCounts := make(map[int]map[error]int)
+	acked := make(map[storage.ServiceAddr][]int, len(nodes))
+	for range nodes {
+		r := <-results
+		for j, i := range r.indices {
+			err := r.err
+			if err == nil {
+				err = r.errs[j]
+			}
+			if err == nil {
+				okCounts[i]++
+				acked[r.node] = append(acked[r.node], i)
+				continue
+			}
+			if errCounts[i] == nil {
+				errCounts[i] = make(map[error]int)
+			}
+			errCounts[i][err]++
+		}
+	}
+	for _, i := range indices {
+		if errs[i] == nil {
+			errs[i] = quorum(required, okCounts[i], errCounts[i])
+		}
+	}
+	return acked
+}
+
+// decide chooses the value of the record with key k from answers of its
+// replicas at the consistency level of ctx the same way GetContext does.
+func (fe *Frontend) decide(ctx context.Context, k storage.RecordID, results []getResult) ([]byte, error) {
+	if fe.erasureCoded(k) {
+		if d, ok, err := restore(results); ok {
+			return d, err
+		}
+		return fe.noShards(k, results)
+	}
+
+	req := getRequests.Get().(*getRequest)
+	req.pending = 1
+	defer req.release()
+
+	required := requiredBy(ctx)
+	var best []byte
+	bestCount := 0
+	var failed error
+	for _, result := range results {
+		if result.err != nil {
+			if req.voteErr(result.err) >= required && (required > 1 || result.err == storage.ErrRecordNotFound) {
+				return nil, result.err
+			}
+			failed = result.err
+			continue
+		}
+
+		req.replicas = append(req.replicas, result)
+		count := req.voteData(result.data)
+		if count >= required {
+			return result.data, nil
+		}
+		if count > bestCount {
+			best, bestCount = result.data, count
+		}
+	}
+
+	if required == 1 && failed != nil {
+		return nil, failed
+	}
+	return fe.noQuorum(k, req, best, bestCount)
+}
@@ -0,0 +1,146 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"node/node"
+	"router/router"
+	"storage"
+)
+
+func TestMGet(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3", "node4"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	var calls int32
+	nc := new(MockNode)
+	nc.mget = func(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+		atomic.AddInt32(&calls, 1)
+		if node == "node4" {
+			return nil, nil, errors.New("unreachable")
+		}
+		data := make([][]byte, len(keys))
+		errs := make([]error, len(keys))
+		for i, k := range keys {
+			if k == 100 {
+				errs[i] = storage.ErrRecordNotFound
+			} else {
+				data[i] = []byte(fmt.Sprint(k))
+			}
+		}
+		return data, errs, nil
+	}
+
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+	keys := make([]storage.RecordID, 0, 101)
+	for k := storage.RecordID(1); k <= 100; k++ {
+		keys = append(keys, k)
+	}
+	data, errs := fe.MGet(keys)
+	for i, k := range keys[:99] {
+		if errs[i] != nil || string(data[i]) != fmt.Sprint(k) {
+			t.Errorf("MGet() of key %v got %q, %v, want %q", k, data[i], errs[i], fmt.Sprint(k))
+		}
+	}
+	if errs[99] != storage.ErrRecordNotFound {
+		t.Errorf("MGet() of missing key got error %v, want %v", errs[99], storage.ErrRecordNotFound)
+	}
+	if calls != int32(len(nodes)) {
+		t.Errorf("MGet() made %v MGet requests, want one per node", calls)
+	}
+}
+
+// batchClient counts batch requests to in-memory nodes.
+type batchClient struct {
+	*nodesClient
+	calls int32
+}
+
+func (c *batchClient) MPut(addr storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.nodesClient.MPut(addr, keys, data)
+}
+
+func (c *batchClient) MDel(addr storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.nodesClient.MDel(addr, keys)
+}
+
+func TestMPutMDel(t *testing.T) {
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3", "node4"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return addrs, nil
+	}
+	nf := router.NewNodesFinder(router.NewMD5Hasher())
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nf.NodesFind(k, addrs), nil
+	}
+	nc := &batchClient{nodesClient: &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+
+	var keys []storage.RecordID
+	var data [][]byte
+	for k := storage.RecordID(1); k <= 50; k++ {
+		keys = append(keys, k)
+		data = append(data, []byte(fmt.Sprint(k)))
+	}
+	if errs := fe.MPut(keys[49:], data[49:]); errs[0] != nil {
+		t.Fatalf("MPut() error: %v", errs[0])
+	}
+	nc.calls = 0
+	for i, err := range fe.MPut(keys, data) {
+		switch {
+		case keys[i] == 50 && err != storage.ErrRecordExists:
+			t.Errorf("MPut() of an existing key got error %v, want %v", err, storage.ErrRecordExists)
+		case keys[i] != 50 && err != nil:
+			t.Errorf("MPut() of key %v got error %v", keys[i], err)
+		}
+	}
+	if nc.calls != int32(len(addrs)) {
+		t.Errorf("MPut() made %v MPut requests, want one per node", nc.calls)
+	}
+	keys = []storage.RecordID{1, 25, 49, 51}
+	data, errs := fe.MGet(keys)
+	for i, k := range keys[:3] {
+		if errs[i] != nil || string(data[i]) != fmt.Sprint(k) {
+			t.Errorf("MGet() of key %v got %q, %v, want %q", k, data[i], errs[i], fmt.Sprint(k))
+		}
+	}
+
+	nc.calls = 0
+	errs = fe.MDel(keys)
+	for i, k := range keys[:3] {
+		if errs[i] != nil {
+			t.Errorf("MDel() of key %v got error %v", k, errs[i])
+		}
+	}
+	if errs[3] != storage.ErrRecordNotFound {
+		t.Errorf("MDel() of a missing key got error %v, want %v", errs[3], storage.ErrRecordNotFound)
+	}
+	if nc.calls > int32(len(addrs)) {
+		t.Errorf("MDel() made %v MDel requests, want at most one per node", nc.calls)
+	}
+	if _, errs := fe.MGet([]storage.RecordID{25}); errs[0] != storage.ErrRecordNotFound {
+		t.Errorf("MGet() after MDel() got error %v, want %v", errs[0], storage.ErrRecordNotFound)
+	}
+
+	// Replicas the router considers down are skipped like by Put.
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nf.NodesFind(k, addrs)[:2], nil
+	}
+	all := storage.WithConsistency(context.Background(), storage.ConsistencyAll)
+	if errs := fe.MPutContext(all, keys[:1], data[:1]); errs[0] != storage.ErrNotEnoughDaemons {
+		t.Errorf("MPutContext() with a replica down got error %v, want %v", errs[0], storage.ErrNotEnoughDaemons)
+	}
+	if errs := fe.MPut(keys[:1], data[:1]); errs[0] != nil {
+		t.Errorf("MPut() with a replica down got error %v", errs[0])
+	}
+}
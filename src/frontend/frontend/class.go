@@ -0,0 +1,91 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+
+	"storage"
+	"storage/fanout"
+)
+
+// putShards writes shards of d to the replicas of the record with key k
+// with write, e.g. storage.Client.Put, one per replica, as
+// storage.ClassErasureCoded values are stored. Any storage.MinRedundancy
+// stored shards restore d, so the write succeeds once they are acknowledged.
+func (fe *Frontend) putShards(ctx context.Context, k storage.RecordID, d []byte, write writeFunc) error {
+	shards := storage.EncodeShards(d)
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	return fe.applyPutDelAt(ctx, k, func(i int, node storage.ServiceAddr) error {
+		return write(nc, node, k, shards[i%storage.Shards])
+	})
+}
+
+// getShards gets shards of the record with key k from its replicas and
+// restores its value as soon as enough of them are returned.
+func (fe *Frontend) getShards(ctx context.Context, k storage.RecordID) ([]byte, error) {
+	nodes := fe.conf.NF.NodesFind(k, fe.nodes())
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	results := fanout.Collect(ctx, 0, nodes, func(_ int, node storage.ServiceAddr) getResult {
+		data, err := nc.Get(node, k)
+		return getResult{node: node, data: data, err: err}
+	})
+
+	replies := make([]getResult, 0, len(nodes))
+	for range nodes {
+		select {
+		case r := <-results:
+			replies = append(replies, r)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if d, ok, err := restore(replies); ok {
+			return d, err
+		}
+	}
+	return fe.noShards(k, replies)
+}
+
+// restore restores the value of an erasure-coded record from replies of its
+// replicas. Returns false if they are not enough to decide yet.
+func restore(replies []getResult) ([]byte, bool, error) {
+	shards := make([][]byte, 0, len(replies))
+	var errs []error
+	for _, r := range replies {
+		if r.err == nil {
+			shards = append(shards, r.data)
+			continue
+		}
+		n := 1
+		for _, err := range errs {
+			if err == r.err {
+				n++
+			}
+		}
+		if n >= storage.MinRedundancy {
+			return nil, true, r.err
+		}
+		errs = append(errs, r.err)
+	}
+	if len(shards) < storage.MinRedundancy {
+		return nil, false, nil
+	}
+	d, err := storage.DecodeShards(shards)
+	return d, err == nil, err
+}
+
+// noShards returns the error of a read of the record with key k whose
+// replies don't restore its value.
+func (fe *Frontend) noShards(k storage.RecordID, replies []getResult) ([]byte, error) {
+	if _, _, err := restore(replies); errors.Is(err, storage.ErrInvalidShard) {
+		fe.conf.Sink.IncrCounter("frontend.shards.invalid", 1)
+		fe.conf.Logger.Printf("Failed to restore erasure-coded key %v: %v", k, err)
+		return nil, err
+	}
+	return nil, storage.ErrQuorumNotReached
+}
+
+// erasureCoded reports whether k belongs to a storage.ClassErasureCoded
+// namespace.
+func (fe *Frontend) erasureCoded(k storage.RecordID) bool {
+	return fe.conf.Namespaces.Class(k) == storage.ClassErasureCoded
+}
@@ -0,0 +1,99 @@
+package frontend
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"storage"
+)
+
+func TestErasureCoded(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc := &MockRouter{
+		nodesFind: func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+			return nodes, nil
+		},
+		list: func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+			return nodes, nil
+		},
+	}
+	var lock sync.Mutex
+	stored := make(map[storage.ServiceAddr]map[storage.RecordID][]byte)
+	down := storage.ServiceAddr("")
+	nc := new(MockNode)
+	nc.put = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if stored[node] == nil {
+			stored[node] = make(map[storage.RecordID][]byte)
+		}
+		stored[node][k] = d
+		return nil
+	}
+	nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if node == down {
+			return nil, errors.New("unreachable")
+		}
+		d, ok := stored[node][k]
+		if !ok {
+			return nil, storage.ErrRecordNotFound
+		}
+		return d, nil
+	}
+	nc.mget = func(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+		data := make([][]byte, len(keys))
+		errs := make([]error, len(keys))
+		for i, k := range keys {
+			data[i], errs[i] = nc.get(node, k)
+		}
+		return data, errs, nil
+	}
+
+	ec := storage.RecordID(0x80000001)
+	fe := New(Config{RC: rc, NC: nc, Router: "router", Namespaces: storage.Namespaces{
+		{Prefix: storage.Prefix{Key: ec, Len: 1}, Class: storage.ClassErasureCoded},
+	}})
+	value := bytes.Repeat([]byte("erasure-coded value "), 4)
+	if err := fe.Put(ec, value); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := fe.Put(1, value); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	for _, node := range nodes {
+		if len(stored[node][ec]) >= len(value) {
+			t.Errorf("Node %v stores %d bytes of erasure-coded value, want less than %d", node, len(stored[node][ec]), len(value))
+		}
+		if string(stored[node][1]) != string(value) {
+			t.Errorf("Node %v stores %q of replicated value, want %q", node, stored[node][1], value)
+		}
+	}
+
+	for _, node := range append(nodes, "") {
+		lock.Lock()
+		down = node
+		lock.Unlock()
+		if d, err := fe.Get(ec); err != nil || string(d) != string(value) {
+			t.Errorf("Get() with %q down got %q, %v, want %q", node, d, err, value)
+		}
+		data, errs := fe.MGet([]storage.RecordID{ec, 1})
+		if errs[0] != nil || string(data[0]) != string(value) || errs[1] != nil || string(data[1]) != string(value) {
+			t.Errorf("MGet() with %q down got %q, %v", node, data, errs)
+		}
+	}
+	if _, err := fe.Get(ec + 1); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() of missing key got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	lock.Lock()
+	stored["node1"][ec] = []byte("garbage")
+	down = "node2"
+	lock.Unlock()
+	if _, err := fe.Get(ec); err != storage.ErrQuorumNotReached {
+		t.Errorf("Get() with a single valid shard got error %v, want %v", err, storage.ErrQuorumNotReached)
+	}
+}
@@ -0,0 +1,51 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+
+	"ratelimit"
+	"storage"
+)
+
+// nodeLimiter returns the adaptive limiter of concurrent requests to node.
+func (fe *Frontend) nodeLimiter(node storage.ServiceAddr) *ratelimit.Adaptive {
+	fe.nodeLimLock.Lock()
+	defer fe.nodeLimLock.Unlock()
+	a, ok := fe.nodeLimiters[node]
+	if !ok {
+		a = ratelimit.NewAdaptive(fe.conf.Concurrency)
+		fe.nodeLimiters[node] = a
+	}
+	return a
+}
+
+// limitConcurrency makes a request to node with call within
+// cfg.Concurrency adapting the limit to its latency and result.
+func (fe *Frontend) limitConcurrency(node storage.ServiceAddr, call func() error) error {
+	a := fe.nodeLimiter(node)
+	if !a.Acquire() {
+		fe.conf.Sink.IncrCounter("frontend.concurrency.rejected", 1)
+		return fmt.Errorf("%w: node %v is at concurrency limit %v", storage.ErrRateLimited, node, a.Limit())
+	}
+	start := fe.conf.Clock.Now()
+	err := call()
+	a.Release(fe.conf.Clock.Now().Sub(start), errors.Is(err, storage.ErrRateLimited))
+	return err
+}
+
+// ConcurrencyLimits returns the current limits of concurrent requests to
+// nodes requested so far, it is empty if cfg.Concurrency is not enabled.
+//
+// ConcurrencyLimits возвращает текущие ограничения параллельных запросов
+// к node, к которым уже были запросы, он пуст, если cfg.Concurrency
+// не включен.
+func (fe *Frontend) ConcurrencyLimits() map[storage.ServiceAddr]int {
+	fe.nodeLimLock.Lock()
+	defer fe.nodeLimLock.Unlock()
+	limits := make(map[storage.ServiceAddr]int, len(fe.nodeLimiters))
+	for node, a := range fe.nodeLimiters {
+		limits[node] = a.Limit()
+	}
+	return limits
+}
@@ -0,0 +1,218 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+func TestConsistency(t *testing.T) {
+	key := storage.RecordID(1)
+	testData := []byte("test")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	dummyError := errors.New("dummy error")
+
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	// Replicas of earlier subtests may still be called, so each of them
+	// gets its own node client.
+	newFrontend := func(nc *MockNode) *Frontend {
+		c := cfg
+		c.NC = nc
+		c.NF = router.NewNodesFinder(FakeHasher{
+			t:      t,
+			hashes: map[storage.ServiceAddr]uint64{nodes[0]: 1, nodes[1]: 2, nodes[2]: 3},
+		})
+		return New(c)
+	}
+	one := storage.WithConsistency(context.Background(), storage.ConsistencyOne)
+	all := storage.WithConsistency(context.Background(), storage.ConsistencyAll)
+
+	t.Run("get_one", func(t *testing.T) {
+		// Only the first replica answers, the rest wait for the test to end.
+		done := make(chan struct{})
+		defer close(done)
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+			if node != nodes[0] {
+				<-done
+				return nil, dummyError
+			}
+			return testData, nil
+		}
+		got, err := fe.GetContext(one, key)
+		if err != nil {
+			t.Fatalf("GetContext() got error %v", err)
+		}
+		if !reflect.DeepEqual(got, testData) {
+			t.Errorf("GetContext() got %s, want %s", got, testData)
+		}
+	})
+
+	t.Run("get_one_failed", func(t *testing.T) {
+		// The first replica fails at once, the rest answer after it.
+		failed := make(chan struct{})
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+			if node == nodes[0] {
+				close(failed)
+				return nil, dummyError
+			}
+			<-failed
+			return testData, nil
+		}
+		got, err := fe.GetContext(one, key)
+		if err != nil {
+			t.Fatalf("GetContext() got error %v", err)
+		}
+		if !reflect.DeepEqual(got, testData) {
+			t.Errorf("GetContext() got %s, want %s", got, testData)
+		}
+
+		nc = new(MockNode)
+		fe = newFrontend(nc)
+		nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+			return nil, dummyError
+		}
+		if _, err := fe.GetContext(one, key); err != dummyError {
+			t.Errorf("GetContext() with all replicas failed got error %v, want %v", err, dummyError)
+		}
+	})
+
+	t.Run("get_all", func(t *testing.T) {
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+			if node == nodes[2] {
+				return []byte("stale"), nil
+			}
+			return testData, nil
+		}
+		if _, err := fe.Get(key); err != nil {
+			t.Errorf("Get() got error %v", err)
+		}
+		if _, err := fe.GetContext(all, key); err != storage.ErrQuorumNotReached {
+			t.Errorf("GetContext() got error %v, want %v", err, storage.ErrQuorumNotReached)
+		}
+	})
+
+	for _, test := range []struct {
+		name   string
+		ctx    context.Context
+		failed int
+		err    error
+	}{
+		{name: "put_one", ctx: one, failed: 2},
+		{name: "put_quorum", ctx: context.Background(), failed: 2, err: storage.ErrQuorumNotReached},
+		{name: "put_all", ctx: all, failed: 1, err: storage.ErrQuorumNotReached},
+		{name: "put_all_ok", ctx: all},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			nc := new(MockNode)
+			fe := newFrontend(nc)
+			nc.put = put(t, nodes, key, testData, func(node storage.ServiceAddr) error {
+				for _, n := range nodes[:test.failed] {
+					if n == node {
+						return errors.New("failed")
+					}
+				}
+				return nil
+			})
+			// Failed puts are rolled back on the replicas storing testData.
+			nc.del = del(t, nodes[test.failed:], key, nil)
+			if err := fe.PutContext(test.ctx, key, testData); err != test.err {
+				t.Errorf("PutContext() got error %v, want %v", err, test.err)
+			}
+		})
+	}
+
+	t.Run("put_all_node_down", func(t *testing.T) {
+		// Router finds only the live replicas, which are not all of them.
+		rc.nodesFind = nodesFind(t, cfg, key, nodes[:2], nil)
+		defer func() { rc.nodesFind = nodesFind(t, cfg, key, nodes, nil) }()
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.put = put(t, nodes[:2], key, testData, nil)
+		if err := fe.PutContext(all, key, testData); err != storage.ErrNotEnoughDaemons {
+			t.Errorf("PutContext() got error %v, want %v", err, storage.ErrNotEnoughDaemons)
+		}
+		if err := fe.Put(key, testData); err != nil {
+			t.Errorf("Put() got error %v", err)
+		}
+	})
+
+	t.Run("mget", func(t *testing.T) {
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.mget = func(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+			switch node {
+			case nodes[0]:
+				return nil, nil, dummyError
+			case nodes[1]:
+				return [][]byte{[]byte("stale")}, []error{nil}, nil
+			}
+			return [][]byte{testData}, []error{nil}, nil
+		}
+		keys := []storage.RecordID{key}
+		if data, errs := fe.MGetContext(one, keys); errs[0] != nil || len(data[0]) == 0 {
+			t.Errorf("MGetContext() got %q, %v", data[0], errs[0])
+		}
+		if _, errs := fe.MGet(keys); errs[0] != storage.ErrQuorumNotReached {
+			t.Errorf("MGet() got error %v, want %v", errs[0], storage.ErrQuorumNotReached)
+		}
+		if _, errs := fe.MGetContext(all, keys); errs[0] != storage.ErrQuorumNotReached {
+			t.Errorf("MGetContext() got error %v, want %v", errs[0], storage.ErrQuorumNotReached)
+		}
+	})
+
+	t.Run("upsert_all", func(t *testing.T) {
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.upsert = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+			if node == nodes[0] {
+				return dummyError
+			}
+			return nil
+		}
+		if err := fe.UpsertContext(all, key, testData); err != storage.ErrQuorumNotReached {
+			t.Errorf("UpsertContext() got error %v, want %v", err, storage.ErrQuorumNotReached)
+		}
+		if err := fe.Upsert(key, testData); err != nil {
+			t.Errorf("Upsert() got error %v", err)
+		}
+		if err := fe.UpsertContext(one, key, testData); err != nil {
+			t.Errorf("UpsertContext() got error %v", err)
+		}
+	})
+
+	t.Run("mput_all", func(t *testing.T) {
+		nc := new(MockNode)
+		fe := newFrontend(nc)
+		nc.mput = func(node storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+			if node == nodes[0] {
+				return nil, dummyError
+			}
+			return make([]error, len(keys)), nil
+		}
+		nc.mdel = func(node storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+			return make([]error, len(keys)), nil
+		}
+		if errs := fe.MPutContext(all, []storage.RecordID{key}, [][]byte{testData}); errs[0] != storage.ErrQuorumNotReached {
+			t.Errorf("MPutContext() got error %v, want %v", errs[0], storage.ErrQuorumNotReached)
+		}
+		if errs := fe.MPut([]storage.RecordID{key}, [][]byte{testData}); errs[0] != nil {
+			t.Errorf("MPut() got error %v", errs[0])
+		}
+		if errs := fe.MDelContext(all, []storage.RecordID{key}); errs[0] != nil {
+			t.Errorf("MDelContext() got error %v", errs[0])
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+
+	"storage"
+	"storage/crdt"
+)
+
+// Merge merges the CRDT value d into the replicas of the record with key
+// k, see crdt.Merge, so concurrent merges are never lost. Succeeds once
+// storage.MinRedundancy replicas merge d. Records of erasure coded
+// namespaces can't be merged.
+//
+// Merge -- объединить CRDT значение d с репликами записи с ключом k, см.
+// crdt.Merge, так что одновременные объединения не теряются. Завершается
+// успешно, когда d объединено на storage.MinRedundancy репликах. Записи
+// пространств ключей с erasure coding не могут быть объединены.
+func (fe *Frontend) Merge(k storage.RecordID, d []byte) error {
+	if _, err := crdt.Unmarshal(d); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrInvalidValue, err)
+	}
+	if fe.erasureCoded(k) {
+		return fmt.Errorf("%w: erasure coded records can't be merged", storage.ErrInvalidValue)
+	}
+	return fe.replace(context.Background(), "merge", k, d, storage.Client.Merge)
+}
+
+// mergeValue merges the CRDT value v into the record with key k.
+func (fe *Frontend) mergeValue(k storage.RecordID, v crdt.Value) error {
+	d, err := crdt.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return fe.Merge(k, d)
+}
+
+// AddToSet adds elem to the crdt.ORSet stored as the record with key k,
+// creating it if there is no such record.
+//
+// AddToSet добавляет elem в crdt.ORSet, хранящееся в записи с ключом k,
+// создавая его, если такой записи нет.
+func (fe *Frontend) AddToSet(k storage.RecordID, elem string) error {
+	delta := crdt.NewORSet()
+	delta.Add(elem)
+	return fe.mergeValue(k, delta)
+}
+
+// RemoveFromSet removes elem from the crdt.ORSet stored as the record with
+// key k. Only the additions of elem read from the replicas are removed,
+// so concurrent ones win. Removing an element which is not in the set
+// does nothing.
+//
+// RemoveFromSet удаляет elem из crdt.ORSet, хранящегося в записи с ключом
+// k. Удаляются только прочитанные с реплик добавления elem, поэтому
+// одновременные добавления побеждают. Удаление отсутствующего элемента
+// ничего не делает.
+func (fe *Frontend) RemoveFromSet(k storage.RecordID, elem string) error {
+	d, err := fe.Get(k)
+	if err == storage.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	v, err := crdt.Unmarshal(d)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrInvalidValue, err)
+	}
+	set, ok := v.(*crdt.ORSet)
+	if !ok {
+		return fmt.Errorf("%w: %v", storage.ErrInvalidValue, crdt.ErrTypeMismatch)
+	}
+	if len(set.Adds[elem]) == 0 {
+		return nil
+	}
+	delta := crdt.NewORSet()
+	for tag := range set.Adds[elem] {
+		delta.Removes[tag] = true
+	}
+	return fe.mergeValue(k, delta)
+}
+
+// MergeMap sets entries of the crdt.LWWMap stored as the record with key
+// k, creating it if there is no such record. Entries with nil values are
+// deleted. Concurrent writes of an entry are resolved by the time of
+// the frontends.
+//
+// MergeMap задает элементы crdt.LWWMap, хранящегося в записи с ключом k,
+// создавая его, если такой записи нет. Элементы со значением nil
+// удаляются. Одновременные записи элемента разрешаются по времени
+// frontend.
+func (fe *Frontend) MergeMap(k storage.RecordID, entries map[string][]byte) error {
+	delta := crdt.NewLWWMap()
+	ts := fe.conf.Clock.Now().UnixNano()
+	for key, v := range entries {
+		delta.Set(key, v, ts, string(fe.conf.Addr))
+	}
+	return fe.mergeValue(k, delta)
+}
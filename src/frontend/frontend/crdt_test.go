@@ -0,0 +1,102 @@
+package frontend
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"node/node"
+	"storage"
+	"storage/crdt"
+)
+
+func TestCRDT(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+
+	fe1 := New(Config{Addr: "fe1", RC: &rc, NC: nc, Router: cfg.Router})
+	fe2 := New(Config{Addr: "fe2", RC: &rc, NC: nc, Router: cfg.Router})
+
+	// Concurrent additions made by different frontends are all kept.
+	var wg sync.WaitGroup
+	for i, elem := range []string{"a", "b", "c", "d"} {
+		fe := []*Frontend{fe1, fe2}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fe.AddToSet(key, elem); err != nil {
+				t.Errorf("AddToSet(%q) error: %v", elem, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := fe2.RemoveFromSet(key, "b"); err != nil {
+		t.Fatalf("RemoveFromSet() error: %v", err)
+	}
+	if err := fe1.RemoveFromSet(key, "x"); err != nil {
+		t.Fatalf("RemoveFromSet() of a missing element error: %v", err)
+	}
+
+	for _, addr := range addrs {
+		d, err := nc.nodes[addr].Get(key)
+		if err != nil {
+			t.Fatalf("Get() from %v error: %v", addr, err)
+		}
+		v, err := crdt.Unmarshal(d)
+		if err != nil {
+			t.Fatalf("Unmarshal() error: %v", err)
+		}
+		elems := v.(*crdt.ORSet).Elems()
+		sort.Strings(elems)
+		if want := []string{"a", "c", "d"}; !reflect.DeepEqual(elems, want) {
+			t.Errorf("Wrong set at %v: got %v, want %v", addr, elems, want)
+		}
+	}
+
+	if err := fe1.MergeMap(key, map[string][]byte{"x": []byte("x")}); !errors.Is(err, storage.ErrInvalidValue) {
+		t.Errorf("MergeMap() into a set got error %v, want %v", err, storage.ErrInvalidValue)
+	}
+	if err := fe1.Merge(key, []byte("garbage")); !errors.Is(err, storage.ErrInvalidValue) {
+		t.Errorf("Merge() of garbage got error %v, want %v", err, storage.ErrInvalidValue)
+	}
+}
+
+func TestMergeMap(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+	fe := New(Config{Addr: "fe1", RC: &rc, NC: nc, Router: cfg.Router})
+
+	if err := fe.MergeMap(key, map[string][]byte{"x": []byte("1"), "y": []byte("1")}); err != nil {
+		t.Fatalf("MergeMap() error: %v", err)
+	}
+	if err := fe.MergeMap(key, map[string][]byte{"y": nil, "z": []byte("2")}); err != nil {
+		t.Fatalf("MergeMap() error: %v", err)
+	}
+
+	d, err := fe.Get(key)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	v, err := crdt.Unmarshal(d)
+	if err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	m := v.(*crdt.LWWMap)
+	keys := m.Keys()
+	sort.Strings(keys)
+	if want := []string{"x", "z"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Wrong map keys: got %v, want %v", keys, want)
+	}
+}
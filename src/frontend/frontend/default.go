@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"fmt"
+	"os"
+
+	"metrics"
+	rclient "router/client"
+	"router/router"
+	"storage"
+)
+
+// NewDefault creates a new Frontend with a given cfg modified by opts wiring
+// the shipped gRPC clients, shedding load from nodes hinting to retry later,
+// the NodesFinder built from cfg.Placement, the
+// metrics sink described by cfg.Metrics and the recorder writing to
+// cfg.RecordFile for the fields which are not set. Returns an error if cfg is
+// invalid.
+//
+// NewDefault создает новый Frontend с данным cfg, измененной opts, подставляя
+// в незаданные поля поставляемые gRPC клиенты, снимающие нагрузку с node,
+// предлагающих повторить запрос позже, NodesFinder, построенный по
+// cfg.Placement, приемник метрик, описанный cfg.Metrics, и Recorder, пишущий
+// в cfg.RecordFile. Возвращает ошибку, если cfg некорректна.
+func NewDefault(cfg Config, opts ...Option) (*Frontend, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	resolver, err := storage.NewResolver(cfg.Resolve)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create resolver: %v", err)
+	}
+	if cfg.NC == nil {
+		signer := cfg.Signer
+		if signer == nil {
+			if signer, err = storage.NewSigner(cfg.Signing, cfg.Clock); err != nil {
+				return nil, err
+			}
+		}
+		cfg.NC = storage.NewBackoffClient(storage.NewSigningClient(resolver, signer), 0)
+	}
+	if cfg.RC == nil {
+		cfg.RC = rclient.NewWithResolver(resolver)
+	}
+
+	if cfg.NF.IsZero() {
+		cfg.NF, err = router.NewNodesFinderWithPlacement(router.NewMD5Hasher(), cfg.Placement)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create nodes finder: %v", err)
+		}
+	}
+
+	if cfg.Sink == nil {
+		cfg.Sink, err = metrics.Serve(cfg.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to set up metrics: %v", err)
+		}
+	}
+
+	if cfg.Recorder == nil && cfg.RecordFile != "" {
+		f, err := os.OpenFile(cfg.RecordFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open record file: %v", err)
+		}
+		cfg.Recorder = NewRecorder(f)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return New(cfg), nil
+}
@@ -0,0 +1,97 @@
+package frontend
+
+import (
+	"context"
+	"sync"
+
+	"storage"
+	"storage/fanout"
+)
+
+// DelParallelism is a default number of concurrent Del requests made by DelPrefix.
+//
+// DelParallelism -- количество одновременных запросов Del в DelPrefix по умолчанию.
+const DelParallelism = 16
+
+// DelPrefix deletes all records with keys having the given prefix.
+// Keys are enumerated with Scan and deleted with not more than
+// cfg.DelParallelism concurrent Del calls. If progress is not nil, it is
+// called with the number of deleted records after every deleted page.
+// Returns the number of deleted records and the first error occurred.
+//
+// DelPrefix удаляет все записи с ключами, имеющими данный префикс.
+// Ключи перечисляются с помощью Scan и удаляются не более чем
+// cfg.DelParallelism одновременными вызовами Del. Если progress не nil,
+// он вызывается с количеством удаленных записей после каждой страницы.
+// Возвращает количество удаленных записей и первую возникшую ошибку.
+func (fe *Frontend) DelPrefix(prefix storage.Prefix, progress func(deleted int)) (int, error) {
+	return fe.eachPrefix(prefix, progress, func(k storage.RecordID) (func(), error) {
+		if err := fe.Del(k); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	})
+}
+
+// eachPrefix applies apply to keys having the prefix enumerated with Scan
+// in not more than cfg.DelParallelism concurrent calls. If progress is not
+// nil, it is called with the number of counted keys after every page.
+// A key is counted if apply returns a function, which is then called
+// under a lock, so it may collect results. Keys apply fails for with
+// storage.ErrRecordNotFound are skipped, as they are gone since scanned.
+// Once apply fails, the keys it isn't called for yet are left. Returns
+// the number of counted keys and the first error occurred.
+func (fe *Frontend) eachPrefix(prefix storage.Prefix, progress func(n int), apply func(k storage.RecordID) (func(), error)) (int, error) {
+	parallelism := fe.conf.DelParallelism
+	if parallelism <= 0 {
+		parallelism = DelParallelism
+	}
+
+	var cursor storage.Cursor
+	if first := prefix.First(); first > 0 {
+		cursor = storage.CursorAfter(first - 1)
+	}
+
+	counted := 0
+	for {
+		records, next, err := fe.Scan(cursor, storage.ScanLimit)
+		if err != nil {
+			return counted, err
+		}
+
+		var keys []storage.RecordID
+		done := false
+		for _, r := range records {
+			if r.Key > prefix.Last() {
+				done = true
+				break
+			}
+			keys = append(keys, r.Key)
+		}
+		var lock sync.Mutex
+		err = fanout.Each(context.Background(), parallelism, keys, func(_ context.Context, k storage.RecordID) error {
+			count, err := apply(k)
+			if err == storage.ErrRecordNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			count()
+			counted++
+			return nil
+		})
+		if progress != nil {
+			progress(counted)
+		}
+		if err != nil {
+			return counted, err
+		}
+		if done || next == nil {
+			return counted, nil
+		}
+		cursor = next
+	}
+}
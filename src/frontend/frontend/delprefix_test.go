@@ -0,0 +1,153 @@
+package frontend
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+func TestDelPrefix(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	prefix := storage.Prefix{Key: 0x12345678, Len: 8}
+	keys := []storage.RecordID{0x11ffffff, 0x12000000, 0x12000001, 0x12abcdef, 0x12ffffff, 0x13000000}
+
+	var lock sync.Mutex
+	stored := make(map[storage.ServiceAddr]map[storage.RecordID]bool)
+	for _, node := range nodes {
+		stored[node] = make(map[storage.RecordID]bool)
+		for _, k := range keys {
+			stored[node][k] = true
+		}
+	}
+
+	nc := new(MockNode)
+	nc.scan = func(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+		lock.Lock()
+		var records []storage.Record
+		for k := range stored[node] {
+			records = append(records, storage.Record{Key: k})
+		}
+		lock.Unlock()
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Key < records[j].Key
+		})
+		return scanRecords(records, cursor, 2)
+	}
+	nc.del = func(node storage.ServiceAddr, k storage.RecordID) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if !prefix.Match(k) {
+			t.Errorf("Del() of key %x not matching prefix", k)
+		}
+		if !stored[node][k] {
+			return storage.ErrRecordNotFound
+		}
+		delete(stored[node], k)
+		return nil
+	}
+
+	fe := New(Config{
+		RC:             &rc,
+		NC:             nc,
+		NF:             router.NewNodesFinder(router.NewMD5Hasher()),
+		Router:         "router",
+		DelParallelism: 2,
+	})
+
+	reported := 0
+	deleted, err := fe.DelPrefix(prefix, func(n int) {
+		reported = n
+	})
+	if err != nil {
+		t.Fatalf("DelPrefix() error: %v", err)
+	}
+	if deleted != 4 || reported != 4 {
+		t.Errorf("DelPrefix() deleted %d records, reported %d, want %d", deleted, reported, 4)
+	}
+	for _, node := range nodes {
+		if len(stored[node]) != 2 || !stored[node][keys[0]] || !stored[node][keys[5]] {
+			t.Errorf("Wrong records left on %v: %v", node, stored[node])
+		}
+	}
+}
+
+func TestDelPrefixDryRun(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	prefix := storage.Prefix{Key: 0x12345678, Len: 8}
+	keys := []storage.RecordID{0x11ffffff, 0x12000000, 0x12abcdef, 0x13000000}
+	stored := make(map[storage.ServiceAddr]map[storage.RecordID]bool)
+	for _, node := range nodes {
+		stored[node] = make(map[storage.RecordID]bool)
+		for _, k := range keys {
+			stored[node][k] = true
+		}
+	}
+	delete(stored["node2"], keys[2])
+
+	nc := new(MockNode)
+	nc.scan = func(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+		var records []storage.Record
+		for k := range stored[node] {
+			records = append(records, storage.Record{Key: k})
+		}
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Key < records[j].Key
+		})
+		return scanRecords(records, cursor, 2)
+	}
+	nc.checksum = func(node storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+		if !stored[node][k] {
+			return storage.Checksum{}, storage.ErrRecordNotFound
+		}
+		return storage.Checksum{}, nil
+	}
+	nc.del = func(node storage.ServiceAddr, k storage.RecordID) error {
+		t.Errorf("Del() of key %x in dry run", k)
+		return nil
+	}
+
+	fe := New(Config{
+		RC:             &rc,
+		NC:             nc,
+		NF:             router.NewNodesFinder(router.NewMD5Hasher()),
+		Router:         "router",
+		DelParallelism: 2,
+	})
+
+	reported := 0
+	affected, err := fe.DelPrefixDryRun(prefix, func(n int) {
+		reported = n
+	})
+	if err != nil {
+		t.Fatalf("DelPrefixDryRun() error: %v", err)
+	}
+	want := []Affected{
+		{Key: keys[1], Nodes: nodes},
+		{Key: keys[2], Nodes: []storage.ServiceAddr{"node1", "node3"}},
+	}
+	if !reflect.DeepEqual(affected, want) || reported != len(want) {
+		t.Errorf("DelPrefixDryRun() = %v, reported %d, want %v", affected, reported, want)
+	}
+
+	if _, err := fe.DelDryRun(0x12000001); err != storage.ErrRecordNotFound {
+		t.Errorf("DelDryRun() of missing record error: got %v, want %v", err, storage.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,103 @@
+package frontend
+
+import (
+	"context"
+	"sort"
+
+	"storage"
+)
+
+// Affected describes a record a destructive operation would change and
+// the replicas holding it, which the change would be made on.
+//
+// Affected описывает запись, которую изменила бы разрушающая операция,
+// и хранящие ее реплики, на которых было бы сделано изменение.
+type Affected struct {
+	// Key is a key of the record.
+	// Key -- ключ записи.
+	Key storage.RecordID
+	// Nodes are replicas holding the record sorted by addresses.
+	// Nodes -- реплики, хранящие запись, упорядоченные по адресам.
+	Nodes []storage.ServiceAddr
+}
+
+// DelDryRun reports the replicas Del of the record with key k would delete
+// it from without deleting anything. Returns the storage.ErrRecordNotFound
+// error if no replica holds the record and the first error of a replica
+// if it can't tell whether it holds the record.
+//
+// DelDryRun сообщает, с каких реплик Del удалил бы запись с ключом k,
+// ничего не удаляя. Возвращает ошибку storage.ErrRecordNotFound, если
+// ни одна реплика не хранит запись, и первую ошибку реплики, если она
+// не может сообщить, хранит ли запись.
+func (fe *Frontend) DelDryRun(k storage.RecordID) (Affected, error) {
+	if err := fe.checkKey(k); err != nil {
+		return Affected{}, err
+	}
+	nodes, err := fe.replicas(context.Background(), k)
+	if err != nil {
+		return Affected{}, err
+	}
+	if len(nodes) < storage.MinRedundancy {
+		return Affected{}, storage.ErrNotEnoughDaemons
+	}
+
+	type result struct {
+		node storage.ServiceAddr
+		err  error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			_, err := fe.conf.NC.Checksum(node, k, storage.HashCRC64)
+			results <- result{node: node, err: err}
+		}(node)
+	}
+
+	a := Affected{Key: k}
+	var firstErr error
+	for range nodes {
+		r := <-results
+		switch {
+		case r.err == nil:
+			a.Nodes = append(a.Nodes, r.node)
+		case r.err == storage.ErrRecordNotFound:
+		case firstErr == nil:
+			firstErr = r.err
+		}
+	}
+	if firstErr != nil {
+		return Affected{}, firstErr
+	}
+	if len(a.Nodes) == 0 {
+		return Affected{}, storage.ErrRecordNotFound
+	}
+	sort.Slice(a.Nodes, func(i, j int) bool {
+		return a.Nodes[i] < a.Nodes[j]
+	})
+	return a, nil
+}
+
+// DelPrefixDryRun reports the records DelPrefix with the given prefix would
+// delete and their replicas like DelDryRun without deleting anything.
+// Records are sorted by keys, progress is called like in DelPrefix.
+//
+// DelPrefixDryRun сообщает, какие записи удалил бы DelPrefix с данным
+// префиксом, и их реплики, как DelDryRun, ничего не удаляя. Записи
+// упорядочены по ключам, progress вызывается как в DelPrefix.
+func (fe *Frontend) DelPrefixDryRun(prefix storage.Prefix, progress func(affected int)) ([]Affected, error) {
+	var affected []Affected
+	_, err := fe.eachPrefix(prefix, progress, func(k storage.RecordID) (func(), error) {
+		a, err := fe.DelDryRun(k)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			affected = append(affected, a)
+		}, nil
+	})
+	sort.Slice(affected, func(i, j int) bool {
+		return affected[i].Key < affected[j].Key
+	})
+	return affected, err
+}
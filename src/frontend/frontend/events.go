@@ -0,0 +1,69 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"events"
+	"storage"
+)
+
+// Events returns the bus the frontend publishes events to, so subscribers
+// can be added to it.
+//
+// Events возвращает шину, в которую frontend публикует события, чтобы к ней
+// можно было подключить подписчиков.
+func (fe *Frontend) Events() *events.Bus {
+	return fe.conf.Bus
+}
+
+// Changes returns the bus the frontend publishes events.RecordChanged to,
+// so caches of its clients can be invalidated. It is separate from the one
+// returned by Events, so subscribers of the latter are not notified of
+// every write.
+//
+// Changes возвращает шину, в которую frontend публикует
+// events.RecordChanged, чтобы можно было сбрасывать кэши его клиентов. Она
+// отделена от возвращаемой Events, чтобы подписчики последней
+// не уведомлялись о каждой записи.
+func (fe *Frontend) Changes() *events.Bus {
+	return fe.changes
+}
+
+// publishFailure publishes events.QuorumFailed if op of the record with
+// key k failed as replicas didn't reach quorum.
+func (fe *Frontend) publishFailure(op string, k storage.RecordID, err error) {
+	if errors.Is(err, storage.ErrQuorumNotReached) {
+		fe.conf.Bus.Publish(events.Event{
+			Kind:   events.QuorumFailed,
+			Key:    events.KeyOf(k),
+			Reason: op + ": " + err.Error(),
+		})
+	}
+}
+
+// Admin returns a handler serving the admin API of fe:
+//
+//	GET /events[?kind=<kind>]  -- stream of events as JSON lines or
+//	                              server-sent events with format=sse
+//	GET /watch                 -- stream of events.RecordChanged of
+//	                              records put or deleted via fe
+//	GET /stats                 -- ClusterStats as JSON
+//
+// Admin возвращает обработчик API администратора fe.
+func Admin(fe *Frontend) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/events", events.Handler(fe.Events()))
+	mux.Handle("/watch", events.Handler(fe.Changes()))
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fe.ClusterStats())
+	})
+	return mux
+}
@@ -1,20 +1,24 @@
 package frontend
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"auth"
+	"events"
+	"metrics"
+	"ratelimit"
 	rclient "router/client"
 	"router/router"
+	"secrets"
 	"storage"
+	"storage/fanout"
 )
 
-// InitTimeout is a timeout to wait after unsuccessful List() request to Router.
-//
-// InitTimeout -- количество времени, которое нужно подождать до следующей попытки
-// отправки запроса List() в Router.
-const InitTimeout = 100 * time.Millisecond
-
 // Config stores configuration for a Frontend service.
 //
 // Config -- содержит конфигурацию Frontend.
@@ -26,55 +30,495 @@ type Config struct {
 	// Router -- адрес Router service.
 	Router storage.ServiceAddr
 
+	// Resolve specifies how addresses of nodes and Router are resolved.
+	// Resolve -- способ разрешения адресов node и Router.
+	Resolve storage.ResolverConfig
+
 	// NC specifies client for Node.
 	// NC -- клиент для node.
 	NC storage.Client `yaml:"-"`
 	// RC specifies client for Router.
 	// RC -- клиент для router.
 	RC rclient.Client `yaml:"-"`
-	// NodesFinder specifies a NodeFinder to use.
-	// NodesFinder -- NodesFinder, который нужно использовать в Frontend.
+	// NodesFinder specifies a NodeFinder to use, the one without placement
+	// constraints is used by default.
+	// NodesFinder -- NodesFinder, который нужно использовать в Frontend,
+	// по умолчанию используется NodesFinder без ограничений на размещение.
 	NF router.NodesFinder `yaml:"-"`
+
+	// Placement specifies failure domains of nodes and placement constraints.
+	// It should be the same as the one of Router.
+	// Placement -- failure domains node и ограничения на размещение реплик.
+	// Должен совпадать с Placement в Router.
+	Placement router.Placement
+
+	// DegradedReads allows Get to return the best single-replica answer
+	// with storage.ErrPossiblyStale error if quorum can't be reached.
+	// DegradedReads -- разрешает Get возвращать лучший ответ одной реплики
+	// с ошибкой storage.ErrPossiblyStale, если кворум не достигнут.
+	DegradedReads bool `yaml:"degraded_reads"`
+
+	// ParanoidReads makes Get verify every value like GetVerified does.
+	// ParanoidReads -- Get проверяет каждое значение, как GetVerified.
+	ParanoidReads bool `yaml:"paranoid_reads"`
+
+	// Fingerprint is a hash GetVerified compares values of replicas with,
+	// storage.HashSHA256 by default.
+	// Fingerprint -- хэш, с помощью которого GetVerified сравнивает значения
+	// реплик, по умолчанию storage.HashSHA256.
+	Fingerprint storage.Hash `yaml:"fingerprint"`
+
+	// RejectNullKeys makes requests with storage.NullRecordID fail with
+	// storage.ErrInvalidKey before they are sent to nodes.
+	// RejectNullKeys -- запросы с storage.NullRecordID завершаются ошибкой
+	// storage.ErrInvalidKey до отправки на node.
+	RejectNullKeys bool `yaml:"reject_null_keys"`
+
+	// MaxValueSize is a maximum size of a value in bytes accepted by Put,
+	// larger values fail with storage.ErrValueTooLarge. Zero means no limit.
+	// MaxValueSize -- максимальный размер значения в байтах, принимаемого Put,
+	// большие значения завершаются ошибкой storage.ErrValueTooLarge.
+	// Ноль означает отсутствие ограничения.
+	MaxValueSize int `yaml:"max_value_size"`
+
+	// ReservePuts makes Put reserve space for values on replicas before
+	// writing them, so quotas of nodes are not overshot by concurrent writers.
+	// ReservePuts -- Put резервирует место для значений на репликах перед
+	// записью, чтобы одновременные записи не превышали квоты node.
+	ReservePuts bool `yaml:"reserve_puts"`
+
+	// ReserveTTL is a time reservations of Put are kept for.
+	// ReserveTTL -- время, на которое сохраняются резервирования Put.
+	ReserveTTL time.Duration `yaml:"reserve_ttl"`
+
+	// InitBackoff is a delay before the first retry of unsuccessful List()
+	// request to Router during init, doubled after every next retry.
+	// InitBackoff -- задержка перед первым повтором неуспешного запроса List()
+	// в Router при инициализации, удваивается после каждого повтора.
+	InitBackoff time.Duration `yaml:"init_backoff"`
+
+	// InitMaxBackoff caps the delay between retries of List() during init.
+	// InitMaxBackoff -- максимальная задержка между повторами List()
+	// при инициализации.
+	InitMaxBackoff time.Duration `yaml:"init_max_backoff"`
+
+	// InitRetryTimeout is a time requests wait for Router during lazy init,
+	// after it they fail with storage.ErrRouterUnavailable.
+	// InitRetryTimeout -- время, которое запросы ждут Router при ленивой
+	// инициализации, после чего завершаются ошибкой storage.ErrRouterUnavailable.
+	InitRetryTimeout time.Duration `yaml:"init_retry_timeout"`
+
+	// RefreshInterval is a time between refreshes of the list of nodes
+	// from Router made by Refreshes, RefreshInterval by default.
+	// RefreshInterval -- время между обновлениями списка node от Router,
+	// выполняемыми Refreshes, по умолчанию RefreshInterval.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	// RateLimit limits the rate of Put, Del and Get requests, requests over
+	// it fail with storage.ErrRateLimited, so clients back off instead of
+	// overloading nodes. Not limited by default.
+	// RateLimit -- ограничение скорости запросов Put, Del и Get, запросы
+	// сверх него завершаются ошибкой storage.ErrRateLimited, чтобы клиенты
+	// снижали нагрузку, а не перегружали node. По умолчанию не ограничена.
+	RateLimit ratelimit.Config `yaml:"rate_limit"`
+	// Concurrency adapts a limit of concurrent requests to each node to
+	// the concurrency it sustains, requests over it fail with
+	// storage.ErrRateLimited as if the node was overloaded. Not limited
+	// by default.
+	// Concurrency -- адаптирует ограничение параллельных запросов к каждой
+	// node к выдерживаемой ей параллельности, запросы сверх него завершаются
+	// ошибкой storage.ErrRateLimited, как если бы node была перегружена.
+	// По умолчанию не ограничена.
+	Concurrency ratelimit.AdaptiveConfig `yaml:"concurrency"`
+
+	// Namespaces specifies storage classes of namespaces of keys, they
+	// should be the same as the ones of nodes. Put and Get split values
+	// of storage.ClassErasureCoded namespaces into shards and restore them,
+	// other operations see the shards as stored by nodes.
+	// Namespaces -- классы хранения пространств ключей, должны совпадать
+	// с Namespaces в node. Put и Get разбивают значения пространств класса
+	// storage.ClassErasureCoded на части и восстанавливают их, остальные
+	// операции видят части в том виде, в котором они хранятся на node.
+	Namespaces storage.Namespaces
+
+	// Validators specifies validators of values written to namespaces.
+	// Validators -- функции проверки значений, записываемых в пространства ключей.
+	Validators []Validator `yaml:"-"`
+
+	// Resolvers specifies functions to merge divergent values of records
+	// in namespaces, the one of the longest namespace having a key is used.
+	// Values of records in other namespaces are not merged.
+	// Resolvers -- функции для объединения различающихся значений записей
+	// в пространствах ключей, используется функция самого длинного
+	// пространства, содержащего ключ. Значения записей в остальных
+	// пространствах не объединяются.
+	Resolvers []NamespaceResolver `yaml:"-"`
+	// ResolveDryRun makes Get only log the replicas a value merged by
+	// Resolvers would be written back to without writing it.
+	// ResolveDryRun -- Get только сообщает в лог, на какие реплики было бы
+	// записано значение, объединенное Resolvers, не записывая его.
+	ResolveDryRun bool `yaml:"resolve_dry_run"`
+
+	// DelParallelism is a number of concurrent Del requests made by DelPrefix.
+	// DelParallelism -- количество одновременных запросов Del в DelPrefix.
+	DelParallelism int `yaml:"del_parallelism"`
+
+	// IDBatch is a number of IDs allocated by NextID at once.
+	// IDBatch -- количество ID, выделяемых NextID за один раз.
+	IDBatch int `yaml:"id_batch"`
+
+	// Webhooks is a list of webhooks notified on successful Put and Del.
+	// Webhooks -- список webhooks, уведомляемых об успешных Put и Del.
+	Webhooks []WebhookConfig
+
+	// Metrics specifies a metrics sink and its options.
+	// Metrics -- приемник метрик и его настройки.
+	Metrics metrics.Config
+
+	// Events specifies subscribers of events published by the frontend.
+	// Events -- подписчики событий, публикуемых frontend.
+	Events events.Config
+
+	// Bus specifies a bus to publish events to, the one built from Events
+	// is used by default.
+	// Bus -- шина, в которую публикуются события, по умолчанию
+	// используется построенная по Events.
+	Bus *events.Bus `yaml:"-"`
+
+	// AdminAddr is an address to serve the admin HTTP API streaming events
+	// at, the API is disabled if it is empty.
+	// AdminAddr -- адрес HTTP API администратора, транслирующего события,
+	// API отключен, если он пуст.
+	AdminAddr storage.ServiceAddr `yaml:"admin_addr"`
+
+	// Auth specifies tokens authorizing calls of admin APIs, calls are not
+	// authorized if it has none.
+	// Auth -- tokens для авторизации вызовов API администратора, вызовы
+	// не авторизуются, если их нет.
+	Auth auth.Config
+	// Signing specifies keys to sign requests to nodes and to verify
+	// requests to the frontend and their replies with, not signed by default.
+	// Signing -- ключи для подписи запросов к node и проверки подписей
+	// запросов к frontend и ответов на них, по умолчанию подпись отключена.
+	Signing storage.SigningConfig
+	// Signer specifies a signer of requests to nodes, NewDefault creates
+	// it from Signing if it is not set.
+	// Signer -- подписывающий запросы к node, NewDefault создает его
+	// по Signing, если он не задан.
+	Signer *storage.Signer `yaml:"-"`
+	// Secrets specifies a provider of secrets config values refer to as
+	// secret:<name>, e.g. keys and tokens, which are rotated without
+	// restarts.
+	// Secrets -- хранилище секретов, на которые значения конфигурации
+	// ссылаются как secret:<name>, например ключей и tokens, которые
+	// заменяются без перезапуска.
+	Secrets secrets.Config
+
+	// RecordFile is a file to record all served operations to.
+	// RecordFile -- файл, в который записываются все обслуженные операции.
+	RecordFile string `yaml:"record_file"`
+
+	// SerializeWrites makes concurrent Puts and Dels of the same key wait
+	// for each other, so replicas don't diverge when they are applied
+	// in different orders by different nodes.
+	// SerializeWrites -- упорядочивать параллельные Put и Del одного ключа,
+	// чтобы реплики не расходились, когда node применяют их в разном порядке.
+	SerializeWrites bool `yaml:"serialize_writes"`
+
+	// LWTRetries is a number of Paxos rounds a lightweight transaction
+	// retries after its ballot is rejected, LWTRetries by default.
+	// LWTRetries -- количество раундов Paxos, которые повторяет легковесная
+	// транзакция после отклонения ее ballot, по умолчанию LWTRetries.
+	LWTRetries int `yaml:"lwt_retries"`
+
+	// ReadLease is a time read leases on records are taken from their
+	// replicas for. While a lease is held Gets are served by a single
+	// replica, and writes of the record wait on replicas until it expires,
+	// so it should be well below storage.Timeout. Disabled by default.
+	// ReadLease -- время, на которое у реплик берутся аренды чтения
+	// записей. Пока аренда действует, Get обслуживаются одной репликой,
+	// а записи ждут на репликах ее окончания, поэтому она должна быть
+	// заметно меньше storage.Timeout. По умолчанию отключено.
+	ReadLease time.Duration `yaml:"read_lease"`
+
+	// TopologyFile is a file the list of nodes received from Router is
+	// saved to. If Router is unreachable at start, Gets are served with
+	// the nodes saved there until Router returns, writes still need Router.
+	// TopologyFile -- файл, в который сохраняется список node, полученный
+	// от Router. Если Router недоступен при запуске, Get обслуживаются
+	// с сохраненными в нем node, пока Router не вернется, для записи
+	// Router по-прежнему нужен.
+	TopologyFile string `yaml:"topology_file"`
+
+	// Recorder specifies a Recorder to record operations with.
+	// Recorder -- Recorder, которым записываются операции.
+	Recorder *Recorder `yaml:"-"`
+
+	// Sink specifies a sink to report metrics to.
+	// Sink -- приемник, в который отправляются метрики.
+	Sink metrics.Sink `yaml:"-"`
+
+	// Logger specifies a logger, the standard logger is used by default.
+	// Logger -- логгер, по умолчанию используется стандартный логгер.
+	Logger *log.Logger `yaml:"-"`
+
+	// Clock specifies a clock to tell time with.
+	// Clock -- часы, по которым определяется время.
+	Clock storage.Clock `yaml:"-"`
+}
+
+// Validate checks that cfg is complete and consistent and returns
+// a storage.ConfigError listing all found problems.
+//
+// Validate проверяет, что cfg полна и непротиворечива, и возвращает
+// storage.ConfigError со списком всех найденных проблем.
+func (cfg Config) Validate() error {
+	var errs storage.ConfigError
+	errs.Check(cfg.Router != "", "Router should be set")
+	errs.Check(cfg.NC != nil, "NC should be set")
+	errs.Check(cfg.RC != nil, "RC should be set")
+	errs.Check(cfg.DelParallelism >= 0, "DelParallelism should not be negative, got %v", cfg.DelParallelism)
+	errs.Check(cfg.IDBatch >= 0, "IDBatch should not be negative, got %v", cfg.IDBatch)
+	errs.Check(cfg.ReserveTTL >= 0, "ReserveTTL should not be negative, got %v", cfg.ReserveTTL)
+	errs.Check(cfg.InitBackoff >= 0, "InitBackoff should not be negative, got %v", cfg.InitBackoff)
+	errs.Check(cfg.InitMaxBackoff >= 0, "InitMaxBackoff should not be negative, got %v", cfg.InitMaxBackoff)
+	errs.Check(cfg.InitRetryTimeout >= 0, "InitRetryTimeout should not be negative, got %v", cfg.InitRetryTimeout)
+	errs.Check(cfg.RefreshInterval >= 0, "RefreshInterval should not be negative, got %v", cfg.RefreshInterval)
+	errs.Check(cfg.LWTRetries >= 0, "LWTRetries should not be negative, got %v", cfg.LWTRetries)
+	errs.Check(cfg.ReadLease >= 0 && cfg.ReadLease < storage.Timeout, "ReadLease should be in [0, %v), got %v", storage.Timeout, cfg.ReadLease)
+	errs.Merge(cfg.RateLimit.Validate())
+	errs.Merge(cfg.Signing.Validate())
+	errs.Merge(cfg.Concurrency.Validate())
+	errs.Merge(cfg.Fingerprint.Validate())
+	errs.Merge(cfg.Namespaces.Validate())
+	errs.Check(cfg.MaxValueSize >= 0, "MaxValueSize should not be negative, got %v", cfg.MaxValueSize)
+	for i, v := range cfg.Validators {
+		errs.Check(v.Validate != nil, "Validators[%v].Validate should be set", i)
+	}
+	for i, wh := range cfg.Webhooks {
+		errs.Check(wh.URL != "", "Webhooks[%v].URL should be set", i)
+		errs.Check(wh.BatchSize >= 0, "Webhooks[%v].BatchSize should not be negative, got %v", i, wh.BatchSize)
+		errs.Check(wh.Retries >= 0, "Webhooks[%v].Retries should not be negative, got %v", i, wh.Retries)
+	}
+	return errs.Err()
 }
 
 // Frontend is a frontend service.
 type Frontend struct {
-	conf        Config
-	initOnce    sync.Once
-	routerNodes []storage.ServiceAddr
+	// ballot is the last ballot of lightweight transactions, it is first
+	// to be aligned on 32-bit platforms.
+	ballot       uint64
+	initialized  int32
+	initFailures uint32
+	refreshing   int32
+	conf         Config
+	initLock     sync.Mutex
+	// topology is replaced as a whole when the nodes change, so readers
+	// never see it partially updated and don't need a lock.
+	topology atomic.Pointer[topology]
+	ids      map[string]*idRange
+	idLock   sync.Mutex
+	// webhooks are guarded by webhookLock, so Close doesn't race with
+	// notify.
+	webhooks    []*webhook
+	webhookLock sync.RWMutex
+	limiter     ratelimit.Limiter
+	// changes is the bus events.RecordChanged are published to.
+	changes *events.Bus
+	// nodeLimiters are adaptive limiters of concurrent requests to nodes.
+	nodeLimiters map[storage.ServiceAddr]*ratelimit.Adaptive
+	nodeLimLock  sync.Mutex
+	// writeLocks serialize writes of the same key if cfg.SerializeWrites
+	// is set.
+	writeLocks []sync.Mutex
+	// readLeases are read leases held if cfg.ReadLease is set, readHolder
+	// is the holder of the last one.
+	readLeases     map[storage.RecordID]readLease
+	readLeaseSweep int
+	readLeaseLock  sync.Mutex
+	readHolder     atomic.Uint64
 }
 
-// New creates a new Frontend with a given cfg.
+// New creates a new Frontend with a given cfg modified by opts.
+// Panics if cfg is invalid, use cfg.Validate to check it beforehand.
 //
-// New создает новый Frontend с данным cfg.
-func New(cfg Config) *Frontend {
-	return &Frontend{
-		conf: cfg,
+// New создает новый Frontend с данным cfg, измененной opts.
+// Паникует, если cfg некорректна, для проверки используйте cfg.Validate.
+func New(cfg Config, opts ...Option) *Frontend {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	if cfg.Sink == nil {
+		cfg.Sink = metrics.Discard
+	}
+	if cfg.NF.IsZero() {
+		cfg.NF = router.NewNodesFinder(router.NewMD5Hasher())
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = storage.SystemClock
+	}
+	if cfg.Bus == nil {
+		cfg.Bus = events.New(cfg.Events, cfg.Addr, cfg.Sink, cfg.Logger, cfg.Clock)
+	}
+	if cfg.ReserveTTL == 0 {
+		cfg.ReserveTTL = ReserveTTL
+	}
+	if cfg.InitBackoff == 0 {
+		cfg.InitBackoff = InitTimeout
+	}
+	if cfg.InitMaxBackoff == 0 {
+		cfg.InitMaxBackoff = InitMaxBackoff
+	}
+	if cfg.InitRetryTimeout == 0 {
+		cfg.InitRetryTimeout = InitRetryTimeout
+	}
+	if cfg.RefreshInterval == 0 {
+		cfg.RefreshInterval = RefreshInterval
+	}
+	if cfg.LWTRetries == 0 {
+		cfg.LWTRetries = LWTRetries
 	}
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		panic(err)
+	}
+	fe := &Frontend{
+		conf:         cfg,
+		limiter:      limiter,
+		ids:          make(map[string]*idRange),
+		webhooks:     startWebhooks(cfg.Webhooks, cfg.Logger),
+		changes:      events.NewBus(cfg.Addr, cfg.Logger, cfg.Clock),
+		nodeLimiters: make(map[storage.ServiceAddr]*ratelimit.Adaptive),
+		writeLocks:   newWriteLocks(cfg.SerializeWrites),
+		readLeases:   make(map[storage.RecordID]readLease),
+	}
+	if cfg.ReadLease > 0 {
+		holder, err := newHolder()
+		if err != nil {
+			panic(err)
+		}
+		fe.readHolder.Store(holder)
+	}
+	if cfg.Concurrency.Enabled {
+		fe.conf.NC = storage.InterceptedClient{Client: cfg.NC, Intercept: fe.limitConcurrency}
+	}
+	if cfg.TopologyFile != "" {
+		if err := fe.loadTopology(); err != nil {
+			cfg.Logger.Printf("Failed to load topology from %q: %v", cfg.TopologyFile, err)
+		}
+	}
+	return fe
 }
 
-func (fe *Frontend) applyPutDel(k storage.RecordID, method func(node storage.ServiceAddr) error) error {
-	nodes, err := fe.conf.RC.NodesFind(fe.conf.Router, k)
+// opMetrics maps operations to names of their latency metrics, so they
+// are not built on every request.
+var opMetrics = map[string]string{
+	"put": "frontend.put",
+	"get": "frontend.get",
+	"del": "frontend.del",
+
+	"update": "frontend.update",
+	"upsert": "frontend.upsert",
+
+	"get_verified": "frontend.get_verified",
+}
+
+// allow admits n requests within cfg.RateLimit or returns
+// storage.ErrRateLimited with a hint to retry after the time
+// n requests are allowed in.
+func (fe *Frontend) allow(n int) error {
+	if fe.limiter.AllowN(n) {
+		return nil
+	}
+	fe.conf.Sink.IncrCounter("frontend.rate_limited", int64(n))
+	return storage.RetryAfterError{Err: storage.ErrRateLimited, After: fe.conf.RateLimit.RetryAfter(n)}
+}
+
+// observe reports latency and result of the operation op started at start
+// and records the operation if cfg.Recorder is set.
+func (fe *Frontend) observe(op string, k storage.RecordID, start time.Time, size int, err error) {
+	latency := fe.conf.Clock.Now().Sub(start)
+	fe.conf.Sink.ObserveDuration(opMetrics[op], latency)
+	if err != nil {
+		fe.conf.Sink.IncrCounter(opMetrics[op]+".errors", 1)
+		fe.publishFailure(op, k, err)
+	}
+
+	if fe.conf.Recorder != nil {
+		rec := Operation{Op: op, Key: k, Size: size, Latency: latency}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		fe.conf.Recorder.Record(rec, start)
+	}
+}
+
+// checkKey rejects invalid keys before fan-out.
+func (fe *Frontend) checkKey(k storage.RecordID) error {
+	if fe.conf.RejectNullKeys {
+		return k.Validate()
+	}
+	return nil
+}
+
+// checkValue rejects values over cfg.MaxValueSize or failing validation
+// before fan-out.
+func (fe *Frontend) checkValue(k storage.RecordID, d []byte) error {
+	if fe.conf.MaxValueSize > 0 && len(d) > fe.conf.MaxValueSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", storage.ErrValueTooLarge, len(d), fe.conf.MaxValueSize)
+	}
+	return fe.validate(k, d)
+}
+
+// applyPutDel calls method for every replica of the record with key k and
+// returns once they all answer or ctx is done. Succeeds if as many of them
+// as the consistency level of ctx requires do.
+func (fe *Frontend) applyPutDel(ctx context.Context, k storage.RecordID, method func(node storage.ServiceAddr) error) error {
+	return fe.applyPutDelAt(ctx, k, func(_ int, node storage.ServiceAddr) error {
+		return method(node)
+	})
+}
+
+// replicas asks the router for the live replicas of the record with key k,
+// giving up once ctx is done.
+func (fe *Frontend) replicas(ctx context.Context, k storage.RecordID) ([]storage.ServiceAddr, error) {
+	return rclient.WithContext(fe.conf.RC, ctx).NodesFind(fe.conf.Router, k)
+}
+
+// applyPutDelAt is applyPutDel passing method the index of node among
+// the replicas of k.
+func (fe *Frontend) applyPutDelAt(ctx context.Context, k storage.RecordID, method func(i int, node storage.ServiceAddr) error) error {
+	if err := fe.checkKey(k); err != nil {
+		return err
+	}
+	nodes, err := fe.replicas(ctx, k)
 	if err != nil {
 		return err
 	}
 
-	if len(nodes) < storage.MinRedundancy {
+	required := requiredBy(ctx)
+	if len(nodes) < required {
 		return storage.ErrNotEnoughDaemons
 	}
 
-	results := make(chan error, len(nodes))
-	for _, node := range nodes {
-		go func(node storage.ServiceAddr) {
-			results <- method(node)
-		}(node)
-	}
+	results := fanout.Collect(ctx, 0, nodes, method)
 
 	okCount := 0
 	errCounts := make(map[error]int)
 
 	for range nodes {
-		err := <-results
+		var err error
+		select {
+		case err = <-results:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 		if err == nil {
 			okCount++
 		} else {
@@ -82,12 +526,27 @@ func (fe *Frontend) applyPutDel(k storage.RecordID, method func(node storage.Ser
 		}
 	}
 
-	if okCount >= storage.MinRedundancy {
+	return quorum(required, okCount, errCounts)
+}
+
+// requiredBy returns the number of replicas of a record which must agree on
+// a request to it at the consistency level of ctx. ConsistencyAll counts all
+// storage.ReplicationFactor replicas of the record rather than the live
+// ones found, so requests at it fail while any of them is down.
+func requiredBy(ctx context.Context) int {
+	return storage.ConsistencyOf(ctx).Required(storage.ReplicationFactor)
+}
+
+// quorum decides the result of a write acknowledged by okCount replicas
+// and failed by the rest with errors counted in errCounts, required of
+// them must agree.
+func quorum(required, okCount int, errCounts map[error]int) error {
+	if okCount >= required {
 		return nil
 	}
 
 	for err, count := range errCounts {
-		if count >= storage.MinRedundancy {
+		if count >= required {
 			return err
 		}
 	}
@@ -96,14 +555,66 @@ func (fe *Frontend) applyPutDel(k storage.RecordID, method func(node storage.Ser
 }
 
 // Put an item to the storage if an item for the given key doesn't exist.
-// Returns error otherwise.
+// Returns error otherwise. Values over cfg.MaxValueSize are rejected with
+// the storage.ErrValueTooLarge error and values failing cfg.Validators with
+// the storage.ErrInvalidValue error before they are sent to nodes.
+// A failed Put deletes the record from the replicas which stored it, but
+// that may fail or be cut short by the context, so it should be retried
+// with storage.PutWithRetry rather than called again.
 //
 // Put -- добавить запись в хранилище, если запись для данного ключа
-// не существует. Иначе вернуть ошибку.
+// не существует. Иначе вернуть ошибку. Значения больше cfg.MaxValueSize
+// отклоняются с ошибкой storage.ErrValueTooLarge, а не прошедшие проверку
+// cfg.Validators -- с ошибкой storage.ErrInvalidValue до отправки на node.
+// Неудачный Put удаляет запись с сохранивших ее реплик, но это может не
+// удаться или быть прервано контекстом, поэтому его следует повторять
+// с помощью storage.PutWithRetry, а не вызывать снова.
 func (fe *Frontend) Put(k storage.RecordID, d []byte) error {
-	return fe.applyPutDel(k, func(node storage.ServiceAddr) error {
-		return fe.conf.NC.Put(node, k, d)
-	})
+	return fe.PutContext(context.Background(), k, d)
+}
+
+// PutContext is Put returning ctx.Err() without waiting for the replicas
+// once ctx is done, requests to them are canceled then. The record may
+// still be put by some replicas. Succeeds once as many replicas as
+// storage.ConsistencyOf(ctx) requires store the record.
+//
+// PutContext -- Put, возвращающий ctx.Err(), не дожидаясь реплик, когда
+// ctx завершен, запросы к ним при этом отменяются. Запись все равно может
+// быть добавлена на некоторые реплики. Успешен, когда запись сохранили
+// столько реплик, сколько требует storage.ConsistencyOf(ctx).
+func (fe *Frontend) PutContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	start := fe.conf.Clock.Now()
+	err := ctx.Err()
+	if err == nil {
+		err = fe.allow(1)
+	}
+	if err == nil {
+		err = fe.checkValue(k, d)
+	}
+	if err == nil {
+		err = fe.put(ctx, k, d)
+	}
+	if err == nil {
+		fe.notify("put", k, d)
+	}
+	fe.observe("put", k, start, len(d), err)
+	return err
+}
+
+func (fe *Frontend) put(ctx context.Context, k storage.RecordID, d []byte) error {
+	defer fe.lockKey(k)()
+	fe.dropReadLease(k)
+	cancel, err := fe.reservePut(ctx, k, d)
+	if err != nil {
+		return err
+	}
+	if fe.erasureCoded(k) {
+		err = fe.putShards(ctx, k, d, storage.Client.Put)
+	} else {
+		err = fe.putReplicas(ctx, k, d)
+	}
+	cancel(err)
+	return err
 }
 
 // Del an item from the storage if an item exists for the given key.
@@ -112,65 +623,157 @@ func (fe *Frontend) Put(k storage.RecordID, d []byte) error {
 // Del -- удалить запись из хранилища, если запись для данного ключа
 // существует. Иначе вернуть ошибку.
 func (fe *Frontend) Del(k storage.RecordID) error {
-	return fe.applyPutDel(k, func(node storage.ServiceAddr) error {
-		return fe.conf.NC.Del(node, k)
-	})
+	return fe.DelContext(context.Background(), k)
+}
+
+// DelContext is Del returning ctx.Err() without waiting for the replicas
+// once ctx is done, requests to them are canceled then. The record may
+// still be deleted by some replicas. Succeeds once as many replicas as
+// storage.ConsistencyOf(ctx) requires delete the record.
+//
+// DelContext -- Del, возвращающий ctx.Err(), не дожидаясь реплик, когда
+// ctx завершен, запросы к ним при этом отменяются. Запись все равно может
+// быть удалена на некоторых репликах. Успешен, когда запись удалили
+// столько реплик, сколько требует storage.ConsistencyOf(ctx).
+func (fe *Frontend) DelContext(ctx context.Context, k storage.RecordID) error {
+	start := fe.conf.Clock.Now()
+	err := ctx.Err()
+	if err == nil {
+		err = fe.allow(1)
+	}
+	if err == nil {
+		unlock := fe.lockKey(k)
+		fe.dropReadLease(k)
+		nc := storage.WithContext(fe.conf.NC, ctx)
+		err = fe.applyPutDel(ctx, k, func(node storage.ServiceAddr) error {
+			return nc.Del(node, k)
+		})
+		unlock()
+	}
+	if err == nil {
+		fe.notify("del", k, nil)
+	}
+	fe.observe("del", k, start, 0, err)
+	return err
 }
 
 // Get an item from the storage if an item exists for the given key.
-// Returns error otherwise.
+// Returns error otherwise. If replicas returned divergent values and
+// cfg.Resolvers has a resolver of its namespace, the merged value is
+// returned and written back to the replicas. If quorum can't be reached
+// and cfg.DegradedReads is set, the answer of the most replicas is
+// returned along with storage.ErrPossiblyStale error. Values are verified
+// by GetVerified if cfg.ParanoidReads is set.
 //
 // Get -- получить запись из хранилища, если запись для данного ключа
-// существует. Иначе вернуть ошибку.
+// существует. Иначе вернуть ошибку. Если реплики вернули различающиеся
+// значения и в cfg.Resolvers есть функция для его пространства ключей,
+// возвращается объединенное значение, которое также записывается
+// в реплики. Если кворум не достигнут и задан cfg.DegradedReads,
+// возвращается ответ наибольшего числа реплик вместе с ошибкой
+// storage.ErrPossiblyStale. Если задан cfg.ParanoidReads, значения
+// проверяются с помощью GetVerified.
 func (fe *Frontend) Get(k storage.RecordID) ([]byte, error) {
-	fe.initOnce.Do(func() {
-		for {
-			nodes, err := fe.conf.RC.List(fe.conf.Router)
-			if err == nil {
-				fe.routerNodes = nodes
-				break
-			}
-			time.Sleep(InitTimeout)
-		}
-	})
+	return fe.GetContext(context.Background(), k)
+}
 
-	nodes := fe.conf.NF.NodesFind(k, fe.routerNodes)
+// GetContext is Get returning ctx.Err() without waiting for the replicas
+// once ctx is done, requests to them are canceled then. Returns the answer
+// of as many replicas as storage.ConsistencyOf(ctx) requires: the first
+// one at storage.ConsistencyOne, the one of all replicas bypassing read
+// leases at storage.ConsistencyAll. At storage.ConsistencyOne replicas
+// failing to answer are skipped, their error is returned only once all
+// of them fail.
+//
+// GetContext -- Get, возвращающий ctx.Err(), не дожидаясь реплик, когда
+// ctx завершен, запросы к ним при этом отменяются. Возвращает ответ
+// стольких реплик, сколько требует storage.ConsistencyOf(ctx): первый
+// при storage.ConsistencyOne, ответ всех реплик без учета read lease
+// при storage.ConsistencyAll. При storage.ConsistencyOne не ответившие
+// реплики пропускаются, их ошибка возвращается, только если не ответила
+// ни одна.
+func (fe *Frontend) GetContext(ctx context.Context, k storage.RecordID) ([]byte, error) {
+	if fe.conf.ParanoidReads {
+		return fe.getVerified(ctx, k)
+	}
+	start := fe.conf.Clock.Now()
+	d, err := fe.get(ctx, k)
+	fe.observe("get", k, start, len(d), err)
+	return d, err
+}
 
-	type result struct {
-		data []byte
-		err  error
+func (fe *Frontend) get(ctx context.Context, k storage.RecordID) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := fe.allow(1); err != nil {
+		return nil, err
 	}
-	results := make(chan result, len(nodes))
+	if err := fe.checkKey(k); err != nil {
+		return nil, err
+	}
+	if err := fe.initReads(); err != nil {
+		return nil, err
+	}
+	if fe.erasureCoded(k) {
+		return fe.getShards(ctx, k)
+	}
+	level := storage.ConsistencyOf(ctx)
+	if fe.conf.ReadLease > 0 && level != storage.ConsistencyAll {
+		if d, err := fe.getLeased(k); err != errNoReadLease {
+			return d, err
+		}
+	}
+
+	req := getRequests.Get().(*getRequest)
+	defer req.release()
+
+	req.nodes = fe.conf.NF.NodesFindAppend(req.nodes, k, fe.nodes())
+	req.pending = int32(len(req.nodes)) + 1
+	required := requiredBy(ctx)
 
 	// Make method calls asynchronously
-	for _, node := range nodes {
-		go func(node storage.ServiceAddr) {
-			data, err := fe.conf.NC.Get(node, k)
-			results <- result{data: data, err: err}
-		}(node)
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	for _, node := range req.nodes {
+		go fe.getFrom(nc, req, node, k)
 	}
 
 	// Collect and process results of requests
-	dataCounts := make(map[string]int)
-	errCounts := make(map[error]int)
+	var best []byte
+	bestCount := 0
+	var failed error
 
-	for range nodes {
-		result := <-results
+	for range req.nodes {
+		var result getResult
+		select {
+		case result = <-req.results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 
 		if result.err != nil {
-			errCounts[result.err]++
-			if errCounts[result.err] >= storage.MinRedundancy {
+			// A single replica failing to answer, e.g. being down, doesn't
+			// fail the read while the others may still answer.
+			if req.voteErr(result.err) >= required && (required > 1 || result.err == storage.ErrRecordNotFound) {
 				return nil, result.err
 			}
+			failed = result.err
 			continue
 		}
 
-		dataKey := string(result.data)
-		dataCounts[dataKey]++
-		if dataCounts[dataKey] >= storage.MinRedundancy {
+		req.replicas = append(req.replicas, result)
+		count := req.voteData(result.data)
+		if count >= required {
 			return result.data, nil
 		}
+		if count > bestCount {
+			best, bestCount = result.data, count
+		}
 	}
 
-	return nil, storage.ErrQuorumNotReached
+	if required == 1 && failed != nil {
+		// No replica answered.
+		return nil, failed
+	}
+	return fe.noQuorum(k, req, best, bestCount)
 }
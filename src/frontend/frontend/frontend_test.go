@@ -1,8 +1,10 @@
 package frontend
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"sync"
@@ -10,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"ratelimit"
 	"router/router"
 	"storage"
 )
@@ -17,12 +20,21 @@ import (
 type MockRouter struct {
 	nodesFind func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error)
 	list      func(router storage.ServiceAddr) ([]storage.ServiceAddr, error)
+	orphans   func(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error)
 }
 
-func (r *MockRouter) Heartbeat(router, node storage.ServiceAddr) error {
+func (r *MockRouter) Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error {
 	return nil
 }
 
+func (r *MockRouter) Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	return nil, nil
+}
+
+func (r *MockRouter) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	return make([]error, len(beats)), nil
+}
+
 func (r *MockRouter) NodesFind(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
 	return r.nodesFind(router, k)
 }
@@ -31,10 +43,38 @@ func (r *MockRouter) List(router storage.ServiceAddr) ([]storage.ServiceAddr, er
 	return r.list(router)
 }
 
+func (r *MockRouter) Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	return r.orphans(router, node, keys)
+}
+
 type MockNode struct {
-	put func(node storage.ServiceAddr, k storage.RecordID, d []byte) error
-	get func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error)
-	del func(node storage.ServiceAddr, k storage.RecordID) error
+	put  func(node storage.ServiceAddr, k storage.RecordID, d []byte) error
+	get  func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error)
+	del  func(node storage.ServiceAddr, k storage.RecordID) error
+	scan func(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error)
+
+	acquireLease func(node storage.ServiceAddr, k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error)
+	leaseFence   func(node storage.ServiceAddr, k storage.RecordID) (uint64, error)
+	releaseLease func(node storage.ServiceAddr, k storage.RecordID, holder uint64) error
+	sequence     func(node storage.ServiceAddr, name string, floor uint64) (uint64, error)
+	stats        func(node storage.ServiceAddr) (storage.Stats, error)
+	getVersion   func(node storage.ServiceAddr, k storage.RecordID, version uint64) ([]byte, error)
+	listVersions func(node storage.ServiceAddr, k storage.RecordID) ([]uint64, error)
+	snapshot     func(node storage.ServiceAddr, id uint64, phase storage.SnapshotPhase, ttl time.Duration) error
+	scanSnapshot func(node storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error)
+	scanChanges  func(node storage.ServiceAddr, id, since uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error)
+	reserve      func(node storage.ServiceAddr, k storage.RecordID, size int64, ttl time.Duration) error
+	cancelRes    func(node storage.ServiceAddr, k storage.RecordID) error
+	mget         func(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error)
+	mput         func(node storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error)
+	mdel         func(node storage.ServiceAddr, keys []storage.RecordID) ([]error, error)
+	delta        func(node storage.ServiceAddr, since uint64, limit int) (storage.Delta, error)
+	checksum     func(node storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error)
+	paxos        func(node storage.ServiceAddr, k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error)
+	readLease    func(node storage.ServiceAddr, k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error)
+	update       func(node storage.ServiceAddr, k storage.RecordID, d []byte) error
+	upsert       func(node storage.ServiceAddr, k storage.RecordID, d []byte) error
+	merge        func(node storage.ServiceAddr, k storage.RecordID, d []byte) error
 }
 
 func (n *MockNode) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
@@ -49,6 +89,98 @@ func (n *MockNode) Del(node storage.ServiceAddr, k storage.RecordID) error {
 	return n.del(node, k)
 }
 
+func (n *MockNode) Scan(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return n.scan(node, cursor, limit)
+}
+
+func (n *MockNode) AcquireLease(node storage.ServiceAddr, k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	return n.acquireLease(node, k, holder, fence, ttl)
+}
+
+func (n *MockNode) LeaseFence(node storage.ServiceAddr, k storage.RecordID) (uint64, error) {
+	return n.leaseFence(node, k)
+}
+
+func (n *MockNode) ReleaseLease(node storage.ServiceAddr, k storage.RecordID, holder uint64) error {
+	return n.releaseLease(node, k, holder)
+}
+
+func (n *MockNode) Sequence(node storage.ServiceAddr, name string, floor uint64) (uint64, error) {
+	return n.sequence(node, name, floor)
+}
+
+func (n *MockNode) Stats(node storage.ServiceAddr) (storage.Stats, error) {
+	return n.stats(node)
+}
+
+func (n *MockNode) GetVersion(node storage.ServiceAddr, k storage.RecordID, version uint64) ([]byte, error) {
+	return n.getVersion(node, k, version)
+}
+
+func (n *MockNode) ListVersions(node storage.ServiceAddr, k storage.RecordID) ([]uint64, error) {
+	return n.listVersions(node, k)
+}
+
+func (n *MockNode) Snapshot(node storage.ServiceAddr, id uint64, phase storage.SnapshotPhase, ttl time.Duration) error {
+	return n.snapshot(node, id, phase, ttl)
+}
+
+func (n *MockNode) ScanSnapshot(node storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return n.scanSnapshot(node, id, cursor, limit)
+}
+
+func (n *MockNode) ScanChanges(node storage.ServiceAddr, id, since uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return n.scanChanges(node, id, since, cursor, limit)
+}
+
+func (n *MockNode) Reserve(node storage.ServiceAddr, k storage.RecordID, size int64, ttl time.Duration) error {
+	return n.reserve(node, k, size, ttl)
+}
+
+func (n *MockNode) MGet(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+	return n.mget(node, keys)
+}
+
+func (n *MockNode) MPut(node storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+	return n.mput(node, keys, data)
+}
+
+func (n *MockNode) MDel(node storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+	return n.mdel(node, keys)
+}
+
+func (n *MockNode) Delta(node storage.ServiceAddr, since uint64, limit int) (storage.Delta, error) {
+	return n.delta(node, since, limit)
+}
+
+func (n *MockNode) Checksum(node storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	return n.checksum(node, k, h)
+}
+
+func (n *MockNode) Paxos(node storage.ServiceAddr, k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error) {
+	return n.paxos(node, k, phase, p)
+}
+
+func (n *MockNode) AcquireReadLease(node storage.ServiceAddr, k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error) {
+	return n.readLease(node, k, holder, ttl)
+}
+
+func (n *MockNode) Update(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return n.update(node, k, d)
+}
+
+func (n *MockNode) Upsert(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return n.upsert(node, k, d)
+}
+
+func (n *MockNode) Merge(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return n.merge(node, k, d)
+}
+
+func (n *MockNode) CancelReservation(node storage.ServiceAddr, k storage.RecordID) error {
+	return n.cancelRes(node, k)
+}
+
 func nodesFind(t *testing.T, cfg Config, key storage.RecordID, nodes []storage.ServiceAddr, err error) func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
 	return func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
 		if router != cfg.Router {
@@ -157,6 +289,91 @@ func TestPutDel_nodesFindError(t *testing.T) {
 	}
 }
 
+func TestRejectNullKeys(t *testing.T) {
+	rc.nodesFind = func(storage.ServiceAddr, storage.RecordID) ([]storage.ServiceAddr, error) {
+		t.Errorf("NodesFind() called for a null key")
+		return nil, storage.ErrNotEnoughDaemons
+	}
+	c := cfg
+	c.RejectNullKeys = true
+	fe := New(c)
+	if err := fe.Put(storage.NullRecordID, []byte("123")); !errors.Is(err, storage.ErrInvalidKey) {
+		t.Errorf("Put() got error %v, want %v", err, storage.ErrInvalidKey)
+	}
+	if err := fe.Del(storage.NullRecordID); !errors.Is(err, storage.ErrInvalidKey) {
+		t.Errorf("Del() got error %v, want %v", err, storage.ErrInvalidKey)
+	}
+	if _, err := fe.Get(storage.NullRecordID); !errors.Is(err, storage.ErrInvalidKey) {
+		t.Errorf("Get() got error %v, want %v", err, storage.ErrInvalidKey)
+	}
+}
+
+func TestMaxValueSize(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc.put = put(t, nodes, key, []byte("1234"), func(storage.ServiceAddr) error { return nil })
+	c := cfg
+	c.MaxValueSize = 4
+	fe := New(c)
+	if err := fe.Put(key, []byte("12345")); !errors.Is(err, storage.ErrValueTooLarge) {
+		t.Errorf("Put() of a large value got error %v, want %v", err, storage.ErrValueTooLarge)
+	}
+	if err := fe.Put(key, []byte("1234")); err != nil {
+		t.Errorf("Put() error: %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc.put = put(t, nodes, key, []byte("1234"), func(storage.ServiceAddr) error { return nil })
+	c := cfg
+	c.RateLimit = ratelimit.Config{Kind: ratelimit.KindTokenBucket, Rate: 0.001, Burst: 2}
+	fe := New(c)
+	if err := fe.Put(key, []byte("1234")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	nc.del = del(t, nodes, key, func(storage.ServiceAddr) error { return nil })
+	if err := fe.Del(key); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	err := fe.Put(key, []byte("1234"))
+	if !errors.Is(err, storage.ErrRateLimited) {
+		t.Errorf("Put() over rate limit got error %v, want %v", err, storage.ErrRateLimited)
+	}
+	if d, ok := storage.RetryAfter(err); !ok || d != 1000*time.Second {
+		t.Errorf("Put() over rate limit got hint %v, want %v", d, 1000*time.Second)
+	}
+	if _, err := fe.Get(key); !errors.Is(err, storage.ErrRateLimited) {
+		t.Errorf("Get() over rate limit got error %v, want %v", err, storage.ErrRateLimited)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc.put = put(t, nodes, key, []byte("1234"), func(storage.ServiceAddr) error { return nil })
+	c := cfg
+	c.Concurrency = ratelimit.AdaptiveConfig{Enabled: true, Initial: 1, Max: 1}
+	fe := New(c)
+	if err := fe.Put(key, []byte("1234")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if limits := fe.ConcurrencyLimits(); len(limits) != len(nodes) || limits["node1"] != 1 {
+		t.Errorf("ConcurrencyLimits() got %v, want limit 1 for each of %v", limits, nodes)
+	}
+
+	err := fe.limitConcurrency("node1", func() error {
+		return fe.limitConcurrency("node1", func() error { return nil })
+	})
+	if !errors.Is(err, storage.ErrRateLimited) {
+		t.Errorf("limitConcurrency() over the limit got error %v, want %v", err, storage.ErrRateLimited)
+	}
+}
+
 func TestPutDel_Redundancy(t *testing.T) {
 	key := storage.RecordID(1)
 	testData := []byte("testtesttest")
@@ -195,14 +412,22 @@ func TestPutDel_Redundancy(t *testing.T) {
 			nc.put = put(t, nodes, key, testData, func(node storage.ServiceAddr) error {
 				return test.errors[node]
 			})
-			nc.del = del(t, nodes, key, func(node storage.ServiceAddr) error {
-				return test.errors[node]
-			})
+			// The failed put is rolled back on the replicas storing testData.
+			var acked []storage.ServiceAddr
+			for _, node := range test.nodes {
+				if test.errors[node] == nil {
+					acked = append(acked, node)
+				}
+			}
+			nc.del = del(t, acked, key, nil)
 
 			fe := New(cfg)
 			if err := fe.Put(key, testData); err != test.err {
 				t.Errorf("Put() got error %v, want %v", err, test.err)
 			}
+			nc.del = del(t, nodes, key, func(node storage.ServiceAddr) error {
+				return test.errors[node]
+			})
 			if err := fe.Del(key); err != test.err {
 				t.Errorf("Del() got error %v, want %v", err, test.err)
 			}
@@ -384,6 +609,73 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGet_DegradedReads(t *testing.T) {
+	key := storage.RecordID(1)
+	testData := []byte("test")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	dummyError := fmt.Errorf("dummy error")
+	for _, test := range []struct {
+		name string
+		errs map[storage.ServiceAddr]error
+		err  error
+	}{
+		{
+			name: "one_answer",
+			errs: map[storage.ServiceAddr]error{nodes[0]: errors.New("err1"), nodes[1]: errors.New("err2")},
+			err:  storage.ErrPossiblyStale,
+		},
+		{
+			name: "two_errors",
+			errs: map[storage.ServiceAddr]error{nodes[0]: dummyError, nodes[1]: dummyError},
+			err:  dummyError,
+		},
+		{
+			name: "quorum",
+			errs: map[storage.ServiceAddr]error{nodes[0]: dummyError},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			nc := new(MockNode)
+			nf := router.NewNodesFinder(FakeHasher{
+				t: t,
+				hashes: map[storage.ServiceAddr]uint64{
+					nodes[0]: 1,
+					nodes[1]: 2,
+					nodes[2]: 3,
+				},
+			})
+			fe := New(Config{
+				RC:            &rc,
+				NC:            nc,
+				NF:            nf,
+				Router:        "router",
+				DegradedReads: true,
+			})
+			nc.get = get(t, nodes, key, func(node storage.ServiceAddr) ([]byte, error) {
+				if err := test.errs[node]; err != nil {
+					return nil, err
+				}
+				return testData, nil
+			})
+			got, err := fe.Get(key)
+			if err != test.err {
+				t.Fatalf("Get() error: %v, want %v", err, test.err)
+			}
+			if err != nil && err != storage.ErrPossiblyStale {
+				return
+			}
+			if !reflect.DeepEqual(got, testData) {
+				t.Errorf("Wrong data: got %s, want %s", got, testData)
+			}
+		})
+	}
+}
+
 func TestGet_InitOnce(t *testing.T) {
 	key := storage.RecordID(1)
 	testData := []byte("test")
@@ -436,6 +728,145 @@ func TestGet_InitOnce(t *testing.T) {
 	}
 }
 
+type gaugeSink struct {
+	mu       sync.Mutex
+	gauges   map[string]float64
+	counters map[string]int64
+}
+
+func (s *gaugeSink) IncrCounter(name string, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[name] += delta
+}
+
+func (s *gaugeSink) SetGauge(name string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges[name] = value
+}
+
+func (s *gaugeSink) ObserveDuration(name string, d time.Duration) {}
+
+func TestInitialized(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	var cnt uint32
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		if atomic.AddUint32(&cnt, 1) < 3 {
+			return nil, errors.New("unreachable")
+		}
+		return nodes, nil
+	}
+	sink := &gaugeSink{gauges: make(map[string]float64), counters: make(map[string]int64)}
+	fe := New(Config{RC: &rc, NC: new(MockNode), Router: "router"}, WithMetrics(sink))
+	if fe.Initialized() {
+		t.Fatalf("Initialized() got true before init")
+	}
+
+	fe.ListNodes()
+	if !fe.Initialized() {
+		t.Fatalf("Initialized() got false after init")
+	}
+	if got := sink.counters["frontend.init.retries"]; got != 2 {
+		t.Errorf("Got %v init retries, want 2", got)
+	}
+	if got, ok := sink.gauges["frontend.init.degraded"]; !ok || got != 0 {
+		t.Errorf("Got frontend.init.degraded gauge %v (set %v), want 0", got, ok)
+	}
+}
+
+func TestStart(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	var cnt uint32
+	var reachable int32
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		atomic.AddUint32(&cnt, 1)
+		if atomic.LoadInt32(&reachable) == 0 {
+			return nil, errors.New("unreachable")
+		}
+		return nodes, nil
+	}
+	fe := New(Config{RC: &rc, NC: new(MockNode), Router: "router"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*InitTimeout)
+	defer cancel()
+	if err := fe.Start(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Start() got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if fe.Initialized() {
+		t.Fatalf("Initialized() got true while Router is unreachable")
+	}
+
+	atomic.StoreInt32(&reachable, 1)
+	if err := fe.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	n := atomic.LoadUint32(&cnt)
+	if got := fe.ListNodes(); !reflect.DeepEqual(got, nodes) {
+		t.Errorf("ListNodes() got %v, want %v", got, nodes)
+	}
+	if err := fe.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if got := atomic.LoadUint32(&cnt); got != n {
+		t.Errorf("List() was called %v more times after Start()", got-n)
+	}
+}
+
+func TestInitRetryTimeout(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	var cnt uint32
+	var reachable int32
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		atomic.AddUint32(&cnt, 1)
+		if atomic.LoadInt32(&reachable) == 0 {
+			return nil, errors.New("unreachable")
+		}
+		return nodes, nil
+	}
+	nc := new(MockNode)
+	nc.get = func(node storage.ServiceAddr, key storage.RecordID) ([]byte, error) {
+		return []byte("test"), nil
+	}
+	fe := New(Config{
+		RC:               &rc,
+		NC:               nc,
+		Router:           "router",
+		InitBackoff:      10 * time.Millisecond,
+		InitMaxBackoff:   40 * time.Millisecond,
+		InitRetryTimeout: 300 * time.Millisecond,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			if _, err := fe.Get(1); !errors.Is(err, storage.ErrRouterUnavailable) {
+				t.Errorf("Get() got error %v, want %v", err, storage.ErrRouterUnavailable)
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("Get() failed after %v, want about 300ms", d)
+			}
+		}()
+	}
+	wg.Wait()
+	// With backoff capped at 40ms there are more than 5 retries in 300ms,
+	// but not as many as with a fixed 10ms delay.
+	if n := atomic.LoadUint32(&cnt); n < 5 || n > 25 {
+		t.Errorf("List() was called %v times, want between 5 and 25", n)
+	}
+
+	atomic.StoreInt32(&reachable, 1)
+	if _, err := fe.Get(1); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if !fe.Initialized() {
+		t.Errorf("Initialized() got false after Router recovered")
+	}
+}
+
 func TestGet_Timing(t *testing.T) {
 	key := storage.RecordID(1)
 	testData := []byte("test")
@@ -485,6 +916,64 @@ func TestGet_Timing(t *testing.T) {
 	}
 }
 
+// contextNode is a MockNode whose requests block until their context
+// is done.
+type contextNode struct {
+	*MockNode
+	ctx      context.Context
+	canceled chan storage.ServiceAddr
+}
+
+func (n contextNode) WithContext(ctx context.Context) storage.Client {
+	n.ctx = ctx
+	return n
+}
+
+func (n contextNode) Get(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	<-n.ctx.Done()
+	n.canceled <- node
+	return nil, n.ctx.Err()
+}
+
+func (n contextNode) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	_, err := n.Get(node, k)
+	return err
+}
+
+func TestContext(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	nc := contextNode{MockNode: new(MockNode), ctx: context.Background(), canceled: make(chan storage.ServiceAddr, 2*len(nodes))}
+	fe := New(Config{RC: &rc, NC: nc, Router: "router"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fe.GetContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("GetContext() got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := fe.PutContext(ctx, 1, []byte("test")); err != context.DeadlineExceeded {
+		t.Errorf("PutContext() got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	for range nodes {
+		select {
+		case <-nc.canceled:
+		case <-time.After(time.Second):
+			t.Fatalf("Requests to replicas are not canceled")
+		}
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fe.DelContext(canceled, 1); err != context.Canceled {
+		t.Errorf("DelContext() got error %v, want %v", err, context.Canceled)
+	}
+}
+
 func TestParallelOps(t *testing.T) {
 	key := storage.RecordID(1)
 	testData := []byte("test")
@@ -521,33 +1010,283 @@ func TestParallelOps(t *testing.T) {
 		Router: "router",
 	})
 
-	go func() {
-		for {
-			runtime.Gosched()
-			if err := fe.Del(key); err != nil {
-				t.Errorf("Del() error: %v", err)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	loop := func(op func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				runtime.Gosched()
+				op()
 			}
+		}()
+	}
+	loop(func() {
+		if err := fe.Del(key); err != nil {
+			t.Errorf("Del() error: %v", err)
 		}
-	}()
+	})
+	loop(func() {
+		if err := fe.Put(key, testData); err != nil {
+			t.Errorf("Put() error: %v", err)
+		}
+	})
+	loop(func() {
+		got, err := fe.Get(key)
+		if err != nil {
+			t.Errorf("Get() error: %v", err)
+		}
+		if !reflect.DeepEqual(got, testData) {
+			t.Errorf("Wrong data: got %s, want %s", got, testData)
+		}
+	})
+	time.Sleep(3 * time.Second)
+	close(stop)
+	wg.Wait()
+}
+
+func newBenchFrontend() *Frontend {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3", "node4", "node5"}
+	testData := []byte("test")
+
+	rc := new(MockRouter)
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	nc := new(MockNode)
+	nc.get = func(node storage.ServiceAddr, key storage.RecordID) ([]byte, error) {
+		return testData, nil
+	}
+
+	return New(Config{
+		RC:     rc,
+		NC:     nc,
+		NF:     router.NewNodesFinder(router.NewMD5Hasher()),
+		Router: "router",
+	})
+}
+
+func TestGet_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("The race detector inflates allocations")
+	}
+	fe := newBenchFrontend()
+
+	// Only the calls to nodes are allowed to allocate.
+	allocs := testing.AllocsPerRun(100, func() {
+		fe.Get(1)
+	})
+	if allocs > storage.ReplicationFactor {
+		t.Errorf("Get() allocations: got %v, want at most %v", allocs, storage.ReplicationFactor)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	fe := newBenchFrontend()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fe.Get(storage.RecordID(i))
+	}
+}
+
+func TestNewDefault(t *testing.T) {
+	fe, err := NewDefault(Config{Router: "router"})
+	if err != nil {
+		t.Fatalf("NewDefault() error: %v", err)
+	}
+	if fe.conf.NC == nil || fe.conf.RC == nil || fe.conf.NF.IsZero() || fe.conf.Sink == nil {
+		t.Errorf("NewDefault() left dependencies unset: %+v", fe.conf)
+	}
+
+	if _, err := NewDefault(Config{}); err == nil {
+		t.Errorf("NewDefault() without Router got no error")
+	}
+}
+
+func TestTopologySwap(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc := &MockRouter{list: func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}}
+	fe := New(Config{RC: rc, NC: new(MockNode), Router: "router"})
+	fe.ListNodes()
+	nodes[0] = "node4"
+	if got := fe.nodes(); got[0] != "node1" {
+		t.Errorf("nodes() got %v after changing the published list, want node1 first", got)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
-		for {
-			runtime.Gosched()
-			if err := fe.Put(key, testData); err != nil {
-				t.Errorf("Put() error: %v", err)
-			}
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			fe.setNodes(nodes[:1+i%len(nodes)])
 		}
 	}()
-	go func() {
-		for {
-			runtime.Gosched()
-			got, err := fe.Get(key)
-			if err != nil {
-				t.Errorf("Get() error: %v", err)
-			}
-			if !reflect.DeepEqual(got, testData) {
-				t.Errorf("Wrong data: got %s, want %s", got, testData)
+	for i := 0; i < 1000; i++ {
+		if got := fe.ListNodes(); len(got) == 0 {
+			t.Fatalf("ListNodes() got no nodes during a swap")
+		}
+	}
+	wg.Wait()
+}
+
+func TestRefreshNodes(t *testing.T) {
+	var lock sync.Mutex
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	fail := false
+	rc := &MockRouter{list: func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if fail {
+			return nil, storage.ErrRouterUnavailable
+		}
+		return append([]storage.ServiceAddr(nil), nodes...), nil
+	}}
+	fe := New(Config{RC: rc, NC: new(MockNode), Router: "router", RefreshInterval: 10 * time.Millisecond})
+	if err := fe.RefreshNodes(); err != nil {
+		t.Fatalf("RefreshNodes() error: %v", err)
+	}
+	if !fe.Initialized() {
+		t.Errorf("Initialized() got false after RefreshNodes()")
+	}
+	top := fe.topology.Load()
+	if err := fe.RefreshNodes(); err != nil {
+		t.Fatalf("RefreshNodes() error: %v", err)
+	}
+	if fe.topology.Load() != top {
+		t.Errorf("RefreshNodes() replaced the topology with the same nodes")
+	}
+
+	fe.Refreshes()
+	lock.Lock()
+	nodes = append(nodes, "node4")
+	lock.Unlock()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fe.nodes()) != 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("nodes() got %v, want node4 added by Refreshes()", fe.nodes())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	lock.Lock()
+	fail = true
+	lock.Unlock()
+	if err := fe.RefreshNodes(); err != storage.ErrRouterUnavailable {
+		t.Errorf("RefreshNodes() got error %v, want %v", err, storage.ErrRouterUnavailable)
+	}
+	if got := fe.nodes(); len(got) != 4 {
+		t.Errorf("nodes() got %v after a failed refresh, want the last known nodes", got)
+	}
+}
+
+func TestTopologyFile(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "topology")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	var reachable int32 = 1
+	rc := &MockRouter{
+		list: func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+			if atomic.LoadInt32(&reachable) == 0 {
+				return nil, errors.New("unreachable")
 			}
+			return nodes, nil
+		},
+		nodesFind: func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+			return nil, errors.New("unreachable")
+		},
+	}
+	nc := new(MockNode)
+	nc.get = func(node storage.ServiceAddr, key storage.RecordID) ([]byte, error) {
+		return []byte("test"), nil
+	}
+	conf := Config{
+		RC:               rc,
+		NC:               nc,
+		Router:           "router",
+		TopologyFile:     fname,
+		InitBackoff:      10 * time.Millisecond,
+		InitMaxBackoff:   10 * time.Millisecond,
+		InitRetryTimeout: 50 * time.Millisecond,
+	}
+	if err := New(conf).Start(context.Background()); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	atomic.StoreInt32(&reachable, 0)
+	fe := New(conf)
+	if !fe.Stale() {
+		t.Fatalf("Stale() got false with a saved topology")
+	}
+	if got, err := fe.Get(1); err != nil || string(got) != "test" {
+		t.Errorf("Get() got %q, %v while Router is unreachable, want %q", got, err, "test")
+	}
+	if err := fe.Put(1, []byte("test")); err == nil {
+		t.Errorf("Put() got no error while Router is unreachable")
+	}
+
+	atomic.StoreInt32(&reachable, 1)
+	deadline := time.Now().Add(time.Second)
+	for !fe.Initialized() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !fe.Initialized() || fe.Stale() {
+		t.Errorf("Frontend was not refreshed after Router returned")
+	}
+}
+
+func TestSerializeWrites(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc := &MockRouter{nodesFind: func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}}
+	var inflight, overlaps int32
+	write := func() error {
+		if atomic.AddInt32(&inflight, 1) > int32(len(nodes)) {
+			atomic.AddInt32(&overlaps, 1)
 		}
-	}()
-	time.Sleep(3 * time.Second)
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inflight, -1)
+		return nil
+	}
+	nc := new(MockNode)
+	nc.put = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error { return write() }
+	nc.del = func(node storage.ServiceAddr, k storage.RecordID) error { return write() }
+
+	run := func(serialize bool) int32 {
+		atomic.StoreInt32(&overlaps, 0)
+		fe := New(Config{RC: rc, NC: nc, Router: "router", SerializeWrites: serialize})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					fe.Put(1, []byte("test"))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					fe.Del(1)
+				}
+			}()
+		}
+		wg.Wait()
+		return atomic.LoadInt32(&overlaps)
+	}
+
+	if got := run(true); got != 0 {
+		t.Errorf("Writes of the same key overlapped %v times with SerializeWrites", got)
+	}
+	if got := run(false); got == 0 {
+		t.Errorf("Writes of the same key never overlapped without SerializeWrites")
+	}
 }
@@ -0,0 +1,126 @@
+package frontend
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+
+	"storage"
+)
+
+type getResult struct {
+	node storage.ServiceAddr
+	data []byte
+	err  error
+}
+
+type dataVote struct {
+	data  []byte
+	count int
+}
+
+type errVote struct {
+	err   error
+	count int
+}
+
+// getRequest holds state of a Get request. It is reused between requests
+// to avoid allocations on the hot path, so it is returned to the pool
+// only when all requests to nodes are finished.
+type getRequest struct {
+	nodes    []storage.ServiceAddr
+	results  chan getResult
+	pending  int32
+	replicas []getResult
+	data     []dataVote
+	errs     []errVote
+}
+
+var getRequests = sync.Pool{
+	New: func() interface{} {
+		return &getRequest{
+			nodes:    make([]storage.ServiceAddr, 0, storage.ReplicationFactor),
+			results:  make(chan getResult, storage.ReplicationFactor),
+			replicas: make([]getResult, 0, storage.ReplicationFactor),
+			data:     make([]dataVote, 0, storage.ReplicationFactor),
+			errs:     make([]errVote, 0, storage.ReplicationFactor),
+		}
+	},
+}
+
+// voteData counts a replica returning data and returns the number
+// of replicas returned the same data.
+func (req *getRequest) voteData(data []byte) int {
+	for i := range req.data {
+		if bytes.Equal(req.data[i].data, data) {
+			req.data[i].count++
+			return req.data[i].count
+		}
+	}
+	req.data = append(req.data, dataVote{data: data, count: 1})
+	return 1
+}
+
+// voteErr counts a replica returning err and returns the number
+// of replicas returned the same error.
+func (req *getRequest) voteErr(err error) int {
+	for i := range req.errs {
+		if req.errs[i].err == err {
+			req.errs[i].count++
+			return req.errs[i].count
+		}
+	}
+	req.errs = append(req.errs, errVote{err: err, count: 1})
+	return 1
+}
+
+// release drops a reference to req and returns it to the pool
+// when there are no more references.
+func (req *getRequest) release() {
+	if atomic.AddInt32(&req.pending, -1) != 0 {
+		return
+	}
+	for len(req.results) > 0 {
+		<-req.results
+	}
+	// Drop references to data, so it can be collected.
+	for i := range req.replicas {
+		req.replicas[i] = getResult{}
+	}
+	for i := range req.data {
+		req.data[i] = dataVote{}
+	}
+	for i := range req.errs {
+		req.errs[i] = errVote{}
+	}
+	req.nodes = req.nodes[:0]
+	req.replicas = req.replicas[:0]
+	req.data = req.data[:0]
+	req.errs = req.errs[:0]
+	getRequests.Put(req)
+}
+
+func (fe *Frontend) getFrom(nc storage.Client, req *getRequest, node storage.ServiceAddr, k storage.RecordID) {
+	data, err := nc.Get(node, k)
+	req.results <- getResult{node: node, data: data, err: err}
+	req.release()
+}
+
+// noQuorum chooses the value of the record with key k voted by req if
+// replicas didn't reach quorum: the one merged by the resolver of its
+// namespace in cfg.Resolvers or the best one if cfg.DegradedReads is set.
+func (fe *Frontend) noQuorum(k storage.RecordID, req *getRequest, best []byte, bestCount int) ([]byte, error) {
+	if resolve := fe.resolver(k); resolve != nil && len(req.data) > 1 {
+		replicas := make(map[storage.ServiceAddr][]byte, len(req.replicas))
+		for _, r := range req.replicas {
+			replicas[r.node] = r.data
+		}
+		return fe.resolve(k, resolve, replicas)
+	}
+
+	if fe.conf.DegradedReads && bestCount > 0 {
+		return best, storage.ErrPossiblyStale
+	}
+
+	return nil, storage.ErrQuorumNotReached
+}
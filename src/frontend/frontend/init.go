@@ -0,0 +1,127 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// InitTimeout is a default delay before the first retry of unsuccessful
+// List() request to Router.
+//
+// InitTimeout -- задержка по умолчанию перед первым повтором неуспешного
+// запроса List() в Router.
+const InitTimeout = 100 * time.Millisecond
+
+// InitMaxBackoff is a default cap of the delay between retries of List().
+//
+// InitMaxBackoff -- максимальная задержка по умолчанию между повторами List().
+const InitMaxBackoff = 5 * time.Second
+
+// InitRetryTimeout is a default time requests wait for Router during lazy init.
+//
+// InitRetryTimeout -- время по умолчанию, которое запросы ждут Router
+// при ленивой инициализации.
+const InitRetryTimeout = 30 * time.Second
+
+// Start initializes the frontend by fetching the list of nodes from Router,
+// retrying until it succeeds or ctx is done. Calling Start before serving
+// is optional: requests initialize the frontend lazily otherwise.
+//
+// Start инициализирует frontend, получая список node от Router, и повторяет
+// попытки до успеха или завершения ctx. Вызывать Start перед обслуживанием
+// запросов необязательно: иначе frontend инициализируется при первом запросе.
+func (fe *Frontend) Start(ctx context.Context) error {
+	if fe.Initialized() {
+		return nil
+	}
+	fe.initLock.Lock()
+	defer fe.initLock.Unlock()
+	if fe.Initialized() {
+		return nil
+	}
+	return fe.fetchNodes(ctx)
+}
+
+// init fetches the list of nodes from Router once, unless Start already has.
+// It gives up after InitRetryTimeout with storage.ErrRouterUnavailable,
+// requests waiting for the same attempt fail without retrying.
+func (fe *Frontend) init() error {
+	if fe.Initialized() {
+		return nil
+	}
+	failures := atomic.LoadUint32(&fe.initFailures)
+	fe.initLock.Lock()
+	defer fe.initLock.Unlock()
+	if fe.Initialized() {
+		return nil
+	}
+	if atomic.LoadUint32(&fe.initFailures) != failures {
+		return storage.ErrRouterUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fe.conf.InitRetryTimeout)
+	defer cancel()
+	if err := fe.fetchNodes(ctx); err != nil {
+		atomic.AddUint32(&fe.initFailures, 1)
+		fe.conf.Sink.IncrCounter("frontend.init.timeouts", 1)
+		return fmt.Errorf("%w: no reply from %q in %v", storage.ErrRouterUnavailable, fe.conf.Router, fe.conf.InitRetryTimeout)
+	}
+	return nil
+}
+
+// fetchNodes fetches the list of nodes from Router, retrying with
+// exponential backoff and jitter. While Router is unreachable the frontend
+// is reported degraded by the frontend.init.degraded gauge.
+func (fe *Frontend) fetchNodes(ctx context.Context) error {
+	backoff := fe.conf.InitBackoff
+	for attempt := 1; ; attempt++ {
+		nodes, err := fe.conf.RC.List(fe.conf.Router)
+		if err == nil {
+			fe.setNodes(nodes)
+			if attempt > 1 {
+				fe.conf.Logger.Printf("Router %q is reachable after %v attempts, frontend is initialized", fe.conf.Router, attempt)
+				fe.conf.Sink.SetGauge("frontend.init.degraded", 0)
+			}
+			atomic.StoreInt32(&fe.initialized, 1)
+			return nil
+		}
+		if attempt == 1 {
+			fe.conf.Logger.Printf("Router %q is unreachable during init, retrying: %v", fe.conf.Router, err)
+			fe.conf.Sink.SetGauge("frontend.init.degraded", 1)
+		}
+		fe.conf.Sink.IncrCounter("frontend.init.retries", 1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(backoff)):
+		}
+		if backoff *= 2; backoff > fe.conf.InitMaxBackoff {
+			backoff = fe.conf.InitMaxBackoff
+		}
+	}
+}
+
+// jitter returns a random duration between d/2 and d, so frontends
+// started together don't retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d < 2 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// Initialized reports whether the frontend has fetched the list of nodes
+// from Router. While Router is unreachable requests of an uninitialized
+// frontend wait for it up to InitRetryTimeout.
+//
+// Initialized сообщает, получил ли frontend список node от Router.
+// Пока Router недоступен, запросы к неинициализированному frontend ждут его
+// не дольше InitRetryTimeout.
+func (fe *Frontend) Initialized() bool {
+	return atomic.LoadInt32(&fe.initialized) != 0
+}
@@ -0,0 +1,254 @@
+package frontend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"storage"
+	"storage/fanout"
+)
+
+// leaseAttempts is the number of rounds AcquireLease makes while its
+// fences are rejected by concurrent acquisitions.
+const leaseAttempts = 3
+
+// AcquireLease acquires a lease on the record with key k for the holder on
+// at least storage.MinRedundancy replicas and returns the fencing token,
+// which is at least fence. Partially acquired leases are released on
+// failure.
+//
+// Tokens are granted in two phases: the greatest token granted so far is
+// read from a quorum of replicas and the next one is proposed to them,
+// replicas accept only tokens greater than the ones they have granted.
+// Any two quorums intersect, so the token is greater than the tokens of
+// all the leases acquired before, even by other quorums. A lease already
+// held by the holder is renewed keeping its token, as replicas asked for
+// a zero token extend the lease of its holder and grant the next token to
+// the others, so a token granted by a quorum is greater than the tokens of
+// the leases acquired before as well.
+//
+// AcquireLease получает аренду записи с ключом k для holder как минимум на
+// storage.MinRedundancy репликах и возвращает fencing token не меньше fence.
+// В случае ошибки частично полученные аренды освобождаются.
+//
+// Token выдается в две фазы: наибольший выданный token читается с кворума
+// реплик, и им предлагается следующий, реплики принимают только token
+// больше выданных ими. Любые два кворума пересекаются, поэтому token больше
+// token всех ранее полученных аренд, даже другими кворумами. Аренда, уже
+// принадлежащая holder, продлевается с сохранением token, так как реплики,
+// которым предложен нулевой token, продлевают аренду ее holder, а остальным
+// выдают следующий token, поэтому token, выданный кворумом, также больше
+// token ранее полученных аренд.
+func (fe *Frontend) AcquireLease(k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	if err := fe.checkKey(k); err != nil {
+		return 0, err
+	}
+	nodes, err := fe.replicas(context.Background(), k)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(nodes) < storage.MinRedundancy {
+		return 0, storage.ErrNotEnoughDaemons
+	}
+
+	token, renewed := fe.renewLease(nodes, k, holder, ttl)
+	if token != 0 && token >= fence {
+		return token, nil
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			fe.conf.Sink.IncrCounter("frontend.lease.retries", 1)
+		}
+		last, err := fe.leaseFence(nodes, k)
+		if err == nil {
+			next := max(last+1, fence)
+			if err = fe.grantLease(nodes, k, holder, next, ttl); err == nil {
+				return next, nil
+			}
+		}
+		if err != storage.ErrFenceRejected || attempt+1 == leaseAttempts {
+			for _, node := range renewed {
+				go fe.conf.NC.ReleaseLease(node, k, holder)
+			}
+			return 0, err
+		}
+	}
+}
+
+// renewLease asks nodes to grant the lease with a zero token, so the ones
+// leasing the record to the holder extend the lease keeping its token.
+// Returns the token if storage.MinRedundancy of nodes granted it and none
+// granted a greater one, zero otherwise, and the nodes which granted
+// the lease.
+func (fe *Frontend) renewLease(nodes []storage.ServiceAddr, k storage.RecordID, holder uint64, ttl time.Duration) (uint64, []storage.ServiceAddr) {
+	type result struct {
+		node  storage.ServiceAddr
+		fence uint64
+		err   error
+	}
+	results := fanout.Collect(context.Background(), 0, nodes, func(_ int, node storage.ServiceAddr) result {
+		fence, err := fe.conf.NC.AcquireLease(node, k, holder, 0, ttl)
+		return result{node: node, fence: fence, err: err}
+	})
+
+	var (
+		granted []storage.ServiceAddr
+		token   uint64
+		count   int
+	)
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			continue
+		}
+		granted = append(granted, result.node)
+		switch {
+		case result.fence > token:
+			token, count = result.fence, 1
+		case result.fence == token:
+			count++
+		}
+	}
+	if count < storage.MinRedundancy {
+		return 0, granted
+	}
+	return token, granted
+}
+
+// LeaseFence returns the greatest fencing token of the leases on the record
+// with key k acquired so far.
+//
+// LeaseFence возвращает наибольший fencing token аренд записи с ключом k,
+// полученных до сих пор.
+func (fe *Frontend) LeaseFence(k storage.RecordID) (uint64, error) {
+	if err := fe.checkKey(k); err != nil {
+		return 0, err
+	}
+	nodes, err := fe.replicas(context.Background(), k)
+	if err != nil {
+		return 0, err
+	}
+	return fe.leaseFence(nodes, k)
+}
+
+// leaseFence returns the greatest fencing token granted by
+// storage.MinRedundancy of nodes.
+func (fe *Frontend) leaseFence(nodes []storage.ServiceAddr, k storage.RecordID) (uint64, error) {
+	type result struct {
+		fence uint64
+		err   error
+	}
+	results := fanout.Collect(context.Background(), 0, nodes, func(_ int, node storage.ServiceAddr) result {
+		fence, err := fe.conf.NC.LeaseFence(node, k)
+		return result{fence: fence, err: err}
+	})
+
+	var fence uint64
+	okCount := 0
+	errCounts := make(map[error]int)
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			errCounts[result.err]++
+			continue
+		}
+		okCount++
+		fence = max(fence, result.fence)
+	}
+	return fence, quorum(storage.MinRedundancy, okCount, errCounts)
+}
+
+// grantLease makes nodes grant the lease with the fencing token fence.
+// Returns storage.ErrLocked if the record is leased by another holder and
+// storage.ErrFenceRejected if a concurrent acquisition took the token.
+func (fe *Frontend) grantLease(nodes []storage.ServiceAddr, k storage.RecordID, holder, fence uint64, ttl time.Duration) error {
+	type result struct {
+		node storage.ServiceAddr
+		err  error
+	}
+	results := fanout.Collect(context.Background(), 0, nodes, func(_ int, node storage.ServiceAddr) result {
+		_, err := fe.conf.NC.AcquireLease(node, k, holder, fence, ttl)
+		return result{node: node, err: err}
+	})
+
+	var (
+		granted          []storage.ServiceAddr
+		locked, rejected bool
+	)
+	for range nodes {
+		result := <-results
+		switch result.err {
+		case nil:
+			granted = append(granted, result.node)
+		case storage.ErrLocked:
+			locked = true
+		case storage.ErrFenceRejected:
+			rejected = true
+		}
+	}
+
+	if len(granted) >= storage.MinRedundancy {
+		return nil
+	}
+
+	for _, node := range granted {
+		go fe.conf.NC.ReleaseLease(node, k, holder)
+	}
+	switch {
+	case locked:
+		return storage.ErrLocked
+	case rejected:
+		return storage.ErrFenceRejected
+	}
+	return storage.ErrQuorumNotReached
+}
+
+// ReleaseLease releases the lease on the record with key k held by the holder.
+//
+// ReleaseLease освобождает аренду записи с ключом k, принадлежащую holder.
+func (fe *Frontend) ReleaseLease(k storage.RecordID, holder uint64) error {
+	return fe.applyPutDel(context.Background(), k, func(node storage.ServiceAddr) error {
+		return fe.conf.NC.ReleaseLease(node, k, holder)
+	})
+}
+
+// Lock acquires a lease on the record with key k for ttl. The returned token
+// should be used to Unlock the record, its Fence can be used as a fencing
+// token. Returns the storage.ErrLocked error if the record is already locked.
+//
+// Lock получает аренду записи с ключом k на время ttl. Возвращаемый token
+// используется в Unlock, его Fence может использоваться как fencing token.
+// Возвращает ошибку storage.ErrLocked, если запись уже заблокирована.
+func (fe *Frontend) Lock(k storage.RecordID, ttl time.Duration) (storage.LockToken, error) {
+	holder, err := newHolder()
+	if err != nil {
+		return storage.LockToken{}, err
+	}
+	fence, err := fe.AcquireLease(k, holder, 0, ttl)
+	if err != nil {
+		return storage.LockToken{}, err
+	}
+	return storage.LockToken{Holder: holder, Fence: fence}, nil
+}
+
+// Unlock releases the lock on the record with key k acquired by Lock.
+//
+// Unlock освобождает блокировку записи с ключом k, полученную с помощью Lock.
+func (fe *Frontend) Unlock(k storage.RecordID, token storage.LockToken) error {
+	return fe.ReleaseLease(k, token.Holder)
+}
+
+func newHolder() (uint64, error) {
+	buf := make([]byte, 8)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return 0, err
+		}
+		if holder := binary.LittleEndian.Uint64(buf); holder != 0 {
+			return holder, nil
+		}
+	}
+}
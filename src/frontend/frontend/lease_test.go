@@ -0,0 +1,235 @@
+package frontend
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"node/node"
+	"storage"
+)
+
+// nodesClient dispatches requests to in-memory nodes.
+type nodesClient struct {
+	MockNode
+	nodes map[storage.ServiceAddr]*node.Node
+}
+
+func (c *nodesClient) AcquireLease(addr storage.ServiceAddr, k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	return c.nodes[addr].AcquireLease(k, holder, fence, ttl)
+}
+
+func (c *nodesClient) LeaseFence(addr storage.ServiceAddr, k storage.RecordID) (uint64, error) {
+	return c.nodes[addr].LeaseFence(k)
+}
+
+func (c *nodesClient) Sequence(addr storage.ServiceAddr, name string, floor uint64) (uint64, error) {
+	return c.nodes[addr].Sequence(name, floor)
+}
+
+func (c *nodesClient) ReleaseLease(addr storage.ServiceAddr, k storage.RecordID, holder uint64) error {
+	return c.nodes[addr].ReleaseLease(k, holder)
+}
+
+func (c *nodesClient) MGet(addr storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+	data, errs := c.nodes[addr].MGet(keys)
+	return data, errs, nil
+}
+
+func (c *nodesClient) MPut(addr storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+	return c.nodes[addr].MPut(keys, data), nil
+}
+
+func (c *nodesClient) MDel(addr storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+	return c.nodes[addr].MDel(keys), nil
+}
+
+func (c *nodesClient) Delta(addr storage.ServiceAddr, since uint64, limit int) (storage.Delta, error) {
+	return c.nodes[addr].Delta(since, limit)
+}
+
+func (c *nodesClient) Checksum(addr storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	return c.nodes[addr].Checksum(k, h)
+}
+
+func (c *nodesClient) Paxos(addr storage.ServiceAddr, k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error) {
+	return c.nodes[addr].Paxos(k, phase, p)
+}
+
+func (c *nodesClient) AcquireReadLease(addr storage.ServiceAddr, k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error) {
+	return c.nodes[addr].AcquireReadLease(k, holder, ttl)
+}
+
+func (c *nodesClient) Reserve(addr storage.ServiceAddr, k storage.RecordID, size int64, ttl time.Duration) error {
+	return c.nodes[addr].Reserve(k, size, ttl)
+}
+
+func (c *nodesClient) CancelReservation(addr storage.ServiceAddr, k storage.RecordID) error {
+	return c.nodes[addr].CancelReservation(k)
+}
+
+func (c *nodesClient) Put(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return c.nodes[addr].Put(k, d)
+}
+
+func (c *nodesClient) Get(addr storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	return c.nodes[addr].Get(k)
+}
+
+func (c *nodesClient) Del(addr storage.ServiceAddr, k storage.RecordID) error {
+	return c.nodes[addr].Del(k)
+}
+
+func (c *nodesClient) Merge(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return c.nodes[addr].Merge(k, d)
+}
+
+func (c *nodesClient) Snapshot(addr storage.ServiceAddr, id uint64, phase storage.SnapshotPhase, ttl time.Duration) error {
+	return c.nodes[addr].Snapshot(id, phase, ttl)
+}
+
+func (c *nodesClient) ScanSnapshot(addr storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return c.nodes[addr].ScanSnapshot(id, cursor, limit)
+}
+
+func TestLock(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+
+	fe1 := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+	fe2 := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+
+	token, err := fe1.Lock(key, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() error: %v", err)
+	}
+	if _, err := fe2.Lock(key, time.Minute); err != storage.ErrLocked {
+		t.Fatalf("Lock() got error %v, want %v", err, storage.ErrLocked)
+	}
+	if err := fe2.Unlock(key, storage.LockToken{Holder: token.Holder + 1}); err != storage.ErrNotLockHolder {
+		t.Fatalf("Unlock() got error %v, want %v", err, storage.ErrNotLockHolder)
+	}
+	if err := fe1.Unlock(key, token); err != nil {
+		t.Fatalf("Unlock() error: %v", err)
+	}
+
+	next, err := fe2.Lock(key, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() error: %v", err)
+	}
+	if next.Fence <= token.Fence {
+		t.Errorf("Fencing token didn't increase: got %v, previous %v", next.Fence, token.Fence)
+	}
+}
+
+func TestAcquireLease_Renew(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+
+	token, err := fe.AcquireLease(key, 1, 0, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLease() error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := fe.AcquireLease(key, 1, 0, time.Minute); err != nil || got != token {
+			t.Errorf("AcquireLease() renewal got %v, %v, want %v", got, err, token)
+		}
+	}
+	if _, err := fe.AcquireLease(key, 2, 0, time.Minute); err != storage.ErrLocked {
+		t.Errorf("AcquireLease() by another holder got error %v, want %v", err, storage.ErrLocked)
+	}
+
+	// A renewal asking for a greater token gets it.
+	if got, err := fe.AcquireLease(key, 1, token+10, time.Minute); err != nil || got != token+10 {
+		t.Errorf("AcquireLease() renewal with fence %v got %v, %v", token+10, got, err)
+	}
+	if err := fe.ReleaseLease(key, 1); err != nil {
+		t.Fatalf("ReleaseLease() error: %v", err)
+	}
+	if got, err := fe.AcquireLease(key, 2, 0, time.Minute); err != nil || got <= token+10 {
+		t.Errorf("AcquireLease() after release got %v, %v, want more than %v", got, err, token+10)
+	}
+}
+
+func TestLock_Exclusive(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		holders int
+	)
+	const n = 10
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+			if _, err := fe.Lock(key, time.Minute); err == nil {
+				lock.Lock()
+				holders++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if holders > 1 {
+		t.Errorf("Lock() acquired by %d holders simultaneously", holders)
+	}
+}
+
+func TestLock_DifferentQuorums(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	// Leases acquired and released on node1 alone push it ahead.
+	for holder := uint64(100); holder < 103; holder++ {
+		if _, err := nc.nodes[addrs[0]].AcquireLease(key, holder, 0, time.Minute); err != nil {
+			t.Fatalf("AcquireLease() error: %v", err)
+		}
+		if err := nc.nodes[addrs[0]].ReleaseLease(key, holder); err != nil {
+			t.Fatalf("ReleaseLease() error: %v", err)
+		}
+	}
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+
+	var last uint64
+	for _, quorum := range [][]storage.ServiceAddr{
+		{addrs[0], addrs[2]},
+		{addrs[1], addrs[2]},
+		{addrs[0], addrs[1]},
+	} {
+		rc.nodesFind = nodesFind(t, cfg, key, quorum, nil)
+		token, err := fe.Lock(key, time.Minute)
+		if err != nil {
+			t.Fatalf("Lock() on %v error: %v", quorum, err)
+		}
+		if token.Fence <= last {
+			t.Errorf("Lock() on %v got fence %v, previous %v", quorum, token.Fence, last)
+		}
+		last = token.Fence
+		if err := fe.Unlock(key, token); err != nil {
+			t.Fatalf("Unlock() error: %v", err)
+		}
+	}
+}
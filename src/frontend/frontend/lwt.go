@@ -0,0 +1,250 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// LWTRetries is a default number of Paxos rounds a lightweight transaction
+// retries after its ballot is rejected by a concurrent one.
+//
+// LWTRetries -- количество раундов Paxos по умолчанию, которые повторяет
+// легковесная транзакция после отклонения ее ballot конкурирующей.
+const LWTRetries = 10
+
+// LWTBackoff is a delay before the first retry of a lightweight transaction,
+// it grows linearly with the following retries.
+//
+// LWTBackoff -- задержка перед первым повтором легковесной транзакции,
+// линейно растущая с последующими повторами.
+const LWTBackoff = 10 * time.Millisecond
+
+// PutIfAbsent puts d for the key k if there is no record for it, like Put,
+// but as a lightweight transaction: a Paxos round on the replicas of k
+// makes it linearizable with other lightweight transactions of k, even
+// from other frontends. Returns the storage.ErrRecordExists error if
+// the record exists and an error wrapping storage.ErrQuorumNotReached
+// if the outcome is unknown, as the value may be committed later.
+// Guarantees hold only if all writes of k are made by lightweight
+// transactions.
+//
+// PutIfAbsent -- добавить d для ключа k, если записи для него нет, как Put,
+// но легковесной транзакцией: раунд Paxos на репликах k делает ее
+// линеаризуемой с другими легковесными транзакциями k, в том числе
+// от других frontend. Возвращает ошибку storage.ErrRecordExists, если
+// запись существует, и ошибку, оборачивающую storage.ErrQuorumNotReached,
+// если результат неизвестен, так как значение может быть зафиксировано
+// позже. Гарантии выполняются, только если все записи k делаются
+// легковесными транзакциями.
+func (fe *Frontend) PutIfAbsent(k storage.RecordID, d []byte) error {
+	if err := fe.checkValue(k, d); err != nil {
+		return err
+	}
+	err := fe.lwt(k, func(cur []byte, found bool) (storage.Proposal, error) {
+		if found {
+			return storage.Proposal{}, storage.ErrRecordExists
+		}
+		return storage.Proposal{Data: d}, nil
+	})
+	if err == nil {
+		fe.notify("put", k, d)
+	}
+	return err
+}
+
+// CompareAndSwap replaces the value of the record with key k with d if it
+// is old as a lightweight transaction like PutIfAbsent. Returns
+// the storage.ErrConditionFailed error if the value differs and
+// the storage.ErrRecordNotFound error if there is no record.
+//
+// CompareAndSwap заменяет значение записи с ключом k на d, если оно равно
+// old, легковесной транзакцией, как PutIfAbsent. Возвращает ошибку
+// storage.ErrConditionFailed, если значение отличается, и ошибку
+// storage.ErrRecordNotFound, если записи нет.
+func (fe *Frontend) CompareAndSwap(k storage.RecordID, old, d []byte) error {
+	if err := fe.checkValue(k, d); err != nil {
+		return err
+	}
+	err := fe.lwt(k, func(cur []byte, found bool) (storage.Proposal, error) {
+		if !found {
+			return storage.Proposal{}, storage.ErrRecordNotFound
+		}
+		if !bytes.Equal(cur, old) {
+			return storage.Proposal{}, storage.ErrConditionFailed
+		}
+		return storage.Proposal{Data: d}, nil
+	})
+	if err == nil {
+		fe.notify("put", k, d)
+	}
+	return err
+}
+
+// Paxos always returns the storage.ErrBallotRejected error, as frontends
+// don't take part in Paxos rounds. Use PutIfAbsent or CompareAndSwap.
+//
+// Paxos всегда возвращает ошибку storage.ErrBallotRejected, так как frontend
+// не участвуют в раундах Paxos. Используйте PutIfAbsent или CompareAndSwap.
+func (fe *Frontend) Paxos(k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error) {
+	return storage.Promise{}, storage.ErrBallotRejected
+}
+
+// lwt runs Paxos rounds on the replicas of k until the value returned by
+// apply for the current value of the record is committed, a round fails
+// for another reason than a concurrent ballot or the retries are over.
+func (fe *Frontend) lwt(k storage.RecordID, apply func(cur []byte, found bool) (storage.Proposal, error)) error {
+	start := fe.conf.Clock.Now()
+	defer func() {
+		fe.conf.Sink.ObserveDuration("frontend.lwt", fe.conf.Clock.Now().Sub(start))
+	}()
+	if err := fe.allow(1); err != nil {
+		return err
+	}
+	if err := fe.checkKey(k); err != nil {
+		return err
+	}
+	nodes, err := fe.replicas(context.Background(), k)
+	if err != nil {
+		return err
+	}
+	if len(nodes) < storage.MinRedundancy {
+		return storage.ErrNotEnoughDaemons
+	}
+	fe.dropReadLease(k)
+
+	var seen uint64
+	for attempt := 0; attempt <= fe.conf.LWTRetries; attempt++ {
+		if attempt > 0 {
+			fe.conf.Sink.IncrCounter("frontend.lwt.retries", 1)
+			time.Sleep(jitter(time.Duration(attempt) * LWTBackoff))
+		}
+		ballot := fe.nextBallot(seen)
+		promises, promised, err := fe.paxosPhase(nodes, k, storage.PaxosPrepare, storage.Proposal{Ballot: ballot})
+		if err == storage.ErrBallotRejected {
+			seen = promised
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// The value is taken from the replica with the latest commit,
+		// a proposal accepted after it is finished first.
+		var current storage.Promise
+		var inProgress storage.Proposal
+		for _, p := range promises {
+			if p.Committed >= current.Committed {
+				current = p
+			}
+			if p.Accepted.Ballot > inProgress.Ballot {
+				inProgress = p.Accepted
+			}
+		}
+		if inProgress.Ballot > current.Committed {
+			fe.conf.Sink.IncrCounter("frontend.lwt.repairs", 1)
+			inProgress.Ballot = ballot
+			if seen, err = fe.propose(nodes, k, inProgress); err != nil && err != storage.ErrBallotRejected && !errors.Is(err, storage.ErrQuorumNotReached) {
+				return err
+			}
+			continue
+		}
+
+		p, err := apply(current.Value, current.Found)
+		if err != nil {
+			return err
+		}
+		p.Ballot = ballot
+		if seen, err = fe.propose(nodes, k, p); err != storage.ErrBallotRejected {
+			return err
+		}
+	}
+	fe.conf.Sink.IncrCounter("frontend.lwt.contended", 1)
+	return fmt.Errorf("%w: %v retries are over", storage.ErrBallotRejected, fe.conf.LWTRetries)
+}
+
+// propose makes the replicas accept and commit p. Returns the highest
+// ballot promised by the replicas if p is rejected by all of them.
+// If only some accepted it, p may still be committed by a later round
+// finishing it, so the outcome is unknown and it is not retried.
+func (fe *Frontend) propose(nodes []storage.ServiceAddr, k storage.RecordID, p storage.Proposal) (uint64, error) {
+	accepted, promised, err := fe.paxosPhase(nodes, k, storage.PaxosAccept, p)
+	if err == storage.ErrBallotRejected && len(accepted) > 0 {
+		return 0, fmt.Errorf("%w: accepted by %v of %v replicas, the value may still be committed", storage.ErrQuorumNotReached, len(accepted), len(nodes))
+	}
+	if err != nil {
+		return promised, err
+	}
+	_, promised, err = fe.paxosPhase(nodes, k, storage.PaxosCommit, p)
+	return promised, err
+}
+
+// paxosPhase runs the phase on all nodes and returns the promises of
+// the nodes which accepted p. Returns the storage.ErrBallotRejected error
+// and the highest promised ballot if the majority didn't accept p because
+// of concurrent ballots.
+func (fe *Frontend) paxosPhase(nodes []storage.ServiceAddr, k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) ([]storage.Promise, uint64, error) {
+	type result struct {
+		promise storage.Promise
+		err     error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			promise, err := fe.conf.NC.Paxos(node, k, phase, p)
+			results <- result{promise: promise, err: err}
+		}(node)
+	}
+
+	var promises []storage.Promise
+	var promised uint64
+	var rejected bool
+	var lastErr error
+	for range nodes {
+		r := <-results
+		switch {
+		case r.err == nil:
+			promises = append(promises, r.promise)
+		case r.err == storage.ErrBallotRejected:
+			rejected = true
+			if r.promise.Promised > promised {
+				promised = r.promise.Promised
+			}
+		default:
+			lastErr = r.err
+		}
+	}
+	switch {
+	case len(promises) > len(nodes)/2:
+		return promises, 0, nil
+	case rejected:
+		return promises, promised, storage.ErrBallotRejected
+	case lastErr != nil:
+		return promises, 0, fmt.Errorf("%w: %v", storage.ErrQuorumNotReached, lastErr)
+	}
+	return promises, 0, storage.ErrQuorumNotReached
+}
+
+// nextBallot returns a ballot higher than seen and than all ballots
+// returned before. Ballots are based on the time, so they grow across
+// frontends and restarts.
+func (fe *Frontend) nextBallot(seen uint64) uint64 {
+	for {
+		last := atomic.LoadUint64(&fe.ballot)
+		b := uint64(fe.conf.Clock.Now().UnixNano())
+		if b <= last {
+			b = last + 1
+		}
+		if b <= seen {
+			b = seen + 1
+		}
+		if atomic.CompareAndSwapUint64(&fe.ballot, last, b) {
+			return b
+		}
+	}
+}
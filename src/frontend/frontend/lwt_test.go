@@ -0,0 +1,82 @@
+package frontend
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"node/node"
+	"storage"
+)
+
+func TestLWT(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{PaxosRecovery: -1})
+	}
+	rc.nodesFind = nodesFind(t, cfg, key, addrs, nil)
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+
+	if err := fe.CompareAndSwap(key, []byte("0"), []byte("1")); err != storage.ErrRecordNotFound {
+		t.Errorf("CompareAndSwap() of missing record got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if err := fe.PutIfAbsent(key, []byte("0")); err != nil {
+		t.Fatalf("PutIfAbsent() error: %v", err)
+	}
+	if err := fe.PutIfAbsent(key, []byte("1")); err != storage.ErrRecordExists {
+		t.Errorf("PutIfAbsent() of existing record got error %v, want %v", err, storage.ErrRecordExists)
+	}
+	if err := fe.CompareAndSwap(key, []byte("1"), []byte("2")); err != storage.ErrConditionFailed {
+		t.Errorf("CompareAndSwap() with wrong value got error %v, want %v", err, storage.ErrConditionFailed)
+	}
+	for addr, n := range nc.nodes {
+		if d, err := n.Get(key); err != nil || string(d) != "0" {
+			t.Errorf("Get() from %v got %q, %v, want %q", addr, d, err, "0")
+		}
+	}
+
+	// Concurrent increments from several frontends are linearizable.
+	// Increments with unknown outcome may or may not be applied.
+	const workers, increments = 4, 5
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	unknown := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router, LWTRetries: 100})
+			for done := 0; done < increments; {
+				d, err := nc.nodes[addrs[0]].Get(key)
+				if err != nil {
+					t.Errorf("Get() error: %v", err)
+					return
+				}
+				v, _ := strconv.Atoi(string(d))
+				err = fe.CompareAndSwap(key, d, []byte(strconv.Itoa(v+1)))
+				switch {
+				case err == nil:
+					done++
+				case errors.Is(err, storage.ErrQuorumNotReached):
+					lock.Lock()
+					unknown++
+					lock.Unlock()
+				case !errors.Is(err, storage.ErrConditionFailed) && !errors.Is(err, storage.ErrBallotRejected):
+					t.Errorf("CompareAndSwap() error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for addr, n := range nc.nodes {
+		d, err := n.Get(key)
+		if v, _ := strconv.Atoi(string(d)); err != nil || v < workers*increments || v > workers*increments+unknown {
+			t.Errorf("Get() from %v after %v increments, %v unknown, got %q, %v", addr, workers*increments, unknown, d, err)
+		}
+	}
+}
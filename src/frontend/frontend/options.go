@@ -0,0 +1,114 @@
+package frontend
+
+import (
+	"log"
+
+	"events"
+	"metrics"
+	rclient "router/client"
+	"router/router"
+	"storage"
+)
+
+// Option configures a Frontend created by New.
+//
+// Option настраивает Frontend, создаваемый New.
+type Option func(cfg *Config)
+
+// WithLogger makes the frontend log with l.
+//
+// WithLogger -- frontend пишет журнал в l.
+func WithLogger(l *log.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = l
+	}
+}
+
+// WithMetrics makes the frontend report metrics to sink.
+//
+// WithMetrics -- frontend отправляет метрики в sink.
+func WithMetrics(sink metrics.Sink) Option {
+	return func(cfg *Config) {
+		cfg.Sink = sink
+	}
+}
+
+// WithClock makes the frontend tell time with c.
+//
+// WithClock -- frontend определяет время по часам c.
+func WithClock(c storage.Clock) Option {
+	return func(cfg *Config) {
+		cfg.Clock = c
+	}
+}
+
+// WithClients makes the frontend talk to nodes with nc and to Router
+// at addr with rc.
+//
+// WithClients -- frontend обращается к node с помощью nc и к Router
+// по адресу addr с помощью rc.
+func WithClients(addr storage.ServiceAddr, nc storage.Client, rc rclient.Client) Option {
+	return func(cfg *Config) {
+		cfg.Router = addr
+		cfg.NC = nc
+		cfg.RC = rc
+	}
+}
+
+// WithNodesFinder makes the frontend find nodes with nf.
+//
+// WithNodesFinder -- frontend находит nodes с помощью nf.
+func WithNodesFinder(nf router.NodesFinder) Option {
+	return func(cfg *Config) {
+		cfg.NF = nf
+	}
+}
+
+// WithResolver makes the frontend merge divergent values of records with r
+// unless their namespace has another resolver.
+//
+// WithResolver -- frontend объединяет различающиеся значения записей
+// с помощью r, если для их пространства ключей не задана другая функция.
+func WithResolver(r Resolver) Option {
+	return WithNamespaceResolver(storage.Prefix{}, r)
+}
+
+// WithNamespaceResolver makes the frontend merge divergent values of records
+// with keys having prefix p with r.
+//
+// WithNamespaceResolver -- frontend объединяет различающиеся значения
+// записей с ключами, имеющими префикс p, с помощью r.
+func WithNamespaceResolver(p storage.Prefix, r Resolver) Option {
+	return func(cfg *Config) {
+		cfg.Resolvers = append(cfg.Resolvers, NamespaceResolver{Prefix: p, Resolve: r})
+	}
+}
+
+// WithRecorder makes the frontend record all served operations with r.
+//
+// WithRecorder -- frontend записывает все обслуженные операции с помощью r.
+func WithRecorder(r *Recorder) Option {
+	return func(cfg *Config) {
+		cfg.Recorder = r
+	}
+}
+
+// WithValidator makes the frontend check values of records with keys having
+// prefix p with fn before writing them.
+//
+// WithValidator -- frontend проверяет значения записей с ключами, имеющими
+// префикс p, с помощью fn перед их записью.
+func WithValidator(p storage.Prefix, fn ValidateFunc) Option {
+	return func(cfg *Config) {
+		cfg.Validators = append(cfg.Validators, Validator{Prefix: p, Validate: fn})
+	}
+}
+
+// WithEvents makes the frontend publish events to bus.
+//
+// WithEvents -- frontend публикует события в bus.
+func WithEvents(bus *events.Bus) Option {
+	return func(cfg *Config) {
+		cfg.Bus = bus
+	}
+}
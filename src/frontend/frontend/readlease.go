@@ -0,0 +1,187 @@
+package frontend
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"storage"
+)
+
+// ReadLeaseSafety is the part of cfg.ReadLease cut off the end of a lease
+// by the frontend, so it stops serving reads before replicas may apply
+// writes again even if their clocks run a bit faster.
+//
+// ReadLeaseSafety -- доля cfg.ReadLease, отрезаемая frontend от конца
+// аренды, чтобы он прекращал обслуживать чтения раньше, чем реплики
+// снова могут применять записи, даже если их часы немного спешат.
+const ReadLeaseSafety = 10
+
+// readLeaseSweep is the least number of read leases swept.
+const readLeaseSweep = 64
+
+// errNoReadLease is returned by getLeased if a read lease can't be used
+// and Get should be served by a quorum.
+var errNoReadLease = errors.New("no read lease")
+
+// readLease is a read lease on a record held by the frontend on nodes,
+// granted with the topology top. Every lease has its own holder, so
+// a late release of an old lease doesn't cancel a new one.
+type readLease struct {
+	holder  uint64
+	nodes   []storage.ServiceAddr
+	top     *topology
+	expires time.Time
+}
+
+// AcquireReadLease always returns the storage.ErrLocked error, as frontends
+// don't grant read leases, they take them from replicas if cfg.ReadLease
+// is set.
+//
+// AcquireReadLease всегда возвращает ошибку storage.ErrLocked, так как
+// frontend не выдают аренды чтения, а получают их от реплик, если задан
+// cfg.ReadLease.
+func (fe *Frontend) AcquireReadLease(k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error) {
+	return storage.ReadLease{}, storage.ErrLocked
+}
+
+// getLeased serves a Get of the record with key k from a single replica
+// if the frontend holds a read lease on k, taking the lease first if it
+// doesn't. Returns errNoReadLease if the lease can't be taken.
+func (fe *Frontend) getLeased(k storage.RecordID) ([]byte, error) {
+	now := fe.conf.Clock.Now()
+	top := fe.topology.Load()
+	if top == nil || top.stale {
+		return nil, errNoReadLease
+	}
+
+	fe.readLeaseLock.Lock()
+	l, ok := fe.readLeases[k]
+	if ok && (l.top != top || !now.Before(l.expires)) {
+		delete(fe.readLeases, k)
+		ok = false
+	}
+	fe.readLeaseLock.Unlock()
+
+	if !ok {
+		return fe.takeReadLease(k, top, now)
+	}
+	// Replicas holding the lease have the latest value and apply no
+	// writes until it expires, so any of them is as good as a quorum.
+	for _, node := range l.nodes {
+		d, err := fe.conf.NC.Get(node, k)
+		if err == nil || err == storage.ErrRecordNotFound {
+			fe.conf.Sink.IncrCounter("frontend.read_lease.hits", 1)
+			return d, err
+		}
+	}
+	fe.dropReadLease(k)
+	return nil, errNoReadLease
+}
+
+// takeReadLease takes a read lease on the record with key k from its
+// replicas in top and returns the value of the record. The lease is taken
+// only if the replicas granting it agree on the value and intersect every
+// write quorum, so none of the writes acknowledged before is missed and
+// no write can be acknowledged while the lease is held.
+func (fe *Frontend) takeReadLease(k storage.RecordID, top *topology, start time.Time) ([]byte, error) {
+	fe.conf.Sink.IncrCounter("frontend.read_lease.misses", 1)
+	nodes := fe.conf.NF.NodesFind(k, top.nodes)
+	holder := fe.readHolder.Add(1)
+
+	type result struct {
+		node  storage.ServiceAddr
+		lease storage.ReadLease
+		err   error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			lease, err := fe.conf.NC.AcquireReadLease(node, k, holder, fe.conf.ReadLease)
+			results <- result{node: node, lease: lease, err: err}
+		}(node)
+	}
+
+	var (
+		granted []storage.ServiceAddr
+		value   storage.ReadLease
+		agree   = true
+	)
+	for range nodes {
+		r := <-results
+		if r.err != nil {
+			continue
+		}
+		if len(granted) > 0 && (r.lease.Found != value.Found || !bytes.Equal(r.lease.Value, value.Value)) {
+			agree = false
+		}
+		granted = append(granted, r.node)
+		value = r.lease
+	}
+
+	need := len(nodes) - storage.MinRedundancy + 1
+	if need < storage.MinRedundancy {
+		need = storage.MinRedundancy
+	}
+	if !agree || len(granted) < need {
+		fe.releaseReadLease(k, holder, granted)
+		return nil, errNoReadLease
+	}
+
+	fe.readLeaseLock.Lock()
+	fe.readLeases[k] = readLease{
+		holder:  holder,
+		nodes:   granted,
+		top:     top,
+		expires: start.Add(fe.conf.ReadLease - fe.conf.ReadLease/ReadLeaseSafety),
+	}
+	fe.sweepReadLeases(start)
+	fe.readLeaseLock.Unlock()
+
+	if !value.Found {
+		return nil, storage.ErrRecordNotFound
+	}
+	return value.Value, nil
+}
+
+// sweepReadLeases drops leases expired by now once their number doubles,
+// so leases of records read once don't pile up. Should be called with
+// fe.readLeaseLock held.
+func (fe *Frontend) sweepReadLeases(now time.Time) {
+	if len(fe.readLeases) < fe.readLeaseSweep {
+		return
+	}
+	for k, l := range fe.readLeases {
+		if !now.Before(l.expires) {
+			delete(fe.readLeases, k)
+		}
+	}
+	fe.readLeaseSweep = 2 * len(fe.readLeases)
+	if fe.readLeaseSweep < readLeaseSweep {
+		fe.readLeaseSweep = readLeaseSweep
+	}
+}
+
+// dropReadLease stops serving reads of the record with key k under a read
+// lease and releases it on replicas, so writes of k made by the frontend
+// don't wait for it.
+func (fe *Frontend) dropReadLease(k storage.RecordID) {
+	if fe.conf.ReadLease == 0 {
+		return
+	}
+	fe.readLeaseLock.Lock()
+	l, ok := fe.readLeases[k]
+	delete(fe.readLeases, k)
+	fe.readLeaseLock.Unlock()
+	if ok {
+		fe.releaseReadLease(k, l.holder, l.nodes)
+	}
+}
+
+// releaseReadLease releases the read lease on k held by the holder on nodes
+// in background.
+func (fe *Frontend) releaseReadLease(k storage.RecordID, holder uint64, nodes []storage.ServiceAddr) {
+	for _, node := range nodes {
+		go fe.conf.NC.AcquireReadLease(node, k, holder, 0)
+	}
+}
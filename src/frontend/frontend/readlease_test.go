@@ -0,0 +1,79 @@
+package frontend
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"node/node"
+	"storage"
+)
+
+// countingClient counts Gets made to nodes.
+type countingClient struct {
+	*nodesClient
+	gets int32
+}
+
+func (c *countingClient) Get(addr storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	atomic.AddInt32(&c.gets, 1)
+	return c.nodesClient.Get(addr, k)
+}
+
+func TestReadLease(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &countingClient{nodesClient: &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc := &MockRouter{
+		list: func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+			return addrs, nil
+		},
+		nodesFind: func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+			return addrs, nil
+		},
+	}
+	const ttl = 200 * time.Millisecond
+	leased := New(Config{RC: rc, NC: nc, Router: "router", ReadLease: ttl})
+	writer := New(Config{RC: rc, NC: nc, Router: "router"})
+
+	if err := writer.Put(key, []byte("old")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if d, err := leased.Get(key); err != nil || string(d) != "old" {
+		t.Fatalf("Get() taking a lease got %q, %v, want %q", d, err, "old")
+	}
+	atomic.StoreInt32(&nc.gets, 0)
+	if d, err := leased.Get(key); err != nil || string(d) != "old" {
+		t.Fatalf("Get() under a lease got %q, %v, want %q", d, err, "old")
+	}
+	if gets := atomic.LoadInt32(&nc.gets); gets != 1 {
+		t.Errorf("Get() under a lease asked %d replicas, want 1", gets)
+	}
+
+	// A write of another frontend waits for the lease on replicas.
+	start := time.Now()
+	if err := writer.Del(key); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < ttl/2 {
+		t.Errorf("Del() of a leased record took %v, want it to wait for the lease", elapsed)
+	}
+	if _, err := leased.Get(key); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after Del() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	// A write of the holder releases its lease instead of waiting for it.
+	start = time.Now()
+	if err := leased.Put(key, []byte("new")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= ttl/2 {
+		t.Errorf("Put() of the lease holder took %v, want it not to wait", elapsed)
+	}
+	if d, err := leased.Get(key); err != nil || string(d) != "new" {
+		t.Errorf("Get() after Put() got %q, %v, want %q", d, err, "new")
+	}
+}
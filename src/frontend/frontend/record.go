@@ -0,0 +1,89 @@
+package frontend
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"storage"
+)
+
+// Operation describes an operation served by Frontend.
+//
+// Operation описывает операцию, обслуженную Frontend.
+type Operation struct {
+	// At is a time passed since the start of recording.
+	// At -- время, прошедшее с начала записи.
+	At time.Duration `json:"at"`
+	// Op is a name of the operation: put, get, del, update or upsert.
+	// Op -- имя операции: put, get, del, update или upsert.
+	Op string `json:"op"`
+	// Key is a key of the record.
+	// Key -- ключ записи.
+	Key storage.RecordID `json:"key"`
+	// Size is a size of the record data put or got.
+	// Size -- размер данных записи.
+	Size int `json:"size"`
+	// Latency is a time taken by the operation.
+	// Latency -- время выполнения операции.
+	Latency time.Duration `json:"latency"`
+	// Error is an error returned by the operation if any.
+	// Error -- ошибка, которую вернула операция.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder writes operations served by Frontend as JSON lines.
+//
+// Recorder записывает операции, обслуженные Frontend, в виде строк JSON.
+type Recorder struct {
+	lock  sync.Mutex
+	start time.Time
+	w     io.Writer
+	enc   *json.Encoder
+}
+
+// NewRecorder creates a new Recorder writing to w.
+//
+// NewRecorder создает новый Recorder, пишущий в w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{start: time.Now(), w: w, enc: json.NewEncoder(w)}
+}
+
+// Record writes op started at start. Errors of writing are ignored
+// to not affect serving.
+//
+// Record записывает операцию op, начатую в момент start. Ошибки записи
+// игнорируются, чтобы не влиять на обслуживание запросов.
+func (r *Recorder) Record(op Operation, start time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	op.At = start.Sub(r.start)
+	r.enc.Encode(op)
+}
+
+// ReadOperations reads operations written by Recorder and sorts them by
+// At. Operations are written once they complete, so they are not ordered
+// by start in the file.
+//
+// ReadOperations читает операции, записанные Recorder, и сортирует их по
+// At. Операции записываются по завершении, поэтому в файле они не
+// упорядочены по времени начала.
+func ReadOperations(r io.Reader) ([]Operation, error) {
+	var ops []Operation
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		var op Operation
+		if err := json.Unmarshal(s.Bytes(), &op); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].At < ops[j].At })
+	return ops, nil
+}
@@ -0,0 +1,68 @@
+package frontend
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"storage"
+)
+
+func TestRecorder(t *testing.T) {
+	key := storage.RecordID(1)
+	testData := []byte("testtesttest")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc.put = put(t, nodes, key, testData, nil)
+	nc.del = del(t, nodes, key, func(storage.ServiceAddr) error {
+		return storage.ErrRecordNotFound
+	})
+
+	var buf bytes.Buffer
+	c := cfg
+	c.Recorder = NewRecorder(&buf)
+	fe := New(c)
+	fe.Put(key, testData)
+	fe.Del(key)
+
+	ops, err := ReadOperations(&buf)
+	if err != nil {
+		t.Fatalf("ReadOperations() error: %v", err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("Got %d operations, want 2", len(ops))
+	}
+	if op := ops[0]; op.Op != "put" || op.Key != key || op.Size != len(testData) || op.Error != "" {
+		t.Errorf("Wrong put operation recorded: %+v", op)
+	}
+	if op := ops[1]; op.Op != "del" || op.Key != key || op.Error != storage.ErrRecordNotFound.Error() {
+		t.Errorf("Wrong del operation recorded: %+v", op)
+	}
+	if ops[1].At < ops[0].At {
+		t.Errorf("Operations recorded out of order: %v < %v", ops[1].At, ops[0].At)
+	}
+}
+
+func TestReadOperations_Sorted(t *testing.T) {
+	// The second operation started first but took longer to complete.
+	r := strings.NewReader(`{"at":2000,"op":"get","key":1}
+{"at":1000,"op":"put","key":1,"latency":5000}
+{"at":2000,"op":"del","key":1}
+`)
+	ops, err := ReadOperations(r)
+	if err != nil {
+		t.Fatalf("ReadOperations() error: %v", err)
+	}
+	var got []string
+	for _, op := range ops {
+		got = append(got, op.Op)
+	}
+	if strings.Join(got, ",") != "put,get,del" {
+		t.Errorf("ReadOperations() got %v, want [put get del]", got)
+	}
+	if ops[0].At != time.Microsecond {
+		t.Errorf("ReadOperations() got At %v, want %v", ops[0].At, time.Microsecond)
+	}
+}
@@ -0,0 +1,62 @@
+package frontend
+
+import (
+	"context"
+	"time"
+
+	"storage"
+)
+
+// ReserveTTL is a default time Put reservations are kept by nodes for.
+//
+// ReserveTTL -- время по умолчанию, на которое node сохраняют
+// резервирования для Put.
+const ReserveTTL = 10 * time.Second
+
+// Reserve reserves size bytes of quotas of at least storage.MinRedundancy
+// replicas of the record with key k for ttl for a following Put. Partially
+// made reservations are cancelled on error.
+//
+// Reserve резервирует size байт квот как минимум storage.MinRedundancy реплик
+// записи с ключом k на время ttl для последующего Put. В случае ошибки
+// частично сделанные резервирования отменяются.
+func (fe *Frontend) Reserve(k storage.RecordID, size int64, ttl time.Duration) error {
+	return fe.reserve(context.Background(), k, size, ttl)
+}
+
+func (fe *Frontend) reserve(ctx context.Context, k storage.RecordID, size int64, ttl time.Duration) error {
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	err := fe.applyPutDel(ctx, k, func(node storage.ServiceAddr) error {
+		return nc.Reserve(node, k, size, ttl)
+	})
+	if err != nil {
+		fe.CancelReservation(k)
+	}
+	return err
+}
+
+// CancelReservation cancels reservations made for the record with key k.
+//
+// CancelReservation отменяет резервирования, сделанные для записи с ключом k.
+func (fe *Frontend) CancelReservation(k storage.RecordID) error {
+	return fe.applyPutDel(context.Background(), k, func(node storage.ServiceAddr) error {
+		return fe.conf.NC.CancelReservation(node, k)
+	})
+}
+
+// reservePut reserves space for the value d of the record with key k if
+// cfg.ReservePuts is set. The returned function cancels reservations left
+// by a failed Put.
+func (fe *Frontend) reservePut(ctx context.Context, k storage.RecordID, d []byte) (func(err error), error) {
+	if !fe.conf.ReservePuts {
+		return func(error) {}, nil
+	}
+	if err := fe.reserve(ctx, k, int64(len(d)), fe.conf.ReserveTTL); err != nil {
+		return nil, err
+	}
+	return func(err error) {
+		if err != nil {
+			fe.CancelReservation(k)
+		}
+	}, nil
+}
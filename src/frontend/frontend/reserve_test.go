@@ -0,0 +1,46 @@
+package frontend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"node/node"
+	"storage"
+)
+
+func TestReservePuts(t *testing.T) {
+	key := storage.RecordID(1)
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{MaxBytes: 10})
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return addrs, nil
+	}
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router, ReservePuts: true})
+
+	for _, addr := range addrs[:2] {
+		if err := nc.nodes[addr].Put(2, []byte("12345678")); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if err := fe.Put(key, []byte("1234")); !errors.Is(err, storage.ErrQuotaExceeded) {
+		t.Errorf("Put() over quota got error %v, want %v", err, storage.ErrQuotaExceeded)
+	}
+	// The reservation made on node3 is cancelled.
+	if err := nc.nodes[addrs[2]].Reserve(3, 10, time.Minute); err != nil {
+		t.Errorf("Reserve() after failed Put() error: %v", err)
+	}
+	nc.nodes[addrs[2]].CancelReservation(3)
+
+	if err := fe.Put(key, []byte("12")); err != nil {
+		t.Errorf("Put() error: %v", err)
+	}
+	for _, addr := range addrs {
+		if d, err := nc.nodes[addr].Get(key); err != nil || string(d) != "12" {
+			t.Errorf("Get() from %v got %q, %v, want %q", addr, d, err, "12")
+		}
+	}
+}
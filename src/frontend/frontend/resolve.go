@@ -0,0 +1,112 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"events"
+	"storage"
+	"storage/fanout"
+)
+
+// Resolver merges divergent values of the record with key k returned by
+// replicas into a single value.
+//
+// Resolver объединяет различающиеся значения записи с ключом k,
+// полученные от реплик, в одно значение.
+type Resolver func(k storage.RecordID, values [][]byte) ([]byte, error)
+
+// NamespaceResolver merges divergent values of records with keys having
+// Prefix.
+//
+// NamespaceResolver объединяет различающиеся значения записей с ключами,
+// имеющими префикс Prefix.
+type NamespaceResolver struct {
+	// Prefix is a namespace of keys resolved.
+	// Prefix -- пространство разрешаемых ключей.
+	Prefix storage.Prefix
+	// Resolve is a function merging values.
+	// Resolve -- функция, объединяющая значения.
+	Resolve Resolver
+}
+
+// resolver returns the Resolver of the longest namespace of cfg.Resolvers
+// having k or nil if there is none, like storage.Namespaces.Class.
+func (fe *Frontend) resolver(k storage.RecordID) Resolver {
+	var resolve Resolver
+	longest := -1
+	for _, r := range fe.conf.Resolvers {
+		if int(r.Prefix.Len) > longest && r.Prefix.Match(k) {
+			resolve, longest = r.Resolve, int(r.Prefix.Len)
+		}
+	}
+	return resolve
+}
+
+// resolve merges divergent replicas with resolve and writes the merged
+// value back to the replicas having a different one unless
+// cfg.ResolveDryRun is set.
+func (fe *Frontend) resolve(k storage.RecordID, resolve Resolver, replicas map[storage.ServiceAddr][]byte) ([]byte, error) {
+	set := make(map[string]bool)
+	values := make([][]byte, 0, len(replicas))
+	for _, data := range replicas {
+		if !set[string(data)] {
+			set[string(data)] = true
+			values = append(values, data)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return bytes.Compare(values[i], values[j]) < 0
+	})
+
+	merged, err := resolve(k, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if fe.conf.ResolveDryRun {
+		for node, data := range replicas {
+			if !bytes.Equal(data, merged) {
+				fe.conf.Logger.Printf("Dry run: would write back resolved record to %q, key = %v", node, k)
+			}
+		}
+		return merged, nil
+	}
+
+	defer fe.lockKey(k)()
+	var stale []storage.ServiceAddr
+	for node, data := range replicas {
+		if !bytes.Equal(data, merged) {
+			stale = append(stale, node)
+		}
+	}
+	var written, failed int32
+	fanout.Each(context.Background(), 0, stale, func(_ context.Context, node storage.ServiceAddr) error {
+		if fe.writeBack(node, k, merged) {
+			atomic.AddInt32(&written, 1)
+		} else {
+			atomic.AddInt32(&failed, 1)
+		}
+		return nil
+	})
+
+	fe.conf.Bus.Publish(events.Event{
+		Kind:   events.RepairCompleted,
+		Key:    events.KeyOf(k),
+		Reason: fmt.Sprintf("resolved value written back to %d replicas, %d failed", written, failed),
+	})
+	return merged, nil
+}
+
+// writeBack replaces the record with key k on node with d at once and
+// reports whether it succeeded.
+func (fe *Frontend) writeBack(node storage.ServiceAddr, k storage.RecordID, d []byte) bool {
+	if err := fe.conf.NC.Upsert(node, k, d); err != nil {
+		fe.conf.Logger.Printf("Failed to write back resolved record to %q, key = %v: %v", node, k, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package frontend
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"events"
+	"router/router"
+	"storage"
+)
+
+func TestGet_Resolver(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	values := map[storage.ServiceAddr][]byte{
+		nodes[0]: []byte("b"),
+		nodes[1]: []byte("a"),
+		nodes[2]: []byte("c"),
+	}
+	want := []byte("abc")
+
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	var lock sync.Mutex
+	written := make(map[storage.ServiceAddr][]byte)
+
+	nc := new(MockNode)
+	nc.get = get(t, nodes, key, func(node storage.ServiceAddr) ([]byte, error) {
+		return values[node], nil
+	})
+	nc.upsert = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+		lock.Lock()
+		defer lock.Unlock()
+		written[node] = d
+		return nil
+	}
+
+	repaired := make(chan events.Event, 1)
+	bus := events.NewBus("frontend", nil, nil)
+	bus.Subscribe(events.SubscriberFunc(func(e events.Event) {
+		if e.Kind == events.RepairCompleted {
+			repaired <- e
+		}
+	}))
+
+	fe := New(Config{
+		RC:     &rc,
+		NC:     nc,
+		NF:     router.NewNodesFinder(router.NewMD5Hasher()),
+		Router: "router",
+		Bus:    bus,
+		Resolvers: []NamespaceResolver{{Resolve: func(k storage.RecordID, values [][]byte) ([]byte, error) {
+			if k != key {
+				t.Errorf("Got %v, want %v", k, key)
+			}
+			return bytes.Join(values, nil), nil
+		}}},
+	})
+
+	got, err := fe.Get(key)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrong data: got %s, want %s", got, want)
+	}
+	for _, node := range nodes {
+		if !reflect.DeepEqual(written[node], want) {
+			t.Errorf("Wrong data written back to %v: got %s, want %s", node, written[node], want)
+		}
+	}
+	select {
+	case e := <-repaired:
+		if *e.Key != key || e.Reason != "resolved value written back to 3 replicas, 0 failed" {
+			t.Errorf("Got repair event %+v, want one of key %v written back to 3 replicas", e, key)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("No %v event", events.RepairCompleted)
+	}
+}
+
+func TestGet_ResolverDryRun(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	values := map[storage.ServiceAddr][]byte{
+		nodes[0]: []byte("a"),
+		nodes[1]: []byte("ab"),
+		nodes[2]: []byte("b"),
+	}
+
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	nc := new(MockNode)
+	nc.get = get(t, nodes, key, func(node storage.ServiceAddr) ([]byte, error) {
+		return values[node], nil
+	})
+	nc.upsert = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+		t.Errorf("Upsert() on %v in dry run", node)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	fe := New(Config{
+		RC:            &rc,
+		NC:            nc,
+		NF:            router.NewNodesFinder(router.NewMD5Hasher()),
+		Router:        "router",
+		ResolveDryRun: true,
+		Logger:        log.New(&buf, "", 0),
+		Resolvers: []NamespaceResolver{{Resolve: func(k storage.RecordID, values [][]byte) ([]byte, error) {
+			return []byte("ab"), nil
+		}}},
+	})
+
+	got, err := fe.Get(key)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if string(got) != "ab" {
+		t.Errorf("Wrong data: got %s, want %s", got, "ab")
+	}
+	for _, node := range nodes {
+		logged := strings.Contains(buf.String(), fmt.Sprintf("would write back resolved record to %q", node))
+		if want := node != nodes[1]; logged != want {
+			t.Errorf("Write back to %v logged %v, want %v, log:\n%s", node, logged, want, buf.String())
+		}
+	}
+}
+
+func TestGet_NamespaceResolver(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	nc := new(MockNode)
+	nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+		return []byte(node), nil
+	}
+	nc.upsert = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+		return nil
+	}
+
+	resolveTo := func(v string) Resolver {
+		return func(k storage.RecordID, values [][]byte) ([]byte, error) {
+			return []byte(v), nil
+		}
+	}
+	fe := New(Config{
+		RC:     &rc,
+		NC:     nc,
+		NF:     router.NewNodesFinder(router.NewMD5Hasher()),
+		Router: "router",
+		Resolvers: []NamespaceResolver{
+			{Prefix: storage.Prefix{Key: 0x10000000, Len: 4}, Resolve: resolveTo("outer")},
+			{Prefix: storage.Prefix{Key: 0x11000000, Len: 8}, Resolve: resolveTo("inner")},
+		},
+	})
+
+	// Nested namespaces override the resolvers of the enclosing ones,
+	// records of other namespaces are not resolved.
+	for k, want := range map[storage.RecordID]string{0x10000001: "outer", 0x11000001: "inner"} {
+		if got, err := fe.Get(k); err != nil || string(got) != want {
+			t.Errorf("Get(%#x) got %q, %v, want %q", k, got, err, want)
+		}
+	}
+	if _, err := fe.Get(0x20000001); err != storage.ErrQuorumNotReached {
+		t.Errorf("Get() outside namespaces got error %v, want %v", err, storage.ErrQuorumNotReached)
+	}
+}
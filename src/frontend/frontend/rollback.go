@@ -0,0 +1,93 @@
+package frontend
+
+import (
+	"context"
+	"sync"
+
+	"storage"
+	"storage/fanout"
+)
+
+// putReplicas puts d to every replica of the record with key k like
+// applyPutDel. If the put fails once all replicas answer, the copies
+// stored by the replicas acknowledging it are deleted, so they don't
+// linger and fail the next attempts with storage.ErrRecordExists.
+func (fe *Frontend) putReplicas(ctx context.Context, k storage.RecordID, d []byte) error {
+	var lock sync.Mutex
+	var acked []storage.ServiceAddr
+	ctx = fe.versioned(ctx)
+	nc := storage.WithContext(fe.conf.NC, ctx)
+	err := fe.applyPutDel(ctx, k, func(node storage.ServiceAddr) error {
+		err := nc.Put(node, k, d)
+		if err == nil {
+			lock.Lock()
+			acked = append(acked, node)
+			lock.Unlock()
+		}
+		return err
+	})
+	// Replicas may still be putting d if ctx is done, they aren't known.
+	if err != nil && ctx.Err() == nil && len(acked) > 0 {
+		fe.rollbackPut(storage.VersionOf(ctx), k, acked)
+	}
+	return err
+}
+
+// rollbackPut deletes the record with key k put as the version v from
+// nodes, logging failures. Nodes compare the version, so values written
+// by others since are kept. Records left behind are deleted by the next
+// successful Del or replaced by a resolver.
+func (fe *Frontend) rollbackPut(v uint64, k storage.RecordID, nodes []storage.ServiceAddr) {
+	fe.conf.Sink.IncrCounter("frontend.put.rollbacks", 1)
+	nc := storage.WithContext(fe.conf.NC, storage.WithVersion(context.Background(), v))
+	fanout.Each(context.Background(), 0, nodes, func(_ context.Context, node storage.ServiceAddr) error {
+		if err := nc.Del(node, k); !rolledBack(err) {
+			fe.conf.Logger.Printf("Failed to roll back a failed put on %q, key = %v: %v", node, k, err)
+		}
+		return nil
+	})
+}
+
+// rollbackBatch deletes the records of a batch put as the version v whose
+// errs are set from the nodes which acked them like rollbackPut does.
+// acked maps nodes to the indices of keys they put.
+func (fe *Frontend) rollbackBatch(v uint64, keys []storage.RecordID, errs []error, acked map[storage.ServiceAddr][]int) {
+	byNode := make(map[storage.ServiceAddr][]storage.RecordID)
+	for node, indices := range acked {
+		for _, i := range indices {
+			if errs[i] != nil {
+				byNode[node] = append(byNode[node], keys[i])
+			}
+		}
+	}
+	if len(byNode) == 0 {
+		return
+	}
+	nodes := make([]storage.ServiceAddr, 0, len(byNode))
+	for node := range byNode {
+		nodes = append(nodes, node)
+	}
+	fe.conf.Sink.IncrCounter("frontend.batch_put.rollbacks", 1)
+	nc := storage.WithContext(fe.conf.NC, storage.WithVersion(context.Background(), v))
+	fanout.Each(context.Background(), 0, nodes, func(_ context.Context, node storage.ServiceAddr) error {
+		nodeKeys := byNode[node]
+		errs, err := nc.MDel(node, nodeKeys)
+		for i, k := range nodeKeys {
+			keyErr := err
+			if keyErr == nil {
+				keyErr = errs[i]
+			}
+			if !rolledBack(keyErr) {
+				fe.conf.Logger.Printf("Failed to roll back a failed put on %q, key = %v: %v", node, k, keyErr)
+			}
+		}
+		return nil
+	})
+}
+
+// rolledBack tells if a rollback of a put failed with err leaves no value
+// of the put: the value is deleted, already gone or replaced by another
+// writer.
+func rolledBack(err error) bool {
+	return err == nil || err == storage.ErrRecordNotFound || err == storage.ErrConditionFailed
+}
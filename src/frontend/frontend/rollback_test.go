@@ -0,0 +1,108 @@
+package frontend
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"storage"
+)
+
+func TestPut_Rollback(t *testing.T) {
+	key := storage.RecordID(1)
+	testData := []byte("testtesttest")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+
+	for _, test := range []struct {
+		name    string
+		errors  map[storage.ServiceAddr]error
+		err     error
+		deleted []storage.ServiceAddr
+	}{
+		{
+			name:   "stored",
+			errors: map[storage.ServiceAddr]error{nodes[0]: storage.ErrRecordExists},
+		},
+		{
+			name:    "exists",
+			errors:  map[storage.ServiceAddr]error{nodes[0]: storage.ErrRecordExists, nodes[1]: storage.ErrRecordExists},
+			err:     storage.ErrRecordExists,
+			deleted: nodes[2:],
+		},
+		{
+			name:    "no_quorum",
+			errors:  map[storage.ServiceAddr]error{nodes[0]: storage.ErrRecordExists, nodes[1]: storage.ErrQuotaExceeded},
+			err:     storage.ErrQuorumNotReached,
+			deleted: nodes[2:],
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+			nc.put = put(t, nodes, key, testData, func(node storage.ServiceAddr) error {
+				return test.errors[node]
+			})
+			var lock sync.Mutex
+			var deleted []storage.ServiceAddr
+			nc.del = del(t, nodes, key, func(node storage.ServiceAddr) error {
+				lock.Lock()
+				defer lock.Unlock()
+				deleted = append(deleted, node)
+				return nil
+			})
+
+			fe := New(cfg)
+			if err := fe.Put(key, testData); err != test.err {
+				t.Errorf("Put() got error %v, want %v", err, test.err)
+			}
+			sort.Slice(deleted, func(i, j int) bool { return deleted[i] < deleted[j] })
+			if !reflect.DeepEqual(deleted, test.deleted) {
+				t.Errorf("Put() rolled back on %v, want %v", deleted, test.deleted)
+			}
+		})
+	}
+}
+
+func TestMPut_Rollback(t *testing.T) {
+	keys := []storage.RecordID{1, 2}
+	data := [][]byte{[]byte("one"), []byte("two")}
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	nc := new(MockNode)
+	// Key 1 exists on two replicas, so its copy on the third one is deleted.
+	nc.mput = func(node storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+		errs := make([]error, len(keys))
+		for i, k := range keys {
+			if k == 1 && node != nodes[2] {
+				errs[i] = storage.ErrRecordExists
+			}
+		}
+		return errs, nil
+	}
+	var lock sync.Mutex
+	deleted := make(map[storage.ServiceAddr][]storage.RecordID)
+	nc.mdel = func(node storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted[node] = append(deleted[node], keys...)
+		return make([]error, len(keys)), nil
+	}
+
+	c := cfg
+	c.NC = nc
+	fe := New(c)
+	errs := fe.MPut(keys, data)
+	if errs[0] != storage.ErrRecordExists || errs[1] != nil {
+		t.Errorf("MPut() got errors %v, want [%v <nil>]", errs, storage.ErrRecordExists)
+	}
+	want := map[storage.ServiceAddr][]storage.RecordID{nodes[2]: {1}}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("MPut() rolled back %v, want %v", deleted, want)
+	}
+}
@@ -0,0 +1,178 @@
+package frontend
+
+import (
+	"context"
+	"sort"
+
+	"storage"
+)
+
+// Scan returns up to limit records of the storage with keys greater than
+// the cursor position in ascending order of keys, and a cursor to continue
+// scanning from. The returned cursor is nil if there are no more records.
+// Not more than storage.ScanLimit records is returned. Values of records
+// of storage.ClassErasureCoded namespaces are restored from their shards.
+//
+// Scan возвращает не больше limit записей хранилища с ключами, большими
+// позиции cursor, в порядке возрастания ключей, и cursor для продолжения.
+// Возвращаемый cursor равен nil, если записей больше нет.
+// Возвращается не больше чем storage.ScanLimit записей. Значения записей
+// пространств storage.ClassErasureCoded восстанавливаются из их частей.
+func (fe *Frontend) Scan(cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return fe.scan(cursor, limit, func(node storage.ServiceAddr, limit int) ([]storage.Record, storage.Cursor, error) {
+		return fe.conf.NC.Scan(node, cursor, limit)
+	})
+}
+
+// Records returns an iterator over records of the storage scanned
+// by Scan in pages of limit records until ctx is done, e.g.
+//
+//	it := fe.Records(ctx, 0)
+//	for k, d := range it.All() {
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Records возвращает итератор по записям хранилища, просматриваемым Scan
+// страницами по limit записей, пока ctx не завершен.
+func (fe *Frontend) Records(ctx context.Context, limit int) *storage.Iterator {
+	return storage.NewIterator(ctx, limit, fe.Scan)
+}
+
+// ScanSnapshot scans records of the cluster-wide snapshot with the given id
+// like Scan.
+//
+// ScanSnapshot просматривает записи снимка кластера с данным id
+// аналогично Scan.
+func (fe *Frontend) ScanSnapshot(id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return fe.scan(cursor, limit, func(node storage.ServiceAddr, limit int) ([]storage.Record, storage.Cursor, error) {
+		return fe.conf.NC.ScanSnapshot(node, id, cursor, limit)
+	})
+}
+
+// ScanChanges scans records of the cluster-wide snapshot with the given id
+// changed after the snapshot since was taken like Scan. Records deleted in
+// between are returned with Deleted set.
+//
+// ScanChanges просматривает записи снимка кластера с данным id, изменившиеся
+// после создания снимка since, аналогично Scan. Записи, удаленные за это
+// время, возвращаются с установленным Deleted.
+func (fe *Frontend) ScanChanges(id, since uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return fe.scan(cursor, limit, func(node storage.ServiceAddr, limit int) ([]storage.Record, storage.Cursor, error) {
+		return fe.conf.NC.ScanChanges(node, id, since, cursor, limit)
+	})
+}
+
+func (fe *Frontend) scan(cursor storage.Cursor, limit int, scanNode func(node storage.ServiceAddr, limit int) ([]storage.Record, storage.Cursor, error)) ([]storage.Record, storage.Cursor, error) {
+	if _, _, err := cursor.After(); err != nil {
+		return nil, nil, err
+	}
+	limit = storage.ScanLimitOf(limit)
+
+	if err := fe.init(); err != nil {
+		return nil, nil, err
+	}
+	nodes := fe.nodes()
+
+	type result struct {
+		records []storage.Record
+		next    storage.Cursor
+		err     error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			records, next, err := scanNode(node, limit)
+			results <- result{records: records, next: next, err: err}
+		}(node)
+	}
+
+	// Every node returns records in ascending order of keys, so all records
+	// with keys up to the smallest last key of unfinished nodes are known.
+	var (
+		horizon  storage.RecordID
+		bounded  bool
+		failures int
+		pages    []result
+	)
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			failures++
+			if failures >= storage.MinRedundancy {
+				return nil, nil, storage.ErrQuorumNotReached
+			}
+			continue
+		}
+		pages = append(pages, result)
+		if result.next == nil || len(result.records) == 0 {
+			continue
+		}
+		last := result.records[len(result.records)-1].Key
+		if !bounded || last < horizon {
+			horizon, bounded = last, true
+		}
+	}
+
+	// Merge replicas choosing data returned by most of them. Replicas of
+	// erasure-coded records return different shards, so the shards vote
+	// together and the value is restored from them.
+	type value struct {
+		data    string
+		deleted bool
+		shard   bool
+	}
+	votes := make(map[storage.RecordID]map[value]int)
+	shards := make(map[storage.RecordID][][]byte)
+	for _, page := range pages {
+		for _, r := range page.records {
+			if bounded && r.Key > horizon {
+				break
+			}
+			if votes[r.Key] == nil {
+				votes[r.Key] = make(map[value]int)
+			}
+			if !r.Deleted && fe.erasureCoded(r.Key) {
+				votes[r.Key][value{shard: true}]++
+				shards[r.Key] = append(shards[r.Key], r.Data)
+				continue
+			}
+			votes[r.Key][value{data: string(r.Data), deleted: r.Deleted}]++
+		}
+	}
+
+	records := make([]storage.Record, 0, len(votes))
+	for k, counts := range votes {
+		var v value
+		best := 0
+		for c, count := range counts {
+			if count > best || count == best && (c.data < v.data || c.data == v.data && c.deleted) {
+				v, best = c, count
+			}
+		}
+		r := storage.Record{Key: k, Data: []byte(v.data), Deleted: v.deleted}
+		if v.shard {
+			d, err := storage.DecodeShards(shards[k])
+			if err != nil {
+				fe.conf.Logger.Printf("Failed to restore erasure-coded key %v: %v", k, err)
+				return nil, nil, err
+			}
+			r.Data = d
+		}
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+
+	var next storage.Cursor
+	if len(records) > limit {
+		records = records[:limit]
+		next = storage.CursorAfter(records[limit-1].Key)
+	} else if bounded {
+		next = storage.CursorAfter(horizon)
+	}
+	return records, next, nil
+}
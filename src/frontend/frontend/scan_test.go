@@ -0,0 +1,94 @@
+package frontend
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+// scanRecords emulates node Scan over records sorted by keys.
+func scanRecords(records []storage.Record, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	after, ok, err := cursor.After()
+	if err != nil {
+		return nil, nil, err
+	}
+	var page []storage.Record
+	for _, r := range records {
+		if ok && r.Key <= after {
+			continue
+		}
+		if len(page) == limit {
+			return page, storage.CursorAfter(page[limit-1].Key), nil
+		}
+		page = append(page, r)
+	}
+	return page, nil, nil
+}
+
+func TestScan(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	// Every record is stored on two of three nodes.
+	var want []storage.Record
+	stored := make(map[storage.ServiceAddr][]storage.Record)
+	for k := 0; k < 20; k++ {
+		r := storage.Record{Key: storage.RecordID(k), Data: []byte(fmt.Sprint("data", k))}
+		want = append(want, r)
+		for i, node := range nodes {
+			if i != k%len(nodes) {
+				stored[node] = append(stored[node], r)
+			}
+		}
+	}
+
+	nc := new(MockNode)
+	nc.scan = func(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+		return scanRecords(stored[node], cursor, limit)
+	}
+	fe := New(Config{
+		RC:     &rc,
+		NC:     nc,
+		NF:     router.NewNodesFinder(router.NewMD5Hasher()),
+		Router: "router",
+	})
+
+	for _, limit := range []int{1, 3, 7, 100} {
+		t.Run(fmt.Sprint("limit=", limit), func(t *testing.T) {
+			var got []storage.Record
+			var cursor storage.Cursor
+			for i := 0; ; i++ {
+				if i > len(want) {
+					t.Fatalf("Scan() didn't finish after %d pages", i)
+				}
+				records, next, err := fe.Scan(cursor, limit)
+				if err != nil {
+					t.Fatalf("Scan() error: %v", err)
+				}
+				if len(records) > limit {
+					t.Fatalf("Scan() returned %d records, limit is %d", len(records), limit)
+				}
+				got = append(got, records...)
+				if next == nil {
+					break
+				}
+				cursor = next
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Scan() wrong records, got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestScan_InvalidCursor(t *testing.T) {
+	fe := New(cfg)
+	if _, _, err := fe.Scan(storage.Cursor("bad"), 0); err != storage.ErrInvalidCursor {
+		t.Errorf("Scan() got error %v, want %v", err, storage.ErrInvalidCursor)
+	}
+}
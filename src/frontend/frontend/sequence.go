@@ -0,0 +1,140 @@
+package frontend
+
+import (
+	"context"
+	"time"
+
+	"storage"
+)
+
+const (
+	// IDBatch is a default number of IDs allocated by NextID at once.
+	//
+	// IDBatch -- количество ID, выделяемых NextID за один раз, по умолчанию.
+	IDBatch = 100
+
+	// LockRetries is a number of attempts to lock a sequence.
+	//
+	// LockRetries -- количество попыток заблокировать последовательность.
+	LockRetries = 10
+
+	// LockRetryTimeout is a timeout to wait after unsuccessful attempt
+	// to lock a sequence.
+	//
+	// LockRetryTimeout -- количество времени, которое нужно подождать
+	// после неудачной попытки заблокировать последовательность.
+	LockRetryTimeout = 10 * time.Millisecond
+)
+
+type idRange struct {
+	next, end uint64
+}
+
+// Sequence raises the value of the sequence with the given name to floor
+// on the replicas and returns the maximal value of at least
+// storage.MinRedundancy replicas.
+//
+// Sequence увеличивает значение последовательности с данным именем до floor
+// на репликах и возвращает максимальное значение как минимум
+// storage.MinRedundancy реплик.
+func (fe *Frontend) Sequence(name string, floor uint64) (uint64, error) {
+	nodes, err := fe.replicas(context.Background(), storage.SequenceKey(name))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(nodes) < storage.MinRedundancy {
+		return 0, storage.ErrNotEnoughDaemons
+	}
+
+	type result struct {
+		value uint64
+		err   error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			value, err := fe.conf.NC.Sequence(node, name, floor)
+			results <- result{value: value, err: err}
+		}(node)
+	}
+
+	var value uint64
+	okCount := 0
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			continue
+		}
+		okCount++
+		if result.value > value {
+			value = result.value
+		}
+	}
+
+	if okCount < storage.MinRedundancy {
+		return 0, storage.ErrQuorumNotReached
+	}
+	return value, nil
+}
+
+// NextID returns the next unique ID of the sequence with the given name.
+// IDs are monotonic for a Frontend and are allocated in ranges of
+// cfg.IDBatch IDs under the lock of the sequence.
+//
+// NextID возвращает следующий уникальный ID последовательности с данным
+// именем. ID монотонны в пределах Frontend и выделяются диапазонами по
+// cfg.IDBatch ID под блокировкой последовательности.
+func (fe *Frontend) NextID(name string) (uint64, error) {
+	fe.idLock.Lock()
+	defer fe.idLock.Unlock()
+
+	r := fe.ids[name]
+	if r == nil || r.next == r.end {
+		batch := uint64(fe.conf.IDBatch)
+		if batch == 0 {
+			batch = IDBatch
+		}
+		first, err := fe.allocateIDs(name, batch)
+		if err != nil {
+			return 0, err
+		}
+		r = &idRange{next: first, end: first + batch}
+		fe.ids[name] = r
+	}
+
+	id := r.next
+	r.next++
+	return id, nil
+}
+
+// allocateIDs reserves n IDs of the sequence and returns the first one.
+func (fe *Frontend) allocateIDs(name string, n uint64) (uint64, error) {
+	key := storage.SequenceKey(name)
+
+	var (
+		token storage.LockToken
+		err   error
+	)
+	for i := 0; i < LockRetries; i++ {
+		if token, err = fe.Lock(key, 5*storage.Timeout); err != storage.ErrLocked {
+			break
+		}
+		time.Sleep(LockRetryTimeout)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer fe.Unlock(key, token)
+
+	// Read and write quorums intersect, so the value read is not less than
+	// the end of any range allocated before.
+	last, err := fe.Sequence(name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fe.Sequence(name, last+n); err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
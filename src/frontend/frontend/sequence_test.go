@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"sync"
+	"testing"
+
+	"node/node"
+	"storage"
+)
+
+func TestNextID(t *testing.T) {
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return addrs, nil
+	}
+
+	const (
+		frontends = 4
+		n         = 50
+	)
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		seen = make(map[uint64]bool)
+	)
+	wg.Add(frontends)
+	for i := 0; i < frontends; i++ {
+		go func() {
+			defer wg.Done()
+			fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router, IDBatch: 7})
+			var prev uint64
+			for j := 0; j < n; j++ {
+				id, err := fe.NextID("seq")
+				if err != nil {
+					t.Errorf("NextID() error: %v", err)
+					return
+				}
+				if id <= prev {
+					t.Errorf("NextID() is not monotonic: got %v after %v", id, prev)
+				}
+				prev = id
+
+				lock.Lock()
+				if seen[id] {
+					t.Errorf("NextID() returned duplicate %v", id)
+				}
+				seen[id] = true
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"sort"
+	"sync"
+
+	"storage"
+)
+
+// WriteStripes is a number of locks keys are spread over when
+// cfg.SerializeWrites is set. Writes of different keys sharing a lock
+// are serialized too, which is rare enough not to matter.
+//
+// WriteStripes -- количество блокировок, между которыми распределяются
+// ключи, если задан cfg.SerializeWrites. Запись разных ключей с общей
+// блокировкой тоже упорядочивается, что достаточно редко, чтобы не мешать.
+const WriteStripes = 256
+
+func noUnlock() {}
+
+// newWriteLocks returns the locks serializing writes if enabled.
+func newWriteLocks(enabled bool) []sync.Mutex {
+	if !enabled {
+		return nil
+	}
+	return make([]sync.Mutex, WriteStripes)
+}
+
+// lockKey waits until no other write of the key k is in progress if
+// cfg.SerializeWrites is set and returns the function ending the write.
+func (fe *Frontend) lockKey(k storage.RecordID) func() {
+	if fe.writeLocks == nil {
+		return noUnlock
+	}
+	m := &fe.writeLocks[uint32(k)%uint32(len(fe.writeLocks))]
+	m.Lock()
+	return m.Unlock
+}
+
+// lockKeys is lockKey for all the keys at once. Locks are taken in
+// ascending order, so concurrent batches sharing them don't deadlock.
+func (fe *Frontend) lockKeys(keys []storage.RecordID) func() {
+	if fe.writeLocks == nil {
+		return noUnlock
+	}
+	stripes := make([]int, 0, len(keys))
+	for _, k := range keys {
+		stripes = append(stripes, int(uint32(k)%uint32(len(fe.writeLocks))))
+	}
+	sort.Ints(stripes)
+	n := 0
+	for i, s := range stripes {
+		if i == 0 || s != stripes[n-1] {
+			stripes[n] = s
+			n++
+		}
+	}
+	stripes = stripes[:n]
+	for _, s := range stripes {
+		fe.writeLocks[s].Lock()
+	}
+	return func() {
+		for _, s := range stripes {
+			fe.writeLocks[s].Unlock()
+		}
+	}
+}
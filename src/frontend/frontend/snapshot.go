@@ -0,0 +1,63 @@
+package frontend
+
+import (
+	"time"
+
+	"storage"
+)
+
+// SnapshotFreezeTimeout is a maximal time writes are blocked while
+// taking a snapshot.
+//
+// SnapshotFreezeTimeout -- максимальное время, на которое блокируется
+// запись при создании снимка.
+const SnapshotFreezeTimeout = 10 * time.Second
+
+// Snapshot performs the given phase of taking a snapshot on all nodes of
+// the cluster. Returns the first error if the phase failed on any node.
+//
+// Snapshot выполняет данную фазу создания снимка на всех node кластера.
+// Возвращает первую ошибку, если фаза не выполнилась на какой-либо node.
+func (fe *Frontend) Snapshot(id uint64, phase storage.SnapshotPhase, ttl time.Duration) error {
+	if err := fe.init(); err != nil {
+		return err
+	}
+	nodes := fe.nodes()
+
+	results := make(chan error, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			results <- fe.conf.NC.Snapshot(node, id, phase, ttl)
+		}(node)
+	}
+
+	var ret error
+	for range nodes {
+		if err := <-results; err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
+}
+
+// TakeSnapshot takes a consistent snapshot of the whole cluster and returns
+// its id. Writes are blocked on all nodes before taking the snapshot, so it
+// represents a single moment in time.
+//
+// TakeSnapshot создает согласованный снимок всего кластера и возвращает
+// его id. Перед созданием снимка запись блокируется на всех node, поэтому
+// снимок соответствует одному моменту времени.
+func (fe *Frontend) TakeSnapshot() (uint64, error) {
+	id := uint64(fe.conf.Clock.Now().UnixNano())
+
+	if err := fe.Snapshot(id, storage.SnapshotFreeze, SnapshotFreezeTimeout); err != nil {
+		fe.Snapshot(id, storage.SnapshotAbort, 0)
+		return 0, err
+	}
+	if err := fe.Snapshot(id, storage.SnapshotTake, 0); err != nil {
+		fe.Snapshot(id, storage.SnapshotAbort, 0)
+		fe.Snapshot(id, storage.SnapshotDrop, 0)
+		return 0, err
+	}
+	return id, nil
+}
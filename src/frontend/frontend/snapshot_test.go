@@ -0,0 +1,48 @@
+package frontend
+
+import (
+	"testing"
+
+	"node/node"
+	"storage"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return addrs, nil
+	}
+	for _, addr := range addrs {
+		nc.Put(addr, 1, []byte("old"))
+	}
+
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+	id, err := fe.TakeSnapshot()
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error: %v", err)
+	}
+	for _, addr := range addrs {
+		if err := nc.Put(addr, 2, []byte("new")); err != nil {
+			t.Fatalf("Put() after snapshot error: %v", err)
+		}
+	}
+
+	records, next, err := fe.ScanSnapshot(id, nil, 0)
+	if err != nil {
+		t.Fatalf("ScanSnapshot() error: %v", err)
+	}
+	if len(records) != 1 || records[0].Key != 1 || string(records[0].Data) != "old" || next != nil {
+		t.Errorf("ScanSnapshot() got %v, %v, want only record 1", records, next)
+	}
+
+	if err := fe.Snapshot(id, storage.SnapshotDrop, 0); err != nil {
+		t.Fatalf("Snapshot(drop) error: %v", err)
+	}
+	if _, _, err := fe.ScanSnapshot(id, nil, 0); err != storage.ErrQuorumNotReached {
+		t.Errorf("ScanSnapshot() of dropped snapshot got error %v, want %v", err, storage.ErrQuorumNotReached)
+	}
+}
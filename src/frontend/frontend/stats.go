@@ -0,0 +1,136 @@
+package frontend
+
+import (
+	"storage"
+)
+
+// ReplicationHealth estimates how many records lack replicas as some nodes
+// are unreachable, assuming replicas are spread evenly among nodes.
+//
+// ReplicationHealth -- оценка количества записей, которым не хватает
+// реплик из-за недоступности части node, в предположении, что реплики
+// распределены по node равномерно.
+type ReplicationHealth struct {
+	// Healthy is set if all nodes are reachable.
+	// Healthy -- задан, если все node доступны.
+	Healthy bool
+	// UnderReplicated is an estimated number of records having some of
+	// their replicas on unreachable nodes.
+	// UnderReplicated -- оценка количества записей, часть реплик которых
+	// находится на недоступных node.
+	UnderReplicated uint64
+	// Unavailable is an estimated number of records having less than
+	// storage.MinRedundancy replicas on reachable nodes, so their quorum
+	// can't be reached.
+	// Unavailable -- оценка количества записей, у которых на доступных
+	// node меньше storage.MinRedundancy реплик, так что для них
+	// не достигается кворум.
+	Unavailable uint64
+}
+
+// ClusterStats describes records stored in the cluster.
+//
+// ClusterStats описывает записи, хранящиеся в кластере.
+type ClusterStats struct {
+	// Nodes contains stats of every reachable node.
+	// Nodes -- статистика каждой доступной node.
+	Nodes map[storage.ServiceAddr]storage.Stats
+	// Unreachable is a list of nodes failed to return stats.
+	// Unreachable -- список node, не вернувших статистику.
+	Unreachable []storage.ServiceAddr
+	// Total is a sum of stats of all reachable nodes, i.e. of all replicas.
+	// Total -- сумма статистики всех доступных node, т.е. всех реплик.
+	Total storage.Stats
+	// Records is an estimated number of records without replicas.
+	// Records -- оценка количества записей без учета реплик.
+	Records uint64
+	// Namespaces break Records down by namespaces, estimated the same way.
+	// Namespaces -- Records в разбивке по пространствам ключей, оцененные
+	// так же.
+	Namespaces []storage.NamespaceStats
+	// Replication is health of replication of the records.
+	// Replication -- состояние репликации записей.
+	Replication ReplicationHealth
+}
+
+// ClusterStats queries all nodes for their stats and aggregates them.
+//
+// ClusterStats запрашивает статистику всех node и агрегирует ее.
+func (fe *Frontend) ClusterStats() ClusterStats {
+	cv := fe.ClusterView()
+
+	cs := ClusterStats{
+		Nodes: make(map[storage.ServiceAddr]storage.Stats),
+	}
+	for _, nv := range cv.Nodes {
+		if !nv.Reachable {
+			cs.Unreachable = append(cs.Unreachable, nv.Addr)
+			continue
+		}
+		cs.Nodes[nv.Addr] = nv.Stats
+		cs.Total.Add(nv.Stats)
+	}
+
+	// Every record is expected to be stored on storage.ReplicationFactor
+	// nodes, only reachable ones are accounted.
+	if reachable := len(cs.Nodes); reachable > 0 {
+		replicas := storage.ReplicationFactor * uint64(reachable)
+		estimate := func(n uint64) uint64 { return n * uint64(len(cv.Nodes)) / replicas }
+		cs.Records = estimate(cs.Total.Records)
+		for _, n := range cs.Total.Namespaces {
+			cs.Namespaces = append(cs.Namespaces, storage.NamespaceStats{
+				Prefix:  n.Prefix,
+				Records: estimate(n.Records),
+				Bytes:   estimate(n.Bytes),
+			})
+		}
+	}
+	cs.Replication = replicationHealth(cs.Records, len(cv.Nodes), len(cs.Nodes))
+	return cs
+}
+
+// replicationHealth estimates health of replication of records stored on
+// nodes of which reachable ones are. The replicas of a record are placed
+// on distinct nodes, so the number of them on reachable nodes follows
+// the hypergeometric distribution.
+func replicationHealth(records uint64, nodes, reachable int) ReplicationHealth {
+	h := ReplicationHealth{Healthy: reachable == nodes}
+	if h.Healthy || records == 0 {
+		return h
+	}
+	rf := min(storage.ReplicationFactor, nodes)
+	var under, unavailable float64
+	for k := 0; k < rf; k++ {
+		p := binomial(reachable, k) * binomial(nodes-reachable, rf-k) / binomial(nodes, rf)
+		under += p
+		if k < storage.MinRedundancy {
+			unavailable += p
+		}
+	}
+	h.UnderReplicated = uint64(float64(records) * under)
+	h.Unavailable = uint64(float64(records) * unavailable)
+	return h
+}
+
+// binomial returns the number of ways to choose k of n items.
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	c := 1.0
+	for i := 0; i < k; i++ {
+		c = c * float64(n-i) / float64(i+1)
+	}
+	return c
+}
+
+// Stats returns the total stats of all replicas stored in the cluster.
+//
+// Stats возвращает суммарную статистику всех реплик, хранящихся в кластере.
+func (fe *Frontend) Stats() (storage.Stats, error) {
+	cs := fe.ClusterStats()
+	if len(cs.Nodes) == 0 && len(cs.Unreachable) > 0 {
+		return storage.Stats{}, storage.ErrNotEnoughDaemons
+	}
+	return cs.Total, nil
+}
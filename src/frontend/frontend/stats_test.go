@@ -0,0 +1,95 @@
+package frontend
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+func TestClusterStats(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3", "node4"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	prefix := storage.Prefix{Key: 0x12000000, Len: 8}
+	stats := map[storage.ServiceAddr]storage.Stats{
+		nodes[0]: {Records: 10, Bytes: 100, Namespaces: []storage.NamespaceStats{{Prefix: prefix, Records: 3, Bytes: 30}}},
+		nodes[1]: {Records: 20, Bytes: 200, Namespaces: []storage.NamespaceStats{{Prefix: prefix, Records: 6, Bytes: 60}}},
+		nodes[2]: {Records: 30, Bytes: 300},
+	}
+	nc := new(MockNode)
+	nc.stats = func(node storage.ServiceAddr) (storage.Stats, error) {
+		if s, ok := stats[node]; ok {
+			return s, nil
+		}
+		return storage.Stats{}, errors.New("unreachable")
+	}
+
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+	cs := fe.ClusterStats()
+	if !reflect.DeepEqual(cs.Nodes, stats) {
+		t.Errorf("Wrong nodes stats: got %v, want %v", cs.Nodes, stats)
+	}
+	if !reflect.DeepEqual(cs.Unreachable, nodes[3:]) {
+		t.Errorf("Wrong unreachable nodes: got %v, want %v", cs.Unreachable, nodes[3:])
+	}
+	want := storage.Stats{Records: 60, Bytes: 600, Namespaces: []storage.NamespaceStats{{Prefix: prefix, Records: 9, Bytes: 90}}}
+	if !reflect.DeepEqual(cs.Total, want) {
+		t.Errorf("Wrong total stats: got %v, want %v", cs.Total, want)
+	}
+	if cs.Records != 26 {
+		t.Errorf("Wrong estimated number of records: got %v, want %v", cs.Records, 26)
+	}
+	if want := []storage.NamespaceStats{{Prefix: prefix, Records: 4, Bytes: 40}}; !reflect.DeepEqual(cs.Namespaces, want) {
+		t.Errorf("Wrong namespaces stats: got %v, want %v", cs.Namespaces, want)
+	}
+	// A record is fully replicated only if none of its 3 replicas is on
+	// the unreachable node, which happens for 1 of 4 placements, and
+	// always has 2 replicas left.
+	if want := (ReplicationHealth{UnderReplicated: 19}); cs.Replication != want {
+		t.Errorf("Wrong replication health: got %+v, want %+v", cs.Replication, want)
+	}
+
+	total, err := fe.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	if !reflect.DeepEqual(total, cs.Total) {
+		t.Errorf("Stats() got %v, want %v", total, cs.Total)
+	}
+}
+
+func TestClusterView(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node2", "node1"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	nc := new(MockNode)
+	nc.stats = func(node storage.ServiceAddr) (storage.Stats, error) {
+		if node == "node1" {
+			return storage.Stats{Records: 1, Bytes: 10}, nil
+		}
+		return storage.Stats{}, errors.New("unreachable")
+	}
+
+	c := Config{RC: &rc, NC: nc, Router: cfg.Router}
+	c.Placement.Domains = map[storage.ServiceAddr]router.Domains{"node1": {"zone": "a"}}
+	fe := New(c)
+	if got := fe.ListNodes(); !reflect.DeepEqual(got, nodes) {
+		t.Errorf("ListNodes() got %v, want %v", got, nodes)
+	}
+	want := ClusterView{
+		Router: cfg.Router,
+		Nodes: []NodeView{
+			{Addr: "node1", Domains: router.Domains{"zone": "a"}, Reachable: true, Stats: storage.Stats{Records: 1, Bytes: 10}},
+			{Addr: "node2", Error: "unreachable"},
+		},
+	}
+	if got := fe.ClusterView(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ClusterView() got %+v, want %+v", got, want)
+	}
+}
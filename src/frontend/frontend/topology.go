@@ -0,0 +1,175 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// RefreshInterval is a default time between refreshes of the list of nodes
+// from Router.
+//
+// RefreshInterval -- время по умолчанию между обновлениями списка node
+// от Router.
+const RefreshInterval = 30 * time.Second
+
+// topology is the view of the cluster received from Router.
+// It is never modified after it is published.
+type topology struct {
+	nodes []storage.ServiceAddr
+	// stale is set for a topology loaded from cfg.TopologyFile
+	// until Router confirms it.
+	stale bool
+}
+
+// savedTopology is the format of cfg.TopologyFile.
+type savedTopology struct {
+	Nodes []storage.ServiceAddr
+	Saved time.Time
+}
+
+// nodes returns the list of nodes received from Router, nil before init.
+// The list should not be modified.
+func (fe *Frontend) nodes() []storage.ServiceAddr {
+	if t := fe.topology.Load(); t != nil {
+		return t.nodes
+	}
+	return nil
+}
+
+// setNodes publishes a copy of nodes received from Router as the new
+// topology and saves it to cfg.TopologyFile.
+func (fe *Frontend) setNodes(nodes []storage.ServiceAddr) {
+	nodes = append([]storage.ServiceAddr(nil), nodes...)
+	fe.topology.Store(&topology{nodes: nodes})
+	fe.conf.Sink.SetGauge("frontend.topology.stale", 0)
+	if fe.conf.TopologyFile == "" {
+		return
+	}
+	if err := fe.saveTopology(nodes); err != nil {
+		fe.conf.Logger.Printf("Failed to save topology to %q: %v", fe.conf.TopologyFile, err)
+	}
+}
+
+// saveTopology atomically replaces cfg.TopologyFile with nodes.
+func (fe *Frontend) saveTopology(nodes []storage.ServiceAddr) error {
+	b, err := json.Marshal(savedTopology{Nodes: nodes, Saved: fe.conf.Clock.Now()})
+	if err != nil {
+		return err
+	}
+	tmp := fe.conf.TopologyFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fe.conf.TopologyFile)
+}
+
+// loadTopology publishes the topology saved in cfg.TopologyFile as stale,
+// so reads are served before Router is reachable. A missing file is
+// not an error.
+func (fe *Frontend) loadTopology() error {
+	b, err := ioutil.ReadFile(fe.conf.TopologyFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved savedTopology
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+	if len(saved.Nodes) == 0 {
+		return nil
+	}
+	fe.topology.Store(&topology{nodes: saved.Nodes, stale: true})
+	fe.conf.Sink.SetGauge("frontend.topology.stale", 1)
+	fe.conf.Logger.Printf("Serving reads from %v nodes saved in %q at %v until Router is reachable",
+		len(saved.Nodes), fe.conf.TopologyFile, saved.Saved.Format(time.RFC3339))
+	return nil
+}
+
+// Stale reports whether the frontend serves reads with the nodes loaded
+// from cfg.TopologyFile, which Router hasn't confirmed yet.
+//
+// Stale сообщает, обслуживает ли frontend чтение с node, загруженными
+// из cfg.TopologyFile и еще не подтвержденными Router.
+func (fe *Frontend) Stale() bool {
+	t := fe.topology.Load()
+	return t != nil && t.stale
+}
+
+// initReads is init for requests which only read records. With a stale
+// topology loaded from cfg.TopologyFile they are served right away, while
+// the nodes are refreshed from Router in background.
+func (fe *Frontend) initReads() error {
+	if fe.Initialized() {
+		return nil
+	}
+	if !fe.Stale() {
+		return fe.init()
+	}
+	if atomic.CompareAndSwapInt32(&fe.refreshing, 0, 1) {
+		go fe.fetchNodes(context.Background())
+	}
+	return nil
+}
+
+// RefreshNodes fetches the list of nodes from Router once and makes
+// requests started from now on use it, so nodes added or removed after
+// init are seen. Requests in flight keep using the list they started with.
+//
+// RefreshNodes однократно получает список node от Router, и запросы,
+// начатые после этого, используют его, так что добавленные или удаленные
+// после инициализации node учитываются. Выполняющиеся запросы продолжают
+// использовать список, с которым они начались.
+func (fe *Frontend) RefreshNodes() error {
+	nodes, err := fe.conf.RC.List(fe.conf.Router)
+	if err != nil {
+		fe.conf.Sink.IncrCounter("frontend.topology.refresh_errors", 1)
+		return err
+	}
+	// Read leases are bound to the topology, so it is only replaced
+	// when the nodes change.
+	if t := fe.topology.Load(); t == nil || t.stale || !sameNodes(t.nodes, nodes) {
+		if t != nil && !t.stale {
+			fe.conf.Logger.Printf("Nodes changed: %v -> %v", t.nodes, nodes)
+		}
+		fe.setNodes(nodes)
+	}
+	atomic.StoreInt32(&fe.initialized, 1)
+	return nil
+}
+
+// Refreshes refreshes the list of nodes from Router each time interval
+// set by cfg.RefreshInterval.
+//
+// Refreshes обновляет список node от Router через каждый интервал
+// времени, заданный в cfg.RefreshInterval.
+func (fe *Frontend) Refreshes() {
+	go func() {
+		for {
+			time.Sleep(fe.conf.RefreshInterval)
+			if err := fe.RefreshNodes(); err != nil {
+				fe.conf.Logger.Printf("Failed to refresh nodes from %q: %v", fe.conf.Router, err)
+			}
+		}
+	}()
+}
+
+func sameNodes(a, b []storage.ServiceAddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
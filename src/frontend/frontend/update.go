@@ -0,0 +1,89 @@
+package frontend
+
+import (
+	"context"
+
+	"storage"
+)
+
+// writeFunc writes the value d of the record with key k to node with nc,
+// e.g. storage.Client.Put.
+type writeFunc func(nc storage.Client, node storage.ServiceAddr, k storage.RecordID, d []byte) error
+
+// Update replaces the value of the record with key k if it exists.
+// Returns the storage.ErrRecordNotFound error if a quorum of replicas
+// don't have it. Values are checked like the ones of Put.
+//
+// Update -- заменить значение записи с ключом k, если она существует.
+// Возвращает ошибку storage.ErrRecordNotFound, если ее нет на кворуме
+// реплик. Значения проверяются так же, как в Put.
+func (fe *Frontend) Update(k storage.RecordID, d []byte) error {
+	return fe.UpdateContext(context.Background(), k, d)
+}
+
+// UpdateContext is Update returning ctx.Err() without waiting for the
+// replicas once ctx is done, requests to them are canceled then. Succeeds
+// once as many replicas as storage.ConsistencyOf(ctx) requires replace
+// the value.
+//
+// UpdateContext -- Update, возвращающий ctx.Err(), не дожидаясь реплик,
+// когда ctx завершен, запросы к ним при этом отменяются. Успешен, когда
+// значение заменили столько реплик, сколько требует
+// storage.ConsistencyOf(ctx).
+func (fe *Frontend) UpdateContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	return fe.replace(ctx, "update", k, d, storage.Client.Update)
+}
+
+// Upsert puts the record with key k, replacing its value if it exists.
+// Succeeds once a quorum of replicas store d. Values are checked like
+// the ones of Put.
+//
+// Upsert -- добавить запись с ключом k, заменив ее значение, если она
+// существует. Завершается успешно, когда d сохранено на кворуме реплик.
+// Значения проверяются так же, как в Put.
+func (fe *Frontend) Upsert(k storage.RecordID, d []byte) error {
+	return fe.UpsertContext(context.Background(), k, d)
+}
+
+// UpsertContext is Upsert returning ctx.Err() without waiting for the
+// replicas once ctx is done, requests to them are canceled then. Succeeds
+// once as many replicas as storage.ConsistencyOf(ctx) requires store d.
+//
+// UpsertContext -- Upsert, возвращающий ctx.Err(), не дожидаясь реплик,
+// когда ctx завершен, запросы к ним при этом отменяются. Успешен, когда
+// d сохранили столько реплик, сколько требует storage.ConsistencyOf(ctx).
+func (fe *Frontend) UpsertContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	return fe.replace(ctx, "upsert", k, d, storage.Client.Upsert)
+}
+
+// replace writes d to the replicas of the record with key k with write
+// and reports it as the operation op.
+func (fe *Frontend) replace(ctx context.Context, op string, k storage.RecordID, d []byte, write writeFunc) error {
+	start := fe.conf.Clock.Now()
+	err := ctx.Err()
+	if err == nil {
+		err = fe.allow(1)
+	}
+	if err == nil {
+		err = fe.checkValue(k, d)
+	}
+	if err == nil {
+		ctx := fe.versioned(ctx)
+		nc := storage.WithContext(fe.conf.NC, ctx)
+		unlock := fe.lockKey(k)
+		fe.dropReadLease(k)
+		if fe.erasureCoded(k) {
+			err = fe.putShards(ctx, k, d, write)
+		} else {
+			err = fe.applyPutDel(ctx, k, func(node storage.ServiceAddr) error {
+				return write(nc, node, k, d)
+			})
+		}
+		unlock()
+	}
+	if err == nil {
+		fe.notify(op, k, d)
+	}
+	fe.observe(op, k, start, len(d), err)
+	return err
+}
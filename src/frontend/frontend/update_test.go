@@ -0,0 +1,45 @@
+package frontend
+
+import (
+	"fmt"
+	"testing"
+
+	"storage"
+)
+
+func TestUpdateUpsert(t *testing.T) {
+	key := storage.RecordID(1)
+	testData := []byte("testtesttest")
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+
+	for _, test := range []struct {
+		missing   int
+		updateErr error
+	}{
+		{missing: 0, updateErr: nil},
+		{missing: 1, updateErr: nil},
+		{missing: 2, updateErr: storage.ErrRecordNotFound},
+	} {
+		t.Run(fmt.Sprint("missing=", test.missing), func(t *testing.T) {
+			rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+			missing := func(node storage.ServiceAddr) error {
+				for _, n := range nodes[:test.missing] {
+					if n == node {
+						return storage.ErrRecordNotFound
+					}
+				}
+				return nil
+			}
+			nc.update = put(t, nodes, key, testData, missing)
+			nc.upsert = put(t, nodes, key, testData, nil)
+
+			fe := New(cfg)
+			if err := fe.Update(key, testData); err != test.updateErr {
+				t.Errorf("Update() got error %v, want %v", err, test.updateErr)
+			}
+			if err := fe.Upsert(key, testData); err != nil {
+				t.Errorf("Upsert() got error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"storage"
+)
+
+// ValidateFunc checks the value d of the record with key k before it is
+// written. A non-nil error rejects the write.
+//
+// ValidateFunc проверяет значение d записи с ключом k перед записью.
+// Ошибка, отличная от nil, отклоняет запись.
+type ValidateFunc func(k storage.RecordID, d []byte) error
+
+// Validator validates values of records with keys having Prefix.
+//
+// Validator проверяет значения записей с ключами, имеющими префикс Prefix.
+type Validator struct {
+	// Prefix is a namespace of keys validated.
+	// Prefix -- пространство проверяемых ключей.
+	Prefix storage.Prefix
+	// Validate is a function checking values.
+	// Validate -- функция, проверяющая значения.
+	Validate ValidateFunc
+}
+
+// ValidJSON returns a ValidateFunc accepting JSON objects having
+// all of the given fields.
+//
+// ValidJSON возвращает ValidateFunc, принимающую JSON объекты,
+// имеющие все данные поля.
+func ValidJSON(fields ...string) ValidateFunc {
+	return func(k storage.RecordID, d []byte) error {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(d, &obj); err != nil {
+			return fmt.Errorf("not a JSON object: %v", err)
+		}
+		for _, f := range fields {
+			if _, ok := obj[f]; !ok {
+				return fmt.Errorf("missing field %q", f)
+			}
+		}
+		return nil
+	}
+}
+
+// validate runs validators of all namespaces having k. Their errors are
+// returned as the storage.ErrInvalidValue error.
+func (fe *Frontend) validate(k storage.RecordID, d []byte) error {
+	for _, v := range fe.conf.Validators {
+		if !v.Prefix.Match(k) {
+			continue
+		}
+		if err := v.Validate(k, d); err != nil {
+			if errors.Is(err, storage.ErrInvalidValue) {
+				return err
+			}
+			return fmt.Errorf("%w: %v", storage.ErrInvalidValue, err)
+		}
+	}
+	return nil
+}
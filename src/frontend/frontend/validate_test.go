@@ -0,0 +1,43 @@
+package frontend
+
+import (
+	"errors"
+	"testing"
+
+	"node/node"
+	"storage"
+)
+
+func TestValidators(t *testing.T) {
+	addrs := []storage.ServiceAddr{"node1", "node2", "node3"}
+	nc := &nodesClient{nodes: make(map[storage.ServiceAddr]*node.Node)}
+	for _, addr := range addrs {
+		nc.nodes[addr] = node.New(node.Config{})
+	}
+	rc.nodesFind = func(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+		return addrs, nil
+	}
+
+	users := storage.Prefix{Key: 0x01000000, Len: 8}
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router},
+		WithValidator(users, ValidJSON("name")))
+
+	for _, tc := range []struct {
+		k   storage.RecordID
+		d   string
+		err error
+	}{
+		{0x01000001, `{"name": "bob"}`, nil},
+		{0x01000002, `{"id": 1}`, storage.ErrInvalidValue},
+		{0x01000003, `not json`, storage.ErrInvalidValue},
+		{0x02000001, `not json`, nil},
+	} {
+		if err := fe.Put(tc.k, []byte(tc.d)); !errors.Is(err, tc.err) {
+			t.Errorf("Put(%v, %q) got error %v, want %v", tc.k, tc.d, err, tc.err)
+		}
+		_, err := nc.nodes[addrs[0]].Get(tc.k)
+		if stored := err == nil; stored != (tc.err == nil) {
+			t.Errorf("Put(%v, %q) stored the value: %v, want %v", tc.k, tc.d, stored, tc.err == nil)
+		}
+	}
+}
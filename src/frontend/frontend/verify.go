@@ -0,0 +1,98 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"storage"
+)
+
+// checksumResult is a checksum of a value a replica reported.
+type checksumResult struct {
+	node storage.ServiceAddr
+	sum  storage.Checksum
+	err  error
+}
+
+// GetVerified gets an item like Get and then requests checksums of the value
+// computed by cfg.Fingerprint from all replicas of the record, answering
+// only if every one of them
+// matches the returned bytes. Returns an error wrapping
+// storage.ErrChecksumMismatch listing the replicas which failed to confirm
+// the value otherwise. Values returned with storage.ErrPossiblyStale are not
+// verified, as replicas are known to disagree on them.
+//
+// GetVerified получает запись как Get, а затем запрашивает контрольные суммы
+// значения, вычисленные cfg.Fingerprint, у всех реплик записи и отвечает, только если каждая из них
+// совпадает с возвращаемыми байтами. Иначе возвращает ошибку, оборачивающую
+// storage.ErrChecksumMismatch, со списком реплик, не подтвердивших значение.
+// Значения, возвращенные с storage.ErrPossiblyStale, не проверяются, так как
+// реплики заведомо расходятся в них.
+func (fe *Frontend) GetVerified(k storage.RecordID) ([]byte, error) {
+	return fe.getVerified(context.Background(), k)
+}
+
+func (fe *Frontend) getVerified(ctx context.Context, k storage.RecordID) ([]byte, error) {
+	start := fe.conf.Clock.Now()
+	d, err := fe.get(ctx, k)
+	if err == nil {
+		if err = fe.verify(k, d); err != nil {
+			d = nil
+		}
+	}
+	fe.observe("get_verified", k, start, len(d), err)
+	return d, err
+}
+
+// Checksum returns the checksum computed by h of the value GetVerified
+// returns.
+//
+// Checksum возвращает контрольную сумму, вычисленную h, значения,
+// возвращаемого GetVerified.
+func (fe *Frontend) Checksum(k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	if err := h.Validate(); err != nil {
+		return storage.Checksum{}, err
+	}
+	d, err := fe.GetVerified(k)
+	if err != nil {
+		return storage.Checksum{}, err
+	}
+	return h.Sum(d), nil
+}
+
+// verify checks that all replicas of the record with key k hold d.
+// Replicas of erasure-coded records hold different shards, which are
+// verified by their checksums when the value is restored instead.
+func (fe *Frontend) verify(k storage.RecordID, d []byte) error {
+	if fe.erasureCoded(k) {
+		return nil
+	}
+	nodes := fe.conf.NF.NodesFind(k, fe.nodes())
+	results := make(chan checksumResult, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			sum, err := fe.conf.NC.Checksum(node, k, fe.conf.Fingerprint)
+			results <- checksumResult{node: node, sum: sum, err: err}
+		}(node)
+	}
+
+	want := fe.conf.Fingerprint.Sum(d)
+	var failed []string
+	for range nodes {
+		r := <-results
+		switch {
+		case r.err != nil:
+			failed = append(failed, fmt.Sprintf("%v: %v", r.node, r.err))
+		case r.sum != want:
+			failed = append(failed, fmt.Sprintf("%v: %x", r.node, r.sum))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	fe.conf.Sink.IncrCounter("frontend.checksum.mismatch", 1)
+	fe.conf.Logger.Printf("Checksum mismatch for key %v, want %x: %v", k, want, failed)
+	return fmt.Errorf("%w: key %v, replicas %v", storage.ErrChecksumMismatch, k, failed)
+}
@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"storage"
+)
+
+func TestGetVerified(t *testing.T) {
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+	var lock sync.Mutex
+	sums := make(map[storage.ServiceAddr]storage.Checksum)
+	errs := make(map[storage.ServiceAddr]error)
+	nc := new(MockNode)
+	nc.get = func(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+		return []byte("value"), nil
+	}
+	nc.checksum = func(node storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+		if h != storage.HashCRC64 {
+			t.Errorf("Checksum() requested with hash %q, want %q", h, storage.HashCRC64)
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if sum, ok := sums[node]; ok {
+			return sum, errs[node]
+		}
+		return h.Sum([]byte("value")), errs[node]
+	}
+
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router, Fingerprint: storage.HashCRC64})
+	if d, err := fe.GetVerified(1); err != nil || string(d) != "value" {
+		t.Fatalf("GetVerified() got %q, %v, want %q", d, err, "value")
+	}
+	if d, err := fe.Get(1); err != nil || string(d) != "value" {
+		t.Fatalf("Get() got %q, %v, want %q", d, err, "value")
+	}
+
+	// A single replica holding a corrupted value fails the read.
+	lock.Lock()
+	sums["node2"] = storage.HashCRC64.Sum([]byte("valve"))
+	errs["node3"] = errors.New("unreachable")
+	lock.Unlock()
+	d, err := fe.GetVerified(1)
+	if !errors.Is(err, storage.ErrChecksumMismatch) || d != nil {
+		t.Fatalf("GetVerified() got %q, %v, want error %v", d, err, storage.ErrChecksumMismatch)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "node2") || !strings.Contains(msg, "node3: unreachable") || strings.Contains(msg, "node1") {
+		t.Errorf("GetVerified() got error %q, want node2 and node3 listed", msg)
+	}
+	if _, err := fe.Get(1); err != nil {
+		t.Errorf("Get() without ParanoidReads got error %v", err)
+	}
+
+	fe = New(Config{RC: &rc, NC: nc, Router: cfg.Router, ParanoidReads: true, Fingerprint: storage.HashCRC64})
+	if _, err := fe.Get(1); !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Errorf("Get() with ParanoidReads got error %v, want %v", err, storage.ErrChecksumMismatch)
+	}
+	if _, err := fe.Checksum(1, storage.HashSHA256); !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Errorf("Checksum() got error %v, want %v", err, storage.ErrChecksumMismatch)
+	}
+}
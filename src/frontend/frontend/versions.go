@@ -0,0 +1,127 @@
+package frontend
+
+import (
+	"context"
+	"sort"
+
+	"storage"
+)
+
+// versioned returns ctx making writes create a version of records numbered
+// by the clock of the frontend unless ctx has one, see storage.WithVersion,
+// so all replicas number it the same.
+func (fe *Frontend) versioned(ctx context.Context) context.Context {
+	if storage.VersionOf(ctx) != 0 {
+		return ctx
+	}
+	return storage.WithVersion(ctx, uint64(fe.conf.Clock.Now().UnixNano()))
+}
+
+// GetVersion returns the given version of the record with key k if at least
+// storage.MinRedundancy replicas agree on it. Returns error otherwise.
+//
+// GetVersion возвращает данную версию записи с ключом k, если как минимум
+// storage.MinRedundancy реплик согласны в ее значении. Иначе возвращает ошибку.
+func (fe *Frontend) GetVersion(k storage.RecordID, version uint64) ([]byte, error) {
+	if err := fe.checkKey(k); err != nil {
+		return nil, err
+	}
+	if err := fe.init(); err != nil {
+		return nil, err
+	}
+
+	nodes := fe.conf.NF.NodesFind(k, fe.nodes())
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			data, err := fe.conf.NC.GetVersion(node, k, version)
+			results <- result{data: data, err: err}
+		}(node)
+	}
+
+	dataCounts := make(map[string]int)
+	errCounts := make(map[error]int)
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			errCounts[result.err]++
+			if errCounts[result.err] >= storage.MinRedundancy {
+				return nil, result.err
+			}
+			continue
+		}
+		dataKey := string(result.data)
+		dataCounts[dataKey]++
+		if dataCounts[dataKey] >= storage.MinRedundancy {
+			return result.data, nil
+		}
+	}
+
+	return nil, storage.ErrQuorumNotReached
+}
+
+// ListVersions returns versions of the record with key k kept by at least
+// storage.MinRedundancy replicas in ascending order.
+//
+// ListVersions возвращает версии записи с ключом k, хранящиеся как минимум
+// на storage.MinRedundancy репликах, в порядке возрастания.
+func (fe *Frontend) ListVersions(k storage.RecordID) ([]uint64, error) {
+	if err := fe.checkKey(k); err != nil {
+		return nil, err
+	}
+	if err := fe.init(); err != nil {
+		return nil, err
+	}
+
+	nodes := fe.conf.NF.NodesFind(k, fe.nodes())
+
+	type result struct {
+		versions []uint64
+		err      error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			versions, err := fe.conf.NC.ListVersions(node, k)
+			results <- result{versions: versions, err: err}
+		}(node)
+	}
+
+	okCount := 0
+	versionCounts := make(map[uint64]int)
+	errCounts := make(map[error]int)
+	for range nodes {
+		result := <-results
+		if result.err != nil {
+			errCounts[result.err]++
+			if errCounts[result.err] >= storage.MinRedundancy {
+				return nil, result.err
+			}
+			continue
+		}
+		okCount++
+		for _, v := range result.versions {
+			versionCounts[v]++
+		}
+	}
+
+	if okCount < storage.MinRedundancy {
+		return nil, storage.ErrQuorumNotReached
+	}
+
+	versions := []uint64{}
+	for v, count := range versionCounts {
+		if count >= storage.MinRedundancy {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions, nil
+}
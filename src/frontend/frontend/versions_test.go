@@ -0,0 +1,64 @@
+package frontend
+
+import (
+	"reflect"
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+func TestVersions(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.list = func(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+		return nodes, nil
+	}
+
+	// node3 missed the second version and the first one is already dropped on node1.
+	versions := map[storage.ServiceAddr]map[uint64]string{
+		nodes[0]: {2: "v2", 3: "v3"},
+		nodes[1]: {1: "v1", 2: "v2", 3: "v3"},
+		nodes[2]: {1: "v1", 3: "v3*"},
+	}
+	nc := new(MockNode)
+	nc.getVersion = func(node storage.ServiceAddr, k storage.RecordID, version uint64) ([]byte, error) {
+		if d, ok := versions[node][version]; ok {
+			return []byte(d), nil
+		}
+		return nil, storage.ErrVersionNotFound
+	}
+	nc.listVersions = func(node storage.ServiceAddr, k storage.RecordID) ([]uint64, error) {
+		var ret []uint64
+		for v := uint64(1); v <= 3; v++ {
+			if _, ok := versions[node][v]; ok {
+				ret = append(ret, v)
+			}
+		}
+		return ret, nil
+	}
+
+	fe := New(Config{
+		RC:     &rc,
+		NC:     nc,
+		NF:     router.NewNodesFinder(router.NewMD5Hasher()),
+		Router: cfg.Router,
+	})
+
+	got, err := fe.ListVersions(key)
+	if err != nil {
+		t.Fatalf("ListVersions() error: %v", err)
+	}
+	if want := []uint64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListVersions() got %v, want %v", got, want)
+	}
+
+	for version, want := range map[uint64]string{1: "v1", 2: "v2", 3: "v3"} {
+		if d, err := fe.GetVersion(key, version); err != nil || string(d) != want {
+			t.Errorf("GetVersion(%d) got %q, %v, want %q", version, d, err, want)
+		}
+	}
+	if _, err := fe.GetVersion(key, 4); err != storage.ErrVersionNotFound {
+		t.Errorf("GetVersion(4) got error %v, want %v", err, storage.ErrVersionNotFound)
+	}
+}
@@ -0,0 +1,83 @@
+package frontend
+
+import (
+	"sort"
+
+	"router/router"
+	"storage"
+)
+
+// NodeView describes a node as seen by the frontend.
+//
+// NodeView описывает node, как ее видит frontend.
+type NodeView struct {
+	// Addr is an address of the node.
+	// Addr -- адрес node.
+	Addr storage.ServiceAddr
+	// Domains are failure domains of the node from cfg.Placement.
+	// Domains -- failure domains node из cfg.Placement.
+	Domains router.Domains `json:",omitempty"`
+	// Reachable is set if the node has answered the frontend.
+	// Reachable -- задан, если node ответила frontend.
+	Reachable bool
+	// Error is an error the node has answered with if it is unreachable.
+	// Error -- ошибка, которой ответила node, если она недоступна.
+	Error string `json:",omitempty"`
+	// Stats are stats of records stored on the node.
+	// Stats -- статистика записей, хранящихся на node.
+	Stats storage.Stats
+}
+
+// ClusterView describes the topology of the cluster and health of its nodes
+// as seen by the frontend.
+//
+// ClusterView описывает топологию кластера и состояние его node, как их
+// видит frontend.
+type ClusterView struct {
+	// Router is an address of Router the list of nodes is received from.
+	// Router -- адрес Router, от которого получен список node.
+	Router storage.ServiceAddr
+	// Nodes are views of nodes sorted by addresses.
+	// Nodes -- описания node, упорядоченные по адресам.
+	Nodes []NodeView
+}
+
+// ListNodes returns the list of nodes received from Router.
+//
+// ListNodes возвращает список node, полученный от Router.
+func (fe *Frontend) ListNodes() []storage.ServiceAddr {
+	// Without nodes from Router the list is empty.
+	fe.init()
+	return append([]storage.ServiceAddr(nil), fe.nodes()...)
+}
+
+// ClusterView probes all nodes and returns the current view of the cluster.
+//
+// ClusterView опрашивает все node и возвращает текущее описание кластера.
+func (fe *Frontend) ClusterView() ClusterView {
+	nodes := fe.ListNodes()
+
+	results := make(chan NodeView, len(nodes))
+	for _, node := range nodes {
+		go func(node storage.ServiceAddr) {
+			nv := NodeView{Addr: node, Domains: fe.conf.Placement.Domains[node]}
+			stats, err := fe.conf.NC.Stats(node)
+			if err != nil {
+				nv.Error = err.Error()
+			} else {
+				nv.Reachable = true
+				nv.Stats = stats
+			}
+			results <- nv
+		}(node)
+	}
+
+	cv := ClusterView{Router: fe.conf.Router, Nodes: make([]NodeView, 0, len(nodes))}
+	for range nodes {
+		cv.Nodes = append(cv.Nodes, <-results)
+	}
+	sort.Slice(cv.Nodes, func(i, j int) bool {
+		return cv.Nodes[i].Addr < cv.Nodes[j].Addr
+	})
+	return cv
+}
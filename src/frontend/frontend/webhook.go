@@ -0,0 +1,194 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"events"
+	"storage"
+)
+
+const (
+	// WebhookQueueSize is a number of events queued for a webhook. Events
+	// are dropped if the queue is full.
+	//
+	// WebhookQueueSize -- количество событий в очереди webhook. Если очередь
+	// заполнена, события отбрасываются.
+	WebhookQueueSize = 4096
+
+	defaultWebhookBatchSize     = 100
+	defaultWebhookFlushInterval = time.Second
+	defaultWebhookRetries       = 3
+	webhookRetryTimeout         = 100 * time.Millisecond
+)
+
+// WebhookConfig stores configuration of a webhook notified on successful
+// Put and Del operations.
+//
+// WebhookConfig -- содержит конфигурацию webhook, уведомляемого об
+// успешных операциях Put и Del.
+type WebhookConfig struct {
+	// URL is an address to POST batches of events to.
+	// URL -- адрес, на который отправляются (POST) пачки событий.
+	URL string
+	// BatchSize is a maximum number of events sent in one request.
+	// BatchSize -- максимальное количество событий в одном запросе.
+	BatchSize int `yaml:"batch_size"`
+	// FlushInterval is a maximum time an event waits to be sent.
+	// FlushInterval -- максимальное время ожидания отправки события.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// Retries is a number of retries of failed requests.
+	// Retries -- количество повторов неудачных запросов.
+	Retries int
+	// HashValues enables sending SHA-256 hashes of put values.
+	// HashValues -- включает отправку SHA-256 хешей добавленных значений.
+	HashValues bool `yaml:"hash_values"`
+}
+
+// WebhookEvent is a notification about a successful operation.
+//
+// WebhookEvent -- уведомление об успешной операции.
+type WebhookEvent struct {
+	Op   string           `json:"op"`
+	Key  storage.RecordID `json:"key"`
+	Hash string           `json:"hash,omitempty"`
+}
+
+type webhook struct {
+	conf   WebhookConfig
+	client *http.Client
+	events chan WebhookEvent
+	logger *log.Logger
+	// done is closed by run once the events queued are sent.
+	done chan struct{}
+}
+
+func startWebhooks(confs []WebhookConfig, logger *log.Logger) []*webhook {
+	webhooks := make([]*webhook, 0, len(confs))
+	for _, conf := range confs {
+		if conf.BatchSize <= 0 {
+			conf.BatchSize = defaultWebhookBatchSize
+		}
+		if conf.FlushInterval <= 0 {
+			conf.FlushInterval = defaultWebhookFlushInterval
+		}
+		if conf.Retries <= 0 {
+			conf.Retries = defaultWebhookRetries
+		}
+		w := &webhook{
+			conf:   conf,
+			client: &http.Client{Timeout: storage.Timeout},
+			events: make(chan WebhookEvent, WebhookQueueSize),
+			logger: logger,
+			done:   make(chan struct{}),
+		}
+		go w.run()
+		webhooks = append(webhooks, w)
+	}
+	return webhooks
+}
+
+func (w *webhook) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]WebhookEvent, 0, w.conf.BatchSize)
+	for {
+		select {
+		case e, ok := <-w.events:
+			if !ok {
+				if len(batch) > 0 {
+					w.send(batch)
+				}
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) < w.conf.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		w.send(batch)
+		batch = batch[:0]
+	}
+}
+
+func (w *webhook) send(batch []WebhookEvent) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		w.logger.Printf("Failed to encode webhook events: %v", err)
+		return
+	}
+
+	timeout := webhookRetryTimeout
+	for i := 0; ; i++ {
+		err = w.post(body)
+		if err == nil {
+			return
+		}
+		if i == w.conf.Retries {
+			break
+		}
+		time.Sleep(timeout)
+		timeout *= 2
+	}
+	w.logger.Printf("Failed to send %d events to webhook %q: %v", len(batch), w.conf.URL, err)
+}
+
+func (w *webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.conf.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Unexpected status %q", resp.Status)
+	}
+	return nil
+}
+
+// Close stops webhooks once they send the events queued, events of later
+// operations are not sent.
+//
+// Close останавливает webhooks, когда они отправят события в очереди,
+// события последующих операций не отправляются.
+func (fe *Frontend) Close() {
+	fe.webhookLock.Lock()
+	webhooks := fe.webhooks
+	fe.webhooks = nil
+	fe.webhookLock.Unlock()
+	for _, w := range webhooks {
+		close(w.events)
+	}
+	for _, w := range webhooks {
+		<-w.done
+	}
+}
+
+func (fe *Frontend) notify(op string, k storage.RecordID, d []byte) {
+	fe.changes.Publish(events.Event{Kind: events.RecordChanged, Key: events.KeyOf(k), Reason: op})
+	fe.webhookLock.RLock()
+	defer fe.webhookLock.RUnlock()
+	for _, w := range fe.webhooks {
+		e := WebhookEvent{Op: op, Key: k}
+		if w.conf.HashValues && d != nil {
+			hash := sha256.Sum256(d)
+			e.Hash = hex.EncodeToString(hash[:])
+		}
+		select {
+		case w.events <- e:
+		default:
+			fe.conf.Logger.Printf("Webhook %q queue is full, dropping event for key = %v", w.conf.URL, k)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"events"
+	"storage"
+)
+
+func TestWebhooks(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		events   []WebhookEvent
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var batch []WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Failed to decode events: %v", err)
+		}
+		events = append(events, batch...)
+	}))
+	defer srv.Close()
+
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc := new(MockNode)
+	nc.put = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error { return nil }
+	nc.del = func(node storage.ServiceAddr, k storage.RecordID) error { return nil }
+
+	fe := New(Config{
+		RC:     &rc,
+		NC:     nc,
+		Router: cfg.Router,
+		Webhooks: []WebhookConfig{{
+			URL:           srv.URL,
+			BatchSize:     2,
+			FlushInterval: time.Minute,
+			HashValues:    true,
+		}},
+	})
+	if err := fe.Put(key, []byte("data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := fe.Del(key); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+
+	// Close waits for the webhook to send the events queued.
+	fe.Close()
+	if err := fe.Put(key, []byte("late")); err != nil {
+		t.Fatalf("Put() after Close() error: %v", err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if requests != 2 {
+		t.Errorf("Got %d requests, want 2", requests)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Got %d events, want 2", len(events))
+	}
+	if e := events[0]; e.Op != "put" || e.Key != key || len(e.Hash) != 64 {
+		t.Errorf("Wrong put event: %+v", e)
+	}
+	if e := events[1]; e.Op != "del" || e.Key != key || e.Hash != "" {
+		t.Errorf("Wrong del event: %+v", e)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	key := storage.RecordID(1)
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3"}
+	rc.nodesFind = nodesFind(t, cfg, key, nodes, nil)
+	nc := new(MockNode)
+	nc.put = func(node storage.ServiceAddr, k storage.RecordID, d []byte) error { return nil }
+	nc.del = func(node storage.ServiceAddr, k storage.RecordID) error { return nil }
+
+	fe := New(Config{RC: &rc, NC: nc, Router: cfg.Router})
+	changes := make(chan events.Event, 2)
+	fe.Changes().Subscribe(events.SubscriberFunc(func(e events.Event) {
+		changes <- e
+	}))
+	if err := fe.Put(key, []byte("data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := fe.Del(key); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	for _, op := range []string{"put", "del"} {
+		select {
+		case e := <-changes:
+			if e.Kind != events.RecordChanged || *e.Key != key || e.Reason != op {
+				t.Errorf("Got change %+v, want %v of key %v", e, op, key)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("No %v event of %v", events.RecordChanged, op)
+		}
+	}
+}
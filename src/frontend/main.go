@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
+	"auth"
 	"frontend/frontend"
-	rclient "router/client"
-	"router/router"
+	"secrets"
 	"storage"
 )
 
@@ -17,50 +20,109 @@ func usage() {
 	fmt.Println("frontend -- service to access the distributed KV storage")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Printf("%24s\n\n", "frontend <conf.yaml>")
+	fmt.Printf("%24s\n\n", "frontend [conf.yaml]")
+	fmt.Println("Config fields can be set or overridden with environment variables,")
+	fmt.Printf("e.g. %s_ADDR or %s_METRICS_SINK.\n", envPrefix, envPrefix)
 }
 
+// envPrefix is a prefix of environment variables overriding config fields,
+// e.g. DDSP_FRONTEND_ADDR.
+const envPrefix = "DDSP_FRONTEND"
+
+// startTimeout is how long to wait for Router before serving requests.
+const startTimeout = 10 * time.Second
+
 func parseConfig(fname string) (cfg frontend.Config, err error) {
-	f, err := os.Open(fname)
-	if err != nil {
-		return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+	src := "environment"
+	if fname != "" {
+		src = fmt.Sprintf("config file %q", fname)
+		f, err := os.Open(fname)
+		if err != nil {
+			return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
+		}
 	}
-	defer f.Close()
-	dec := yaml.NewDecoder(f)
-	if err := dec.Decode(&cfg); err != nil {
-		return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
-
+	if err := storage.ApplyEnv(envPrefix, &cfg); err != nil {
+		return cfg, fmt.Errorf("Failed to parse environment: %v", err)
 	}
 	if cfg.Addr == "" {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Addr should be set", fname)
-	}
-	if cfg.Router == "" {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Router should be set", fname)
+		return cfg, fmt.Errorf("Failed to parse %v: Addr should be set", src)
 	}
 
 	return cfg, nil
 }
 
 func main() {
-	if len(os.Args) != 2 {
+	if len(os.Args) > 2 {
 		usage()
 		os.Exit(1)
 	}
 
-	cfg, err := parseConfig(os.Args[1])
+	var fname string
+	if len(os.Args) == 2 {
+		fname = os.Args[1]
+	}
+	raw, err := parseConfig(fname)
 	if err != nil {
 		log.Fatal(err)
 	}
+	provider, err := secrets.New(raw.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up secrets: %v", err)
+	}
+	cfg := raw
+	if err := secrets.Resolve(provider, &cfg); err != nil {
+		log.Fatalf("Failed to resolve secrets: %v", err)
+	}
+	cfg.Signer, err = storage.NewSigner(cfg.Signing, cfg.Clock)
+	if err != nil {
+		log.Fatalf("Failed to set up signing: %v", err)
+	}
+	authz := auth.New(cfg.Auth)
+	rotate(provider, raw, cfg.Signer, authz)
 
-	cfg.NC = storage.NewClient()
-	cfg.RC = rclient.New()
-
-	hasher := router.NewMD5Hasher()
-	cfg.NF = router.NewNodesFinder(hasher)
-
-	fe := frontend.New(cfg)
-	srv := storage.NewServer(fe, string(cfg.Addr))
+	fe, err := frontend.NewDefault(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create frontend: %v", err)
+	}
+	// With a saved topology reads are served right away and nodes
+	// are refreshed on requests.
+	if !fe.Stale() {
+		ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+		if err := fe.Start(ctx); err != nil {
+			log.Printf("Failed to initialize frontend, will retry on requests: %v", err)
+		}
+		cancel()
+	}
+	fe.Refreshes()
+	if cfg.AdminAddr != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(string(cfg.AdminAddr), auth.HTTP(authz, frontend.Admin(fe))))
+		}()
+	}
+	srv := storage.NewServer(fe, string(cfg.Addr), storage.UnaryInterceptors(cfg.Signer.Server())...)
 	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// rotate replaces signing keys of signer and admin tokens of authz when
+// the secrets raw refers to change in provider.
+func rotate(provider secrets.Provider, raw frontend.Config, signer *storage.Signer, authz auth.Authorizer) {
+	if signer != nil {
+		secrets.Watch(provider, raw.Secrets.Interval, raw.Signing, func(resolved interface{}) {
+			if err := signer.Rotate(resolved.(storage.SigningConfig)); err != nil {
+				log.Printf("Failed to rotate signing keys: %v", err)
+			}
+		})
+	}
+	if tokens, ok := authz.(*auth.Tokens); ok {
+		secrets.Watch(provider, raw.Secrets.Interval, raw.Auth, func(resolved interface{}) {
+			tokens.Set(resolved.(auth.Config))
+		})
+	}
+}
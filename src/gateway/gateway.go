@@ -0,0 +1,175 @@
+// Package gateway implements an HTTP gateway to the storage, so it can be
+// used with curl or any HTTP client without a gRPC client.
+//
+// Records are accessed at /records/<key> with GET, PUT and DELETE methods,
+// values are passed as request and response bodies. Keys are decimal or
+// 0x-prefixed hex uint32 values. Stats are returned as JSON at /stats and,
+// for a Frontend, the view of the cluster at /cluster.
+//
+// Package gateway реализует HTTP шлюз к хранилищу, чтобы его можно было
+// использовать с помощью curl или любого HTTP клиента без клиента gRPC.
+//
+// Записи доступны по адресу /records/<key> с методами GET, PUT и DELETE,
+// значения передаются в телах запросов и ответов. Ключи -- значения uint32
+// в десятичной или шестнадцатеричной с префиксом 0x записи. Статистика
+// возвращается в формате JSON по адресу /stats, а для Frontend описание
+// кластера -- по адресу /cluster.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"frontend/frontend"
+	"storage"
+)
+
+// StaleHeader is a response header set if the returned value is possibly stale.
+//
+// StaleHeader -- заголовок ответа, задаваемый, если возвращенное значение
+// возможно устарело.
+const StaleHeader = "X-Ddsp-Possibly-Stale"
+
+// MaxValueSize is the maximum size of a value accepted by the gateway.
+//
+// MaxValueSize -- максимальный размер значения, принимаемого шлюзом.
+const MaxValueSize = 64 << 20
+
+// ClusterViewer is implemented by storages describing the cluster,
+// e.g. Frontend.
+//
+// ClusterViewer реализуется хранилищами, описывающими кластер,
+// например Frontend.
+type ClusterViewer interface {
+	ClusterView() frontend.ClusterView
+}
+
+// Gateway serves HTTP requests to the storage.
+//
+// Gateway -- обслуживает HTTP запросы к хранилищу.
+type Gateway struct {
+	st  storage.Storage
+	mux *http.ServeMux
+}
+
+// New creates a new Gateway to st, e.g. a Frontend.
+//
+// New создает новый Gateway к st, например к Frontend.
+func New(st storage.Storage) *Gateway {
+	gw := &Gateway{st: st, mux: http.NewServeMux()}
+	gw.mux.HandleFunc("/records/", gw.record)
+	gw.mux.HandleFunc("/stats", gw.stats)
+	if cv, ok := st.(ClusterViewer); ok {
+		gw.mux.HandleFunc("/cluster", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", "GET")
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(cv.ClusterView())
+		})
+	}
+	return gw
+}
+
+// ServeHTTP implements http.Handler.
+//
+// ServeHTTP реализует http.Handler.
+func (gw *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	gw.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the Gateway at addr.
+//
+// ListenAndServe обслуживает Gateway по адресу addr.
+func (gw *Gateway) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, gw)
+}
+
+func (gw *Gateway) record(w http.ResponseWriter, r *http.Request) {
+	k, err := storage.ParseRecordID(strings.TrimPrefix(r.URL.Path, "/records/"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		d, err := gw.st.Get(k)
+		if errors.Is(err, storage.ErrPossiblyStale) {
+			w.Header().Set(StaleHeader, "true")
+		} else if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(d)
+	case http.MethodPut:
+		d, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxValueSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := gw.st.Put(k, d); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if err := gw.st.Del(k); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (gw *Gateway) stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, err := gw.st.Stats()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+// httpStatus maps storage errors to HTTP status codes.
+var httpStatus = map[storage.StatusCode]int{
+	storage.StatusRecordNotFound:    http.StatusNotFound,
+	storage.StatusVersionNotFound:   http.StatusNotFound,
+	storage.StatusRecordExists:      http.StatusConflict,
+	storage.StatusLocked:            http.StatusConflict,
+	storage.StatusQuorumNotReached:  http.StatusServiceUnavailable,
+	storage.StatusNotEnoughDaemons:  http.StatusServiceUnavailable,
+	storage.StatusRouterUnavailable: http.StatusServiceUnavailable,
+	storage.StatusRateLimited:       http.StatusTooManyRequests,
+	storage.StatusInvalidKey:        http.StatusBadRequest,
+	storage.StatusValueTooLarge:     http.StatusRequestEntityTooLarge,
+	storage.StatusInvalidValue:      http.StatusUnprocessableEntity,
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code, ok := httpStatus[storage.ErrToStatus(err)]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	if d, ok := storage.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	}
+	http.Error(w, err.Error(), code)
+}
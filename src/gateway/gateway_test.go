@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"frontend/frontend"
+	"inproc"
+	"node/node"
+	"storage"
+)
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%v %v error: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%v %v error reading body: %v", method, path, err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestGateway(t *testing.T) {
+	srv := httptest.NewServer(New(node.New(node.Config{})))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		method, path, body string
+		code               int
+		resp               string
+	}{
+		{http.MethodGet, "/records/1", "", http.StatusNotFound, ""},
+		{http.MethodPut, "/records/1", "value", http.StatusCreated, ""},
+		{http.MethodPut, "/records/1", "value", http.StatusConflict, ""},
+		{http.MethodGet, "/records/1", "", http.StatusOK, "value"},
+		{http.MethodGet, "/records/0x00000001", "", http.StatusOK, "value"},
+		{http.MethodGet, "/stats", "", http.StatusOK, "{\"Records\":1,\"Bytes\":5,\"Requests\":5}\n"},
+		{http.MethodDelete, "/records/1", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/records/1", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/records/key", "", http.StatusBadRequest, ""},
+		{http.MethodPost, "/records/1", "", http.StatusMethodNotAllowed, ""},
+	} {
+		code, resp := do(t, srv, tc.method, tc.path, tc.body)
+		if code != tc.code || (tc.resp != "" && resp != tc.resp) {
+			t.Errorf("%v %v got %v %q, want %v %q", tc.method, tc.path, code, resp, tc.code, tc.resp)
+		}
+	}
+}
+
+func TestGatewayCluster(t *testing.T) {
+	c, err := inproc.NewCluster(inproc.Config{})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer c.Stop()
+	srv := httptest.NewServer(New(c))
+	defer srv.Close()
+
+	code, resp := do(t, srv, http.MethodGet, "/cluster", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /cluster got %v %q", code, resp)
+	}
+	var cv frontend.ClusterView
+	if err := json.Unmarshal([]byte(resp), &cv); err != nil {
+		t.Fatalf("GET /cluster returned bad JSON %q: %v", resp, err)
+	}
+	if len(cv.Nodes) != len(c.Nodes) {
+		t.Errorf("GET /cluster got %d nodes, want %d", len(cv.Nodes), len(c.Nodes))
+	}
+	for _, nv := range cv.Nodes {
+		if !nv.Reachable {
+			t.Errorf("GET /cluster got unreachable node %+v", nv)
+		}
+	}
+
+	nodeSrv := httptest.NewServer(New(node.New(node.Config{})))
+	defer nodeSrv.Close()
+	if code, _ := do(t, nodeSrv, http.MethodGet, "/cluster", ""); code != http.StatusNotFound {
+		t.Errorf("GET /cluster of a node got %v, want %v", code, http.StatusNotFound)
+	}
+}
+
+func FuzzGatewayRecord(f *testing.F) {
+	f.Add("1", uint8(0), []byte(""))
+	f.Add("0x0000002a", uint8(1), []byte("value"))
+	f.Add("4294967296", uint8(2), []byte(""))
+	f.Add("key", uint8(3), []byte("value"))
+	gw := New(node.New(node.Config{}))
+	methods := []string{http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPost}
+
+	f.Fuzz(func(t *testing.T, key string, method uint8, body []byte) {
+		r := &http.Request{
+			Method: methods[int(method)%len(methods)],
+			URL:    &url.URL{Path: "/records/" + key},
+			Body:   ioutil.NopCloser(bytes.NewReader(body)),
+		}
+		w := httptest.NewRecorder()
+		gw.record(w, r)
+
+		_, err := storage.ParseRecordID(key)
+		if code := w.Code; (err != nil) != (code == http.StatusBadRequest) || code >= 500 {
+			t.Fatalf("%v /records/%v got %v, key error %v", r.Method, key, code, err)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package inproc
+
+import (
+	"fmt"
+	"time"
+
+	"frontend/frontend"
+	"node/node"
+	"router/router"
+	"storage"
+)
+
+// Heartbeat is a default time interval between heartbeats of nodes
+// of a Cluster.
+//
+// Heartbeat -- интервал по умолчанию между heartbeats nodes в Cluster.
+const Heartbeat = 100 * time.Millisecond
+
+// ForgetTimeout is a default timeout after which the router of a Cluster
+// considers a node unavailable.
+//
+// ForgetTimeout -- время по умолчанию, через которое router в Cluster
+// считает node недоступной.
+const ForgetTimeout = time.Second
+
+// Config stores configuration of a Cluster.
+//
+// Config -- содержит конфигурацию Cluster.
+type Config struct {
+	// Nodes is a number of nodes, storage.ReplicationFactor by default.
+	// Nodes -- количество nodes, по умолчанию storage.ReplicationFactor.
+	Nodes int
+
+	// Router is a configuration of the router. Addr, Nodes and
+	// ForgetTimeout are filled in if they are not set.
+	// Router -- конфигурация router. Addr, Nodes и ForgetTimeout
+	// заполняются, если не заданы.
+	Router router.Config
+	// Node is a configuration shared by all nodes. Addr, Router, Heartbeat,
+	// Client and Peers are filled in.
+	// Node -- общая конфигурация всех nodes. Addr, Router, Heartbeat,
+	// Client и Peers заполняются.
+	Node node.Config
+	// Frontend is a configuration of the frontend. Router, NC, RC and NF
+	// are filled in.
+	// Frontend -- конфигурация frontend. Router, NC, RC и NF заполняются.
+	Frontend frontend.Config
+}
+
+// Cluster is a Frontend, a Router and nodes running in the current process
+// and connected by a Network.
+//
+// Cluster -- Frontend, Router и nodes, работающие в текущем процессе
+// и связанные Network.
+type Cluster struct {
+	*frontend.Frontend
+
+	Network *Network
+	Router  *router.Router
+	Nodes   []*node.Node
+
+	forgetTimeout time.Duration
+}
+
+// NewCluster creates and starts a Cluster with a given cfg.
+//
+// NewCluster создает и запускает Cluster с данной cfg.
+func NewCluster(cfg Config) (*Cluster, error) {
+	if cfg.Nodes == 0 {
+		cfg.Nodes = storage.ReplicationFactor
+	}
+	if cfg.Router.Addr == "" {
+		cfg.Router.Addr = "router"
+	}
+	if cfg.Router.ForgetTimeout == 0 {
+		cfg.Router.ForgetTimeout = ForgetTimeout
+	}
+	if cfg.Node.Heartbeat == 0 {
+		cfg.Node.Heartbeat = Heartbeat
+	}
+	if cfg.Router.NodesFinder.IsZero() {
+		cfg.Router.NodesFinder = router.NewNodesFinder(router.NewMD5Hasher())
+	}
+	if len(cfg.Router.Nodes) == 0 {
+		for i := 1; i <= cfg.Nodes; i++ {
+			cfg.Router.Nodes = append(cfg.Router.Nodes, storage.ServiceAddr(fmt.Sprintf("node%d", i)))
+		}
+	}
+
+	c := &Cluster{Network: NewNetwork(), forgetTimeout: cfg.Router.ForgetTimeout}
+	rtr, err := router.New(cfg.Router)
+	if err != nil {
+		return nil, err
+	}
+	c.Router = rtr
+	c.Network.AddRouter(cfg.Router.Addr, rtr)
+
+	for _, addr := range cfg.Router.Nodes {
+		ncfg := cfg.Node
+		ncfg.Addr = addr
+		ncfg.Router = cfg.Router.Addr
+		ncfg.Client = c.Network.RouterClient()
+		ncfg.Peers = c.Network.NodeClient()
+		if err := ncfg.Validate(); err != nil {
+			c.Stop()
+			return nil, err
+		}
+		n := node.New(ncfg)
+		c.Network.AddNode(addr, n)
+		c.Nodes = append(c.Nodes, n)
+		n.Heartbeats()
+	}
+
+	fcfg := cfg.Frontend
+	fcfg.Router = cfg.Router.Addr
+	fcfg.NC = c.Network.NodeClient()
+	fcfg.RC = c.Network.RouterClient()
+	fcfg.NF = cfg.Router.NodesFinder
+	if err := fcfg.Validate(); err != nil {
+		c.Stop()
+		return nil, err
+	}
+	c.Frontend = frontend.New(fcfg)
+	return c, nil
+}
+
+// Stop stops heartbeats of the nodes and closes them and the frontend.
+//
+// Stop останавливает отправку heartbeats nodes и закрывает их и frontend.
+func (c *Cluster) Stop() {
+	for _, n := range c.Nodes {
+		n.Stop()
+		n.Close()
+	}
+	c.Frontend.Close()
+}
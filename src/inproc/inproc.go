@@ -0,0 +1,469 @@
+// Package inproc implements an in-process transport for embedded
+// deployments, where Frontend, Router and nodes run in the same binary.
+// Clients dispatch requests to services by direct method calls without
+// serialization.
+//
+// Package inproc реализует транспорт внутри процесса для встроенного
+// использования, когда Frontend, Router и nodes работают в одной программе.
+// Клиенты передают запросы сервисам прямым вызовом методов без сериализации.
+package inproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	rclient "router/client"
+	"router/router"
+	"storage"
+)
+
+// ErrNoService is returned by clients if no service is added to the Network
+// at the requested address.
+//
+// ErrNoService возвращается клиентами, если в Network нет сервиса
+// с запрошенным адресом.
+var ErrNoService = errors.New("No service at address")
+
+// Network connects services running in the same process by their addresses.
+//
+// Network -- связывает сервисы, работающие в одном процессе, по их адресам.
+type Network struct {
+	lock    sync.RWMutex
+	nodes   map[storage.ServiceAddr]storage.Storage
+	routers map[storage.ServiceAddr]*router.Router
+}
+
+// NewNetwork creates a new empty Network.
+//
+// NewNetwork создает новую пустую Network.
+func NewNetwork() *Network {
+	return &Network{
+		nodes:   make(map[storage.ServiceAddr]storage.Storage),
+		routers: make(map[storage.ServiceAddr]*router.Router),
+	}
+}
+
+// AddNode makes node available at addr.
+//
+// AddNode делает node доступной по адресу addr.
+func (n *Network) AddNode(addr storage.ServiceAddr, node storage.Storage) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.nodes[addr] = node
+}
+
+// AddRouter makes rtr available at addr.
+//
+// AddRouter делает rtr доступным по адресу addr.
+func (n *Network) AddRouter(addr storage.ServiceAddr, rtr *router.Router) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.routers[addr] = rtr
+}
+
+// Remove makes the service at addr unavailable, e.g. to simulate its failure.
+//
+// Remove делает сервис по адресу addr недоступным, например для
+// имитации его отказа.
+func (n *Network) Remove(addr storage.ServiceAddr) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	delete(n.nodes, addr)
+	delete(n.routers, addr)
+}
+
+func (n *Network) node(addr storage.ServiceAddr) (storage.Storage, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	if node, ok := n.nodes[addr]; ok {
+		return node, nil
+	}
+	return nil, fmt.Errorf("Error dialing %q: %w", addr, ErrNoService)
+}
+
+func (n *Network) router(addr storage.ServiceAddr) (*router.Router, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	if rtr, ok := n.routers[addr]; ok {
+		return rtr, nil
+	}
+	return nil, fmt.Errorf("Error dialing %q: %w", addr, ErrNoService)
+}
+
+// NodeClient returns a client for nodes added to the Network.
+//
+// NodeClient возвращает клиент для nodes, добавленных в Network.
+func (n *Network) NodeClient() storage.Client {
+	return nodeClient{net: n}
+}
+
+// RouterClient returns a client for routers added to the Network.
+//
+// RouterClient возвращает клиент для routers, добавленных в Network.
+func (n *Network) RouterClient() rclient.Client {
+	return routerClient{net: n}
+}
+
+// clone copies d, so neither a caller nor a node can change a value
+// the other one holds, as if it was sent over the network.
+func clone(d []byte) []byte {
+	if d == nil {
+		return nil
+	}
+	return append([]byte(nil), d...)
+}
+
+func cloneRecords(records []storage.Record) []storage.Record {
+	for i := range records {
+		records[i].Data = clone(records[i].Data)
+	}
+	return records
+}
+
+type nodeClient struct {
+	net *Network
+	ctx context.Context
+}
+
+// WithContext returns a copy of c whose writes are made with ctx, e.g.
+// creating its version, see storage.WithVersion.
+func (c nodeClient) WithContext(ctx context.Context) storage.Client {
+	c.ctx = ctx
+	return c
+}
+
+func (c nodeClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c nodeClient) Put(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	if cs, ok := node.(storage.ContextStorage); ok {
+		return cs.PutContext(c.context(), k, clone(d))
+	}
+	return node.Put(k, clone(d))
+}
+
+func (c nodeClient) Update(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	if cu, ok := node.(storage.ContextUpdater); ok {
+		return cu.UpdateContext(c.context(), k, clone(d))
+	}
+	return node.Update(k, clone(d))
+}
+
+func (c nodeClient) Upsert(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	if cu, ok := node.(storage.ContextUpdater); ok {
+		return cu.UpsertContext(c.context(), k, clone(d))
+	}
+	return node.Upsert(k, clone(d))
+}
+
+func (c nodeClient) Merge(addr storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	return node.Merge(k, clone(d))
+}
+
+func (c nodeClient) Get(addr storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, err
+	}
+	d, err := node.Get(k)
+	return clone(d), err
+}
+
+func (c nodeClient) Del(addr storage.ServiceAddr, k storage.RecordID) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	if cs, ok := node.(storage.ContextStorage); ok {
+		return cs.DelContext(c.context(), k)
+	}
+	return node.Del(k)
+}
+
+func (c nodeClient) Scan(addr storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, next, err := node.Scan(cursor, limit)
+	return cloneRecords(records), next, err
+}
+
+func (c nodeClient) AcquireLease(addr storage.ServiceAddr, k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return 0, err
+	}
+	return node.AcquireLease(k, holder, fence, ttl)
+}
+
+func (c nodeClient) LeaseFence(addr storage.ServiceAddr, k storage.RecordID) (uint64, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return 0, err
+	}
+	return node.LeaseFence(k)
+}
+
+func (c nodeClient) ReleaseLease(addr storage.ServiceAddr, k storage.RecordID, holder uint64) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	return node.ReleaseLease(k, holder)
+}
+
+func (c nodeClient) MGet(addr storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	var data [][]byte
+	var errs []error
+	if cb, ok := node.(storage.ContextBatcher); ok {
+		data, errs = cb.MGetContext(c.context(), keys)
+	} else {
+		data, errs = node.MGet(keys)
+	}
+	for i := range data {
+		data[i] = clone(data[i])
+	}
+	return data, errs, nil
+}
+
+func (c nodeClient) MPut(addr storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, err
+	}
+	cloned := make([][]byte, len(data))
+	for i := range data {
+		cloned[i] = clone(data[i])
+	}
+	if cb, ok := node.(storage.ContextBatcher); ok {
+		return cb.MPutContext(c.context(), keys, cloned), nil
+	}
+	return node.MPut(keys, cloned), nil
+}
+
+func (c nodeClient) MDel(addr storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, err
+	}
+	if cb, ok := node.(storage.ContextBatcher); ok {
+		return cb.MDelContext(c.context(), keys), nil
+	}
+	return node.MDel(keys), nil
+}
+
+func (c nodeClient) Delta(addr storage.ServiceAddr, since uint64, limit int) (storage.Delta, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return storage.Delta{}, err
+	}
+	ds, ok := node.(storage.DeltaSource)
+	if !ok {
+		return storage.Delta{}, storage.ErrSeqOutOfRange
+	}
+	delta, err := ds.Delta(since, limit)
+	delta.Records = cloneRecords(delta.Records)
+	return delta, err
+}
+
+func (c nodeClient) Checksum(addr storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return storage.Checksum{}, err
+	}
+	return node.Checksum(k, h)
+}
+
+func (c nodeClient) Paxos(addr storage.ServiceAddr, k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return storage.Promise{}, err
+	}
+	p.Data = clone(p.Data)
+	promise, err := node.Paxos(k, phase, p)
+	promise.Value = clone(promise.Value)
+	promise.Accepted.Data = clone(promise.Accepted.Data)
+	return promise, err
+}
+
+func (c nodeClient) AcquireReadLease(addr storage.ServiceAddr, k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return storage.ReadLease{}, err
+	}
+	lease, err := node.AcquireReadLease(k, holder, ttl)
+	lease.Value = clone(lease.Value)
+	return lease, err
+}
+
+func (c nodeClient) Reserve(addr storage.ServiceAddr, k storage.RecordID, size int64, ttl time.Duration) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	return node.Reserve(k, size, ttl)
+}
+
+func (c nodeClient) CancelReservation(addr storage.ServiceAddr, k storage.RecordID) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	return node.CancelReservation(k)
+}
+
+func (c nodeClient) Sequence(addr storage.ServiceAddr, name string, floor uint64) (uint64, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return 0, err
+	}
+	return node.Sequence(name, floor)
+}
+
+func (c nodeClient) Stats(addr storage.ServiceAddr) (storage.Stats, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return storage.Stats{}, err
+	}
+	return node.Stats()
+}
+
+func (c nodeClient) GetVersion(addr storage.ServiceAddr, k storage.RecordID, version uint64) ([]byte, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, err
+	}
+	d, err := node.GetVersion(k, version)
+	return clone(d), err
+}
+
+func (c nodeClient) ListVersions(addr storage.ServiceAddr, k storage.RecordID) ([]uint64, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, err
+	}
+	return node.ListVersions(k)
+}
+
+func (c nodeClient) Snapshot(addr storage.ServiceAddr, id uint64, phase storage.SnapshotPhase, ttl time.Duration) error {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return err
+	}
+	return node.Snapshot(id, phase, ttl)
+}
+
+func (c nodeClient) ScanSnapshot(addr storage.ServiceAddr, id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, next, err := node.ScanSnapshot(id, cursor, limit)
+	return cloneRecords(records), next, err
+}
+
+func (c nodeClient) ScanChanges(addr storage.ServiceAddr, id, since uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	node, err := c.net.node(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, next, err := node.ScanChanges(id, since, cursor, limit)
+	return cloneRecords(records), next, err
+}
+
+type routerClient struct {
+	net *Network
+	ctx context.Context
+}
+
+// WithContext returns a copy of c whose requests fail once ctx is done.
+func (c routerClient) WithContext(ctx context.Context) rclient.Client {
+	c.ctx = ctx
+	return c
+}
+
+func (c routerClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c routerClient) Heartbeat(addr storage.ServiceAddr, hb storage.Heartbeat) error {
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return err
+	}
+	return rtr.Beat(hb)
+}
+
+func (c routerClient) Heartbeats(addr storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return nil, err
+	}
+	return rtr.Heartbeats(), nil
+}
+
+func (c routerClient) HeartbeatBatch(addr storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return nil, err
+	}
+	return rtr.HeartbeatBatch(beats), nil
+}
+
+func (c routerClient) NodesFind(addr storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+	if err := c.context().Err(); err != nil {
+		return nil, err
+	}
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return nil, err
+	}
+	return rtr.NodesFind(k)
+}
+
+func (c routerClient) List(addr storage.ServiceAddr) ([]storage.ServiceAddr, error) {
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return nil, err
+	}
+	return append([]storage.ServiceAddr(nil), rtr.List()...), nil
+}
+
+func (c routerClient) Orphans(addr, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	rtr, err := c.net.router(addr)
+	if err != nil {
+		return nil, err
+	}
+	return rtr.Orphans(node, keys)
+}
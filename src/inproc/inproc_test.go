@@ -0,0 +1,219 @@
+package inproc
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"node/node"
+	rclient "router/client"
+	"router/router"
+	"storage"
+)
+
+var soak = flag.Duration("soak", 0, "duration of the soak test, e.g. for nightly runs")
+
+func TestCluster(t *testing.T) {
+	c, err := NewCluster(Config{})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer c.Stop()
+
+	d := []byte("value")
+	if err := c.Put(1, d); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	d[0] = 'V'
+	got, err := c.Get(1)
+	if err != nil || string(got) != "value" {
+		t.Errorf("Get() got %q, %v, want %q", got, err, "value")
+	}
+	got[0] = 'V'
+	if got, _ := c.Get(1); string(got) != "value" {
+		t.Errorf("Get() got %q after changing a returned value, want %q", got, "value")
+	}
+	if err := c.Put(1, d); err != storage.ErrRecordExists {
+		t.Errorf("Put() got error %v, want %v", err, storage.ErrRecordExists)
+	}
+
+	c.Network.Remove(c.Router.List()[0])
+	if got, err := c.Get(1); err != nil || string(got) != "value" {
+		t.Errorf("Get() got %q, %v with a node removed, want %q", got, err, "value")
+	}
+}
+
+func TestCluster_Versions(t *testing.T) {
+	c, err := NewCluster(Config{Node: node.Config{Versions: 2}})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer c.Stop()
+
+	if err := c.Put(1, []byte("v1")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := c.Upsert(1, []byte("v2")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	// Replicas number versions the way the frontend does, so they agree.
+	versions, err := c.ListVersions(1)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("ListVersions() got %v, %v, want 2 versions", versions, err)
+	}
+	for _, n := range c.Nodes {
+		if got, err := n.ListVersions(1); err != nil || !reflect.DeepEqual(got, versions) {
+			t.Errorf("ListVersions() of a node got %v, %v, want %v", got, err, versions)
+		}
+	}
+	if got, err := c.GetVersion(1, versions[0]); err != nil || string(got) != "v1" {
+		t.Errorf("GetVersion() got %q, %v, want %q", got, err, "v1")
+	}
+}
+
+func TestCluster_Canceled(t *testing.T) {
+	c, err := NewCluster(Config{})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer c.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rc := rclient.WithContext(c.Network.RouterClient(), ctx)
+	if _, err := rc.NodesFind("router", 1); err != context.Canceled {
+		t.Errorf("NodesFind() got error %v, want %v", err, context.Canceled)
+	}
+	if err := c.PutContext(ctx, 1, []byte("value")); err != context.Canceled {
+		t.Errorf("PutContext() got error %v, want %v", err, context.Canceled)
+	}
+	if _, err := c.Get(1); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+}
+
+func TestNetwork_NoService(t *testing.T) {
+	net := NewNetwork()
+	if _, err := net.NodeClient().Get("node1", 1); !errors.Is(err, ErrNoService) {
+		t.Errorf("Get() got error %v, want %v", err, ErrNoService)
+	}
+	if _, err := net.RouterClient().List("router"); !errors.Is(err, ErrNoService) {
+		t.Errorf("List() got error %v, want %v", err, ErrNoService)
+	}
+}
+
+func TestSoak(t *testing.T) {
+	d := *soak
+	if d == 0 {
+		if testing.Short() {
+			t.Skip("Skipping soak test in short mode")
+		}
+		d = 2 * time.Second
+	}
+	c, err := NewCluster(Config{
+		Router: router.Config{ForgetTimeout: 200 * time.Millisecond},
+		Node:   node.Config{Heartbeat: 20 * time.Millisecond, WarmUp: true, DeltaLog: 1 << 22},
+	})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	defer c.Stop()
+
+	seed := time.Now().UnixNano()
+	res, err := c.Soak(SoakConfig{Duration: d, Seed: seed})
+	if err != nil {
+		t.Fatalf("Soak() with seed %v error: %v", seed, err)
+	}
+	if res.Failures == 0 {
+		t.Errorf("Soak() got no failures of nodes in %v", d)
+	}
+	t.Logf("Soak() made %v operations, %v failed, with %v failures of nodes", res.Ops, res.Failed, res.Failures)
+}
+
+// TestStress runs all kinds of requests to every service of a cluster
+// concurrently while nodes stop heartbeating, so with -race it catches
+// unsynchronized access.
+func TestStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping stress test in short mode")
+	}
+	c, err := NewCluster(Config{
+		Router: router.Config{ForgetTimeout: 200 * time.Millisecond},
+		Node:   node.Config{Heartbeat: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	loop := func(op func(rnd *rand.Rand) error) {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err := op(rnd); storage.ErrToStatus(err) == storage.StatusUnknown {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}(time.Now().UnixNano())
+	}
+	key := func(rnd *rand.Rand) storage.RecordID {
+		return storage.RecordID(1 + rnd.Intn(64))
+	}
+
+	for i := 0; i < 2; i++ {
+		loop(func(rnd *rand.Rand) error { return c.Put(key(rnd), []byte("value")) })
+		loop(func(rnd *rand.Rand) error { _, err := c.Get(key(rnd)); return err })
+		loop(func(rnd *rand.Rand) error { return c.Del(key(rnd)) })
+	}
+	loop(func(rnd *rand.Rand) error {
+		n := c.Nodes[rnd.Intn(len(c.Nodes))]
+		if _, err := n.Get(key(rnd)); err != nil && err != storage.ErrRecordNotFound {
+			return err
+		}
+		_, _, err := n.Scan(storage.Cursor{}, 16)
+		return err
+	})
+	loop(func(rnd *rand.Rand) error {
+		var cursor storage.Cursor
+		for {
+			_, next, err := c.Scan(cursor, 16)
+			if err != nil || next == nil {
+				return err
+			}
+			cursor = next
+		}
+	})
+	loop(func(rnd *rand.Rand) error {
+		id, err := c.TakeSnapshot()
+		if err != nil {
+			return err
+		}
+		defer c.Snapshot(id, storage.SnapshotDrop, 0)
+		_, _, err = c.ScanSnapshot(id, storage.Cursor{}, 16)
+		return err
+	})
+	loop(func(rnd *rand.Rand) error {
+		c.Router.List()
+		_, err := c.Router.NodesFind(key(rnd))
+		return err
+	})
+
+	time.Sleep(time.Second)
+	c.Stop()
+	time.Sleep(500 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,219 @@
+package inproc
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"node/node"
+	"storage"
+)
+
+// SoakConfig stores configuration of a soak test run by Cluster.Soak.
+//
+// SoakConfig -- содержит конфигурацию нагрузочного теста, выполняемого
+// Cluster.Soak.
+type SoakConfig struct {
+	// Duration is how long the test runs.
+	// Duration -- продолжительность теста.
+	Duration time.Duration
+	// Keys is a number of keys operated on, 100 by default.
+	// Keys -- количество используемых ключей, по умолчанию 100.
+	Keys int
+	// Churn is a time interval between failures and recoveries of nodes,
+	// 2 ForgetTimeout of the router by default.
+	// Churn -- интервал между отказами и восстановлениями node,
+	// по умолчанию 2 ForgetTimeout router.
+	Churn time.Duration
+	// RedundancySLA is a time all replicas of acknowledged records should
+	// be restored in after a node recovers, ForgetTimeout by default.
+	// RedundancySLA -- время, за которое после восстановления node должны
+	// быть восстановлены все реплики подтвержденных записей,
+	// по умолчанию ForgetTimeout.
+	RedundancySLA time.Duration
+	// Seed seeds the random operations, so failed runs can be repeated.
+	// Seed -- начальное значение случайных операций, чтобы неудачный запуск
+	// можно было повторить.
+	Seed int64
+}
+
+// SoakResult describes a soak test.
+//
+// SoakResult описывает нагрузочный тест.
+type SoakResult struct {
+	// Ops is a number of operations made.
+	// Ops -- количество выполненных операций.
+	Ops int
+	// Failed is a number of operations which were not acknowledged,
+	// records they changed are no longer checked.
+	// Failed -- количество неподтвержденных операций, записи, которые они
+	// изменяли, больше не проверяются.
+	Failed int
+	// Failures is a number of simulated failures of nodes.
+	// Failures -- количество имитированных отказов node.
+	Failures int
+}
+
+// soakKey is the acknowledged state of a record.
+type soakKey struct {
+	data []byte
+	// tainted is set if an operation on the record was not acknowledged,
+	// so its state is unknown.
+	tainted bool
+}
+
+// Soak runs random Put, Get and Del operations through the frontend while
+// failing and recovering random nodes, and checks that acknowledged values
+// are never lost and that every replica of them is restored within
+// cfg.RedundancySLA after a node recovers. Returns an error describing the
+// first violation found. Nodes are recovered by WarmUp, so cfg.Node.WarmUp
+// of the Cluster should be set.
+//
+// Soak выполняет случайные операции Put, Get и Del через frontend, вызывая
+// отказы и восстановления случайных node, и проверяет, что подтвержденные
+// значения никогда не теряются и что все их реплики восстанавливаются
+// в течение cfg.RedundancySLA после восстановления node. Возвращает ошибку,
+// описывающую первое найденное нарушение. Node восстанавливаются с помощью
+// WarmUp, поэтому для Cluster должен быть задан cfg.Node.WarmUp.
+func (c *Cluster) Soak(cfg SoakConfig) (SoakResult, error) {
+	if cfg.Keys == 0 {
+		cfg.Keys = 100
+	}
+	if cfg.Churn == 0 {
+		cfg.Churn = 2 * c.forgetTimeout
+	}
+	if cfg.RedundancySLA == 0 {
+		cfg.RedundancySLA = c.forgetTimeout
+	}
+	// Nodes remember sequence numbers of peers reported in heartbeats,
+	// so they catch up by Delta after failures.
+	for !c.heartbeating() {
+		time.Sleep(c.forgetTimeout / 10)
+	}
+	for _, n := range c.Nodes {
+		if _, err := n.WarmUp(); err != nil {
+			return SoakResult{}, fmt.Errorf("Failed to warm up: %v", err)
+		}
+	}
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	keys := make([]soakKey, cfg.Keys)
+	var res SoakResult
+	var down *node.Node
+	var downAddr storage.ServiceAddr
+	nextChurn := time.Now().Add(cfg.Churn)
+
+	for end := time.Now().Add(cfg.Duration); time.Now().Before(end); {
+		if time.Now().After(nextChurn) {
+			nextChurn = time.Now().Add(cfg.Churn)
+			if down == nil {
+				i := rnd.Intn(len(c.Nodes))
+				down, downAddr = c.Nodes[i], c.Router.List()[i]
+				c.Network.Remove(downAddr)
+				down.Stop()
+				res.Failures++
+				continue
+			}
+			c.Network.AddNode(downAddr, down)
+			if _, err := down.WarmUp(); err != nil {
+				return res, fmt.Errorf("Failed to warm up %v: %v", downAddr, err)
+			}
+			down.Heartbeats()
+			down = nil
+			if err := c.checkRedundancy(keys, cfg.RedundancySLA); err != nil {
+				return res, err
+			}
+			continue
+		}
+
+		res.Ops++
+		k := storage.RecordID(rnd.Intn(cfg.Keys))
+		state := &keys[k]
+		switch op := rnd.Intn(3); {
+		case op == 0:
+			got, err := c.Get(k)
+			if state.tainted {
+				continue
+			}
+			if state.data == nil && err != storage.ErrRecordNotFound && err != nil {
+				res.Failed++
+				continue
+			}
+			if state.data != nil && (err != nil || !bytes.Equal(got, state.data)) {
+				return res, fmt.Errorf("Acknowledged value %q of key %v is lost: got %q, %v", state.data, k, got, err)
+			}
+		case state.data == nil:
+			d := []byte(fmt.Sprintf("value %v of key %v", res.Ops, k))
+			if err := c.Put(k, d); err != nil {
+				res.Failed++
+				state.tainted = true
+				continue
+			}
+			state.data = d
+		default:
+			if err := c.Del(k); err != nil {
+				res.Failed++
+				state.tainted = true
+				continue
+			}
+			state.data = nil
+		}
+	}
+	if down != nil {
+		c.Network.AddNode(downAddr, down)
+		down.Heartbeats()
+	}
+	return res, nil
+}
+
+// heartbeating reports whether the router got heartbeats of all nodes.
+func (c *Cluster) heartbeating() bool {
+	for _, hb := range c.Router.Heartbeats() {
+		if hb.Seq == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRedundancy checks that acknowledged values of keys are stored on all
+// their replicas within sla.
+func (c *Cluster) checkRedundancy(keys []soakKey, sla time.Duration) error {
+	client := c.Network.NodeClient()
+	deadline := time.Now().Add(sla)
+	for i, state := range keys {
+		if state.tainted || state.data == nil {
+			continue
+		}
+		k := storage.RecordID(i)
+		for {
+			missing, err := c.missingReplica(client, k, state.data)
+			if err == nil && missing == "" {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("Replica %v of key %v is not restored in %v: %v", missing, k, sla, err)
+			}
+			time.Sleep(sla / 20)
+		}
+	}
+	return nil
+}
+
+// missingReplica returns a replica of k not storing d, if any.
+func (c *Cluster) missingReplica(client storage.Client, k storage.RecordID, d []byte) (storage.ServiceAddr, error) {
+	replicas, err := c.Router.NodesFind(k)
+	if err != nil {
+		return "", err
+	}
+	if len(replicas) < storage.ReplicationFactor {
+		return "", fmt.Errorf("only %v replicas available", len(replicas))
+	}
+	for _, replica := range replicas {
+		got, err := client.Get(replica, k)
+		if err != nil || !bytes.Equal(got, d) {
+			return replica, err
+		}
+	}
+	return "", nil
+}
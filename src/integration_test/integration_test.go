@@ -76,6 +76,16 @@ func iterationSimple(t *testing.T, n int) {
 		}
 	}
 
+	got, errs, err := client.MGet(fe[rand.Intn(len(fe))], keys)
+	if err != nil {
+		t.Fatalf("Error getting %v records at once: %v", len(keys), err)
+	}
+	for i, key := range keys {
+		if errs[i] != nil || !bytes.Equal(got[i], getTestData(key)) {
+			t.Fatalf("MGet() of key %v got %v, %v, want %v", key, got[i], errs[i], getTestData(key))
+		}
+	}
+
 	for _, i := range rand.Perm(n) {
 		key := keys[i]
 		data := getTestData(key)
@@ -147,6 +147,7 @@ func (r *Runner) StopFrontends() {
 func (r *Runner) stopFrontends() {
 	for _, fe := range r.fe {
 		fe.srv.Stop()
+		fe.fe.Close()
 	}
 	r.fe = nil
 }
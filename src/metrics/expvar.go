@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+)
+
+// Expvar is a Sink publishing metrics with the expvar package.
+type Expvar struct {
+	prefix    string
+	counters  *expvar.Map
+	gauges    *expvar.Map
+	durations *expvar.Map
+}
+
+func NewExpvar(prefix string) *Expvar {
+	newMap := func(name string) *expvar.Map {
+		if v, ok := expvar.Get(prefix + name).(*expvar.Map); ok {
+			return v
+		}
+		return expvar.NewMap(prefix + name)
+	}
+	return &Expvar{
+		prefix:    prefix,
+		counters:  newMap("counters"),
+		gauges:    newMap("gauges"),
+		durations: newMap("durations_ns"),
+	}
+}
+
+func (e *Expvar) IncrCounter(name string, delta int64) {
+	e.counters.Add(name, delta)
+}
+
+func (e *Expvar) SetGauge(name string, value float64) {
+	v := new(expvar.Float)
+	v.Set(value)
+	e.gauges.Set(name, v)
+}
+
+// ObserveDuration accumulates durations, the number of observations
+// is counted in the name + ".count" counter.
+func (e *Expvar) ObserveDuration(name string, d time.Duration) {
+	e.durations.Add(name, int64(d))
+	e.durations.Add(name+".count", 1)
+}
+
+func (e *Expvar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
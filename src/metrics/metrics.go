@@ -0,0 +1,133 @@
+// Package metrics provides an abstraction over monitoring systems. Services
+// report metrics to a Sink, which is selected in config: Prometheus (pull),
+// statsd (push) or expvar.
+//
+// Package metrics предоставляет абстракцию над системами мониторинга. Сервисы
+// отправляют метрики в Sink, который выбирается в конфигурации: Prometheus
+// (pull), statsd (push) или expvar.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sink is the common interface of metric sinks.
+//
+// Sink это общий интерфейс приемников метрик.
+type Sink interface {
+	// IncrCounter adds delta to the counter with the given name.
+	IncrCounter(name string, delta int64)
+	// SetGauge sets the gauge with the given name to value.
+	SetGauge(name string, value float64)
+	// ObserveDuration records a duration to the histogram with the given name.
+	ObserveDuration(name string, d time.Duration)
+}
+
+const (
+	SinkNone       = ""
+	SinkPrometheus = "prometheus"
+	SinkStatsd     = "statsd"
+	SinkExpvar     = "expvar"
+)
+
+// Config stores configuration of metrics.
+//
+// Config -- содержит конфигурацию метрик.
+type Config struct {
+	// Sink is a name of the sink to use: prometheus, statsd or expvar.
+	// Sink -- имя используемого приемника: prometheus, statsd или expvar.
+	Sink string
+	// Addr is an address to serve pull based metrics at.
+	// Addr -- адрес, по которому отдаются метрики для pull.
+	Addr string
+	// Statsd is an address of statsd daemon.
+	// Statsd -- адрес демона statsd.
+	Statsd string
+	// Prefix is prepended to names of all metrics.
+	// Prefix -- префикс имен всех метрик.
+	Prefix string
+}
+
+// New creates a Sink selected by cfg. For pull based sinks it also returns
+// a http.Handler which should be served at cfg.Addr.
+//
+// New создает Sink, выбранный в cfg. Для pull приемников также возвращается
+// http.Handler, который должен обслуживаться по адресу cfg.Addr.
+func New(cfg Config) (Sink, http.Handler, error) {
+	switch cfg.Sink {
+	case SinkNone:
+		return Discard, nil, nil
+	case SinkPrometheus:
+		s := NewPrometheus(cfg.Prefix)
+		return s, s, nil
+	case SinkStatsd:
+		s, err := NewStatsd(cfg.Statsd, cfg.Prefix)
+		return s, nil, err
+	case SinkExpvar:
+		s := NewExpvar(cfg.Prefix)
+		return s, s, nil
+	default:
+		return nil, nil, fmt.Errorf("Unknown metrics sink %q", cfg.Sink)
+	}
+}
+
+// Serve creates a Sink selected by cfg and serves it at cfg.Addr if needed.
+//
+// Serve создает Sink, выбранный в cfg, и обслуживает его по адресу cfg.Addr,
+// если требуется.
+func Serve(cfg Config) (Sink, error) {
+	sink, handler, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if handler == nil {
+		return sink, nil
+	}
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("Addr should be set for %q metrics sink", cfg.Sink)
+	}
+	go func() {
+		if err := http.ListenAndServe(cfg.Addr, handler); err != nil {
+			panic(fmt.Sprintf("Failed to serve metrics: %v", err))
+		}
+	}()
+	return sink, nil
+}
+
+// ExemplarSink is implemented by sinks able to link observations of
+// histograms to traces.
+//
+// ExemplarSink реализуется приемниками, которые могут связывать
+// наблюдения гистограмм с трассировками.
+type ExemplarSink interface {
+	ObserveDurationWithExemplar(name string, d time.Duration, traceID string)
+}
+
+// ObserveTraced records a duration to the histogram with the given name
+// and attaches traceID as an exemplar if s supports exemplars.
+//
+// ObserveTraced записывает длительность в гистограмму с данным именем
+// и прикрепляет к ней traceID, если s поддерживает exemplars.
+func ObserveTraced(s Sink, name string, d time.Duration, traceID string) {
+	if es, ok := s.(ExemplarSink); ok && traceID != "" {
+		es.ObserveDurationWithExemplar(name, d, traceID)
+		return
+	}
+	s.ObserveDuration(name, d)
+}
+
+// Since records the duration passed since start to the histogram with the given name.
+func Since(s Sink, name string, start time.Time) {
+	s.ObserveDuration(name, time.Since(start))
+}
+
+type discard struct{}
+
+func (discard) IncrCounter(name string, delta int64)         {}
+func (discard) SetGauge(name string, value float64)          {}
+func (discard) ObserveDuration(name string, d time.Duration) {}
+
+// Discard is a Sink dropping all metrics.
+var Discard Sink = discard{}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheus(t *testing.T) {
+	sink, handler, err := New(Config{Sink: SinkPrometheus, Prefix: "ddsp_"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	sink.IncrCounter("frontend.get", 2)
+	sink.SetGauge("node.records", 5)
+	sink.ObserveDuration("frontend.get", 3*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"ddsp_frontend_get 2\n",
+		"ddsp_node_records 5\n",
+		"ddsp_frontend_get_bucket{le=\"0.005\"} 1\n",
+		"ddsp_frontend_get_bucket{le=\"0.001\"} 0\n",
+		"ddsp_frontend_get_count 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics %q don't contain %q", body, want)
+		}
+	}
+}
+
+func TestPrometheus_Exemplars(t *testing.T) {
+	p := NewPrometheus("")
+	ObserveTraced(p, "frontend.get", 3*time.Millisecond, "4bf92f3577b34da6")
+	ObserveTraced(p, "frontend.get", time.Minute, "00f067aa0ba902b7")
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, r)
+	body := w.Body.String()
+	for _, want := range []string{
+		"frontend_get_bucket{le=\"0.005\"} 1 # {trace_id=\"4bf92f3577b34da6\"} 0.003\n",
+		"frontend_get_bucket{le=\"0.01\"} 1\n",
+		"frontend_get_bucket{le=\"+Inf\"} 2 # {trace_id=\"00f067aa0ba902b7\"} 60\n",
+		"# EOF\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics %q don't contain %q", body, want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if body := w.Body.String(); strings.Contains(body, "trace_id") {
+		t.Errorf("Exemplars %q in Prometheus text format", body)
+	}
+}
+
+func TestStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error: %v", err)
+	}
+	defer conn.Close()
+
+	sink, handler, err := New(Config{Sink: SinkStatsd, Statsd: conn.LocalAddr().String(), Prefix: "ddsp."})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if handler != nil {
+		t.Errorf("New() returned handler for push sink")
+	}
+	sink.IncrCounter("frontend.get", 1)
+	sink.ObserveDuration("frontend.get", 1500*time.Microsecond)
+
+	buf := make([]byte, 1024)
+	for _, want := range []string{"ddsp.frontend.get:1|c", "ddsp.frontend.get:1.5|ms"} {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() error: %v", err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("Got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	sink, handler, err := New(Config{Sink: SinkExpvar, Prefix: "ddsp."})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	sink.IncrCounter("frontend.get", 1)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/debug/vars", nil))
+	if body := w.Body.String(); !strings.Contains(body, `"frontend.get": 1`) {
+		t.Errorf("Metrics %q don't contain the counter", body)
+	}
+}
+
+func TestNew_Unknown(t *testing.T) {
+	if _, _, err := New(Config{Sink: "snmp"}); err == nil {
+		t.Errorf("New() expected error for unknown sink")
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are upper bounds of histogram buckets in seconds.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+type exemplar struct {
+	traceID string
+	value   float64
+}
+
+type histogram struct {
+	counts    []uint64
+	count     uint64
+	sum       float64
+	exemplars []exemplar
+}
+
+// Prometheus is a Sink exposing metrics in Prometheus text format.
+type Prometheus struct {
+	prefix     string
+	lock       sync.Mutex
+	counters   map[string]int64
+	gauges     map[string]float64
+	histograms map[string]*histogram
+}
+
+func NewPrometheus(prefix string) *Prometheus {
+	return &Prometheus{
+		prefix:     prefix,
+		counters:   make(map[string]int64),
+		gauges:     make(map[string]float64),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+func (p *Prometheus) name(name string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(p.prefix + name)
+}
+
+func (p *Prometheus) IncrCounter(name string, delta int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.counters[p.name(name)] += delta
+}
+
+func (p *Prometheus) SetGauge(name string, value float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.gauges[p.name(name)] = value
+}
+
+func (p *Prometheus) ObserveDuration(name string, d time.Duration) {
+	p.ObserveDurationWithExemplar(name, d, "")
+}
+
+// ObserveDurationWithExemplar records a duration and remembers traceID as
+// an exemplar of the bucket the duration falls into.
+func (p *Prometheus) ObserveDurationWithExemplar(name string, d time.Duration, traceID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	name = p.name(name)
+	h, ok := p.histograms[name]
+	if !ok {
+		h = &histogram{
+			counts:    make([]uint64, len(DefaultBuckets)),
+			exemplars: make([]exemplar, len(DefaultBuckets)+1),
+		}
+		p.histograms[name] = h
+	}
+	v := d.Seconds()
+	bucket := len(DefaultBuckets)
+	for i := len(DefaultBuckets) - 1; i >= 0 && v <= DefaultBuckets[i]; i-- {
+		h.counts[i]++
+		bucket = i
+	}
+	h.count++
+	h.sum += v
+	if traceID != "" {
+		h.exemplars[bucket] = exemplar{traceID: traceID, value: v}
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]int64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]float64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*histogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const openMetricsType = "application/openmetrics-text"
+
+// ServeHTTP writes all metrics in Prometheus text exposition format.
+// If the client accepts OpenMetrics format, it is used instead and
+// histogram buckets are annotated with trace ID exemplars.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), openMetricsType)
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsType+"; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	}
+	for _, name := range sortedKeys(p.counters) {
+		sample := name
+		if openMetrics {
+			sample += "_total"
+		}
+		fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", name, sample, p.counters[name])
+	}
+	for _, name := range sortedKeys(p.gauges) {
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s %g\n", name, name, p.gauges[name])
+	}
+	for _, name := range sortedKeys(p.histograms) {
+		h := p.histograms[name]
+		fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+		writeBucket := func(i int, le string, count uint64) {
+			fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d", name, le, count)
+			if e := h.exemplars[i]; openMetrics && e.traceID != "" {
+				fmt.Fprintf(w, " # {trace_id=%q} %g", e.traceID, e.value)
+			}
+			fmt.Fprintln(w)
+		}
+		for i, bound := range DefaultBuckets {
+			writeBucket(i, fmt.Sprintf("%g", bound), h.counts[i])
+		}
+		writeBucket(len(DefaultBuckets), "+Inf", h.count)
+		fmt.Fprintf(w, "%s_sum %g\n%s_count %d\n", name, h.sum, name, h.count)
+	}
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Statsd is a Sink pushing metrics to statsd daemon over UDP.
+type Statsd struct {
+	prefix string
+	conn   net.Conn
+}
+
+func NewStatsd(addr, prefix string) (*Statsd, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("Statsd address should be set")
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to dial statsd %q: %v", addr, err)
+	}
+	return &Statsd{prefix: prefix, conn: conn}, nil
+}
+
+func (s *Statsd) send(format string, args ...interface{}) {
+	// Metrics are best effort, errors are ignored.
+	fmt.Fprintf(s.conn, format, args...)
+}
+
+func (s *Statsd) IncrCounter(name string, delta int64) {
+	s.send("%s%s:%d|c", s.prefix, name, delta)
+}
+
+func (s *Statsd) SetGauge(name string, value float64) {
+	s.send("%s%s:%g|g", s.prefix, name, value)
+}
+
+func (s *Statsd) ObserveDuration(name string, d time.Duration) {
+	s.send("%s%s:%g|ms", s.prefix, name, float64(d)/float64(time.Millisecond))
+}
@@ -7,8 +7,11 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 
+	"auth"
+	"fault"
 	"node/node"
-	"router/client"
+	"registry"
+	"secrets"
 	"storage"
 )
 
@@ -16,51 +19,175 @@ func usage() {
 	fmt.Println("node -- service to store data for the distributed KV storage")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Printf("%24s\n\n", "node <conf.yaml>")
+	fmt.Printf("%24s\n\n", "node [conf.yaml ...]")
+	fmt.Println("Several configs run co-located nodes in one process which")
+	fmt.Println("send batched heartbeats to their routers.")
+	fmt.Println()
+	fmt.Println("Config fields can be set or overridden with environment variables,")
+	fmt.Printf("e.g. %s_ADDR or %s_METRICS_SINK, they apply to every config.\n", envPrefix, envPrefix)
 }
 
+// envPrefix is a prefix of environment variables overriding config fields,
+// e.g. DDSP_NODE_ADDR.
+const envPrefix = "DDSP_NODE"
+
 func parseConfig(fname string) (cfg node.Config, err error) {
-	f, err := os.Open(fname)
+	src := "environment"
+	if fname != "" {
+		src = fmt.Sprintf("config file %q", fname)
+		f, err := os.Open(fname)
+		if err != nil {
+			return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
+		}
+	}
+	if err := storage.ApplyEnv(envPrefix, &cfg); err != nil {
+		return cfg, fmt.Errorf("Failed to parse environment: %v", err)
+	}
+
+	var errs storage.ConfigError
+	errs.Check(cfg.Addr != "", "Addr should be set")
+	errs.Check(cfg.Router != "", "Router should be set")
+	errs.Check(cfg.Heartbeat > 0, "Heartbeat should be set and be positive")
+	errs.Merge(cfg.Validate())
+	if err := errs.Err(); err != nil {
+		return cfg, fmt.Errorf("Failed to parse %v: %v", src, err)
+	}
+
+	return cfg, nil
+}
+
+// start starts a node with the given raw cfg and returns it with its server
+// and cfg with resolved secrets, which are rotated in the background.
+func start(raw node.Config) (*node.Node, *storage.Server, node.Config) {
+	provider, err := secrets.New(raw.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up secrets: %v", err)
+	}
+	cfg := raw
+	if err := secrets.Resolve(provider, &cfg); err != nil {
+		log.Fatalf("Failed to resolve secrets: %v", err)
+	}
+	cfg.Signer, err = storage.NewSigner(cfg.Signing, cfg.Clock)
 	if err != nil {
-		return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+		log.Fatalf("Failed to set up signing: %v", err)
 	}
-	defer f.Close()
-	dec := yaml.NewDecoder(f)
-	if err := dec.Decode(&cfg); err != nil {
-		return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
 
+	st, err := node.NewDefault(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create node: %v", err)
+	}
+	if cfg.GCInterval > 0 {
+		st.GCs()
 	}
-	if cfg.Addr == "" {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Addr should be set", fname)
+	if cfg.CompactInterval > 0 {
+		st.Compactions()
 	}
-	if cfg.Router == "" {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Router should be set", fname)
+	if cfg.Tiering.Interval > 0 {
+		st.Tierings()
 	}
-	if cfg.Heartbeat == 0 {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Hearbeat should be set", fname)
+	if cfg.WarmUp {
+		if res, err := st.WarmUp(); err != nil {
+			log.Printf("Failed to warm up node, starting with local records: %v", err)
+		} else {
+			log.Printf("Warmed up from %d nodes: %d records missed, %d copied", res.Peers, res.Missing, res.Copied)
+		}
 	}
 
-	return cfg, nil
+	reg, err := registry.New(cfg.Registry)
+	if err != nil {
+		log.Fatalf("Failed to set up registry: %v", err)
+	}
+	if reg != nil {
+		registry.Keep(reg, cfg.Addr, cfg.Registry.TTL)
+	}
+
+	authz := auth.New(cfg.Auth)
+	faults, err := fault.Serve(cfg.Faults, authz)
+	if err != nil {
+		log.Fatalf("Failed to set up failure injection: %v", err)
+	}
+	rotate(provider, raw, cfg.Signer, authz)
+
+	// Signatures are verified before faults are injected, so tampered
+	// requests are rejected regardless of them.
+	opts := storage.UnaryInterceptors(cfg.Signer.Server(), faults)
+	return st, storage.NewServer(st, string(cfg.Addr), opts...), cfg
+}
+
+// rotate replaces signing keys of signer and admin tokens of authz when
+// the secrets raw refers to change in provider.
+func rotate(provider secrets.Provider, raw node.Config, signer *storage.Signer, authz auth.Authorizer) {
+	if signer != nil {
+		secrets.Watch(provider, raw.Secrets.Interval, raw.Signing, func(resolved interface{}) {
+			if err := signer.Rotate(resolved.(storage.SigningConfig)); err != nil {
+				log.Printf("Failed to rotate signing keys: %v", err)
+			}
+		})
+	}
+	if tokens, ok := authz.(*auth.Tokens); ok {
+		secrets.Watch(provider, raw.Secrets.Interval, raw.Auth, func(resolved interface{}) {
+			tokens.Set(resolved.(auth.Config))
+		})
+	}
 }
 
 func main() {
-	if len(os.Args) != 2 {
+	fnames := os.Args[1:]
+	if len(fnames) == 1 && (fnames[0] == "-h" || fnames[0] == "--help") {
 		usage()
-		os.Exit(1)
+		return
+	}
+	if len(fnames) == 0 {
+		fnames = []string{""}
 	}
 
-	cfg, err := parseConfig(os.Args[1])
-	if err != nil {
-		log.Fatal(err)
+	cfgs := make([]node.Config, 0, len(fnames))
+	for _, fname := range fnames {
+		cfg, err := parseConfig(fname)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfgs = append(cfgs, cfg)
 	}
 
-	cfg.Client = client.New()
+	if len(cfgs) == 1 {
+		st, srv, _ := start(cfgs[0])
+		st.Heartbeats()
+		if err := srv.ListenAndServe(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Agent mode: heartbeats of nodes sharing a router are batched.
+	agents := make(map[storage.ServiceAddr]*node.Agent)
+	errs := make(chan error, len(cfgs))
+	for _, raw := range cfgs {
+		st, srv, cfg := start(raw)
 
-	st := node.New(cfg)
-	st.Heartbeats()
+		agent, ok := agents[cfg.Router]
+		if !ok {
+			c, err := node.NewDefaultClient(cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			agent = node.NewAgent(cfg.Router, c, cfg.Heartbeat)
+			agents[cfg.Router] = agent
+		}
+		agent.Add(st)
 
-	srv := storage.NewServer(st, string(cfg.Addr))
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal(err)
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
 	}
+	for _, agent := range agents {
+		agent.Heartbeats()
+	}
+
+	log.Fatal(<-errs)
 }
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	router "router/client"
+	"storage"
+)
+
+// Agent sends heartbeats of several nodes running on the same host
+// to a router in one batched call instead of a call per node.
+//
+// Agent отправляет heartbeats нескольких node, запущенных на одном хосте,
+// в router одним пакетным вызовом вместо вызова на каждую node.
+type Agent struct {
+	router    storage.ServiceAddr
+	client    router.Client
+	interval  time.Duration
+	heartbeat chan struct{}
+	nodes     []*Node
+	lock      sync.Mutex
+}
+
+// NewAgent creates a new Agent sending heartbeats to rtr using client
+// each time interval.
+//
+// NewAgent создает новый Agent, отправляющий heartbeats в rtr с помощью
+// client через каждый интервал времени interval.
+func NewAgent(rtr storage.ServiceAddr, client router.Client, interval time.Duration) *Agent {
+	return &Agent{
+		router:    rtr,
+		client:    client,
+		interval:  interval,
+		heartbeat: make(chan struct{}),
+	}
+}
+
+// Add makes the agent send heartbeats of node. The node itself
+// should not run Heartbeats.
+//
+// Add добавляет node, heartbeats которой отправляет agent. Сама node
+// не должна запускать Heartbeats.
+func (a *Agent) Add(node *Node) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.nodes = append(a.nodes, node)
+}
+
+// Heartbeats runs batched heartbeats of all added nodes.
+//
+// Heartbeats запускает отправку пакетных heartbeats всех добавленных node.
+func (a *Agent) Heartbeats() {
+	go func() {
+		for {
+			select {
+			case <-a.heartbeat:
+				return
+			default:
+				a.beat()
+				time.Sleep(a.interval)
+			}
+		}
+	}()
+}
+
+// Stop stops heartbeats.
+//
+// Stop останавливает отправку heartbeats.
+func (a *Agent) Stop() {
+	a.heartbeat <- struct{}{}
+}
+
+func (a *Agent) beat() {
+	a.lock.Lock()
+	beats := make([]storage.Heartbeat, 0, len(a.nodes))
+	for _, node := range a.nodes {
+		beats = append(beats, node.beat())
+	}
+	a.lock.Unlock()
+
+	errs, err := a.client.HeartbeatBatch(a.router, beats)
+	if err != nil {
+		log.Printf("Failed to send heartbeats to %q: %v", a.router, err)
+		return
+	}
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("Heartbeat of %q rejected: %v", beats[i].Node, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package node
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheBlockSize is a size of blocks of the disk log kept in the block cache.
+//
+// CacheBlockSize -- размер блоков лога на диске, хранящихся в кэше блоков.
+const CacheBlockSize = 4 << 10
+
+type blockKey struct {
+	seg int
+	n   int64
+}
+
+type cachedBlock struct {
+	key blockKey
+	d   []byte
+}
+
+// blockCache is an LRU cache of blocks of disk log segments. Blocks at the
+// end of the active segment may be cached partially, they are read again
+// when more of them is needed.
+type blockCache struct {
+	lock   sync.Mutex
+	size   int64
+	max    int64
+	lru    *list.List
+	blocks map[blockKey]*list.Element
+	hits   int64
+	misses int64
+}
+
+// newBlockCache creates a cache keeping at most max bytes, or nil if max
+// is not positive.
+func newBlockCache(max int64) *blockCache {
+	if max <= 0 {
+		return nil
+	}
+	return &blockCache{
+		max:    max,
+		lru:    list.New(),
+		blocks: make(map[blockKey]*list.Element),
+	}
+}
+
+// get returns at least need first bytes of the block with key k
+// if they are cached.
+func (c *blockCache) get(k blockKey, need int) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if el, ok := c.blocks[k]; ok {
+		if b := el.Value.(*cachedBlock); len(b.d) >= need {
+			c.lru.MoveToFront(el)
+			c.hits++
+			return b.d, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+// put caches d as the block with key k evicting the least recently used
+// blocks over the size limit.
+func (c *blockCache) put(k blockKey, d []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if el, ok := c.blocks[k]; ok {
+		b := el.Value.(*cachedBlock)
+		c.size += int64(len(d) - len(b.d))
+		b.d = d
+		c.lru.MoveToFront(el)
+	} else {
+		c.blocks[k] = c.lru.PushFront(&cachedBlock{key: k, d: d})
+		c.size += int64(len(d))
+	}
+	for c.size > c.max {
+		c.evict(c.lru.Back())
+	}
+}
+
+func (c *blockCache) evict(el *list.Element) {
+	b := c.lru.Remove(el).(*cachedBlock)
+	delete(c.blocks, b.key)
+	c.size -= int64(len(b.d))
+}
+
+// purge drops cached blocks of the segment seg.
+func (c *blockCache) purge(seg int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k, el := range c.blocks {
+		if k.seg == seg {
+			c.evict(el)
+		}
+	}
+}
+
+// stats returns the numbers of hits and misses and the size of cached blocks.
+func (c *blockCache) stats() (hits, misses, size int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hits, c.misses, c.size
+}
@@ -0,0 +1,240 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// CompactGarbageRatio is a default share of garbage in a sealed segment
+// from which it is compacted.
+//
+// CompactGarbageRatio -- доля мусора в закрытом сегменте по умолчанию,
+// начиная с которой он сжимается.
+const CompactGarbageRatio = 0.5
+
+// CompactResult describes a compaction of the disk log.
+//
+// CompactResult описывает сжатие лога на диске.
+type CompactResult struct {
+	// Segments is a number of compacted segments.
+	// Segments -- количество сжатых сегментов.
+	Segments int
+	// Copied is a number of bytes of live values copied to the active segment.
+	// Copied -- количество байт живых значений, скопированных в активный сегмент.
+	Copied int64
+	// Reclaimed is a number of bytes of garbage freed.
+	// Reclaimed -- количество освобожденных байт мусора.
+	Reclaimed int64
+}
+
+// liveEntries returns distinct entries on disk referenced by records,
+// their versions, snapshots and iterations, grouped by segments and keyed
+// by offsets.
+// Should be called with node.lock held.
+func (node *Node) liveEntries() map[int]map[int64]entry {
+	live := make(map[int]map[int64]entry)
+	add := func(e entry) {
+		if !e.disk {
+			return
+		}
+		if live[e.seg] == nil {
+			live[e.seg] = make(map[int64]entry)
+		}
+		live[e.seg][e.off] = e
+	}
+	for _, e := range node.storage {
+		add(e)
+	}
+	for _, h := range node.history {
+		for _, v := range h.versions {
+			add(v.e)
+		}
+	}
+	for _, s := range node.snapshots {
+		for _, e := range s.records {
+			add(e)
+		}
+	}
+	for it := range node.iters {
+		for _, e := range it.records {
+			add(e)
+		}
+	}
+	return live
+}
+
+// relocate replaces references to entries of the segment seg with their
+// copies in moved keyed by offsets, journaling new locations of records
+// of durable classes. Should be called with node.lock held.
+func (node *Node) relocate(seg int, moved map[int64]entry) error {
+	move := func(e entry) entry {
+		if e.disk && e.seg == seg {
+			return moved[e.off]
+		}
+		return e
+	}
+	for k, e := range node.storage {
+		ne := move(e)
+		if ne.seg != e.seg {
+			if err := node.journal(k, ne, false); err != nil {
+				return err
+			}
+		}
+		node.storage[k] = ne
+	}
+	for _, h := range node.history {
+		for i := range h.versions {
+			h.versions[i].e = move(h.versions[i].e)
+		}
+	}
+	for _, s := range node.snapshots {
+		for k, e := range s.records {
+			s.records[k] = move(e)
+		}
+	}
+	for it := range node.iters {
+		for k, e := range it.records {
+			it.records[k] = move(e)
+		}
+	}
+	return nil
+}
+
+// victims chooses sealed segments to compact: the ones with a share of
+// garbage of at least cfg.CompactGarbageRatio and the ones with the most
+// garbage over cfg.CompactMaxSegments.
+func (node *Node) victims(sizes map[int]int64, live map[int]int64) []int {
+	ids := make([]int, 0, len(sizes))
+	for id := range sizes {
+		ids = append(ids, id)
+	}
+	garbage := func(id int) int64 {
+		return sizes[id] - live[id]
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return garbage(ids[i]) > garbage(ids[j])
+	})
+
+	var victims []int
+	for i, id := range ids {
+		ratio := 1.0
+		if sizes[id] > 0 {
+			ratio = float64(garbage(id)) / float64(sizes[id])
+		}
+		over := node.conf.CompactMaxSegments > 0 && len(ids)-i > node.conf.CompactMaxSegments
+		if ratio >= node.conf.CompactGarbageRatio || over {
+			victims = append(victims, id)
+		}
+	}
+	return victims
+}
+
+// Compact reclaims space of values in the disk log which are no longer
+// referenced by records, their versions or snapshots. Live values of the
+// chosen sealed segments are copied to the active one at cfg.CompactRate
+// and the segments are removed.
+//
+// Compact освобождает место, занятое в логе на диске значениями, на которые
+// больше не ссылаются записи, их версии или снимки. Живые значения выбранных
+// закрытых сегментов копируются в активный со скоростью cfg.CompactRate,
+// после чего сегменты удаляются.
+func (node *Node) Compact() (CompactResult, error) {
+	var res CompactResult
+	if node.conf.DataDir == "" {
+		return res, nil
+	}
+	if err := node.Open(); err != nil {
+		return res, err
+	}
+	node.compLock.Lock()
+	defer node.compLock.Unlock()
+
+	sizes := node.disk.sealed()
+	node.lock.RLock()
+	entries := node.liveEntries()
+	node.lock.RUnlock()
+
+	live := make(map[int]int64, len(entries))
+	var total, garbage int64
+	for id, es := range entries {
+		for _, e := range es {
+			live[id] += int64(e.size)
+		}
+	}
+	for id, size := range sizes {
+		total += size
+		garbage += size - live[id]
+	}
+	if total > 0 {
+		node.conf.Sink.SetGauge("node.disk.garbage_ratio", float64(garbage)/float64(total))
+	}
+
+	for _, id := range node.victims(sizes, live) {
+		moved := make(map[int64]entry, len(entries[id]))
+		for off, e := range entries[id] {
+			d, err := node.disk.readRaw(e)
+			if err != nil {
+				node.reportError(err)
+				return res, err
+			}
+			ne, err := node.disk.append(node.conf.DataDir, d)
+			if err != nil {
+				node.reportError(err)
+				return res, err
+			}
+			moved[off] = ne
+			res.Copied += int64(len(d))
+			node.compactRate.WaitN(context.Background(), len(d))
+		}
+
+		node.lock.Lock()
+		err := node.relocate(id, moved)
+		node.lock.Unlock()
+		if err != nil {
+			return res, err
+		}
+		// Copies and their journal records are durable before the segment
+		// is removed.
+		if err := node.disk.sync(context.Background()); err != nil {
+			node.reportError(err)
+			return res, err
+		}
+
+		// Wait for values of the segment being read without node.lock.
+		node.segLock.Lock()
+		err = node.disk.remove(id)
+		node.segLock.Unlock()
+		if err != nil {
+			node.reportError(err)
+			return res, err
+		}
+		res.Segments++
+		res.Reclaimed += sizes[id] - live[id]
+		node.conf.Sink.IncrCounter("node.compaction.segments", 1)
+		node.conf.Sink.IncrCounter("node.compaction.reclaimed_bytes", sizes[id]-live[id])
+	}
+	return res, nil
+}
+
+// Compactions runs compactions of the disk log each time interval set by
+// cfg.CompactInterval.
+//
+// Compactions запускает сжатия лога на диске через каждый интервал времени,
+// заданный в cfg.CompactInterval.
+func (node *Node) Compactions() {
+	go func() {
+		for {
+			time.Sleep(node.conf.CompactInterval)
+			res, err := node.Compact()
+			if err != nil {
+				node.conf.Logger.Printf("Compaction failed: %v", err)
+				continue
+			}
+			if res.Segments > 0 {
+				node.conf.Logger.Printf("Compaction: compacted %d segments, copied %d bytes, reclaimed %d bytes",
+					res.Segments, res.Copied, res.Reclaimed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"context"
+
+	"storage"
+)
+
+// GetContext is Get returning ctx.Err() if ctx is done.
+//
+// GetContext -- Get, возвращающий ctx.Err(), если ctx завершен.
+func (node *Node) GetContext(ctx context.Context, k storage.RecordID) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return node.Get(k)
+}
+
+// MGetContext is MGet returning ctx.Err() for every key if ctx is done.
+//
+// MGetContext -- MGet, возвращающий ctx.Err() для каждого ключа, если ctx
+// завершен.
+func (node *Node) MGetContext(ctx context.Context, keys []storage.RecordID) ([][]byte, []error) {
+	if err := ctx.Err(); err != nil {
+		errs := make([]error, len(keys))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([][]byte, len(keys)), errs
+	}
+	return node.MGet(keys)
+}
+
+// DelContext is Del returning ctx.Err() if ctx is done, so requests
+// canceled by frontends are not applied. Only the value of the version
+// storage.VersionOf(ctx) is deleted if it is set, so writers can delete
+// their own values without deleting the ones written since.
+//
+// DelContext -- Del, возвращающий ctx.Err(), если ctx завершен, так что
+// запросы, отмененные frontend, не применяются. Если задана версия
+// storage.VersionOf(ctx), удаляется только значение этой версии, так что
+// записывающие могут удалить свои значения, не удаляя записанные позже.
+func (node *Node) DelContext(ctx context.Context, k storage.RecordID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := node.allow(1); err != nil {
+		return err
+	}
+	return node.delVersion(k, storage.VersionOf(ctx))
+}
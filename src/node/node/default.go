@@ -0,0 +1,93 @@
+package node
+
+import (
+	"fmt"
+	"os"
+
+	"metrics"
+	router "router/client"
+	"storage"
+)
+
+// NewDefaultClient creates the shipped gRPC client for Router resolving its
+// address as described by cfg.Resolve and sending heartbeats over UDP if
+// cfg.RouterUDP is set.
+//
+// NewDefaultClient создает поставляемый gRPC клиент для Router, разрешающий
+// его адрес способом, описанным cfg.Resolve, и отправляющий heartbeats по
+// UDP, если задан cfg.RouterUDP.
+func NewDefaultClient(cfg Config) (router.Client, error) {
+	resolver, err := storage.NewResolver(cfg.Resolve)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create resolver: %v", err)
+	}
+	c := router.NewWithResolver(resolver)
+	if cfg.RouterUDP != "" {
+		return router.NewUDP(c, cfg.RouterUDP, []byte(cfg.UDPKey)), nil
+	}
+	return c, nil
+}
+
+// NewDefault creates a new Node with a given cfg modified by opts wiring
+// the client returned by NewDefaultClient, the gRPC client of other nodes,
+// the metrics sink described by cfg.Metrics and the GC archive appending
+// to cfg.GCArchiveFile for the fields which are not set. Opens cfg.DataDir
+// if it is set. Returns an error if cfg is invalid or the data directory
+// can't be used.
+//
+// NewDefault создает новый Node с данным cfg, измененной opts, подставляя
+// в незаданные поля клиент, возвращаемый NewDefaultClient, gRPC клиент
+// других node, приемник метрик, описанный cfg.Metrics, и архив GC,
+// дописываемый в cfg.GCArchiveFile. Открывает cfg.DataDir, если она задана.
+// Возвращает ошибку, если cfg некорректна или директорию данных невозможно
+// использовать.
+func NewDefault(cfg Config, opts ...Option) (*Node, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var err error
+	if cfg.Client == nil {
+		cfg.Client, err = NewDefaultClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Peers == nil {
+		resolver, err := storage.NewResolver(cfg.Resolve)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create resolver: %v", err)
+		}
+		signer := cfg.Signer
+		if signer == nil {
+			if signer, err = storage.NewSigner(cfg.Signing, cfg.Clock); err != nil {
+				return nil, err
+			}
+		}
+		cfg.Peers = storage.NewSigningClient(resolver, signer)
+	}
+
+	if cfg.Sink == nil {
+		cfg.Sink, err = metrics.Serve(cfg.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to set up metrics: %v", err)
+		}
+	}
+
+	if cfg.GCArchive == nil && cfg.GCArchiveFile != "" {
+		f, err := os.OpenFile(cfg.GCArchiveFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open GC archive file: %v", err)
+		}
+		cfg.GCArchive = f
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	node := New(cfg)
+	if err := node.Open(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"errors"
+
+	"storage"
+)
+
+// DeltaLog is a default number of the latest operations kept for Delta.
+//
+// DeltaLog -- количество последних операций, хранящихся для Delta,
+// по умолчанию.
+const DeltaLog = 1 << 16
+
+// logOp appends a change of the record with key k made by the operation
+// node.seq to the log of operations, dropping the oldest ones over
+// cfg.DeltaLog. Should be called with node.lock held.
+func (node *Node) logOp(k storage.RecordID) {
+	node.ops = append(node.ops, k)
+	if len(node.ops) >= 2*node.conf.DeltaLog {
+		drop := len(node.ops) - node.conf.DeltaLog
+		node.ops = append(node.ops[:0], node.ops[drop:]...)
+		node.opsStart += uint64(drop)
+	}
+}
+
+// errChangedLocally is returned by applyBatchSince for ops on records
+// changed after the given sequence number.
+var errChangedLocally = errors.New("Record is changed locally")
+
+// changedSince returns a function reporting whether the record with key k
+// was changed by the operations after the sequence number since. All records
+// are reported changed if the operations are no longer kept. Should be
+// called with node.lock held.
+func (node *Node) changedSince(since uint64) func(k storage.RecordID) bool {
+	if since < node.opsStart {
+		return func(storage.RecordID) bool { return true }
+	}
+	changed := make(map[storage.RecordID]bool)
+	for _, k := range node.ops[min(since-node.opsStart, uint64(len(node.ops))):] {
+		changed[k] = true
+	}
+	return func(k storage.RecordID) bool { return changed[k] }
+}
+
+// Seq returns the sequence number of the last operation applied by the node.
+// Sequence numbers start from the time the node was created in unix
+// nanoseconds, so they grow across restarts.
+//
+// Seq возвращает номер последовательности последней операции, примененной
+// node. Номера последовательности начинаются со времени создания node
+// в наносекундах unix, поэтому растут и между перезапусками.
+func (node *Node) Seq() uint64 {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	return node.seq
+}
+
+// Delta returns the latest state of at most limit records changed by
+// operations after the sequence number since. Records deleted since are
+// returned with Deleted set. Returns storage.ErrSeqOutOfRange if the
+// operations after since are no longer kept or since is ahead of the node,
+// e.g. it was restarted, then the caller should fall back to a full scan.
+//
+// Delta возвращает последнее состояние не более limit записей, измененных
+// операциями после номера последовательности since. Записи, удаленные
+// за это время, возвращаются с установленным Deleted. Возвращает
+// storage.ErrSeqOutOfRange, если операции после since больше не хранятся
+// или since опережает node, например после ее перезапуска, тогда вызывающему
+// следует перейти к полному сканированию.
+func (node *Node) Delta(since uint64, limit int) (storage.Delta, error) {
+	limit = storage.ScanLimitOf(limit)
+	node.lock.RLock()
+	if since < node.opsStart || since > node.seq {
+		node.lock.RUnlock()
+		return storage.Delta{}, storage.ErrSeqOutOfRange
+	}
+
+	delta := storage.Delta{Seq: node.seq}
+	seen := make(map[storage.RecordID]bool)
+	var deleted []storage.Record
+	records := make([]storage.Record, 0, limit)
+	entries := make([]entry, 0, limit)
+	for i := since - node.opsStart; i < uint64(len(node.ops)); i++ {
+		k := node.ops[i]
+		if seen[k] {
+			continue
+		}
+		if len(seen) == limit {
+			delta.Seq, delta.More = node.opsStart+i, true
+			break
+		}
+		seen[k] = true
+		if e, ok := node.storage[k]; ok {
+			records = append(records, storage.Record{Key: k})
+			entries = append(entries, e)
+		} else {
+			deleted = append(deleted, storage.Record{Key: k, Deleted: true})
+		}
+	}
+
+	records, _, err := node.fill(context.Background(), records, entries, nil, nil)
+	if err != nil {
+		return storage.Delta{}, err
+	}
+	delta.Records = append(records, deleted...)
+	return delta, nil
+}
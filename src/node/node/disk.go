@@ -0,0 +1,580 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"metrics"
+	"storage"
+)
+
+const (
+	// LargeValueThreshold is a default size of values from which they are
+	// stored in the disk log if cfg.DataDir is set.
+	//
+	// LargeValueThreshold -- размер значений по умолчанию, начиная с которого
+	// они хранятся в логе на диске, если задан cfg.DataDir.
+	LargeValueThreshold = 4 << 10
+
+	// SegmentSize is a default size of a disk log segment after which
+	// a new segment is started.
+	//
+	// SegmentSize -- размер сегмента лога на диске по умолчанию, после
+	// которого начинается новый сегмент.
+	SegmentSize = 64 << 20
+
+	// ValuesFile is a name of the disk log in cfg.DataDir in data formats
+	// before 3, which have a single segment.
+	//
+	// ValuesFile -- имя лога на диске в cfg.DataDir в форматах данных до 3,
+	// в которых он состоит из одного сегмента.
+	ValuesFile = "values.log"
+)
+
+// SegmentFile returns a name of the disk log segment with the given id
+// in cfg.DataDir.
+//
+// SegmentFile возвращает имя сегмента лога на диске с данным id в cfg.DataDir.
+func SegmentFile(id int) string {
+	return fmt.Sprintf("values-%06d.log", id)
+}
+
+// entry is data of a record stored either in memory, in the disk log or
+// as the object obj of the object store. sum is the CRC-32C checksum of
+// values in the disk log, verified when they are read.
+type entry struct {
+	d    []byte
+	seg  int
+	off  int64
+	size int
+	disk bool
+	obj  string
+	sum  uint32
+}
+
+// castagnoli is the table of checksums of values in the disk log.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// verify returns d read for e, or an error wrapping
+// storage.ErrChecksumMismatch if d doesn't match the checksum of e.
+func verify(e entry, d []byte) ([]byte, error) {
+	if sum := crc32.Checksum(d, castagnoli); sum != e.sum {
+		return nil, fmt.Errorf("%w: segment %v, offset %v: stored %08x, computed %08x", storage.ErrChecksumMismatch, e.seg, e.off, e.sum, sum)
+	}
+	return d, nil
+}
+
+// segment is a file of the disk log.
+type segment struct {
+	f    *os.File
+	size int64
+}
+
+// diskLog is an append-only log of segment files storing large values.
+// The index of records is kept in memory, only values of records of durable
+// classes are located by IndexFile, so the segments they are in are kept
+// and the rest are removed when the log is opened. The data directory is
+// locked while the log is open and its layout is migrated to format. Values
+// are appended to the active segment, the sealed ones are only read and
+// removed by compaction.
+type diskLog struct {
+	once        sync.Once
+	err         error
+	lock        sync.Mutex
+	dir         string
+	dirLock     *os.File
+	format      int
+	segmentSize int64
+	segments    map[int]*segment
+	active      int
+	cache       *blockCache
+	readAhead   int
+	sink        metrics.Sink
+	dirty       map[int]bool
+	syncDelay   time.Duration
+	syncLock    sync.Mutex
+	batch       *syncBatch
+
+	// index is the journal appended to by journal, it is synced along with
+	// the segments if indexDirty is set. recovered are the records read
+	// from it when the log was opened, see takeRecovered.
+	index      *os.File
+	indexSize  int64
+	indexDirty bool
+	recovered  map[storage.RecordID]entry
+}
+
+// syncBatch is a group of writers waiting for the same fsync.
+type syncBatch struct {
+	done chan struct{}
+	err  error
+}
+
+func (l *diskLog) open(dir string) error {
+	l.once.Do(func() {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			l.err = fmt.Errorf("Failed to create data directory: %v", err)
+			return
+		}
+		if l.dirLock, l.err = lockDir(dir); l.err != nil {
+			return
+		}
+		if l.err = migrate(dir, l.format); l.err != nil {
+			return
+		}
+		if l.format < DataFormat {
+			l.err = fmt.Errorf("%w %d", ErrDowngraded, l.format)
+			return
+		}
+		recovered, err := readIndex(dir)
+		if err != nil {
+			l.err = err
+			return
+		}
+		l.dir = dir
+		l.segments = make(map[int]*segment)
+		l.dirty = make(map[int]bool)
+		if l.err = l.reopen(recovered); l.err != nil {
+			return
+		}
+		if l.index, l.indexSize, err = writeIndex(dir, recovered); err != nil {
+			l.err = fmt.Errorf("Failed to write index: %v", err)
+			return
+		}
+		l.recovered = recovered
+		if err := syncDir(dir); err != nil {
+			l.err = fmt.Errorf("Failed to sync data directory: %v", err)
+		}
+	})
+	return l.err
+}
+
+// reopen opens the segments holding values of recovered records, removes
+// the rest and starts a new active segment after them.
+func (l *diskLog) reopen(recovered map[storage.RecordID]entry) error {
+	keep := make(map[int]bool)
+	for _, e := range recovered {
+		keep[e.seg] = true
+	}
+	old, err := filepath.Glob(filepath.Join(l.dir, "values-*.log"))
+	if err != nil {
+		return err
+	}
+	active := 0
+	for _, fname := range old {
+		var id int
+		if _, err := fmt.Sscanf(filepath.Base(fname), "values-%06d.log", &id); err != nil || !keep[id] {
+			if err := os.Remove(fname); err != nil {
+				return fmt.Errorf("Failed to remove old segment: %v", err)
+			}
+			continue
+		}
+		f, err := os.OpenFile(fname, os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		l.segments[id] = &segment{f: f, size: fi.Size()}
+		active = max(active, id+1)
+	}
+	for _, e := range recovered {
+		if l.segments[e.seg] == nil {
+			return fmt.Errorf("Segment %d of indexed values is missing", e.seg)
+		}
+	}
+	return l.roll(active)
+}
+
+// roll starts a new active segment with the given id.
+// Should be called with l.lock held.
+func (l *diskLog) roll(id int) error {
+	f, err := os.OpenFile(filepath.Join(l.dir, SegmentFile(id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	l.segments[id] = &segment{f: f}
+	l.active = id
+	return nil
+}
+
+// close closes the log and unlocks the data directory. The log can't be
+// opened again.
+func (l *diskLog) close() error {
+	l.once.Do(func() {
+		l.err = os.ErrClosed
+	})
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var err error
+	for _, seg := range l.segments {
+		if cerr := seg.f.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	l.segments = nil
+	if l.index != nil {
+		if cerr := l.index.Close(); cerr != nil {
+			err = cerr
+		}
+		l.index = nil
+	}
+	if l.dirLock != nil {
+		l.dirLock.Close()
+	}
+	return err
+}
+
+func (l *diskLog) append(dir string, d []byte) (entry, error) {
+	entries, err := l.appendBatch(dir, [][]byte{d})
+	if err != nil {
+		return entry{}, err
+	}
+	return entries[0], nil
+}
+
+// appendBatch appends values ds to the active segment with one write.
+func (l *diskLog) appendBatch(dir string, ds [][]byte) ([]entry, error) {
+	if err := l.open(dir); err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, d := range ds {
+		total += int64(len(d))
+	}
+	buf := ds[0]
+	if len(ds) > 1 {
+		buf = make([]byte, 0, total)
+		for _, d := range ds {
+			buf = append(buf, d...)
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	seg := l.segments[l.active]
+	if seg.size > 0 && seg.size+total > l.segmentSize {
+		if err := l.roll(l.active + 1); err != nil {
+			return nil, err
+		}
+		seg = l.segments[l.active]
+	}
+	if _, err := seg.f.WriteAt(buf, seg.size); err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(ds))
+	for _, d := range ds {
+		entries = append(entries, entry{seg: l.active, off: seg.size, size: len(d), disk: true, sum: crc32.Checksum(d, castagnoli)})
+		seg.size += int64(len(d))
+	}
+	l.dirty[l.active] = true
+	return entries, nil
+}
+
+// sync makes all appended values durable. Concurrent callers within
+// l.syncDelay share one fsync of each changed segment. Returns ctx.Err()
+// without waiting for the fsync once ctx is done.
+func (l *diskLog) sync(ctx context.Context) error {
+	l.syncLock.Lock()
+	b := l.batch
+	if b == nil {
+		b = &syncBatch{done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(l.syncDelay, func() {
+			l.flush(b)
+		})
+	}
+	l.syncLock.Unlock()
+
+	select {
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush fsyncs segments changed since the last flush and releases writers
+// waiting in b. Writers coming later wait for the next batch, so their
+// values are synced even if they are appended after the segments are taken.
+func (l *diskLog) flush(b *syncBatch) {
+	l.syncLock.Lock()
+	l.batch = nil
+	l.syncLock.Unlock()
+
+	l.lock.Lock()
+	files := make([]*os.File, 0, len(l.dirty))
+	for id := range l.dirty {
+		if seg, ok := l.segments[id]; ok {
+			files = append(files, seg.f)
+		}
+	}
+	l.dirty = make(map[int]bool)
+	if l.indexDirty && l.index != nil {
+		files = append(files, l.index)
+	}
+	l.indexDirty = false
+	l.lock.Unlock()
+
+	start := time.Now()
+	for _, f := range files {
+		if err := f.Sync(); err != nil {
+			b.err = err
+		}
+	}
+	l.sink.ObserveDuration("node.disk.fsync", time.Since(start))
+	l.sink.IncrCounter("node.disk.fsyncs", 1)
+	close(b.done)
+}
+
+func (l *diskLog) segment(id int) (*segment, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	seg, ok := l.segments[id]
+	if !ok {
+		return nil, fmt.Errorf("Segment %d is removed", id)
+	}
+	return seg, nil
+}
+
+// readRaw reads e bypassing the block cache.
+func (l *diskLog) readRaw(e entry) ([]byte, error) {
+	seg, err := l.segment(e.seg)
+	if err != nil {
+		return nil, err
+	}
+	d := make([]byte, e.size)
+	if _, err := seg.f.ReadAt(d, e.off); err != nil {
+		return nil, err
+	}
+	return verify(e, d)
+}
+
+// read reads e through the block cache if it is enabled. Missing blocks
+// are read along with l.readAhead following ones.
+func (l *diskLog) read(e entry) ([]byte, error) {
+	if l.cache == nil || e.size == 0 {
+		return l.readRaw(e)
+	}
+	seg, err := l.segment(e.seg)
+	if err != nil {
+		return nil, err
+	}
+
+	d := make([]byte, e.size)
+	end := e.off + int64(e.size)
+	var hits, misses int64
+	for n := e.off / CacheBlockSize; n*CacheBlockSize < end; n++ {
+		start := n * CacheBlockSize
+		need := int(end - start)
+		if need > CacheBlockSize {
+			need = CacheBlockSize
+		}
+		block, ok := l.cache.get(blockKey{e.seg, n}, need)
+		if ok {
+			hits++
+		} else {
+			misses++
+			last := (end-1)/CacheBlockSize + int64(l.readAhead)
+			buf := make([]byte, (last-n+1)*CacheBlockSize)
+			read, err := seg.f.ReadAt(buf, start)
+			if read < need {
+				if err == nil {
+					err = io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			buf = buf[:read]
+			for i := int64(0); i*CacheBlockSize < int64(len(buf)); i++ {
+				b := buf[i*CacheBlockSize:]
+				if len(b) > CacheBlockSize {
+					b = b[:CacheBlockSize]
+				}
+				l.cache.put(blockKey{e.seg, n + i}, b)
+			}
+			block = buf
+		}
+
+		from := int64(0)
+		if start < e.off {
+			from = e.off - start
+		}
+		copy(d[start+from-e.off:], block[from:need])
+	}
+	l.sink.IncrCounter("node.cache.hits", hits)
+	if misses > 0 {
+		l.sink.IncrCounter("node.cache.misses", misses)
+		_, _, size := l.cache.stats()
+		l.sink.SetGauge("node.cache.bytes", float64(size))
+	}
+	return verify(e, d)
+}
+
+// sealed returns sizes of the segments which are not active.
+func (l *diskLog) sealed() map[int]int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	sizes := make(map[int]int64, len(l.segments))
+	for id, seg := range l.segments {
+		if id != l.active {
+			sizes[id] = seg.size
+		}
+	}
+	return sizes
+}
+
+// remove removes the sealed segment with the given id.
+func (l *diskLog) remove(id int) error {
+	l.lock.Lock()
+	seg, ok := l.segments[id]
+	if !ok || id == l.active {
+		l.lock.Unlock()
+		return fmt.Errorf("Segment %d can't be removed", id)
+	}
+	delete(l.segments, id)
+	l.lock.Unlock()
+	if l.cache != nil {
+		l.cache.purge(id)
+	}
+
+	seg.f.Close()
+	return os.Remove(filepath.Join(l.dir, SegmentFile(id)))
+}
+
+// Open opens cfg.DataDir and locks it, so another node process can't use
+// it, then restores records of durable classes stored in it. Returns an
+// error wrapping ErrDataDirLocked if it is already used. The directory is
+// opened on the first write of a large value otherwise, the records are
+// restored once Open is called then.
+//
+// Open открывает cfg.DataDir и блокирует ее, чтобы другой процесс node не мог
+// ее использовать, затем восстанавливает хранящиеся в ней записи надежных
+// классов. Возвращает ошибку, оборачивающую ErrDataDirLocked, если она
+// уже используется. Иначе директория открывается при первой записи большого
+// значения, а записи восстанавливаются, когда будет вызван Open.
+func (node *Node) Open() error {
+	if node.conf.DataDir == "" {
+		return nil
+	}
+	if err := node.disk.open(node.conf.DataDir); err != nil {
+		return err
+	}
+	node.restore()
+	return nil
+}
+
+// Close stops hooks once they handle the events queued, then closes files
+// in cfg.DataDir and unlocks it.
+//
+// Close останавливает hooks, когда они обработают события в очереди, затем
+// закрывает файлы в cfg.DataDir и снимает с нее блокировку.
+func (node *Node) Close() error {
+	node.stopHooks()
+	return node.disk.close()
+}
+
+// store chooses where to keep the value d of the record with key k by its
+// storage class and size and stores it there. Values stored on disk are
+// synced if cfg.SyncWrites is set or their class is durable, so store
+// should be called without node.lock held for fsyncs to be shared by
+// concurrent writers. Returns ctx.Err() if ctx is done before the value
+// is written or synced, the value written is left as garbage then.
+func (node *Node) store(ctx context.Context, k storage.RecordID, d []byte) (entry, error) {
+	onDisk, sync := node.placement(k, d)
+	if !onDisk {
+		return entry{d: d, size: len(d)}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return entry{}, err
+	}
+	e, err := node.disk.append(node.conf.DataDir, d)
+	if err == nil && sync {
+		err = node.disk.sync(ctx)
+		if err != nil && err == ctx.Err() {
+			// Not a failure of the disk, so it isn't reported.
+			return entry{}, err
+		}
+	}
+	if err != nil {
+		node.reportError(err)
+	}
+	return e, err
+}
+
+// storeBatch stores values of Put ops like store, appending the ones
+// stored on disk at once. Ops having errors in errs are skipped.
+// Returns entries aligned with ops.
+func (node *Node) storeBatch(ops []storage.Op, errs []error) ([]entry, error) {
+	entries := make([]entry, len(ops))
+	var large [][]byte
+	var idx []int
+	sync := false
+	for i, op := range ops {
+		if op.Del || errs[i] != nil {
+			continue
+		}
+		onDisk, opSync := node.placement(op.Key, op.Data)
+		if !onDisk {
+			entries[i] = entry{d: op.Data, size: len(op.Data)}
+			continue
+		}
+		large = append(large, op.Data)
+		idx = append(idx, i)
+		sync = sync || opSync
+	}
+	if len(large) == 0 {
+		return entries, nil
+	}
+
+	stored, err := node.disk.appendBatch(node.conf.DataDir, large)
+	if err == nil && sync {
+		err = node.disk.sync(context.Background())
+	}
+	if err != nil {
+		node.reportError(err)
+		return nil, err
+	}
+	for j, i := range idx {
+		entries[i] = stored[j]
+	}
+	return entries, nil
+}
+
+// placement reports whether the value d of the record with key k is kept
+// on disk and whether it is synced there. Values of storage.ClassMemory
+// are kept in memory and durable classes are kept on disk regardless of
+// their size.
+func (node *Node) placement(k storage.RecordID, d []byte) (onDisk, sync bool) {
+	class := node.conf.Namespaces.Class(k)
+	switch {
+	case node.conf.DataDir == "" || class == storage.ClassMemory:
+		return false, false
+	case class.Durable():
+		return true, true
+	}
+	return len(d) >= node.conf.LargeValueThreshold, node.conf.SyncWrites
+}
+
+// load returns data of e.
+func (node *Node) load(e entry) ([]byte, error) {
+	if e.obj != "" {
+		return node.fetch(e)
+	}
+	if !e.disk {
+		return e.d, nil
+	}
+	d, err := node.disk.read(e)
+	if err != nil {
+		node.reportError(err)
+	}
+	return d, err
+}
@@ -0,0 +1,14 @@
+package node
+
+import (
+	"events"
+)
+
+// Events returns the bus the node publishes events to, so subscribers
+// can be added to it.
+//
+// Events возвращает шину, в которую node публикует события, чтобы к ней
+// можно было подключить подписчиков.
+func (node *Node) Events() *events.Bus {
+	return node.conf.Bus
+}
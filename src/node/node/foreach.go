@@ -0,0 +1,72 @@
+package node
+
+import (
+	"context"
+	"sort"
+
+	"storage"
+)
+
+// iteration is a copy of the index of records visited by ForEach. Like
+// the ones of snapshots, its entries are kept and relocated by compaction.
+type iteration struct {
+	records map[storage.RecordID]entry
+}
+
+// ForEach calls fn with the key and the value of every record of the node
+// in ascending order of keys until fn returns false. Records are visited as
+// of the call: their index is copied at once, so writes made meanwhile are
+// not seen. Values are loaded storage.ScanLimit records at a time without
+// holding the lock, so memory used for them is bounded and fn may write to
+// the node. fn must not modify v.
+//
+// ForEach вызывает fn с ключом и значением каждой записи node в порядке
+// возрастания ключей, пока fn не вернет false. Просматриваются записи
+// на момент вызова: их индекс копируется сразу, так что изменения, сделанные
+// за это время, не видны. Значения загружаются по storage.ScanLimit записей
+// без удержания блокировки, так что используемая для них память ограничена
+// и fn может писать в node. fn не должна изменять v.
+func (node *Node) ForEach(fn func(k storage.RecordID, v []byte) bool) error {
+	node.lock.Lock()
+	it := &iteration{records: make(map[storage.RecordID]entry, len(node.storage))}
+	keys := make([]storage.RecordID, 0, len(node.storage))
+	for k, e := range node.storage {
+		it.records[k] = e
+		keys = append(keys, k)
+	}
+	node.iters[it] = struct{}{}
+	node.lock.Unlock()
+	defer func() {
+		node.lock.Lock()
+		delete(node.iters, it)
+		node.lock.Unlock()
+	}()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	for len(keys) > 0 {
+		n := storage.ScanLimit
+		if n > len(keys) {
+			n = len(keys)
+		}
+		records := make([]storage.Record, 0, n)
+		entries := make([]entry, 0, n)
+		node.lock.RLock()
+		for _, k := range keys[:n] {
+			records = append(records, storage.Record{Key: k})
+			entries = append(entries, it.records[k])
+		}
+		records, _, err := node.fill(context.Background(), records, entries, nil, nil)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if !fn(r.Key, r.Data) {
+				return nil
+			}
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
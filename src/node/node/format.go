@@ -0,0 +1,252 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DataFormat is the current version of the data directory layout.
+	// Version 1 is the layout without FormatFile, version 2 adds it,
+	// version 3 splits ValuesFile into segments and version 4 adds
+	// IndexFile.
+	//
+	// DataFormat -- текущая версия формата директории данных.
+	// Версия 1 -- формат без FormatFile, версия 2 добавляет его,
+	// версия 3 разбивает ValuesFile на сегменты, версия 4 добавляет
+	// IndexFile.
+	DataFormat = 4
+
+	// FormatFile is a name of the file in cfg.DataDir storing its version.
+	//
+	// FormatFile -- имя файла в cfg.DataDir, хранящего версию ее формата.
+	FormatFile = "FORMAT"
+
+	// BackupPrefix is a prefix of directories in cfg.DataDir the old layout
+	// is copied to before a migration.
+	//
+	// BackupPrefix -- префикс директорий в cfg.DataDir, в которые копируется
+	// старый формат перед миграцией.
+	BackupPrefix = "backup-v"
+)
+
+// ErrDowngraded is returned when a data directory is opened after it is
+// migrated to an older format, which can only be used by older nodes.
+//
+// ErrDowngraded возвращается при открытии директории данных после ее
+// миграции в старый формат, который могут использовать только старые nodes.
+var ErrDowngraded = errors.New("Data directory is migrated to older format")
+
+// migration converts a data directory between two adjacent versions.
+type migration struct {
+	up   func(dir string) error
+	down func(dir string) error
+}
+
+// migrations[v-1] converts a data directory between versions v and v+1.
+// FormatFile is updated after each step, so migrations only change the layout.
+var migrations = []migration{
+	// 1 -> 2: FormatFile is introduced, the layout is the same.
+	{up: noMigration, down: noMigration},
+	// 2 -> 3: ValuesFile becomes the first segment.
+	{up: splitValues, down: joinValues},
+	// 3 -> 4: IndexFile is introduced, older nodes empty the disk log.
+	{up: noMigration, down: dropIndex},
+}
+
+func noMigration(dir string) error {
+	return nil
+}
+
+func splitValues(dir string) error {
+	err := os.Rename(filepath.Join(dir, ValuesFile), filepath.Join(dir, SegmentFile(0)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// dropIndex removes IndexFile, as older nodes don't update it and would
+// leave it locating values they remove.
+func dropIndex(dir string) error {
+	err := os.Remove(filepath.Join(dir, IndexFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// joinValues makes the first segment ValuesFile and drops the rest, as
+// the disk log is emptied when opened by nodes of that format anyway.
+func joinValues(dir string) error {
+	segments, err := filepath.Glob(filepath.Join(dir, "values-*.log"))
+	if err != nil {
+		return err
+	}
+	for _, fname := range segments {
+		if filepath.Base(fname) == SegmentFile(0) {
+			err = os.Rename(fname, filepath.Join(dir, ValuesFile))
+		} else {
+			err = os.Remove(fname)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFormat returns the version of the layout of dir. Directories without
+// FormatFile have version 1 or 0 if they are empty.
+func readFormat(dir string) (int, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, FormatFile))
+	if os.IsNotExist(err) {
+		files, err := dataFiles(dir)
+		if err != nil {
+			return 0, err
+		}
+		if len(files) == 0 {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %v file: %v", FormatFile, err)
+	}
+	return v, nil
+}
+
+// writeFormat atomically replaces FormatFile in dir with version v.
+// FormatFile is removed for version 1.
+func writeFormat(dir string, v int) error {
+	fname := filepath.Join(dir, FormatFile)
+	if v == 1 {
+		if err := os.Remove(fname); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return syncDir(dir)
+	}
+	tmp := fname + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(v)+"\n"), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// dataFiles lists files of the layout in dir, skipping the lock file
+// and backups.
+func dataFiles(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range infos {
+		if fi.Name() == LockFile || strings.HasPrefix(fi.Name(), BackupPrefix) {
+			continue
+		}
+		files = append(files, fi.Name())
+	}
+	return files, nil
+}
+
+// migrate converts the layout of dir to version to, backing up the old
+// layout first.
+func migrate(dir string, to int) error {
+	from, err := readFormat(dir)
+	if err != nil {
+		return fmt.Errorf("Failed to read data format: %v", err)
+	}
+	if from == 0 {
+		return writeFormat(dir, to)
+	}
+	if from == to {
+		return nil
+	}
+	if from < 1 || from > DataFormat {
+		return fmt.Errorf("Data format %d of %q is not supported, supported formats are 1 to %d", from, dir, DataFormat)
+	}
+
+	backup, err := backupLayout(dir, from)
+	if err != nil {
+		return fmt.Errorf("Failed to back up data directory: %v", err)
+	}
+	for v := from; v != to; {
+		var next int
+		var step func(dir string) error
+		if to > from {
+			next, step = v+1, migrations[v-1].up
+		} else {
+			next, step = v-1, migrations[v-2].down
+		}
+		if err := step(dir); err != nil {
+			return fmt.Errorf("Failed to migrate data format from %d to %d, the old layout is in %q: %v", v, next, backup, err)
+		}
+		if err := writeFormat(dir, next); err != nil {
+			return fmt.Errorf("Failed to write data format %d: %v", next, err)
+		}
+		v = next
+	}
+	return nil
+}
+
+// backupLayout copies files of the layout of version v in dir to a new
+// backup directory in dir and returns its path.
+func backupLayout(dir string, v int) (string, error) {
+	backup := filepath.Join(dir, fmt.Sprintf("%s%d-%d", BackupPrefix, v, time.Now().Unix()))
+	if err := os.Mkdir(backup, 0755); err != nil {
+		return "", err
+	}
+	files, err := dataFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range files {
+		if err := copyFile(filepath.Join(dir, name), filepath.Join(backup, name)); err != nil {
+			return "", err
+		}
+	}
+	return backup, syncDir(backup)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("Unexpected directory %q", src)
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,175 @@
+package node
+
+import (
+	"encoding/json"
+	"time"
+
+	"storage"
+)
+
+// GCResult describes a garbage collection pass.
+//
+// GCResult описывает проход сборки мусора.
+type GCResult struct {
+	// Scanned is a number of scanned records.
+	// Scanned -- количество просмотренных записей.
+	Scanned int
+	// Orphans is a number of records the node doesn't own.
+	// Orphans -- количество записей, которые node не должна хранить.
+	Orphans int
+	// Deleted is a number of deleted orphaned records.
+	// Deleted -- количество удаленных записей.
+	Deleted int
+	// Unconfirmed is a number of orphaned records kept as their owners
+	// don't store them.
+	// Unconfirmed -- количество лишних записей, оставленных, так как
+	// их не хранят владельцы.
+	Unconfirmed int
+}
+
+// GC deletes records the node doesn't own according to the router, e.g.
+// after placement changes. Records are deleted only once storage.MinRedundancy
+// of their owners store the same value, so the last copies are kept until
+// they are copied to the owners. Deleted records are written to
+// cfg.GCArchive if it is set. Nothing is deleted if dryRun is set.
+//
+// GC удаляет записи, которые node не должна хранить согласно router,
+// например после изменения размещения. Записи удаляются, только когда
+// storage.MinRedundancy их владельцев хранят то же значение, поэтому
+// последние копии сохраняются, пока не будут скопированы владельцам.
+// Удаленные записи записываются в cfg.GCArchive, если он задан. Если задан
+// dryRun, ничего не удаляется.
+func (node *Node) GC(dryRun bool) (GCResult, error) {
+	var res GCResult
+	var err error
+	keys := make([]storage.RecordID, 0, storage.ScanLimit)
+	collect := func() bool {
+		orphans, oerr := node.conf.Client.Orphans(node.conf.Router, node.conf.Addr, keys)
+		if oerr != nil {
+			err = oerr
+			return false
+		}
+		res.Orphans += len(orphans)
+		keys = keys[:0]
+
+		for _, k := range orphans {
+			sum, ok := node.replicated(k)
+			if !ok {
+				res.Unconfirmed++
+				continue
+			}
+			if dryRun {
+				continue
+			}
+			dropped, derr := node.dropOrphan(k, sum)
+			if derr != nil {
+				err = derr
+				return false
+			}
+			if dropped {
+				res.Deleted++
+			}
+		}
+		return true
+	}
+
+	if ferr := node.ForEach(func(k storage.RecordID, _ []byte) bool {
+		res.Scanned++
+		keys = append(keys, k)
+		return len(keys) < cap(keys) || collect()
+	}); ferr != nil {
+		return res, ferr
+	}
+	if err == nil && len(keys) > 0 {
+		collect()
+	}
+	return res, err
+}
+
+// replicated reports whether storage.MinRedundancy owners of the record
+// with key k other than the node store its value, and returns the checksum
+// of the value. Owners failing to answer don't count.
+func (node *Node) replicated(k storage.RecordID) (storage.Checksum, bool) {
+	node.lock.RLock()
+	e, ok := node.storage[k]
+	var d []byte
+	var err error
+	if ok {
+		d, err = node.load(e)
+	}
+	node.lock.RUnlock()
+	if !ok || err != nil {
+		return storage.Checksum{}, false
+	}
+	sum := storage.HashSHA256.Sum(d)
+
+	owners, err := node.conf.Client.NodesFind(node.conf.Router, k)
+	if err != nil {
+		return sum, false
+	}
+	confirmed := 0
+	for _, owner := range owners {
+		if owner == node.conf.Addr {
+			continue
+		}
+		if got, err := node.conf.Peers.Checksum(owner, k, storage.HashSHA256); err == nil && got == sum {
+			confirmed++
+		}
+	}
+	return sum, confirmed >= storage.MinRedundancy
+}
+
+// dropOrphan removes the record with key k if its value still has
+// the checksum sum, without notifying hooks or tracking the change, as
+// the record is still stored by its owners. Reports whether it was removed.
+func (node *Node) dropOrphan(k storage.RecordID, sum storage.Checksum) (bool, error) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+
+	e, ok := node.storage[k]
+	if !ok {
+		return false, nil
+	}
+	d, err := node.load(e)
+	if err != nil {
+		return false, err
+	}
+	if storage.HashSHA256.Sum(d) != sum {
+		// Changed since it was confirmed, it is checked again next time.
+		return false, nil
+	}
+	if node.conf.GCArchive != nil {
+		if err := json.NewEncoder(node.conf.GCArchive).Encode(storage.Record{Key: k, Data: d}); err != nil {
+			node.reportError(err)
+			return false, err
+		}
+	}
+	// Not synced, the record is dropped again if it is restored.
+	if err := node.journal(k, e, true); err != nil {
+		return false, err
+	}
+	delete(node.storage, k)
+	node.account(k, -1, -int64(e.size))
+	node.untouch(k, e)
+	return true, nil
+}
+
+// GCs runs garbage collection passes each time interval set by
+// cfg.GCInterval.
+//
+// GCs запускает проходы сборки мусора через каждый интервал времени,
+// заданный в cfg.GCInterval.
+func (node *Node) GCs() {
+	go func() {
+		for {
+			time.Sleep(node.conf.GCInterval)
+			res, err := node.GC(node.conf.GCDryRun)
+			if err != nil {
+				node.conf.Logger.Printf("GC failed: %v", err)
+				continue
+			}
+			node.conf.Logger.Printf("GC: scanned %d records, found %d orphans, deleted %d, kept %d unconfirmed (dry run: %v)",
+				res.Scanned, res.Orphans, res.Deleted, res.Unconfirmed, node.conf.GCDryRun)
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package node
+
+import (
+	"time"
+)
+
+// QuarantineWindow is a default time window to count local errors in.
+//
+// QuarantineWindow -- интервал времени по умолчанию, за который считаются
+// локальные ошибки.
+const QuarantineWindow = time.Minute
+
+// reportError registers a local error of the node, e.g. an I/O error.
+func (node *Node) reportError(err error) {
+	node.conf.Logger.Printf("Local error: %v", err)
+	node.conf.Sink.IncrCounter("node.local_errors", 1)
+
+	node.errLock.Lock()
+	defer node.errLock.Unlock()
+	node.errors = append(node.errors, node.conf.Clock.Now())
+}
+
+// Degraded reports whether the number of local errors within
+// cfg.QuarantineWindow reached cfg.QuarantineErrors. A degraded node
+// reports itself in heartbeats, so the router routes around it.
+//
+// Degraded сообщает, достигло ли количество локальных ошибок за
+// cfg.QuarantineWindow значения cfg.QuarantineErrors. Неисправная node
+// сообщает об этом в heartbeats, и router перестает ее использовать.
+func (node *Node) Degraded() bool {
+	node.errLock.Lock()
+	defer node.errLock.Unlock()
+
+	since := node.conf.Clock.Now().Add(-node.conf.QuarantineWindow)
+	i := 0
+	for i < len(node.errors) && node.errors[i].Before(since) {
+		i++
+	}
+	node.errors = node.errors[i:]
+
+	return node.conf.QuarantineErrors > 0 && len(node.errors) >= node.conf.QuarantineErrors
+}
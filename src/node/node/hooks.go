@@ -0,0 +1,102 @@
+package node
+
+import (
+	"log"
+
+	"storage"
+)
+
+// HookQueueSize is a number of events queued for a hook. Events are dropped
+// if the queue is full.
+//
+// HookQueueSize -- количество событий в очереди hook. Если очередь
+// заполнена, события отбрасываются.
+const HookQueueSize = 1024
+
+// Hooks is the common interface to react on changes of records stored on the node.
+// Hooks are called asynchronously, their panics are recovered and logged.
+//
+// Hooks это общий интерфейс для реакции на изменения записей, хранящихся на node.
+// Hooks вызываются асинхронно, их паники перехватываются и логируются.
+type Hooks interface {
+	// OnPut is called after the record with key k was put to the node.
+	// OnPut вызывается после того, как запись с ключом k добавлена в node.
+	OnPut(k storage.RecordID, d []byte)
+	// OnDel is called after the record with key k was deleted from the node.
+	// OnDel вызывается после того, как запись с ключом k удалена из node.
+	OnDel(k storage.RecordID)
+}
+
+type hookEvent struct {
+	del bool
+	k   storage.RecordID
+	d   []byte
+}
+
+type hookRunner struct {
+	hooks  Hooks
+	events chan hookEvent
+	done   chan struct{}
+	logger *log.Logger
+}
+
+func startHooks(hooks []Hooks, logger *log.Logger) []hookRunner {
+	runners := make([]hookRunner, 0, len(hooks))
+	for _, h := range hooks {
+		r := hookRunner{
+			hooks:  h,
+			events: make(chan hookEvent, HookQueueSize),
+			done:   make(chan struct{}),
+			logger: logger,
+		}
+		go r.run()
+		runners = append(runners, r)
+	}
+	return runners
+}
+
+func (r hookRunner) run() {
+	defer close(r.done)
+	for e := range r.events {
+		r.call(e)
+	}
+}
+
+func (r hookRunner) call(e hookEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			r.logger.Printf("Hook panicked on key = %v: %v", e.k, err)
+		}
+	}()
+	if e.del {
+		r.hooks.OnDel(e.k)
+	} else {
+		r.hooks.OnPut(e.k, e.d)
+	}
+}
+
+// stopHooks closes the queues of hooks and waits for the hooks to handle
+// the events queued, events of later changes are not delivered.
+func (node *Node) stopHooks() {
+	node.lock.Lock()
+	runners := node.hooks
+	node.hooks = nil
+	node.lock.Unlock()
+	for _, r := range runners {
+		close(r.events)
+	}
+	for _, r := range runners {
+		<-r.done
+	}
+}
+
+// notify queues e for every hook. Should be called with node.lock held.
+func (node *Node) notify(e hookEvent) {
+	for _, r := range node.hooks {
+		select {
+		case r.events <- e:
+		default:
+			node.conf.Logger.Printf("Hook queue is full, dropping event for key = %v", e.k)
+		}
+	}
+}
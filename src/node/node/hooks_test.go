@@ -0,0 +1,67 @@
+package node
+
+import (
+	"sync"
+	"testing"
+
+	"storage"
+)
+
+type recordingHooks struct {
+	sync.Mutex
+	puts map[storage.RecordID]string
+	dels []storage.RecordID
+}
+
+func (h *recordingHooks) OnPut(k storage.RecordID, d []byte) {
+	h.Lock()
+	defer h.Unlock()
+	h.puts[k] = string(d)
+}
+
+func (h *recordingHooks) OnDel(k storage.RecordID) {
+	h.Lock()
+	defer h.Unlock()
+	h.dels = append(h.dels, k)
+}
+
+type panickingHooks struct{}
+
+func (panickingHooks) OnPut(k storage.RecordID, d []byte) { panic("OnPut") }
+func (panickingHooks) OnDel(k storage.RecordID)           { panic("OnDel") }
+
+func TestHooks(t *testing.T) {
+	h := &recordingHooks{puts: make(map[storage.RecordID]string)}
+	s := New(Config{
+		Hooks: []Hooks{panickingHooks{}, h},
+	})
+
+	if err := s.Put(1, []byte("data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := s.Put(1, []byte("other")); err != storage.ErrRecordExists {
+		t.Fatalf("Put() got error %v, want %v", err, storage.ErrRecordExists)
+	}
+	if err := s.Del(1); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if err := s.Del(2); err != storage.ErrRecordNotFound {
+		t.Fatalf("Del() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	// Close waits for the hooks to handle the queued events.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if err := s.Put(3, []byte("late")); err != nil {
+		t.Fatalf("Put() after Close() error: %v", err)
+	}
+	h.Lock()
+	defer h.Unlock()
+	if len(h.puts) != 1 || h.puts[1] != "data" {
+		t.Errorf("Wrong OnPut() calls: %v", h.puts)
+	}
+	if len(h.dels) != 1 || h.dels[0] != 1 {
+		t.Errorf("Wrong OnDel() calls: %v", h.dels)
+	}
+}
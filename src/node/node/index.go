@@ -0,0 +1,194 @@
+package node
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"storage"
+)
+
+// IndexFile is a name of the journal in cfg.DataDir locating values of
+// records of durable storage classes in the disk log, so the records are
+// restored by Open.
+//
+// IndexFile -- имя журнала в cfg.DataDir, указывающего расположение
+// значений записей надежных классов хранения в логе на диске, чтобы Open
+// восстанавливал эти записи.
+const IndexFile = "index.log"
+
+// indexRecordSize is a size of a record of the journal: its kind, the key,
+// the segment, offset, size and checksum of the value and the CRC-32C of
+// the preceding fields.
+const indexRecordSize = 1 + 4 + 4 + 8 + 4 + 4 + 4
+
+const (
+	indexPut byte = 1
+	indexDel byte = 2
+)
+
+// encodeIndex encodes the journal record of the record with key k stored
+// in e, or deleted if del is set.
+func encodeIndex(k storage.RecordID, e entry, del bool) []byte {
+	b := make([]byte, indexRecordSize)
+	b[0] = indexPut
+	if del {
+		b[0] = indexDel
+	}
+	binary.BigEndian.PutUint32(b[1:], uint32(k))
+	binary.BigEndian.PutUint32(b[5:], uint32(e.seg))
+	binary.BigEndian.PutUint64(b[9:], uint64(e.off))
+	binary.BigEndian.PutUint32(b[17:], uint32(e.size))
+	binary.BigEndian.PutUint32(b[21:], e.sum)
+	binary.BigEndian.PutUint32(b[25:], crc32.Checksum(b[:25], castagnoli))
+	return b
+}
+
+// readIndex replays the journal in dir and returns entries of the records
+// it locates. A torn or corrupt record ends the journal, as it is left by
+// a crash in the middle of an append which was never acknowledged.
+func readIndex(dir string) (map[storage.RecordID]entry, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, IndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read index: %v", err)
+	}
+	entries := make(map[storage.RecordID]entry)
+	for ; len(b) >= indexRecordSize; b = b[indexRecordSize:] {
+		if crc32.Checksum(b[:25], castagnoli) != binary.BigEndian.Uint32(b[25:]) {
+			break
+		}
+		k := storage.RecordID(binary.BigEndian.Uint32(b[1:]))
+		switch b[0] {
+		case indexPut:
+			entries[k] = entry{
+				seg:  int(binary.BigEndian.Uint32(b[5:])),
+				off:  int64(binary.BigEndian.Uint64(b[9:])),
+				size: int(binary.BigEndian.Uint32(b[17:])),
+				sum:  binary.BigEndian.Uint32(b[21:]),
+				disk: true,
+			}
+		case indexDel:
+			delete(entries, k)
+		}
+	}
+	return entries, nil
+}
+
+// writeIndex atomically replaces the journal in dir with the records
+// stored in entries and returns it opened for appending with its size.
+func writeIndex(dir string, entries map[storage.RecordID]entry) (*os.File, int64, error) {
+	fname := filepath.Join(dir, IndexFile)
+	tmp := fname + ".tmp"
+	b := make([]byte, 0, len(entries)*indexRecordSize)
+	for k, e := range entries {
+		b = append(b, encodeIndex(k, e, false)...)
+	}
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, fname)
+	}
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, int64(len(b)), nil
+}
+
+// journal appends the journal record of the record with key k stored in e,
+// or deleted if del is set. The record is durable once the log is synced.
+func (l *diskLog) journal(k storage.RecordID, e entry, del bool) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.index == nil {
+		return os.ErrClosed
+	}
+	if _, err := l.index.WriteAt(encodeIndex(k, e, del), l.indexSize); err != nil {
+		return err
+	}
+	l.indexSize += indexRecordSize
+	l.indexDirty = true
+	return nil
+}
+
+// takeRecovered returns the records restored from the journal when the log
+// was opened, only once.
+func (l *diskLog) takeRecovered() map[storage.RecordID]entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	recovered := l.recovered
+	l.recovered = nil
+	return recovered
+}
+
+// journaled reports whether the record with key k stored in e is kept in
+// the journal, i.e. its class is durable.
+func (node *Node) journaled(k storage.RecordID, e entry) bool {
+	return e.disk && node.conf.Namespaces.Class(k).Durable()
+}
+
+// journal records that the record with key k is stored in e, or deleted
+// if del is set, if the record is journaled. Should be called with
+// node.lock held before the record is changed.
+func (node *Node) journal(k storage.RecordID, e entry, del bool) error {
+	if !node.journaled(k, e) {
+		return nil
+	}
+	err := node.disk.journal(k, e, del)
+	if err != nil {
+		node.reportError(err)
+	}
+	return err
+}
+
+// syncJournal makes the changes journaled by the caller durable if any of
+// keys is of a durable class. Should be called without node.lock held, so
+// fsyncs are shared by concurrent writers. Returns ctx.Err() once ctx is
+// done, the changes are applied in memory then.
+func (node *Node) syncJournal(ctx context.Context, keys ...storage.RecordID) error {
+	if node.conf.DataDir == "" {
+		return nil
+	}
+	for _, k := range keys {
+		if !node.conf.Namespaces.Class(k).Durable() {
+			continue
+		}
+		err := node.disk.sync(ctx)
+		if err != nil && err != ctx.Err() {
+			node.reportError(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// restore adds the records restored from the journal to the node unless
+// they are added since.
+func (node *Node) restore() {
+	recovered := node.disk.takeRecovered()
+	if len(recovered) == 0 {
+		return
+	}
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	for k, e := range recovered {
+		if _, ok := node.storage[k]; ok {
+			continue
+		}
+		node.storage[k] = e
+		node.account(k, 1, int64(e.size))
+	}
+	node.conf.Sink.SetGauge("node.records", float64(len(node.storage)))
+}
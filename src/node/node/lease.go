@@ -0,0 +1,83 @@
+package node
+
+import (
+	"time"
+
+	"storage"
+)
+
+type lease struct {
+	holder  uint64
+	fence   uint64
+	expires time.Time
+}
+
+// AcquireLease grants a lease on the record with key k to the holder for ttl
+// if the record is not leased by another holder, and returns its fencing
+// token. The token is fence, which must be greater than the tokens of all
+// the leases granted before, or the storage.ErrFenceRejected error is
+// returned. If fence is zero, the token of a new lease is the next one and
+// the token of a lease already held by the holder is kept, the lease is
+// extended then. Returns the storage.ErrLocked error if the record is
+// leased by another holder.
+//
+// AcquireLease выдает holder аренду записи с ключом k на время ttl, если
+// запись не арендована другим holder, и возвращает ее fencing token.
+// Token равен fence, который должен быть больше token всех ранее выданных
+// аренд, иначе возвращается ошибка storage.ErrFenceRejected. Если fence
+// равен нулю, token новой аренды -- следующий, а token аренды, уже
+// принадлежащей holder, сохраняется, и аренда продлевается. Если запись
+// арендована другим holder, возвращается ошибка storage.ErrLocked.
+func (node *Node) AcquireLease(k storage.RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	node.leaseLock.Lock()
+	defer node.leaseLock.Unlock()
+
+	now := node.conf.Clock.Now()
+	l := node.leases[k]
+	if l.holder != holder && l.holder != 0 && now.Before(l.expires) {
+		return 0, storage.ErrLocked
+	}
+	switch {
+	case fence > l.fence:
+		l.fence = fence
+	case fence != 0:
+		return 0, storage.ErrFenceRejected
+	case l.holder != holder:
+		l.fence++
+	}
+	l.holder = holder
+	l.expires = now.Add(ttl)
+	node.leases[k] = l
+
+	return l.fence, nil
+}
+
+// LeaseFence returns the greatest fencing token of the leases on the record
+// with key k granted so far, zero if there were none.
+//
+// LeaseFence возвращает наибольший fencing token аренд записи с ключом k,
+// выданных до сих пор, или ноль, если их не было.
+func (node *Node) LeaseFence(k storage.RecordID) (uint64, error) {
+	node.leaseLock.Lock()
+	defer node.leaseLock.Unlock()
+	return node.leases[k].fence, nil
+}
+
+// ReleaseLease releases the lease on the record with key k held by the holder.
+// Returns the storage.ErrNotLockHolder error if the lease isn't held by the holder.
+//
+// ReleaseLease освобождает аренду записи с ключом k, принадлежащую holder.
+// Возвращает ошибку storage.ErrNotLockHolder, если аренда не принадлежит holder.
+func (node *Node) ReleaseLease(k storage.RecordID, holder uint64) error {
+	node.leaseLock.Lock()
+	defer node.leaseLock.Unlock()
+
+	l, ok := node.leases[k]
+	if !ok || l.holder != holder || !node.conf.Clock.Now().Before(l.expires) {
+		return storage.ErrNotLockHolder
+	}
+	l.holder = 0
+	node.leases[k] = l
+
+	return nil
+}
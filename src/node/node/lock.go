@@ -0,0 +1,64 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockFile is a name of the file in cfg.DataDir locked by the node using
+// the directory.
+//
+// LockFile -- имя файла в cfg.DataDir, блокируемого node, которая
+// использует директорию.
+const LockFile = "LOCK"
+
+// ErrDataDirLocked is returned if the data directory is used by another node.
+//
+// ErrDataDirLocked возвращается, если директория данных используется
+// другой node.
+var ErrDataDirLocked = errors.New("Data directory is used by another node")
+
+// errWouldBlock is returned by lockFile if the file is locked by another process.
+var errWouldBlock = errors.New("File is locked")
+
+// lockDir locks dir, so no other node process can use it until the returned
+// file is closed. The pid of the process is written to the lock file to be
+// reported by others.
+func lockDir(dir string) (*os.File, error) {
+	fname := filepath.Join(dir, LockFile)
+	f, err := os.OpenFile(fname, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open lock file: %v", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if err != errWouldBlock {
+			return nil, fmt.Errorf("Failed to lock %q: %v", fname, err)
+		}
+		if pid := lockHolder(fname); pid != 0 {
+			return nil, fmt.Errorf("%w: %q is locked by process %d", ErrDataDirLocked, dir, pid)
+		}
+		return nil, fmt.Errorf("%w: %q", ErrDataDirLocked, dir)
+	}
+
+	pid := []byte(strconv.Itoa(os.Getpid()) + "\n")
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt(pid, 0)
+	}
+	return f, nil
+}
+
+// lockHolder returns the pid written to a lock file or 0 if it can't be read.
+func lockHolder(fname string) int {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return pid
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package node
+
+import "os"
+
+// lockFile does nothing, as there is no portable file locking on the platform.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// syncDir does nothing on the platform.
+func syncDir(dir string) error {
+	return nil
+}
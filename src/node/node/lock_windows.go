@@ -0,0 +1,38 @@
+package node
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// lockFile takes an exclusive lock on the first byte of f past the pid
+// written to it, so other processes can still read the pid. The lock is
+// released when f is closed or the process exits.
+func lockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	ol.Offset = 1 << 10
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r != 0 {
+		return nil
+	}
+	if err == errorLockViolation {
+		return errWouldBlock
+	}
+	return err
+}
+
+// syncDir does nothing, as directories can't be synced on Windows and
+// NTFS journals creation of files.
+func syncDir(dir string) error {
+	return nil
+}
@@ -1,11 +1,23 @@
 package node
 
 import (
+	"context"
+	"io"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"auth"
+	"events"
+	"fault"
+	"metrics"
+	"ratelimit"
+	"registry"
 	router "router/client"
+	"secrets"
 	"storage"
+	"tiering"
 )
 
 // Config stores configuration for a Node service.
@@ -21,50 +33,513 @@ type Config struct {
 	// Heartbeat is a time interval between heartbeats.
 	// Heartbeat -- интервал между двумя heartbeats.
 	Heartbeat time.Duration
+	// RouterUDP is an address of Router to send heartbeats over UDP to.
+	// Heartbeats are sent over RPC if it is not set.
+	// RouterUDP -- адрес Router для отправки heartbeats по UDP.
+	// Если не задан, heartbeats отправляются по RPC.
+	RouterUDP storage.ServiceAddr `yaml:"router_udp"`
+	// UDPKey is a secret key to authenticate UDP heartbeats with.
+	// UDPKey -- секретный ключ для аутентификации heartbeats по UDP.
+	UDPKey string `yaml:"udp_key"`
+
+	// Registry specifies a service registry to register the node in.
+	// Registry -- реестр сервисов, в котором регистрируется node.
+	Registry registry.Config
+
+	// Resolve specifies how the address of Router is resolved.
+	// Resolve -- способ разрешения адреса Router.
+	Resolve storage.ResolverConfig
+
+	// Versions is a number of last versions of a record to keep, 0 disables history.
+	// Versions -- количество последних версий записи, которые нужно хранить,
+	// 0 отключает хранение истории.
+	Versions int
+	// VersionsTTL is a time versions of deleted records are kept for,
+	// VersionsTTL by default.
+	// VersionsTTL -- время хранения версий удаленных записей, по умолчанию
+	// VersionsTTL.
+	VersionsTTL time.Duration `yaml:"versions_ttl"`
+
+	// DataDir is a directory to store large values in. All values are kept
+	// in memory if it is not set.
+	// DataDir -- директория для хранения больших значений. Если не задана,
+	// все значения хранятся в памяти.
+	DataDir string `yaml:"data_dir"`
+	// DataFormat is a version of the data directory layout to migrate
+	// DataDir to when it is opened, the current one by default. The old
+	// layout is backed up before a migration, so it can be reverted.
+	// DataFormat -- версия формата директории данных, в которую мигрирует
+	// DataDir при открытии, по умолчанию текущая. Перед миграцией
+	// сохраняется копия старого формата, поэтому ее можно откатить.
+	DataFormat int `yaml:"data_format"`
+	// LargeValueThreshold is a size of values from which they are stored on disk.
+	// LargeValueThreshold -- размер значений, начиная с которого они хранятся на диске.
+	LargeValueThreshold int `yaml:"large_value_threshold"`
+	// MaxBytes is a quota of bytes of values stored on the node, Put and
+	// Reserve fail with storage.ErrQuotaExceeded over it. Zero means no quota.
+	// MaxBytes -- квота байт значений, хранящихся на node, сверх нее Put
+	// и Reserve завершаются ошибкой storage.ErrQuotaExceeded. Ноль означает
+	// отсутствие квоты.
+	MaxBytes int64 `yaml:"max_bytes"`
+	// RateLimit limits the rate of Put, Del and Get requests, requests over
+	// it fail with storage.ErrRateLimited. Not limited by default.
+	// RateLimit -- ограничение скорости запросов Put, Del и Get, запросы
+	// сверх него завершаются ошибкой storage.ErrRateLimited. По умолчанию
+	// не ограничена.
+	RateLimit ratelimit.Config `yaml:"rate_limit"`
+	// SegmentSize is a size of a disk log segment after which a new one is started.
+	// SegmentSize -- размер сегмента лога на диске, после которого начинается новый.
+	SegmentSize int64 `yaml:"segment_size"`
+	// SyncWrites makes Put wait until values stored on disk are synced.
+	// SyncWrites -- Put ждет синхронизации значений, хранящихся на диске.
+	SyncWrites bool `yaml:"sync_writes"`
+	// SyncDelay is a time concurrent writes wait at most to share one fsync.
+	// SyncDelay -- наибольшее время ожидания одновременных записей для
+	// совместного fsync.
+	SyncDelay time.Duration `yaml:"sync_delay"`
+	// CacheSize is a size of the cache of disk log blocks in bytes, 0 disables the cache.
+	// CacheSize -- размер кэша блоков лога на диске в байтах, 0 отключает кэш.
+	CacheSize int64 `yaml:"cache_size"`
+	// ReadAhead is a number of blocks read after the missing ones into the cache.
+	// ReadAhead -- количество блоков, читаемых в кэш после недостающих.
+	ReadAhead int `yaml:"read_ahead"`
+
+	// CompactInterval is a time interval between compactions of the disk log,
+	// 0 disables compaction.
+	// CompactInterval -- интервал между сжатиями лога на диске, 0 отключает сжатие.
+	CompactInterval time.Duration `yaml:"compact_interval"`
+	// CompactGarbageRatio is a share of garbage in a sealed segment from which
+	// it is compacted.
+	// CompactGarbageRatio -- доля мусора в закрытом сегменте, начиная с которой
+	// он сжимается.
+	CompactGarbageRatio float64 `yaml:"compact_garbage_ratio"`
+	// CompactMaxSegments is a number of sealed segments over which the ones
+	// with the most garbage are compacted regardless of its share, 0 disables
+	// the limit.
+	// CompactMaxSegments -- количество закрытых сегментов, сверх которого
+	// сжимаются сегменты с наибольшим количеством мусора независимо от его доли,
+	// 0 отключает ограничение.
+	CompactMaxSegments int `yaml:"compact_max_segments"`
+	// CompactRate is a number of bytes per second compaction copies at most
+	// not to slow down requests, 0 disables throttling.
+	// CompactRate -- наибольшее количество байт в секунду, копируемых при
+	// сжатии, чтобы не замедлять запросы, 0 отключает ограничение.
+	CompactRate int64 `yaml:"compact_rate"`
+
+	// GCInterval is a time interval between garbage collection passes, 0 disables GC.
+	// GCInterval -- интервал между проходами сборки мусора, 0 отключает сборку.
+	GCInterval time.Duration `yaml:"gc_interval"`
+	// GCDryRun makes GC only report orphaned records without deleting them.
+	// GCDryRun -- GC только сообщает о лишних записях, не удаляя их.
+	GCDryRun bool `yaml:"gc_dry_run"`
+	// GCArchiveFile is a file to archive records deleted by GC to.
+	// GCArchiveFile -- файл, в который архивируются удаленные GC записи.
+	GCArchiveFile string `yaml:"gc_archive_file"`
+
+	// Namespaces specifies storage classes of namespaces of keys, they
+	// should be the same as the ones of Frontend. Values are kept as
+	// described above by default.
+	// Namespaces -- классы хранения пространств ключей, должны совпадать
+	// с Namespaces во Frontend. По умолчанию значения хранятся, как
+	// описано выше.
+	Namespaces storage.Namespaces
+
+	// Tiering specifies an object store records not accessed for a while
+	// are migrated to and the policy of migration, records are kept local
+	// by default.
+	// Tiering -- хранилище объектов, в которое переносятся записи, к которым
+	// давно не было обращений, и политика переноса, по умолчанию записи
+	// хранятся на node.
+	Tiering tiering.Config
+	// ColdStore specifies the object store to migrate records to, New
+	// creates it from Tiering if it is not set.
+	// ColdStore -- хранилище объектов, в которое переносятся записи, New
+	// создает его по Tiering, если оно не задано.
+	ColdStore tiering.Store `yaml:"-"`
 
 	// Client specifies client for Router.
 	// Client -- клиент для Router.
 	Client router.Client `yaml:"-"`
+	// Peers specifies client for other nodes.
+	// Peers -- клиент для других node.
+	Peers storage.Client `yaml:"-"`
+
+	// WarmUp makes the node copy records it misses from other nodes
+	// before it starts sending heartbeats, e.g. after a restart.
+	// WarmUp -- node копирует недостающие записи с других node перед
+	// началом отправки heartbeats, например после перезапуска.
+	WarmUp bool `yaml:"warm_up"`
+	// DeltaLog is a number of the latest operations kept, so nodes which
+	// missed fewer of them catch up with Delta instead of a full scan,
+	// DeltaLog by default.
+	// DeltaLog -- количество хранящихся последних операций, так что node,
+	// пропустившие меньше операций, догоняют остальные с помощью Delta
+	// вместо полного сканирования, по умолчанию DeltaLog.
+	DeltaLog int `yaml:"delta_log"`
+	// MergeCRDT makes the node merge CRDT values of records it catches up
+	// with the ones it stores, see crdt.Merge, instead of replacing them.
+	// MergeCRDT -- node объединяет CRDT значения записей, которые она
+	// догоняет, с хранящимися, см. crdt.Merge, вместо их замены.
+	MergeCRDT bool `yaml:"merge_crdt"`
+
+	// PaxosRecovery is a time the node refuses to prepare and accept Paxos
+	// ballots for after it starts, PaxosRecovery by default, negative value
+	// disables it. The state of acceptors is kept in memory only, so rounds
+	// the node took part in before a restart finish on the other replicas
+	// meanwhile.
+	// PaxosRecovery -- время после запуска node, в течение которого она
+	// отказывается подготавливать и принимать ballot Paxos, по умолчанию
+	// PaxosRecovery, отрицательное значение отключает отказ. Состояние
+	// acceptor хранится только в памяти, поэтому раунды, в которых node
+	// участвовала до перезапуска, тем временем завершаются на других репликах.
+	PaxosRecovery time.Duration `yaml:"paxos_recovery"`
+
+	// QuarantineErrors is a number of local errors within QuarantineWindow
+	// after which the node reports itself degraded, 0 disables quarantine.
+	// QuarantineErrors -- количество локальных ошибок за QuarantineWindow,
+	// после которого node сообщает о своей неисправности, 0 отключает карантин.
+	QuarantineErrors int `yaml:"quarantine_errors"`
+	// QuarantineWindow is a time window to count local errors in.
+	// QuarantineWindow -- интервал времени, за который считаются локальные ошибки.
+	QuarantineWindow time.Duration `yaml:"quarantine_window"`
+
+	// Hooks specifies hooks called on changes of records.
+	// Hooks -- hooks, вызываемые при изменениях записей.
+	Hooks []Hooks `yaml:"-"`
+
+	// GCArchive specifies a writer to archive records deleted by GC to.
+	// GCArchive -- writer, в который архивируются удаленные GC записи.
+	GCArchive io.Writer `yaml:"-"`
+
+	// Faults enables the failure injection admin API.
+	// Faults -- включает API администратора для внесения сбоев.
+	Faults fault.Config
+
+	// Auth specifies tokens authorizing calls of admin APIs, calls are not
+	// authorized if it has none.
+	// Auth -- tokens для авторизации вызовов API администратора, вызовы
+	// не авторизуются, если их нет.
+	Auth auth.Config
+	// Signing specifies keys to sign requests to nodes and to verify
+	// requests to the node and their replies with, not signed by default.
+	// Signing -- ключи для подписи запросов к node и проверки подписей
+	// запросов к node и ответов на них, по умолчанию подпись отключена.
+	Signing storage.SigningConfig
+	// Signer specifies a signer of requests to nodes, NewDefault creates
+	// it from Signing if it is not set.
+	// Signer -- подписывающий запросы к node, NewDefault создает его
+	// по Signing, если он не задан.
+	Signer *storage.Signer `yaml:"-"`
+	// Secrets specifies a provider of secrets config values refer to as
+	// secret:<name>, e.g. keys and tokens, which are rotated without
+	// restarts.
+	// Secrets -- хранилище секретов, на которые значения конфигурации
+	// ссылаются как secret:<name>, например ключей и tokens, которые
+	// заменяются без перезапуска.
+	Secrets secrets.Config
+
+	// Metrics specifies a metrics sink and its options.
+	// Metrics -- приемник метрик и его настройки.
+	Metrics metrics.Config
+
+	// Events specifies subscribers of events published by the node.
+	// Events -- подписчики событий, публикуемых node.
+	Events events.Config
+
+	// Bus specifies a bus to publish events to, the one built from Events
+	// is used by default.
+	// Bus -- шина, в которую публикуются события, по умолчанию
+	// используется построенная по Events.
+	Bus *events.Bus `yaml:"-"`
+
+	// Sink specifies a sink to report metrics to.
+	// Sink -- приемник, в который отправляются метрики.
+	Sink metrics.Sink `yaml:"-"`
+
+	// Logger specifies a logger, the standard logger is used by default.
+	// Logger -- логгер, по умолчанию используется стандартный логгер.
+	Logger *log.Logger `yaml:"-"`
+
+	// Clock specifies a clock to tell time with.
+	// Clock -- часы, по которым определяется время.
+	Clock storage.Clock `yaml:"-"`
+}
+
+// Validate checks that cfg is consistent and returns a storage.ConfigError
+// listing all found problems. Router and Heartbeat are only required if
+// cfg.Client is set, a node without Client can be used as a local storage.
+//
+// Validate проверяет, что cfg непротиворечива, и возвращает
+// storage.ConfigError со списком всех найденных проблем. Router и Heartbeat
+// обязательны, только если задан cfg.Client, node без Client может
+// использоваться как локальное хранилище.
+func (cfg Config) Validate() error {
+	var errs storage.ConfigError
+	if cfg.Client != nil {
+		errs.Check(cfg.Router != "", "Router should be set with Client")
+		errs.Check(cfg.Heartbeat > 0, "Heartbeat should be positive, got %v", cfg.Heartbeat)
+	}
+	errs.Check(cfg.Versions >= 0, "Versions should not be negative, got %v", cfg.Versions)
+	errs.Check(cfg.VersionsTTL >= 0, "VersionsTTL should not be negative, got %v", cfg.VersionsTTL)
+	errs.Check(cfg.DataFormat >= 0 && cfg.DataFormat <= DataFormat,
+		"DataFormat should be between 1 and %v, got %v", DataFormat, cfg.DataFormat)
+	errs.Check(cfg.LargeValueThreshold >= 0, "LargeValueThreshold should not be negative, got %v", cfg.LargeValueThreshold)
+	errs.Check(cfg.SegmentSize >= 0, "SegmentSize should not be negative, got %v", cfg.SegmentSize)
+	errs.Check(cfg.SyncDelay >= 0, "SyncDelay should not be negative, got %v", cfg.SyncDelay)
+	errs.Check(cfg.CacheSize >= 0, "CacheSize should not be negative, got %v", cfg.CacheSize)
+	errs.Check(cfg.ReadAhead >= 0, "ReadAhead should not be negative, got %v", cfg.ReadAhead)
+	errs.Check(cfg.CompactInterval >= 0, "CompactInterval should not be negative, got %v", cfg.CompactInterval)
+	errs.Check(cfg.CompactGarbageRatio >= 0 && cfg.CompactGarbageRatio <= 1,
+		"CompactGarbageRatio should be between 0 and 1, got %v", cfg.CompactGarbageRatio)
+	errs.Check(cfg.CompactMaxSegments >= 0, "CompactMaxSegments should not be negative, got %v", cfg.CompactMaxSegments)
+	errs.Check(cfg.CompactRate >= 0, "CompactRate should not be negative, got %v", cfg.CompactRate)
+	errs.Check(cfg.GCInterval >= 0, "GCInterval should not be negative, got %v", cfg.GCInterval)
+	errs.Check(cfg.GCInterval == 0 || cfg.Client != nil && cfg.Peers != nil, "Client and Peers should be set to run GC")
+	errs.Check(!cfg.WarmUp || cfg.Client != nil && cfg.Peers != nil, "Client and Peers should be set to warm up")
+	errs.Check(cfg.DeltaLog >= 0, "DeltaLog should not be negative, got %v", cfg.DeltaLog)
+	errs.Check(cfg.MaxBytes >= 0, "MaxBytes should not be negative, got %v", cfg.MaxBytes)
+	errs.Merge(cfg.RateLimit.Validate())
+	errs.Merge(cfg.Signing.Validate())
+	errs.Merge(cfg.Tiering.Validate())
+	errs.Check(cfg.Tiering.Interval == 0 || cfg.Tiering.Kind != "" || cfg.ColdStore != nil,
+		"Tiering.Kind or ColdStore should be set with Tiering.Interval")
+	errs.Merge(cfg.Namespaces.Validate())
+	errs.Check(!cfg.Namespaces.Has(storage.ClassTiered) || cfg.Tiering.Kind != "" || cfg.ColdStore != nil,
+		"Tiering.Kind or ColdStore should be set for tiered namespaces")
+	errs.Check(cfg.DataDir != "" || !cfg.Namespaces.Has(storage.ClassPersistent) && !cfg.Namespaces.Has(storage.ClassErasureCoded),
+		"DataDir should be set for persistent and erasure-coded namespaces")
+	errs.Check(cfg.QuarantineErrors >= 0, "QuarantineErrors should not be negative, got %v", cfg.QuarantineErrors)
+	errs.Check(cfg.QuarantineWindow >= 0, "QuarantineWindow should not be negative, got %v", cfg.QuarantineWindow)
+	errs.Check(cfg.RouterUDP == "" || cfg.UDPKey != "", "UDPKey should be set with RouterUDP")
+	errs.Check(cfg.Registry.Kind == "" || cfg.Registry.Addr != "", "Registry.Addr should be set with Registry.Kind")
+	return errs.Err()
 }
 
 // Node is a Node service.
 type Node struct {
+	// records, bytes and requests are updated atomically for Stats not to
+	// take lock, they are first to be aligned on 32-bit platforms.
+	records   int64
+	bytes     int64
+	requests  int64
 	conf      Config
 	heartbeat chan struct{}
-	storage   map[storage.RecordID][]byte
+	storage   map[storage.RecordID]entry
+	disk      diskLog
 	lock      sync.RWMutex
+	leases    map[storage.RecordID]lease
+	leaseLock sync.Mutex
+	sequences map[string]uint64
+	seqLock   sync.Mutex
+	hooks     []hookRunner
+	history   map[storage.RecordID]*history
+	snapshots map[uint64]*snapshot
+	iters     map[*iteration]struct{}
+	seq       uint64
+	changes   map[storage.RecordID]change
+	ops       []storage.RecordID
+	opsStart  uint64
+	peerSeqs  map[storage.ServiceAddr]uint64
+	warmLock  sync.Mutex
+	barrier   *barrier
+	barLock   sync.Mutex
+	errors    []time.Time
+	errLock   sync.Mutex
+	compLock  sync.Mutex
+	segLock   sync.RWMutex
+	paxos     map[storage.RecordID]*paxosState
+	paxosLock sync.Mutex
+	// readLeases are expiry times of read leases by holders, leaseWriters
+	// count writes waiting for them and leaseReleased is closed to wake
+	// the writes when a lease is released.
+	readLeases    map[storage.RecordID]map[uint64]time.Time
+	leaseWriters  map[storage.RecordID]int
+	leaseReleased chan struct{}
+	readLeaseLock sync.Mutex
+
+	reservations map[storage.RecordID]reservation
+	reserved     int64
+	quotaLock    sync.Mutex
+	limiter      ratelimit.Limiter
+	compactRate  ratelimit.Limiter
+
+	// access are times of the last accesses of records tracked for tiering
+	// and objects is a number of objects uploaded to name new ones.
+	access   map[storage.RecordID]time.Time
+	objects  uint64
+	tierLock sync.Mutex
+
+	// historySwept is the time expired histories of deleted records were
+	// dropped at.
+	historySwept time.Time
+
+	// written maps keys of records to the versions their values were
+	// written with by writers, see storage.WithVersion. Values written
+	// without a version or restored on startup are not in it.
+	written map[storage.RecordID]uint64
+
+	// started is the time the node was created at, see cfg.PaxosRecovery.
+	// paxosFloor is the highest ballot committed by the dropped states
+	// of acceptors, new states start from it. Both are guarded by
+	// paxosLock.
+	started    time.Time
+	paxosFloor uint64
+
+	// namespaces are stats of records of cfg.Namespaces by their indices,
+	// updated atomically as records and bytes are.
+	namespaces []namespaceStats
 }
 
-// New creates a new Node with a given cfg.
+// New creates a new Node with a given cfg modified by opts.
+// Panics if cfg is invalid, use cfg.Validate to check it beforehand.
 //
-// New создает новый Node с данным cfg.
-func New(cfg Config) *Node {
+// New создает новый Node с данным cfg, измененной opts.
+// Паникует, если cfg некорректна, для проверки используйте cfg.Validate.
+func New(cfg Config, opts ...Option) *Node {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	if cfg.Sink == nil {
+		cfg.Sink = metrics.Discard
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = storage.SystemClock
+	}
+	if cfg.Bus == nil {
+		cfg.Bus = events.New(cfg.Events, cfg.Addr, cfg.Sink, cfg.Logger, cfg.Clock)
+	}
+	if cfg.LargeValueThreshold == 0 {
+		cfg.LargeValueThreshold = LargeValueThreshold
+	}
+	if cfg.QuarantineWindow == 0 {
+		cfg.QuarantineWindow = QuarantineWindow
+	}
+	if cfg.DataFormat == 0 {
+		cfg.DataFormat = DataFormat
+	}
+	if cfg.SegmentSize == 0 {
+		cfg.SegmentSize = SegmentSize
+	}
+	if cfg.CompactGarbageRatio == 0 {
+		cfg.CompactGarbageRatio = CompactGarbageRatio
+	}
+	if cfg.DeltaLog == 0 {
+		cfg.DeltaLog = DeltaLog
+	}
+	if cfg.VersionsTTL == 0 {
+		cfg.VersionsTTL = VersionsTTL
+	}
+	if cfg.PaxosRecovery == 0 {
+		cfg.PaxosRecovery = PaxosRecovery
+	}
+	if cfg.Tiering.After == 0 {
+		cfg.Tiering.After = tiering.After
+	}
+	if cfg.ColdStore == nil {
+		store, err := tiering.New(cfg.Tiering)
+		if err != nil {
+			panic(err)
+		}
+		cfg.ColdStore = store
+	}
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		panic(err)
+	}
+	compactRate := ratelimit.Unlimited
+	if cfg.CompactRate > 0 {
+		compactRate = ratelimit.NewTokenBucket(float64(cfg.CompactRate), int(cfg.CompactRate))
+	}
+	seq := uint64(cfg.Clock.Now().UnixNano())
 	return &Node{
-		conf:      cfg,
+		conf: cfg,
+		disk: diskLog{
+			format:      cfg.DataFormat,
+			segmentSize: cfg.SegmentSize,
+			cache:       newBlockCache(cfg.CacheSize),
+			readAhead:   cfg.ReadAhead,
+			sink:        cfg.Sink,
+			syncDelay:   cfg.SyncDelay,
+		},
 		heartbeat: make(chan struct{}),
-		storage:   make(map[storage.RecordID][]byte),
+		storage:   make(map[storage.RecordID]entry),
+		leases:    make(map[storage.RecordID]lease),
+		sequences: make(map[string]uint64),
+		hooks:     startHooks(cfg.Hooks, cfg.Logger),
+		history:   make(map[storage.RecordID]*history),
+		written:   make(map[storage.RecordID]uint64),
+		started:   cfg.Clock.Now(),
+		snapshots: make(map[uint64]*snapshot),
+		iters:     make(map[*iteration]struct{}),
+		seq:       seq,
+		changes:   make(map[storage.RecordID]change),
+		opsStart:  seq,
+		peerSeqs:  make(map[storage.ServiceAddr]uint64),
+		paxos:     make(map[storage.RecordID]*paxosState),
+
+		readLeases:    make(map[storage.RecordID]map[uint64]time.Time),
+		leaseWriters:  make(map[storage.RecordID]int),
+		leaseReleased: make(chan struct{}),
+
+		reservations: make(map[storage.RecordID]reservation),
+		limiter:      limiter,
+		compactRate:  compactRate,
+
+		access:  make(map[storage.RecordID]time.Time),
+		objects: seq,
+
+		namespaces: make([]namespaceStats, len(cfg.Namespaces)),
 	}
 }
 
 // Heartbeats runs heartbeats from node to a router
-// each time interval set by cfg.Heartbeat.
+// each time interval set by cfg.Heartbeat. If cfg.WarmUp is set, the node
+// catches up with other nodes by WarmUp once heartbeats succeed again after
+// failing, e.g. after a network partition.
 //
 // Heartbeats запускает отправку heartbeats от node к router
-// через каждый интервал времени, заданный в cfg.Heartbeat.
+// через каждый интервал времени, заданный в cfg.Heartbeat. Если задан
+// cfg.WarmUp, node догоняет другие node с помощью WarmUp, когда heartbeats
+// снова проходят после ошибок, например после разделения сети.
 func (node *Node) Heartbeats() {
 	go func() {
+		failed := false
 		for {
 			select {
 			case <-node.heartbeat:
 				return
 			default:
-				node.conf.Client.Heartbeat(node.conf.Router, node.conf.Addr)
+				err := node.conf.Client.Heartbeat(node.conf.Router, node.beat())
+				switch {
+				case err != nil:
+					failed = true
+				case failed:
+					failed = false
+					if node.conf.WarmUp {
+						node.catchUp()
+					}
+				}
 				time.Sleep(node.conf.Heartbeat)
 			}
 		}
 	}()
 }
 
+// beat returns the current heartbeat of the node.
+func (node *Node) beat() storage.Heartbeat {
+	return storage.Heartbeat{
+		Node:     node.conf.Addr,
+		Degraded: node.Degraded(),
+		Seq:      node.Seq(),
+	}
+}
+
 // Stop stops heartbeats
 //
 // Stop останавливает отправку heartbeats.
@@ -73,18 +548,86 @@ func (node *Node) Stop() {
 }
 
 // Put an item to the node if an item for the given key doesn't exist.
-// Returns the storage.ErrRecordExists error otherwise.
+// Returns the storage.ErrRecordExists error otherwise. Returns the
+// storage.ErrQuotaExceeded error if the item doesn't fit cfg.MaxBytes.
 //
 // Put -- добавить запись в node, если запись для данного ключа
-// не существует. Иначе вернуть ошибку storage.ErrRecordExists.
+// не существует. Иначе вернуть ошибку storage.ErrRecordExists. Возвращает
+// ошибку storage.ErrQuotaExceeded, если запись не помещается в cfg.MaxBytes.
 func (node *Node) Put(k storage.RecordID, d []byte) error {
+	return node.PutContext(context.Background(), k, d)
+}
+
+// PutContext is Put returning ctx.Err() once ctx is done, e.g. when the
+// deadline of the request passes while the value is written or synced.
+// The record is not added then.
+//
+// PutContext -- Put, возвращающий ctx.Err(), когда ctx завершен, например
+// если срок запроса истек во время записи или синхронизации значения.
+// Запись в этом случае не добавляется.
+func (node *Node) PutContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := node.allow(1); err != nil {
+		return err
+	}
+	node.waitBarrier()
+	defer node.waitReadLeases(k)()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	node.lock.RLock()
+	_, ok := node.storage[k]
+	node.lock.RUnlock()
+	if ok {
+		return storage.ErrRecordExists
+	}
+	done, err := node.admit(k, int64(len(d)))
+	if err != nil {
+		return err
+	}
+	defer done()
+	e, err := node.store(ctx, k, d)
+	if err != nil {
+		return err
+	}
+
 	node.lock.Lock()
-	defer node.lock.Unlock()
+	err = node.putVersion(k, d, e, storage.VersionOf(ctx))
+	node.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return node.syncJournal(ctx, k)
+}
+
+// put adds the record with key k stored in e unless it exists.
+// Should be called with node.lock held.
+func (node *Node) put(k storage.RecordID, d []byte, e entry) error {
+	return node.putVersion(k, d, e, 0)
+}
 
+// putVersion is put creating the version v of the record, see remember.
+func (node *Node) putVersion(k storage.RecordID, d []byte, e entry, v uint64) error {
 	if _, ok := node.storage[k]; ok {
+		// e is left in the disk log as garbage for compaction.
 		return storage.ErrRecordExists
 	}
-	node.storage[k] = d
+	if err := node.journal(k, e, false); err != nil {
+		return err
+	}
+	node.storage[k] = e
+	node.account(k, 1, int64(e.size))
+	node.remember(k, e, v)
+	if v != 0 {
+		node.written[k] = v
+	}
+	node.touch(k)
+	node.track(k, false)
+	node.notify(hookEvent{k: k, d: d})
+	node.conf.Sink.IncrCounter("node.put", 1)
+	node.conf.Sink.SetGauge("node.records", float64(len(node.storage)))
 
 	return nil
 }
@@ -95,29 +638,331 @@ func (node *Node) Put(k storage.RecordID, d []byte) error {
 // Del -- удалить запись из node, если запись для данного ключа
 // существует. Иначе вернуть ошибку storage.ErrRecordNotFound.
 func (node *Node) Del(k storage.RecordID) error {
+	if err := node.allow(1); err != nil {
+		return err
+	}
+	return node.delVersion(k, 0)
+}
+
+// delVersion is Del deleting the record with key k only if its value was
+// written with the version v, see storage.WithVersion. Returns the
+// storage.ErrConditionFailed error otherwise. Any value is deleted if v
+// is 0.
+func (node *Node) delVersion(k storage.RecordID, v uint64) error {
+	node.waitBarrier()
+	defer node.waitReadLeases(k)()
 	node.lock.Lock()
-	defer node.lock.Unlock()
+	err := node.checkVersion(k, v)
+	if err == nil {
+		err = node.del(k)
+	}
+	node.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return node.syncJournal(context.Background(), k)
+}
+
+// checkVersion returns the storage.ErrConditionFailed error if the record
+// with key k exists and its value was not written with the version v,
+// unless v is 0. Should be called with node.lock held.
+func (node *Node) checkVersion(k storage.RecordID, v uint64) error {
+	if _, ok := node.storage[k]; ok && v != 0 && node.written[k] != v {
+		return storage.ErrConditionFailed
+	}
+	return nil
+}
 
-	if _, ok := node.storage[k]; !ok {
+// del removes the record with key k if it exists.
+// Should be called with node.lock held.
+func (node *Node) del(k storage.RecordID) error {
+	e, ok := node.storage[k]
+	if !ok {
 		return storage.ErrRecordNotFound
 	}
+	if err := node.journal(k, e, true); err != nil {
+		return err
+	}
 	delete(node.storage, k)
+	delete(node.written, k)
+	node.account(k, -1, -int64(e.size))
+	node.untouch(k, e)
+	node.retire(k)
+	node.track(k, true)
+	node.notify(hookEvent{del: true, k: k})
+	node.conf.Sink.IncrCounter("node.del", 1)
+	node.conf.Sink.SetGauge("node.records", float64(len(node.storage)))
 
 	return nil
 }
 
+// ApplyBatch applies ops in order under one lock acquisition, appending
+// values stored on disk at once, and returns an error of every op. Ops are
+// applied independently, as if Put and Del were called one by one, so
+// a failed op doesn't stop the rest.
+//
+// ApplyBatch применяет ops по порядку под одной блокировкой, дописывая
+// хранящиеся на диске значения за один раз, и возвращает ошибку каждой
+// операции. Операции применяются независимо, как если бы Put и Del
+// вызывались по очереди, поэтому неудачная операция не останавливает остальные.
+func (node *Node) ApplyBatch(ops []storage.Op) []error {
+	if err := node.allow(len(ops)); err != nil {
+		errs := make([]error, len(ops))
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	return node.applyBatch(ops)
+}
+
+// applyBatch is ApplyBatch not limited by cfg.RateLimit.
+func (node *Node) applyBatch(ops []storage.Op) []error {
+	return node.applyBatchSince(ops, nil)
+}
+
+// applyBatchSince is applyBatch failing ops on records changed after
+// the sequence number *since with errChangedLocally, e.g. by clients while
+// the ops were prepared from another node. All ops are applied if since is
+// nil.
+func (node *Node) applyBatchSince(ops []storage.Op, since *uint64) []error {
+	errs := make([]error, len(ops))
+	node.waitBarrier()
+	keys := make([]storage.RecordID, len(ops))
+	for i, op := range ops {
+		keys[i] = op.Key
+	}
+	defer node.waitReadLeases(keys...)()
+	for i, op := range ops {
+		if op.Del {
+			continue
+		}
+		done, err := node.admit(op.Key, int64(len(op.Data)))
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		defer done()
+	}
+	entries, err := node.storeBatch(ops, errs)
+	if err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return errs
+	}
+
+	node.lock.Lock()
+	defer func() {
+		node.lock.Unlock()
+		// Ops are acknowledged once changes of durable records are synced.
+		if err := node.syncJournal(context.Background(), keys...); err != nil {
+			for i := range errs {
+				if errs[i] == nil {
+					errs[i] = err
+				}
+			}
+		}
+	}()
+	// Checked before any op is applied, as they are changes too.
+	var changed func(k storage.RecordID) bool
+	if since != nil {
+		changed = node.changedSince(*since)
+	}
+	for i, op := range ops {
+		if errs[i] != nil {
+			continue
+		}
+		if changed != nil && changed(op.Key) {
+			errs[i] = errChangedLocally
+			continue
+		}
+		if op.Del {
+			if errs[i] = node.checkVersion(op.Key, op.Version); errs[i] == nil {
+				errs[i] = node.del(op.Key)
+			}
+		} else {
+			errs[i] = node.putVersion(op.Key, op.Data, entries[i], op.Version)
+		}
+	}
+	node.conf.Sink.IncrCounter("node.batches", 1)
+	return errs
+}
+
+// MPut puts items for the given keys with one append to the disk log and
+// returns an error of every key, as if Put was called for each of them.
+//
+// MPut добавляет записи для данных ключей одной записью в журнал на диске
+// и возвращает ошибку для каждого ключа, как если бы Put вызывался для каждого.
+func (node *Node) MPut(keys []storage.RecordID, data [][]byte) []error {
+	return node.MPutContext(context.Background(), keys, data)
+}
+
+// MPutContext is MPut creating the versions of the records given by
+// storage.VersionOf(ctx).
+//
+// MPutContext -- MPut, создающий версии записей, заданные
+// storage.VersionOf(ctx).
+func (node *Node) MPutContext(ctx context.Context, keys []storage.RecordID, data [][]byte) []error {
+	v := storage.VersionOf(ctx)
+	ops := make([]storage.Op, len(keys))
+	for i, k := range keys {
+		ops[i] = storage.Op{Key: k, Data: data[i], Version: v}
+	}
+	return node.ApplyBatch(ops)
+}
+
+// MDel deletes items for the given keys under one lock acquisition and
+// returns an error of every key, as if Del was called for each of them.
+//
+// MDel удаляет записи для данных ключей под одной блокировкой и возвращает
+// ошибку для каждого ключа, как если бы Del вызывался для каждого.
+func (node *Node) MDel(keys []storage.RecordID) []error {
+	return node.MDelContext(context.Background(), keys)
+}
+
+// MDelContext is MDel deleting only the records whose values are
+// the version storage.VersionOf(ctx) if it is set, see delVersion.
+//
+// MDelContext -- MDel, удаляющий только записи, значения которых имеют
+// версию storage.VersionOf(ctx), если она задана, см. delVersion.
+func (node *Node) MDelContext(ctx context.Context, keys []storage.RecordID) []error {
+	v := storage.VersionOf(ctx)
+	ops := make([]storage.Op, len(keys))
+	for i, k := range keys {
+		ops[i] = storage.Op{Key: k, Del: true, Version: v}
+	}
+	return node.ApplyBatch(ops)
+}
+
 // Get an item from the node if an item exists for the given key.
-// Returns the storage.ErrRecordNotFound error otherwise.
+// Returns the storage.ErrRecordNotFound error otherwise. Items migrated
+// to cfg.ColdStore are fetched back.
 //
 // Get -- получить запись из node, если запись для данного ключа
-// существует. Иначе вернуть ошибку storage.ErrRecordNotFound.
+// существует. Иначе вернуть ошибку storage.ErrRecordNotFound. Записи,
+// перенесенные в cfg.ColdStore, загружаются обратно.
 func (node *Node) Get(k storage.RecordID) ([]byte, error) {
+	if err := node.allow(1); err != nil {
+		return nil, err
+	}
 	node.lock.RLock()
+	node.conf.Sink.IncrCounter("node.get", 1)
+	e, ok := node.storage[k]
+	if !ok {
+		node.lock.RUnlock()
+		return nil, storage.ErrRecordNotFound
+	}
+	node.touch(k)
+	if e.obj != "" {
+		node.lock.RUnlock()
+		return node.fetchBack(k, e)
+	}
 	defer node.lock.RUnlock()
+	return node.load(e)
+}
 
-	if item, ok := node.storage[k]; ok {
-		return item, nil
+// Checksum returns the checksum computed by h of the value stored for
+// the given key, computed from the value loaded from the node's storage,
+// or the storage.ErrRecordNotFound error if there is no such record.
+//
+// Checksum возвращает контрольную сумму, вычисленную h, значения,
+// хранящегося для данного ключа, вычисленную по значению, загруженному
+// из хранилища node, или ошибку storage.ErrRecordNotFound, если такой
+// записи нет.
+func (node *Node) Checksum(k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	if err := h.Validate(); err != nil {
+		return storage.Checksum{}, err
+	}
+	d, err := node.Get(k)
+	if err != nil {
+		return storage.Checksum{}, err
 	}
+	node.conf.Sink.IncrCounter("node.checksum", 1)
+	return h.Sum(d), nil
+}
 
-	return nil, storage.ErrRecordNotFound
+// MGet gets items for the given keys under one lock acquisition and returns
+// a value and an error of every key, as if Get was called for each of them.
+//
+// MGet получает записи для данных ключей под одной блокировкой и возвращает
+// значение и ошибку для каждого ключа, как если бы Get вызывался для каждого.
+func (node *Node) MGet(keys []storage.RecordID) ([][]byte, []error) {
+	data := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	if err := node.allow(len(keys)); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return data, errs
+	}
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+
+	node.conf.Sink.IncrCounter("node.get", int64(len(keys)))
+	for i, k := range keys {
+		if e, ok := node.storage[k]; ok {
+			node.touch(k)
+			data[i], errs[i] = node.load(e)
+		} else {
+			errs[i] = storage.ErrRecordNotFound
+		}
+	}
+	return data, errs
+}
+
+// Sequence raises the value of the sequence with the given name
+// to floor if it is less and returns the resulting value.
+//
+// Sequence увеличивает значение последовательности с данным именем
+// до floor, если оно меньше, и возвращает получившееся значение.
+func (node *Node) Sequence(name string, floor uint64) (uint64, error) {
+	node.seqLock.Lock()
+	defer node.seqLock.Unlock()
+
+	if node.sequences[name] < floor {
+		node.sequences[name] = floor
+	}
+	return node.sequences[name], nil
+}
+
+// namespaceStats are stats of records of a namespace.
+type namespaceStats struct {
+	records atomic.Int64
+	bytes   atomic.Int64
+}
+
+// Stats returns the number of records stored on the node, their size and
+// the number of requests received, in total and by cfg.Namespaces. Stats
+// doesn't take locks and never blocks writes.
+//
+// Stats возвращает количество записей, хранящихся на node, их размер
+// и количество полученных запросов, всего и по cfg.Namespaces. Stats
+// не берет блокировок и никогда не блокирует запись.
+func (node *Node) Stats() (storage.Stats, error) {
+	stats := storage.Stats{
+		Records:  uint64(atomic.LoadInt64(&node.records)),
+		Bytes:    uint64(atomic.LoadInt64(&node.bytes)),
+		Requests: uint64(atomic.LoadInt64(&node.requests)),
+	}
+	for i, ns := range node.conf.Namespaces {
+		stats.Namespaces = append(stats.Namespaces, storage.NamespaceStats{
+			Prefix:  ns.Prefix,
+			Records: uint64(node.namespaces[i].records.Load()),
+			Bytes:   uint64(node.namespaces[i].bytes.Load()),
+		})
+	}
+	return stats, nil
+}
+
+// account adds n records of the given size with key k to the statistics.
+func (node *Node) account(k storage.RecordID, n, size int64) {
+	atomic.AddInt64(&node.records, n)
+	atomic.AddInt64(&node.bytes, size)
+	if i := node.conf.Namespaces.Index(k); i >= 0 {
+		node.namespaces[i].records.Add(n)
+		node.namespaces[i].bytes.Add(size)
+	}
 }
@@ -1,16 +1,25 @@
 package node
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"ratelimit"
 	"storage"
+	"storage/crdt"
+	"tiering"
 )
 
 var cfg = Config{
@@ -72,6 +81,80 @@ func TestDel(t *testing.T) {
 	}
 }
 
+func TestUpdateUpsert(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	if err := s.Update(key, []byte("a")); err != storage.ErrRecordNotFound {
+		t.Fatalf("Update() of a missing record got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if err := s.Upsert(key, []byte("a")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	if err := s.Update(key, []byte("bb")); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if got, err := s.Get(key); err != nil || string(got) != "bb" {
+		t.Errorf("Get() after Update() got %q, %v, want %q", got, err, "bb")
+	}
+	if err := s.Upsert(key, []byte("c")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	if got, err := s.Get(key); err != nil || string(got) != "c" {
+		t.Errorf("Get() after Upsert() got %q, %v, want %q", got, err, "c")
+	}
+	if st, err := s.Stats(); err != nil || st.Records != 1 || st.Bytes != 1 {
+		t.Errorf("Stats() got %+v, %v, want 1 record of 1 byte", st, err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.PutContext(ctx, key, []byte("some data")); err != nil {
+		t.Fatalf("PutContext() error: %v", err)
+	}
+	cancel()
+	if _, err := s.GetContext(ctx, key); err != context.Canceled {
+		t.Errorf("GetContext() got error %v, want %v", err, context.Canceled)
+	}
+	if err := s.DelContext(ctx, key); err != context.Canceled {
+		t.Errorf("DelContext() got error %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.Get(key); err != nil {
+		t.Errorf("Get() after a canceled DelContext() error: %v", err)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SyncWrites = true
+	c.SyncDelay = time.Second
+	s := New(c)
+	defer s.Close()
+
+	key := storage.RecordID(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.PutContext(ctx, key, []byte("large value")); err != context.DeadlineExceeded {
+		t.Fatalf("PutContext() waiting for fsync past the deadline got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := s.Get(key); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after a timed out PutContext() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if _, _, err := s.ScanContext(ctx, nil, 0); err != context.DeadlineExceeded {
+		t.Errorf("ScanContext() past the deadline got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
 func TestParallelOps(t *testing.T) {
 	s := New(cfg)
 	var keys []storage.RecordID
@@ -139,8 +222,19 @@ func (c *FakeClient) NodesFind(router storage.ServiceAddr, k storage.RecordID) (
 	return nil, nil
 }
 func (c *FakeClient) List(router storage.ServiceAddr) ([]storage.ServiceAddr, error) { return nil, nil }
+func (c *FakeClient) Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	return nil, nil
+}
 
-func (c *FakeClient) Heartbeat(router, node storage.ServiceAddr) error {
+func (c *FakeClient) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	return nil, nil
+}
+
+func (c *FakeClient) Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	return nil, nil
+}
+
+func (c *FakeClient) Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error {
 	c.Lock()
 	defer c.Unlock()
 	if c.n == 2 {
@@ -172,6 +266,7 @@ func TestHeartbeat(t *testing.T) {
 	s := New(Config{
 		Client:    c,
 		Addr:      "test",
+		Router:    "router",
 		Heartbeat: d,
 	})
 
@@ -199,7 +294,19 @@ func (c *FakeClientStopHeartbeat) List(router storage.ServiceAddr) ([]storage.Se
 	return nil, nil
 }
 
-func (c *FakeClientStopHeartbeat) Heartbeat(router, node storage.ServiceAddr) error {
+func (c *FakeClientStopHeartbeat) Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	return nil, nil
+}
+
+func (c *FakeClientStopHeartbeat) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	return nil, nil
+}
+
+func (c *FakeClientStopHeartbeat) Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	return nil, nil
+}
+
+func (c *FakeClientStopHeartbeat) Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error {
 	c.Lock()
 	defer c.Unlock()
 	if c.stopped {
@@ -214,6 +321,7 @@ func TestStopHeartbeat(t *testing.T) {
 	s := New(Config{
 		Client:    c,
 		Addr:      "test",
+		Router:    "router",
 		Heartbeat: 100 * time.Millisecond,
 	})
 
@@ -236,3 +344,1865 @@ func TestMain(m *testing.M) {
 	rand.Seed(time.Now().UnixNano())
 	os.Exit(m.Run())
 }
+
+func TestScan(t *testing.T) {
+	s := New(cfg)
+	const n = 25
+	for _, i := range rand.Perm(n) {
+		if err := s.Put(storage.RecordID(i), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	for _, limit := range []int{1, 4, n, 2 * n} {
+		var cursor storage.Cursor
+		var got []storage.RecordID
+		for {
+			records, next, err := s.Scan(cursor, limit)
+			if err != nil {
+				t.Fatalf("Scan() error: %v", err)
+			}
+			if len(records) > limit {
+				t.Fatalf("Scan() returned %d records, limit is %d", len(records), limit)
+			}
+			for _, r := range records {
+				if string(r.Data) != fmt.Sprint(r.Key) {
+					t.Errorf("Wrong data for key %v: got %s", r.Key, r.Data)
+				}
+				got = append(got, r.Key)
+			}
+			if next == nil {
+				break
+			}
+			cursor = next
+		}
+		if len(got) != n {
+			t.Fatalf("Scan() with limit %d returned %d records, want %d", limit, len(got), n)
+		}
+		for i, k := range got {
+			if k != storage.RecordID(i) {
+				t.Fatalf("Scan() with limit %d wrong order of keys: %v", limit, got)
+			}
+		}
+	}
+
+	if _, _, err := s.Scan(storage.Cursor("bad"), 0); err != storage.ErrInvalidCursor {
+		t.Errorf("Scan() got error %v, want %v", err, storage.ErrInvalidCursor)
+	}
+}
+
+func TestLease(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	ttl := 100 * time.Millisecond
+
+	fence, err := s.AcquireLease(key, 1, 0, ttl)
+	if err != nil {
+		t.Fatalf("AcquireLease() error: %v", err)
+	}
+	if _, err := s.AcquireLease(key, 2, 0, ttl); err != storage.ErrLocked {
+		t.Fatalf("AcquireLease() got error %v, want %v", err, storage.ErrLocked)
+	}
+	if got, err := s.AcquireLease(key, 1, 0, ttl); err != nil || got != fence {
+		t.Fatalf("AcquireLease() renewal got %v, %v, want %v", got, err, fence)
+	}
+	if err := s.ReleaseLease(key, 2); err != storage.ErrNotLockHolder {
+		t.Fatalf("ReleaseLease() got error %v, want %v", err, storage.ErrNotLockHolder)
+	}
+	if err := s.ReleaseLease(key, 1); err != nil {
+		t.Fatalf("ReleaseLease() error: %v", err)
+	}
+
+	next, err := s.AcquireLease(key, 2, 0, ttl)
+	if err != nil {
+		t.Fatalf("AcquireLease() error: %v", err)
+	}
+	if next <= fence {
+		t.Errorf("Fencing token didn't increase: got %v, previous %v", next, fence)
+	}
+
+	time.Sleep(ttl)
+	if _, err := s.AcquireLease(key, 3, 0, ttl); err != nil {
+		t.Errorf("AcquireLease() after expiration error: %v", err)
+	}
+	if err := s.ReleaseLease(key, 2); err != storage.ErrNotLockHolder {
+		t.Errorf("ReleaseLease() of expired lease got error %v, want %v", err, storage.ErrNotLockHolder)
+	}
+	if err := s.ReleaseLease(key, 3); err != nil {
+		t.Fatalf("ReleaseLease() error: %v", err)
+	}
+
+	// Proposed fences must exceed the greatest one granted so far.
+	last, err := s.LeaseFence(key)
+	if err != nil || last <= next {
+		t.Fatalf("LeaseFence() got %v, %v, want more than %v", last, err, next)
+	}
+	if _, err := s.AcquireLease(key, 4, last, ttl); err != storage.ErrFenceRejected {
+		t.Errorf("AcquireLease() of fence %v got error %v, want %v", last, err, storage.ErrFenceRejected)
+	}
+	if got, err := s.AcquireLease(key, 4, last+10, ttl); err != nil || got != last+10 {
+		t.Errorf("AcquireLease() of fence %v got %v, %v", last+10, got, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := New(cfg)
+	for i, d := range []string{"a", "bb", "ccc"} {
+		if err := s.Put(storage.RecordID(i), []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	if want := (storage.Stats{Records: 3, Bytes: 6, Requests: 3}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats() got %v, want %v", stats, want)
+	}
+
+	if err := s.Del(1); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	// Stats should not wait for writers.
+	s.lock.Lock()
+	done := make(chan storage.Stats)
+	go func() {
+		stats, _ := s.Stats()
+		done <- stats
+	}()
+	select {
+	case stats := <-done:
+		if want := (storage.Stats{Records: 2, Bytes: 4, Requests: 4}); !reflect.DeepEqual(stats, want) {
+			t.Errorf("Stats() after Del() got %v, want %v", stats, want)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Stats() blocked by a writer")
+	}
+	s.lock.Unlock()
+}
+
+func TestStats_Namespaces(t *testing.T) {
+	c := cfg
+	c.Namespaces = storage.Namespaces{
+		{Prefix: storage.Prefix{Key: 0x10000000, Len: 4}, Class: storage.ClassMemory},
+		{Prefix: storage.Prefix{Key: 0x12000000, Len: 8}, Class: storage.ClassMemory},
+	}
+	s := New(c)
+	for k, d := range map[storage.RecordID]string{0x10000001: "a", 0x12000001: "bb", 1: "ccc"} {
+		if err := s.Put(k, []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if err := s.Del(0x12000001); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	// Records of nested namespaces are only counted in the longest one.
+	want := []storage.NamespaceStats{
+		{Prefix: c.Namespaces[0].Prefix, Records: 1, Bytes: 1},
+		{Prefix: c.Namespaces[1].Prefix},
+	}
+	if stats.Records != 2 || !reflect.DeepEqual(stats.Namespaces, want) {
+		t.Errorf("Stats() got %+v, want 2 records in namespaces %+v", stats, want)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	s := New(cfg)
+	if err := s.Put(1, []byte("value")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	for _, h := range []storage.Hash{"", storage.HashSHA256, storage.HashCRC64, storage.HashFNV} {
+		if sum, err := s.Checksum(1, h); err != nil || sum != h.Sum([]byte("value")) {
+			t.Errorf("Checksum(%q) got %x, %v, want %x", h, sum, err, h.Sum([]byte("value")))
+		}
+	}
+	if _, err := s.Checksum(2, storage.HashSHA256); err != storage.ErrRecordNotFound {
+		t.Errorf("Checksum() of missing record got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if _, err := s.Checksum(1, "md4"); err == nil {
+		t.Errorf("Checksum() with unknown hash got no error")
+	}
+}
+
+func TestMGet(t *testing.T) {
+	s := New(cfg)
+	if err := s.Put(1, []byte("1")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := s.Put(3, []byte("3")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	data, errs := s.MGet([]storage.RecordID{1, 2, 3})
+	want := [][]byte{[]byte("1"), nil, []byte("3")}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("MGet() got %q, want %q", data, want)
+	}
+	if errs[0] != nil || errs[1] != storage.ErrRecordNotFound || errs[2] != nil {
+		t.Errorf("MGet() got errors %v, want [<nil> %v <nil>]", errs, storage.ErrRecordNotFound)
+	}
+}
+
+func TestMPutMDel(t *testing.T) {
+	s := New(cfg)
+	if err := s.Put(2, []byte("2")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	keys := []storage.RecordID{1, 2, 3}
+	errs := s.MPut(keys, [][]byte{[]byte("1"), []byte("x"), []byte("3")})
+	if errs[0] != nil || errs[1] != storage.ErrRecordExists || errs[2] != nil {
+		t.Errorf("MPut() got errors %v, want [<nil> %v <nil>]", errs, storage.ErrRecordExists)
+	}
+	data, _ := s.MGet(keys)
+	if want := [][]byte{[]byte("1"), []byte("2"), []byte("3")}; !reflect.DeepEqual(data, want) {
+		t.Errorf("MGet() after MPut() got %q, want %q", data, want)
+	}
+
+	errs = s.MDel([]storage.RecordID{1, 4})
+	if errs[0] != nil || errs[1] != storage.ErrRecordNotFound {
+		t.Errorf("MDel() got errors %v, want [<nil> %v]", errs, storage.ErrRecordNotFound)
+	}
+	if _, err := s.Get(1); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after MDel() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	c := cfg
+	// 0.001 requests per second make 4 requests an hour.
+	c.RateLimit = ratelimit.Config{Kind: ratelimit.KindSlidingWindow, Rate: 0.001, Window: time.Hour}
+	s := New(c)
+
+	if err := s.Put(1, []byte("1")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if _, err := s.Get(1); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if err := s.Put(3, []byte("3")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	ops := []storage.Op{{Key: 1, Del: true}, {Key: 2, Data: []byte("2")}}
+	if errs := s.ApplyBatch(ops); !errors.Is(errs[0], storage.ErrRateLimited) || !errors.Is(errs[1], storage.ErrRateLimited) {
+		t.Errorf("ApplyBatch() over rate limit got errors %v, want %v", errs, storage.ErrRateLimited)
+	}
+	if errs := s.ApplyBatch(ops[:1]); errs[0] != nil {
+		t.Fatalf("ApplyBatch() error: %v", errs[0])
+	}
+	_, err := s.Get(3)
+	if !errors.Is(err, storage.ErrRateLimited) {
+		t.Errorf("Get() over rate limit got error %v, want %v", err, storage.ErrRateLimited)
+	}
+	if d, ok := storage.RetryAfter(err); !ok || d != 1000*time.Second {
+		t.Errorf("Get() over rate limit got hint %v, want %v", d, 1000*time.Second)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	c := cfg
+	c.MaxBytes = 10
+	s := New(c)
+
+	if err := s.Put(1, []byte("123456")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := s.Reserve(2, 4, time.Minute); err != nil {
+		t.Fatalf("Reserve() error: %v", err)
+	}
+	if err := s.Reserve(3, 1, time.Minute); !errors.Is(err, storage.ErrQuotaExceeded) {
+		t.Errorf("Reserve() over quota got error %v, want %v", err, storage.ErrQuotaExceeded)
+	}
+	if err := s.Put(3, []byte("1")); !errors.Is(err, storage.ErrQuotaExceeded) {
+		t.Errorf("Put() over reserved quota got error %v, want %v", err, storage.ErrQuotaExceeded)
+	}
+	if err := s.Put(2, []byte("1234")); err != nil {
+		t.Errorf("Put() of reserved record error: %v", err)
+	}
+	if err := s.Del(1); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if err := s.Reserve(3, 6, time.Minute); err != nil {
+		t.Fatalf("Reserve() error: %v", err)
+	}
+	if err := s.CancelReservation(3); err != nil {
+		t.Fatalf("CancelReservation() error: %v", err)
+	}
+	if err := s.Reserve(4, 6, 0); err != nil {
+		t.Fatalf("Reserve() after cancel error: %v", err)
+	}
+	// The reservation of 4 has expired at once.
+	if err := s.Reserve(5, 6, 0); err != nil {
+		t.Fatalf("Reserve() after expiration error: %v", err)
+	}
+
+	// Concurrent writers don't overshoot the quota.
+	s = New(c)
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	stored := 0
+	for k := storage.RecordID(0); k < 20; k++ {
+		wg.Add(1)
+		go func(k storage.RecordID) {
+			defer wg.Done()
+			if err := s.Put(k, []byte("1")); err == nil {
+				lock.Lock()
+				stored++
+				lock.Unlock()
+			}
+		}(k)
+	}
+	wg.Wait()
+	if stored != 10 {
+		t.Errorf("Concurrent Put() stored %d records, want 10", stored)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 100)}
+	c := cfg
+	c.Versions = 2
+	c.VersionsTTL = time.Hour
+	c.Clock = clock
+	s := New(c)
+	key := storage.RecordID(1)
+
+	if _, err := s.ListVersions(key); err != storage.ErrRecordNotFound {
+		t.Errorf("ListVersions() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	// Writers number versions, the node numbers the ones they don't by its
+	// clock, versions not above the last one are moved past it.
+	for _, w := range []struct {
+		d string
+		v uint64
+	}{{"v1", 10}, {"v2", 20}, {"v3", 0}, {"v4", 50}} {
+		ctx := storage.WithVersion(context.Background(), w.v)
+		if err := s.PutContext(ctx, key, []byte(w.d)); err != nil {
+			t.Fatalf("PutContext() error: %v", err)
+		}
+		if err := s.Del(key); err != nil {
+			t.Fatalf("Del() error: %v", err)
+		}
+	}
+	if err := s.UpsertContext(storage.WithVersion(context.Background(), 60), key, []byte("v5")); err != nil {
+		t.Fatalf("UpsertContext() error: %v", err)
+	}
+
+	versions, err := s.ListVersions(key)
+	if err != nil {
+		t.Fatalf("ListVersions() error: %v", err)
+	}
+	if !reflect.DeepEqual(versions, []uint64{101, 102}) {
+		t.Errorf("ListVersions() got %v, want %v", versions, []uint64{101, 102})
+	}
+	if d, err := s.GetVersion(key, 101); err != nil || string(d) != "v4" {
+		t.Errorf("GetVersion(101) got %q, %v, want %q", d, err, "v4")
+	}
+	if _, err := s.GetVersion(key, 20); err != storage.ErrVersionNotFound {
+		t.Errorf("GetVersion(20) got error %v, want %v", err, storage.ErrVersionNotFound)
+	}
+
+	// Versions of deleted records expire.
+	if err := s.Del(key); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if _, err := s.ListVersions(key); err != storage.ErrRecordNotFound {
+		t.Errorf("ListVersions() after VersionsTTL got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if _, err := s.GetVersion(key, 102); err != storage.ErrVersionNotFound {
+		t.Errorf("GetVersion() after VersionsTTL got error %v, want %v", err, storage.ErrVersionNotFound)
+	}
+	if err := s.Upsert(2, []byte("x")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	if err := s.Del(2); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	s.lock.RLock()
+	_, kept := s.history[key]
+	s.lock.RUnlock()
+	if kept {
+		t.Errorf("Expired versions are not dropped")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := New(cfg)
+	if err := s.Put(1, []byte("a")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	if err := s.Snapshot(1, storage.SnapshotFreeze, time.Minute); err != nil {
+		t.Fatalf("Snapshot(freeze) error: %v", err)
+	}
+	if err := s.Snapshot(2, storage.SnapshotFreeze, time.Minute); err != storage.ErrSnapshotActive {
+		t.Errorf("Snapshot(freeze) got error %v, want %v", err, storage.ErrSnapshotActive)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Put(2, []byte("b"))
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Put() is not blocked by freeze")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if err := s.Snapshot(1, storage.SnapshotTake, 0); err != nil {
+		t.Fatalf("Snapshot(take) error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	records, _, err := s.ScanSnapshot(1, nil, 0)
+	if err != nil {
+		t.Fatalf("ScanSnapshot() error: %v", err)
+	}
+	if want := []storage.Record{{Key: 1, Data: []byte("a")}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("ScanSnapshot() got %v, want %v", records, want)
+	}
+
+	s.Snapshot(1, storage.SnapshotDrop, 0)
+	if _, _, err := s.ScanSnapshot(1, nil, 0); err != storage.ErrSnapshotNotFound {
+		t.Errorf("ScanSnapshot() got error %v, want %v", err, storage.ErrSnapshotNotFound)
+	}
+}
+
+func TestSnapshot_FreezeTimeout(t *testing.T) {
+	s := New(cfg)
+	if err := s.Snapshot(1, storage.SnapshotFreeze, 10*time.Millisecond); err != nil {
+		t.Fatalf("Snapshot(freeze) error: %v", err)
+	}
+	start := time.Now()
+	if err := s.Put(1, []byte("a")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Put() blocked for %v after freeze expired", d)
+	}
+}
+
+func TestScanChanges(t *testing.T) {
+	s := New(cfg)
+	for _, k := range []storage.RecordID{1, 2, 3} {
+		if err := s.Put(k, []byte("a")); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	s.Snapshot(1, storage.SnapshotTake, 0)
+	s.Del(2)
+	s.Del(3)
+	s.Put(3, []byte("b"))
+	s.Put(4, []byte("c"))
+	s.Snapshot(2, storage.SnapshotTake, 0)
+	s.Put(5, []byte("d"))
+
+	records, next, err := s.ScanChanges(2, 1, nil, 0)
+	if err != nil {
+		t.Fatalf("ScanChanges() error: %v", err)
+	}
+	want := []storage.Record{
+		{Key: 2, Deleted: true},
+		{Key: 3, Data: []byte("b")},
+		{Key: 4, Data: []byte("c")},
+	}
+	if !reflect.DeepEqual(records, want) || next != nil {
+		t.Errorf("ScanChanges() got %v, %v, want %v", records, next, want)
+	}
+
+	s.Snapshot(1, storage.SnapshotDrop, 0)
+	if _, _, err := s.ScanChanges(2, 1, nil, 0); err != storage.ErrSnapshotNotFound {
+		t.Errorf("ScanChanges() got error %v, want %v", err, storage.ErrSnapshotNotFound)
+	}
+}
+
+// OrphansClient reports odd keys as orphans.
+type OrphansClient struct {
+	FakeClient
+}
+
+func (c *OrphansClient) Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	var orphans []storage.RecordID
+	for _, k := range keys {
+		if k%2 == 1 {
+			orphans = append(orphans, k)
+		}
+	}
+	return orphans, nil
+}
+
+func (c *OrphansClient) NodesFind(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
+	return []storage.ServiceAddr{"node2", "node3"}, nil
+}
+
+// ownersClient answers checksums of the values the owners of records store,
+// the record with key 9 is missing on them.
+type ownersClient struct {
+	storage.Client
+}
+
+func (c ownersClient) Checksum(node storage.ServiceAddr, k storage.RecordID, h storage.Hash) (storage.Checksum, error) {
+	if k == 9 {
+		return storage.Checksum{}, storage.ErrRecordNotFound
+	}
+	return h.Sum([]byte{byte(k)}), nil
+}
+
+func TestGC(t *testing.T) {
+	var archive bytes.Buffer
+	c := cfg
+	c.Client = &OrphansClient{}
+	c.Peers = ownersClient{}
+	c.Router = "router"
+	c.GCArchive = &archive
+	s := New(c)
+	for k := storage.RecordID(0); k < 10; k++ {
+		if err := s.Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	res, err := s.GC(true)
+	if err != nil {
+		t.Fatalf("GC() error: %v", err)
+	}
+	if want := (GCResult{Scanned: 10, Orphans: 5, Unconfirmed: 1}); res != want {
+		t.Errorf("GC(dry run) got %+v, want %+v", res, want)
+	}
+	if stats, _ := s.Stats(); stats.Records != 10 {
+		t.Errorf("GC(dry run) deleted records: %v left", stats.Records)
+	}
+
+	res, err = s.GC(false)
+	if err != nil {
+		t.Fatalf("GC() error: %v", err)
+	}
+	if want := (GCResult{Scanned: 10, Orphans: 5, Deleted: 4, Unconfirmed: 1}); res != want {
+		t.Errorf("GC() got %+v, want %+v", res, want)
+	}
+	for k := storage.RecordID(0); k < 10; k++ {
+		_, err := s.Get(k)
+		kept := k%2 == 0 || k == 9
+		if kept && err != nil || !kept && err != storage.ErrRecordNotFound {
+			t.Errorf("Get(%v) after GC got error %v", k, err)
+		}
+	}
+	if n := strings.Count(archive.String(), "\n"); n != 4 {
+		t.Errorf("Got %d archived records, want 4", n)
+	}
+}
+
+// peersClient scans other nodes in the process.
+// gset encodes a crdt.GSet of elems.
+func gset(t *testing.T, elems ...string) []byte {
+	s := crdt.NewGSet()
+	for _, elem := range elems {
+		s.Add(elem)
+	}
+	d, err := crdt.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	return d
+}
+
+func TestMerge(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	var wg sync.WaitGroup
+	for _, elem := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Merge(key, gset(t, elem)); err != nil {
+				t.Errorf("Merge() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got, err := s.Get(key); err != nil || !bytes.Equal(got, gset(t, "a", "b", "c", "d")) {
+		t.Errorf("Get() after Merge() got %q, %v, want all elements", got, err)
+	}
+
+	if err := s.Merge(key, []byte("garbage")); err != storage.ErrInvalidValue {
+		t.Errorf("Merge() of garbage got error %v, want %v", err, storage.ErrInvalidValue)
+	}
+	d, _ := crdt.Marshal(crdt.NewPNCounter())
+	if err := s.Merge(key, d); err != storage.ErrInvalidValue {
+		t.Errorf("Merge() of another type got error %v, want %v", err, storage.ErrInvalidValue)
+	}
+	if err := s.Upsert(key, []byte("plain")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	if err := s.Merge(key, gset(t, "a")); err != storage.ErrInvalidValue {
+		t.Errorf("Merge() into a plain value got error %v, want %v", err, storage.ErrInvalidValue)
+	}
+}
+
+type peersClient struct {
+	storage.Client
+	nodes map[storage.ServiceAddr]*Node
+}
+
+func (c *peersClient) Scan(node storage.ServiceAddr, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	n, ok := c.nodes[node]
+	if !ok {
+		return nil, nil, errors.New("unreachable")
+	}
+	return n.Scan(cursor, limit)
+}
+
+func (c *peersClient) Delta(node storage.ServiceAddr, since uint64, limit int) (storage.Delta, error) {
+	n, ok := c.nodes[node]
+	if !ok {
+		return storage.Delta{}, errors.New("unreachable")
+	}
+	return n.Delta(since, limit)
+}
+
+// listClient lists nodes with sequence numbers of the ones in peers
+// and reports odd keys as orphans.
+type listClient struct {
+	OrphansClient
+	nodes []storage.ServiceAddr
+	peers *peersClient
+}
+
+func (c *listClient) Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	beats := make([]storage.Heartbeat, 0, len(c.nodes))
+	for _, addr := range c.nodes {
+		hb := storage.Heartbeat{Node: addr}
+		if n, ok := c.peers.nodes[addr]; ok {
+			hb.Seq = n.Seq()
+		}
+		beats = append(beats, hb)
+	}
+	return beats, nil
+}
+
+func TestWarmUp(t *testing.T) {
+	peers := &peersClient{nodes: make(map[storage.ServiceAddr]*Node)}
+	c := cfg
+	c.Router = "router"
+	c.Client = &listClient{nodes: []storage.ServiceAddr{"node1", "node2", "node3", "node4"}, peers: peers}
+	c.Peers = peers
+	c.WarmUp = true
+	for _, addr := range []storage.ServiceAddr{"node1", "node2"} {
+		c.Addr = addr
+		peers.nodes[addr] = New(c)
+	}
+	for k := storage.RecordID(0); k < 6; k++ {
+		if err := peers.nodes["node2"].Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	c.Addr = "node3"
+	s := New(c)
+	if err := s.Put(2, []byte{2}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	// node4 is unreachable and skipped, odd keys are not owned by node3.
+	res, err := s.WarmUp()
+	if err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+	if want := (WarmResult{Peers: 2, Missing: 5, Copied: 2}); res != want {
+		t.Errorf("WarmUp() got %+v, want %+v", res, want)
+	}
+	for k := storage.RecordID(0); k < 6; k++ {
+		d, err := s.Get(k)
+		if k%2 == 0 && (err != nil || !bytes.Equal(d, []byte{byte(k)})) || k%2 == 1 && err != storage.ErrRecordNotFound {
+			t.Errorf("Get(%v) after WarmUp() got %v, %v", k, d, err)
+		}
+	}
+
+	// Nothing changed on peers, so they are not scanned again.
+	res, err = s.WarmUp()
+	if err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+	if want := (WarmResult{Peers: 2}); res != want {
+		t.Errorf("Repeated WarmUp() got %+v, want %+v", res, want)
+	}
+
+	// Changes on node2 are caught up with by delta, deletions and values
+	// put again included.
+	for _, k := range []storage.RecordID{6, 7} {
+		if err := peers.nodes["node2"].Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	for _, k := range []storage.RecordID{0, 2, 4} {
+		if err := peers.nodes["node2"].Del(k); err != nil {
+			t.Fatalf("Del() error: %v", err)
+		}
+	}
+	if err := peers.nodes["node2"].Put(2, []byte{20}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	res, err = s.WarmUp()
+	if err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+	if want := (WarmResult{Peers: 2, Deltas: 1, Missing: 2, Copied: 1, Deleted: 2, Replaced: 1}); res != want {
+		t.Errorf("WarmUp() by delta got %+v, want %+v", res, want)
+	}
+	for k, want := range map[storage.RecordID]error{0: storage.ErrRecordNotFound, 2: nil, 4: storage.ErrRecordNotFound, 6: nil, 7: storage.ErrRecordNotFound} {
+		if _, err := s.Get(k); err != want {
+			t.Errorf("Get(%v) after WarmUp() by delta got error %v, want %v", k, err, want)
+		}
+	}
+	if d, err := s.Get(2); err != nil || !bytes.Equal(d, []byte{20}) {
+		t.Errorf("Get(2) after WarmUp() by delta got %v, %v, want the value put again", d, err)
+	}
+}
+
+// changingPeersClient calls change after fetching every delta.
+type changingPeersClient struct {
+	*peersClient
+	change func()
+}
+
+func (c *changingPeersClient) Delta(node storage.ServiceAddr, since uint64, limit int) (storage.Delta, error) {
+	delta, err := c.peersClient.Delta(node, since, limit)
+	if c.change != nil {
+		c.change()
+	}
+	return delta, err
+}
+
+func TestWarmUp_LocalChanges(t *testing.T) {
+	peers := &peersClient{nodes: make(map[storage.ServiceAddr]*Node)}
+	changing := &changingPeersClient{peersClient: peers}
+	c := cfg
+	c.Router = "router"
+	c.Client = &listClient{nodes: []storage.ServiceAddr{"node2"}, peers: peers}
+	c.Peers = changing
+	c.WarmUp = true
+	c.Addr = "node2"
+	peer := New(c)
+	peers.nodes["node2"] = peer
+	for _, k := range []storage.RecordID{0, 2} {
+		if err := peer.Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	c.Addr = "node3"
+	s := New(c)
+	if _, err := s.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+
+	// Clients of node3 change the records after the delta of node2 is
+	// fetched, their values are newer and are kept.
+	peer.Del(0)
+	peer.Del(2)
+	peer.Put(2, []byte{20})
+	peer.Put(4, []byte{4})
+	changing.change = func() {
+		changing.change = nil
+		s.Upsert(0, []byte{100})
+		s.Upsert(2, []byte{200})
+		s.Put(4, []byte{40})
+		s.Del(4)
+	}
+	if _, err := s.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+	for k, want := range map[storage.RecordID][]byte{0: {100}, 2: {200}, 4: nil} {
+		if d, err := s.Get(k); !bytes.Equal(d, want) {
+			t.Errorf("Get(%v) after WarmUp() got %v, %v, want %v", k, d, err, want)
+		}
+	}
+}
+
+func TestWarmUp_MergeCRDT(t *testing.T) {
+	peers := &peersClient{nodes: make(map[storage.ServiceAddr]*Node)}
+	c := cfg
+	c.Router = "router"
+	c.Client = &listClient{nodes: []storage.ServiceAddr{"node2"}, peers: peers}
+	c.Peers = peers
+	c.WarmUp = true
+	c.MergeCRDT = true
+	c.Addr = "node2"
+	peer := New(c)
+	peers.nodes["node2"] = peer
+	c.Addr = "node3"
+	s := New(c)
+	if _, err := s.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+
+	// The replicas diverged, e.g. while node3 was partitioned, so their
+	// values are merged rather than replaced.
+	if err := s.Merge(0, gset(t, "a")); err != nil {
+		t.Fatalf("Merge() error: %v", err)
+	}
+	if err := peer.Merge(0, gset(t, "b")); err != nil {
+		t.Fatalf("Merge() error: %v", err)
+	}
+	if err := s.Put(2, []byte{2}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := peer.Put(2, []byte{20}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	res, err := s.WarmUp()
+	if err != nil {
+		t.Fatalf("WarmUp() error: %v", err)
+	}
+	if want := (WarmResult{Peers: 1, Deltas: 1, Replaced: 2}); res != want {
+		t.Errorf("WarmUp() got %+v, want %+v", res, want)
+	}
+	if d, err := s.Get(0); err != nil || !bytes.Equal(d, gset(t, "a", "b")) {
+		t.Errorf("Get(0) after WarmUp() got %q, %v, want merged sets", d, err)
+	}
+	if d, err := s.Get(2); err != nil || !bytes.Equal(d, []byte{20}) {
+		t.Errorf("Get(2) after WarmUp() got %v, %v, want the value of the peer", d, err)
+	}
+}
+
+func TestDelContext_Version(t *testing.T) {
+	n := New(Config{Addr: "node"})
+	first := storage.WithVersion(context.Background(), 1)
+	second := storage.WithVersion(context.Background(), 2)
+
+	if err := n.PutContext(first, 1, []byte("first")); err != nil {
+		t.Fatalf("PutContext() error: %v", err)
+	}
+	if err := n.DelContext(second, 1); err != storage.ErrConditionFailed {
+		t.Errorf("DelContext() of another version got error %v, want %v", err, storage.ErrConditionFailed)
+	}
+	if err := n.DelContext(first, 1); err != nil {
+		t.Errorf("DelContext() of the version got error %v", err)
+	}
+
+	// Values written without a version are only deleted without one.
+	if errs := n.MPut([]storage.RecordID{1, 2}, [][]byte{[]byte("one"), []byte("two")}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("MPut() errors: %v", errs)
+	}
+	if errs := n.MDelContext(first, []storage.RecordID{1, 3}); errs[0] != storage.ErrConditionFailed || errs[1] != storage.ErrRecordNotFound {
+		t.Errorf("MDelContext() got errors %v, want [%v %v]", errs, storage.ErrConditionFailed, storage.ErrRecordNotFound)
+	}
+	if errs := n.MPutContext(second, []storage.RecordID{3}, [][]byte{[]byte("three")}); errs[0] != nil {
+		t.Fatalf("MPutContext() error: %v", errs[0])
+	}
+	if errs := n.MDelContext(second, []storage.RecordID{3}); errs[0] != nil {
+		t.Errorf("MDelContext() got error %v", errs[0])
+	}
+	if err := n.Del(1); err != nil {
+		t.Errorf("Del() got error %v", err)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	c := cfg
+	c.DeltaLog = 4
+	s := New(c)
+	start := s.Seq()
+	for k := storage.RecordID(1); k <= 3; k++ {
+		if err := s.Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if err := s.Del(2); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if got := s.Seq(); got != start+4 {
+		t.Fatalf("Seq() got %v, want %v", got, start+4)
+	}
+
+	for _, test := range []struct {
+		since uint64
+		limit int
+		want  storage.Delta
+	}{
+		{since: start, want: storage.Delta{
+			Records: []storage.Record{{Key: 1, Data: []byte{1}}, {Key: 3, Data: []byte{3}}, {Key: 2, Deleted: true}},
+			Seq:     start + 4,
+		}},
+		{since: start, limit: 1, want: storage.Delta{
+			Records: []storage.Record{{Key: 1, Data: []byte{1}}},
+			Seq:     start + 1,
+			More:    true,
+		}},
+		{since: start + 1, limit: 1, want: storage.Delta{
+			Records: []storage.Record{{Key: 2, Deleted: true}},
+			Seq:     start + 2,
+			More:    true,
+		}},
+		{since: start + 4, want: storage.Delta{Records: []storage.Record{}, Seq: start + 4}},
+	} {
+		got, err := s.Delta(test.since, test.limit)
+		if err != nil {
+			t.Errorf("Delta(%v, %v) error: %v", test.since, test.limit, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Delta(%v, %v) got %+v, want %+v", test.since, test.limit, got, test.want)
+		}
+	}
+	if _, err := s.Delta(start+5, 0); err != storage.ErrSeqOutOfRange {
+		t.Errorf("Delta() ahead of the node got error %v, want %v", err, storage.ErrSeqOutOfRange)
+	}
+
+	// The oldest operations are dropped over DeltaLog.
+	for k := storage.RecordID(4); k <= 7; k++ {
+		if err := s.Put(k, []byte{byte(k)}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if _, err := s.Delta(start, 0); err != storage.ErrSeqOutOfRange {
+		t.Errorf("Delta() of dropped operations got error %v, want %v", err, storage.ErrSeqOutOfRange)
+	}
+	if got, err := s.Delta(start+4, 0); err != nil || len(got.Records) != 4 || got.More {
+		t.Errorf("Delta() of kept operations got %+v, %v, want 4 records", got, err)
+	}
+}
+
+func TestDegraded(t *testing.T) {
+	c := cfg
+	c.QuarantineErrors = 2
+	c.QuarantineWindow = 50 * time.Millisecond
+	s := New(c)
+
+	s.reportError(errors.New("I/O error"))
+	if s.Degraded() {
+		t.Errorf("Degraded() after one error, want healthy")
+	}
+	s.reportError(errors.New("I/O error"))
+	if !s.Degraded() {
+		t.Errorf("Healthy after %d errors, want degraded", c.QuarantineErrors)
+	}
+	time.Sleep(c.QuarantineWindow)
+	if s.Degraded() {
+		t.Errorf("Degraded() after errors left the window, want healthy")
+	}
+}
+
+func TestDegraded_ChecksumMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.QuarantineErrors = 1
+	s := New(c)
+	defer s.Close()
+	if err := s.Put(1, []byte("abcdef")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	// Flip a byte of the value on disk.
+	if err := ioutil.WriteFile(filepath.Join(dir, SegmentFile(0)), []byte("abcdeF"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if _, err := s.Get(1); !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Errorf("Get() of a corrupted value got error %v, want %v", err, storage.ErrChecksumMismatch)
+	}
+	if !s.Degraded() {
+		t.Errorf("Healthy after a checksum mismatch, want degraded")
+	}
+}
+
+func TestLargeValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	s := New(c)
+
+	values := map[storage.RecordID]string{1: "abc", 2: "abcdef", 3: "abcdefgh"}
+	for k, d := range values {
+		if err := s.Put(k, []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	for k, want := range values {
+		if d, err := s.Get(k); err != nil || string(d) != want {
+			t.Errorf("Get(%v) got %q, %v, want %q", k, d, err, want)
+		}
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, SegmentFile(0)))
+	if err != nil {
+		t.Fatalf("Stat() error: %v", err)
+	}
+	if want := int64(len(values[2]) + len(values[3])); fi.Size() != want {
+		t.Errorf("Disk log size got %v, want %v", fi.Size(), want)
+	}
+	if stats, _ := s.Stats(); stats.Bytes != 17 {
+		t.Errorf("Stats() got %v bytes, want 17", stats.Bytes)
+	}
+
+	records, _, err := s.Scan(nil, 0)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	for _, r := range records {
+		if string(r.Data) != values[r.Key] {
+			t.Errorf("Scan() got %q for key %v, want %q", r.Data, r.Key, values[r.Key])
+		}
+	}
+}
+
+func TestDataDirLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	s := New(c)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+
+	if err := New(c).Open(); !errors.Is(err, ErrDataDirLocked) {
+		t.Errorf("Open() of a locked directory got error %v, want %v", err, ErrDataDirLocked)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	other := New(c)
+	if err := other.Open(); err != nil {
+		t.Errorf("Open() after Close() error: %v", err)
+	}
+	other.Close()
+}
+
+func TestDataFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, ValuesFile), []byte("legacy"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	open := func(format int) (int, error) {
+		c := cfg
+		c.DataDir = dir
+		c.DataFormat = format
+		s := New(c)
+		defer s.Close()
+		err := s.Open()
+		v, _ := readFormat(dir)
+		return v, err
+	}
+
+	if v, err := open(0); err != nil || v != DataFormat {
+		t.Errorf("Open() got format %v, %v, want %v", v, err, DataFormat)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, BackupPrefix+"1-*", ValuesFile))
+	if len(backups) != 1 {
+		t.Fatalf("Open() made backups %v, want one with %v", backups, ValuesFile)
+	}
+	if b, _ := ioutil.ReadFile(backups[0]); string(b) != "legacy" {
+		t.Errorf("Backup of %v got %q, want %q", ValuesFile, b, "legacy")
+	}
+
+	if v, err := open(1); !errors.Is(err, ErrDowngraded) || v != 1 {
+		t.Errorf("Open() of format 1 got format %v, %v, want 1, %v", v, err, ErrDowngraded)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ValuesFile)); err != nil {
+		t.Errorf("Open() of format 1 got no %v: %v", ValuesFile, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FormatFile)); !os.IsNotExist(err) {
+		t.Errorf("Open() of format 1 left %v, got error %v", FormatFile, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, FormatFile), []byte("9\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if _, err := open(0); err == nil {
+		t.Errorf("Open() of unsupported format got no error")
+	}
+}
+
+func TestDataFormat_Fresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	s := New(c)
+	defer s.Close()
+	if err := s.Open(); err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if v, err := readFormat(dir); err != nil || v != DataFormat {
+		t.Errorf("Open() of an empty directory got format %v, %v, want %v", v, err, DataFormat)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, BackupPrefix+"*")); len(backups) != 0 {
+		t.Errorf("Open() of an empty directory made backups %v", backups)
+	}
+}
+
+func TestScanDuringCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SegmentSize = 64
+	c.CompactGarbageRatio = 0.01
+	s := New(c)
+	defer s.Close()
+
+	const n = 50
+	for k := storage.RecordID(0); k < n; k++ {
+		if err := s.Put(k, []byte(fmt.Sprintf("value-%02d", k))); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for k := storage.RecordID(n); k < 2*n; k++ {
+			s.Put(k, []byte(fmt.Sprintf("value-%02d", k)))
+			s.Del(k - n)
+			if _, err := s.Compact(); err != nil {
+				t.Errorf("Compact() error: %v", err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		records, _, err := s.Scan(nil, n)
+		if err != nil {
+			t.Fatalf("Scan() during compaction error: %v", err)
+		}
+		for _, r := range records {
+			if want := fmt.Sprintf("value-%02d", r.Key); string(r.Data) != want {
+				t.Fatalf("Scan() during compaction got %q for %v, want %q", r.Data, r.Key, want)
+			}
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SegmentSize = 16
+	s := New(c)
+	defer s.Close()
+
+	for k := storage.RecordID(1); k <= 6; k++ {
+		if err := s.Put(k, []byte(fmt.Sprintf("value-%02d", k))); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	for k := storage.RecordID(1); k <= 3; k++ {
+		if err := s.Del(k); err != nil {
+			t.Fatalf("Del() error: %v", err)
+		}
+	}
+
+	res, err := s.Compact()
+	if err != nil {
+		t.Fatalf("Compact() error: %v", err)
+	}
+	if want := (CompactResult{Segments: 2, Copied: 8, Reclaimed: 24}); res != want {
+		t.Errorf("Compact() got %+v, want %+v", res, want)
+	}
+	for _, id := range []int{0, 1} {
+		if _, err := os.Stat(filepath.Join(dir, SegmentFile(id))); !os.IsNotExist(err) {
+			t.Errorf("Segment %d is not removed: %v", id, err)
+		}
+	}
+	for k := storage.RecordID(4); k <= 6; k++ {
+		if d, err := s.Get(k); err != nil || string(d) != fmt.Sprintf("value-%02d", k) {
+			t.Errorf("Get(%v) got %q, %v after compaction", k, d, err)
+		}
+	}
+
+	if res, err := s.Compact(); err != nil || res.Segments != 0 {
+		t.Errorf("Compact() without garbage got %+v, %v, want nothing compacted", res, err)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.Namespaces = storage.Namespaces{
+		{Prefix: storage.Prefix{Key: 0x10000000, Len: 4}, Class: storage.ClassMemory},
+		{Prefix: storage.Prefix{Key: 0x20000000, Len: 4}, Class: storage.ClassPersistent},
+	}
+	s := New(c)
+	defer s.Close()
+
+	values := map[storage.RecordID]string{
+		0x10000001: "large memory value",
+		0x20000001: "ab",
+		0x30000001: "large default value",
+		0x30000002: "ab",
+	}
+	for k, d := range values {
+		if err := s.Put(k, []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	for k, want := range values {
+		if d, err := s.Get(k); err != nil || string(d) != want {
+			t.Errorf("Get(%#x) got %q, %v, want %q", k, d, err, want)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dir, SegmentFile(0)))
+	if err != nil {
+		t.Fatalf("Stat() error: %v", err)
+	}
+	if want := int64(len(values[0x20000001]) + len(values[0x30000001])); fi.Size() != want {
+		t.Errorf("Disk log size got %v, want %v", fi.Size(), want)
+	}
+
+	c.DataDir = ""
+	if err := c.Validate(); err == nil {
+		t.Errorf("Validate() of persistent namespace without DataDir got no error")
+	}
+}
+
+func TestNamespaces_Restart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SegmentSize = 16
+	c.Namespaces = storage.Namespaces{
+		{Prefix: storage.Prefix{Key: 0x20000000, Len: 4}, Class: storage.ClassPersistent},
+	}
+	s := New(c)
+	for k, d := range map[storage.RecordID]string{
+		0x20000001: "durable-01",
+		0x20000002: "durable-02",
+		0x20000003: "durable-03",
+		0x30000001: "default-01",
+	} {
+		if err := s.Put(k, []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if err := s.Del(0x20000002); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if err := s.Upsert(0x20000003, []byte("durable-v2")); err != nil {
+		t.Fatalf("Upsert() error: %v", err)
+	}
+	// Relocated values are found by their new locations.
+	if _, err := s.Compact(); err != nil {
+		t.Fatalf("Compact() error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	s = New(c)
+	defer s.Close()
+	if err := s.Open(); err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for k, want := range map[storage.RecordID]string{0x20000001: "durable-01", 0x20000003: "durable-v2"} {
+		if d, err := s.Get(k); err != nil || string(d) != want {
+			t.Errorf("Get(%#x) after restart got %q, %v, want %q", k, d, err, want)
+		}
+	}
+	for _, k := range []storage.RecordID{0x20000002, 0x30000001} {
+		if d, err := s.Get(k); err != storage.ErrRecordNotFound {
+			t.Errorf("Get(%#x) after restart got %q, %v, want %v", k, d, err, storage.ErrRecordNotFound)
+		}
+	}
+	if stats, _ := s.Stats(); stats.Records != 2 {
+		t.Errorf("Stats() after restart got %d records, want 2", stats.Records)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SegmentSize = 16
+	s := New(c)
+	defer s.Close()
+
+	for k := storage.RecordID(1); k <= 6; k++ {
+		if err := s.Put(k, []byte(fmt.Sprintf("value-%02d", k))); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	var keys []storage.RecordID
+	err = s.ForEach(func(k storage.RecordID, v []byte) bool {
+		if want := fmt.Sprintf("value-%02d", k); string(v) != want {
+			t.Errorf("ForEach() got %v = %q, want %q", k, v, want)
+		}
+		if len(keys) == 0 {
+			// Writes and compaction during iteration don't affect it.
+			for k := storage.RecordID(1); k <= 6; k++ {
+				if err := s.Del(k); err != nil {
+					t.Fatalf("Del() error: %v", err)
+				}
+			}
+			if err := s.Put(7, []byte("value-07")); err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+			if _, err := s.Compact(); err != nil {
+				t.Fatalf("Compact() error: %v", err)
+			}
+		}
+		keys = append(keys, k)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ForEach() error: %v", err)
+	}
+	if want := []storage.RecordID{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ForEach() visited %v, want %v", keys, want)
+	}
+
+	keys = nil
+	if err := s.ForEach(func(k storage.RecordID, v []byte) bool {
+		keys = append(keys, k)
+		return false
+	}); err != nil || !reflect.DeepEqual(keys, []storage.RecordID{7}) {
+		t.Errorf("ForEach() stopped at once visited %v, %v, want [7]", keys, err)
+	}
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTier(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cold := tiering.NewMemory()
+	c := cfg
+	c.ColdStore = cold
+	c.Tiering.After = time.Hour
+	c.Tiering.MinSize = 4
+	s := New(c, WithClock(clock))
+
+	values := map[storage.RecordID]string{1: "abc", 2: "cold value", 3: "hot value"}
+	for k, d := range values {
+		if err := s.Put(k, []byte(d)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	clock.now = clock.now.Add(2 * time.Hour)
+	if _, err := s.Get(3); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	res, err := s.Tier()
+	if err != nil {
+		t.Fatalf("Tier() error: %v", err)
+	}
+	if want := (TierResult{Scanned: 3, Migrated: 1, Bytes: 10}); res != want {
+		t.Errorf("Tier() got %+v, want %+v", res, want)
+	}
+	if cold.Len() != 1 {
+		t.Errorf("Cold store has %v objects, want 1", cold.Len())
+	}
+
+	records, _, err := s.Scan(nil, 0)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	for _, r := range records {
+		if string(r.Data) != values[r.Key] {
+			t.Errorf("Scan() got %q for key %v, want %q", r.Data, r.Key, values[r.Key])
+		}
+	}
+
+	// Get fetches the value back and removes its object.
+	if d, err := s.Get(2); err != nil || string(d) != values[2] {
+		t.Errorf("Get() got %q, %v, want %q", d, err, values[2])
+	}
+	waitObjects(t, cold, 0)
+	if res, err := s.Tier(); err != nil || res.Migrated != 0 {
+		t.Errorf("Tier() of accessed records got %+v, %v, want none migrated", res, err)
+	}
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	if res, err := s.Tier(); err != nil || res.Migrated != 2 {
+		t.Errorf("Tier() got %+v, %v, want 2 migrated", res, err)
+	}
+	if err := s.Del(2); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	waitObjects(t, cold, 1)
+	if stats, _ := s.Stats(); stats.Records != 2 || stats.Bytes != 12 {
+		t.Errorf("Stats() got %+v, want 2 records of 12 bytes", stats)
+	}
+
+	if _, err := New(cfg).Tier(); err != ErrTieringDisabled {
+		t.Errorf("Tier() without cold store got error %v, want %v", err, ErrTieringDisabled)
+	}
+}
+
+// waitObjects waits until the objects of deleted records are removed
+// from cold in the background, so n are left.
+func waitObjects(t *testing.T, cold *tiering.Memory, n int) {
+	t.Helper()
+	for i := 0; cold.Len() != n; i++ {
+		if i == 100 {
+			t.Fatalf("Cold store has %v objects, want %v", cold.Len(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBlockCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.CacheSize = 4 * CacheBlockSize
+	c.ReadAhead = 1
+	s := New(c)
+	defer s.Close()
+
+	values := map[storage.RecordID][]byte{
+		1: bytes.Repeat([]byte("a"), 100),
+		2: bytes.Repeat([]byte("b"), CacheBlockSize+100),
+		3: bytes.Repeat([]byte("c"), 100),
+	}
+	for k := storage.RecordID(1); k <= 3; k++ {
+		if err := s.Put(k, values[k]); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		// Reading right after Put caches a partial block, which grows with the next Put.
+		for i := 0; i < 2; i++ {
+			if d, err := s.Get(k); err != nil || !bytes.Equal(d, values[k]) {
+				t.Fatalf("Get(%v) got %d bytes, %v, want %d bytes", k, len(d), err, len(values[k]))
+			}
+		}
+	}
+	hits, misses, size := s.disk.cache.stats()
+	if hits == 0 || misses == 0 || size > c.CacheSize {
+		t.Errorf("Cache got %d hits, %d misses, %d bytes, want both hits and misses within %d bytes", hits, misses, size, c.CacheSize)
+	}
+
+	cache := newBlockCache(2 * CacheBlockSize)
+	for n := int64(0); n < 3; n++ {
+		cache.put(blockKey{0, n}, make([]byte, CacheBlockSize))
+	}
+	if _, ok := cache.get(blockKey{0, 0}, 1); ok {
+		t.Errorf("Least recently used block is not evicted")
+	}
+	cache.purge(0)
+	if _, _, size := cache.stats(); size != 0 {
+		t.Errorf("Cache got %d bytes after purge, want 0", size)
+	}
+}
+
+// countingSink counts increments of counters.
+type countingSink struct {
+	lock     sync.Mutex
+	counters map[string]int64
+}
+
+func (s *countingSink) IncrCounter(name string, delta int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counters[name] += delta
+}
+
+func (s *countingSink) counter(name string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.counters[name]
+}
+
+func (s *countingSink) SetGauge(name string, value float64)          {}
+func (s *countingSink) ObserveDuration(name string, d time.Duration) {}
+
+func TestSyncWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := &countingSink{counters: make(map[string]int64)}
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	c.SyncWrites = true
+	c.SyncDelay = 50 * time.Millisecond
+	c.Sink = sink
+	s := New(c)
+	defer s.Close()
+
+	const writers = 16
+	var wg sync.WaitGroup
+	for k := storage.RecordID(0); k < writers; k++ {
+		wg.Add(1)
+		go func(k storage.RecordID) {
+			defer wg.Done()
+			if err := s.Put(k, []byte("large value")); err != nil {
+				t.Errorf("Put() error: %v", err)
+			}
+		}(k)
+	}
+	wg.Wait()
+
+	if fsyncs := sink.counter("node.disk.fsyncs"); fsyncs < 1 || fsyncs >= writers {
+		t.Errorf("%d concurrent writes made %d fsyncs, want them grouped", writers, fsyncs)
+	}
+	for k := storage.RecordID(0); k < writers; k++ {
+		if d, err := s.Get(k); err != nil || string(d) != "large value" {
+			t.Errorf("Get(%v) got %q, %v", k, d, err)
+		}
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := cfg
+	c.DataDir = dir
+	c.LargeValueThreshold = 4
+	s := New(c)
+	defer s.Close()
+
+	errs := s.ApplyBatch([]storage.Op{
+		{Key: 1, Data: []byte("abc")},
+		{Key: 2, Data: []byte("abcdef")},
+		{Key: 1, Data: []byte("abcd")},
+		{Key: 3, Del: true},
+		{Key: 1, Del: true},
+		{Key: 1, Data: []byte("xyz")},
+	})
+	want := []error{nil, nil, storage.ErrRecordExists, storage.ErrRecordNotFound, nil, nil}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("ApplyBatch() got %v, want %v", errs, want)
+	}
+	for k, want := range map[storage.RecordID]string{1: "xyz", 2: "abcdef"} {
+		if d, err := s.Get(k); err != nil || string(d) != want {
+			t.Errorf("Get(%v) got %q, %v, want %q", k, d, err, want)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dir, SegmentFile(0)))
+	if err != nil {
+		t.Fatalf("Stat() error: %v", err)
+	}
+	if fi.Size() != 10 {
+		t.Errorf("Disk log size got %v, want 10 bytes of large values", fi.Size())
+	}
+}
+
+func TestGet_Allocs(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	if err := s.Put(key, []byte("some data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		s.Get(key)
+	})
+	if allocs != 0 {
+		t.Errorf("Get() allocations: got %v, want 0", allocs)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	s := New(cfg)
+	for i := 0; i < 1000; i++ {
+		s.Put(storage.RecordID(i), []byte("some data"))
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Get(storage.RecordID(i % 1000))
+	}
+}
+
+// BatchClient records batched heartbeats.
+type BatchClient struct {
+	FakeClient
+	beats [][]storage.Heartbeat
+}
+
+func (c *BatchClient) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.beats = append(c.beats, beats)
+	return make([]error, len(beats)), nil
+}
+
+func TestAgent(t *testing.T) {
+	c := &BatchClient{}
+	a := NewAgent("router", c, 50*time.Millisecond)
+
+	n1 := New(Config{Addr: "node1"})
+	n2 := New(Config{Addr: "node2", QuarantineErrors: 1})
+	n2.reportError(errors.New("disk failure"))
+	a.Add(n1)
+	a.Add(n2)
+
+	a.Heartbeats()
+	time.Sleep(200 * time.Millisecond)
+	a.Stop()
+
+	c.Lock()
+	defer c.Unlock()
+	if len(c.beats) < 2 {
+		t.Fatalf("Got %v batches of heartbeats, want at least 2", len(c.beats))
+	}
+	want := []storage.Heartbeat{
+		{Node: "node1", Seq: n1.Seq()},
+		{Node: "node2", Degraded: true, Seq: n2.Seq()},
+	}
+	for _, beats := range c.beats {
+		if !reflect.DeepEqual(beats, want) {
+			t.Errorf("HeartbeatBatch() got %v, want %v", beats, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Config{
+		Client:    &FakeClient{},
+		Versions:  -1,
+		RouterUDP: "127.0.0.1:1235",
+	}.Validate()
+	want := storage.ConfigError{
+		"Router should be set with Client",
+		"Heartbeat should be positive, got 0s",
+		"Versions should not be negative, got -1",
+		"UDPKey should be set with RouterUDP",
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Validate() got %v, want %v", err, want)
+	}
+}
+
+func FuzzReadFormat(f *testing.F) {
+	for _, s := range []string{"3\n", "1", " 2 \n", "", "-1", "9999999999999999999999", "x"} {
+		f.Add([]byte(s))
+	}
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		f.Fatalf("TempDir() error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		if err := ioutil.WriteFile(filepath.Join(dir, FormatFile), b, 0644); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		v, err := readFormat(dir)
+		if err != nil || v == 1 {
+			// Version 1 is written as a missing FormatFile.
+			return
+		}
+		if err := writeFormat(dir, v); err != nil {
+			t.Fatalf("writeFormat(%v) error: %v", v, err)
+		}
+		if got, err := readFormat(dir); err != nil || got != v {
+			t.Fatalf("readFormat() after writeFormat(%v) got %v, %v", v, got, err)
+		}
+	})
+}
+
+func TestPaxos(t *testing.T) {
+	c := cfg
+	c.PaxosRecovery = -1
+	s := New(c)
+	key := storage.RecordID(1)
+
+	if _, err := s.Paxos(key, storage.PaxosPrepare, storage.Proposal{Ballot: 2}); err != nil {
+		t.Fatalf("Paxos(PaxosPrepare) error: %v", err)
+	}
+	for _, phase := range []storage.PaxosPhase{storage.PaxosPrepare, storage.PaxosAccept} {
+		promise, err := s.Paxos(key, phase, storage.Proposal{Ballot: 1})
+		if err != storage.ErrBallotRejected || promise.Promised != 2 {
+			t.Errorf("Paxos(%v) of a lower ballot got %+v, %v, want promised 2, %v", phase, promise, err, storage.ErrBallotRejected)
+		}
+	}
+
+	p := storage.Proposal{Ballot: 2, Data: []byte("value")}
+	promise, err := s.Paxos(key, storage.PaxosAccept, p)
+	if err != nil || promise.Accepted.Ballot != 2 || promise.Found {
+		t.Fatalf("Paxos(PaxosAccept) got %+v, %v, want accepted ballot 2 and no value", promise, err)
+	}
+	promise, err = s.Paxos(key, storage.PaxosCommit, p)
+	if err != nil || promise.Committed != 2 || promise.Accepted.Ballot != 0 || !promise.Found || string(promise.Value) != "value" {
+		t.Fatalf("Paxos(PaxosCommit) got %+v, %v, want committed value", promise, err)
+	}
+	if d, err := s.Get(key); err != nil || string(d) != "value" {
+		t.Errorf("Get() after commit got %q, %v, want %q", d, err, "value")
+	}
+
+	p = storage.Proposal{Ballot: 3, Data: []byte("other")}
+	if _, err := s.Paxos(key, storage.PaxosCommit, p); err != nil {
+		t.Fatalf("Paxos(PaxosCommit) error: %v", err)
+	}
+	if d, err := s.Get(key); err != nil || string(d) != "other" {
+		t.Errorf("Get() after replacing commit got %q, %v, want %q", d, err, "other")
+	}
+	if _, err := s.Paxos(key, storage.PaxosCommit, storage.Proposal{Ballot: 4, Deleted: true}); err != nil {
+		t.Fatalf("Paxos(PaxosCommit) error: %v", err)
+	}
+	if _, err := s.Get(key); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after deleting commit got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	// The state of the deleted record is dropped, but its ballots can't
+	// be reused.
+	if len(s.paxos) != 0 {
+		t.Errorf("Paxos() kept %v states after a deleting commit, want 0", len(s.paxos))
+	}
+	if promise, err := s.Paxos(key, storage.PaxosPrepare, storage.Proposal{Ballot: 3}); err != storage.ErrBallotRejected || promise.Promised != 4 {
+		t.Errorf("Paxos(PaxosPrepare) of a committed ballot got %+v, %v, want promised 4, %v", promise, err, storage.ErrBallotRejected)
+	}
+	if _, err := s.Paxos(key, storage.PaxosCommit, storage.Proposal{Ballot: 4, Data: []byte("stale")}); err != nil {
+		t.Fatalf("Paxos(PaxosCommit) error: %v", err)
+	}
+	if _, err := s.Get(key); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after a stale commit got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+}
+
+func TestPaxos_Recovery(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	c := cfg
+	c.Clock = clock
+	c.PaxosRecovery = time.Minute
+	s := New(c)
+	key := storage.RecordID(1)
+
+	for _, phase := range []storage.PaxosPhase{storage.PaxosPrepare, storage.PaxosAccept} {
+		if _, err := s.Paxos(key, phase, storage.Proposal{Ballot: 1}); err != storage.ErrBallotRejected {
+			t.Errorf("Paxos(%v) after start got error %v, want %v", phase, err, storage.ErrBallotRejected)
+		}
+	}
+	// Rounds finished on other replicas are still committed.
+	if _, err := s.Paxos(key, storage.PaxosCommit, storage.Proposal{Ballot: 1, Data: []byte("value")}); err != nil {
+		t.Errorf("Paxos(PaxosCommit) after start got error %v", err)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if _, err := s.Paxos(key, storage.PaxosPrepare, storage.Proposal{Ballot: 2}); err != nil {
+		t.Errorf("Paxos(PaxosPrepare) after recovery got error %v", err)
+	}
+}
+
+func TestReadLease(t *testing.T) {
+	s := New(cfg)
+	key := storage.RecordID(1)
+	if err := s.Put(key, []byte("old")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	lease, err := s.AcquireReadLease(key, 1, time.Minute)
+	if err != nil || !lease.Found || string(lease.Value) != "old" {
+		t.Fatalf("AcquireReadLease() got %+v, %v, want %q", lease, err, "old")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Del(key)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Del() of a leased record finished with %v before the lease", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := s.AcquireReadLease(key, 2, time.Minute); err != storage.ErrLocked {
+		t.Errorf("AcquireReadLease() while a write waits got error %v, want %v", err, storage.ErrLocked)
+	}
+	if d, err := s.Get(key); err != nil || string(d) != "old" {
+		t.Errorf("Get() under a lease got %q, %v, want %q", d, err, "old")
+	}
+
+	if _, err := s.AcquireReadLease(key, 1, 0); err != nil {
+		t.Fatalf("AcquireReadLease() releasing the lease error: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Del() error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Del() didn't finish after the lease was released")
+	}
+
+	lease, err = s.AcquireReadLease(key, 2, 10*time.Millisecond)
+	if err != nil || lease.Found {
+		t.Fatalf("AcquireReadLease() of a deleted record got %+v, %v, want not found", lease, err)
+	}
+	if err := s.Put(key, []byte("new")); err != nil {
+		t.Errorf("Put() after the lease expired error: %v", err)
+	}
+}
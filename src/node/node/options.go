@@ -0,0 +1,70 @@
+package node
+
+import (
+	"log"
+
+	"events"
+	"metrics"
+	router "router/client"
+	"storage"
+)
+
+// Option configures a Node created by New.
+//
+// Option настраивает Node, создаваемую New.
+type Option func(cfg *Config)
+
+// WithLogger makes the node log with l.
+//
+// WithLogger -- node пишет журнал в l.
+func WithLogger(l *log.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = l
+	}
+}
+
+// WithMetrics makes the node report metrics to sink.
+//
+// WithMetrics -- node отправляет метрики в sink.
+func WithMetrics(sink metrics.Sink) Option {
+	return func(cfg *Config) {
+		cfg.Sink = sink
+	}
+}
+
+// WithClock makes the node tell time with c.
+//
+// WithClock -- node определяет время по часам c.
+func WithClock(c storage.Clock) Option {
+	return func(cfg *Config) {
+		cfg.Clock = c
+	}
+}
+
+// WithClient makes the node talk to Router at addr with c.
+//
+// WithClient -- node обращается к Router по адресу addr с помощью c.
+func WithClient(addr storage.ServiceAddr, c router.Client) Option {
+	return func(cfg *Config) {
+		cfg.Router = addr
+		cfg.Client = c
+	}
+}
+
+// WithHooks adds hooks called on changes of records.
+//
+// WithHooks добавляет hooks, вызываемые при изменениях записей.
+func WithHooks(hooks ...Hooks) Option {
+	return func(cfg *Config) {
+		cfg.Hooks = append(cfg.Hooks, hooks...)
+	}
+}
+
+// WithEvents makes the node publish events to bus.
+//
+// WithEvents -- node публикует события в bus.
+func WithEvents(bus *events.Bus) Option {
+	return func(cfg *Config) {
+		cfg.Bus = bus
+	}
+}
@@ -0,0 +1,140 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"storage"
+)
+
+// PaxosRecovery is a default time a node refuses Paxos ballots for after
+// it starts. It is longer than rounds of frontends last with their retries.
+//
+// PaxosRecovery -- время по умолчанию, в течение которого node после
+// запуска отказывается от ballot Paxos. Оно больше времени раундов
+// frontend вместе с повторами.
+const PaxosRecovery = 10 * time.Second
+
+// paxosState is the state of the acceptor of a single key.
+type paxosState struct {
+	promised  uint64
+	accepted  storage.Proposal
+	committed uint64
+}
+
+// Paxos runs the given phase of a Paxos round for the record with key k
+// on the node as an acceptor and returns its promise with the current
+// value of the record. Returns the storage.ErrBallotRejected error if
+// the node promised a higher ballot than the one of p in PaxosPrepare
+// or PaxosAccept, or for cfg.PaxosRecovery after the node starts, as
+// the state of acceptors is kept in memory only. Committing a proposal
+// replaces the record with it, the state is dropped once a deletion is
+// committed.
+//
+// Paxos выполняет данную фазу раунда Paxos для записи с ключом k на node
+// в роли acceptor и возвращает ее обещание с текущим значением записи.
+// Возвращает ошибку storage.ErrBallotRejected, если в PaxosPrepare или
+// PaxosAccept node уже пообещала больший ballot, чем у p, или в течение
+// cfg.PaxosRecovery после запуска node, так как состояние acceptor
+// хранится только в памяти. Фиксация предложения заменяет им запись,
+// состояние удаляется после фиксации удаления.
+func (node *Node) Paxos(k storage.RecordID, phase storage.PaxosPhase, p storage.Proposal) (storage.Promise, error) {
+	if err := node.allow(1); err != nil {
+		return storage.Promise{}, err
+	}
+	node.paxosLock.Lock()
+	defer node.paxosLock.Unlock()
+
+	st := node.paxos[k]
+	if st == nil {
+		st = &paxosState{promised: node.paxosFloor, committed: node.paxosFloor}
+		node.paxos[k] = st
+	}
+	var err error
+	switch phase {
+	case storage.PaxosPrepare:
+		node.conf.Sink.IncrCounter("node.paxos.prepare", 1)
+		if p.Ballot <= st.promised || node.recovering() {
+			err = storage.ErrBallotRejected
+			break
+		}
+		st.promised = p.Ballot
+	case storage.PaxosAccept:
+		node.conf.Sink.IncrCounter("node.paxos.accept", 1)
+		if p.Ballot < st.promised || node.recovering() {
+			err = storage.ErrBallotRejected
+			break
+		}
+		st.promised, st.accepted = p.Ballot, p
+	case storage.PaxosCommit:
+		node.conf.Sink.IncrCounter("node.paxos.commit", 1)
+		if p.Ballot > st.committed {
+			if err = node.commit(k, p); err != nil {
+				break
+			}
+			st.committed = p.Ballot
+		}
+		if st.accepted.Ballot <= st.committed {
+			st.accepted = storage.Proposal{}
+			if p.Deleted && st.committed == p.Ballot {
+				node.dropPaxos(k, st)
+			}
+		}
+	default:
+		return storage.Promise{}, fmt.Errorf("Unknown Paxos phase %v", phase)
+	}
+	if err == storage.ErrBallotRejected {
+		node.conf.Sink.IncrCounter("node.paxos.rejected", 1)
+	}
+
+	promise := storage.Promise{Promised: st.promised, Accepted: st.accepted, Committed: st.committed}
+	if err == nil {
+		promise.Value, err = node.get(k)
+		promise.Found = err == nil
+		if err == storage.ErrRecordNotFound {
+			err = nil
+		}
+	}
+	return promise, err
+}
+
+// recovering tells if the node started less than cfg.PaxosRecovery ago.
+func (node *Node) recovering() bool {
+	return node.conf.PaxosRecovery > 0 && node.conf.Clock.Now().Sub(node.started) < node.conf.PaxosRecovery
+}
+
+// dropPaxos drops the state st of the acceptor of the record with key k
+// whose deletion is committed. Later rounds can't reuse its ballots, as
+// new states start from the highest committed ballot of the dropped ones.
+// Should be called with node.paxosLock held.
+func (node *Node) dropPaxos(k storage.RecordID, st *paxosState) {
+	node.paxosFloor = max(node.paxosFloor, st.committed)
+	delete(node.paxos, k)
+}
+
+// commit replaces the record with key k with the committed proposal p.
+// Should be called with node.paxosLock held.
+func (node *Node) commit(k storage.RecordID, p storage.Proposal) error {
+	ops := []storage.Op{{Key: k, Del: true}}
+	if !p.Deleted {
+		ops = append(ops, storage.Op{Key: k, Data: p.Data})
+	}
+	errs := node.applyBatch(ops)
+	if errs[0] != nil && errs[0] != storage.ErrRecordNotFound {
+		return errs[0]
+	}
+	if len(errs) > 1 {
+		return errs[1]
+	}
+	return nil
+}
+
+// get is Get not limited by cfg.RateLimit.
+func (node *Node) get(k storage.RecordID) ([]byte, error) {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	if e, ok := node.storage[k]; ok {
+		return node.load(e)
+	}
+	return nil, storage.ErrRecordNotFound
+}
@@ -0,0 +1,118 @@
+package node
+
+import (
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+type reservation struct {
+	size    int64
+	expires time.Time
+}
+
+// expireReservations drops expired reservations.
+// Should be called with node.quotaLock held.
+func (node *Node) expireReservations(now time.Time) {
+	for k, r := range node.reservations {
+		if !now.Before(r.expires) {
+			delete(node.reservations, k)
+			node.reserved -= r.size
+		}
+	}
+}
+
+// fits returns the storage.ErrQuotaExceeded error if size more bytes don't
+// fit cfg.MaxBytes along with stored and reserved ones.
+// Should be called with node.quotaLock held.
+func (node *Node) fits(size int64) error {
+	if atomic.LoadInt64(&node.bytes)+node.reserved+size > node.conf.MaxBytes {
+		return storage.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// admit checks that a value of the given size written to the record with
+// key k fits cfg.MaxBytes, consuming a reservation made for k, and reserves
+// the bytes until the value is stored. The returned function should be
+// called after that.
+func (node *Node) admit(k storage.RecordID, size int64) (func(), error) {
+	if node.conf.MaxBytes == 0 {
+		return func() {}, nil
+	}
+	node.quotaLock.Lock()
+	defer node.quotaLock.Unlock()
+
+	node.expireReservations(node.conf.Clock.Now())
+	r := node.reservations[k]
+	node.reserved -= r.size
+	if err := node.fits(size); err != nil {
+		node.reserved += r.size
+		node.conf.Sink.IncrCounter("node.quota.rejected", 1)
+		return nil, err
+	}
+	delete(node.reservations, k)
+	node.reserved += size
+	return func() {
+		node.quotaLock.Lock()
+		node.reserved -= size
+		node.quotaLock.Unlock()
+	}, nil
+}
+
+// Reserve reserves size bytes of cfg.MaxBytes for ttl for a following Put of
+// the record with key k, replacing a previous reservation for k. The Put
+// consumes the reservation. Returns the storage.ErrQuotaExceeded error if the
+// bytes don't fit the quota. Does nothing if cfg.MaxBytes is not set.
+//
+// Reserve резервирует size байт из cfg.MaxBytes на время ttl для последующего
+// Put записи с ключом k, заменяя предыдущее резервирование для k. Put
+// использует резервирование. Возвращает ошибку storage.ErrQuotaExceeded, если
+// байты не помещаются в квоту. Ничего не делает, если cfg.MaxBytes не задан.
+func (node *Node) Reserve(k storage.RecordID, size int64, ttl time.Duration) error {
+	if node.conf.MaxBytes == 0 {
+		return nil
+	}
+	node.quotaLock.Lock()
+	defer node.quotaLock.Unlock()
+
+	now := node.conf.Clock.Now()
+	node.expireReservations(now)
+	r := node.reservations[k]
+	node.reserved -= r.size
+	if err := node.fits(size); err != nil {
+		node.reserved += r.size
+		node.conf.Sink.IncrCounter("node.quota.rejected", 1)
+		return err
+	}
+	node.reservations[k] = reservation{size: size, expires: now.Add(ttl)}
+	node.reserved += size
+	return nil
+}
+
+// CancelReservation cancels the reservation made for the record with key k.
+//
+// CancelReservation отменяет резервирование, сделанное для записи с ключом k.
+func (node *Node) CancelReservation(k storage.RecordID) error {
+	node.quotaLock.Lock()
+	defer node.quotaLock.Unlock()
+
+	if r, ok := node.reservations[k]; ok {
+		delete(node.reservations, k)
+		node.reserved -= r.size
+	}
+	return nil
+}
+
+// allow admits n requests within cfg.RateLimit or returns
+// storage.ErrRateLimited with a hint to retry after the time
+// n requests are allowed in. Requests are counted for Stats either way.
+func (node *Node) allow(n int) error {
+	atomic.AddInt64(&node.requests, int64(n))
+	if node.limiter.AllowN(n) {
+		return nil
+	}
+	node.conf.Sink.IncrCounter("node.rate_limited", int64(n))
+	return storage.RetryAfterError{Err: storage.ErrRateLimited, After: node.conf.RateLimit.RetryAfter(n)}
+}
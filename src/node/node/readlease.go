@@ -0,0 +1,133 @@
+package node
+
+import (
+	"time"
+
+	"storage"
+)
+
+// AcquireReadLease grants a read lease on the record with key k to
+// the holder for ttl and returns the current value of the record. Until
+// the lease expires the node doesn't apply writes of k, they wait for it,
+// so the holder may serve strong reads of k from the node alone.
+// The lease is extended if it is already held by the holder and released
+// if ttl is not positive. Returns the storage.ErrLocked error if a write
+// of k is waiting, so writes are not delayed forever by renewed leases.
+// Leases are kept in memory only and lost on restart.
+//
+// AcquireReadLease выдает holder аренду чтения записи с ключом k на время
+// ttl и возвращает текущее значение записи. Пока аренда не истекла, node
+// не применяет записи k, они ждут ее окончания, поэтому holder может
+// обслуживать строгие чтения k с одной этой node. Если аренда уже
+// принадлежит holder, она продлевается, а если ttl не положительно,
+// освобождается. Возвращает ошибку storage.ErrLocked, если ожидает
+// запись k, чтобы продлеваемые аренды не задерживали записи бесконечно.
+// Аренды хранятся только в памяти и теряются при перезапуске.
+func (node *Node) AcquireReadLease(k storage.RecordID, holder uint64, ttl time.Duration) (storage.ReadLease, error) {
+	if err := node.allow(1); err != nil {
+		return storage.ReadLease{}, err
+	}
+	node.readLeaseLock.Lock()
+	if ttl <= 0 {
+		node.releaseReadLease(k, holder)
+		node.readLeaseLock.Unlock()
+		return storage.ReadLease{}, nil
+	}
+	if node.leaseWriters[k] > 0 {
+		node.readLeaseLock.Unlock()
+		node.conf.Sink.IncrCounter("node.read_lease.denied", 1)
+		return storage.ReadLease{}, storage.ErrLocked
+	}
+	now := node.conf.Clock.Now()
+	holders := node.readLeases[k]
+	if holders == nil {
+		holders = make(map[uint64]time.Time)
+		node.readLeases[k] = holders
+	}
+	for h, expires := range holders {
+		if !now.Before(expires) {
+			delete(holders, h)
+		}
+	}
+	holders[holder] = now.Add(ttl)
+	node.readLeaseLock.Unlock()
+	node.conf.Sink.IncrCounter("node.read_lease.granted", 1)
+
+	// Writes registered after the lease wait for it to expire and
+	// the ones registered before were denying it until they were
+	// applied, so the value read now stays current during the lease.
+	d, err := node.get(k)
+	switch err {
+	case nil:
+		return storage.ReadLease{Value: d, Found: true}, nil
+	case storage.ErrRecordNotFound:
+		return storage.ReadLease{}, nil
+	default:
+		node.readLeaseLock.Lock()
+		node.releaseReadLease(k, holder)
+		node.readLeaseLock.Unlock()
+		return storage.ReadLease{}, err
+	}
+}
+
+// releaseReadLease drops the read lease on k held by the holder and wakes
+// writes waiting for it. Should be called with node.readLeaseLock held.
+func (node *Node) releaseReadLease(k storage.RecordID, holder uint64) {
+	holders := node.readLeases[k]
+	if _, ok := holders[holder]; !ok {
+		return
+	}
+	delete(holders, holder)
+	if len(holders) == 0 {
+		delete(node.readLeases, k)
+	}
+	close(node.leaseReleased)
+	node.leaseReleased = make(chan struct{})
+}
+
+// waitReadLeases blocks new read leases on keys and waits until the ones
+// granted before expire or are released. The returned function should be
+// called once the writes of keys are applied to allow leases again.
+func (node *Node) waitReadLeases(keys ...storage.RecordID) func() {
+	node.readLeaseLock.Lock()
+	for _, k := range keys {
+		node.leaseWriters[k]++
+	}
+	for {
+		now := node.conf.Clock.Now()
+		var last time.Time
+		for _, k := range keys {
+			for holder, expires := range node.readLeases[k] {
+				if !now.Before(expires) {
+					node.releaseReadLease(k, holder)
+				} else if expires.After(last) {
+					last = expires
+				}
+			}
+		}
+		if last.IsZero() {
+			break
+		}
+		released := node.leaseReleased
+		node.readLeaseLock.Unlock()
+
+		timer := time.NewTimer(last.Sub(now))
+		select {
+		case <-timer.C:
+		case <-released:
+			timer.Stop()
+		}
+		node.readLeaseLock.Lock()
+	}
+	node.readLeaseLock.Unlock()
+
+	return func() {
+		node.readLeaseLock.Lock()
+		defer node.readLeaseLock.Unlock()
+		for _, k := range keys {
+			if node.leaseWriters[k]--; node.leaseWriters[k] == 0 {
+				delete(node.leaseWriters, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package node
+
+import (
+	"context"
+	"sort"
+
+	"storage"
+)
+
+// Scan returns up to limit records with keys greater than the cursor position
+// in ascending order of keys, and a cursor to continue scanning from.
+// The returned cursor is nil if there are no more records.
+// Not more than storage.ScanLimit records is returned.
+// Values are loaded without holding the lock not to block writes.
+//
+// Scan возвращает не больше limit записей с ключами, большими позиции
+// cursor, в порядке возрастания ключей, и cursor для продолжения.
+// Возвращаемый cursor равен nil, если записей больше нет.
+// Возвращается не больше чем storage.ScanLimit записей.
+// Значения загружаются без удержания блокировки, чтобы не блокировать запись.
+func (node *Node) Scan(cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	return node.ScanContext(context.Background(), cursor, limit)
+}
+
+// ScanContext is Scan returning ctx.Err() once ctx is done while values
+// are loaded.
+//
+// ScanContext -- Scan, возвращающий ctx.Err(), когда ctx завершается
+// во время загрузки значений.
+func (node *Node) ScanContext(ctx context.Context, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	node.lock.RLock()
+	records, entries, next, err := node.page(node.storage, cursor, limit)
+	return node.fill(ctx, records, entries, next, err)
+}
+
+// ScanSnapshot scans records of the snapshot with the given id like Scan.
+// Returns the storage.ErrSnapshotNotFound error if there is no such snapshot.
+//
+// ScanSnapshot просматривает записи снимка с данным id аналогично Scan.
+// Возвращает ошибку storage.ErrSnapshotNotFound, если такого снимка нет.
+func (node *Node) ScanSnapshot(id uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	node.lock.RLock()
+	s, ok := node.snapshots[id]
+	if !ok {
+		node.lock.RUnlock()
+		return nil, nil, storage.ErrSnapshotNotFound
+	}
+	records, entries, next, err := node.page(s.records, cursor, limit)
+	return node.fill(context.Background(), records, entries, next, err)
+}
+
+// ScanChanges scans records of the snapshot with the given id changed
+// after the snapshot since was taken like Scan. Records deleted in between
+// are returned with Deleted set.
+// Returns the storage.ErrSnapshotNotFound error if any snapshot doesn't exist.
+//
+// ScanChanges просматривает записи снимка с данным id, изменившиеся
+// после создания снимка since, аналогично Scan. Записи, удаленные
+// за это время, возвращаются с установленным Deleted.
+// Возвращает ошибку storage.ErrSnapshotNotFound, если какого-либо снимка нет.
+func (node *Node) ScanChanges(id, since uint64, cursor storage.Cursor, limit int) ([]storage.Record, storage.Cursor, error) {
+	node.lock.RLock()
+	s, ok := node.snapshots[id]
+	base, baseOk := node.snapshots[since]
+	if !ok || !baseOk {
+		node.lock.RUnlock()
+		return nil, nil, storage.ErrSnapshotNotFound
+	}
+
+	changed := make(map[storage.RecordID]entry)
+	for k, c := range s.changes {
+		if c.seq > base.seq {
+			changed[k] = s.records[k]
+		}
+	}
+	records, entries, next, err := node.page(changed, cursor, limit)
+	for i := range records {
+		records[i].Deleted = s.changes[records[i].Key].deleted
+	}
+	return node.fill(context.Background(), records, entries, next, err)
+}
+
+// page chooses records of the page after the cursor and their entries
+// without loading values. Should be called with node.lock held.
+func (node *Node) page(records map[storage.RecordID]entry, cursor storage.Cursor, limit int) ([]storage.Record, []entry, storage.Cursor, error) {
+	after, ok, err := cursor.After()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	limit = storage.ScanLimitOf(limit)
+
+	sortKeys := func(keys []storage.RecordID) {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i] < keys[j]
+		})
+	}
+
+	// Keep not more than 2*(limit+1) candidates to bound memory usage.
+	keys := make([]storage.RecordID, 0, 2*(limit+1))
+	for k := range records {
+		if ok && k <= after {
+			continue
+		}
+		keys = append(keys, k)
+		if len(keys) == cap(keys) {
+			sortKeys(keys)
+			keys = keys[:limit+1]
+		}
+	}
+	sortKeys(keys)
+
+	var next storage.Cursor
+	if len(keys) > limit {
+		keys = keys[:limit]
+		next = storage.CursorAfter(keys[limit-1])
+	}
+
+	page := make([]storage.Record, 0, len(keys))
+	entries := make([]entry, 0, len(keys))
+	for _, k := range keys {
+		page = append(page, storage.Record{Key: k})
+		entries = append(entries, records[k])
+	}
+	return page, entries, next, nil
+}
+
+// fill releases node.lock held for reading and loads values of records
+// from entries until ctx is done. Segments of the entries are kept from
+// removal by compaction until the values are loaded.
+func (node *Node) fill(ctx context.Context, records []storage.Record, entries []entry, next storage.Cursor, err error) ([]storage.Record, storage.Cursor, error) {
+	node.segLock.RLock()
+	defer node.segLock.RUnlock()
+	node.lock.RUnlock()
+
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		d, err := node.load(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		records[i].Data = d
+	}
+	return records, next, nil
+}
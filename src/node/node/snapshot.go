@@ -0,0 +1,148 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"storage"
+)
+
+// change describes the last change of a record.
+type change struct {
+	seq     uint64
+	deleted bool
+}
+
+type snapshot struct {
+	seq     uint64
+	records map[storage.RecordID]entry
+	changes map[storage.RecordID]change
+}
+
+// track assigns the next sequence number to a change of the record with
+// key k and remembers it for Delta and incremental scans of snapshots.
+// Changes are tracked for snapshots only while there are any, as changes
+// before the oldest snapshot are never scanned. Should be called with
+// node.lock held.
+func (node *Node) track(k storage.RecordID, deleted bool) {
+	node.seq++
+	node.logOp(k)
+	if len(node.snapshots) > 0 {
+		node.changes[k] = change{seq: node.seq, deleted: deleted}
+	}
+}
+
+// prune drops changes made before the oldest snapshot.
+// Should be called with node.lock held.
+func (node *Node) prune() {
+	var oldest uint64
+	first := true
+	for _, s := range node.snapshots {
+		if first || s.seq < oldest {
+			oldest, first = s.seq, false
+		}
+	}
+	for k, c := range node.changes {
+		if first || c.seq <= oldest {
+			delete(node.changes, k)
+		}
+	}
+}
+
+// barrier blocks writes to the node while a snapshot is being taken.
+type barrier struct {
+	id    uint64
+	done  chan struct{}
+	timer *time.Timer
+}
+
+// waitBarrier blocks until there is no active barrier.
+func (node *Node) waitBarrier() {
+	node.barLock.Lock()
+	b := node.barrier
+	node.barLock.Unlock()
+
+	if b != nil {
+		<-b.done
+	}
+}
+
+func (node *Node) freeze(id uint64, ttl time.Duration) error {
+	node.barLock.Lock()
+	defer node.barLock.Unlock()
+
+	if b := node.barrier; b != nil {
+		if b.id != id {
+			return storage.ErrSnapshotActive
+		}
+		b.timer.Reset(ttl)
+		return nil
+	}
+	b := &barrier{id: id, done: make(chan struct{})}
+	b.timer = time.AfterFunc(ttl, func() {
+		node.thaw(id)
+	})
+	node.barrier = b
+	return nil
+}
+
+func (node *Node) thaw(id uint64) {
+	node.barLock.Lock()
+	defer node.barLock.Unlock()
+
+	if b := node.barrier; b != nil && b.id == id {
+		b.timer.Stop()
+		close(b.done)
+		node.barrier = nil
+	}
+}
+
+// Snapshot performs the given phase of taking a snapshot with the given id.
+// SnapshotFreeze blocks writes for at most ttl, SnapshotTake copies the
+// records and unblocks writes, SnapshotAbort unblocks writes without
+// taking a snapshot and SnapshotDrop removes the snapshot.
+// Returns the storage.ErrSnapshotActive error if writes are blocked
+// for another snapshot.
+//
+// Snapshot выполняет данную фазу создания снимка с данным id.
+// SnapshotFreeze блокирует запись не дольше чем на ttl, SnapshotTake копирует
+// записи и разблокирует запись, SnapshotAbort разблокирует запись без
+// создания снимка, SnapshotDrop удаляет снимок.
+// Возвращает ошибку storage.ErrSnapshotActive, если запись заблокирована
+// для другого снимка.
+func (node *Node) Snapshot(id uint64, phase storage.SnapshotPhase, ttl time.Duration) error {
+	switch phase {
+	case storage.SnapshotFreeze:
+		return node.freeze(id, ttl)
+	case storage.SnapshotTake:
+		node.lock.Lock()
+		if _, ok := node.snapshots[id]; !ok {
+			s := &snapshot{
+				seq:     node.seq,
+				records: make(map[storage.RecordID]entry, len(node.storage)),
+				changes: make(map[storage.RecordID]change, len(node.changes)),
+			}
+			for k, e := range node.storage {
+				s.records[k] = e
+			}
+			for k, c := range node.changes {
+				s.changes[k] = c
+			}
+			node.snapshots[id] = s
+		}
+		node.lock.Unlock()
+		node.thaw(id)
+		return nil
+	case storage.SnapshotAbort:
+		node.thaw(id)
+		return nil
+	case storage.SnapshotDrop:
+		node.lock.Lock()
+		delete(node.snapshots, id)
+		node.prune()
+		node.lock.Unlock()
+		return nil
+	default:
+		return fmt.Errorf("Unknown snapshot phase %v", phase)
+	}
+}
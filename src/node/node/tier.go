@@ -0,0 +1,227 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"storage"
+)
+
+// ErrTieringDisabled is returned by Tier if the node has no object store.
+//
+// ErrTieringDisabled возвращается Tier, если у node нет хранилища объектов.
+var ErrTieringDisabled = errors.New("Tiering is disabled")
+
+// TierResult describes a tiering pass.
+//
+// TierResult описывает проход переноса записей.
+type TierResult struct {
+	// Scanned is a number of scanned records.
+	// Scanned -- количество просмотренных записей.
+	Scanned int
+	// Migrated is a number of records migrated to the object store.
+	// Migrated -- количество записей, перенесенных в хранилище объектов.
+	Migrated int
+	// Bytes is a size of values of the migrated records.
+	// Bytes -- размер значений перенесенных записей.
+	Bytes int64
+}
+
+// Tier migrates values of records not accessed for cfg.Tiering.After to
+// cfg.ColdStore, keeping only names of their objects locally. Only records
+// of storage.ClassTiered namespaces are migrated if there are any, and
+// records of no namespace otherwise. Records accessed or changed while
+// their values are uploaded stay local. Values are fetched back on Get
+// and stored locally again.
+//
+// Objects of deleted records are removed in the background, so snapshots
+// taken before may fail to load them.
+//
+// Tier переносит значения записей, к которым не было обращений
+// в течение cfg.Tiering.After, в cfg.ColdStore, оставляя у себя только
+// имена их объектов. Переносятся только записи пространств ключей класса
+// storage.ClassTiered, если они есть, иначе записи вне пространств.
+// Записи, к которым обращались или которые изменились во время загрузки
+// значений, остаются на node. При Get значения загружаются обратно
+// и снова хранятся на node.
+//
+// Объекты удаленных записей удаляются в фоне, поэтому снимки, сделанные
+// до этого, могут не суметь их загрузить.
+func (node *Node) Tier() (TierResult, error) {
+	var res TierResult
+	if node.conf.ColdStore == nil {
+		return res, ErrTieringDisabled
+	}
+	cutoff := node.conf.Clock.Now().Add(-node.conf.Tiering.After)
+
+	var cold []storage.RecordID
+	node.lock.RLock()
+	node.tierLock.Lock()
+	for k, e := range node.storage {
+		res.Scanned++
+		if e.obj == "" && e.size >= node.conf.Tiering.MinSize && node.tiered(k) && !node.access[k].After(cutoff) {
+			cold = append(cold, k)
+		}
+	}
+	node.tierLock.Unlock()
+	node.lock.RUnlock()
+
+	for _, k := range cold {
+		size, err := node.migrate(k, cutoff)
+		if err != nil {
+			return res, err
+		}
+		if size >= 0 {
+			res.Migrated++
+			res.Bytes += int64(size)
+		}
+	}
+	node.conf.Sink.IncrCounter("node.tier.migrated", int64(res.Migrated))
+	return res, nil
+}
+
+// migrate uploads the value of the record with key k and replaces its entry
+// with the name of the object unless the record was accessed after cutoff
+// or changed meanwhile. Returns the size of the value or -1 if the record
+// is kept local.
+func (node *Node) migrate(k storage.RecordID, cutoff time.Time) (int, error) {
+	node.lock.RLock()
+	e, ok := node.storage[k]
+	if !ok || e.obj != "" {
+		node.lock.RUnlock()
+		return -1, nil
+	}
+	records, _, err := node.fill(context.Background(), []storage.Record{{Key: k}}, []entry{e}, nil, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	node.tierLock.Lock()
+	node.objects++
+	name := fmt.Sprintf("%s%s/%d-%d", node.conf.Tiering.Prefix,
+		strings.Replace(string(node.conf.Addr), ":", "_", -1), k, node.objects)
+	node.tierLock.Unlock()
+	if err := node.conf.ColdStore.Put(name, records[0].Data); err != nil {
+		return -1, fmt.Errorf("Failed to upload record %v: %v", k, err)
+	}
+
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	node.tierLock.Lock()
+	accessed := node.access[k].After(cutoff)
+	node.tierLock.Unlock()
+	if cur, ok := node.storage[k]; !ok || !sameEntry(cur, e) || accessed {
+		node.forget(entry{obj: name})
+		return -1, nil
+	}
+	node.storage[k] = entry{obj: name, size: e.size}
+	return e.size, nil
+}
+
+// fetchBack loads the value of the record with key k stored in the object
+// store by e and stores it locally again unless the record changed meanwhile.
+// Should be called without node.lock held.
+func (node *Node) fetchBack(k storage.RecordID, e entry) ([]byte, error) {
+	d, err := node.load(e)
+	if err != nil {
+		return nil, err
+	}
+	local, err := node.store(context.Background(), k, d)
+	if err != nil {
+		// The value is still served from the object store.
+		return d, nil
+	}
+
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	if cur, ok := node.storage[k]; ok && sameEntry(cur, e) {
+		node.storage[k] = local
+		node.forget(e)
+	}
+	return d, nil
+}
+
+// fetch downloads the value of e from the object store.
+func (node *Node) fetch(e entry) ([]byte, error) {
+	d, err := node.conf.ColdStore.Get(e.obj)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch object %q: %v", e.obj, err)
+	}
+	node.conf.Sink.IncrCounter("node.tier.fetched", 1)
+	return d, nil
+}
+
+// forget removes the object of e from the object store in the background
+// if e refers to one.
+func (node *Node) forget(e entry) {
+	if e.obj == "" {
+		return
+	}
+	go func() {
+		if err := node.conf.ColdStore.Delete(e.obj); err != nil {
+			node.conf.Logger.Printf("Failed to delete object %q: %v", e.obj, err)
+		}
+	}()
+}
+
+// touch records an access of the record with key k if tiering is enabled.
+func (node *Node) touch(k storage.RecordID) {
+	if node.conf.ColdStore == nil {
+		return
+	}
+	node.tierLock.Lock()
+	node.access[k] = node.conf.Clock.Now()
+	node.tierLock.Unlock()
+}
+
+// untouch forgets accesses of the deleted record with key k and removes
+// its object if it has one.
+func (node *Node) untouch(k storage.RecordID, e entry) {
+	if node.conf.ColdStore == nil {
+		return
+	}
+	node.tierLock.Lock()
+	delete(node.access, k)
+	node.tierLock.Unlock()
+	node.forget(e)
+}
+
+// tiered reports whether the record with key k may be migrated.
+func (node *Node) tiered(k storage.RecordID) bool {
+	class := node.conf.Namespaces.Class(k)
+	if node.conf.Namespaces.Has(storage.ClassTiered) {
+		return class == storage.ClassTiered
+	}
+	return class == storage.ClassDefault
+}
+
+// sameEntry reports whether a and b refer to the same stored value.
+func sameEntry(a, b entry) bool {
+	if a.disk != b.disk || a.seg != b.seg || a.off != b.off || a.size != b.size || a.obj != b.obj {
+		return false
+	}
+	return len(a.d) == 0 || len(b.d) != 0 && &a.d[0] == &b.d[0]
+}
+
+// Tierings runs tiering passes each time interval set by
+// cfg.Tiering.Interval.
+//
+// Tierings запускает проходы переноса записей через каждый интервал
+// времени, заданный в cfg.Tiering.Interval.
+func (node *Node) Tierings() {
+	go func() {
+		for {
+			time.Sleep(node.conf.Tiering.Interval)
+			res, err := node.Tier()
+			if err != nil {
+				node.conf.Logger.Printf("Tiering failed: %v", err)
+				continue
+			}
+			node.conf.Logger.Printf("Tiering: scanned %d records, migrated %d of %d bytes",
+				res.Scanned, res.Migrated, res.Bytes)
+		}
+	}()
+}
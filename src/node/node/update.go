@@ -0,0 +1,128 @@
+package node
+
+import (
+	"bytes"
+	"context"
+
+	"storage"
+	"storage/crdt"
+)
+
+// Update replaces the value of the record with key k if it exists.
+// Returns the storage.ErrRecordNotFound error otherwise.
+//
+// Update -- заменить значение записи с ключом k, если она существует.
+// Иначе вернуть ошибку storage.ErrRecordNotFound.
+func (node *Node) Update(k storage.RecordID, d []byte) error {
+	return node.UpdateContext(context.Background(), k, d)
+}
+
+// UpdateContext is Update creating the version of ctx, see
+// storage.WithVersion.
+//
+// UpdateContext -- Update, создающий версию ctx, см. storage.WithVersion.
+func (node *Node) UpdateContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	return node.replace(ctx, k, d, true)
+}
+
+// Upsert puts the record with key k, replacing its value if it exists.
+//
+// Upsert -- добавить запись с ключом k, заменив ее значение, если она
+// существует.
+func (node *Node) Upsert(k storage.RecordID, d []byte) error {
+	return node.UpsertContext(context.Background(), k, d)
+}
+
+// UpsertContext is Upsert creating the version of ctx, see
+// storage.WithVersion.
+//
+// UpsertContext -- Upsert, создающий версию ctx, см. storage.WithVersion.
+func (node *Node) UpsertContext(ctx context.Context, k storage.RecordID, d []byte) error {
+	return node.replace(ctx, k, d, false)
+}
+
+// Merge merges the CRDT value d into the record with key k, see
+// crdt.Merge, or puts d if there is no such record. Returns the
+// storage.ErrInvalidValue error if d or the stored value is not a CRDT
+// value of the same type.
+//
+// Merge -- объединить CRDT значение d с записью с ключом k, см.
+// crdt.Merge, или добавить d, если такой записи нет. Возвращает ошибку
+// storage.ErrInvalidValue, если d или хранящееся значение не является
+// CRDT значением того же типа.
+func (node *Node) Merge(k storage.RecordID, d []byte) error {
+	if err := node.allow(1); err != nil {
+		return err
+	}
+	if _, err := crdt.Unmarshal(d); err != nil {
+		return storage.ErrInvalidValue
+	}
+	for {
+		// The merged value replaces the record unless it is changed
+		// meanwhile, then it is merged again.
+		since := node.Seq()
+		merged := d
+		cur, err := node.get(k)
+		switch {
+		case err == nil:
+			if merged, err = crdt.Merge(cur, d); err != nil {
+				return storage.ErrInvalidValue
+			}
+			if bytes.Equal(merged, cur) {
+				return nil
+			}
+		case err != storage.ErrRecordNotFound:
+			return err
+		}
+		errs := node.applyBatchSince([]storage.Op{{Key: k, Del: true}, {Key: k, Data: merged}}, &since)
+		if errs[0] == errChangedLocally {
+			node.conf.Sink.IncrCounter("node.merge.retries", 1)
+			continue
+		}
+		if errs[0] != nil && errs[0] != storage.ErrRecordNotFound {
+			return errs[0]
+		}
+		return errs[1]
+	}
+}
+
+// replace stores d and swaps the record with key k for it under one lock
+// acquisition, so readers see either the old value or the new one. Fails
+// with storage.ErrRecordNotFound if the record doesn't exist and mustExist
+// is set.
+func (node *Node) replace(ctx context.Context, k storage.RecordID, d []byte, mustExist bool) error {
+	if err := node.allow(1); err != nil {
+		return err
+	}
+	node.waitBarrier()
+	defer node.waitReadLeases(k)()
+	if mustExist {
+		node.lock.RLock()
+		_, ok := node.storage[k]
+		node.lock.RUnlock()
+		if !ok {
+			return storage.ErrRecordNotFound
+		}
+	}
+	done, err := node.admit(k, int64(len(d)))
+	if err != nil {
+		return err
+	}
+	defer done()
+	e, err := node.store(ctx, k, d)
+	if err != nil {
+		return err
+	}
+
+	node.lock.Lock()
+	err = node.del(k)
+	if err == nil || !mustExist && err == storage.ErrRecordNotFound {
+		err = node.putVersion(k, d, e, storage.VersionOf(ctx))
+	}
+	// e is left in the disk log as garbage for compaction on errors.
+	node.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return node.syncJournal(ctx, k)
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"time"
+
+	"storage"
+)
+
+// VersionsTTL is a default time versions of deleted records are kept for.
+//
+// VersionsTTL -- время хранения версий удаленных записей по умолчанию.
+const VersionsTTL = 24 * time.Hour
+
+type version struct {
+	n uint64
+	e entry
+}
+
+// history stores the last versions of a record in ascending order.
+// deleted is the time the record was deleted at, zero if it exists.
+type history struct {
+	last     uint64
+	versions []version
+	deleted  time.Time
+}
+
+// remember adds e as the version v of the record with key k, dropping
+// the oldest versions over cfg.Versions. Versions are assigned by writers,
+// see storage.VersionOf, the node numbers them by its clock if v is 0.
+// Versions not above the last one are moved past it, so they still
+// ascend, e.g. if clocks of writers are skewed. Should be called with
+// node.lock held.
+func (node *Node) remember(k storage.RecordID, e entry, v uint64) {
+	if node.conf.Versions <= 0 {
+		return
+	}
+	h, ok := node.history[k]
+	if !ok {
+		h = &history{}
+		node.history[k] = h
+	}
+	if v == 0 {
+		v = uint64(node.conf.Clock.Now().UnixNano())
+	}
+	h.last = max(v, h.last+1)
+	h.deleted = time.Time{}
+	h.versions = append(h.versions, version{n: h.last, e: e})
+	if extra := len(h.versions) - node.conf.Versions; extra > 0 {
+		h.versions = append(h.versions[:0], h.versions[extra:]...)
+	}
+}
+
+// retire marks the history of the record with key k deleted, so it expires
+// after cfg.VersionsTTL unless the record is put again. Expired histories
+// are dropped once per cfg.VersionsTTL, so they are kept for twice as long
+// at most. Should be called with node.lock held.
+func (node *Node) retire(k storage.RecordID) {
+	h, ok := node.history[k]
+	if !ok {
+		return
+	}
+	now := node.conf.Clock.Now()
+	h.deleted = now
+	if now.Sub(node.historySwept) < node.conf.VersionsTTL {
+		return
+	}
+	node.historySwept = now
+	for k := range node.history {
+		if _, ok := node.versions(k); !ok {
+			delete(node.history, k)
+		}
+	}
+}
+
+// versions returns the history of the record with key k unless it was
+// deleted over cfg.VersionsTTL ago. Should be called with node.lock held.
+func (node *Node) versions(k storage.RecordID) (*history, bool) {
+	h, ok := node.history[k]
+	if !ok || !h.deleted.IsZero() && node.conf.Clock.Now().Sub(h.deleted) >= node.conf.VersionsTTL {
+		return nil, false
+	}
+	return h, true
+}
+
+// GetVersion returns the given version of the record with key k, even if the
+// record was deleted less than cfg.VersionsTTL ago. Returns the
+// storage.ErrVersionNotFound error if the version is not kept.
+//
+// GetVersion возвращает данную версию записи с ключом k, даже если запись
+// была удалена менее cfg.VersionsTTL назад. Возвращает ошибку
+// storage.ErrVersionNotFound, если версия не хранится.
+func (node *Node) GetVersion(k storage.RecordID, n uint64) ([]byte, error) {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+
+	if h, ok := node.versions(k); ok {
+		for _, v := range h.versions {
+			if v.n == n {
+				return node.load(v.e)
+			}
+		}
+	}
+	return nil, storage.ErrVersionNotFound
+}
+
+// ListVersions returns kept versions of the record with key k in ascending
+// order. Every Put of the record creates a new version numbered by its
+// writer, so replicas of the record agree on the numbers.
+// Returns the storage.ErrRecordNotFound error if there are no versions.
+//
+// ListVersions возвращает хранящиеся версии записи с ключом k в порядке
+// возрастания. Каждый Put записи создает новую версию, номер которой
+// задает записывающий, поэтому реплики записи согласны в номерах.
+// Возвращает ошибку storage.ErrRecordNotFound, если версий нет.
+func (node *Node) ListVersions(k storage.RecordID) ([]uint64, error) {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+
+	h, ok := node.versions(k)
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	versions := make([]uint64, 0, len(h.versions))
+	for _, v := range h.versions {
+		versions = append(versions, v.n)
+	}
+	return versions, nil
+}
@@ -0,0 +1,337 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"events"
+	"storage"
+	"storage/crdt"
+)
+
+// WarmResult describes a warm up of a node.
+//
+// WarmResult описывает прогрев node.
+type WarmResult struct {
+	// Peers is a number of other nodes the node caught up with.
+	// Peers -- количество других node, которые догнала node.
+	Peers int
+	// Deltas is a number of peers caught up with by Delta
+	// instead of a full scan.
+	// Deltas -- количество node, догнанных с помощью Delta
+	// вместо полного сканирования.
+	Deltas int
+	// Missing is a number of scanned records the node didn't have.
+	// Missing -- количество просканированных записей, которых не было в node.
+	Missing int
+	// Copied is a number of missing records the node owns and copied.
+	// Copied -- количество недостающих записей, которые node должна хранить
+	// и скопировала.
+	Copied int
+	// Deleted is a number of records deleted on peers and deleted by the node.
+	// Deleted -- количество записей, удаленных на других node и удаленных node.
+	Deleted int
+	// Replaced is a number of stale values replaced with the ones changed
+	// on peers.
+	// Replaced -- количество устаревших значений, замененных измененными
+	// на других node.
+	Replaced int
+}
+
+// WarmUp copies records the node owns according to the router but misses
+// from other live nodes, e.g. the ones written while it was down. It should
+// be called before Heartbeats, so the node isn't read from until it catches
+// up. Unreachable peers are skipped. Records changed on the node while it
+// catches up, e.g. by clients once it is live, keep their newer values.
+//
+// The node remembers sequence numbers of peers it caught up with. Peers
+// whose sequence numbers reported to the router didn't change since are
+// skipped, others are caught up with by Delta, which also deletes records
+// deleted on them and replaces values changed on them. Peers are scanned in full the first time or if they no
+// longer keep the missed operations, then records deleted while the node
+// was down are not detected and are kept until they are deleted again.
+//
+// WarmUp копирует записи, которые node должна хранить согласно router, но
+// которых у нее нет, с других живых node, например записанные, пока она
+// была недоступна. Должен вызываться до Heartbeats, чтобы из node не читали,
+// пока она не догонит остальные. Недоступные node пропускаются. Записи,
+// измененные на node, пока она догоняет остальные, например клиентами,
+// когда она уже доступна, сохраняют свои более новые значения.
+//
+// Node запоминает номера последовательности догнанных node. Node, номера
+// последовательности которых в router с тех пор не изменились, пропускаются,
+// остальные догоняются с помощью Delta, при этом удаляются и записи,
+// удаленные на них, и заменяются значения, измененные на них. В первый раз или если node больше не хранят пропущенные
+// операции, они сканируются полностью, и тогда записи, удаленные, пока node
+// была недоступна, не обнаруживаются и хранятся, пока не будут удалены снова.
+func (node *Node) WarmUp() (WarmResult, error) {
+	node.warmLock.Lock()
+	defer node.warmLock.Unlock()
+
+	var res WarmResult
+	beats, err := node.conf.Client.Heartbeats(node.conf.Router)
+	if err != nil {
+		return res, err
+	}
+	for _, hb := range beats {
+		if hb.Node == node.conf.Addr {
+			continue
+		}
+		if err := node.warmFrom(hb, &res); err != nil {
+			node.conf.Logger.Printf("Failed to warm up from %q: %v", hb.Node, err)
+			continue
+		}
+		res.Peers++
+	}
+	node.conf.Sink.IncrCounter("node.warm.copied", int64(res.Copied))
+	node.conf.Sink.IncrCounter("node.warm.deleted", int64(res.Deleted))
+	node.conf.Sink.IncrCounter("node.warm.replaced", int64(res.Replaced))
+	return res, nil
+}
+
+// catchUp runs WarmUp and logs its result.
+func (node *Node) catchUp() {
+	res, err := node.WarmUp()
+	if err != nil {
+		node.conf.Logger.Printf("Failed to catch up: %v", err)
+		return
+	}
+	node.conf.Logger.Printf("Caught up with %v nodes, %v by delta: copied %v records, deleted %v, replaced %v",
+		res.Peers, res.Deltas, res.Copied, res.Deleted, res.Replaced)
+	if res.Copied+res.Deleted+res.Replaced > 0 {
+		node.conf.Bus.Publish(events.Event{
+			Kind:   events.RepairCompleted,
+			Node:   node.conf.Addr,
+			Reason: fmt.Sprintf("caught up with %v nodes: copied %v records, deleted %v, replaced %v", res.Peers, res.Copied, res.Deleted, res.Replaced),
+		})
+	}
+}
+
+// warmFrom catches up with the peer which sent hb.
+// Should be called with node.warmLock held.
+func (node *Node) warmFrom(hb storage.Heartbeat, res *WarmResult) error {
+	if since, ok := node.peerSeqs[hb.Node]; ok {
+		if since == hb.Seq {
+			return nil
+		}
+		err := node.deltaFrom(hb.Node, since, res)
+		if err != storage.ErrSeqOutOfRange {
+			return err
+		}
+		node.conf.Logger.Printf("Operations of %q after %v are not kept, scanning it", hb.Node, since)
+	}
+	// Records scanned include all the changes up to hb.Seq.
+	if err := node.scanFrom(hb.Node, res); err != nil {
+		return err
+	}
+	node.peerSeqs[hb.Node] = hb.Seq
+	return nil
+}
+
+// deltaFrom applies changes made on peer after the sequence number since.
+// Records changed on the node after the changes are fetched, e.g. by clients
+// of a live node, are skipped, so their newer values aren't reverted.
+// Should be called with node.warmLock held.
+func (node *Node) deltaFrom(peer storage.ServiceAddr, since uint64, res *WarmResult) error {
+	for {
+		mark := node.Seq()
+		delta, err := node.conf.Peers.Delta(peer, since, storage.ScanLimit)
+		if err != nil {
+			return err
+		}
+		var puts, changed, local []storage.Record
+		var entries []entry
+		var dels []storage.RecordID
+		node.lock.RLock()
+		for _, r := range delta.Records {
+			e, ok := node.storage[r.Key]
+			switch {
+			case !r.Deleted && !ok:
+				puts = append(puts, r)
+			case !r.Deleted:
+				changed = append(changed, r)
+				local = append(local, storage.Record{Key: r.Key})
+				entries = append(entries, e)
+			case r.Deleted && ok:
+				dels = append(dels, r.Key)
+			}
+		}
+		local, _, err = node.fill(context.Background(), local, entries, nil, nil)
+		if err != nil {
+			return err
+		}
+		res.Missing += len(puts)
+
+		if err := node.copyMissing(puts, mark, res); err != nil {
+			return err
+		}
+		if err := node.replaceStale(changed, local, mark, res); err != nil {
+			return err
+		}
+		if err := node.deleteFrom(peer, dels, mark, res); err != nil {
+			return err
+		}
+		node.peerSeqs[peer] = delta.Seq
+		if !delta.More {
+			res.Deltas++
+			return nil
+		}
+		since = delta.Seq
+	}
+}
+
+// scanFrom copies records the node owns and misses from peer.
+func (node *Node) scanFrom(peer storage.ServiceAddr, res *WarmResult) error {
+	var cursor storage.Cursor
+	for {
+		mark := node.Seq()
+		records, next, err := node.conf.Peers.Scan(peer, cursor, storage.ScanLimit)
+		if err != nil {
+			return err
+		}
+
+		var missing []storage.Record
+		node.lock.RLock()
+		for _, r := range records {
+			if _, ok := node.storage[r.Key]; !ok {
+				missing = append(missing, r)
+			}
+		}
+		node.lock.RUnlock()
+		res.Missing += len(missing)
+
+		if err := node.copyMissing(missing, mark, res); err != nil {
+			return err
+		}
+
+		if next == nil {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// copyMissing puts records the node owns out of missing ones unless they
+// are changed after the sequence number since.
+func (node *Node) copyMissing(missing []storage.Record, since uint64, res *WarmResult) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	keys := make([]storage.RecordID, 0, len(missing))
+	for _, r := range missing {
+		keys = append(keys, r.Key)
+	}
+	orphans, err := node.conf.Client.Orphans(node.conf.Router, node.conf.Addr, keys)
+	if err != nil {
+		return err
+	}
+	skip := make(map[storage.RecordID]bool, len(orphans))
+	for _, k := range orphans {
+		skip[k] = true
+	}
+	ops := make([]storage.Op, 0, len(missing))
+	for _, r := range missing {
+		if !skip[r.Key] {
+			ops = append(ops, storage.Op{Key: r.Key, Data: r.Data})
+		}
+	}
+	for i, err := range node.applyBatchSince(ops, &since) {
+		switch err {
+		case nil:
+			res.Copied++
+		case storage.ErrRecordExists, errChangedLocally:
+			// Written by a client meanwhile.
+		default:
+			return fmt.Errorf("Failed to copy record %v: %v", ops[i].Key, err)
+		}
+	}
+	return nil
+}
+
+// replaceStale replaces values of local records the node owns with
+// the ones of changed records if they differ. The records are changed on
+// a peer after the node saw it last, e.g. deleted and put again while the
+// node was down, so the local values are stale. CRDT values are merged
+// instead if cfg.MergeCRDT is set. Records changed after the sequence
+// number since are kept.
+func (node *Node) replaceStale(changed, local []storage.Record, since uint64, res *WarmResult) error {
+	var stale []storage.Record
+	for i, r := range changed {
+		if node.conf.MergeCRDT {
+			if merged, err := crdt.Merge(local[i].Data, r.Data); err == nil {
+				r.Data = merged
+			}
+		}
+		if !bytes.Equal(r.Data, local[i].Data) {
+			stale = append(stale, r)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	keys := make([]storage.RecordID, 0, len(stale))
+	for _, r := range stale {
+		keys = append(keys, r.Key)
+	}
+	orphans, err := node.conf.Client.Orphans(node.conf.Router, node.conf.Addr, keys)
+	if err != nil {
+		return err
+	}
+	skip := make(map[storage.RecordID]bool, len(orphans))
+	for _, k := range orphans {
+		skip[k] = true
+	}
+	ops := make([]storage.Op, 0, 2*len(stale))
+	for _, r := range stale {
+		if !skip[r.Key] {
+			ops = append(ops, storage.Op{Key: r.Key, Del: true}, storage.Op{Key: r.Key, Data: r.Data})
+		}
+	}
+	errs := node.applyBatchSince(ops, &since)
+	for i := 1; i < len(ops); i += 2 {
+		if errs[i-1] == errChangedLocally {
+			// Both ops are skipped then.
+			continue
+		}
+		if errs[i-1] != nil || errs[i] != nil {
+			return fmt.Errorf("Failed to replace record %v: %v, %v", ops[i].Key, errs[i-1], errs[i])
+		}
+		res.Replaced++
+	}
+	return nil
+}
+
+// deleteFrom deletes records with keys deleted on peer. Records peer
+// doesn't own are skipped, as they may have been deleted by its GC, and so
+// are records changed after the sequence number since.
+func (node *Node) deleteFrom(peer storage.ServiceAddr, keys []storage.RecordID, since uint64, res *WarmResult) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	orphans, err := node.conf.Client.Orphans(node.conf.Router, peer, keys)
+	if err != nil {
+		return err
+	}
+	skip := make(map[storage.RecordID]bool, len(orphans))
+	for _, k := range orphans {
+		skip[k] = true
+	}
+	ops := make([]storage.Op, 0, len(keys))
+	for _, k := range keys {
+		if !skip[k] {
+			ops = append(ops, storage.Op{Key: k, Del: true})
+		}
+	}
+	for i, err := range node.applyBatchSince(ops, &since) {
+		switch err {
+		case nil:
+			res.Deleted++
+		case storage.ErrRecordNotFound, errChangedLocally:
+			// Changed by a client meanwhile.
+		default:
+			return fmt.Errorf("Failed to delete record %v: %v", ops[i].Key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Defaults of AdaptiveConfig.
+//
+// Значения AdaptiveConfig по умолчанию.
+const (
+	AdaptiveInitial   = 20
+	AdaptiveMin       = 1
+	AdaptiveMax       = 1000
+	AdaptiveTolerance = 2.0
+	AdaptiveBackoff   = 0.9
+)
+
+// AdaptiveConfig stores configuration of an adaptive concurrency limiter.
+//
+// AdaptiveConfig -- содержит конфигурацию адаптивного ограничителя
+// параллельности.
+type AdaptiveConfig struct {
+	// Enabled enables the limiter, concurrency is not limited otherwise.
+	// Enabled -- включает ограничитель, иначе параллельность не ограничена.
+	Enabled bool
+	// Initial is a limit to start with, AdaptiveInitial by default.
+	// Initial -- начальное ограничение, по умолчанию AdaptiveInitial.
+	Initial int
+	// Min and Max bound the limit, AdaptiveMin and AdaptiveMax by default.
+	// Min и Max -- границы ограничения, по умолчанию AdaptiveMin и AdaptiveMax.
+	Min, Max int
+	// Tolerance is how many times latency may exceed the lowest one seen
+	// before it is considered a sign of overload, AdaptiveTolerance by default.
+	// Tolerance -- во сколько раз задержка может превысить наименьшую
+	// из наблюдавшихся, прежде чем считаться признаком перегрузки,
+	// по умолчанию AdaptiveTolerance.
+	Tolerance float64
+	// Backoff is a factor the limit is multiplied by on overload,
+	// AdaptiveBackoff by default.
+	// Backoff -- множитель, на который умножается ограничение при перегрузке,
+	// по умолчанию AdaptiveBackoff.
+	Backoff float64
+}
+
+// Validate checks that cfg is consistent.
+//
+// Validate проверяет, что cfg непротиворечива.
+func (cfg AdaptiveConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Initial < 0 || cfg.Min < 0 || cfg.Max < 0 {
+		return fmt.Errorf("Limits of adaptive concurrency limiter should not be negative, got %v, %v and %v", cfg.Initial, cfg.Min, cfg.Max)
+	}
+	if cfg.Max != 0 && cfg.Min > cfg.Max {
+		return fmt.Errorf("Min %v of adaptive concurrency limiter should not exceed Max %v", cfg.Min, cfg.Max)
+	}
+	if cfg.Tolerance != 0 && cfg.Tolerance < 1 {
+		return fmt.Errorf("Tolerance of adaptive concurrency limiter should be at least 1, got %v", cfg.Tolerance)
+	}
+	if cfg.Backoff < 0 || cfg.Backoff >= 1 {
+		return fmt.Errorf("Backoff of adaptive concurrency limiter should be in [0, 1), got %v", cfg.Backoff)
+	}
+	return nil
+}
+
+// Adaptive limits the number of concurrent requests to a service finding
+// the concurrency it sustains with AIMD: the limit grows by one each time
+// as many requests as the limit succeed in time and is cut by
+// cfg.Backoff when latency grows over cfg.Tolerance times the lowest one
+// or the service reports overload.
+//
+// Adaptive ограничивает количество параллельных запросов к сервису, находя
+// выдерживаемую им параллельность с помощью AIMD: ограничение растет на
+// единицу каждый раз, когда столько запросов, каково ограничение, успешно
+// выполнены вовремя, и уменьшается в cfg.Backoff раз, когда задержка
+// превышает наименьшую в cfg.Tolerance раз или сервис сообщает о перегрузке.
+type Adaptive struct {
+	conf     AdaptiveConfig
+	limit    float64
+	inflight int
+	// minLatency is the lowest latency seen, it slowly follows latencies
+	// up, so a permanent change of the service is learned.
+	minLatency time.Duration
+	lock       sync.Mutex
+}
+
+// NewAdaptive creates an Adaptive limiter configured by cfg, it should
+// be valid.
+//
+// NewAdaptive создает ограничитель Adaptive, настроенный cfg, которая должна
+// быть корректна.
+func NewAdaptive(cfg AdaptiveConfig) *Adaptive {
+	if cfg.Min == 0 {
+		cfg.Min = AdaptiveMin
+	}
+	if cfg.Max == 0 {
+		cfg.Max = AdaptiveMax
+	}
+	if cfg.Initial == 0 {
+		cfg.Initial = AdaptiveInitial
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = AdaptiveTolerance
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = AdaptiveBackoff
+	}
+	return &Adaptive{
+		conf:  cfg,
+		limit: math.Max(float64(cfg.Min), math.Min(float64(cfg.Max), float64(cfg.Initial))),
+	}
+}
+
+// Acquire reports whether one more request may be sent now and accounts it
+// if so, then Release should be called when it completes.
+//
+// Acquire сообщает, можно ли сейчас отправить еще один запрос, и учитывает
+// его, если да, тогда по его завершении следует вызвать Release.
+func (a *Adaptive) Acquire() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if float64(a.inflight) >= math.Floor(a.limit) {
+		return false
+	}
+	a.inflight++
+	return true
+}
+
+// Release accounts completion of a request acquired with Acquire which took
+// latency, overloaded tells whether the service reported overload.
+//
+// Release учитывает завершение запроса, полученного с помощью Acquire,
+// занявшего latency, overloaded сообщает, сообщил ли сервис о перегрузке.
+func (a *Adaptive) Release(latency time.Duration, overloaded bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.inflight--
+
+	switch {
+	case a.minLatency == 0 || latency < a.minLatency:
+		a.minLatency = latency
+	default:
+		a.minLatency += (latency - a.minLatency) / 100
+	}
+	if overloaded || float64(latency) > a.conf.Tolerance*float64(a.minLatency) {
+		a.limit = math.Max(float64(a.conf.Min), a.limit*a.conf.Backoff)
+		return
+	}
+	a.limit = math.Min(float64(a.conf.Max), a.limit+1/a.limit)
+}
+
+// Limit returns the current limit.
+//
+// Limit возвращает текущее ограничение.
+func (a *Adaptive) Limit() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return int(a.limit)
+}
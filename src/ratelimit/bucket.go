@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a Limiter refilled with rate tokens per second up to burst.
+// WaitN may take more tokens than burst, delaying the following events,
+// so it suits throttling transfers of arbitrary sized chunks.
+//
+// TokenBucket -- Limiter, пополняемый rate токенами в секунду вплоть до burst.
+// WaitN может взять больше токенов, чем burst, задерживая следующие события,
+// поэтому подходит для замедления передачи блоков произвольного размера.
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	lock   sync.Mutex
+}
+
+// NewTokenBucket creates a full TokenBucket.
+//
+// NewTokenBucket создает полный TokenBucket.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// refill adds tokens accumulated since the last call.
+// Should be called with b.lock held.
+func (b *TokenBucket) refill() {
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// AllowN takes n tokens if there are enough of them.
+//
+// AllowN берет n токенов, если их достаточно.
+func (b *TokenBucket) AllowN(n int) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill()
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// WaitN takes n tokens and waits until the debt is refilled. The tokens
+// are returned if ctx is done first.
+//
+// WaitN берет n токенов и ждет, пока долг не будет восполнен. Токены
+// возвращаются, если ctx завершится раньше.
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	b.refill()
+	b.tokens -= float64(n)
+	debt := -b.tokens
+	b.lock.Unlock()
+
+	if debt <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, time.Duration(debt/b.rate*float64(time.Second))); err != nil {
+		b.lock.Lock()
+		b.tokens += float64(n)
+		b.lock.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package ratelimit provides rate limiters shared by services: nodes admit
+// requests with them, frontends push back on clients and bulk transfers,
+// like compaction and restore, are throttled. A limiter is selected in
+// config: a token bucket or a sliding window.
+//
+// Package ratelimit предоставляет ограничители скорости, общие для сервисов:
+// node ограничивают ими прием запросов, frontend -- запросы клиентов,
+// а массовые передачи данных, такие как компактификация и восстановление,
+// замедляются. Ограничитель выбирается в конфигурации: token bucket или
+// sliding window.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrExceedsLimit is returned by WaitN if n events can never be allowed at once.
+//
+// ErrExceedsLimit возвращается WaitN, если n событий никогда не могут быть
+// разрешены одновременно.
+var ErrExceedsLimit = errors.New("Number of events exceeds the limit")
+
+// Limiter is the common interface of rate limiters.
+//
+// Limiter это общий интерфейс ограничителей скорости.
+type Limiter interface {
+	// AllowN reports whether n events may happen now and accounts them if so.
+	AllowN(n int) bool
+	// WaitN blocks until n events may happen or ctx is done.
+	WaitN(ctx context.Context, n int) error
+}
+
+const (
+	KindNone          = ""
+	KindTokenBucket   = "token_bucket"
+	KindSlidingWindow = "sliding_window"
+)
+
+// Config stores configuration of a rate limiter.
+//
+// Config -- содержит конфигурацию ограничителя скорости.
+type Config struct {
+	// Kind is a kind of the limiter: token_bucket or sliding_window,
+	// events are not limited if it is empty.
+	// Kind -- вид ограничителя: token_bucket или sliding_window,
+	// если не задан, события не ограничиваются.
+	Kind string
+	// Rate is a number of events per second allowed on average.
+	// Rate -- среднее разрешенное количество событий в секунду.
+	Rate float64
+	// Burst is a number of events a token bucket allows at once,
+	// Rate rounded up by default.
+	// Burst -- количество событий, разрешаемых token bucket за раз,
+	// по умолчанию Rate, округленный вверх.
+	Burst int
+	// Window is a length of a sliding window, a second by default.
+	// Window -- длина sliding window, по умолчанию секунда.
+	Window time.Duration
+}
+
+// Validate checks that cfg is consistent.
+//
+// Validate проверяет, что cfg непротиворечива.
+func (cfg Config) Validate() error {
+	switch cfg.Kind {
+	case KindNone:
+		return nil
+	case KindTokenBucket, KindSlidingWindow:
+	default:
+		return fmt.Errorf("Unknown rate limiter %q", cfg.Kind)
+	}
+	if cfg.Rate <= 0 {
+		return fmt.Errorf("Rate of %q rate limiter should be positive, got %v", cfg.Kind, cfg.Rate)
+	}
+	if cfg.Burst < 0 {
+		return fmt.Errorf("Burst of %q rate limiter should not be negative, got %v", cfg.Kind, cfg.Burst)
+	}
+	if cfg.Window < 0 {
+		return fmt.Errorf("Window of %q rate limiter should not be negative, got %v", cfg.Kind, cfg.Window)
+	}
+	return nil
+}
+
+// RetryAfter returns the time it takes to allow n more events on average,
+// a hint for how long clients rejected over the limit should wait.
+//
+// RetryAfter возвращает время, за которое в среднем разрешается еще n
+// событий, -- подсказку, сколько следует подождать клиентам, получившим
+// отказ сверх ограничения.
+func (cfg Config) RetryAfter(n int) time.Duration {
+	if cfg.Kind == KindNone || cfg.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / cfg.Rate * float64(time.Second))
+}
+
+// New creates a Limiter selected by cfg.
+//
+// New создает Limiter, выбранный в cfg.
+func New(cfg Config) (Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Kind {
+	case KindTokenBucket:
+		burst := cfg.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(cfg.Rate))
+		}
+		return NewTokenBucket(cfg.Rate, burst), nil
+	case KindSlidingWindow:
+		window := cfg.Window
+		if window == 0 {
+			window = time.Second
+		}
+		limit := int(math.Ceil(cfg.Rate * window.Seconds()))
+		return NewSlidingWindow(limit, window), nil
+	default:
+		return Unlimited, nil
+	}
+}
+
+// Unlimited is a Limiter allowing all events.
+//
+// Unlimited -- Limiter, разрешающий все события.
+var Unlimited Limiter = unlimited{}
+
+type unlimited struct{}
+
+func (unlimited) AllowN(n int) bool                      { return true }
+func (unlimited) WaitN(ctx context.Context, n int) error { return ctx.Err() }
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	b := NewTokenBucket(10, 5)
+	b.now, b.last = clock.Now, clock.now
+
+	for i := 0; i < 5; i++ {
+		if !b.AllowN(1) {
+			t.Fatalf("AllowN(1) #%v got false within burst", i)
+		}
+	}
+	if b.AllowN(1) {
+		t.Fatalf("AllowN(1) got true with empty bucket")
+	}
+	clock.now = clock.now.Add(200 * time.Millisecond)
+	if !b.AllowN(2) || b.AllowN(1) {
+		t.Errorf("AllowN() should allow 2 tokens refilled in 200ms")
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if b.AllowN(6) {
+		t.Errorf("AllowN(6) got true beyond burst")
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	b := NewTokenBucket(100, 10)
+	start := time.Now()
+	// Taking more than burst is allowed, the debt of 10 tokens is waited for.
+	if err := b.WaitN(context.Background(), 20); err != nil {
+		t.Fatalf("WaitN() error: %v", err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond || d > time.Second {
+		t.Errorf("WaitN() waited %v, want about 100ms", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.WaitN(ctx, 100); err != context.DeadlineExceeded {
+		t.Fatalf("WaitN() got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Tokens of the cancelled wait are returned.
+	if err := b.WaitN(context.Background(), 1); err != nil {
+		t.Fatalf("WaitN() error: %v", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("WaitN() after cancellation waited too long, %v in total", d)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	w := NewSlidingWindow(10, time.Second)
+	w.now, w.start = clock.Now, clock.now
+
+	if !w.AllowN(10) || w.AllowN(1) {
+		t.Fatalf("AllowN() should allow exactly 10 events in a window")
+	}
+	// Half of the previous window slid out, so 5 of its events are counted.
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	if !w.AllowN(5) || w.AllowN(1) {
+		t.Errorf("AllowN() should allow exactly 5 events in the middle of the next window")
+	}
+	if d := w.wait(1); d != 100*time.Millisecond {
+		t.Errorf("wait(1) got %v, want %v", d, 100*time.Millisecond)
+	}
+	clock.now = clock.now.Add(2 * time.Second)
+	if !w.AllowN(10) {
+		t.Errorf("AllowN(10) got false after two idle windows")
+	}
+	if err := w.WaitN(context.Background(), 11); err != ErrExceedsLimit {
+		t.Errorf("WaitN(11) got error %v, want %v", err, ErrExceedsLimit)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, test := range []struct {
+		cfg  Config
+		want interface{}
+		err  bool
+	}{
+		{cfg: Config{}, want: Unlimited},
+		{cfg: Config{Kind: KindTokenBucket, Rate: 2.5}, want: &TokenBucket{}},
+		{cfg: Config{Kind: KindSlidingWindow, Rate: 10, Window: time.Minute}, want: &SlidingWindow{}},
+		{cfg: Config{Kind: KindTokenBucket}, err: true},
+		{cfg: Config{Kind: KindSlidingWindow, Rate: 1, Window: -time.Second}, err: true},
+		{cfg: Config{Kind: "leaky_bucket", Rate: 1}, err: true},
+	} {
+		l, err := New(test.cfg)
+		if (err != nil) != test.err {
+			t.Errorf("New(%+v) got error %v, want error %v", test.cfg, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		switch want := test.want.(type) {
+		case *TokenBucket:
+			if b, ok := l.(*TokenBucket); !ok || b.burst != 3 {
+				t.Errorf("New(%+v) got %#v, want token bucket with burst 3", test.cfg, l)
+			}
+		case *SlidingWindow:
+			if w, ok := l.(*SlidingWindow); !ok || w.limit != 600 {
+				t.Errorf("New(%+v) got %#v, want sliding window with limit 600", test.cfg, l)
+			}
+		default:
+			if l != want {
+				t.Errorf("New(%+v) got %#v, want %#v", test.cfg, l, want)
+			}
+		}
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{Enabled: true, Initial: 2, Max: 3})
+	if !a.Acquire() || !a.Acquire() {
+		t.Fatalf("Acquire() got false within the initial limit")
+	}
+	if a.Acquire() {
+		t.Fatalf("Acquire() got true over the limit")
+	}
+
+	// Requests completing in time grow the limit by one per limit of them.
+	a.Release(10*time.Millisecond, false)
+	a.Release(10*time.Millisecond, false)
+	if limit := a.Limit(); limit != 2 {
+		t.Errorf("Limit() got %v, want 2 after fewer than 3 requests in time", limit)
+	}
+	for i := 0; i < 10; i++ {
+		a.Acquire()
+		a.Release(10*time.Millisecond, false)
+	}
+	if limit := a.Limit(); limit != 3 {
+		t.Errorf("Limit() got %v, want it capped at Max 3", limit)
+	}
+
+	a.Acquire()
+	a.Release(50*time.Millisecond, false)
+	if limit := a.Limit(); limit != 2 {
+		t.Errorf("Limit() got %v, want 2 after a slow request", limit)
+	}
+	for i := 0; i < 20; i++ {
+		a.Acquire()
+		a.Release(10*time.Millisecond, true)
+	}
+	if limit := a.Limit(); limit != 1 {
+		t.Errorf("Limit() got %v, want it bounded by Min 1 on overload", limit)
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// SlidingWindow is a Limiter allowing limit events in any window of time.
+// Events of the previous window are assumed to be spread evenly, so only
+// counters of two windows are kept.
+//
+// SlidingWindow -- Limiter, разрешающий limit событий в любом окне времени.
+// События предыдущего окна считаются распределенными равномерно, поэтому
+// хранятся только счетчики двух окон.
+type SlidingWindow struct {
+	limit  float64
+	window time.Duration
+	start  time.Time
+	prev   float64
+	cur    float64
+	now    func() time.Time
+	lock   sync.Mutex
+}
+
+// NewSlidingWindow creates an empty SlidingWindow.
+//
+// NewSlidingWindow создает пустой SlidingWindow.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{
+		limit:  float64(limit),
+		window: window,
+		start:  time.Now(),
+		now:    time.Now,
+	}
+}
+
+// advance moves the current window to now and returns the part of it passed.
+// Should be called with w.lock held.
+func (w *SlidingWindow) advance() float64 {
+	now := w.now()
+	switch passed := now.Sub(w.start) / w.window; {
+	case passed == 1:
+		w.prev, w.cur = w.cur, 0
+		w.start = w.start.Add(w.window)
+	case passed > 1:
+		w.prev, w.cur = 0, 0
+		w.start = w.start.Add(passed * w.window)
+	}
+	return float64(now.Sub(w.start)) / float64(w.window)
+}
+
+// wait returns how long n events should wait to fit the limit.
+// Should be called with w.lock held.
+func (w *SlidingWindow) wait(n float64) time.Duration {
+	part := w.advance()
+	if w.prev*(1-part)+w.cur+n <= w.limit {
+		return 0
+	}
+	if w.cur+n > w.limit || w.prev == 0 {
+		// Only the next window may have room.
+		return w.part(1 - part)
+	}
+	// The previous window should slide out enough.
+	need := 1 - (w.limit-w.cur-n)/w.prev
+	return w.part(need - part)
+}
+
+// part returns the given part of the window rounded up.
+func (w *SlidingWindow) part(p float64) time.Duration {
+	return time.Duration(math.Ceil(p * float64(w.window)))
+}
+
+// AllowN accounts n events if they fit the limit.
+//
+// AllowN учитывает n событий, если они укладываются в ограничение.
+func (w *SlidingWindow) AllowN(n int) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.wait(float64(n)) > 0 {
+		return false
+	}
+	w.cur += float64(n)
+	return true
+}
+
+// WaitN waits until n events fit the limit and accounts them. Returns
+// ErrExceedsLimit if n is larger than the limit.
+//
+// WaitN ждет, пока n событий не уложатся в ограничение, и учитывает их.
+// Возвращает ErrExceedsLimit, если n больше ограничения.
+func (w *SlidingWindow) WaitN(ctx context.Context, n int) error {
+	if float64(n) > w.limit {
+		return ErrExceedsLimit
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for {
+		w.lock.Lock()
+		d := w.wait(float64(n))
+		if d <= 0 {
+			w.cur += float64(n)
+		}
+		w.lock.Unlock()
+		if d <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"storage"
+)
+
+var httpClient = http.Client{Timeout: storage.Timeout}
+
+// Consul registers nodes as instances of Service with TTL health checks
+// in a consul agent listening at Addr.
+//
+// Consul регистрирует nodes как экземпляры Service с проверками
+// доступности по TTL в агенте consul, слушающем адрес Addr.
+type Consul struct {
+	Addr    string
+	Service string
+}
+
+func (c Consul) do(method, path string, body interface{}, reply interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", c.Addr, path), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Consul replied %v", resp.Status)
+	}
+	if reply != nil {
+		return json.NewDecoder(resp.Body).Decode(reply)
+	}
+	return nil
+}
+
+// Register registers node and marks its health check passing for ttl.
+func (c Consul) Register(node storage.ServiceAddr, ttl time.Duration) error {
+	host, port, err := net.SplitHostPort(string(node))
+	if err != nil {
+		return err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+
+	id := c.Service + "-" + string(node)
+	service := map[string]interface{}{
+		"ID":      id,
+		"Name":    c.Service,
+		"Address": host,
+		"Port":    p,
+		"Check": map[string]string{
+			"TTL":                            ttl.String(),
+			"DeregisterCriticalServiceAfter": (10 * ttl).String(),
+		},
+	}
+	if err := c.do("PUT", "/v1/agent/service/register", service, nil); err != nil {
+		return err
+	}
+	return c.do("PUT", "/v1/agent/check/pass/service:"+id, nil, nil)
+}
+
+// Nodes returns instances of Service with passing health checks.
+func (c Consul) Nodes() ([]storage.ServiceAddr, error) {
+	var entries []struct {
+		Node struct {
+			Address string
+		}
+		Service struct {
+			Address string
+			Port    int
+		}
+	}
+	if err := c.do("GET", "/v1/health/service/"+c.Service+"?passing", nil, &entries); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]storage.ServiceAddr, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		nodes = append(nodes, storage.ServiceAddr(net.JoinHostPort(host, strconv.Itoa(e.Service.Port))))
+	}
+	return nodes, nil
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"storage"
+)
+
+// Etcd registers nodes as keys Prefix+node attached to leases
+// through the etcd v3 JSON gateway listening at Addr.
+//
+// Etcd регистрирует nodes как ключи Prefix+node, привязанные к lease,
+// через шлюз JSON etcd v3, слушающий адрес Addr.
+type Etcd struct {
+	Addr   string
+	Prefix string
+}
+
+func (e Etcd) do(path string, body interface{}, reply interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(fmt.Sprintf("http://%s%s", e.Addr, path), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Etcd replied %v", resp.Status)
+	}
+	if reply != nil {
+		return json.NewDecoder(resp.Body).Decode(reply)
+	}
+	return nil
+}
+
+// Register puts the key of node attached to a new lease for ttl.
+// The previous lease of the node expires on its own.
+func (e Etcd) Register(node storage.ServiceAddr, ttl time.Duration) error {
+	var lease struct {
+		ID string
+	}
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if err := e.do("/v3/lease/grant", map[string]int64{"TTL": seconds}, &lease); err != nil {
+		return err
+	}
+
+	// []byte values are encoded in base64 as the gateway expects.
+	return e.do("/v3/kv/put", map[string]interface{}{
+		"key":   []byte(e.Prefix + string(node)),
+		"value": []byte(node),
+		"lease": lease.ID,
+	}, nil)
+}
+
+// Nodes returns values of all keys having Prefix.
+func (e Etcd) Nodes() ([]storage.ServiceAddr, error) {
+	var reply struct {
+		Kvs []struct {
+			Value []byte
+		}
+	}
+	if err := e.do("/v3/kv/range", map[string][]byte{
+		"key":       []byte(e.Prefix),
+		"range_end": prefixEnd([]byte(e.Prefix)),
+	}, &reply); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]storage.ServiceAddr, 0, len(reply.Kvs))
+	for _, kv := range reply.Kvs {
+		nodes = append(nodes, storage.ServiceAddr(kv.Value))
+	}
+	return nodes, nil
+}
+
+// prefixEnd returns the smallest key greater than all keys having prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All keys are greater than or equal to the empty prefix.
+	return []byte{0}
+}
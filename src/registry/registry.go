@@ -0,0 +1,122 @@
+// Package registry integrates services with Consul or etcd service
+// registries. Nodes register themselves in a registry and the router
+// builds its set of nodes from it instead of a static list.
+//
+// Package registry интегрирует сервисы с реестрами сервисов Consul
+// или etcd. Nodes регистрируются в реестре, а router строит по нему
+// список node вместо статического списка.
+package registry
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"storage"
+)
+
+// DefaultTTL is a default time a node registration is valid for.
+//
+// DefaultTTL -- время действия регистрации node по умолчанию.
+const DefaultTTL = 10 * time.Second
+
+// DefaultInterval is a default time interval between reconciliations
+// of the router node set with a registry.
+//
+// DefaultInterval -- интервал по умолчанию между сверками списка node
+// в router с реестром.
+const DefaultInterval = 10 * time.Second
+
+// Config stores configuration of a registry.
+//
+// Config -- содержит конфигурацию реестра.
+type Config struct {
+	// Kind is a kind of the registry, "consul" or "etcd". Registry is not
+	// used if it is empty.
+	// Kind -- тип реестра, "consul" или "etcd". Если не задан, реестр
+	// не используется.
+	Kind string
+	// Addr is an address of the consul agent or the etcd gateway.
+	// Addr -- адрес агента consul или шлюза etcd.
+	Addr string
+	// Service is a name of the consul service or the prefix of etcd keys
+	// nodes are registered under.
+	// Service -- имя сервиса consul или префикс ключей etcd, под которым
+	// регистрируются nodes.
+	Service string
+	// TTL is a time a node registration is valid for.
+	// TTL -- время действия регистрации node.
+	TTL time.Duration
+	// Interval is a time interval between reconciliations of the router
+	// node set with the registry.
+	// Interval -- интервал между сверками списка node в router с реестром.
+	Interval time.Duration
+}
+
+// Registry is the common interface of service registries.
+//
+// Registry -- общий интерфейс реестров сервисов.
+type Registry interface {
+	// Register registers node for ttl.
+	Register(node storage.ServiceAddr, ttl time.Duration) error
+	// Nodes returns all registered nodes.
+	Nodes() ([]storage.ServiceAddr, error)
+}
+
+// New creates a Registry described by cfg. Returns nil if cfg.Kind is empty.
+//
+// New создает Registry, описанный cfg. Возвращает nil, если cfg.Kind не задан.
+func New(cfg Config) (Registry, error) {
+	switch cfg.Kind {
+	case "":
+		return nil, nil
+	case "consul":
+		return Consul{Addr: cfg.Addr, Service: cfg.Service}, nil
+	case "etcd":
+		return Etcd{Addr: cfg.Addr, Prefix: cfg.Service}, nil
+	}
+	return nil, fmt.Errorf("Unknown registry %q", cfg.Kind)
+}
+
+// Keep registers node in reg and refreshes the registration
+// in the background until the process exits.
+//
+// Keep регистрирует node в reg и обновляет регистрацию в фоне
+// до завершения процесса.
+func Keep(reg Registry, node storage.ServiceAddr, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	go func() {
+		for {
+			if err := reg.Register(node, ttl); err != nil {
+				log.Printf("Failed to register %q: %v", node, err)
+			}
+			time.Sleep(ttl / 3)
+		}
+	}()
+}
+
+// Watch calls update with the nodes registered in reg each interval
+// in the background until the process exits.
+//
+// Watch вызывает update со списком node, зарегистрированных в reg,
+// через каждый интервал interval в фоне до завершения процесса.
+func Watch(reg Registry, interval time.Duration, update func(nodes []storage.ServiceAddr) error) {
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	go func() {
+		for {
+			time.Sleep(interval)
+			nodes, err := reg.Nodes()
+			if err != nil {
+				log.Printf("Failed to list registered nodes: %v", err)
+				continue
+			}
+			if err := update(nodes); err != nil {
+				log.Printf("Failed to update nodes to %v: %v", nodes, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"storage"
+)
+
+// fakeConsul implements the parts of consul agent API used by Consul.
+type fakeConsul struct {
+	lock     sync.Mutex
+	services map[string]map[string]interface{}
+	passing  map[string]bool
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/agent/service/register":
+		var service map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&service)
+		f.services[service["ID"].(string)] = service
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/pass/service:"):
+		f.passing[strings.TrimPrefix(r.URL.Path, "/v1/agent/check/pass/service:")] = true
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		entries := []interface{}{}
+		for id, s := range f.services {
+			if f.passing[id] {
+				entries = append(entries, map[string]interface{}{"Service": s})
+			}
+		}
+		json.NewEncoder(w).Encode(entries)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// fakeEtcd implements the parts of etcd v3 gateway API used by Etcd.
+type fakeEtcd struct {
+	lock sync.Mutex
+	kvs  map[string][]byte
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var req struct {
+		Key      []byte `json:"key"`
+		RangeEnd []byte `json:"range_end"`
+		Value    []byte `json:"value"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		json.NewEncoder(w).Encode(map[string]string{"ID": "1"})
+	case "/v3/kv/put":
+		f.kvs[string(req.Key)] = req.Value
+	case "/v3/kv/range":
+		type kv struct {
+			Value []byte `json:"value"`
+		}
+		reply := struct {
+			Kvs []kv `json:"kvs"`
+		}{}
+		for k, v := range f.kvs {
+			if k >= string(req.Key) && k < string(req.RangeEnd) {
+				reply.Kvs = append(reply.Kvs, kv{Value: v})
+			}
+		}
+		json.NewEncoder(w).Encode(reply)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func testRegistry(t *testing.T, reg Registry) {
+	want := []storage.ServiceAddr{"127.0.0.1:7321", "127.0.0.1:7322"}
+	for _, node := range want {
+		if err := reg.Register(node, time.Second); err != nil {
+			t.Fatalf("Register() error: %v", err)
+		}
+	}
+
+	got, err := reg.Nodes()
+	if err != nil {
+		t.Fatalf("Nodes() error: %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Nodes() got %v, want %v", got, want)
+	}
+}
+
+func TestConsul(t *testing.T) {
+	srv := httptest.NewServer(&fakeConsul{
+		services: make(map[string]map[string]interface{}),
+		passing:  make(map[string]bool),
+	})
+	defer srv.Close()
+
+	reg, err := New(Config{Kind: "consul", Addr: strings.TrimPrefix(srv.URL, "http://"), Service: "ddsp-node"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	testRegistry(t, reg)
+}
+
+func TestEtcd(t *testing.T) {
+	f := &fakeEtcd{kvs: make(map[string][]byte)}
+	f.kvs["other/127.0.0.1:7323"] = []byte("127.0.0.1:7323")
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	reg, err := New(Config{Kind: "etcd", Addr: strings.TrimPrefix(srv.URL, "http://"), Service: "ddsp/nodes/"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	testRegistry(t, reg)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	for prefix, want := range map[string]string{
+		"a/":       "a0",
+		"a\xff":    "b",
+		"\xff\xff": "\x00",
+		"":         "\x00",
+	} {
+		if got := string(prefixEnd([]byte(prefix))); got != want {
+			t.Errorf("prefixEnd(%q) got %q, want %q", prefix, got, want)
+		}
+	}
+}
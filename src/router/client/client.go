@@ -2,7 +2,6 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 
@@ -13,12 +12,35 @@ import (
 )
 
 type Client interface {
-	Heartbeat(router, node storage.ServiceAddr) error
+	Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error
+	HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error)
+	Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error)
 	NodesFind(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error)
 	List(router storage.ServiceAddr) ([]storage.ServiceAddr, error)
+	Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error)
 }
 
-type RouterClient struct{}
+// ContextClient is a Client which can make requests on behalf of a context.
+type ContextClient interface {
+	Client
+	// WithContext returns a copy of the client whose requests are canceled
+	// once ctx is done.
+	WithContext(ctx context.Context) Client
+}
+
+// WithContext returns c making requests canceled once ctx is done if it is
+// a ContextClient, and c otherwise.
+func WithContext(c Client, ctx context.Context) Client {
+	if cc, ok := c.(ContextClient); ok {
+		return cc.WithContext(ctx)
+	}
+	return c
+}
+
+type RouterClient struct {
+	resolver storage.Resolver
+	ctx      context.Context
+}
 
 var defaultClient Client = RouterClient{}
 
@@ -26,10 +48,33 @@ func New() Client {
 	return defaultClient
 }
 
+// NewWithResolver creates a Client resolving router addresses with r.
+func NewWithResolver(r storage.Resolver) Client {
+	return RouterClient{resolver: r}
+}
+
+// WithContext returns a copy of c whose requests are canceled once ctx is
+// done.
+func (c RouterClient) WithContext(ctx context.Context) Client {
+	c.ctx = ctx
+	return c
+}
+
+func (c RouterClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c RouterClient) do(addr storage.ServiceAddr, cb func(client pb.RouterClient) ([]storage.ServiceAddr, error)) ([]storage.ServiceAddr, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), storage.Timeout)
+	target, err := storage.ResolveAddr(c.resolver, addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
 	defer cancel()
-	conn, err := grpc.DialContext(ctx, string(addr), grpc.WithInsecure())
+	conn, err := grpc.DialContext(ctx, target, grpc.WithInsecure())
 	if err != nil {
 		return nil, fmt.Errorf("Error dialing %q: %v", addr, err)
 	}
@@ -38,13 +83,15 @@ func (c RouterClient) do(addr storage.ServiceAddr, cb func(client pb.RouterClien
 	return cb(client)
 }
 
-func (c RouterClient) Heartbeat(router, node storage.ServiceAddr) error {
+func (c RouterClient) Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error {
 	log.Printf("Hearbeat request to %q", router)
 	_, err := c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), storage.Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
 		defer cancel()
 		req := pb.HBRequest{
-			Node: string(node),
+			Node:     string(hb.Node),
+			Degraded: hb.Degraded,
+			Seq:      hb.Seq,
 		}
 		reply, err := client.Heartbeat(ctx, &req)
 		if err != nil {
@@ -57,18 +104,62 @@ func (c RouterClient) Heartbeat(router, node storage.ServiceAddr) error {
 			return nil, nil
 		}
 
-		if err := status.ToError(); err != storage.ErrUnknownStatus {
+		return nil, storage.UnmarshalError(status, reply.Error)
+	})
+	return err
+}
+
+func (c RouterClient) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	log.Printf("Hearbeat batch request to %q: nodes = %v", router, len(beats))
+	var errs []error
+	_, err := c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
+		defer cancel()
+		req := pb.HBBatchRequest{
+			Nodes: make([]*pb.HBRequest, 0, len(beats)),
+		}
+		for _, beat := range beats {
+			req.Nodes = append(req.Nodes, &pb.HBRequest{
+				Node:     string(beat.Node),
+				Degraded: beat.Degraded,
+				Seq:      beat.Seq,
+			})
+		}
+		reply, err := client.HeartbeatBatch(ctx, &req)
+		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New(reply.Error)
+
+		status := storage.StatusCode(reply.Status)
+
+		if status == storage.StatusOk {
+			if len(reply.Nodes) != len(beats) {
+				return nil, fmt.Errorf("Wrong number of heartbeat replies: got %v, want %v", len(reply.Nodes), len(beats))
+			}
+			errs = make([]error, 0, len(reply.Nodes))
+			for _, hb := range reply.Nodes {
+				errs = append(errs, hbError(hb))
+			}
+			return nil, nil
+		}
+
+		return nil, storage.UnmarshalError(status, reply.Error)
 	})
-	return err
+	return errs, err
+}
+
+func hbError(reply *pb.HBReply) error {
+	status := storage.StatusCode(reply.Status)
+	if status == storage.StatusOk {
+		return nil
+	}
+	return storage.UnmarshalError(status, reply.Error)
 }
 
 func (c RouterClient) NodesFind(router storage.ServiceAddr, k storage.RecordID) ([]storage.ServiceAddr, error) {
 	log.Printf("NodesFind request: key = %v", k)
 	return c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), storage.Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
 		defer cancel()
 		req := pb.NFRequest{
 			Key: uint32(k),
@@ -88,17 +179,14 @@ func (c RouterClient) NodesFind(router storage.ServiceAddr, k storage.RecordID)
 			return nodes, nil
 		}
 
-		if err := status.ToError(); err != storage.ErrUnknownStatus {
-			return nil, err
-		}
-		return nil, errors.New(reply.Error)
+		return nil, storage.UnmarshalError(status, reply.Error)
 	})
 }
 
 func (c RouterClient) List(router storage.ServiceAddr) ([]storage.ServiceAddr, error) {
 	log.Printf("List request")
 	return c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), storage.Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
 		defer cancel()
 		reply, err := client.List(ctx, &pb.Empty{})
 		if err != nil {
@@ -115,9 +203,69 @@ func (c RouterClient) List(router storage.ServiceAddr) ([]storage.ServiceAddr, e
 			return nodes, nil
 		}
 
-		if err := status.ToError(); err != storage.ErrUnknownStatus {
+		return nil, storage.UnmarshalError(status, reply.Error)
+	})
+}
+
+func (c RouterClient) Orphans(router, node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	log.Printf("Orphans request: node = %q, keys = %v", node, len(keys))
+	var orphans []storage.RecordID
+	_, err := c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
+		defer cancel()
+		req := pb.OrphansRequest{
+			Node: string(node),
+			Keys: make([]uint32, 0, len(keys)),
+		}
+		for _, k := range keys {
+			req.Keys = append(req.Keys, uint32(k))
+		}
+		reply, err := client.Orphans(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		status := storage.StatusCode(reply.Status)
+
+		if status == storage.StatusOk {
+			orphans = make([]storage.RecordID, 0, len(reply.Keys))
+			for _, k := range reply.Keys {
+				orphans = append(orphans, storage.RecordID(k))
+			}
+			return nil, nil
+		}
+
+		return nil, storage.UnmarshalError(status, reply.Error)
+	})
+	return orphans, err
+}
+
+func (c RouterClient) Heartbeats(router storage.ServiceAddr) ([]storage.Heartbeat, error) {
+	log.Printf("Heartbeats request to %q", router)
+	var beats []storage.Heartbeat
+	_, err := c.do(router, func(client pb.RouterClient) ([]storage.ServiceAddr, error) {
+		ctx, cancel := context.WithTimeout(c.context(), storage.Timeout)
+		defer cancel()
+		reply, err := client.Heartbeats(ctx, &pb.Empty{})
+		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New(reply.Error)
+
+		status := storage.StatusCode(reply.Status)
+
+		if status == storage.StatusOk {
+			beats = make([]storage.Heartbeat, 0, len(reply.Nodes))
+			for _, hb := range reply.Nodes {
+				beats = append(beats, storage.Heartbeat{
+					Node:     storage.ServiceAddr(hb.Node),
+					Degraded: hb.Degraded,
+					Seq:      hb.Seq,
+				})
+			}
+			return nil, nil
+		}
+
+		return nil, storage.UnmarshalError(status, reply.Error)
 	})
+	return beats, err
 }
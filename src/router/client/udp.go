@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"storage"
+)
+
+// HeartbeatMaxSkew is the maximum difference between the time a UDP
+// heartbeat was sent and the time it was received. Older packets are
+// dropped, so captured packets can't be replayed later.
+const HeartbeatMaxSkew = 30 * time.Second
+
+const (
+	// udpVersion 2 adds the sequence number after the timestamp,
+	// packets of version 1 are still accepted.
+	udpVersion   = 2
+	flagDegraded = 1
+
+	// version, flags, timestamp
+	udpHeaderSize = 1 + 1 + 8
+	// udpHeaderSize, seq
+	udpHeaderSizeV2 = udpHeaderSize + 8
+)
+
+var ErrBadHeartbeat = errors.New("Bad heartbeat packet")
+
+// EncodeHeartbeat returns a UDP packet carrying hb sent at now
+// authenticated with key.
+func EncodeHeartbeat(key []byte, hb storage.Heartbeat, now time.Time) []byte {
+	packet := make([]byte, udpHeaderSizeV2, udpHeaderSizeV2+len(hb.Node)+sha256.Size)
+	packet[0] = udpVersion
+	if hb.Degraded {
+		packet[1] |= flagDegraded
+	}
+	binary.LittleEndian.PutUint64(packet[2:], uint64(now.UnixNano()))
+	binary.LittleEndian.PutUint64(packet[udpHeaderSize:], hb.Seq)
+	packet = append(packet, hb.Node...)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(packet)
+	return mac.Sum(packet)
+}
+
+// DecodeHeartbeat returns a heartbeat carried by packet received at now.
+// Returns ErrBadHeartbeat if the packet is malformed, is not authenticated
+// with key or was sent more than HeartbeatMaxSkew from now.
+func DecodeHeartbeat(key []byte, packet []byte, now time.Time) (storage.Heartbeat, error) {
+	var header int
+	switch {
+	case len(packet) == 0:
+		return storage.Heartbeat{}, ErrBadHeartbeat
+	case packet[0] == 1:
+		header = udpHeaderSize
+	case packet[0] == udpVersion:
+		header = udpHeaderSizeV2
+	default:
+		return storage.Heartbeat{}, ErrBadHeartbeat
+	}
+	if len(packet) < header+sha256.Size {
+		return storage.Heartbeat{}, ErrBadHeartbeat
+	}
+
+	body, sum := packet[:len(packet)-sha256.Size], packet[len(packet)-sha256.Size:]
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return storage.Heartbeat{}, ErrBadHeartbeat
+	}
+
+	sent := time.Unix(0, int64(binary.LittleEndian.Uint64(body[2:])))
+	if skew := now.Sub(sent); skew > HeartbeatMaxSkew || skew < -HeartbeatMaxSkew {
+		return storage.Heartbeat{}, ErrBadHeartbeat
+	}
+
+	hb := storage.Heartbeat{
+		Node:     storage.ServiceAddr(body[header:]),
+		Degraded: body[1]&flagDegraded != 0,
+	}
+	if header == udpHeaderSizeV2 {
+		hb.Seq = binary.LittleEndian.Uint64(body[udpHeaderSize:])
+	}
+	return hb, nil
+}
+
+// UDPClient sends heartbeats over UDP to addr and falls back to RPC
+// heartbeats of the wrapped Client if a packet can't be sent.
+// All other calls are made by the wrapped Client.
+type UDPClient struct {
+	Client
+	addr storage.ServiceAddr
+	key  []byte
+}
+
+func NewUDP(c Client, addr storage.ServiceAddr, key []byte) Client {
+	return UDPClient{
+		Client: c,
+		addr:   addr,
+		key:    key,
+	}
+}
+
+// WithContext returns a copy of c whose calls made by the wrapped Client
+// are canceled once ctx is done.
+func (c UDPClient) WithContext(ctx context.Context) Client {
+	c.Client = WithContext(c.Client, ctx)
+	return c
+}
+
+func (c UDPClient) send(beats []storage.Heartbeat) error {
+	conn, err := net.Dial("udp", string(c.addr))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	now := time.Now()
+	for _, hb := range beats {
+		if _, err := conn.Write(EncodeHeartbeat(c.key, hb, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c UDPClient) Heartbeat(router storage.ServiceAddr, hb storage.Heartbeat) error {
+	err := c.send([]storage.Heartbeat{hb})
+	if err == nil {
+		return nil
+	}
+	log.Printf("Failed to send UDP heartbeat to %q, falling back to RPC: %v", c.addr, err)
+	return c.Client.Heartbeat(router, hb)
+}
+
+func (c UDPClient) HeartbeatBatch(router storage.ServiceAddr, beats []storage.Heartbeat) ([]error, error) {
+	err := c.send(beats)
+	if err == nil {
+		return make([]error, len(beats)), nil
+	}
+	log.Printf("Failed to send UDP heartbeats to %q, falling back to RPC: %v", c.addr, err)
+	return c.Client.HeartbeatBatch(router, beats)
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"storage"
+)
+
+func TestHeartbeatPacket(t *testing.T) {
+	key := []byte("secret")
+	hb := storage.Heartbeat{Node: "127.0.0.1:7321", Degraded: true, Seq: 42}
+	now := time.Now()
+
+	packet := EncodeHeartbeat(key, hb, now)
+	got, err := DecodeHeartbeat(key, packet, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("DecodeHeartbeat() error: %v", err)
+	}
+	if got != hb {
+		t.Errorf("DecodeHeartbeat() got %v, want %v", got, hb)
+	}
+
+	if _, err := DecodeHeartbeat([]byte("wrong"), packet, now); err != ErrBadHeartbeat {
+		t.Errorf("DecodeHeartbeat() with wrong key got error %v, want %v", err, ErrBadHeartbeat)
+	}
+	if _, err := DecodeHeartbeat(key, packet, now.Add(2*HeartbeatMaxSkew)); err != ErrBadHeartbeat {
+		t.Errorf("DecodeHeartbeat() of stale packet got error %v, want %v", err, ErrBadHeartbeat)
+	}
+
+	packet[len(packet)-sha256.Size-1] ^= 1
+	if _, err := DecodeHeartbeat(key, packet, now); err != ErrBadHeartbeat {
+		t.Errorf("DecodeHeartbeat() of tampered packet got error %v, want %v", err, ErrBadHeartbeat)
+	}
+	if _, err := DecodeHeartbeat(key, packet[:5], now); err != ErrBadHeartbeat {
+		t.Errorf("DecodeHeartbeat() of short packet got error %v, want %v", err, ErrBadHeartbeat)
+	}
+}
+
+func TestHeartbeatPacket_V1(t *testing.T) {
+	key := []byte("secret")
+	now := time.Now()
+
+	// Nodes not upgraded yet send packets without a sequence number.
+	packet := make([]byte, udpHeaderSize)
+	packet[0] = 1
+	binary.LittleEndian.PutUint64(packet[2:], uint64(now.UnixNano()))
+	packet = append(packet, "127.0.0.1:7321"...)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(packet)
+	packet = mac.Sum(packet)
+
+	got, err := DecodeHeartbeat(key, packet, now)
+	if err != nil {
+		t.Fatalf("DecodeHeartbeat() error: %v", err)
+	}
+	if want := (storage.Heartbeat{Node: "127.0.0.1:7321"}); got != want {
+		t.Errorf("DecodeHeartbeat() got %v, want %v", got, want)
+	}
+}
+
+func FuzzDecodeHeartbeat(f *testing.F) {
+	key := []byte("secret")
+	now := time.Unix(1600000000, 0)
+	f.Add(EncodeHeartbeat(key, storage.Heartbeat{Node: "127.0.0.1:7321", Degraded: true, Seq: 42}, now))
+	f.Add(EncodeHeartbeat(key, storage.Heartbeat{}, now))
+	f.Add([]byte{})
+	f.Add([]byte{1})
+	f.Add(make([]byte, udpHeaderSizeV2+sha256.Size))
+
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		hb, err := DecodeHeartbeat(key, packet, now)
+		if err != nil {
+			if err != ErrBadHeartbeat {
+				t.Fatalf("DecodeHeartbeat() got error %v, want %v", err, ErrBadHeartbeat)
+			}
+			return
+		}
+		got, err := DecodeHeartbeat(key, EncodeHeartbeat(key, hb, now), now)
+		if err != nil || got != hb {
+			t.Fatalf("DecodeHeartbeat() of reencoded %v got %v, %v", hb, got, err)
+		}
+	})
+}
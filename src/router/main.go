@@ -7,60 +7,124 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 
+	"auth"
+	"fault"
+	"registry"
 	"router/router"
 	"router/server"
+	"secrets"
+	"storage"
 )
 
 func usage() {
 	fmt.Println("router -- service to store cluser scheme of the distributed KV storage")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Printf("%24s\n\n", "router  <conf.yaml>")
+	fmt.Printf("%24s\n\n", "router [conf.yaml]")
+	fmt.Println("Config fields can be set or overridden with environment variables,")
+	fmt.Printf("e.g. %s_ADDR or %s_METRICS_SINK.\n", envPrefix, envPrefix)
 }
 
+// envPrefix is a prefix of environment variables overriding config fields,
+// e.g. DDSP_ROUTER_ADDR.
+const envPrefix = "DDSP_ROUTER"
+
 func parseConfig(fname string) (cfg router.Config, err error) {
-	f, err := os.Open(fname)
-	if err != nil {
-		return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+	src := "environment"
+	if fname != "" {
+		src = fmt.Sprintf("config file %q", fname)
+		f, err := os.Open(fname)
+		if err != nil {
+			return cfg, fmt.Errorf("Failed to open config file %q: %v", fname, err)
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
+		}
 	}
-	defer f.Close()
-	dec := yaml.NewDecoder(f)
-	if err := dec.Decode(&cfg); err != nil {
-		return cfg, fmt.Errorf("Failed to parse config file %q: %v", fname, err)
+	if err := storage.ApplyEnv(envPrefix, &cfg); err != nil {
+		return cfg, fmt.Errorf("Failed to parse environment: %v", err)
 	}
 	if cfg.Addr == "" {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Addr should be set", fname)
-	}
-	if cfg.Nodes == nil {
-		return cfg, fmt.Errorf("Failed to parse config file %q: Nodes should be set", fname)
-	}
-	if cfg.ForgetTimeout == 0 {
-		return cfg, fmt.Errorf("Failed to parse config file %q: ForgetTimeout should be set and be positive", fname)
+		return cfg, fmt.Errorf("Failed to parse %v: Addr should be set", src)
 	}
 
 	return cfg, nil
 }
 
 func main() {
-	if len(os.Args) != 2 {
+	if len(os.Args) > 2 {
 		usage()
 		os.Exit(1)
 	}
 
-	cfg, err := parseConfig(os.Args[1])
+	var fname string
+	if len(os.Args) == 2 {
+		fname = os.Args[1]
+	}
+	raw, err := parseConfig(fname)
 	if err != nil {
 		log.Fatal(err)
 	}
+	provider, err := secrets.New(raw.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to set up secrets: %v", err)
+	}
+	cfg := raw
+	if err := secrets.Resolve(provider, &cfg); err != nil {
+		log.Fatalf("Failed to resolve secrets: %v", err)
+	}
 
-	hasher := router.NewMD5Hasher()
-	cfg.NodesFinder = router.NewNodesFinder(hasher)
+	reg, err := registry.New(cfg.Registry)
+	if err != nil {
+		log.Fatalf("Failed to set up registry: %v", err)
+	}
+	if reg != nil {
+		cfg.Nodes, err = reg.Nodes()
+		if err != nil {
+			log.Fatalf("Failed to list registered nodes: %v", err)
+		}
+	}
 
-	r, err := router.New(cfg)
+	r, err := router.NewDefault(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create router: %v", err)
 	}
 
-	srv := server.New(r, string(cfg.Addr))
+	if reg != nil {
+		registry.Watch(reg, cfg.Registry.Interval, r.SetNodes)
+	}
+	r.HeartbeatAges()
+	r.WatchNodes()
+	r.PersistState()
+
+	authz := auth.New(cfg.Auth)
+	// UDPKey is only resolved at start, since nodes and the router can't
+	// switch to a new one at once.
+	if tokens, ok := authz.(*auth.Tokens); ok {
+		secrets.Watch(provider, raw.Secrets.Interval, raw.Auth, func(resolved interface{}) {
+			tokens.Set(resolved.(auth.Config))
+		})
+	}
+	faults, err := fault.Serve(cfg.Faults, authz)
+	if err != nil {
+		log.Fatalf("Failed to set up failure injection: %v", err)
+	}
+
+	srv := server.New(r, string(cfg.Addr), storage.UnaryInterceptors(faults)...)
+
+	if cfg.UDPAddr != "" {
+		go func() {
+			log.Fatal(srv.ListenAndServeUDP(string(cfg.UDPAddr), []byte(cfg.UDPKey)))
+		}()
+	}
+
+	if cfg.AdminAddr != "" {
+		go func() {
+			log.Fatal(srv.ListenAndServeAdmin(string(cfg.AdminAddr), authz))
+		}()
+	}
 
 	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
@@ -25,6 +25,8 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type HBRequest struct {
 	Node                 string   `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Degraded             bool     `protobuf:"varint,2,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	Seq                  uint64   `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -34,7 +36,7 @@ func (m *HBRequest) Reset()         { *m = HBRequest{} }
 func (m *HBRequest) String() string { return proto.CompactTextString(m) }
 func (*HBRequest) ProtoMessage()    {}
 func (*HBRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{0}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{0}
 }
 func (m *HBRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_HBRequest.Unmarshal(m, b)
@@ -61,6 +63,20 @@ func (m *HBRequest) GetNode() string {
 	return ""
 }
 
+func (m *HBRequest) GetDegraded() bool {
+	if m != nil {
+		return m.Degraded
+	}
+	return false
+}
+
+func (m *HBRequest) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
 type HBReply struct {
 	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
 	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
@@ -73,7 +89,7 @@ func (m *HBReply) Reset()         { *m = HBReply{} }
 func (m *HBReply) String() string { return proto.CompactTextString(m) }
 func (*HBReply) ProtoMessage()    {}
 func (*HBReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{1}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{1}
 }
 func (m *HBReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_HBReply.Unmarshal(m, b)
@@ -107,6 +123,98 @@ func (m *HBReply) GetError() string {
 	return ""
 }
 
+type HBBatchRequest struct {
+	Nodes                []*HBRequest `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *HBBatchRequest) Reset()         { *m = HBBatchRequest{} }
+func (m *HBBatchRequest) String() string { return proto.CompactTextString(m) }
+func (*HBBatchRequest) ProtoMessage()    {}
+func (*HBBatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_8a6d71f288afa543, []int{2}
+}
+func (m *HBBatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HBBatchRequest.Unmarshal(m, b)
+}
+func (m *HBBatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HBBatchRequest.Marshal(b, m, deterministic)
+}
+func (dst *HBBatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HBBatchRequest.Merge(dst, src)
+}
+func (m *HBBatchRequest) XXX_Size() int {
+	return xxx_messageInfo_HBBatchRequest.Size(m)
+}
+func (m *HBBatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HBBatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HBBatchRequest proto.InternalMessageInfo
+
+func (m *HBBatchRequest) GetNodes() []*HBRequest {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+type HBBatchReply struct {
+	Status               int32      `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string     `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Nodes                []*HBReply `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *HBBatchReply) Reset()         { *m = HBBatchReply{} }
+func (m *HBBatchReply) String() string { return proto.CompactTextString(m) }
+func (*HBBatchReply) ProtoMessage()    {}
+func (*HBBatchReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_8a6d71f288afa543, []int{3}
+}
+func (m *HBBatchReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HBBatchReply.Unmarshal(m, b)
+}
+func (m *HBBatchReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HBBatchReply.Marshal(b, m, deterministic)
+}
+func (dst *HBBatchReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HBBatchReply.Merge(dst, src)
+}
+func (m *HBBatchReply) XXX_Size() int {
+	return xxx_messageInfo_HBBatchReply.Size(m)
+}
+func (m *HBBatchReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HBBatchReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HBBatchReply proto.InternalMessageInfo
+
+func (m *HBBatchReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *HBBatchReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *HBBatchReply) GetNodes() []*HBReply {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
 type NFRequest struct {
 	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -118,7 +226,7 @@ func (m *NFRequest) Reset()         { *m = NFRequest{} }
 func (m *NFRequest) String() string { return proto.CompactTextString(m) }
 func (*NFRequest) ProtoMessage()    {}
 func (*NFRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{2}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{4}
 }
 func (m *NFRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_NFRequest.Unmarshal(m, b)
@@ -158,7 +266,7 @@ func (m *NFReply) Reset()         { *m = NFReply{} }
 func (m *NFReply) String() string { return proto.CompactTextString(m) }
 func (*NFReply) ProtoMessage()    {}
 func (*NFReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{3}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{5}
 }
 func (m *NFReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_NFReply.Unmarshal(m, b)
@@ -209,7 +317,7 @@ func (m *Empty) Reset()         { *m = Empty{} }
 func (m *Empty) String() string { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()    {}
 func (*Empty) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{4}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{6}
 }
 func (m *Empty) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Empty.Unmarshal(m, b)
@@ -242,7 +350,7 @@ func (m *ListReply) Reset()         { *m = ListReply{} }
 func (m *ListReply) String() string { return proto.CompactTextString(m) }
 func (*ListReply) ProtoMessage()    {}
 func (*ListReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fb5e298dfa49bbfc, []int{5}
+	return fileDescriptor_pb_8a6d71f288afa543, []int{7}
 }
 func (m *ListReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ListReply.Unmarshal(m, b)
@@ -283,13 +391,172 @@ func (m *ListReply) GetNodes() []string {
 	return nil
 }
 
+type OrphansRequest struct {
+	Node                 string   `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Keys                 []uint32 `protobuf:"varint,2,rep,packed,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OrphansRequest) Reset()         { *m = OrphansRequest{} }
+func (m *OrphansRequest) String() string { return proto.CompactTextString(m) }
+func (*OrphansRequest) ProtoMessage()    {}
+func (*OrphansRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_8a6d71f288afa543, []int{8}
+}
+func (m *OrphansRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrphansRequest.Unmarshal(m, b)
+}
+func (m *OrphansRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrphansRequest.Marshal(b, m, deterministic)
+}
+func (dst *OrphansRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrphansRequest.Merge(dst, src)
+}
+func (m *OrphansRequest) XXX_Size() int {
+	return xxx_messageInfo_OrphansRequest.Size(m)
+}
+func (m *OrphansRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrphansRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrphansRequest proto.InternalMessageInfo
+
+func (m *OrphansRequest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *OrphansRequest) GetKeys() []uint32 {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type OrphansReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Keys                 []uint32 `protobuf:"varint,3,rep,packed,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OrphansReply) Reset()         { *m = OrphansReply{} }
+func (m *OrphansReply) String() string { return proto.CompactTextString(m) }
+func (*OrphansReply) ProtoMessage()    {}
+func (*OrphansReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_8a6d71f288afa543, []int{9}
+}
+func (m *OrphansReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrphansReply.Unmarshal(m, b)
+}
+func (m *OrphansReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrphansReply.Marshal(b, m, deterministic)
+}
+func (dst *OrphansReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrphansReply.Merge(dst, src)
+}
+func (m *OrphansReply) XXX_Size() int {
+	return xxx_messageInfo_OrphansReply.Size(m)
+}
+func (m *OrphansReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrphansReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrphansReply proto.InternalMessageInfo
+
+func (m *OrphansReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *OrphansReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *OrphansReply) GetKeys() []uint32 {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type HeartbeatsReply struct {
+	Status               int32        `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string       `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Nodes                []*HBRequest `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *HeartbeatsReply) Reset()         { *m = HeartbeatsReply{} }
+func (m *HeartbeatsReply) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatsReply) ProtoMessage()    {}
+func (*HeartbeatsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_8a6d71f288afa543, []int{10}
+}
+func (m *HeartbeatsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatsReply.Unmarshal(m, b)
+}
+func (m *HeartbeatsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatsReply.Marshal(b, m, deterministic)
+}
+func (dst *HeartbeatsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatsReply.Merge(dst, src)
+}
+func (m *HeartbeatsReply) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatsReply.Size(m)
+}
+func (m *HeartbeatsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatsReply proto.InternalMessageInfo
+
+func (m *HeartbeatsReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *HeartbeatsReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *HeartbeatsReply) GetNodes() []*HBRequest {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*HBRequest)(nil), "HBRequest")
 	proto.RegisterType((*HBReply)(nil), "HBReply")
+	proto.RegisterType((*HBBatchRequest)(nil), "HBBatchRequest")
+	proto.RegisterType((*HBBatchReply)(nil), "HBBatchReply")
 	proto.RegisterType((*NFRequest)(nil), "NFRequest")
 	proto.RegisterType((*NFReply)(nil), "NFReply")
 	proto.RegisterType((*Empty)(nil), "Empty")
 	proto.RegisterType((*ListReply)(nil), "ListReply")
+	proto.RegisterType((*OrphansRequest)(nil), "OrphansRequest")
+	proto.RegisterType((*OrphansReply)(nil), "OrphansReply")
+	proto.RegisterType((*HeartbeatsReply)(nil), "HeartbeatsReply")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -305,8 +572,11 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type RouterClient interface {
 	Heartbeat(ctx context.Context, in *HBRequest, opts ...grpc.CallOption) (*HBReply, error)
+	HeartbeatBatch(ctx context.Context, in *HBBatchRequest, opts ...grpc.CallOption) (*HBBatchReply, error)
 	NodesFind(ctx context.Context, in *NFRequest, opts ...grpc.CallOption) (*NFReply, error)
 	List(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListReply, error)
+	Orphans(ctx context.Context, in *OrphansRequest, opts ...grpc.CallOption) (*OrphansReply, error)
+	Heartbeats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HeartbeatsReply, error)
 }
 
 type routerClient struct {
@@ -326,6 +596,15 @@ func (c *routerClient) Heartbeat(ctx context.Context, in *HBRequest, opts ...grp
 	return out, nil
 }
 
+func (c *routerClient) HeartbeatBatch(ctx context.Context, in *HBBatchRequest, opts ...grpc.CallOption) (*HBBatchReply, error) {
+	out := new(HBBatchReply)
+	err := c.cc.Invoke(ctx, "/Router/HeartbeatBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *routerClient) NodesFind(ctx context.Context, in *NFRequest, opts ...grpc.CallOption) (*NFReply, error) {
 	out := new(NFReply)
 	err := c.cc.Invoke(ctx, "/Router/NodesFind", in, out, opts...)
@@ -344,11 +623,32 @@ func (c *routerClient) List(ctx context.Context, in *Empty, opts ...grpc.CallOpt
 	return out, nil
 }
 
+func (c *routerClient) Orphans(ctx context.Context, in *OrphansRequest, opts ...grpc.CallOption) (*OrphansReply, error) {
+	out := new(OrphansReply)
+	err := c.cc.Invoke(ctx, "/Router/Orphans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) Heartbeats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HeartbeatsReply, error) {
+	out := new(HeartbeatsReply)
+	err := c.cc.Invoke(ctx, "/Router/Heartbeats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RouterServer is the server API for Router service.
 type RouterServer interface {
 	Heartbeat(context.Context, *HBRequest) (*HBReply, error)
+	HeartbeatBatch(context.Context, *HBBatchRequest) (*HBBatchReply, error)
 	NodesFind(context.Context, *NFRequest) (*NFReply, error)
 	List(context.Context, *Empty) (*ListReply, error)
+	Orphans(context.Context, *OrphansRequest) (*OrphansReply, error)
+	Heartbeats(context.Context, *Empty) (*HeartbeatsReply, error)
 }
 
 func RegisterRouterServer(s *grpc.Server, srv RouterServer) {
@@ -373,6 +673,24 @@ func _Router_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Router_HeartbeatBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HBBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).HeartbeatBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Router/HeartbeatBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).HeartbeatBatch(ctx, req.(*HBBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Router_NodesFind_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(NFRequest)
 	if err := dec(in); err != nil {
@@ -409,6 +727,42 @@ func _Router_List_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Router_Orphans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrphansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Orphans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Router/Orphans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Orphans(ctx, req.(*OrphansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_Heartbeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Heartbeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Router/Heartbeats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Heartbeats(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Router_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Router",
 	HandlerType: (*RouterServer)(nil),
@@ -417,6 +771,10 @@ var _Router_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Heartbeat",
 			Handler:    _Router_Heartbeat_Handler,
 		},
+		{
+			MethodName: "HeartbeatBatch",
+			Handler:    _Router_HeartbeatBatch_Handler,
+		},
 		{
 			MethodName: "NodesFind",
 			Handler:    _Router_NodesFind_Handler,
@@ -425,28 +783,46 @@ var _Router_serviceDesc = grpc.ServiceDesc{
 			MethodName: "List",
 			Handler:    _Router_List_Handler,
 		},
+		{
+			MethodName: "Orphans",
+			Handler:    _Router_Orphans_Handler,
+		},
+		{
+			MethodName: "Heartbeats",
+			Handler:    _Router_Heartbeats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb.proto",
 }
 
-func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_fb5e298dfa49bbfc) }
-
-var fileDescriptor_pb_fb5e298dfa49bbfc = []byte{
-	// 237 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x51, 0x4d, 0x4b, 0xc4, 0x30,
-	0x10, 0xed, 0xda, 0xed, 0x47, 0x06, 0x04, 0x19, 0x44, 0x4a, 0x51, 0x5c, 0xc6, 0x8b, 0xa7, 0x1c,
-	0xf4, 0xe0, 0x5d, 0x70, 0xd9, 0x83, 0xae, 0x90, 0x7f, 0xd0, 0xb2, 0x39, 0x2c, 0x6a, 0x13, 0x93,
-	0xe9, 0xa1, 0xff, 0x5e, 0x26, 0xd6, 0xde, 0x85, 0xbd, 0xcd, 0x9b, 0xbc, 0xf7, 0x78, 0x79, 0x03,
-	0xb5, 0xef, 0xb5, 0x0f, 0x8e, 0x1d, 0xdd, 0x82, 0xda, 0x3d, 0x1b, 0xfb, 0x3d, 0xda, 0xc8, 0x88,
-	0xb0, 0x1e, 0xdc, 0xc1, 0x36, 0xab, 0xcd, 0xea, 0x5e, 0x99, 0x34, 0xd3, 0x13, 0x54, 0x42, 0xf0,
-	0x9f, 0x13, 0x5e, 0x41, 0x19, 0xb9, 0xe3, 0x31, 0x26, 0x42, 0x61, 0x66, 0x84, 0x97, 0x50, 0xd8,
-	0x10, 0x5c, 0x68, 0xce, 0x92, 0xee, 0x17, 0xd0, 0x0d, 0xa8, 0xfd, 0xf6, 0xcf, 0xf9, 0x02, 0xf2,
-	0x0f, 0x3b, 0x25, 0xdd, 0xb9, 0x91, 0x91, 0xde, 0xa0, 0x92, 0xe7, 0x7f, 0xfb, 0xca, 0x56, 0x82,
-	0xc5, 0x26, 0xdf, 0xe4, 0xb2, 0x4d, 0x80, 0x2a, 0x28, 0x5e, 0xbe, 0x3c, 0x4f, 0xf4, 0x0e, 0xea,
-	0xf5, 0x18, 0xf9, 0x64, 0xce, 0x0f, 0x1e, 0x4a, 0xe3, 0x46, 0xb6, 0x01, 0xef, 0x40, 0xed, 0x6c,
-	0x17, 0xb8, 0xb7, 0x1d, 0x23, 0xe8, 0xa5, 0xb7, 0xb6, 0xd6, 0x73, 0x45, 0x94, 0x09, 0x69, 0x2f,
-	0xba, 0xed, 0x71, 0x38, 0x20, 0xe8, 0xa5, 0x82, 0xb6, 0xd6, 0xf3, 0x7f, 0x29, 0xc3, 0x6b, 0x58,
-	0x4b, 0x48, 0x2c, 0x75, 0x0a, 0xdd, 0x82, 0x5e, 0x32, 0x53, 0xd6, 0x97, 0xe9, 0x34, 0x8f, 0x3f,
-	0x01, 0x00, 0x00, 0xff, 0xff, 0xce, 0x93, 0xe4, 0x6f, 0xa6, 0x01, 0x00, 0x00,
+func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_8a6d71f288afa543) }
+
+var fileDescriptor_pb_8a6d71f288afa543 = []byte{
+	// 398 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0x4f, 0xab, 0xd3, 0x40,
+	0x14, 0xc5, 0x93, 0x97, 0xff, 0xd7, 0xa6, 0x2d, 0x97, 0x22, 0x21, 0xa8, 0x84, 0x71, 0x13, 0x10,
+	0x66, 0x51, 0x17, 0xba, 0x2e, 0x58, 0xba, 0xb0, 0xad, 0xcc, 0xda, 0x4d, 0x6a, 0x06, 0x5b, 0xaa,
+	0x4d, 0x3a, 0x99, 0x2e, 0xf2, 0xd9, 0xdd, 0xc8, 0x4c, 0x93, 0x31, 0x55, 0x10, 0x5a, 0xde, 0xee,
+	0x0e, 0x99, 0xf9, 0x9d, 0xc3, 0x3d, 0x27, 0x10, 0xd6, 0x3b, 0x5a, 0x8b, 0x4a, 0x56, 0x64, 0x0d,
+	0xd1, 0x6a, 0xc1, 0xf8, 0xf9, 0xc2, 0x1b, 0x89, 0x08, 0xee, 0xa9, 0x2a, 0x79, 0x62, 0x67, 0x76,
+	0x1e, 0x31, 0x3d, 0x63, 0x0a, 0x61, 0xc9, 0xbf, 0x8b, 0xa2, 0xe4, 0x65, 0xf2, 0x94, 0xd9, 0x79,
+	0xc8, 0xcc, 0x19, 0xa7, 0xe0, 0x34, 0xfc, 0x9c, 0x38, 0x99, 0x9d, 0xbb, 0x4c, 0x8d, 0xe4, 0x03,
+	0x04, 0x0a, 0x57, 0xff, 0x68, 0xf1, 0x25, 0xf8, 0x8d, 0x2c, 0xe4, 0xa5, 0xd1, 0x38, 0x8f, 0x75,
+	0x27, 0x9c, 0x81, 0xc7, 0x85, 0xa8, 0x84, 0xa6, 0x45, 0xec, 0x7a, 0x20, 0x73, 0x18, 0xaf, 0x16,
+	0x8b, 0x42, 0x7e, 0xdb, 0xf7, 0x66, 0x32, 0xf0, 0x94, 0x01, 0xf5, 0xdc, 0xc9, 0x5f, 0xcc, 0x81,
+	0x1a, 0x9f, 0xec, 0xfa, 0x81, 0x7c, 0x85, 0x91, 0x79, 0x73, 0xb7, 0x22, 0xbe, 0xe9, 0xf9, 0x8e,
+	0xe6, 0x87, 0xb4, 0x33, 0xde, 0xd3, 0x5f, 0x43, 0xb4, 0x59, 0xf6, 0x66, 0xa6, 0xe0, 0x1c, 0x79,
+	0xab, 0xb9, 0x31, 0x53, 0x23, 0x59, 0x43, 0xb0, 0x59, 0x3e, 0xa2, 0x3b, 0x1b, 0xea, 0x46, 0xbd,
+	0x5a, 0x00, 0xde, 0xa7, 0x9f, 0xb5, 0x6c, 0xc9, 0x16, 0xa2, 0xcf, 0x87, 0x46, 0x3e, 0x1f, 0xf9,
+	0x23, 0x8c, 0xb7, 0xa2, 0xde, 0x17, 0xa7, 0xe6, 0x7f, 0x31, 0x23, 0xb8, 0x47, 0xde, 0x36, 0xc9,
+	0x53, 0xe6, 0xe4, 0x31, 0xd3, 0x33, 0xf9, 0x02, 0x23, 0xf3, 0xf2, 0x7e, 0x37, 0x3d, 0xd1, 0x19,
+	0x10, 0x0b, 0x98, 0xac, 0x78, 0x21, 0xe4, 0x8e, 0x17, 0xf2, 0x21, 0x68, 0x76, 0x1b, 0xda, 0xbf,
+	0xa5, 0x98, 0xff, 0xb2, 0xc1, 0x67, 0xd5, 0x45, 0x72, 0x81, 0x6f, 0x21, 0x32, 0x6a, 0x38, 0xb8,
+	0x9a, 0x9a, 0xac, 0x89, 0x85, 0xaa, 0x78, 0xfd, 0x25, 0xdd, 0x25, 0x9c, 0xd0, 0xdb, 0x26, 0xa6,
+	0x31, 0x1d, 0xd6, 0x8c, 0x58, 0x0a, 0xbc, 0x51, 0x62, 0xcb, 0xc3, 0xa9, 0x44, 0xa0, 0xa6, 0x26,
+	0x69, 0x48, 0xbb, 0x4e, 0x10, 0x0b, 0x5f, 0x81, 0xab, 0x82, 0x44, 0x9f, 0xea, 0x60, 0x53, 0xa0,
+	0x26, 0x57, 0x62, 0xe1, 0x3b, 0x08, 0xba, 0xdd, 0xe2, 0x84, 0xde, 0xe6, 0x93, 0xc6, 0x74, 0xb8,
+	0x76, 0x62, 0x61, 0x0e, 0xf0, 0x67, 0x6d, 0x06, 0x38, 0xa5, 0x7f, 0xed, 0x92, 0x58, 0x3b, 0x5f,
+	0xff, 0xd5, 0xef, 0x7f, 0x0f, 0x00, 0x81, 0xbc, 0x21, 0x95, 0xe1, 0x03, 0x00, 0x00,
 }
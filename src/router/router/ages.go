@@ -0,0 +1,56 @@
+package router
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"storage"
+)
+
+// metricName turns the address of node into a part of a metric name.
+func metricName(node storage.ServiceAddr) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, string(node))
+}
+
+// ReportHeartbeatAges reports times passed since last heartbeats of nodes
+// as router.heartbeat.age.<node> gauges, the largest of them as
+// router.heartbeat.age_max and cfg.ForgetTimeout as router.forget_timeout,
+// all in seconds, so alerts can fire before nodes are forgotten.
+//
+// ReportHeartbeatAges отправляет время, прошедшее с последних heartbeats node,
+// в виде gauges router.heartbeat.age.<node>, наибольшее из них в виде
+// router.heartbeat.age_max и cfg.ForgetTimeout в виде router.forget_timeout,
+// все в секундах, чтобы оповещения срабатывали до того, как node будут забыты.
+func (r *Router) ReportHeartbeatAges() {
+	now := r.conf.Clock.Now().UnixNano()
+	var max time.Duration
+	for node, state := range r.nodeSet().states {
+		age := time.Duration(now - atomic.LoadInt64(&state.heartbeat))
+		if age > max {
+			max = age
+		}
+		r.conf.Sink.SetGauge("router.heartbeat.age."+metricName(node), age.Seconds())
+	}
+	r.conf.Sink.SetGauge("router.heartbeat.age_max", max.Seconds())
+	r.conf.Sink.SetGauge("router.forget_timeout", r.conf.ForgetTimeout.Seconds())
+}
+
+// HeartbeatAges runs ReportHeartbeatAges each tenth of cfg.ForgetTimeout.
+//
+// HeartbeatAges запускает ReportHeartbeatAges через каждую десятую часть
+// cfg.ForgetTimeout.
+func (r *Router) HeartbeatAges() {
+	go func() {
+		for {
+			r.ReportHeartbeatAges()
+			time.Sleep(r.conf.ForgetTimeout / 10)
+		}
+	}()
+}
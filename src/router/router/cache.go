@@ -0,0 +1,63 @@
+package router
+
+import (
+	"sync/atomic"
+
+	"storage"
+)
+
+// NodesCacheSize is a default number of NodesFind results cached by Router.
+//
+// NodesCacheSize -- количество результатов NodesFind, кешируемых Router
+// по умолчанию.
+const NodesCacheSize = 1 << 16
+
+// cacheEntry is a NodesFind result for key. It is valid while the router
+// epoch equals epoch and until deadline in unix nanoseconds, when some
+// of the nodes may be forgotten.
+type cacheEntry struct {
+	key      storage.RecordID
+	epoch    uint64
+	deadline int64
+	nodes    []storage.ServiceAddr
+}
+
+// nodesCache is a direct-mapped cache of NodesFind results. Every key is
+// placed independently by the NodesFinder, so results are cached per key
+// rather than per key range. Entries are immutable and slots are replaced
+// atomically, so the cache is safe for concurrent use without locking.
+type nodesCache struct {
+	slots []atomic.Value
+}
+
+func newNodesCache(size int) nodesCache {
+	if size < 0 {
+		return nodesCache{}
+	}
+	return nodesCache{
+		slots: make([]atomic.Value, size),
+	}
+}
+
+func (c nodesCache) slot(k storage.RecordID) *atomic.Value {
+	return &c.slots[uint64(k)%uint64(len(c.slots))]
+}
+
+// get returns a valid cached entry for k or nil.
+func (c nodesCache) get(k storage.RecordID, epoch uint64, now int64) *cacheEntry {
+	if len(c.slots) == 0 {
+		return nil
+	}
+	e, _ := c.slot(k).Load().(*cacheEntry)
+	if e == nil || e.key != k || e.epoch != epoch || now > e.deadline {
+		return nil
+	}
+	return e
+}
+
+func (c nodesCache) put(e *cacheEntry) {
+	if len(c.slots) == 0 {
+		return
+	}
+	c.slot(e.key).Store(e)
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"fmt"
+
+	"metrics"
+)
+
+// NewDefault creates a new Router with a given cfg modified by opts wiring
+// the NodesFinder built from cfg.Placement and the metrics sink described by
+// cfg.Metrics for the fields which are not set. Returns the same errors as
+// New.
+//
+// NewDefault создает новый Router с данным cfg, измененной opts, подставляя в
+// незаданные поля NodesFinder, построенный по cfg.Placement, и приемник
+// метрик, описанный cfg.Metrics. Возвращает те же ошибки, что и New.
+func NewDefault(cfg Config, opts ...Option) (*Router, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var err error
+	if cfg.NodesFinder.IsZero() {
+		cfg.NodesFinder, err = NewNodesFinderWithPlacement(NewMD5Hasher(), cfg.Placement)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create nodes finder: %v", err)
+		}
+	}
+
+	if cfg.Sink == nil {
+		cfg.Sink, err = metrics.Serve(cfg.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to set up metrics: %v", err)
+		}
+	}
+
+	return New(cfg)
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// Exclusion describes why a node was excluded by NodesFind.
+//
+// Exclusion описывает, почему node была исключена NodesFind.
+type Exclusion struct {
+	// Node is an address of the excluded node.
+	// Node -- адрес исключенной node.
+	Node storage.ServiceAddr
+	// Reason is a reason of the exclusion, e.g. a stale heartbeat.
+	// Reason -- причина исключения, например устаревший heartbeat.
+	Reason string
+}
+
+// NodesFindError is returned by NodesFind if not enough nodes are available.
+// It matches storage.ErrNotEnoughDaemons with errors.Is.
+//
+// NodesFindError возвращается NodesFind, если доступно недостаточно node.
+// Соответствует storage.ErrNotEnoughDaemons при проверке errors.Is.
+type NodesFindError struct {
+	// Key is the key of the record nodes were looked for.
+	// Key -- ключ записи, для которой искались node.
+	Key storage.RecordID
+	// Candidates are nodes chosen by NodesFinder regardless of liveness.
+	// Candidates -- node, выбранные NodesFinder без учета доступности.
+	Candidates []storage.ServiceAddr
+	// Excluded are candidates excluded as unavailable.
+	// Excluded -- кандидаты, исключенные как недоступные.
+	Excluded []Exclusion
+}
+
+func (e *NodesFindError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v for key %v: %d of %d candidates %v available",
+		storage.ErrNotEnoughDaemons, e.Key, len(e.Candidates)-len(e.Excluded), len(e.Candidates), e.Candidates)
+	for i, ex := range e.Excluded {
+		if i == 0 {
+			b.WriteString(", excluded ")
+		} else {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%v (%v)", ex.Node, ex.Reason)
+	}
+	return b.String()
+}
+
+func (e *NodesFindError) Unwrap() error {
+	return storage.ErrNotEnoughDaemons
+}
+
+// nodesFindError explains why nodes for k can't be found at now.
+func (r *Router) nodesFindError(k storage.RecordID, now int64) error {
+	set := r.nodeSet()
+	e := &NodesFindError{
+		Key:        k,
+		Candidates: r.conf.NodesFinder.NodesFind(k, set.list),
+	}
+	for _, node := range e.Candidates {
+		state := set.states[node]
+		age := time.Duration(now - atomic.LoadInt64(&state.heartbeat))
+		switch {
+		case atomic.LoadInt32(&state.down) != 0:
+			e.Excluded = append(e.Excluded, Exclusion{Node: node, Reason: "marked down"})
+		case atomic.LoadInt32(&state.awaiting) != 0:
+			e.Excluded = append(e.Excluded, Exclusion{Node: node, Reason: "no heartbeat since start"})
+		case age > r.conf.ForgetTimeout:
+			e.Excluded = append(e.Excluded, Exclusion{
+				Node:   node,
+				Reason: fmt.Sprintf("stale heartbeat %v ago", age.Round(time.Millisecond)),
+			})
+		case atomic.LoadInt32(&state.degraded) != 0:
+			e.Excluded = append(e.Excluded, Exclusion{Node: node, Reason: "degraded"})
+		}
+	}
+	return e
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"events"
+)
+
+// Events returns the bus the router publishes events to, so subscribers
+// can be added to it.
+//
+// Events возвращает шину, в которую router публикует события, чтобы к ней
+// можно было подключить подписчиков.
+func (r *Router) Events() *events.Bus {
+	return r.conf.Bus
+}
+
+// CheckNodes publishes events.NodeDown and events.NodeUp for nodes which
+// became unavailable or available since the previous check.
+//
+// CheckNodes публикует events.NodeDown и events.NodeUp для node, ставших
+// недоступными или доступными после предыдущей проверки.
+func (r *Router) CheckNodes() {
+	set := r.nodeSet()
+	now := r.conf.Clock.Now().UnixNano()
+	for _, node := range set.list {
+		state := set.states[node]
+		up := boolFlag(r.alive(state, now))
+		if atomic.SwapInt32(&state.up, up) == up {
+			continue
+		}
+		if up != 0 {
+			r.conf.Bus.Publish(events.Event{Kind: events.NodeUp, Node: node})
+			continue
+		}
+		r.conf.Bus.Publish(events.Event{Kind: events.NodeDown, Node: node, Reason: r.downReason(state, now)})
+	}
+}
+
+// downReason tells why a node is not available at now.
+func (r *Router) downReason(state *nodeState, now int64) string {
+	switch {
+	case atomic.LoadInt32(&state.down) != 0:
+		return "marked down"
+	case atomic.LoadInt32(&state.degraded) != 0:
+		return "reported degraded"
+	case atomic.LoadInt32(&state.awaiting) != 0:
+		return "awaiting a heartbeat"
+	default:
+		age := time.Duration(now - atomic.LoadInt64(&state.heartbeat))
+		return fmt.Sprintf("no heartbeats for %v", age.Round(time.Millisecond))
+	}
+}
+
+// WatchNodes runs CheckNodes each tenth of cfg.ForgetTimeout.
+//
+// WatchNodes запускает CheckNodes через каждую десятую часть
+// cfg.ForgetTimeout.
+func (r *Router) WatchNodes() {
+	go func() {
+		for {
+			time.Sleep(r.conf.ForgetTimeout / 10)
+			r.CheckNodes()
+		}
+	}()
+}
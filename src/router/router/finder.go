@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/binary"
 	"sort"
+	"sync"
 
 	"storage"
 )
@@ -64,7 +65,8 @@ func (h *MD5) Hash(k storage.RecordID, node storage.ServiceAddr) uint64 {
 // NodesFinder содержит методы и опции для нахождения узлов,
 // на которых должна храниться запись с данным ключом.
 type NodesFinder struct {
-	hasher Hasher
+	hasher    Hasher
+	placement placement
 }
 
 // NewNodesFinder creates NodesFinder instance with given Hasher.
@@ -76,6 +78,38 @@ func NewNodesFinder(h Hasher) NodesFinder {
 	}
 }
 
+// NewNodesFinderWithPlacement creates NodesFinder instance with given Hasher
+// which places replicas according to the given Placement constraints.
+//
+// NewNodesFinderWithPlacement создает NodesFinder с данным Hasher, который
+// размещает реплики в соответствии с ограничениями данного Placement.
+func NewNodesFinderWithPlacement(h Hasher, p Placement) (NodesFinder, error) {
+	pl, err := p.parse()
+	if err != nil {
+		return NodesFinder{}, err
+	}
+	return NodesFinder{
+		hasher:    h,
+		placement: pl,
+	}, nil
+}
+
+// IsZero reports whether nf is the zero NodesFinder without a Hasher.
+//
+// IsZero сообщает, является ли nf нулевым NodesFinder без Hasher.
+func (nf NodesFinder) IsZero() bool {
+	return nf.hasher == nil
+}
+
+// Validate checks that placement constraints of the NodesFinder
+// can be satisfied for the given nodes.
+//
+// Validate проверяет, что ограничения на размещение реплик могут
+// быть выполнены для данных nodes.
+func (nf NodesFinder) Validate(nodes []storage.ServiceAddr) error {
+	return nf.placement.validate(nodes)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -83,37 +117,71 @@ func min(a, b int) int {
 	return b
 }
 
+type descriptor struct {
+	addr storage.ServiceAddr
+	hash uint64
+}
+
+// descriptors orders nodes by hash descending. It implements sort.Interface
+// on a pointer, so sorting does not allocate.
+type descriptors []descriptor
+
+func (d *descriptors) Len() int      { return len(*d) }
+func (d *descriptors) Swap(i, j int) { (*d)[i], (*d)[j] = (*d)[j], (*d)[i] }
+func (d *descriptors) Less(i, j int) bool {
+	if (*d)[i].hash == (*d)[j].hash {
+		return (*d)[i].addr > (*d)[j].addr
+	}
+	return (*d)[i].hash > (*d)[j].hash
+}
+
+var descriptorsPool = sync.Pool{
+	New: func() interface{} {
+		return new(descriptors)
+	},
+}
+
 // NodesFind returns list of nodes where record with associated key k should be stored.
 // Not more than storage.ReplciationFactor nodes is returned.
 // Returned nodes are choosen from the provided slice of nodes.
+// Nodes violating placement constraints are skipped.
 //
 // NodesFind возвращает список nodes, на которых должна храниться запись с ключом k.
 // Возвращается не больше чем storage.ReplicationFactor nodes.
 // Возвращаемые nodes выбираются из передаваемых nodes.
+// Nodes, нарушающие ограничения на размещение, пропускаются.
 func (nf NodesFinder) NodesFind(k storage.RecordID, nodes []storage.ServiceAddr) []storage.ServiceAddr {
-	type descriptor struct {
-		addr storage.ServiceAddr
-		hash uint64
-	}
+	return nf.NodesFindAppend(make([]storage.ServiceAddr, 0, storage.ReplicationFactor), k, nodes)
+}
 
-	descriptors := make([]descriptor, 0, len(nodes))
+// NodesFindAppend is like NodesFind but appends found nodes to dst
+// and returns the extended slice. It does not allocate if dst has
+// enough capacity and no placement constraints are set.
+//
+// NodesFindAppend работает как NodesFind, но добавляет найденные nodes
+// в dst и возвращает расширенный slice. Не выделяет память, если емкости
+// dst достаточно и не заданы ограничения на размещение.
+func (nf NodesFinder) NodesFindAppend(dst []storage.ServiceAddr, k storage.RecordID, nodes []storage.ServiceAddr) []storage.ServiceAddr {
+	d := descriptorsPool.Get().(*descriptors)
+	defer descriptorsPool.Put(d)
+
+	*d = (*d)[:0]
 	for _, node := range nodes {
-		descriptors = append(descriptors, descriptor{
+		*d = append(*d, descriptor{
 			addr: node,
 			hash: nf.hasher.Hash(k, node),
 		})
 	}
 
-	sort.Slice(descriptors, func(i, j int) bool {
-		if descriptors[i].hash == descriptors[j].hash {
-			return descriptors[i].addr > descriptors[j].addr
-		}
-		return descriptors[i].hash > descriptors[j].hash
-	})
+	sort.Sort(d)
 
-	selectedNodes := make([]storage.ServiceAddr, 0, storage.ReplicationFactor)
-	for i := 0; i < min(storage.ReplicationFactor, len(descriptors)); i++ {
-		selectedNodes = append(selectedNodes, descriptors[i].addr)
+	found := 0
+	placer := nf.placement.placer()
+	for i := 0; i < len(*d) && found < storage.ReplicationFactor; i++ {
+		if placer.place((*d)[i].addr) {
+			dst = append(dst, (*d)[i].addr)
+			found++
+		}
 	}
-	return selectedNodes
+	return dst
 }
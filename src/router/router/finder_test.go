@@ -57,3 +57,30 @@ func TestNodes_SameHashes(t *testing.T) {
 		t.Errorf("NodesFind() wrong nodes, got %v, want %v", got, nodes[3:])
 	}
 }
+
+func TestNodesFindAppend_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("The race detector inflates allocations")
+	}
+	nf := NewNodesFinder(NewMD5Hasher())
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3", "node4", "node5", "node6"}
+	dst := make([]storage.ServiceAddr, 0, storage.ReplicationFactor)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = nf.NodesFindAppend(dst[:0], 1, nodes)
+	})
+	if allocs != 0 {
+		t.Errorf("NodesFindAppend() allocations: got %v, want 0", allocs)
+	}
+}
+
+func BenchmarkNodesFind(b *testing.B) {
+	nf := NewNodesFinder(NewMD5Hasher())
+	nodes := []storage.ServiceAddr{"node1", "node2", "node3", "node4", "node5", "node6"}
+	dst := make([]storage.ServiceAddr, 0, storage.ReplicationFactor)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = nf.NodesFindAppend(dst[:0], storage.RecordID(i), nodes)
+	}
+}
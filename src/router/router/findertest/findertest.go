@@ -0,0 +1,172 @@
+// Package findertest provides a suite of property tests for NodesFinder
+// implementations: determinism, the replication factor, stability under
+// removal of a node and balance of replicas among nodes. It runs against
+// anything finding nodes for keys, so a NodesFinder built with a custom
+// Hasher or placement, as well as a third-party finder, can verify itself.
+//
+// Package findertest предоставляет набор тестов свойств для реализаций
+// NodesFinder: детерминированности, фактора репликации, стабильности при
+// удалении node и равномерности распределения реплик по node. Он работает
+// с любым способом нахождения node для ключей, так что NodesFinder,
+// построенный с собственным Hasher или размещением, как и сторонняя
+// реализация, может проверить себя.
+package findertest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"storage"
+)
+
+// Finder is the common interface of finders checked by the suite,
+// router.NodesFinder implements it.
+//
+// Finder -- общий интерфейс проверяемых набором реализаций,
+// router.NodesFinder реализует его.
+type Finder interface {
+	NodesFind(k storage.RecordID, nodes []storage.ServiceAddr) []storage.ServiceAddr
+}
+
+// Config stores configuration of the suite.
+//
+// Config -- содержит конфигурацию набора тестов.
+type Config struct {
+	// Nodes is a list of nodes to find among, 10 generated nodes by default.
+	// Nodes -- список node, среди которых производится поиск, по умолчанию
+	// 10 сгенерированных node.
+	Nodes []storage.ServiceAddr
+	// Keys is a number of random keys checked, 10000 by default.
+	// Keys -- количество проверяемых случайных ключей, по умолчанию 10000.
+	Keys int
+	// Balance is the largest allowed relative deviation of the number of
+	// replicas on a node from the mean, 0.1 by default.
+	// Balance -- наибольшее допустимое относительное отклонение количества
+	// реплик на node от среднего, по умолчанию 0.1.
+	Balance float64
+	// Seed seeds the random keys.
+	// Seed -- начальное значение случайных ключей.
+	Seed int64
+}
+
+// Run runs the suite against nf as subtests of t.
+//
+// Run запускает набор тестов для nf как подтесты t.
+func Run(t *testing.T, nf Finder, cfg Config) {
+	if len(cfg.Nodes) == 0 {
+		for i := 0; i < 10; i++ {
+			cfg.Nodes = append(cfg.Nodes, storage.ServiceAddr(fmt.Sprintf("10.0.0.%d:7320", i+1)))
+		}
+	}
+	if cfg.Keys == 0 {
+		cfg.Keys = 10000
+	}
+	if cfg.Balance == 0 {
+		cfg.Balance = 0.1
+	}
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	keys := make([]storage.RecordID, cfg.Keys)
+	for i := range keys {
+		keys[i] = storage.RecordID(rnd.Uint32())
+	}
+
+	t.Run("Determinism", func(t *testing.T) { determinism(t, nf, cfg.Nodes, keys, rnd) })
+	t.Run("ReplicationFactor", func(t *testing.T) { replicationFactor(t, nf, cfg.Nodes, keys) })
+	t.Run("Stability", func(t *testing.T) { stability(t, nf, cfg.Nodes, keys) })
+	t.Run("Balance", func(t *testing.T) { balance(t, nf, cfg.Nodes, keys, cfg.Balance) })
+}
+
+// sameSet reports whether a and b contain the same nodes in any order.
+func sameSet(a, b []storage.ServiceAddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[storage.ServiceAddr]bool, len(a))
+	for _, node := range a {
+		set[node] = true
+	}
+	for _, node := range b {
+		if !set[node] {
+			return false
+		}
+	}
+	return true
+}
+
+// determinism checks that nodes found for a key don't depend on calls
+// made before or on the order of the given nodes.
+func determinism(t *testing.T, nf Finder, nodes []storage.ServiceAddr, keys []storage.RecordID, rnd *rand.Rand) {
+	shuffled := append([]storage.ServiceAddr(nil), nodes...)
+	for _, k := range keys {
+		first := nf.NodesFind(k, nodes)
+		if again := nf.NodesFind(k, nodes); !sameSet(first, again) {
+			t.Fatalf("NodesFind(%v) got %v, then %v", k, first, again)
+		}
+		rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		if got := nf.NodesFind(k, shuffled); !sameSet(first, got) {
+			t.Fatalf("NodesFind(%v) got %v for nodes %v, but %v for nodes %v", k, first, nodes, got, shuffled)
+		}
+	}
+}
+
+// replicationFactor checks that storage.ReplicationFactor distinct nodes
+// out of the given ones are found for every key.
+func replicationFactor(t *testing.T, nf Finder, nodes []storage.ServiceAddr, keys []storage.RecordID) {
+	given := make(map[storage.ServiceAddr]bool, len(nodes))
+	for _, node := range nodes {
+		given[node] = true
+	}
+	for _, k := range keys {
+		found := nf.NodesFind(k, nodes)
+		if len(found) != storage.ReplicationFactor {
+			t.Fatalf("NodesFind(%v) got %v, want %v nodes", k, found, storage.ReplicationFactor)
+		}
+		seen := make(map[storage.ServiceAddr]bool, len(found))
+		for _, node := range found {
+			if !given[node] || seen[node] {
+				t.Fatalf("NodesFind(%v) got %v with unknown or repeated node %v", k, found, node)
+			}
+			seen[node] = true
+		}
+	}
+}
+
+// stability checks that removing a node only moves replicas it stored:
+// other nodes found for a key are still found without it.
+func stability(t *testing.T, nf Finder, nodes []storage.ServiceAddr, keys []storage.RecordID) {
+	for i, removed := range nodes {
+		rest := append(append([]storage.ServiceAddr(nil), nodes[:i]...), nodes[i+1:]...)
+		for _, k := range keys {
+			after := nf.NodesFind(k, rest)
+			kept := make(map[storage.ServiceAddr]bool, len(after))
+			for _, node := range after {
+				kept[node] = true
+			}
+			for _, node := range nf.NodesFind(k, nodes) {
+				if node != removed && !kept[node] {
+					t.Fatalf("NodesFind(%v) moved a replica from %v after removing %v", k, node, removed)
+				}
+			}
+		}
+	}
+}
+
+// balance checks that the number of replicas on every node deviates from
+// the mean by at most tolerance.
+func balance(t *testing.T, nf Finder, nodes []storage.ServiceAddr, keys []storage.RecordID, tolerance float64) {
+	counts := make(map[storage.ServiceAddr]int, len(nodes))
+	total := 0
+	for _, k := range keys {
+		for _, node := range nf.NodesFind(k, nodes) {
+			counts[node]++
+			total++
+		}
+	}
+	mean := float64(total) / float64(len(nodes))
+	for _, node := range nodes {
+		if dev := (float64(counts[node]) - mean) / mean; dev > tolerance || dev < -tolerance {
+			t.Errorf("Node %v stores %v replicas, %.1f%% off the mean %.0f", node, counts[node], 100*dev, mean)
+		}
+	}
+}
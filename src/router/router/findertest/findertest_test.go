@@ -0,0 +1,29 @@
+package findertest
+
+import (
+	"testing"
+
+	"router/router"
+	"storage"
+)
+
+func TestNodesFinder(t *testing.T) {
+	Run(t, router.NewNodesFinder(router.NewMD5Hasher()), Config{})
+}
+
+func TestNodesFinder_Placement(t *testing.T) {
+	p := router.Placement{
+		Domains:     map[storage.ServiceAddr]router.Domains{},
+		Constraints: []string{"max 1 replica per zone"},
+	}
+	var nodes []storage.ServiceAddr
+	for _, addr := range []storage.ServiceAddr{"a1", "a2", "a3", "b1", "b2", "b3", "c1", "c2", "c3", "d1", "d2", "d3"} {
+		nodes = append(nodes, addr)
+		p.Domains[addr] = router.Domains{"zone": string(addr[:1])}
+	}
+	nf, err := router.NewNodesFinderWithPlacement(router.NewMD5Hasher(), p)
+	if err != nil {
+		t.Fatalf("NewNodesFinderWithPlacement() error: %v", err)
+	}
+	Run(t, nf, Config{Nodes: nodes})
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// MarkDown makes the router consider node unavailable regardless of its
+// heartbeats until MarkUp is called, e.g. during maintenance or if the node
+// is known to be broken but still sends heartbeats. Returns
+// storage.ErrUnknownDaemon error if node is not served by the Router.
+//
+// MarkDown заставляет router считать node недоступной независимо от ее
+// heartbeats до вызова MarkUp, например во время обслуживания или если
+// известно, что node неисправна, но продолжает посылать heartbeats.
+// Возвращает ошибку storage.ErrUnknownDaemon, если node не обслуживается
+// Router.
+func (r *Router) MarkDown(node storage.ServiceAddr) error {
+	state, ok := r.nodeSet().states[node]
+	if !ok {
+		return storage.ErrUnknownDaemon
+	}
+	if atomic.SwapInt32(&state.down, 1) == 0 {
+		r.conf.Logger.Printf("Node %q marked down", node)
+		r.conf.Sink.IncrCounter("router.mark_down", 1)
+		atomic.AddUint64(&r.epoch, 1)
+		r.CheckNodes()
+	}
+	return nil
+}
+
+// MarkUp cancels MarkDown and resets the heartbeat of node to now, so it is
+// considered available until ForgetTimeout passes without heartbeats even
+// if it was forgotten or hasn't sent a heartbeat since start. Returns storage.ErrUnknownDaemon error if node is not
+// served by the Router.
+//
+// MarkUp отменяет MarkDown и сбрасывает время heartbeat node на текущее,
+// так что она считается доступной, пока не пройдет ForgetTimeout без
+// heartbeats, даже если она была забыта или не посылала heartbeat с запуска. Возвращает ошибку
+// storage.ErrUnknownDaemon, если node не обслуживается Router.
+func (r *Router) MarkUp(node storage.ServiceAddr) error {
+	state, ok := r.nodeSet().states[node]
+	if !ok {
+		return storage.ErrUnknownDaemon
+	}
+	atomic.StoreInt64(&state.heartbeat, r.conf.Clock.Now().UnixNano())
+	atomic.StoreInt32(&state.awaiting, 0)
+	if atomic.SwapInt32(&state.down, 0) != 0 {
+		r.conf.Logger.Printf("Node %q marked up", node)
+		r.conf.Sink.IncrCounter("router.mark_up", 1)
+	}
+	atomic.AddUint64(&r.epoch, 1)
+	r.CheckNodes()
+	return nil
+}
+
+// MarkedDown returns nodes marked down by MarkDown.
+//
+// MarkedDown возвращает node, отмеченные недоступными с помощью MarkDown.
+func (r *Router) MarkedDown() []storage.ServiceAddr {
+	set := r.nodeSet()
+	nodes := []storage.ServiceAddr{}
+	for _, node := range set.list {
+		if atomic.LoadInt32(&set.states[node].down) != 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// NodeStatus describes the state of a node as seen by the router.
+//
+// NodeStatus описывает состояние node, как его видит router.
+type NodeStatus struct {
+	// Node is an address of the node.
+	// Node -- адрес node.
+	Node storage.ServiceAddr
+	// Alive is set if the node is returned by NodesFind.
+	// Alive -- задан, если node возвращается NodesFind.
+	Alive bool
+	// Degraded is set if the node reported itself degraded.
+	// Degraded -- задан, если node сообщила о своей неисправности.
+	Degraded bool
+	// Down is set if the node is marked down by MarkDown.
+	// Down -- задан, если node отмечена недоступной с помощью MarkDown.
+	Down bool
+	// HeartbeatAge is a time passed since the last heartbeat of the node.
+	// HeartbeatAge -- время, прошедшее с последнего heartbeat node.
+	HeartbeatAge time.Duration
+	// Seq is the sequence number the node reported in the last heartbeat.
+	// Seq -- номер последовательности из последнего heartbeat node.
+	Seq uint64
+}
+
+// NodeStatuses returns statuses of all nodes served by the Router in order
+// of the list of nodes.
+//
+// NodeStatuses возвращает состояния всех node, обслуживаемых Router,
+// в порядке списка node.
+func (r *Router) NodeStatuses() []NodeStatus {
+	set := r.nodeSet()
+	return r.statuses(set, set.list)
+}
+
+// Placement returns statuses of the nodes the record with key k should be
+// stored on according to cfg.NodesFinder regardless of their liveness,
+// unlike NodesFind, so it can be told why a replica is not used.
+//
+// Placement возвращает состояния node, на которых должна храниться запись
+// с ключом k согласно cfg.NodesFinder, независимо от их доступности,
+// в отличие от NodesFind, чтобы можно было понять, почему реплика
+// не используется.
+func (r *Router) Placement(k storage.RecordID) []NodeStatus {
+	set := r.nodeSet()
+	return r.statuses(set, r.conf.NodesFinder.NodesFind(k, set.list))
+}
+
+// statuses returns statuses of nodes from set.
+func (r *Router) statuses(set *nodeSet, nodes []storage.ServiceAddr) []NodeStatus {
+	now := r.conf.Clock.Now().UnixNano()
+	statuses := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		state := set.states[node]
+		statuses = append(statuses, NodeStatus{
+			Node:         node,
+			Alive:        r.alive(state, now),
+			Degraded:     atomic.LoadInt32(&state.degraded) != 0,
+			Down:         atomic.LoadInt32(&state.down) != 0,
+			HeartbeatAge: time.Duration(now - atomic.LoadInt64(&state.heartbeat)),
+			Seq:          atomic.LoadUint64(&state.seq),
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"log"
+
+	"events"
+	"metrics"
+	"storage"
+)
+
+// Option configures a Router created by New.
+//
+// Option настраивает Router, создаваемый New.
+type Option func(cfg *Config)
+
+// WithLogger makes the router log with l.
+//
+// WithLogger -- router пишет журнал в l.
+func WithLogger(l *log.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = l
+	}
+}
+
+// WithMetrics makes the router report metrics to sink.
+//
+// WithMetrics -- router отправляет метрики в sink.
+func WithMetrics(sink metrics.Sink) Option {
+	return func(cfg *Config) {
+		cfg.Sink = sink
+	}
+}
+
+// WithClock makes the router tell time with c.
+//
+// WithClock -- router определяет время по часам c.
+func WithClock(c storage.Clock) Option {
+	return func(cfg *Config) {
+		cfg.Clock = c
+	}
+}
+
+// WithNodesFinder makes the router find nodes with nf.
+//
+// WithNodesFinder -- router находит nodes с помощью nf.
+func WithNodesFinder(nf NodesFinder) Option {
+	return func(cfg *Config) {
+		cfg.NodesFinder = nf
+	}
+}
+
+// WithEvents makes the router publish events to bus.
+//
+// WithEvents -- router публикует события в bus.
+func WithEvents(bus *events.Bus) Option {
+	return func(cfg *Config) {
+		cfg.Bus = bus
+	}
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"storage"
+)
+
+// Domains describes failure domains of a node as a mapping from a domain
+// level (e.g. "region", "zone", "rack", "host") to the domain name.
+//
+// Domains описывает failure domains node в виде отображения уровня
+// (например "region", "zone", "rack", "host") в имя домена.
+type Domains map[string]string
+
+// Constraint limits number of replicas of a record that can be placed
+// into a single failure domain of the given level.
+//
+// Constraint ограничивает количество реплик записи, которые могут быть
+// размещены в одном failure domain данного уровня.
+type Constraint struct {
+	Level string
+	Max   int
+}
+
+var constraintRe = regexp.MustCompile(`^\s*max\s+(\d+)\s+(?:replicas?\s+)?per\s+(\w+)\s*$`)
+
+// ParseConstraint parses constraint in form "max <n> [replica[s]] per <level>",
+// e.g. "max 1 replica per rack" or "max 2 per zone".
+//
+// ParseConstraint разбирает ограничение в форме "max <n> [replica[s]] per <level>",
+// например "max 1 replica per rack" или "max 2 per zone".
+func ParseConstraint(s string) (Constraint, error) {
+	m := constraintRe.FindStringSubmatch(s)
+	if m == nil {
+		return Constraint{}, fmt.Errorf("Invalid constraint %q: expected \"max <n> per <level>\"", s)
+	}
+	max, err := strconv.Atoi(m[1])
+	if err != nil || max <= 0 {
+		return Constraint{}, fmt.Errorf("Invalid constraint %q: max should be positive", s)
+	}
+	return Constraint{Level: m[2], Max: max}, nil
+}
+
+func (c Constraint) String() string {
+	return fmt.Sprintf("max %d per %s", c.Max, c.Level)
+}
+
+// Placement stores failure domains of nodes and constraints on
+// replicas placement.
+//
+// Placement содержит failure domains node и ограничения на размещение реплик.
+type Placement struct {
+	// Domains maps nodes to their failure domains.
+	// Domains -- failure domains для каждой node.
+	Domains map[storage.ServiceAddr]Domains
+
+	// Constraints is a list of placement constraints, e.g. "max 1 per rack".
+	// Constraints -- список ограничений на размещение, например "max 1 per rack".
+	Constraints []string
+}
+
+type placement struct {
+	domains     map[storage.ServiceAddr]Domains
+	constraints []Constraint
+}
+
+func (p Placement) parse() (placement, error) {
+	ret := placement{domains: p.Domains}
+	for _, s := range p.Constraints {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			return placement{}, err
+		}
+		ret.constraints = append(ret.constraints, c)
+	}
+	return ret, nil
+}
+
+// validate checks that every node has a domain for each constrained level and
+// that constraints still allow to place storage.ReplicationFactor replicas.
+func (p placement) validate(nodes []storage.ServiceAddr) error {
+	for _, c := range p.constraints {
+		counts := make(map[string]int)
+		for _, node := range nodes {
+			domain, ok := p.domains[node][c.Level]
+			if !ok {
+				return fmt.Errorf("Node %q has no %q failure domain required by constraint %q", node, c.Level, c)
+			}
+			counts[domain]++
+		}
+
+		capacity := 0
+		for _, n := range counts {
+			capacity += min(n, c.Max)
+		}
+		if capacity < storage.ReplicationFactor {
+			return fmt.Errorf("Constraint %q allows only %d replicas, %d required", c, capacity, storage.ReplicationFactor)
+		}
+	}
+	return nil
+}
+
+// placer tracks replicas already chosen for a record.
+type placer struct {
+	p      placement
+	counts []map[string]int
+}
+
+func (p placement) placer() placer {
+	counts := make([]map[string]int, len(p.constraints))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	return placer{p: p, counts: counts}
+}
+
+// place reserves a replica on node if no constraint is violated by that.
+func (pl placer) place(node storage.ServiceAddr) bool {
+	domains := pl.p.domains[node]
+	for i, c := range pl.p.constraints {
+		if pl.counts[i][domains[c.Level]] >= c.Max {
+			return false
+		}
+	}
+	for i, c := range pl.p.constraints {
+		pl.counts[i][domains[c.Level]]++
+	}
+	return true
+}
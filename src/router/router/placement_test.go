@@ -0,0 +1,95 @@
+package router
+
+import (
+	"testing"
+
+	"storage"
+)
+
+func TestParseConstraint(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want Constraint
+		err  bool
+	}{
+		{s: "max 1 replica per rack", want: Constraint{Level: "rack", Max: 1}},
+		{s: "max 2 replicas per zone", want: Constraint{Level: "zone", Max: 2}},
+		{s: "max 2 per zone", want: Constraint{Level: "zone", Max: 2}},
+		{s: "max 0 per zone", err: true},
+		{s: "min 1 per zone", err: true},
+		{s: "max per zone", err: true},
+	} {
+		got, err := ParseConstraint(test.s)
+		if (err != nil) != test.err {
+			t.Errorf("ParseConstraint(%q) error: %v", test.s, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseConstraint(%q) got %v, want %v", test.s, got, test.want)
+		}
+	}
+}
+
+var placementNodes = []storage.ServiceAddr{"node1", "node2", "node3", "node4", "node5", "node6"}
+
+var placementDomains = map[storage.ServiceAddr]Domains{
+	"node1": {"zone": "a", "rack": "r1"},
+	"node2": {"zone": "a", "rack": "r2"},
+	"node3": {"zone": "b", "rack": "r3"},
+	"node4": {"zone": "b", "rack": "r3"},
+	"node5": {"zone": "c", "rack": "r4"},
+	"node6": {"zone": "c", "rack": "r4"},
+}
+
+func TestNodesFind_Placement(t *testing.T) {
+	nf, err := NewNodesFinderWithPlacement(FakeHasher{
+		t: t,
+		hashes: map[storage.ServiceAddr]uint64{
+			"node1": 1,
+			"node2": 2,
+			"node3": 3,
+			"node4": 4,
+			"node5": 5,
+			"node6": 6,
+		},
+	}, Placement{
+		Domains:     placementDomains,
+		Constraints: []string{"max 1 per rack", "max 2 per zone"},
+	})
+	if err != nil {
+		t.Fatalf("NewNodesFinderWithPlacement() error: %v", err)
+	}
+	if err := nf.Validate(placementNodes); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	want := []storage.ServiceAddr{"node6", "node4", "node2"}
+	got := nf.NodesFind(1, placementNodes)
+	if !equalNodes(got, want) {
+		t.Errorf("NodesFind() wrong nodes, got %v, want %v", got, want)
+	}
+}
+
+func TestValidate_Placement(t *testing.T) {
+	for _, test := range []struct {
+		constraints []string
+		nodes       []storage.ServiceAddr
+		err         bool
+	}{
+		{constraints: []string{"max 1 per rack"}, nodes: placementNodes},
+		{constraints: []string{"max 1 per zone"}, nodes: placementNodes},
+		{constraints: []string{"max 1 per rack"}, nodes: placementNodes[2:], err: true},
+		{constraints: []string{"max 1 per host"}, nodes: placementNodes, err: true},
+	} {
+		nf, err := NewNodesFinderWithPlacement(NewMD5Hasher(), Placement{
+			Domains:     placementDomains,
+			Constraints: test.constraints,
+		})
+		if err != nil {
+			t.Fatalf("NewNodesFinderWithPlacement() error: %v", err)
+		}
+		if err := nf.Validate(test.nodes); (err != nil) != test.err {
+			t.Errorf("Validate(%v) with %v got error %v", test.nodes, test.constraints, err)
+		}
+	}
+}
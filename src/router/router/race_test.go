@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package router
+
+// raceEnabled reports whether tests run with the race detector, which
+// makes allocation counts meaningless.
+const raceEnabled = true
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"storage"
+)
+
+// RingSlices is a default number of slices of the key space Ring returns.
+//
+// RingSlices -- количество частей пространства ключей, возвращаемых Ring,
+// по умолчанию.
+const RingSlices = 64
+
+// RingSlice describes the placement of a slice of the key space.
+//
+// RingSlice описывает размещение части пространства ключей.
+type RingSlice struct {
+	// First is the first key of the slice.
+	// First -- первый ключ части.
+	First storage.RecordID
+	// Nodes are the nodes the first key of the slice should be stored on
+	// according to the NodesFinder regardless of their liveness.
+	// Nodes -- node, на которых должен храниться первый ключ части согласно
+	// NodesFinder, независимо от их доступности.
+	Nodes []storage.ServiceAddr
+}
+
+// Ring splits the key space into n equal slices and returns the placement
+// of the first key of each of them, RingSlices slices by default, so
+// the distribution of keys among nodes can be seen. Placements of keys
+// are independent, so the one of the first key only samples its slice.
+//
+// Ring делит пространство ключей на n равных частей и возвращает размещение
+// первого ключа каждой из них, по умолчанию RingSlices частей, чтобы можно
+// было увидеть распределение ключей по node. Размещения ключей независимы,
+// поэтому размещение первого ключа -- лишь выборка из его части.
+func (r *Router) Ring(n int) []RingSlice {
+	if n <= 0 {
+		n = RingSlices
+	}
+	set := r.nodeSet()
+	step := (uint64(1) << 32) / uint64(n)
+	if step == 0 {
+		step = 1
+	}
+	ring := make([]RingSlice, 0, n)
+	for i := 0; i < n && uint64(i)*step < 1<<32; i++ {
+		k := storage.RecordID(uint64(i) * step)
+		ring = append(ring, RingSlice{First: k, Nodes: r.conf.NodesFinder.NodesFind(k, set.list)})
+	}
+	return ring
+}
@@ -1,9 +1,20 @@
 package router
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"auth"
+	"events"
+	"fault"
+	"metrics"
+	"registry"
+	"secrets"
 	"storage"
 )
 
@@ -19,49 +30,292 @@ type Config struct {
 	// Nodes -- список node обслуживаемых Router.
 	Nodes []storage.ServiceAddr
 
+	// Registry specifies a service registry to build the list of nodes from
+	// instead of Nodes.
+	// Registry -- реестр сервисов, по которому строится список node
+	// вместо Nodes.
+	Registry registry.Config
+
 	// ForgetTimeout is a timeout after node is considered to be unavailable
 	// in absence of hearbeats.
 	// ForgetTimeout -- если в течении ForgetTimeout node не посылала heartbeats, то
 	// node считается недоступной.
 	ForgetTimeout time.Duration `yaml:"forget_timeout"`
 
+	// Heartbeat is a time interval between heartbeats of nodes. It is only
+	// used to check that ForgetTimeout is longer.
+	// Heartbeat -- интервал между heartbeats node. Используется только для
+	// проверки того, что ForgetTimeout больше.
+	Heartbeat time.Duration
+
+	// UDPAddr is an address to receive heartbeats over UDP at, RPC heartbeats
+	// are received anyway.
+	// UDPAddr -- адрес для получения heartbeats по UDP, heartbeats по RPC
+	// принимаются в любом случае.
+	UDPAddr storage.ServiceAddr `yaml:"udp_addr"`
+	// UDPKey is a secret key to authenticate UDP heartbeats with.
+	// UDPKey -- секретный ключ для аутентификации heartbeats по UDP.
+	UDPKey string `yaml:"udp_key"`
+
+	// AwaitHeartbeats makes the router trust the nodes it starts with only
+	// after they send a heartbeat, so nodes that died while the router was
+	// down are not returned by NodesFind during ForgetTimeout after restart.
+	// AwaitHeartbeats -- router доверяет node, с которыми запускается, только
+	// после получения от них heartbeat, так что node, отказавшие во время
+	// простоя router, не возвращаются NodesFind в течение ForgetTimeout после
+	// перезапуска.
+	AwaitHeartbeats bool `yaml:"await_heartbeats"`
+
+	// StateFile is a file to persist the liveness state of nodes in, so
+	// a restarted router doesn't consider dead nodes available and keeps
+	// nodes marked down. The state is not persisted if it is empty.
+	// StateFile -- файл для хранения состояния доступности node, чтобы
+	// перезапущенный router не считал отказавшие node доступными и сохранял
+	// отметки недоступности. Состояние не сохраняется, если он пуст.
+	StateFile string `yaml:"state_file"`
+
+	// AdminAddr is an address to serve the admin HTTP API marking nodes
+	// down and up at, the API is disabled if it is empty.
+	// AdminAddr -- адрес HTTP API администратора для отметки node
+	// недоступными и доступными, API отключен, если он пуст.
+	AdminAddr storage.ServiceAddr `yaml:"admin_addr"`
+
+	// NodesCacheSize is a number of NodesFind results to cache,
+	// negative value disables caching.
+	// NodesCacheSize -- количество кешируемых результатов NodesFind,
+	// отрицательное значение отключает кеширование.
+	NodesCacheSize int `yaml:"nodes_cache_size"`
+
 	// NodesFinder specifies a NodesFinder to use.
 	// NodesFinder -- NodesFinder, который нужно использовать в Router.
 	NodesFinder NodesFinder `yaml:"-"`
+
+	// Placement specifies failure domains of nodes and placement constraints.
+	// Placement -- failure domains node и ограничения на размещение реплик.
+	Placement Placement
+
+	// Faults enables the failure injection admin API.
+	// Faults -- включает API администратора для внесения сбоев.
+	Faults fault.Config
+
+	// Auth specifies tokens authorizing calls of admin APIs, calls are not
+	// authorized if it has none.
+	// Auth -- tokens для авторизации вызовов API администратора, вызовы
+	// не авторизуются, если их нет.
+	Auth auth.Config
+	// Secrets specifies a provider of secrets config values refer to as
+	// secret:<name>, e.g. keys and tokens, which are rotated without
+	// restarts.
+	// Secrets -- хранилище секретов, на которые значения конфигурации
+	// ссылаются как secret:<name>, например ключей и tokens, которые
+	// заменяются без перезапуска.
+	Secrets secrets.Config
+
+	// Metrics specifies a metrics sink and its options.
+	// Metrics -- приемник метрик и его настройки.
+	Metrics metrics.Config
+
+	// Events specifies subscribers of events published by the router.
+	// Events -- подписчики событий, публикуемых router.
+	Events events.Config
+
+	// Bus specifies a bus to publish events to, the one built from Events
+	// is used by default.
+	// Bus -- шина, в которую публикуются события, по умолчанию
+	// используется построенная по Events.
+	Bus *events.Bus `yaml:"-"`
+
+	// Sink specifies a sink to report metrics to.
+	// Sink -- приемник, в который отправляются метрики.
+	Sink metrics.Sink `yaml:"-"`
+
+	// Logger specifies a logger, the standard logger is used by default.
+	// Logger -- логгер, по умолчанию используется стандартный логгер.
+	Logger *log.Logger `yaml:"-"`
+
+	// Clock specifies a clock to tell time with.
+	// Clock -- часы, по которым определяется время.
+	Clock storage.Clock `yaml:"-"`
+}
+
+// Validate checks that cfg is complete and consistent and returns
+// a storage.ConfigError listing all found problems.
+//
+// Validate проверяет, что cfg полна и непротиворечива, и возвращает
+// storage.ConfigError со списком всех найденных проблем.
+func (cfg Config) Validate() error {
+	var errs storage.ConfigError
+	errs.Check(cfg.Addr != "", "Addr should be set")
+	errs.Check(len(cfg.Nodes) >= storage.ReplicationFactor || cfg.Registry.Kind != "",
+		"Nodes should list at least %v nodes, got %v", storage.ReplicationFactor, len(cfg.Nodes))
+	errs.Check(cfg.ForgetTimeout > 0, "ForgetTimeout should be positive, got %v", cfg.ForgetTimeout)
+	errs.Check(cfg.Heartbeat < cfg.ForgetTimeout,
+		"Heartbeat %v should be less than ForgetTimeout %v, otherwise live nodes are forgotten", cfg.Heartbeat, cfg.ForgetTimeout)
+	errs.Check(!cfg.NodesFinder.IsZero(), "NodesFinder should be set")
+	errs.Check(cfg.UDPAddr == "" || cfg.UDPKey != "", "UDPKey should be set with UDPAddr")
+	errs.Check(cfg.Registry.Kind == "" || cfg.Registry.Addr != "", "Registry.Addr should be set with Registry.Kind")
+	return errs.Err()
+}
+
+// nodeState is a liveness state of a node. It is updated atomically,
+// so heartbeats and NodesFind don't contend on a lock.
+type nodeState struct {
+	// heartbeat is a time of the last heartbeat in unix nanoseconds.
+	heartbeat int64
+	// degraded is 1 if the node reported itself degraded.
+	degraded int32
+	// seq is a sequence number of the last operation the node reported.
+	seq uint64
+	// down is 1 if the node was marked down by an operator.
+	down int32
+	// awaiting is 1 if the node hasn't sent a heartbeat since the router
+	// started with cfg.AwaitHeartbeats.
+	awaiting int32
+	// up is 1 if the node was available at the last CheckNodes.
+	up int32
+}
+
+// nodeSet is a set of nodes served by Router. It is never modified,
+// a new set replaces it when nodes are changed, so it is read without locking.
+type nodeSet struct {
+	list   []storage.ServiceAddr
+	states map[storage.ServiceAddr]*nodeState
 }
 
 // Router is a router service.
 type Router struct {
-	conf      Config
-	heartbeat map[storage.ServiceAddr]time.Time
-	lock      sync.RWMutex
+	conf Config
+	// nodes holds the current *nodeSet.
+	nodes    atomic.Value
+	nodeLock sync.Mutex
+	// epoch is incremented each time a node becomes available,
+	// changes its degraded state, is marked down or up or the set of nodes
+	// is changed.
+	epoch uint64
+	cache nodesCache
 }
 
-// New creates a new Router with a given cfg.
+// New creates a new Router with a given cfg modified by opts.
 // Returns storage.ErrNotEnoughDaemons error if less then storage.ReplicationFactor
-// nodes was provided in cfg.Nodes.
+// nodes was provided in cfg.Nodes, a storage.ConfigError if cfg is invalid
+// and an error if placement constraints of cfg.NodesFinder can't be satisfied
+// or cfg.StateFile can't be loaded.
 //
-// New создает новый Router с данным cfg.
+// New создает новый Router с данным cfg, измененной opts.
 // Возвращает ошибку storage.ErrNotEnoughDaemons если в cfg.Nodes
-// меньше чем storage.ReplicationFactor nodes.
-func New(cfg Config) (*Router, error) {
+// меньше чем storage.ReplicationFactor nodes, storage.ConfigError, если cfg
+// некорректна, и ошибку, если ограничения на размещение cfg.NodesFinder
+// не могут быть выполнены или cfg.StateFile не может быть загружен.
+func New(cfg Config, opts ...Option) (*Router, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	if len(cfg.Nodes) < storage.ReplicationFactor {
 		return nil, storage.ErrNotEnoughDaemons
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.NodesFinder.Validate(cfg.Nodes); err != nil {
+		return nil, err
+	}
+	if cfg.Sink == nil {
+		cfg.Sink = metrics.Discard
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = storage.SystemClock
+	}
+	if cfg.NodesCacheSize == 0 {
+		cfg.NodesCacheSize = NodesCacheSize
+	}
+	if cfg.Bus == nil {
+		cfg.Bus = events.New(cfg.Events, cfg.Addr, cfg.Sink, cfg.Logger, cfg.Clock)
+	}
 
 	ret := Router{
-		conf:      cfg,
-		heartbeat: make(map[storage.ServiceAddr]time.Time),
+		conf:  cfg,
+		cache: newNodesCache(cfg.NodesCacheSize),
 	}
-
-	now := time.Now()
-	for _, node := range cfg.Nodes {
-		ret.heartbeat[node] = now
+	set := newNodeSet(cfg.Nodes, nil, cfg.Clock.Now())
+	if cfg.AwaitHeartbeats {
+		for _, state := range set.states {
+			state.awaiting = 1
+			state.up = 0
+		}
+	}
+	ret.nodes.Store(set)
+	if cfg.StateFile != "" {
+		if err := ret.loadState(); err != nil {
+			return nil, fmt.Errorf("Failed to load state from %q: %v", cfg.StateFile, err)
+		}
 	}
 
 	return &ret, nil
 }
 
+// newNodeSet creates a set of nodes keeping states of nodes from old.
+// New nodes are considered available at the time at.
+func newNodeSet(nodes []storage.ServiceAddr, old *nodeSet, at time.Time) *nodeSet {
+	set := &nodeSet{
+		list:   nodes,
+		states: make(map[storage.ServiceAddr]*nodeState, len(nodes)),
+	}
+	now := at.UnixNano()
+	for _, node := range nodes {
+		if old != nil && old.states[node] != nil {
+			set.states[node] = old.states[node]
+			continue
+		}
+		set.states[node] = &nodeState{heartbeat: now, up: 1}
+	}
+	return set
+}
+
+func (r *Router) nodeSet() *nodeSet {
+	return r.nodes.Load().(*nodeSet)
+}
+
+// SetNodes replaces the list of nodes served by the Router. Liveness state
+// of nodes remaining in the list is kept, new nodes are considered available.
+// Returns storage.ErrNotEnoughDaemons error if less then storage.ReplicationFactor
+// nodes are provided and an error if placement constraints of cfg.NodesFinder
+// can't be satisfied, the list is not changed then.
+//
+// SetNodes заменяет список node, обслуживаемых Router. Состояние node,
+// остающихся в списке, сохраняется, новые node считаются доступными.
+// Возвращает ошибку storage.ErrNotEnoughDaemons если передано меньше чем
+// storage.ReplicationFactor nodes, и ошибку, если ограничения на размещение
+// cfg.NodesFinder не могут быть выполнены, список при этом не меняется.
+func (r *Router) SetNodes(nodes []storage.ServiceAddr) error {
+	if len(nodes) < storage.ReplicationFactor {
+		return storage.ErrNotEnoughDaemons
+	}
+	if err := r.conf.NodesFinder.Validate(nodes); err != nil {
+		return err
+	}
+
+	r.nodeLock.Lock()
+	defer r.nodeLock.Unlock()
+	old := r.nodeSet()
+	set := newNodeSet(nodes, old, r.conf.Clock.Now())
+	r.nodes.Store(set)
+	atomic.AddUint64(&r.epoch, 1)
+	for _, node := range nodes {
+		if old.states[node] == nil {
+			r.conf.Bus.Publish(events.Event{Kind: events.NodeJoined, Node: node})
+		}
+	}
+	for _, node := range old.list {
+		if set.states[node] == nil {
+			r.conf.Bus.Publish(events.Event{Kind: events.NodeLeft, Node: node})
+		}
+	}
+	return nil
+}
+
 // Hearbeat registers node in the router.
 // Returns storage.ErrUnknownDaemon error if node is not served by the Router.
 
@@ -69,47 +323,206 @@ func New(cfg Config) (*Router, error) {
 // Возвращает ошибку storage.ErrUnknownDaemon если node не
 // обслуживается Router.
 func (r *Router) Heartbeat(node storage.ServiceAddr) error {
-	r.lock.Lock()
-	defer r.lock.Unlock()
+	return r.register(storage.Heartbeat{Node: node})
+}
+
+// HeartbeatContext is Heartbeat returning ctx.Err() if ctx is done.
+//
+// HeartbeatContext -- Heartbeat, возвращающий ctx.Err(), если ctx завершен.
+func (r *Router) HeartbeatContext(ctx context.Context, node storage.ServiceAddr) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Heartbeat(node)
+}
 
-	if _, ok := r.heartbeat[node]; !ok {
+// HeartbeatDegraded registers node in the router as alive but unhealthy,
+// so it is not returned by NodesFind until it sends a healthy heartbeat.
+// Returns storage.ErrUnknownDaemon error if node is not served by the Router.
+//
+// HeartbeatDegraded регистритрует node в router как доступную, но неисправную,
+// поэтому она не возвращается NodesFind до получения обычного heartbeat.
+// Возвращает ошибку storage.ErrUnknownDaemon если node не
+// обслуживается Router.
+func (r *Router) HeartbeatDegraded(node storage.ServiceAddr) error {
+	return r.register(storage.Heartbeat{Node: node, Degraded: true})
+}
+
+// Beat registers a node in the router with its degraded state and sequence
+// number carried by hb. Returns storage.ErrUnknownDaemon error if the node
+// is not served by the Router.
+//
+// Beat регистрирует node в router с состоянием неисправности и номером
+// последовательности из hb. Возвращает ошибку storage.ErrUnknownDaemon,
+// если node не обслуживается Router.
+func (r *Router) Beat(hb storage.Heartbeat) error {
+	return r.register(hb)
+}
+
+// HeartbeatBatch registers several nodes in the router at once.
+// Returns an error for each heartbeat, storage.ErrUnknownDaemon
+// for nodes not served by the Router and nil for others.
+//
+// HeartbeatBatch регистрирует в router сразу несколько node.
+// Возвращает ошибку для каждого heartbeat: storage.ErrUnknownDaemon
+// для node, не обслуживаемых Router, и nil для остальных.
+func (r *Router) HeartbeatBatch(beats []storage.Heartbeat) []error {
+	errs := make([]error, 0, len(beats))
+	for _, beat := range beats {
+		errs = append(errs, r.register(beat))
+	}
+	return errs
+}
+
+// Heartbeats returns the last heartbeat of every node served by the Router,
+// so nodes can compare the sequence numbers of their peers with the ones
+// they have seen to tell whether they are behind.
+//
+// Heartbeats возвращает последний heartbeat каждой node, обслуживаемой
+// Router, так что node могут сравнить номера последовательности других node
+// с известными им, чтобы понять, отстают ли они.
+func (r *Router) Heartbeats() []storage.Heartbeat {
+	set := r.nodeSet()
+	beats := make([]storage.Heartbeat, 0, len(set.list))
+	for _, node := range set.list {
+		state := set.states[node]
+		beats = append(beats, storage.Heartbeat{
+			Node:     node,
+			Degraded: atomic.LoadInt32(&state.degraded) != 0,
+			Seq:      atomic.LoadUint64(&state.seq),
+		})
+	}
+	return beats
+}
+
+func (r *Router) register(hb storage.Heartbeat) error {
+	node, degraded := hb.Node, hb.Degraded
+	state, ok := r.nodeSet().states[node]
+	if !ok {
+		r.conf.Sink.IncrCounter("router.heartbeat.errors", 1)
 		return storage.ErrUnknownDaemon
 	}
 
-	r.heartbeat[node] = time.Now()
+	r.conf.Sink.IncrCounter("router.heartbeat", 1)
+	now := r.conf.Clock.Now().UnixNano()
+	age := time.Duration(now - atomic.SwapInt64(&state.heartbeat, now))
+	r.conf.Sink.ObserveDuration("router.heartbeat.age", age)
+	if atomic.SwapInt32(&state.awaiting, 0) != 0 || age > r.conf.ForgetTimeout {
+		// The node was forgotten or awaited and is available again.
+		atomic.AddUint64(&r.epoch, 1)
+	}
+	flag := boolFlag(degraded)
+	atomic.StoreUint64(&state.seq, hb.Seq)
+	if atomic.SwapInt32(&state.degraded, flag) != flag {
+		r.conf.Logger.Printf("Node %q reported degraded = %v", node, degraded)
+		atomic.AddUint64(&r.epoch, 1)
+	}
 	return nil
 }
 
+// alive reports whether node sent a healthy heartbeat within ForgetTimeout
+// before now and is neither marked down nor awaited.
+func (r *Router) alive(state *nodeState, now int64) bool {
+	return now-atomic.LoadInt64(&state.heartbeat) <= int64(r.conf.ForgetTimeout) &&
+		atomic.LoadInt32(&state.degraded) == 0 && atomic.LoadInt32(&state.down) == 0 &&
+		atomic.LoadInt32(&state.awaiting) == 0
+}
+
 // NodesFind returns a list of available nodes, where record with associated key k
-// should be stored. Returns storage.ErrNotEnoughDaemons error
+// should be stored. Returns a *NodesFindError matching storage.ErrNotEnoughDaemons
 // if less then storage.MinRedundancy can be returned.
 //
 // NodesFind возвращает cписок достпуных node, на которых должна храниться
-// запись с ключом k. Возвращает ошибку storage.ErrNotEnoughDaemons
-// если меньше, чем storage.MinRedundancy найдено.
+// запись с ключом k. Возвращает *NodesFindError, соответствующую
+// storage.ErrNotEnoughDaemons, если меньше, чем storage.MinRedundancy найдено.
 func (r *Router) NodesFind(k storage.RecordID) ([]storage.ServiceAddr, error) {
-	nodes := r.conf.NodesFinder.NodesFind(k, r.conf.Nodes)
-	foundNodes := make([]storage.ServiceAddr, 0, len(nodes))
-	now := time.Now()
+	now := r.conf.Clock.Now().UnixNano()
+	epoch := atomic.LoadUint64(&r.epoch)
 
-	for _, node := range nodes {
-		r.lock.RLock()
-		if now.Sub(r.heartbeat[node]) <= r.conf.ForgetTimeout {
-			foundNodes = append(foundNodes, node)
-		}
-		r.lock.RUnlock()
+	e := r.cache.get(k, epoch, now)
+	if e == nil {
+		e = r.nodesFind(k, epoch, now)
+		r.cache.put(e)
 	}
 
-	if len(foundNodes) < storage.MinRedundancy {
-		return nil, storage.ErrNotEnoughDaemons
+	if len(e.nodes) < storage.MinRedundancy {
+		r.conf.Sink.IncrCounter("router.nodes_find.errors", 1)
+		return nil, r.nodesFindError(k, now)
 	}
 
+	foundNodes := make([]storage.ServiceAddr, len(e.nodes))
+	copy(foundNodes, e.nodes)
 	return foundNodes, nil
 }
 
+// NodesFindContext is NodesFind returning ctx.Err() if ctx is done.
+//
+// NodesFindContext -- NodesFind, возвращающий ctx.Err(), если ctx завершен.
+func (r *Router) NodesFindContext(ctx context.Context, k storage.RecordID) ([]storage.ServiceAddr, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.NodesFind(k)
+}
+
+// nodesFind computes a NodesFind result for k valid until some of
+// the found nodes may be forgotten or epoch changes.
+func (r *Router) nodesFind(k storage.RecordID, epoch uint64, now int64) *cacheEntry {
+	set := r.nodeSet()
+	nodes := r.conf.NodesFinder.NodesFind(k, set.list)
+	e := &cacheEntry{
+		key:      k,
+		epoch:    epoch,
+		deadline: math.MaxInt64,
+		nodes:    make([]storage.ServiceAddr, 0, len(nodes)),
+	}
+
+	for _, node := range nodes {
+		state := set.states[node]
+		if r.alive(state, now) {
+			e.nodes = append(e.nodes, node)
+			deadline := atomic.LoadInt64(&state.heartbeat) + int64(r.conf.ForgetTimeout)
+			if deadline < e.deadline {
+				e.deadline = deadline
+			}
+		}
+	}
+	return e
+}
+
 // List returns a list of all nodes served by Router.
 //
 // List возвращает cписок всех node, обслуживаемых Router.
 func (r *Router) List() []storage.ServiceAddr {
-	return r.conf.Nodes
+	return r.nodeSet().list
+}
+
+// Orphans returns keys among the given ones which records should not be
+// stored on node according to the NodesFinder regardless of nodes liveness.
+// Returns storage.ErrUnknownDaemon error if node is not served by the Router.
+//
+// Orphans возвращает ключи из данных, записи с которыми не должны храниться
+// на node согласно NodesFinder, независимо от доступности node.
+// Возвращает ошибку storage.ErrUnknownDaemon если node не
+// обслуживается Router.
+func (r *Router) Orphans(node storage.ServiceAddr, keys []storage.RecordID) ([]storage.RecordID, error) {
+	set := r.nodeSet()
+	if _, ok := set.states[node]; !ok {
+		return nil, storage.ErrUnknownDaemon
+	}
+
+	orphans := []storage.RecordID{}
+	for _, k := range keys {
+		owned := false
+		for _, owner := range r.conf.NodesFinder.NodesFind(k, set.list) {
+			if owner == node {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			orphans = append(orphans, k)
+		}
+	}
+	return orphans, nil
 }
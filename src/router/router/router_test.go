@@ -1,13 +1,18 @@
 package router
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"events"
+	"metrics"
 	"storage"
 )
 
@@ -90,6 +95,27 @@ func TestHeartbeat(t *testing.T) {
 	}
 }
 
+func TestHeartbeats(t *testing.T) {
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.Beat(storage.Heartbeat{Node: "node1", Seq: 10}); err != nil {
+		t.Fatalf("Beat() error: %v", err)
+	}
+	if err := r.HeartbeatBatch([]storage.Heartbeat{{Node: "node2", Degraded: true, Seq: 20}})[0]; err != nil {
+		t.Fatalf("HeartbeatBatch() error: %v", err)
+	}
+	want := []storage.Heartbeat{
+		{Node: "node1", Seq: 10},
+		{Node: "node2", Degraded: true, Seq: 20},
+		{Node: "node3"},
+	}
+	if got := r.Heartbeats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Heartbeats() got %v, want %v", got, want)
+	}
+}
+
 func TestParallelOps(t *testing.T) {
 	r, err := New(cfg)
 	if err != nil {
@@ -165,7 +191,7 @@ func TestRouterNodesFind(t *testing.T) {
 		t.Run(fmt.Sprintf("want=%v,nodes=%v", len(test.want), len(test.nodes)), func(t *testing.T) {
 			registerNodes(t, r, test.nodes, cfg.ForgetTimeout)
 			got, err := r.NodesFind(1)
-			if test.err != err {
+			if !errors.Is(err, test.err) {
 				t.Fatalf("NodesFor() expected error %v, got %v", test.err, err)
 			}
 			if err != nil {
@@ -213,3 +239,619 @@ func TestRouterNodesFind_SameHashes(t *testing.T) {
 		}
 	}
 }
+
+func TestOrphans(t *testing.T) {
+	cfg := Config{
+		Addr:  "router",
+		Nodes: []storage.ServiceAddr{"node1", "node2", "node3", "node4"},
+		NodesFinder: NewNodesFinder(FakeHasher{
+			t: t,
+			hashes: map[storage.ServiceAddr]uint64{
+				"node1": 1,
+				"node2": 2,
+				"node3": 3,
+				"node4": 4,
+			}}),
+		ForgetTimeout: 10 * time.Millisecond,
+	}
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	keys := []storage.RecordID{1, 2, 3}
+	if _, err := r.Orphans("unknown", keys); err != storage.ErrUnknownDaemon {
+		t.Errorf("Orphans() got error %v, want %v", err, storage.ErrUnknownDaemon)
+	}
+
+	// Ownership doesn't depend on liveness of nodes.
+	time.Sleep(cfg.ForgetTimeout)
+	for node, want := range map[storage.ServiceAddr][]storage.RecordID{
+		"node1": keys,
+		"node4": {},
+	} {
+		got, err := r.Orphans(node, keys)
+		if err != nil {
+			t.Fatalf("Orphans() error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Orphans(%v) got %v, want %v", node, got, want)
+		}
+	}
+}
+
+func TestHeartbeatDegraded(t *testing.T) {
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.HeartbeatDegraded("unknown"); err != storage.ErrUnknownDaemon {
+		t.Errorf("HeartbeatDegraded() got %v, exptected error %v", err, storage.ErrUnknownDaemon)
+	}
+
+	if err := r.HeartbeatDegraded(cfg.Nodes[0]); err != nil {
+		t.Fatalf("HeartbeatDegraded() error: %v", err)
+	}
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[1:]) {
+		t.Errorf("NodesFind() got %v, want %v without degraded node", nodes, cfg.Nodes[1:])
+	}
+
+	if err := r.Heartbeat(cfg.Nodes[0]); err != nil {
+		t.Fatalf("Heartbeat() error: %v", err)
+	}
+	nodes, err = r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes) {
+		t.Errorf("NodesFind() got %v, want %v after recovery", nodes, cfg.Nodes)
+	}
+}
+
+func TestNodeStatuses(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	r, err := New(cfg, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.MarkDown(cfg.Nodes[0]); err != nil {
+		t.Fatalf("MarkDown() error: %v", err)
+	}
+	if err := r.HeartbeatDegraded(cfg.Nodes[1]); err != nil {
+		t.Fatalf("HeartbeatDegraded() error: %v", err)
+	}
+	clock.now = clock.now.Add(cfg.ForgetTimeout / 2)
+	if err := r.Beat(storage.Heartbeat{Node: cfg.Nodes[2], Seq: 7}); err != nil {
+		t.Fatalf("Beat() error: %v", err)
+	}
+
+	want := []NodeStatus{
+		{Node: cfg.Nodes[0], Down: true, HeartbeatAge: cfg.ForgetTimeout / 2},
+		{Node: cfg.Nodes[1], Degraded: true, HeartbeatAge: cfg.ForgetTimeout / 2},
+		{Node: cfg.Nodes[2], Alive: true, Seq: 7},
+	}
+	if got := r.NodeStatuses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeStatuses() got %+v, want %+v", got, want)
+	}
+	// Unlike NodesFind, Placement keeps unavailable nodes, in the order
+	// of the finder.
+	want[0], want[2] = want[2], want[0]
+	if got := r.Placement(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Placement() got %+v, want %+v", got, want)
+	}
+}
+
+func TestMarkDown(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	r, err := New(cfg, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.MarkDown("unknown"); err != storage.ErrUnknownDaemon {
+		t.Errorf("MarkDown() got %v, exptected error %v", err, storage.ErrUnknownDaemon)
+	}
+	if err := r.MarkUp("unknown"); err != storage.ErrUnknownDaemon {
+		t.Errorf("MarkUp() got %v, exptected error %v", err, storage.ErrUnknownDaemon)
+	}
+
+	if err := r.MarkDown(cfg.Nodes[0]); err != nil {
+		t.Fatalf("MarkDown() error: %v", err)
+	}
+	if err := r.Heartbeat(cfg.Nodes[0]); err != nil {
+		t.Fatalf("Heartbeat() error: %v", err)
+	}
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[1:]) {
+		t.Errorf("NodesFind() got %v, want %v without node marked down", nodes, cfg.Nodes[1:])
+	}
+	if down := r.MarkedDown(); !equalNodes(down, cfg.Nodes[:1]) {
+		t.Errorf("MarkedDown() got %v, want %v", down, cfg.Nodes[:1])
+	}
+
+	clock.now = clock.now.Add(2 * cfg.ForgetTimeout)
+	if err := r.MarkUp(cfg.Nodes[0]); err != nil {
+		t.Fatalf("MarkUp() error: %v", err)
+	}
+	nodes, err = r.NodesFind(1)
+	var nfe *NodesFindError
+	if !errors.As(err, &nfe) {
+		t.Fatalf("NodesFind() got error %v, want %T", err, nfe)
+	}
+	for _, ex := range nfe.Excluded {
+		if ex.Node == cfg.Nodes[0] {
+			t.Errorf("NodesFind() excluded %v, want only stale nodes after MarkUp", nfe.Excluded)
+		}
+	}
+	if down := r.MarkedDown(); len(down) != 0 {
+		t.Errorf("MarkedDown() got %v, want none", down)
+	}
+}
+
+func TestAwaitHeartbeats(t *testing.T) {
+	c := cfg
+	c.AwaitHeartbeats = true
+	r, err := New(c)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	_, err = r.NodesFind(1)
+	var nfe *NodesFindError
+	if !errors.As(err, &nfe) {
+		t.Fatalf("NodesFind() got error %v, want %T before heartbeats", err, nfe)
+	}
+	for _, ex := range nfe.Excluded {
+		if ex.Reason != "no heartbeat since start" {
+			t.Errorf("NodesFind() excluded %v for %q, want no heartbeat since start", ex.Node, ex.Reason)
+		}
+	}
+
+	for _, node := range cfg.Nodes[1:] {
+		if err := r.Heartbeat(node); err != nil {
+			t.Fatalf("Heartbeat() error: %v", err)
+		}
+	}
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[1:]) {
+		t.Errorf("NodesFind() got %v, want %v without awaited node", nodes, cfg.Nodes[1:])
+	}
+}
+
+func TestState(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := cfg
+	c.StateFile = filepath.Join(t.TempDir(), "router.state")
+	c.AwaitHeartbeats = true
+	r, err := New(c, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.Beat(storage.Heartbeat{Node: "node1", Seq: 7}); err != nil {
+		t.Fatalf("Beat() error: %v", err)
+	}
+	if err := r.Heartbeat("node2"); err != nil {
+		t.Fatalf("Heartbeat() error: %v", err)
+	}
+	if err := r.MarkDown("node3"); err != nil {
+		t.Fatalf("MarkDown() error: %v", err)
+	}
+	if err := r.SaveState(); err != nil {
+		t.Fatalf("SaveState() error: %v", err)
+	}
+
+	clock.now = clock.now.Add(cfg.ForgetTimeout / 2)
+	r, err = New(c, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[:2]) {
+		t.Errorf("NodesFind() got %v, want %v after restart", nodes, cfg.Nodes[:2])
+	}
+	if beats := r.Heartbeats(); beats[0].Seq != 7 {
+		t.Errorf("Heartbeats() got %v, want seq 7 for node1 after restart", beats)
+	}
+	if down := r.MarkedDown(); !equalNodes(down, []storage.ServiceAddr{"node3"}) {
+		t.Errorf("MarkedDown() got %v, want node3 after restart", down)
+	}
+
+	clock.now = clock.now.Add(cfg.ForgetTimeout)
+	r, err = New(c, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if _, err := r.NodesFind(1); !errors.Is(err, storage.ErrNotEnoughDaemons) {
+		t.Errorf("NodesFind() got %v, want %v for nodes stale before restart", err, storage.ErrNotEnoughDaemons)
+	}
+}
+
+func TestHeartbeatBatch(t *testing.T) {
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	errs := r.HeartbeatBatch([]storage.Heartbeat{
+		{Node: cfg.Nodes[0], Degraded: true},
+		{Node: "unknown"},
+		{Node: cfg.Nodes[1]},
+	})
+	want := []error{nil, storage.ErrUnknownDaemon, nil}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("HeartbeatBatch() got %v, want %v", errs, want)
+	}
+
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[1:]) {
+		t.Errorf("NodesFind() got %v, want %v without degraded node", nodes, cfg.Nodes[1:])
+	}
+}
+
+func BenchmarkHeartbeat(b *testing.B) {
+	r, err := New(cfg)
+	if err != nil {
+		b.Fatalf("New() error: %v", err)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			r.Heartbeat(cfg.Nodes[i%len(cfg.Nodes)])
+		}
+	})
+}
+
+func BenchmarkRouterNodesFind(b *testing.B) {
+	c := cfg
+	c.ForgetTimeout = time.Hour
+	r, err := New(c)
+	if err != nil {
+		b.Fatalf("New() error: %v", err)
+	}
+
+	// Heartbeats are received concurrently with NodesFind.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				r.Heartbeat(cfg.Nodes[i%len(cfg.Nodes)])
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			r.NodesFind(storage.RecordID(i))
+		}
+	})
+}
+
+func TestNodesFind_Cache(t *testing.T) {
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	nodes, err := r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes) {
+		t.Fatalf("NodesFind() got %v, want %v", nodes, cfg.Nodes)
+	}
+
+	// Cached result is invalidated by a change of the degraded state.
+	if err := r.HeartbeatDegraded(cfg.Nodes[0]); err != nil {
+		t.Fatalf("HeartbeatDegraded() error: %v", err)
+	}
+	nodes, err = r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes[1:]) {
+		t.Fatalf("NodesFind() got %v, want %v without degraded node", nodes, cfg.Nodes[1:])
+	}
+
+	// Cached result expires when nodes are forgotten.
+	time.Sleep(cfg.ForgetTimeout + 10*time.Millisecond)
+	if _, err := r.NodesFind(1); !errors.Is(err, storage.ErrNotEnoughDaemons) {
+		t.Fatalf("NodesFind() got error %v, want %v", err, storage.ErrNotEnoughDaemons)
+	}
+
+	// Cached result is invalidated when forgotten nodes are available again.
+	registerNodes(t, r, cfg.Nodes, 0)
+	nodes, err = r.NodesFind(1)
+	if err != nil {
+		t.Fatalf("NodesFind() error: %v", err)
+	}
+	if !equalNodes(nodes, cfg.Nodes) {
+		t.Errorf("NodesFind() got %v, want %v", nodes, cfg.Nodes)
+	}
+}
+
+func TestSetNodes(t *testing.T) {
+	c := cfg
+	c.NodesFinder = NewNodesFinder(NewMD5Hasher())
+	r, err := New(c)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := r.HeartbeatDegraded(cfg.Nodes[0]); err != nil {
+		t.Fatalf("HeartbeatDegraded() error: %v", err)
+	}
+
+	if err := r.SetNodes(cfg.Nodes[:2]); err != storage.ErrNotEnoughDaemons {
+		t.Errorf("SetNodes() got error %v, want %v", err, storage.ErrNotEnoughDaemons)
+	}
+
+	nodes := append([]storage.ServiceAddr{"node4"}, cfg.Nodes...)
+	if err := r.SetNodes(nodes); err != nil {
+		t.Fatalf("SetNodes() error: %v", err)
+	}
+	if got := r.List(); !reflect.DeepEqual(got, nodes) {
+		t.Errorf("List() got %v, want %v", got, nodes)
+	}
+
+	// The degraded state of a remaining node is kept.
+	for k := storage.RecordID(0); k < 100; k++ {
+		found, err := r.NodesFind(k)
+		if err != nil {
+			t.Fatalf("NodesFind() error: %v", err)
+		}
+		for _, node := range found {
+			if node == cfg.Nodes[0] {
+				t.Fatalf("NodesFind() got degraded node %v", node)
+			}
+		}
+	}
+
+	if err := r.Heartbeat("node4"); err != nil {
+		t.Errorf("Heartbeat() of a new node error: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	err := Config{
+		Nodes:         cfg.Nodes,
+		ForgetTimeout: time.Second,
+		Heartbeat:     2 * time.Second,
+		UDPAddr:       "127.0.0.1:1235",
+	}.Validate()
+	want := storage.ConfigError{
+		"Addr should be set",
+		"Heartbeat 2s should be less than ForgetTimeout 1s, otherwise live nodes are forgotten",
+		"NodesFinder should be set",
+		"UDPKey should be set with UDPAddr",
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Validate() got %v, want %v", err, want)
+	}
+}
+
+func TestNewDefault(t *testing.T) {
+	r, err := NewDefault(Config{
+		Addr:          "router",
+		Nodes:         cfg.Nodes,
+		ForgetTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewDefault() error: %v", err)
+	}
+	if r.conf.NodesFinder.IsZero() {
+		t.Errorf("NewDefault() left NodesFinder unset")
+	}
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	r, err := New(cfg, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if nodes, err := r.NodesFind(1); err != nil || !equalNodes(nodes, cfg.Nodes) {
+		t.Errorf("NodesFind() got %v, %v, want %v", nodes, err, cfg.Nodes)
+	}
+
+	clock.now = clock.now.Add(2 * cfg.ForgetTimeout)
+	if _, err := r.NodesFind(2); !errors.Is(err, storage.ErrNotEnoughDaemons) {
+		t.Errorf("NodesFind() got error %v after ForgetTimeout, want %v", err, storage.ErrNotEnoughDaemons)
+	}
+}
+
+type gaugeSink struct {
+	metrics.Sink
+	gauges    map[string]float64
+	durations map[string][]time.Duration
+}
+
+func (s *gaugeSink) SetGauge(name string, value float64) {
+	s.gauges[name] = value
+}
+
+func (s *gaugeSink) ObserveDuration(name string, d time.Duration) {
+	s.durations[name] = append(s.durations[name], d)
+}
+
+func TestReportHeartbeatAges(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	sink := &gaugeSink{
+		Sink:      metrics.Discard,
+		gauges:    make(map[string]float64),
+		durations: make(map[string][]time.Duration),
+	}
+	c := cfg
+	c.Nodes = []storage.ServiceAddr{"127.0.0.1:1", "node2", "node3"}
+	r, err := New(c, WithClock(clock), WithMetrics(sink))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	clock.now = clock.now.Add(3 * time.Second)
+	if err := r.Heartbeat("node2"); err != nil {
+		t.Fatalf("Heartbeat() error: %v", err)
+	}
+	if got := sink.durations["router.heartbeat.age"]; len(got) != 1 || got[0] != 3*time.Second {
+		t.Errorf("Heartbeat() observed ages %v, want [3s]", got)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	r.ReportHeartbeatAges()
+	for name, want := range map[string]float64{
+		"router.heartbeat.age.127_0_0_1_1": 4,
+		"router.heartbeat.age.node2":       1,
+		"router.heartbeat.age.node3":       4,
+		"router.heartbeat.age_max":         4,
+		"router.forget_timeout":            c.ForgetTimeout.Seconds(),
+	} {
+		if got, ok := sink.gauges[name]; !ok || got != want {
+			t.Errorf("Gauge %v got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNodesFindError(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	r, err := New(cfg, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	clock.now = clock.now.Add(2 * cfg.ForgetTimeout)
+	if err := r.HeartbeatDegraded("node2"); err != nil {
+		t.Fatalf("HeartbeatDegraded() error: %v", err)
+	}
+
+	_, err = r.NodesFind(1)
+	var nfe *NodesFindError
+	if !errors.As(err, &nfe) || !errors.Is(err, storage.ErrNotEnoughDaemons) {
+		t.Fatalf("NodesFind() got error %v, want %T", err, nfe)
+	}
+	if nfe.Key != 1 || !equalNodes(nfe.Candidates, cfg.Nodes) {
+		t.Errorf("NodesFind() error got key %v and candidates %v, want 1 and %v", nfe.Key, nfe.Candidates, cfg.Nodes)
+	}
+	want := map[storage.ServiceAddr]string{
+		"node1": "stale heartbeat 200ms ago",
+		"node2": "degraded",
+		"node3": "stale heartbeat 200ms ago",
+	}
+	got := make(map[storage.ServiceAddr]string)
+	for _, ex := range nfe.Excluded {
+		got[ex.Node] = ex.Reason
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NodesFind() error excluded %v, want %v", got, want)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "node2 (degraded)") {
+		t.Errorf("NodesFind() error message %q doesn't explain exclusions", msg)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	got := make(chan events.Event, 16)
+	bus := events.NewBus("router", nil, clock)
+	bus.Subscribe(events.SubscriberFunc(func(e events.Event) {
+		got <- e
+	}))
+	c := cfg
+	c.NodesFinder = NewNodesFinder(NewMD5Hasher())
+	r, err := New(c, WithClock(clock), WithEvents(bus))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	expect := func(kind events.Kind, node storage.ServiceAddr, reason string) {
+		t.Helper()
+		select {
+		case e := <-got:
+			if e.Kind != kind || e.Node != node || !strings.HasPrefix(e.Reason, reason) || e.Source != "router" {
+				t.Errorf("Got event %+v, want %v of %v: %v", e, kind, node, reason)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("No event, want %v of %v", kind, node)
+		}
+	}
+
+	r.CheckNodes()
+	if err := r.MarkDown(cfg.Nodes[0]); err != nil {
+		t.Fatalf("MarkDown() error: %v", err)
+	}
+	expect(events.NodeDown, cfg.Nodes[0], "marked down")
+	if err := r.MarkUp(cfg.Nodes[0]); err != nil {
+		t.Fatalf("MarkUp() error: %v", err)
+	}
+	expect(events.NodeUp, cfg.Nodes[0], "")
+
+	clock.now = clock.now.Add(cfg.ForgetTimeout / 2)
+	for _, node := range cfg.Nodes[1:] {
+		if err := r.Heartbeat(node); err != nil {
+			t.Fatalf("Heartbeat() error: %v", err)
+		}
+	}
+	clock.now = clock.now.Add(cfg.ForgetTimeout)
+	r.CheckNodes()
+	expect(events.NodeDown, cfg.Nodes[0], "no heartbeats")
+
+	if err := r.SetNodes(append(cfg.Nodes[1:], "node4")); err != nil {
+		t.Fatalf("SetNodes() error: %v", err)
+	}
+	expect(events.NodeJoined, "node4", "")
+	expect(events.NodeLeft, cfg.Nodes[0], "")
+
+	select {
+	case e := <-got:
+		t.Errorf("Unexpected event %+v", e)
+	default:
+	}
+}
+
+func TestRing(t *testing.T) {
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	ring := r.Ring(4)
+	if len(ring) != 4 {
+		t.Fatalf("Ring() returned %d slices, want 4", len(ring))
+	}
+	for i, s := range ring {
+		if want := storage.RecordID(i) << 30; s.First != want {
+			t.Errorf("Slice %d starts at %v, want %v", i, s.First, want)
+		}
+		if want := cfg.NodesFinder.NodesFind(s.First, cfg.Nodes); !reflect.DeepEqual(s.Nodes, want) {
+			t.Errorf("Slice %d placed on %v, want %v", i, s.Nodes, want)
+		}
+	}
+	if n := len(r.Ring(0)); n != RingSlices {
+		t.Errorf("Ring(0) returned %d slices, want %d", n, RingSlices)
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"storage"
+)
+
+// savedState is a liveness state of a node as it is persisted in
+// cfg.StateFile.
+type savedState struct {
+	Node storage.ServiceAddr
+	// Heartbeat is a time of the last heartbeat in unix nanoseconds.
+	Heartbeat int64
+	Degraded  bool `json:",omitempty"`
+	Down      bool `json:",omitempty"`
+	Awaiting  bool `json:",omitempty"`
+	Seq       uint64
+}
+
+// SaveState atomically replaces cfg.StateFile with the liveness state of
+// all nodes, so it survives a restart of the router. Does nothing if
+// cfg.StateFile is empty.
+//
+// SaveState атомарно заменяет cfg.StateFile состоянием доступности всех
+// node, чтобы оно пережило перезапуск router. Ничего не делает, если
+// cfg.StateFile пуст.
+func (r *Router) SaveState() error {
+	if r.conf.StateFile == "" {
+		return nil
+	}
+	set := r.nodeSet()
+	states := make([]savedState, 0, len(set.list))
+	for _, node := range set.list {
+		state := set.states[node]
+		states = append(states, savedState{
+			Node:      node,
+			Heartbeat: atomic.LoadInt64(&state.heartbeat),
+			Degraded:  atomic.LoadInt32(&state.degraded) != 0,
+			Down:      atomic.LoadInt32(&state.down) != 0,
+			Awaiting:  atomic.LoadInt32(&state.awaiting) != 0,
+			Seq:       atomic.LoadUint64(&state.seq),
+		})
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	tmp := r.conf.StateFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.conf.StateFile)
+}
+
+// loadState restores the liveness state of nodes saved by SaveState.
+// Nodes not served by the Router are skipped, a missing file is not an error.
+func (r *Router) loadState() error {
+	data, err := ioutil.ReadFile(r.conf.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var states []savedState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+
+	set := r.nodeSet()
+	now := r.conf.Clock.Now().UnixNano()
+	for _, saved := range states {
+		state, ok := set.states[saved.Node]
+		if !ok {
+			continue
+		}
+		state.heartbeat = saved.Heartbeat
+		state.degraded = boolFlag(saved.Degraded)
+		state.down = boolFlag(saved.Down)
+		state.awaiting = boolFlag(saved.Awaiting && r.conf.AwaitHeartbeats)
+		state.seq = saved.Seq
+		state.up = boolFlag(r.alive(state, now))
+	}
+	r.conf.Logger.Printf("Restored state of %v nodes from %q", len(states), filepath.Base(r.conf.StateFile))
+	return nil
+}
+
+// boolFlag converts b to a flag stored in nodeState.
+func boolFlag(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// PersistState runs SaveState each tenth of cfg.ForgetTimeout, logging
+// failures. Does nothing if cfg.StateFile is empty.
+//
+// PersistState запускает SaveState через каждую десятую часть
+// cfg.ForgetTimeout, записывая ошибки в лог. Ничего не делает, если
+// cfg.StateFile пуст.
+func (r *Router) PersistState() {
+	if r.conf.StateFile == "" {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(r.conf.ForgetTimeout / 10)
+			if err := r.SaveState(); err != nil {
+				r.conf.Sink.IncrCounter("router.state.errors", 1)
+				r.conf.Logger.Printf("Failed to save state to %q: %v", r.conf.StateFile, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"auth"
+	"events"
+	"router/router"
+	"storage"
+)
+
+// maxRingSlices is the largest number of slices of the key space /ring
+// returns.
+const maxRingSlices = 4096
+
+type admin struct {
+	rtr    *router.Router
+	stream *events.Stream
+}
+
+// Admin returns a handler serving the admin API of rtr:
+//
+//	GET  /                         -- nodes marked down
+//	GET  /nodes                    -- statuses of all nodes
+//	GET  /nodes?key=<key>          -- statuses of the nodes placing a key
+//	GET  /ring[?slices=<n>]        -- placement of slices of the key space
+//	GET  /events[?kind=<kind>]     -- stream of events as JSON lines or
+//	                                  server-sent events with format=sse
+//	GET  /ui/                      -- web admin UI built on the API above
+//	POST /markdown?node=host:port  -- mark a node down
+//	POST /markup?node=host:port    -- mark a node up and reset its heartbeat
+func Admin(rtr *router.Router) http.Handler {
+	return admin{rtr: rtr, stream: events.Handler(rtr.Events())}
+}
+
+func (a admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		var v interface{}
+		switch r.URL.Path {
+		case "/":
+			v = struct {
+				Down []storage.ServiceAddr
+			}{a.rtr.MarkedDown()}
+		case "/events":
+			a.stream.ServeHTTP(w, r)
+			return
+		case "/ui", "/ui/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, adminUI)
+			return
+		case "/ring":
+			n := router.RingSlices
+			if slices := r.URL.Query().Get("slices"); slices != "" {
+				var err error
+				n, err = strconv.Atoi(slices)
+				if err != nil || n <= 0 || n > maxRingSlices {
+					http.Error(w, fmt.Sprintf("slices should be in [1, %d]", maxRingSlices), http.StatusBadRequest)
+					return
+				}
+			}
+			v = a.rtr.Ring(n)
+		case "/nodes":
+			key := r.URL.Query().Get("key")
+			if key == "" {
+				v = a.rtr.NodeStatuses()
+				break
+			}
+			k, err := storage.ParseRecordID(key)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			v = a.rtr.Placement(k)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+
+	node := storage.ServiceAddr(r.URL.Query().Get("node"))
+	if node == "" {
+		http.Error(w, "Node should be set", http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch r.URL.Path {
+	case "/markdown":
+		err = a.rtr.MarkDown(node)
+	case "/markup":
+		err = a.rtr.MarkUp(node)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if errors.Is(err, storage.ErrUnknownDaemon) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListenAndServeAdmin serves the admin API at addr authorized by a.
+func (s *Server) ListenAndServeAdmin(addr string, a auth.Authorizer) error {
+	return http.ListenAndServe(addr, auth.HTTP(a, Admin(s.rtr)))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sync"
 
 	"google.golang.org/grpc"
 
@@ -17,13 +18,15 @@ type Server struct {
 	addr string
 	rtr  *router.Router
 	srv  *grpc.Server
+	udp  net.PacketConn
+	lock sync.Mutex
 }
 
-func New(rtr *router.Router, addr string) *Server {
+func New(rtr *router.Router, addr string, opts ...grpc.ServerOption) *Server {
 	return &Server{
 		addr: addr,
 		rtr:  rtr,
-		srv:  grpc.NewServer(),
+		srv:  grpc.NewServer(opts...),
 	}
 }
 
@@ -40,20 +43,59 @@ func (s *Server) ListenAndServe() error {
 
 func (s *Server) Stop() {
 	s.srv.Stop()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.udp != nil {
+		s.udp.Close()
+	}
 }
 
 func (s *Server) Heartbeat(ctx context.Context, req *pb.HBRequest) (*pb.HBReply, error) {
 	node := storage.ServiceAddr(req.Node)
-	log.Printf("Hearbeat request: node = %q", node)
+	log.Printf("Hearbeat request: node = %q, degraded = %v, seq = %v", node, req.Degraded, req.Seq)
 
-	err := s.rtr.Heartbeat(node)
-	status := storage.ErrToStatus(err)
+	err := s.rtr.Beat(storage.Heartbeat{
+		Node:     node,
+		Degraded: req.Degraded,
+		Seq:      req.Seq,
+	})
+	status, msg := storage.MarshalError(err)
 
 	reply := pb.HBReply{
 		Status: int32(status),
 	}
-	if status == storage.StatusUnknown {
-		reply.Error = err.Error()
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) HeartbeatBatch(ctx context.Context, req *pb.HBBatchRequest) (*pb.HBBatchReply, error) {
+	log.Printf("Hearbeat batch request: nodes = %v", len(req.Nodes))
+
+	beats := make([]storage.Heartbeat, 0, len(req.Nodes))
+	for _, hb := range req.Nodes {
+		beats = append(beats, storage.Heartbeat{
+			Node:     storage.ServiceAddr(hb.Node),
+			Degraded: hb.Degraded,
+			Seq:      hb.Seq,
+		})
+	}
+	errs := s.rtr.HeartbeatBatch(beats)
+
+	reply := pb.HBBatchReply{
+		Status: int32(storage.StatusOk),
+		Nodes:  make([]*pb.HBReply, 0, len(errs)),
+	}
+	for _, err := range errs {
+		status, msg := storage.MarshalError(err)
+		hb := pb.HBReply{
+			Status: int32(status),
+		}
+		if msg != "" {
+			hb.Error = msg
+		}
+		reply.Nodes = append(reply.Nodes, &hb)
 	}
 	return &reply, nil
 }
@@ -62,14 +104,14 @@ func (s *Server) NodesFind(ctx context.Context, req *pb.NFRequest) (*pb.NFReply,
 	key := storage.RecordID(req.Key)
 	log.Printf("NodesFind request: key = %v", key)
 
-	nodes, err := s.rtr.NodesFind(key)
-	status := storage.ErrToStatus(err)
+	nodes, err := s.rtr.NodesFindContext(ctx, key)
+	status, msg := storage.MarshalError(err)
 
 	reply := pb.NFReply{
 		Status: int32(status),
 	}
-	if status == storage.StatusUnknown {
-		reply.Error = err.Error()
+	if msg != "" {
+		reply.Error = msg
 		return &reply, nil
 	}
 
@@ -93,3 +135,47 @@ func (s *Server) List(ctx context.Context, req *pb.Empty) (*pb.ListReply, error)
 	}
 	return &reply, nil
 }
+
+func (s *Server) Heartbeats(ctx context.Context, req *pb.Empty) (*pb.HeartbeatsReply, error) {
+	log.Printf("Heartbeats request")
+
+	beats := s.rtr.Heartbeats()
+	reply := pb.HeartbeatsReply{
+		Status: int32(storage.StatusOk),
+		Nodes:  make([]*pb.HBRequest, 0, len(beats)),
+	}
+	for _, hb := range beats {
+		reply.Nodes = append(reply.Nodes, &pb.HBRequest{
+			Node:     string(hb.Node),
+			Degraded: hb.Degraded,
+			Seq:      hb.Seq,
+		})
+	}
+	return &reply, nil
+}
+
+func (s *Server) Orphans(ctx context.Context, req *pb.OrphansRequest) (*pb.OrphansReply, error) {
+	node := storage.ServiceAddr(req.Node)
+	log.Printf("Orphans request: node = %q, keys = %v", node, len(req.Keys))
+
+	keys := make([]storage.RecordID, 0, len(req.Keys))
+	for _, k := range req.Keys {
+		keys = append(keys, storage.RecordID(k))
+	}
+	orphans, err := s.rtr.Orphans(node, keys)
+	status, msg := storage.MarshalError(err)
+
+	reply := pb.OrphansReply{
+		Status: int32(status),
+	}
+	if msg != "" {
+		reply.Error = msg
+		return &reply, nil
+	}
+
+	reply.Keys = make([]uint32, 0, len(orphans))
+	for _, k := range orphans {
+		reply.Keys = append(reply.Keys, uint32(k))
+	}
+	return &reply, nil
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"router/client"
+	"storage"
+)
+
+// maxPacketSize is enough for any heartbeat packet.
+const maxPacketSize = 1 << 10
+
+// ListenAndServeUDP receives heartbeats authenticated with key at addr.
+// Invalid packets are logged and dropped.
+func (s *Server) ListenAndServeUDP(addr string, key []byte) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("Failed to listen: %v", err)
+	}
+	s.lock.Lock()
+	s.udp = conn
+	s.lock.Unlock()
+
+	log.Printf("Starting UDP heartbeats at %v", addr)
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		hb, err := client.DecodeHeartbeat(key, buf[:n], time.Now())
+		if err != nil {
+			log.Printf("UDP heartbeat from %v dropped: %v", from, err)
+			continue
+		}
+
+		if err = s.rtr.Beat(hb); err == storage.ErrUnknownDaemon {
+			log.Printf("UDP heartbeat from unknown node %q", hb.Node)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+// adminUI is a single page admin UI served at /ui/. It polls /nodes and
+// /ring and follows /events, so it needs nothing but the admin API. If
+// the API is authorized, the page should be opened with a token query
+// parameter, which is passed on to the API.
+const adminUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>ddsp router admin</title>
+<style>
+body { font: 14px sans-serif; margin: 0; background: #f5f5f5; color: #222; }
+header { background: #263238; color: #fff; padding: 10px 16px; }
+header span { color: #b0bec5; margin-left: 12px; }
+main { display: grid; grid-template-columns: 420px 1fr; gap: 16px; padding: 16px; }
+section { background: #fff; border-radius: 4px; padding: 12px; box-shadow: 0 1px 2px rgba(0,0,0,.2); }
+section.wide { grid-column: 1 / 3; }
+h2 { font-size: 15px; margin: 0 0 8px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; white-space: nowrap; }
+.ok { color: #2e7d32; } .degraded { color: #ef6c00; } .down, .lost { color: #c62828; }
+.swatch { display: inline-block; width: 10px; height: 10px; margin-right: 6px; }
+#events { max-height: 320px; overflow-y: auto; font-family: monospace; }
+#events div { padding: 2px 0; border-bottom: 1px solid #f0f0f0; }
+button { font-size: 12px; }
+</style>
+</head>
+<body>
+<header>ddsp router admin<span id="updated"></span></header>
+<main>
+<section>
+<h2>Ring</h2>
+<svg id="ring" width="396" height="396" viewBox="-200 -200 400 400"></svg>
+<div id="rebalance"></div>
+</section>
+<section>
+<h2>Nodes</h2>
+<table>
+<thead><tr><th>Node</th><th>Health</th><th>Heartbeat age</th><th>Seq</th><th>Primary share</th><th>Replica share</th><th></th></tr></thead>
+<tbody id="nodes"></tbody>
+</table>
+</section>
+<section class="wide">
+<h2>Recent events</h2>
+<div id="events"></div>
+</section>
+</main>
+<script>
+"use strict";
+const palette = ["#1e88e5", "#43a047", "#fb8c00", "#8e24aa", "#00acc1", "#e53935", "#6d4c41", "#3949ab", "#c0ca33", "#d81b60"];
+const colors = {};
+const token = new URLSearchParams(location.search).get("token");
+let statuses = [], ring = [], initialRing = null;
+
+// api adds the token the page was opened with to a path of the admin API.
+function api(path) {
+  if (!token) return path;
+  return path + (path.includes("?") ? "&" : "?") + "token=" + encodeURIComponent(token);
+}
+
+function color(node) {
+  if (!(node in colors)) colors[node] = palette[Object.keys(colors).length % palette.length];
+  return colors[node];
+}
+
+function health(st) {
+  if (st.Down) return "down";
+  if (st.Degraded) return "degraded";
+  if (!st.Alive) return "lost";
+  return "ok";
+}
+
+function text(tag, s, cls) {
+  const el = document.createElement(tag);
+  el.textContent = s;
+  if (cls) el.className = cls;
+  return el;
+}
+
+function shares() {
+  const primary = {}, replica = {};
+  for (const s of ring) {
+    (s.Nodes || []).forEach((node, i) => {
+      if (i === 0) primary[node] = (primary[node] || 0) + 1;
+      replica[node] = (replica[node] || 0) + 1;
+    });
+  }
+  return {primary, replica};
+}
+
+function percent(n, total) {
+  return total ? (100 * n / total).toFixed(1) + "%" : "-";
+}
+
+function renderNodes() {
+  const {primary, replica} = shares();
+  const body = document.getElementById("nodes");
+  body.replaceChildren();
+  for (const st of statuses) {
+    const tr = document.createElement("tr");
+    const name = text("td", st.Node);
+    const swatch = text("span", "", "swatch");
+    swatch.style.background = color(st.Node);
+    name.prepend(swatch);
+    tr.append(name, text("td", health(st), health(st)),
+      text("td", (st.HeartbeatAge / 1e9).toFixed(1) + "s"), text("td", st.Seq),
+      text("td", percent(primary[st.Node] || 0, ring.length)),
+      text("td", percent(replica[st.Node] || 0, ring.length)));
+    const action = document.createElement("td");
+    const button = text("button", st.Down ? "Mark up" : "Mark down");
+    button.onclick = () => fetch(api((st.Down ? "../markup" : "../markdown") + "?node=" + encodeURIComponent(st.Node)), {method: "POST"}).then(refresh);
+    action.append(button);
+    tr.append(action);
+    body.append(tr);
+  }
+}
+
+function arc(r0, r1, a0, a1) {
+  const p = (r, a) => (r * Math.sin(a)).toFixed(2) + " " + (-r * Math.cos(a)).toFixed(2);
+  const large = a1 - a0 > Math.PI ? 1 : 0;
+  return "M " + p(r1, a0) + " A " + r1 + " " + r1 + " 0 " + large + " 1 " + p(r1, a1) +
+    " L " + p(r0, a1) + " A " + r0 + " " + r0 + " 0 " + large + " 0 " + p(r0, a0) + " Z";
+}
+
+function renderRing() {
+  const svg = document.getElementById("ring");
+  svg.replaceChildren();
+  const alive = {};
+  for (const st of statuses) alive[st.Node] = st.Alive;
+  const step = 2 * Math.PI / Math.max(ring.length, 1);
+  ring.forEach((s, i) => {
+    (s.Nodes || []).forEach((node, j) => {
+      const path = document.createElementNS("http://www.w3.org/2000/svg", "path");
+      path.setAttribute("d", arc(190 - 40 * (j + 1), 190 - 40 * j - 2, i * step, (i + 1) * step));
+      path.setAttribute("fill", color(node));
+      path.setAttribute("opacity", alive[node] === false ? 0.2 : 1);
+      const title = document.createElementNS("http://www.w3.org/2000/svg", "title");
+      title.textContent = "keys from " + s.First + ": replica " + (j + 1) + " on " + node;
+      path.append(title);
+      svg.append(path);
+    });
+  });
+
+  let underReplicated = 0, moved = 0;
+  ring.forEach((s, i) => {
+    if ((s.Nodes || []).some(node => alive[node] === false)) underReplicated++;
+    if (initialRing && initialRing[i] && JSON.stringify(initialRing[i].Nodes) !== JSON.stringify(s.Nodes)) moved++;
+  });
+  const rebalance = document.getElementById("rebalance");
+  rebalance.replaceChildren(
+    text("div", "Key space with replicas on unavailable nodes: " + percent(underReplicated, ring.length), underReplicated ? "degraded" : "ok"),
+    text("div", "Key space moved to other nodes since the page was opened: " + percent(moved, ring.length)));
+}
+
+function refresh() {
+  return Promise.all([
+    fetch(api("../nodes")).then(r => r.json()),
+    fetch(api("../ring?slices=256")).then(r => r.json()),
+  ]).then(([nodes, slices]) => {
+    statuses = nodes;
+    ring = slices;
+    if (!initialRing || initialRing.length !== ring.length) initialRing = ring;
+    renderNodes();
+    renderRing();
+    document.getElementById("updated").textContent = "updated " + new Date().toLocaleTimeString();
+  }).catch(err => {
+    document.getElementById("updated").textContent = "failed to update: " + err;
+  });
+}
+
+function follow() {
+  const events = document.getElementById("events");
+  const source = new EventSource(api("../events?format=sse&last_event_id=0"));
+  const show = msg => {
+    const e = JSON.parse(msg.data);
+    let line = new Date(e.Time).toLocaleTimeString() + " " + e.Kind;
+    if (e.Node) line += " " + e.Node;
+    if (e.Key !== undefined) line += " key " + e.Key;
+    if (e.Reason) line += ": " + e.Reason;
+    events.prepend(text("div", line));
+    while (events.childElementCount > 200) events.lastChild.remove();
+    if (e.Kind !== "repair_completed") refresh();
+  };
+  for (const kind of ["node_joined", "node_left", "node_down", "node_up", "quorum_failed", "repair_completed"]) {
+    source.addEventListener(kind, show);
+  }
+}
+
+refresh();
+setInterval(refresh, 2000);
+follow();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"storage"
+)
+
+// File is a Provider reading each secret from a file named after it in Dir.
+// Trailing newlines are trimmed.
+//
+// File -- Provider, читающий каждый секрет из файла с его именем в Dir.
+// Завершающие переводы строк отбрасываются.
+type File struct {
+	Dir string
+}
+
+// Secret reads the secret name from its file.
+func (f File) Secret(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(f.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Env is a Provider reading secrets from environment variables named
+// by Prefix and the upper-cased name of a secret, with dashes and dots
+// replaced by underscores.
+//
+// Env -- Provider, читающий секреты из переменных окружения, названных
+// по Prefix и имени секрета в верхнем регистре, с заменой дефисов и точек
+// на подчеркивания.
+type Env struct {
+	Prefix string
+}
+
+// Secret reads the secret name from its environment variable.
+func (e Env) Secret(name string) (string, error) {
+	env := e.Prefix + strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(name))
+	s, ok := os.LookupEnv(env)
+	if !ok {
+		return "", fmt.Errorf("environment variable %v is not set", env)
+	}
+	return s, nil
+}
+
+// Vault is a Provider reading secrets from fields of a KV version 2 secret
+// of a Vault server.
+//
+// Vault -- Provider, читающий секреты из полей секрета KV версии 2
+// сервера Vault.
+type Vault struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewVault creates a Vault reading the secret at path from the server
+// at addr with token, VAULT_TOKEN if it is empty.
+//
+// NewVault создает Vault, читающий секрет по пути path с сервера с адресом
+// addr с token, VAULT_TOKEN, если он пуст.
+func NewVault(addr, path, token string) *Vault {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return &Vault{
+		url:    strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(path, "/"),
+		token:  token,
+		client: &http.Client{Timeout: storage.Timeout},
+	}
+}
+
+// Secret reads the field name of the Vault secret.
+func (v *Vault) Secret(name string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, v.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault replied %v", resp.Status)
+	}
+	var reply struct {
+		Data struct {
+			Data map[string]string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return "", err
+	}
+	s, ok := reply.Data.Data[name]
+	if !ok {
+		return "", fmt.Errorf("Vault secret has no field %q", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,214 @@
+// Package secrets keeps secrets such as HMAC keys and admin tokens out of
+// config files. A config value of the form "secret:<name>" refers to the
+// secret <name> of a Provider: a directory of files, environment variables
+// or Vault. Secrets are resolved at start and re-resolved periodically,
+// so rotated credentials are picked up without restarting long-lived
+// services.
+//
+// Package secrets позволяет не хранить секреты, такие как ключи HMAC
+// и tokens администратора, в файлах конфигурации. Значение конфигурации
+// вида "secret:<name>" ссылается на секрет <name> из Provider: директории
+// файлов, переменных окружения или Vault. Секреты разрешаются при запуске
+// и периодически разрешаются заново, так что замененные учетные данные
+// применяются без перезапуска долго работающих сервисов.
+package secrets
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"storage"
+)
+
+// Ref is a prefix of config values referring to secrets.
+//
+// Ref -- префикс значений конфигурации, ссылающихся на секреты.
+const Ref = "secret:"
+
+// DefaultInterval is a default time interval between re-resolutions of
+// secrets.
+//
+// DefaultInterval -- интервал по умолчанию между повторными разрешениями
+// секретов.
+const DefaultInterval = time.Minute
+
+// Provider is the common interface of secret stores.
+//
+// Provider -- общий интерфейс хранилищ секретов.
+type Provider interface {
+	// Secret returns the current value of the secret name.
+	// Secret возвращает текущее значение секрета name.
+	Secret(name string) (string, error)
+}
+
+// Config stores configuration of a secrets provider.
+//
+// Config -- содержит конфигурацию хранилища секретов.
+type Config struct {
+	// Kind is a kind of the provider, "file", "env" or "vault". Secrets
+	// can't be referred to if it is empty.
+	// Kind -- тип хранилища, "file", "env" или "vault". Если не задан,
+	// ссылаться на секреты нельзя.
+	Kind string
+	// Dir is a directory with a file per secret for "file", e.g. a mounted
+	// Kubernetes secret.
+	// Dir -- директория с файлом на каждый секрет для "file", например
+	// подключенный секрет Kubernetes.
+	Dir string
+	// Prefix is a prefix of environment variables holding secrets for
+	// "env", e.g. DDSP_SECRET_ for the variable DDSP_SECRET_UDP_KEY
+	// of the secret udp_key.
+	// Prefix -- префикс переменных окружения с секретами для "env",
+	// например DDSP_SECRET_ для переменной DDSP_SECRET_UDP_KEY секрета
+	// udp_key.
+	Prefix string
+	// Addr is an address of the Vault server for "vault".
+	// Addr -- адрес сервера Vault для "vault".
+	Addr string
+	// Path is a path of the KV version 2 secret holding secrets as its
+	// fields for "vault", e.g. secret/data/ddsp.
+	// Path -- путь секрета KV версии 2, хранящего секреты в своих полях,
+	// для "vault", например secret/data/ddsp.
+	Path string
+	// Token is a Vault token for "vault", VAULT_TOKEN by default.
+	// Token -- token Vault для "vault", по умолчанию VAULT_TOKEN.
+	Token string
+	// Interval is a time interval between re-resolutions of secrets,
+	// DefaultInterval by default.
+	// Interval -- интервал между повторными разрешениями секретов,
+	// по умолчанию DefaultInterval.
+	Interval time.Duration
+}
+
+// New creates a Provider described by cfg. Returns nil if cfg.Kind is empty.
+//
+// New создает Provider, описанный cfg. Возвращает nil, если cfg.Kind
+// не задан.
+func New(cfg Config) (Provider, error) {
+	switch cfg.Kind {
+	case "":
+		return nil, nil
+	case "file":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("Dir should be set for file secrets")
+		}
+		return File{Dir: cfg.Dir}, nil
+	case "env":
+		return Env{Prefix: cfg.Prefix}, nil
+	case "vault":
+		if cfg.Addr == "" || cfg.Path == "" {
+			return nil, fmt.Errorf("Addr and Path should be set for vault secrets")
+		}
+		return NewVault(cfg.Addr, cfg.Path, cfg.Token), nil
+	}
+	return nil, fmt.Errorf("Unknown secrets provider %q", cfg.Kind)
+}
+
+// Resolve replaces references to secrets of p in string fields, lists
+// of strings and values of string maps of the config struct pointed to
+// by cfg, including nested structs. Lists and maps are replaced with
+// copies, so a copy of the config made before keeps the references.
+// Fails if cfg refers to secrets and p is nil.
+//
+// Resolve заменяет ссылки на секреты p в строковых полях, списках строк
+// и значениях строковых отображений структуры конфигурации, на которую
+// указывает cfg, включая вложенные структуры. Списки и отображения
+// заменяются копиями, так что сделанная ранее копия конфигурации сохраняет
+// ссылки. Завершается ошибкой, если cfg ссылается на секреты, а p равен nil.
+func Resolve(p Provider, cfg interface{}) error {
+	var errs storage.ConfigError
+	resolve(p, reflect.ValueOf(cfg).Elem(), "", &errs)
+	return errs.Err()
+}
+
+func resolve(p Provider, v reflect.Value, path string, errs *storage.ConfigError) {
+	value := func(s, field string) string {
+		if !strings.HasPrefix(s, Ref) {
+			return s
+		}
+		name := strings.TrimPrefix(s, Ref)
+		if p == nil {
+			errs.Check(false, "%v refers to secret %q, but no secrets provider is set", field, name)
+			return s
+		}
+		secret, err := p.Secret(name)
+		errs.Check(err == nil, "%v: failed to get secret %q: %v", field, name, err)
+		return secret
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("yaml") == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		field := path + f.Name
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			resolve(p, fv, field+".", errs)
+		case f.Type.Kind() == reflect.String:
+			fv.SetString(value(fv.String(), field))
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String && !fv.IsNil():
+			list := reflect.MakeSlice(f.Type, fv.Len(), fv.Len())
+			for j := 0; j < fv.Len(); j++ {
+				list.Index(j).SetString(value(fv.Index(j).String(), fmt.Sprintf("%v[%d]", field, j)))
+			}
+			fv.Set(list)
+		case f.Type.Kind() == reflect.Map && f.Type.Elem().Kind() == reflect.String && !fv.IsNil():
+			m := reflect.MakeMapWithSize(f.Type, fv.Len())
+			iter := fv.MapRange()
+			for iter.Next() {
+				m.SetMapIndex(iter.Key(), reflect.ValueOf(value(iter.Value().String(), fmt.Sprintf("%v[%v]", field, iter.Key()))).Convert(f.Type.Elem()))
+			}
+			fv.Set(m)
+		}
+	}
+}
+
+// Watch resolves secrets of p referred to by a copy of the config struct
+// raw each interval in the background until the process exits and calls
+// apply with the resolved copy when it changes, so services rotate their
+// credentials without restarts. Nothing is watched if p is nil.
+//
+// Watch разрешает секреты p, на которые ссылается копия структуры
+// конфигурации raw, через каждый интервал interval в фоне до завершения
+// процесса и вызывает apply с разрешенной копией, когда она меняется, так
+// что сервисы заменяют учетные данные без перезапуска. Если p равен nil,
+// ничего не отслеживается.
+func Watch(p Provider, interval time.Duration, raw interface{}, apply func(resolved interface{})) {
+	if p == nil {
+		return
+	}
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	resolved := func() (interface{}, error) {
+		v := reflect.New(reflect.TypeOf(raw))
+		v.Elem().Set(reflect.ValueOf(raw))
+		err := Resolve(p, v.Interface())
+		return v.Elem().Interface(), err
+	}
+	last, err := resolved()
+	if err != nil {
+		log.Printf("Failed to resolve secrets: %v", err)
+	}
+	go func() {
+		for {
+			time.Sleep(interval)
+			cur, err := resolved()
+			if err != nil {
+				log.Printf("Failed to resolve secrets: %v", err)
+				continue
+			}
+			if !reflect.DeepEqual(cur, last) {
+				log.Printf("Secrets changed, rotating credentials")
+				apply(cur)
+				last = cur
+			}
+		}
+	}()
+}
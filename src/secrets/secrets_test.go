@@ -0,0 +1,163 @@
+package secrets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Key    string `yaml:"key"`
+	Tokens []string
+	Keys   map[string]string
+	Nested struct {
+		Key string
+	}
+	Skipped string `yaml:"-"`
+}
+
+// fakeVault implements the parts of Vault API used by Vault.
+type fakeVault struct {
+	lock   sync.Mutex
+	fields map[string]string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.URL.Path != "/v1/secret/data/ddsp" || r.Header.Get("X-Vault-Token") != "root" {
+		http.Error(w, "permission denied", http.StatusForbidden)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{"data": f.fields},
+	})
+}
+
+func (f *fakeVault) set(name, value string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.fields[name] = value
+}
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "udp-key"), []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_SECRET_UDP_KEY", "from env")
+	defer os.Unsetenv("TEST_SECRET_UDP_KEY")
+	vault := httptest.NewServer(&fakeVault{fields: map[string]string{"udp-key": "from vault"}})
+	defer vault.Close()
+
+	for _, tt := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Kind: "file", Dir: dir}, "from file"},
+		{Config{Kind: "env", Prefix: "TEST_SECRET_"}, "from env"},
+		{Config{Kind: "vault", Addr: vault.URL, Path: "secret/data/ddsp", Token: "root"}, "from vault"},
+	} {
+		p, err := New(tt.cfg)
+		if err != nil {
+			t.Fatalf("New(%+v) error: %v", tt.cfg, err)
+		}
+		if got, err := p.Secret("udp-key"); err != nil || got != tt.want {
+			t.Errorf("%v Secret() = %q, %v, want %q", tt.cfg.Kind, got, err, tt.want)
+		}
+		if _, err := p.Secret("missing"); err == nil {
+			t.Errorf("%v Secret() of a missing secret got no error", tt.cfg.Kind)
+		}
+	}
+
+	if _, err := (File{Dir: dir}).Secret("../udp-key"); err == nil {
+		t.Errorf("File.Secret() outside of Dir got no error")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"a": "1", "b": "2"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var raw testConfig
+	raw.Key = "secret:a"
+	raw.Tokens = []string{"plain", "secret:b"}
+	raw.Keys = map[string]string{"k": "secret:a"}
+	raw.Nested.Key = "secret:b"
+	raw.Skipped = "secret:a"
+
+	cfg := raw
+	if err := Resolve(File{Dir: dir}, &cfg); err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	want := raw
+	want.Key = "1"
+	want.Tokens = []string{"plain", "2"}
+	want.Keys = map[string]string{"k": "1"}
+	want.Nested.Key = "2"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Resolve() = %+v, want %+v", cfg, want)
+	}
+	if raw.Tokens[1] != "secret:b" || raw.Keys["k"] != "secret:a" {
+		t.Errorf("Resolve() changed the original config: %+v", raw)
+	}
+
+	missing := testConfig{Key: "secret:c"}
+	if err := Resolve(File{Dir: dir}, &missing); err == nil || !strings.Contains(err.Error(), "Key") {
+		t.Errorf("Resolve() of a missing secret got error %v", err)
+	}
+	if err := Resolve(nil, &testConfig{Key: "secret:a"}); err == nil {
+		t.Errorf("Resolve() without a provider got no error")
+	}
+	if err := Resolve(nil, &testConfig{Key: "plain"}); err != nil {
+		t.Errorf("Resolve() of plain values without a provider got error %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	vault := &fakeVault{fields: map[string]string{"key": "old"}}
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+	p := NewVault(srv.URL, "secret/data/ddsp", "root")
+
+	applied := make(chan testConfig, 1)
+	Watch(p, 10*time.Millisecond, testConfig{Key: "secret:key"}, func(resolved interface{}) {
+		applied <- resolved.(testConfig)
+	})
+
+	select {
+	case cfg := <-applied:
+		t.Fatalf("Watch() applied %+v before the secret changed", cfg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	vault.set("key", "new")
+	select {
+	case cfg := <-applied:
+		if cfg.Key != "new" {
+			t.Errorf("Watch() applied Key = %q, want %q", cfg.Key, "new")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Watch() didn't apply the rotated secret")
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Backoff honors retry-after hints of overloaded nodes intercepting requests
+// of a Client. Once a node fails a request with a RetryAfterError, requests
+// to it are not sent until the hint passes. Without Retries they fail right
+// away with a RetryAfterError carrying the rest of the hint, so callers shed
+// the load to other nodes, otherwise they wait the hint out and are retried
+// up to Retries times.
+type Backoff struct {
+	Retries int
+	// Clock tells the time hints pass at, SystemClock is used if it is nil.
+	Clock Clock
+
+	lock  sync.Mutex
+	holds map[ServiceAddr]hold
+}
+
+// hold keeps requests to a node until the hint of its error passes.
+type hold struct {
+	err   error
+	until time.Time
+}
+
+// NewBackoffClient creates a Client making requests with c honoring hints
+// with Backoff retrying them up to retries times.
+func NewBackoffClient(c Client, retries int) Client {
+	b := &Backoff{Retries: retries}
+	return InterceptedClient{Client: c, Intercept: b.Intercept}
+}
+
+func (b *Backoff) now() time.Time {
+	if b.Clock == nil {
+		return SystemClock.Now()
+	}
+	return b.Clock.Now()
+}
+
+// holding returns the error of the last hinted request to node and the rest
+// of its hint, ok is false if there is no hint or it has passed.
+func (b *Backoff) holding(node ServiceAddr) (err error, d time.Duration, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	h, ok := b.holds[node]
+	if !ok {
+		return nil, 0, false
+	}
+	if d = h.until.Sub(b.now()); d <= 0 {
+		delete(b.holds, node)
+		return nil, 0, false
+	}
+	return h.err, d, true
+}
+
+// Intercept makes a request to node with call honoring hints.
+func (b *Backoff) Intercept(node ServiceAddr, call func() error) error {
+	for retries := b.Retries; ; retries-- {
+		if err, d, ok := b.holding(node); ok {
+			if b.Retries == 0 {
+				return RetryAfterError{Err: err, After: d}
+			}
+			time.Sleep(d)
+		}
+		err := call()
+		var e RetryAfterError
+		if !errors.As(err, &e) {
+			return err
+		}
+		b.lock.Lock()
+		if b.holds == nil {
+			b.holds = make(map[ServiceAddr]hold)
+		}
+		b.holds[node] = hold{err: e.Err, until: b.now().Add(e.After)}
+		b.lock.Unlock()
+		if retries == 0 {
+			return err
+		}
+	}
+}
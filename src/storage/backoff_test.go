@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// overloadedClient fails Get with a hint until it has served some requests.
+type overloadedClient struct {
+	Client
+	calls, rejects int
+}
+
+func (c *overloadedClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
+	c.calls++
+	if c.calls <= c.rejects {
+		return nil, RetryAfterError{Err: ErrRateLimited, After: time.Millisecond}
+	}
+	return []byte("value"), nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestBackoff_Shed(t *testing.T) {
+	clock := &testClock{now: time.Unix(1000, 0)}
+	o := &overloadedClient{rejects: 1}
+	c := InterceptedClient{Client: o, Intercept: (&Backoff{Clock: clock}).Intercept}
+
+	if _, err := c.Get("node1", 1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Get() got error %v, want %v", err, ErrRateLimited)
+	}
+	_, err := c.Get("node1", 1)
+	if d, ok := RetryAfter(err); !ok || d != time.Millisecond || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Get() within hint got %v, want %v with hint", err, ErrRateLimited)
+	}
+	if o.calls != 1 {
+		t.Errorf("Get() within hint made %d requests, want 1", o.calls)
+	}
+	if _, err := c.Get("node2", 1); err != nil {
+		t.Errorf("Get() of another node error: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Millisecond)
+	if d, err := c.Get("node1", 1); err != nil || string(d) != "value" {
+		t.Errorf("Get() after hint got %q, %v, want %q", d, err, "value")
+	}
+}
+
+func TestBackoff_Retry(t *testing.T) {
+	o := &overloadedClient{rejects: 2}
+	c := NewBackoffClient(o, 2)
+	if d, err := c.Get("node", 1); err != nil || string(d) != "value" {
+		t.Errorf("Get() got %q, %v, want %q after retries", d, err, "value")
+	}
+	if o.calls != 3 {
+		t.Errorf("Get() made %d requests, want 3", o.calls)
+	}
+
+	o = &overloadedClient{rejects: 3}
+	c = NewBackoffClient(o, 1)
+	if _, err := c.Get("node", 1); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Get() got error %v, want %v after retries", err, ErrRateLimited)
+	}
+}
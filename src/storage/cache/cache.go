@@ -0,0 +1,427 @@
+// Package cache provides a client-side cache of records for applications
+// reading the same keys extremely frequently. Client wraps a storage.Client
+// talking to frontends of a cluster, serves Get of cached records without
+// requests and either writes through or writes back Put and Del. Cached
+// records changed by other clients are invalidated via the watch API of
+// frontends.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"events"
+	"storage"
+)
+
+// Mode tells when Put and Del of a Client reach the cluster.
+type Mode string
+
+const (
+	// WriteThrough makes Put and Del return once the cluster applies them.
+	WriteThrough Mode = "write_through"
+	// WriteBack makes Put and Del return at once, they are applied in the
+	// background or on Flush, and Get returns their results meanwhile.
+	WriteBack Mode = "write_back"
+)
+
+const (
+	// FlushInterval is a default interval of flushes of a WriteBack Client.
+	FlushInterval = time.Second
+	// RetryInterval is an interval of reconnects to the watch API.
+	RetryInterval = time.Second
+)
+
+// Config stores configuration of a Client.
+type Config struct {
+	// Size is a maximum size of cached values in bytes. A WriteBack Client
+	// flushes writes once their values exceed it.
+	Size int64
+	// Mode is the write mode, WriteThrough by default.
+	Mode Mode
+	// FlushInterval is an interval of flushes of a WriteBack Client.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// Watch is a URL of the watch API of a frontend, i.e. /watch of its
+	// admin API, invalidating records changed by other clients. Cached
+	// records are not invalidated if it is empty, unless Client is
+	// subscribed to frontend.Frontend.Changes in-process.
+	Watch string
+	// Logger is a logger of failed background flushes and watches.
+	Logger *log.Logger `yaml:"-"`
+}
+
+// Validate checks cfg and returns a storage.ConfigError listing all found
+// problems.
+func (cfg Config) Validate() error {
+	var errs storage.ConfigError
+	errs.Check(cfg.Size > 0, "Size should be positive, got %v", cfg.Size)
+	errs.Check(cfg.Mode == "" || cfg.Mode == WriteThrough || cfg.Mode == WriteBack,
+		"Mode should be %q or %q, got %q", WriteThrough, WriteBack, cfg.Mode)
+	errs.Check(cfg.FlushInterval >= 0, "FlushInterval should not be negative, got %v", cfg.FlushInterval)
+	return errs.Err()
+}
+
+type cached struct {
+	k storage.RecordID
+	d []byte
+}
+
+// write is a Put or a Del not flushed yet.
+type write struct {
+	node storage.ServiceAddr
+	d    []byte
+	del  bool
+}
+
+// Client is a storage.Client caching values of records got from and put
+// to frontends. Values returned by Get are shared with the cache and
+// should not be modified. Records written via Client are invalidated too
+// once frontends report their changes, so they are fetched again.
+type Client struct {
+	storage.Client
+	conf Config
+
+	lock    sync.Mutex
+	lru     *list.List
+	entries map[storage.RecordID]*list.Element
+	size    int64
+	// gen is increased on every change of cached records, so values got
+	// meanwhile are not cached.
+	gen    uint64
+	dirty  map[storage.RecordID]*write
+	unsync int64
+	hits   int64
+	misses int64
+
+	flushLock sync.Mutex
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// New creates a Client caching records of c as described by cfg, and
+// starts background flushes and watches. Panics if cfg is invalid.
+func New(c storage.Client, cfg Config) *Client {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = WriteThrough
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = FlushInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := &Client{
+		Client:  c,
+		conf:    cfg,
+		lru:     list.New(),
+		entries: make(map[storage.RecordID]*list.Element),
+		dirty:   make(map[storage.RecordID]*write),
+		cancel:  cancel,
+	}
+	if cfg.Mode == WriteBack {
+		cc.wg.Add(1)
+		go cc.flushes(ctx)
+	}
+	if cfg.Watch != "" {
+		cc.wg.Add(1)
+		go cc.watches(ctx)
+	}
+	return cc
+}
+
+// Get returns the value of the record with key k from the cache or gets
+// it from node and caches it.
+func (c *Client) Get(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	c.lock.Lock()
+	if w, ok := c.dirty[k]; ok {
+		c.hits++
+		c.lock.Unlock()
+		if w.del {
+			return nil, storage.ErrRecordNotFound
+		}
+		return w.d, nil
+	}
+	if el, ok := c.entries[k]; ok {
+		c.lru.MoveToFront(el)
+		c.hits++
+		c.lock.Unlock()
+		return el.Value.(*cached).d, nil
+	}
+	c.misses++
+	gen := c.gen
+	c.lock.Unlock()
+
+	d, err := c.Client.Get(node, k)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	if c.gen == gen {
+		c.add(k, d)
+	}
+	c.lock.Unlock()
+	return d, nil
+}
+
+// Put puts the record with key k and value d to node and caches it, or only
+// caches it until it is flushed in WriteBack mode.
+func (c *Client) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	return c.write(node, k, &write{node: node, d: d})
+}
+
+// Del deletes the record with key k from node and the cache, or only from
+// the cache until it is flushed in WriteBack mode.
+func (c *Client) Del(node storage.ServiceAddr, k storage.RecordID) error {
+	return c.write(node, k, &write{node: node, del: true})
+}
+
+func (c *Client) write(node storage.ServiceAddr, k storage.RecordID, w *write) error {
+	if c.conf.Mode == WriteBack {
+		c.lock.Lock()
+		c.gen++
+		c.remove(k)
+		if old, ok := c.dirty[k]; ok {
+			c.unsync -= int64(len(old.d))
+		}
+		c.dirty[k] = w
+		c.unsync += int64(len(w.d))
+		full := c.unsync > c.conf.Size
+		c.lock.Unlock()
+		if full {
+			return c.Flush()
+		}
+		return nil
+	}
+
+	err := c.apply(k, w)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gen++
+	c.remove(k)
+	if err == nil && !w.del {
+		c.add(k, w.d)
+	}
+	return err
+}
+
+func (c *Client) apply(k storage.RecordID, w *write) error {
+	if w.del {
+		return c.Client.Del(w.node, k)
+	}
+	return c.Client.Put(w.node, k, w.d)
+}
+
+// MPut puts records bypassing the cache and invalidates them.
+func (c *Client) MPut(node storage.ServiceAddr, keys []storage.RecordID, data [][]byte) ([]error, error) {
+	c.forget(keys)
+	return c.Client.MPut(node, keys, data)
+}
+
+// Update updates the record with key k bypassing the cache and invalidates
+// it, as only node knows whether the record exists.
+func (c *Client) Update(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	c.forget([]storage.RecordID{k})
+	return c.Client.Update(node, k, d)
+}
+
+// Upsert puts or replaces the record with key k bypassing the cache and
+// invalidates it.
+func (c *Client) Upsert(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	c.forget([]storage.RecordID{k})
+	return c.Client.Upsert(node, k, d)
+}
+
+// MDel deletes records bypassing the cache and invalidates them.
+func (c *Client) MDel(node storage.ServiceAddr, keys []storage.RecordID) ([]error, error) {
+	c.forget(keys)
+	return c.Client.MDel(node, keys)
+}
+
+// forget drops cached values and unflushed writes of records with keys,
+// which are about to be overwritten.
+func (c *Client) forget(keys []storage.RecordID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gen++
+	for _, k := range keys {
+		c.remove(k)
+		if w, ok := c.dirty[k]; ok {
+			c.unsync -= int64(len(w.d))
+			delete(c.dirty, k)
+		}
+	}
+}
+
+// Flush applies writes made in WriteBack mode and returns the first error.
+// Failed writes are retried on the next flush unless the records are
+// written again meanwhile.
+func (c *Client) Flush() error {
+	c.flushLock.Lock()
+	defer c.flushLock.Unlock()
+	c.lock.Lock()
+	pending := make(map[storage.RecordID]*write, len(c.dirty))
+	for k, w := range c.dirty {
+		pending[k] = w
+	}
+	c.lock.Unlock()
+
+	var first error
+	for k, w := range pending {
+		if err := c.apply(k, w); err != nil {
+			if first == nil {
+				first = fmt.Errorf("Failed to flush key %v: %w", k, err)
+			}
+			continue
+		}
+		c.lock.Lock()
+		// The record is read from dirty until it is flushed.
+		if c.dirty[k] == w {
+			delete(c.dirty, k)
+			c.unsync -= int64(len(w.d))
+			c.gen++
+			if !w.del {
+				c.add(k, w.d)
+			}
+		}
+		c.lock.Unlock()
+	}
+	return first
+}
+
+// Invalidate drops the cached value of the record with key k. Unflushed
+// writes are kept.
+func (c *Client) Invalidate(k storage.RecordID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gen++
+	c.remove(k)
+}
+
+// Purge drops all cached values. Unflushed writes are kept.
+func (c *Client) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gen++
+	c.lru.Init()
+	c.entries = make(map[storage.RecordID]*list.Element)
+	c.size = 0
+}
+
+// Notify invalidates records of events.RecordChanged, so Client can be
+// subscribed to frontend.Frontend.Changes of an in-process frontend.
+func (c *Client) Notify(e events.Event) {
+	if e.Kind == events.RecordChanged && e.Key != nil {
+		c.Invalidate(*e.Key)
+	}
+}
+
+// CacheStats returns the numbers of hits and misses of Get and the size of
+// cached values.
+func (c *Client) CacheStats() (hits, misses, size int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hits, c.misses, c.size
+}
+
+// Close stops background flushes and watches and flushes writes made in
+// WriteBack mode.
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+	return c.Flush()
+}
+
+// add caches d as the value of the record with key k evicting the least
+// recently used values over the size limit. Should be called with c.lock
+// held.
+func (c *Client) add(k storage.RecordID, d []byte) {
+	c.remove(k)
+	if int64(len(d)) > c.conf.Size {
+		return
+	}
+	c.entries[k] = c.lru.PushFront(&cached{k: k, d: d})
+	c.size += int64(len(d))
+	for c.size > c.conf.Size {
+		c.remove(c.lru.Back().Value.(*cached).k)
+	}
+}
+
+// remove drops the cached value of the record with key k. Should be called
+// with c.lock held.
+func (c *Client) remove(k storage.RecordID) {
+	if el, ok := c.entries[k]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, k)
+		c.size -= int64(len(el.Value.(*cached).d))
+	}
+}
+
+func (c *Client) flushes(ctx context.Context) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.conf.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				c.conf.Logger.Printf("Cache flush failed: %v", err)
+			}
+		}
+	}
+}
+
+// watches invalidates records reported by the watch API until ctx is
+// done, reconnecting to it. All records are invalidated on reconnects,
+// as changes may be missed meanwhile.
+func (c *Client) watches(ctx context.Context) {
+	defer c.wg.Done()
+	for {
+		err := c.watch(ctx)
+		c.Purge()
+		if ctx.Err() != nil {
+			return
+		}
+		c.conf.Logger.Printf("Watching %q failed: %v", c.conf.Watch, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(RetryInterval):
+		}
+	}
+}
+
+func (c *Client) watch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.conf.Watch, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %q", resp.Status)
+	}
+	c.Purge()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var e events.Event
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		c.Notify(e)
+	}
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"events"
+	"storage"
+)
+
+type fakeClient struct {
+	storage.Client
+	lock sync.Mutex
+	data map[storage.RecordID][]byte
+	gets int
+	puts int
+	err  error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{data: make(map[storage.RecordID][]byte)}
+}
+
+func (f *fakeClient) Get(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.gets++
+	d, ok := f.data[k]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func (f *fakeClient) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.puts++
+	f.data[k] = d
+	return nil
+}
+
+func (f *fakeClient) Del(node storage.ServiceAddr, k storage.RecordID) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.data, k)
+	return nil
+}
+
+func (f *fakeClient) stats() (gets, puts int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.gets, f.puts
+}
+
+func TestWriteThrough(t *testing.T) {
+	fc := newFakeClient()
+	c := New(fc, Config{Size: 10})
+	defer c.Close()
+
+	if err := c.Put("fe", 1, []byte("12345")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if _, puts := fc.stats(); puts != 1 {
+		t.Errorf("Put() made %d puts, want 1", puts)
+	}
+	for i := 0; i < 3; i++ {
+		if d, err := c.Get("fe", 1); err != nil || string(d) != "12345" {
+			t.Errorf("Get() got %q, %v, want %q", d, err, "12345")
+		}
+	}
+	if gets, _ := fc.stats(); gets != 0 {
+		t.Errorf("Get() of a put record made %d gets, want 0", gets)
+	}
+
+	fc.data[2] = []byte("67890")
+	fc.data[3] = []byte("abc")
+	c.Get("fe", 2)
+	c.Get("fe", 3)
+	if hits, misses, size := c.CacheStats(); hits != 3 || misses != 2 || size != 8 {
+		t.Errorf("CacheStats() got %d, %d, %d, want 3, 2, 8", hits, misses, size)
+	}
+	c.Get("fe", 1)
+	if gets, _ := fc.stats(); gets != 3 {
+		t.Errorf("Get() of an evicted record made %d gets in total, want 3", gets)
+	}
+
+	fc.err = errors.New("unavailable")
+	if err := c.Put("fe", 3, []byte("def")); err != fc.err {
+		t.Errorf("Put() got error %v, want %v", err, fc.err)
+	}
+	fc.err = nil
+	if d, _ := c.Get("fe", 3); string(d) != "abc" {
+		t.Errorf("Get() after a failed Put() got %q, want %q", d, "abc")
+	}
+	if err := c.Del("fe", 3); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if _, err := c.Get("fe", 3); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after Del() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	fc := newFakeClient()
+	c := New(fc, Config{Size: 10, Mode: WriteBack, FlushInterval: time.Hour})
+
+	c.Put("fe", 1, []byte("123"))
+	c.Put("fe", 1, []byte("456"))
+	fc.data[2] = []byte("old")
+	c.Del("fe", 2)
+	if d, err := c.Get("fe", 1); err != nil || string(d) != "456" {
+		t.Errorf("Get() of an unflushed record got %q, %v, want %q", d, err, "456")
+	}
+	if _, err := c.Get("fe", 2); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() of an unflushed delete got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+	if gets, puts := fc.stats(); gets != 0 || puts != 0 {
+		t.Errorf("Unflushed writes made %d gets and %d puts, want none", gets, puts)
+	}
+
+	fc.err = errors.New("unavailable")
+	if err := c.Flush(); !errors.Is(err, fc.err) {
+		t.Errorf("Flush() got error %v, want %v", err, fc.err)
+	}
+	fc.err = nil
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, puts := fc.stats(); puts != 1 || string(fc.data[1]) != "456" || fc.data[2] != nil {
+		t.Errorf("Close() made %d puts and left %v, want the last writes flushed once", puts, fc.data)
+	}
+	if d, _ := c.Get("fe", 1); string(d) != "456" {
+		t.Errorf("Get() of a flushed record got %q, want %q", d, "456")
+	}
+
+	c = New(fc, Config{Size: 4, Mode: WriteBack, FlushInterval: time.Hour})
+	defer c.Close()
+	c.Put("fe", 3, []byte("abc"))
+	c.Put("fe", 4, []byte("def"))
+	if _, puts := fc.stats(); puts != 3 {
+		t.Errorf("Writes over Size made %d puts in total, want 3", puts)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	fc := newFakeClient()
+	fc.data[1] = []byte("old")
+	stream := events.NewStream()
+	srv := httptest.NewServer(stream)
+	defer srv.Close()
+	c := New(fc, Config{Size: 10, Watch: srv.URL})
+	defer c.Close()
+
+	c.Get("fe", 1)
+	fc.lock.Lock()
+	fc.data[1] = []byte("new")
+	fc.lock.Unlock()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// Events published before the watch connects are missed.
+		stream.Notify(events.Event{Kind: events.NodeDown, Node: "node1"})
+		stream.Notify(events.Event{Kind: events.RecordChanged, Key: events.KeyOf(1)})
+		d, err := c.Get("fe", 1)
+		if err == nil && string(d) == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Get() got %q, %v after the record changed, want %q", d, err, "new")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Get("fe", 2)
+	c.Notify(events.Event{Kind: events.RecordChanged, Key: events.KeyOf(1)})
+	if _, _, size := c.CacheStats(); size != 0 {
+		t.Errorf("CacheStats() got size %d after Notify(), want 0", size)
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChecksumClient is a Client storing a checksum computed by Hash along with
+// every value it puts and verifying it for every value it reads, so
+// corruption anywhere between the application and the disk is detected.
+// Values are stored with the checksum appended, so they should be read by
+// ChecksumClient with the same Hash too. Reads of a corrupted value fail
+// with an error wrapping ErrChecksumMismatch.
+type ChecksumClient struct {
+	Client
+	Hash Hash
+}
+
+// NewChecksumClient creates a ChecksumClient making requests with c
+// and computing checksums with h.
+func NewChecksumClient(c Client, h Hash) Client {
+	return ChecksumClient{Client: c, Hash: h}
+}
+
+// WithContext returns a copy of c whose requests are canceled once ctx
+// is done if its Client supports it.
+func (c ChecksumClient) WithContext(ctx context.Context) Client {
+	c.Client = WithContext(c.Client, ctx)
+	return c
+}
+
+// strip verifies and strips checksums of records with data.
+func (c ChecksumClient) strip(records []Record, err error) ([]Record, error) {
+	if err != nil {
+		return records, err
+	}
+	for i := range records {
+		if records[i].Deleted {
+			continue
+		}
+		d, err := c.Hash.Strip(records[i].Data)
+		if err != nil {
+			return nil, fmt.Errorf("Record %v: %w", records[i].Key, err)
+		}
+		records[i].Data = d
+	}
+	return records, nil
+}
+
+func (c ChecksumClient) Put(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Client.Put(node, k, c.Hash.Append(d))
+}
+
+func (c ChecksumClient) Update(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Client.Update(node, k, c.Hash.Append(d))
+}
+
+func (c ChecksumClient) Upsert(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Client.Upsert(node, k, c.Hash.Append(d))
+}
+
+// Merge fails with ErrInvalidValue, as nodes can't append checksums
+// to the values they merge.
+func (c ChecksumClient) Merge(node ServiceAddr, k RecordID, d []byte) error {
+	return fmt.Errorf("%w: merged values can't be checksummed", ErrInvalidValue)
+}
+
+func (c ChecksumClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
+	d, err := c.Client.Get(node, k)
+	if err != nil && err != ErrPossiblyStale {
+		return d, err
+	}
+	d, serr := c.Hash.Strip(d)
+	if serr != nil {
+		return nil, serr
+	}
+	return d, err
+}
+
+func (c ChecksumClient) GetVersion(node ServiceAddr, k RecordID, version uint64) ([]byte, error) {
+	d, err := c.Client.GetVersion(node, k, version)
+	if err != nil {
+		return d, err
+	}
+	return c.Hash.Strip(d)
+}
+
+func (c ChecksumClient) Scan(node ServiceAddr, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	records, next, err := c.Client.Scan(node, cursor, limit)
+	records, err = c.strip(records, err)
+	return records, next, err
+}
+
+func (c ChecksumClient) ScanSnapshot(node ServiceAddr, id uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	records, next, err := c.Client.ScanSnapshot(node, id, cursor, limit)
+	records, err = c.strip(records, err)
+	return records, next, err
+}
+
+func (c ChecksumClient) ScanChanges(node ServiceAddr, id, since uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	records, next, err := c.Client.ScanChanges(node, id, since, cursor, limit)
+	records, err = c.strip(records, err)
+	return records, next, err
+}
+
+func (c ChecksumClient) Delta(node ServiceAddr, since uint64, limit int) (Delta, error) {
+	delta, err := c.Client.Delta(node, since, limit)
+	delta.Records, err = c.strip(delta.Records, err)
+	return delta, err
+}
+
+func (c ChecksumClient) MGet(node ServiceAddr, keys []RecordID) ([][]byte, []error, error) {
+	data, errs, err := c.Client.MGet(node, keys)
+	if err != nil {
+		return data, errs, err
+	}
+	for i := range data {
+		if errs[i] != nil && errs[i] != ErrPossiblyStale {
+			continue
+		}
+		d, err := c.Hash.Strip(data[i])
+		if err != nil {
+			data[i], errs[i] = nil, err
+			continue
+		}
+		data[i] = d
+	}
+	return data, errs, nil
+}
+
+func (c ChecksumClient) MPut(node ServiceAddr, keys []RecordID, data [][]byte) ([]error, error) {
+	sealed := make([][]byte, len(data))
+	for i, d := range data {
+		sealed[i] = c.Hash.Append(d)
+	}
+	return c.Client.MPut(node, keys, sealed)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// mapClient keeps values of a single node in a map.
+type mapClient struct {
+	Client
+	values map[RecordID][]byte
+}
+
+func (c *mapClient) Put(node ServiceAddr, k RecordID, d []byte) error {
+	c.values[k] = d
+	return nil
+}
+
+func (c *mapClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
+	d, ok := c.values[k]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func (c *mapClient) Scan(node ServiceAddr, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	var records []Record
+	for k, d := range c.values {
+		records = append(records, Record{Key: k, Data: d})
+	}
+	return records, nil, nil
+}
+
+func (c *mapClient) MGet(node ServiceAddr, keys []RecordID) ([][]byte, []error, error) {
+	data := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	for i, k := range keys {
+		data[i], errs[i] = c.Get(node, k)
+	}
+	return data, errs, nil
+}
+
+func TestChecksumClient(t *testing.T) {
+	m := &mapClient{values: make(map[RecordID][]byte)}
+	c := NewChecksumClient(m, HashSHA256)
+	if err := c.Put("node", 1, []byte("value")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if stored := m.values[1]; len(stored) != len("value")+len(Checksum{}) {
+		t.Errorf("Put() stored %d bytes, want the value with a checksum", len(stored))
+	}
+	if d, err := c.Get("node", 1); err != nil || string(d) != "value" {
+		t.Errorf("Get() got %q, %v, want %q", d, err, "value")
+	}
+
+	// Flip a bit of the stored value.
+	m.values[1][0] ^= 1
+	m.values[2] = []byte("short")
+	for k := RecordID(1); k <= 2; k++ {
+		if d, err := c.Get("node", k); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Get(%v) of a corrupted value got %q, %v, want error %v", k, d, err, ErrChecksumMismatch)
+		}
+	}
+	if _, _, err := c.Scan("node", nil, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Scan() of a corrupted value got error %v, want %v", err, ErrChecksumMismatch)
+	}
+
+	m.values[1] = HashSHA256.Append([]byte("value"))
+	data, errs, err := c.MGet("node", []RecordID{1, 2, 3})
+	if err != nil {
+		t.Fatalf("MGet() error: %v", err)
+	}
+	if !bytes.Equal(data[0], []byte("value")) || errs[0] != nil {
+		t.Errorf("MGet() of a valid value got %q, %v", data[0], errs[0])
+	}
+	if !errors.Is(errs[1], ErrChecksumMismatch) || errs[2] != ErrRecordNotFound {
+		t.Errorf("MGet() got errors %v, want %v and %v", errs[1:], ErrChecksumMismatch, ErrRecordNotFound)
+	}
+}
+
+func TestHash(t *testing.T) {
+	for _, h := range []Hash{"", HashSHA256, HashCRC64, HashFNV} {
+		if err := h.Validate(); err != nil {
+			t.Errorf("Validate(%q) error: %v", h, err)
+		}
+		sealed := h.Append([]byte("value"))
+		if len(sealed) != len("value")+h.Size() {
+			t.Errorf("Append() with %q got %d bytes, want %d", h, len(sealed), len("value")+h.Size())
+		}
+		if d, err := h.Strip(sealed); err != nil || string(d) != "value" {
+			t.Errorf("Strip() with %q got %q, %v, want %q", h, d, err, "value")
+		}
+		sealed[0] ^= 1
+		if _, err := h.Strip(sealed); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Strip() with %q of a corrupted value got error %v, want %v", h, err, ErrChecksumMismatch)
+		}
+	}
+	if HashCRC64.Sum([]byte("value")) == HashFNV.Sum([]byte("value")) {
+		t.Errorf("Sum() of different hashes got the same checksum")
+	}
+	if err := Hash("xxhash").Validate(); err == nil {
+		t.Errorf("Validate() of an unknown hash got no error")
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// StorageClass tells how records of a namespace are stored. Nodes keep
+// ClassMemory values in memory only, write ClassPersistent and
+// ClassErasureCoded ones to their data directories, sync them and restore
+// them on restart, and migrate ClassTiered ones to the object store once they are not accessed
+// for a while. Frontends split ClassErasureCoded values into shards, so
+// replicas store less than a full copy each. Records of no namespace are
+// stored as nodes are configured, ClassDefault.
+type StorageClass string
+
+const (
+	ClassDefault      StorageClass = ""
+	ClassMemory       StorageClass = "memory"
+	ClassPersistent   StorageClass = "persistent"
+	ClassTiered       StorageClass = "tiered"
+	ClassErasureCoded StorageClass = "erasure_coded"
+)
+
+// Validate returns an error if c is unknown.
+func (c StorageClass) Validate() error {
+	switch c {
+	case ClassDefault, ClassMemory, ClassPersistent, ClassTiered, ClassErasureCoded:
+		return nil
+	}
+	return fmt.Errorf("Unknown storage class %q, should be one of %q, %q, %q or %q",
+		c, ClassMemory, ClassPersistent, ClassTiered, ClassErasureCoded)
+}
+
+// Durable reports whether values of c should be on disk once written.
+func (c StorageClass) Durable() bool {
+	return c == ClassPersistent || c == ClassErasureCoded
+}
+
+// Namespace declares the storage class of keys having Prefix.
+type Namespace struct {
+	Prefix Prefix
+	Class  StorageClass
+}
+
+// Namespaces are namespaces of a cluster, nodes and frontends should be
+// configured with the same ones. Nested namespaces override the classes
+// of the enclosing ones.
+type Namespaces []Namespace
+
+// Validate checks classes of ns and returns a ConfigError listing
+// all found problems.
+func (ns Namespaces) Validate() error {
+	var errs ConfigError
+	for i, n := range ns {
+		errs.Check(n.Prefix.Len <= 32, "Namespaces[%v].Prefix.Len should be at most 32, got %v", i, n.Prefix.Len)
+		if err := n.Class.Validate(); err != nil {
+			errs.Check(false, "Namespaces[%v]: %v", i, err)
+		}
+	}
+	return errs.Err()
+}
+
+// Class returns the storage class of the longest namespace having k or
+// ClassDefault if there is none.
+func (ns Namespaces) Class(k RecordID) StorageClass {
+	if i := ns.Index(k); i >= 0 {
+		return ns[i].Class
+	}
+	return ClassDefault
+}
+
+// Index returns the index of the longest namespace having k or -1 if
+// there is none.
+func (ns Namespaces) Index(k RecordID) int {
+	index := -1
+	longest := -1
+	for i, n := range ns {
+		if int(n.Prefix.Len) > longest && n.Prefix.Match(k) {
+			index, longest = i, int(n.Prefix.Len)
+		}
+	}
+	return index
+}
+
+// Has reports whether any namespace of ns has class c.
+func (ns Namespaces) Has(c StorageClass) bool {
+	for _, n := range ns {
+		if n.Class == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Shards is a number of shards ClassErasureCoded values are split into,
+// one per replica: two halves of a value and their parity, so any two
+// shards restore it.
+const Shards = 3
+
+// shardHeader is a size of the header of a shard: its index, the size
+// of the value and the CRC-32 of the shard data.
+const shardHeader = 1 + 4 + 4
+
+// ErrInvalidShard is returned by DecodeShards if shards are corrupt or
+// belong to different values.
+var ErrInvalidShard = errors.New("Invalid erasure-coded shard")
+
+// EncodeShards splits d into Shards shards stored by replicas of
+// a ClassErasureCoded record.
+func EncodeShards(d []byte) [][]byte {
+	half := (len(d) + 1) / 2
+	shards := make([][]byte, Shards)
+	for i := range shards {
+		shard := make([]byte, shardHeader+half)
+		shard[0] = byte(i)
+		binary.BigEndian.PutUint32(shard[1:], uint32(len(d)))
+		shards[i] = shard
+	}
+	copy(shards[0][shardHeader:], d[:half])
+	copy(shards[1][shardHeader:], d[half:])
+	for j := 0; j < half; j++ {
+		shards[2][shardHeader+j] = shards[0][shardHeader+j] ^ shards[1][shardHeader+j]
+	}
+	for _, shard := range shards {
+		binary.BigEndian.PutUint32(shard[5:], crc32.ChecksumIEEE(shard[shardHeader:]))
+	}
+	return shards
+}
+
+// DecodeShards restores the value from shards returned by replicas,
+// at least two distinct ones are needed, an error wrapping
+// ErrQuorumNotReached is returned otherwise. Corrupt shards are skipped.
+func DecodeShards(shards [][]byte) ([]byte, error) {
+	var parts [Shards][]byte
+	size := -1
+	for _, shard := range shards {
+		if len(shard) < shardHeader || shard[0] >= Shards ||
+			crc32.ChecksumIEEE(shard[shardHeader:]) != binary.BigEndian.Uint32(shard[5:]) {
+			continue
+		}
+		n := int(binary.BigEndian.Uint32(shard[1:]))
+		if len(shard)-shardHeader != (n+1)/2 || size >= 0 && n != size {
+			return nil, fmt.Errorf("%w: shards of values of %d and %d bytes", ErrInvalidShard, size, n)
+		}
+		size = n
+		parts[shard[0]] = shard[shardHeader:]
+	}
+
+	a, b, p := parts[0], parts[1], parts[2]
+	switch {
+	case a != nil && b != nil:
+	case a != nil && p != nil:
+		b = xor(a, p)
+	case b != nil && p != nil:
+		a = xor(b, p)
+	default:
+		return nil, fmt.Errorf("%w: not enough valid shards to restore the value", ErrQuorumNotReached)
+	}
+	d := make([]byte, 0, 2*len(a))
+	d = append(append(d, a...), b...)
+	return d[:size], nil
+}
+
+func xor(a, b []byte) []byte {
+	x := make([]byte, len(a))
+	for i := range a {
+		x[i] = a[i] ^ b[i]
+	}
+	return x
+}
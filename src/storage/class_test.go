@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNamespacesClass(t *testing.T) {
+	ns := Namespaces{
+		{Prefix: Prefix{Key: 0x10000000, Len: 4}, Class: ClassTiered},
+		{Prefix: Prefix{Key: 0x12000000, Len: 8}, Class: ClassMemory},
+	}
+	if err := ns.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	for k, want := range map[RecordID]StorageClass{
+		0x10000001: ClassTiered,
+		0x12345678: ClassMemory,
+		0x20000000: ClassDefault,
+	} {
+		if got := ns.Class(k); got != want {
+			t.Errorf("Class(%#x) got %q, want %q", k, got, want)
+		}
+	}
+	if !ns.Has(ClassMemory) || ns.Has(ClassPersistent) {
+		t.Errorf("Has() got wrong classes of %v", ns)
+	}
+
+	bad := Namespaces{{Prefix: Prefix{Len: 40}, Class: "tape"}}
+	if err, ok := bad.Validate().(ConfigError); !ok || len(err) != 2 {
+		t.Errorf("Validate() got %v, want 2 problems", err)
+	}
+}
+
+func TestShards(t *testing.T) {
+	for _, d := range []string{"", "a", "odd value", "even value"} {
+		shards := EncodeShards([]byte(d))
+		if len(shards) != Shards {
+			t.Fatalf("EncodeShards(%q) got %d shards, want %d", d, len(shards), Shards)
+		}
+		for lost := 0; lost < Shards; lost++ {
+			var left [][]byte
+			for i, s := range shards {
+				if i != lost {
+					left = append(left, s)
+				}
+			}
+			got, err := DecodeShards(left)
+			if err != nil || !bytes.Equal(got, []byte(d)) {
+				t.Errorf("DecodeShards() of %q without shard %d got %q, %v", d, lost, got, err)
+			}
+		}
+	}
+
+	shards := EncodeShards([]byte("some value"))
+	shards[0][len(shards[0])-1] ^= 1
+	if _, err := DecodeShards(shards[:2]); !errors.Is(err, ErrQuorumNotReached) {
+		t.Errorf("DecodeShards() with a corrupt shard got %v, want %v", err, ErrQuorumNotReached)
+	}
+	if got, err := DecodeShards(shards); err != nil || string(got) != "some value" {
+		t.Errorf("DecodeShards() skipping a corrupt shard got %q, %v", got, err)
+	}
+	other := EncodeShards([]byte("another value"))
+	if _, err := DecodeShards([][]byte{shards[1], other[2]}); !errors.Is(err, ErrInvalidShard) {
+		t.Errorf("DecodeShards() of different values got %v, want %v", err, ErrInvalidShard)
+	}
+}
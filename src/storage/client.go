@@ -2,11 +2,13 @@ package storage
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"storage/pb"
 )
@@ -15,9 +17,55 @@ type Client interface {
 	Put(node ServiceAddr, k RecordID, d []byte) error
 	Get(node ServiceAddr, k RecordID) ([]byte, error)
 	Del(node ServiceAddr, k RecordID) error
+	Scan(node ServiceAddr, cursor Cursor, limit int) ([]Record, Cursor, error)
+	AcquireLease(node ServiceAddr, k RecordID, holder, fence uint64, ttl time.Duration) (uint64, error)
+	LeaseFence(node ServiceAddr, k RecordID) (uint64, error)
+	ReleaseLease(node ServiceAddr, k RecordID, holder uint64) error
+	Sequence(node ServiceAddr, name string, floor uint64) (uint64, error)
+	Stats(node ServiceAddr) (Stats, error)
+	GetVersion(node ServiceAddr, k RecordID, version uint64) ([]byte, error)
+	ListVersions(node ServiceAddr, k RecordID) ([]uint64, error)
+	Snapshot(node ServiceAddr, id uint64, phase SnapshotPhase, ttl time.Duration) error
+	ScanSnapshot(node ServiceAddr, id uint64, cursor Cursor, limit int) ([]Record, Cursor, error)
+	ScanChanges(node ServiceAddr, id, since uint64, cursor Cursor, limit int) ([]Record, Cursor, error)
+	Reserve(node ServiceAddr, k RecordID, size int64, ttl time.Duration) error
+	CancelReservation(node ServiceAddr, k RecordID) error
+	MGet(node ServiceAddr, keys []RecordID) ([][]byte, []error, error)
+	MPut(node ServiceAddr, keys []RecordID, data [][]byte) ([]error, error)
+	MDel(node ServiceAddr, keys []RecordID) ([]error, error)
+	Delta(node ServiceAddr, since uint64, limit int) (Delta, error)
+	Checksum(node ServiceAddr, k RecordID, h Hash) (Checksum, error)
+	Paxos(node ServiceAddr, k RecordID, phase PaxosPhase, p Proposal) (Promise, error)
+	AcquireReadLease(node ServiceAddr, k RecordID, holder uint64, ttl time.Duration) (ReadLease, error)
+	Update(node ServiceAddr, k RecordID, d []byte) error
+	Upsert(node ServiceAddr, k RecordID, d []byte) error
+	// Merge merges the CRDT value d into the record with key k on node,
+	// see crdt.Merge, putting d if there is no such record.
+	Merge(node ServiceAddr, k RecordID, d []byte) error
 }
 
-type StorageClient struct{}
+// ContextClient is a Client which can make requests on behalf of a context.
+type ContextClient interface {
+	Client
+	// WithContext returns a copy of the client whose requests are canceled
+	// once ctx is done.
+	WithContext(ctx context.Context) Client
+}
+
+// WithContext returns c making requests canceled once ctx is done if it is
+// a ContextClient, and c otherwise.
+func WithContext(c Client, ctx context.Context) Client {
+	if cc, ok := c.(ContextClient); ok {
+		return cc.WithContext(ctx)
+	}
+	return c
+}
+
+type StorageClient struct {
+	resolver Resolver
+	signer   *Signer
+	ctx      context.Context
+}
 
 var defaultClient Client = StorageClient{}
 
@@ -25,22 +73,62 @@ func NewClient() Client {
 	return defaultClient
 }
 
+// NewClientWithResolver creates a Client resolving node addresses with r.
+func NewClientWithResolver(r Resolver) Client {
+	return StorageClient{resolver: r}
+}
+
+// NewSigningClient creates a Client resolving node addresses with r and
+// signing requests with s, which doesn't sign if it is nil.
+func NewSigningClient(r Resolver, s *Signer) Client {
+	return StorageClient{resolver: r, signer: s}
+}
+
+// WithContext returns a copy of c whose requests are canceled once ctx
+// is done. Requests still time out after Timeout. They are served at
+// the consistency level of ctx, see WithConsistency, and writes create
+// the version of ctx, see WithVersion.
+func (c StorageClient) WithContext(ctx context.Context) Client {
+	c.ctx = ctx
+	return c
+}
+
+func (c StorageClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return outgoingVersion(outgoingConsistency(c.ctx))
+}
+
 func (c StorageClient) do(addr ServiceAddr, cb func(client pb.StorageClient) ([]byte, error)) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	target, err := ResolveAddr(c.resolver, addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.context(), Timeout)
 	defer cancel()
-	conn, err := grpc.DialContext(ctx, string(addr), grpc.WithInsecure())
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if c.signer != nil {
+		opts = append(opts, c.signer.DialOption())
+	}
+	conn, err := grpc.DialContext(ctx, target, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("Error dialing %q: %v", addr, err)
 	}
 	defer conn.Close()
 	client := pb.NewStorageClient(conn)
-	return cb(client)
+	d, err := cb(client)
+	if status.Code(err) == codes.DeadlineExceeded {
+		// Counted by frontends the same as deadlines exceeded by nodes.
+		err = context.DeadlineExceeded
+	}
+	return d, err
 }
 
 func (c StorageClient) Put(node ServiceAddr, k RecordID, d []byte) error {
 	log.Printf("Putting record to %q, key = %v", node, k)
 	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
 		defer cancel()
 		req := pb.PutRequest{
 			Key:  uint32(k),
@@ -54,10 +142,7 @@ func (c StorageClient) Put(node ServiceAddr, k RecordID, d []byte) error {
 		if status == StatusOk {
 			return nil, nil
 		}
-		if err := status.ToError(); err != ErrUnknownStatus {
-			return nil, err
-		}
-		return nil, errors.New(reply.Error)
+		return nil, UnmarshalError(status, reply.Error)
 	})
 	return err
 }
@@ -65,7 +150,7 @@ func (c StorageClient) Put(node ServiceAddr, k RecordID, d []byte) error {
 func (c StorageClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
 	log.Printf("Getting record from %q, key = %v", node, k)
 	return c.do(node, func(client pb.StorageClient) ([]byte, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
 		defer cancel()
 		req := pb.GetRequest{
 			Key: uint32(k),
@@ -78,17 +163,17 @@ func (c StorageClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
 		if status == StatusOk {
 			return reply.Data, nil
 		}
-		if err := status.ToError(); err != ErrUnknownStatus {
-			return nil, err
+		if status == StatusPossiblyStale {
+			return reply.Data, UnmarshalError(status, reply.Error)
 		}
-		return nil, errors.New(reply.Error)
+		return nil, UnmarshalError(status, reply.Error)
 	})
 }
 
 func (c StorageClient) Del(node ServiceAddr, k RecordID) error {
 	log.Printf("Deleting record from %q, key = %v", node, k)
 	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
-		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
 		defer cancel()
 		req := pb.DelRequest{
 			Key: uint32(k),
@@ -101,10 +186,558 @@ func (c StorageClient) Del(node ServiceAddr, k RecordID) error {
 		if status == StatusOk {
 			return nil, nil
 		}
-		if err := status.ToError(); err != ErrUnknownStatus {
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return err
+}
+
+func (c StorageClient) Scan(node ServiceAddr, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	log.Printf("Scanning records from %q, limit = %v", node, limit)
+	return c.scan(node, func(ctx context.Context, client pb.StorageClient) (*pb.ScanReply, error) {
+		req := pb.ScanRequest{
+			Cursor: cursor,
+			Limit:  uint32(limit),
+		}
+		return client.Scan(ctx, &req)
+	})
+}
+
+func (c StorageClient) ScanSnapshot(node ServiceAddr, id uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	log.Printf("Scanning snapshot %v records from %q, limit = %v", id, node, limit)
+	return c.scan(node, func(ctx context.Context, client pb.StorageClient) (*pb.ScanReply, error) {
+		req := pb.ScanSnapshotRequest{
+			Id:     id,
+			Cursor: cursor,
+			Limit:  uint32(limit),
+		}
+		return client.ScanSnapshot(ctx, &req)
+	})
+}
+
+func (c StorageClient) ScanChanges(node ServiceAddr, id, since uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	log.Printf("Scanning changes of snapshot %v since %v from %q, limit = %v", id, since, node, limit)
+	return c.scan(node, func(ctx context.Context, client pb.StorageClient) (*pb.ScanReply, error) {
+		req := pb.ScanChangesRequest{
+			Id:     id,
+			Since:  since,
+			Cursor: cursor,
+			Limit:  uint32(limit),
+		}
+		return client.ScanChanges(ctx, &req)
+	})
+}
+
+func (c StorageClient) scan(node ServiceAddr, call func(ctx context.Context, client pb.StorageClient) (*pb.ScanReply, error)) ([]Record, Cursor, error) {
+	var records []Record
+	next, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		reply, err := call(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			records = make([]Record, 0, len(reply.Records))
+			for _, r := range reply.Records {
+				records = append(records, Record{
+					Key:     RecordID(r.Key),
+					Data:    r.Data,
+					Deleted: r.Deleted,
+				})
+			}
+			return reply.Next, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return records, next, nil
+}
+
+func (c StorageClient) AcquireLease(node ServiceAddr, k RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	log.Printf("Acquiring lease from %q, key = %v", node, k)
+	var granted uint64
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.AcquireLeaseRequest{
+			Key:    uint32(k),
+			Holder: holder,
+			Ttl:    int64(ttl),
+			Fence:  fence,
+		}
+		reply, err := client.AcquireLease(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			granted = reply.Fence
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return granted, err
+}
+
+func (c StorageClient) LeaseFence(node ServiceAddr, k RecordID) (uint64, error) {
+	log.Printf("Getting lease fence from %q, key = %v", node, k)
+	var fence uint64
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		reply, err := client.LeaseFence(ctx, &pb.LeaseFenceRequest{Key: uint32(k)})
+		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New(reply.Error)
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			fence = reply.Fence
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return fence, err
+}
+
+func (c StorageClient) ReleaseLease(node ServiceAddr, k RecordID, holder uint64) error {
+	log.Printf("Releasing lease from %q, key = %v", node, k)
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.ReleaseLeaseRequest{
+			Key:    uint32(k),
+			Holder: holder,
+		}
+		reply, err := client.ReleaseLease(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
 	})
 	return err
 }
+
+func (c StorageClient) Sequence(node ServiceAddr, name string, floor uint64) (uint64, error) {
+	log.Printf("Sequence request to %q, name = %q", node, name)
+	var value uint64
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.SequenceRequest{
+			Name:  name,
+			Floor: floor,
+		}
+		reply, err := client.Sequence(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			value = reply.Value
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return value, err
+}
+
+func (c StorageClient) Stats(node ServiceAddr) (Stats, error) {
+	log.Printf("Stats request to %q", node)
+	var stats Stats
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		reply, err := client.Stats(ctx, &pb.StatsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			stats = Stats{
+				Records:  reply.Records,
+				Bytes:    reply.Bytes,
+				Requests: reply.Requests,
+			}
+			for _, n := range reply.Namespaces {
+				stats.Namespaces = append(stats.Namespaces, NamespaceStats{
+					Prefix:  Prefix{Key: RecordID(n.Prefix), Len: uint(n.PrefixLen)},
+					Records: n.Records,
+					Bytes:   n.Bytes,
+				})
+			}
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return stats, err
+}
+
+func (c StorageClient) GetVersion(node ServiceAddr, k RecordID, version uint64) ([]byte, error) {
+	log.Printf("Getting version %v of record from %q, key = %v", version, node, k)
+	return c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.GetVersionRequest{
+			Key:     uint32(k),
+			Version: version,
+		}
+		reply, err := client.GetVersion(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			return reply.Data, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+}
+
+func (c StorageClient) ListVersions(node ServiceAddr, k RecordID) ([]uint64, error) {
+	log.Printf("Listing versions of record from %q, key = %v", node, k)
+	var versions []uint64
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.ListVersionsRequest{
+			Key: uint32(k),
+		}
+		reply, err := client.ListVersions(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			versions = reply.Versions
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return versions, err
+}
+
+func (c StorageClient) Snapshot(node ServiceAddr, id uint64, phase SnapshotPhase, ttl time.Duration) error {
+	log.Printf("Snapshot request to %q, id = %v, phase = %v", node, id, phase)
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.SnapshotRequest{
+			Id:    id,
+			Phase: int32(phase),
+			Ttl:   int64(ttl),
+		}
+		reply, err := client.Snapshot(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return err
+}
+
+func (c StorageClient) Reserve(node ServiceAddr, k RecordID, size int64, ttl time.Duration) error {
+	log.Printf("Reserving %d bytes at %q, key = %v", size, node, k)
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.ReserveRequest{
+			Key:  uint32(k),
+			Size: size,
+			Ttl:  int64(ttl),
+		}
+		reply, err := client.Reserve(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		return nil, UnmarshalError(StatusCode(reply.Status), reply.Error)
+	})
+	return err
+}
+
+func (c StorageClient) CancelReservation(node ServiceAddr, k RecordID) error {
+	log.Printf("Cancelling reservation at %q, key = %v", node, k)
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.CancelReservationRequest{
+			Key: uint32(k),
+		}
+		reply, err := client.CancelReservation(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		return nil, UnmarshalError(StatusCode(reply.Status), reply.Error)
+	})
+	return err
+}
+
+func (c StorageClient) MGet(node ServiceAddr, keys []RecordID) ([][]byte, []error, error) {
+	log.Printf("Getting %d records from %q", len(keys), node)
+	var data [][]byte
+	var errs []error
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.MGetRequest{
+			Keys: make([]uint32, 0, len(keys)),
+		}
+		for _, k := range keys {
+			req.Keys = append(req.Keys, uint32(k))
+		}
+		reply, err := client.MGet(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status != StatusOk {
+			return nil, UnmarshalError(status, reply.Error)
+		}
+		if len(reply.Records) != len(keys) {
+			return nil, fmt.Errorf("Wrong number of records: got %v, want %v", len(reply.Records), len(keys))
+		}
+		data = make([][]byte, len(keys))
+		errs = make([]error, len(keys))
+		for i, r := range reply.Records {
+			status := StatusCode(r.Status)
+			if status == StatusOk || status == StatusPossiblyStale {
+				data[i] = r.Data
+			}
+			if status != StatusOk {
+				errs[i] = UnmarshalError(status, r.Error)
+			}
+		}
+		return nil, nil
+	})
+	return data, errs, err
+}
+
+func (c StorageClient) MPut(node ServiceAddr, keys []RecordID, data [][]byte) ([]error, error) {
+	log.Printf("Putting %d records to %q", len(keys), node)
+	var errs []error
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.MPutRequest{
+			Records: make([]*pb.PutRequest, 0, len(keys)),
+		}
+		for i, k := range keys {
+			req.Records = append(req.Records, &pb.PutRequest{Key: uint32(k), Data: data[i]})
+		}
+		reply, err := client.MPut(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status != StatusOk {
+			return nil, UnmarshalError(status, reply.Error)
+		}
+		if len(reply.Records) != len(keys) {
+			return nil, fmt.Errorf("Wrong number of records: got %v, want %v", len(reply.Records), len(keys))
+		}
+		errs = make([]error, len(keys))
+		for i, r := range reply.Records {
+			errs[i] = UnmarshalError(StatusCode(r.Status), r.Error)
+		}
+		return nil, nil
+	})
+	return errs, err
+}
+
+func (c StorageClient) MDel(node ServiceAddr, keys []RecordID) ([]error, error) {
+	log.Printf("Deleting %d records from %q", len(keys), node)
+	var errs []error
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.MDelRequest{
+			Keys: make([]uint32, 0, len(keys)),
+		}
+		for _, k := range keys {
+			req.Keys = append(req.Keys, uint32(k))
+		}
+		reply, err := client.MDel(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status != StatusOk {
+			return nil, UnmarshalError(status, reply.Error)
+		}
+		if len(reply.Records) != len(keys) {
+			return nil, fmt.Errorf("Wrong number of records: got %v, want %v", len(reply.Records), len(keys))
+		}
+		errs = make([]error, len(keys))
+		for i, r := range reply.Records {
+			errs[i] = UnmarshalError(StatusCode(r.Status), r.Error)
+		}
+		return nil, nil
+	})
+	return errs, err
+}
+
+func (c StorageClient) Delta(node ServiceAddr, since uint64, limit int) (Delta, error) {
+	log.Printf("Delta request to %q: since = %v", node, since)
+	var delta Delta
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.DeltaRequest{
+			Since: since,
+			Limit: uint32(limit),
+		}
+		reply, err := client.Delta(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status != StatusOk {
+			return nil, UnmarshalError(status, reply.Error)
+		}
+		delta = Delta{
+			Records: make([]Record, 0, len(reply.Records)),
+			Seq:     reply.Seq,
+			More:    reply.More,
+		}
+		for _, r := range reply.Records {
+			delta.Records = append(delta.Records, Record{Key: RecordID(r.Key), Data: r.Data, Deleted: r.Deleted})
+		}
+		return nil, nil
+	})
+	return delta, err
+}
+
+func (c StorageClient) Checksum(node ServiceAddr, k RecordID, h Hash) (Checksum, error) {
+	log.Printf("Checksum request to %q, key = %v, hash = %q", node, k, h)
+	var sum Checksum
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.ChecksumRequest{
+			Key:  uint32(k),
+			Hash: string(h),
+		}
+		reply, err := client.Checksum(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status != StatusOk {
+			return nil, UnmarshalError(status, reply.Error)
+		}
+		if len(reply.Sum) != len(sum) {
+			return nil, fmt.Errorf("Wrong checksum size: got %v, want %v", len(reply.Sum), len(sum))
+		}
+		copy(sum[:], reply.Sum)
+		return nil, nil
+	})
+	return sum, err
+}
+
+func (c StorageClient) Paxos(node ServiceAddr, k RecordID, phase PaxosPhase, p Proposal) (Promise, error) {
+	log.Printf("Paxos request to %q, key = %v, phase = %v, ballot = %v", node, k, phase, p.Ballot)
+	var promise Promise
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.PaxosRequest{
+			Key:      uint32(k),
+			Phase:    int32(phase),
+			Proposal: proposalToPb(p),
+		}
+		reply, err := client.Paxos(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		promise = Promise{
+			Promised:  reply.Promised,
+			Accepted:  proposalFromPb(reply.Accepted),
+			Committed: reply.Committed,
+			Value:     reply.Value,
+			Found:     reply.Found,
+		}
+		return nil, UnmarshalError(StatusCode(reply.Status), reply.Error)
+	})
+	return promise, err
+}
+
+func (c StorageClient) AcquireReadLease(node ServiceAddr, k RecordID, holder uint64, ttl time.Duration) (ReadLease, error) {
+	log.Printf("Acquiring read lease from %q, key = %v, ttl = %v", node, k, ttl)
+	var lease ReadLease
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.AcquireReadLeaseRequest{
+			Key:    uint32(k),
+			Holder: holder,
+			Ttl:    int64(ttl),
+		}
+		reply, err := client.AcquireReadLease(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			lease = ReadLease{Value: reply.Value, Found: reply.Found}
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return lease, err
+}
+
+func (c StorageClient) Update(node ServiceAddr, k RecordID, d []byte) error {
+	log.Printf("Updating record at %q, key = %v", node, k)
+	return c.write(node, k, d, pb.StorageClient.Update)
+}
+
+func (c StorageClient) Upsert(node ServiceAddr, k RecordID, d []byte) error {
+	log.Printf("Upserting record to %q, key = %v", node, k)
+	return c.write(node, k, d, pb.StorageClient.Upsert)
+}
+
+func (c StorageClient) Merge(node ServiceAddr, k RecordID, d []byte) error {
+	log.Printf("Merging record to %q, key = %v", node, k)
+	return c.write(node, k, d, pb.StorageClient.Merge)
+}
+
+// write makes a request taking a PutRequest with method, e.g. Update.
+func (c StorageClient) write(node ServiceAddr, k RecordID, d []byte,
+	method func(pb.StorageClient, context.Context, *pb.PutRequest, ...grpc.CallOption) (*pb.PutReply, error)) error {
+	_, err := c.do(node, func(client pb.StorageClient) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(c.context(), Timeout)
+		defer cancel()
+		req := pb.PutRequest{
+			Key:  uint32(k),
+			Data: d,
+		}
+		reply, err := method(client, ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusCode(reply.Status)
+		if status == StatusOk {
+			return nil, nil
+		}
+		return nil, UnmarshalError(status, reply.Error)
+	})
+	return err
+}
+
+func proposalToPb(p Proposal) *pb.Proposal {
+	return &pb.Proposal{Ballot: p.Ballot, Data: p.Data, Deleted: p.Deleted}
+}
+
+func proposalFromPb(p *pb.Proposal) Proposal {
+	return Proposal{Ballot: p.GetBallot(), Data: p.GetData(), Deleted: p.GetDeleted()}
+}
@@ -0,0 +1,18 @@
+package storage
+
+import "time"
+
+// Clock tells the current time. Services use it instead of time.Now,
+// so it can be replaced to control time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is a Clock returning the system time.
+var SystemClock Clock = systemClock{}
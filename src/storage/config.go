@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigError lists all problems found in a configuration.
+type ConfigError []string
+
+func (e ConfigError) Error() string {
+	return "Invalid config: " + strings.Join(e, "; ")
+}
+
+// Check adds a problem described by format and args unless ok.
+func (e *ConfigError) Check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		*e = append(*e, fmt.Sprintf(format, args...))
+	}
+}
+
+// Merge adds problems listed by err if it is a ConfigError
+// or err itself otherwise.
+func (e *ConfigError) Merge(err error) {
+	if errs, ok := err.(ConfigError); ok {
+		*e = append(*e, errs...)
+	} else if err != nil {
+		*e = append(*e, err.Error())
+	}
+}
+
+// Err returns e if any problem was found and nil otherwise.
+func (e ConfigError) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ApplyEnv overrides fields of the config struct pointed to by cfg with
+// environment variables named by prefix and the YAML name of a field, e.g.
+// DDSP_NODE_GC_INTERVAL for the gc_interval field and the DDSP_NODE prefix.
+// Fields of nested structs are named by their YAML path, e.g.
+// DDSP_NODE_METRICS_SINK. Values are parsed as YAML, so durations, lists
+// and maps are written the same way as in config files.
+func ApplyEnv(prefix string, cfg interface{}) error {
+	var errs ConfigError
+	applyEnv(prefix, reflect.ValueOf(cfg).Elem(), &errs)
+	return errs.Err()
+}
+
+func applyEnv(prefix string, v reflect.Value, errs *ConfigError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		env := prefix + "_" + strings.ToUpper(name)
+
+		fv := v.Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			if _, ok := fv.Addr().Interface().(yaml.Unmarshaler); !ok {
+				if len(tag) > 1 && tag[1] == "inline" {
+					env = prefix
+				}
+				applyEnv(env, fv, errs)
+				continue
+			}
+		}
+
+		s, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if f.Type.Kind() == reflect.String {
+			fv.SetString(s)
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(s), fv.Addr().Interface()); err != nil {
+			errs.Check(false, "%v: %v", env, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type envConfig struct {
+	Addr     ServiceAddr
+	Timeout  time.Duration `yaml:"timeout"`
+	Versions int
+	Enabled  bool `yaml:"enabled"`
+	Nodes    []ServiceAddr
+	Nested   struct {
+		Kind string
+		Tags map[string]string `yaml:"tags"`
+	}
+	Client Client `yaml:"-"`
+	hidden string
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("DDSP_TEST_ADDR", "127.0.0.1:7321")
+	t.Setenv("DDSP_TEST_TIMEOUT", "3s")
+	t.Setenv("DDSP_TEST_ENABLED", "true")
+	t.Setenv("DDSP_TEST_NODES", "[node1, node2]")
+	t.Setenv("DDSP_TEST_NESTED_KIND", "yes")
+	t.Setenv("DDSP_TEST_NESTED_TAGS", "{zone: a}")
+
+	cfg := envConfig{Versions: 2}
+	if err := ApplyEnv("DDSP_TEST", &cfg); err != nil {
+		t.Fatalf("ApplyEnv() error: %v", err)
+	}
+	want := envConfig{
+		Addr:     "127.0.0.1:7321",
+		Timeout:  3 * time.Second,
+		Versions: 2,
+		Enabled:  true,
+		Nodes:    []ServiceAddr{"node1", "node2"},
+	}
+	want.Nested.Kind = "yes"
+	want.Nested.Tags = map[string]string{"zone": "a"}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("ApplyEnv() got %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("DDSP_TEST_VERSIONS", "many")
+	t.Setenv("DDSP_TEST_TIMEOUT", "long")
+	err := ApplyEnv("DDSP_TEST", &cfg)
+	if errs, ok := err.(ConfigError); !ok || len(errs) != 2 {
+		t.Errorf("ApplyEnv() got error %v, want 2 problems", err)
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ConsistencyLevel tells how many replicas of a record must agree on
+// the result of a request to it. Reads at ConsistencyOne return the first
+// answer and may be stale, writes at it may be lost with the replica.
+// Reads at ConsistencyAll see every write acknowledged at any level, but
+// fail if a replica is unavailable. Requests default to ConsistencyQuorum,
+// i.e. MinRedundancy replicas.
+type ConsistencyLevel string
+
+const (
+	ConsistencyOne    ConsistencyLevel = "one"
+	ConsistencyQuorum ConsistencyLevel = "quorum"
+	ConsistencyAll    ConsistencyLevel = "all"
+)
+
+// Validate returns an error if l is unknown.
+func (l ConsistencyLevel) Validate() error {
+	switch l {
+	case ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+		return nil
+	}
+	return fmt.Errorf("Unknown consistency level %q, should be one of %q, %q or %q",
+		l, ConsistencyOne, ConsistencyQuorum, ConsistencyAll)
+}
+
+// Required returns the number of replicas out of replicas which must agree
+// at level l. replicas should be the number of replicas a record is placed
+// on, e.g. ReplicationFactor, rather than the live ones, or ConsistencyAll
+// would be satisfied by fewer replicas while some are down. ConsistencyAll
+// never requires less than ConsistencyQuorum.
+func (l ConsistencyLevel) Required(replicas int) int {
+	switch l {
+	case ConsistencyOne:
+		return 1
+	case ConsistencyAll:
+		return max(replicas, MinRedundancy)
+	}
+	return MinRedundancy
+}
+
+// consistencyKey is the metadata key passing consistency levels.
+const consistencyKey = "x-ddsp-consistency"
+
+type consistencyContextKey struct{}
+
+// WithConsistency returns a copy of ctx making requests run with it at
+// level l. Clients pass l to nodes and frontends along with requests.
+func WithConsistency(ctx context.Context, l ConsistencyLevel) context.Context {
+	return context.WithValue(ctx, consistencyContextKey{}, l)
+}
+
+// ConsistencyOf returns the consistency level of requests run with ctx,
+// ConsistencyQuorum unless set with WithConsistency.
+func ConsistencyOf(ctx context.Context) ConsistencyLevel {
+	if l, ok := ctx.Value(consistencyContextKey{}).(ConsistencyLevel); ok {
+		return l
+	}
+	return ConsistencyQuorum
+}
+
+// outgoingConsistency adds the consistency level of ctx to the metadata
+// of requests made with it, unless it is the default one.
+func outgoingConsistency(ctx context.Context) context.Context {
+	if l := ConsistencyOf(ctx); l != ConsistencyQuorum {
+		return metadata.AppendToOutgoingContext(ctx, consistencyKey, string(l))
+	}
+	return ctx
+}
+
+// incomingConsistency restores the consistency level passed with
+// the request served with ctx. Unknown levels are ignored.
+func incomingConsistency(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	l := ConsistencyLevel(first(md, consistencyKey))
+	if l == "" || l.Validate() != nil {
+		return ctx
+	}
+	return WithConsistency(ctx, l)
+}
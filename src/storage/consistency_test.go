@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestConsistencyLevel(t *testing.T) {
+	for _, test := range []struct {
+		level    ConsistencyLevel
+		replicas int
+		want     int
+	}{
+		{ConsistencyOne, 3, 1},
+		{ConsistencyQuorum, 3, MinRedundancy},
+		{ConsistencyAll, 3, 3},
+		{ConsistencyAll, 1, MinRedundancy},
+	} {
+		if got := test.level.Required(test.replicas); got != test.want {
+			t.Errorf("%v.Required(%d) = %d, want %d", test.level, test.replicas, got, test.want)
+		}
+	}
+	if err := ConsistencyLevel("two").Validate(); err == nil {
+		t.Errorf("Validate() of an unknown level got no error")
+	}
+}
+
+func TestConsistencyMetadata(t *testing.T) {
+	if l := ConsistencyOf(context.Background()); l != ConsistencyQuorum {
+		t.Errorf("ConsistencyOf() got %v by default, want %v", l, ConsistencyQuorum)
+	}
+	ctx := outgoingConsistency(WithConsistency(context.Background(), ConsistencyOne))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	ctx = incomingConsistency(metadata.NewIncomingContext(context.Background(), md))
+	if l := ConsistencyOf(ctx); l != ConsistencyOne {
+		t.Errorf("ConsistencyOf() got %v passed over the wire, want %v", l, ConsistencyOne)
+	}
+}
+
+func TestVersionMetadata(t *testing.T) {
+	if v := VersionOf(context.Background()); v != 0 {
+		t.Errorf("VersionOf() got %v by default, want 0", v)
+	}
+	ctx := outgoingVersion(WithVersion(context.Background(), 42))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	ctx = incomingVersion(metadata.NewIncomingContext(context.Background(), md))
+	if v := VersionOf(ctx); v != 42 {
+		t.Errorf("VersionOf() got %v passed over the wire, want %v", v, 42)
+	}
+	md = metadata.Pairs(versionKey, "garbage")
+	ctx = incomingVersion(metadata.NewIncomingContext(context.Background(), md))
+	if v := VersionOf(ctx); v != 0 {
+		t.Errorf("VersionOf() got %v for a malformed version, want 0", v)
+	}
+}
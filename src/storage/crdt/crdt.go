@@ -0,0 +1,113 @@
+// Package crdt implements conflict-free replicated data types which
+// can be stored as record values. Divergent replicas of such records
+// are merged deterministically by Resolve, which can be used
+// as a frontend.Resolver.
+//
+// Package crdt реализует conflict-free replicated data types, которые
+// могут храниться в качестве значений записей. Различающиеся реплики таких
+// записей объединяются детерминированно с помощью Resolve, который может
+// быть использован как frontend.Resolver.
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"storage"
+)
+
+// Type is a type of CRDT value.
+type Type byte
+
+const (
+	TypeGSet Type = iota + 1
+	TypeORSet
+	TypeLWWRegister
+	TypePNCounter
+	TypeLWWMap
+)
+
+var (
+	ErrInvalidValue = errors.New("Invalid CRDT value")
+	ErrTypeMismatch = errors.New("CRDT types mismatch")
+)
+
+// Value is the common interface of all CRDT types.
+type Value interface {
+	Type() Type
+	// Merge merges other value of the same type into the value.
+	Merge(other Value) error
+	// init makes nil maps of the value empty, e.g. after decoding nulls.
+	init()
+}
+
+// Marshal encodes v to be stored as a record value.
+func Marshal(v Value) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(v.Type())}, payload...), nil
+}
+
+// Unmarshal decodes a record value encoded with Marshal.
+func Unmarshal(d []byte) (Value, error) {
+	if len(d) == 0 {
+		return nil, ErrInvalidValue
+	}
+
+	var v Value
+	switch Type(d[0]) {
+	case TypeGSet:
+		v = NewGSet()
+	case TypeORSet:
+		v = NewORSet()
+	case TypeLWWRegister:
+		v = &LWWRegister{}
+	case TypePNCounter:
+		v = NewPNCounter()
+	case TypeLWWMap:
+		v = NewLWWMap()
+	default:
+		return nil, ErrInvalidValue
+	}
+	if err := json.Unmarshal(d[1:], v); err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidValue, err)
+	}
+	// Maps encoded as null are decoded as nil ones, which can't be written.
+	v.init()
+	return v, nil
+}
+
+// Merge merges encoded values a and b and returns the encoded result.
+func Merge(a, b []byte) ([]byte, error) {
+	va, err := Unmarshal(a)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := va.Merge(vb); err != nil {
+		return nil, err
+	}
+	return Marshal(va)
+}
+
+// Resolve merges all divergent values of a record into one.
+// It has the signature of frontend.Resolver.
+func Resolve(k storage.RecordID, values [][]byte) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, ErrInvalidValue
+	}
+	merged := values[0]
+	for _, v := range values[1:] {
+		var err error
+		if merged, err = Merge(merged, v); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
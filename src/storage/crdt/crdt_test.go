@@ -0,0 +1,152 @@
+package crdt
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func marshal(t *testing.T, v Value) []byte {
+	d, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	return d
+}
+
+func resolve(t *testing.T, values ...Value) Value {
+	var encoded [][]byte
+	for _, v := range values {
+		encoded = append(encoded, marshal(t, v))
+	}
+	d, err := Resolve(1, encoded)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	v, err := Unmarshal(d)
+	if err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	return v
+}
+
+func TestGSet(t *testing.T) {
+	a, b := NewGSet(), NewGSet()
+	a.Add("x")
+	b.Add("y")
+	got := resolve(t, a, b).(*GSet)
+	if !got.Contains("x") || !got.Contains("y") {
+		t.Errorf("Wrong merged set: %v", got.Elems)
+	}
+}
+
+func TestORSet(t *testing.T) {
+	a := NewORSet()
+	a.Add("x")
+	a.Add("y")
+
+	b := resolve(t, a).(*ORSet)
+	b.Remove("x")
+	a.Add("x")
+
+	got := resolve(t, a, b).(*ORSet)
+	elems := got.Elems()
+	sort.Strings(elems)
+	if want := []string{"x", "y"}; !reflect.DeepEqual(elems, want) {
+		t.Errorf("Wrong merged set: got %v, want %v", elems, want)
+	}
+
+	got.Remove("x")
+	if got.Contains("x") {
+		t.Errorf("Removed element is still present")
+	}
+}
+
+func TestLWWRegister(t *testing.T) {
+	a := &LWWRegister{}
+	a.Set([]byte("a"), 1, "w1")
+	b := &LWWRegister{}
+	b.Set([]byte("b"), 2, "w1")
+	c := &LWWRegister{}
+	c.Set([]byte("c"), 2, "w2")
+
+	got := resolve(t, a, b, c).(*LWWRegister)
+	if string(got.Value) != "c" {
+		t.Errorf("Wrong merged register: got %s, want %s", got.Value, "c")
+	}
+}
+
+func TestPNCounter(t *testing.T) {
+	a, b := NewPNCounter(), NewPNCounter()
+	a.Add("w1", 5)
+	b.Add("w1", 3)
+	b.Add("w2", -2)
+
+	got := resolve(t, a, b).(*PNCounter)
+	if got.Value() != 3 {
+		t.Errorf("Wrong merged counter: got %d, want %d", got.Value(), 3)
+	}
+}
+
+func TestLWWMap(t *testing.T) {
+	a, b := NewLWWMap(), NewLWWMap()
+	a.Set("x", []byte("a"), 1, "w1")
+	a.Set("y", []byte("a"), 1, "w1")
+	b.Set("x", []byte("b"), 2, "w2")
+	b.Set("y", nil, 2, "w2")
+	b.Set("z", []byte("b"), 1, "w2")
+
+	got := resolve(t, a, b).(*LWWMap)
+	keys := got.Keys()
+	sort.Strings(keys)
+	if want := []string{"x", "z"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Wrong merged keys: got %v, want %v", keys, want)
+	}
+	if v, _ := got.Get("x"); string(v) != "b" {
+		t.Errorf("Wrong merged entry: got %s, want %s", v, "b")
+	}
+	if _, ok := got.Get("y"); ok {
+		t.Errorf("Deleted entry is still present")
+	}
+}
+
+func TestMerge_TypeMismatch(t *testing.T) {
+	if _, err := Merge(marshal(t, NewGSet()), marshal(t, NewPNCounter())); err != ErrTypeMismatch {
+		t.Errorf("Merge() got error %v, want %v", err, ErrTypeMismatch)
+	}
+	if _, err := Unmarshal([]byte("garbage")); err == nil {
+		t.Errorf("Unmarshal() expected error")
+	}
+}
+
+func TestUnmarshal_Null(t *testing.T) {
+	for _, d := range []string{
+		"\x01{\"elems\":null}",
+		"\x02{\"adds\":null,\"removes\":null}",
+		"\x04{\"p\":null,\"n\":null}",
+		"\x04{}",
+		"\x05{\"entries\":null}",
+		"\x05{\"entries\":{\"x\":null}}",
+	} {
+		v, err := Unmarshal([]byte(d))
+		if err != nil {
+			t.Fatalf("Unmarshal(%q) error: %v", d, err)
+		}
+		// Merging into the value writes to its maps.
+		if _, err := Merge([]byte(d), marshal(t, v)); err != nil {
+			t.Errorf("Merge(%q) error: %v", d, err)
+		}
+		switch v := v.(type) {
+		case *GSet:
+			v.Add("x")
+		case *ORSet:
+			v.Add("x")
+			v.Remove("x")
+		case *PNCounter:
+			v.Add("w1", 1)
+			v.Add("w1", -1)
+		case *LWWMap:
+			v.Set("x", []byte("x"), 1, "w1")
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package crdt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GSet is a grow-only set.
+type GSet struct {
+	Elems map[string]bool `json:"elems"`
+}
+
+func NewGSet() *GSet {
+	return &GSet{Elems: make(map[string]bool)}
+}
+
+func (s *GSet) Type() Type { return TypeGSet }
+
+func (s *GSet) init() {
+	if s.Elems == nil {
+		s.Elems = make(map[string]bool)
+	}
+}
+
+func (s *GSet) Add(elem string) {
+	s.Elems[elem] = true
+}
+
+func (s *GSet) Contains(elem string) bool {
+	return s.Elems[elem]
+}
+
+func (s *GSet) Merge(other Value) error {
+	o, ok := other.(*GSet)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	for elem := range o.Elems {
+		s.Elems[elem] = true
+	}
+	return nil
+}
+
+// ORSet is an observed-remove set: an element is present if
+// it has an add tag which was not removed.
+type ORSet struct {
+	Adds    map[string]map[string]bool `json:"adds"`
+	Removes map[string]bool            `json:"removes"`
+}
+
+func NewORSet() *ORSet {
+	return &ORSet{
+		Adds:    make(map[string]map[string]bool),
+		Removes: make(map[string]bool),
+	}
+}
+
+func (s *ORSet) Type() Type { return TypeORSet }
+
+func (s *ORSet) init() {
+	if s.Adds == nil {
+		s.Adds = make(map[string]map[string]bool)
+	}
+	if s.Removes == nil {
+		s.Removes = make(map[string]bool)
+	}
+}
+
+func newTag() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (s *ORSet) Add(elem string) {
+	if s.Adds[elem] == nil {
+		s.Adds[elem] = make(map[string]bool)
+	}
+	s.Adds[elem][newTag()] = true
+}
+
+// Remove removes all observed add tags of elem.
+func (s *ORSet) Remove(elem string) {
+	for tag := range s.Adds[elem] {
+		s.Removes[tag] = true
+	}
+}
+
+func (s *ORSet) Contains(elem string) bool {
+	for tag := range s.Adds[elem] {
+		if !s.Removes[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ORSet) Elems() []string {
+	var elems []string
+	for elem := range s.Adds {
+		if s.Contains(elem) {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+func (s *ORSet) Merge(other Value) error {
+	o, ok := other.(*ORSet)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	for elem, tags := range o.Adds {
+		if s.Adds[elem] == nil {
+			s.Adds[elem] = make(map[string]bool)
+		}
+		for tag := range tags {
+			s.Adds[elem][tag] = true
+		}
+	}
+	for tag := range o.Removes {
+		s.Removes[tag] = true
+	}
+	return nil
+}
+
+// LWWRegister is a last-writer-wins register. Ties of timestamps
+// are broken by comparing writer names.
+type LWWRegister struct {
+	Value     []byte `json:"value"`
+	Timestamp int64  `json:"ts"`
+	Writer    string `json:"writer"`
+}
+
+func (r *LWWRegister) Type() Type { return TypeLWWRegister }
+
+func (r *LWWRegister) init() {}
+
+func (r *LWWRegister) Set(v []byte, ts int64, writer string) {
+	if r.newer(ts, writer) {
+		r.Value, r.Timestamp, r.Writer = v, ts, writer
+	}
+}
+
+func (r *LWWRegister) newer(ts int64, writer string) bool {
+	return ts > r.Timestamp || ts == r.Timestamp && writer > r.Writer
+}
+
+func (r *LWWRegister) Merge(other Value) error {
+	o, ok := other.(*LWWRegister)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	r.Set(o.Value, o.Timestamp, o.Writer)
+	return nil
+}
+
+// PNCounter is a counter supporting increments and decrements
+// made by different writers.
+type PNCounter struct {
+	P map[string]uint64 `json:"p"`
+	N map[string]uint64 `json:"n"`
+}
+
+func NewPNCounter() *PNCounter {
+	return &PNCounter{
+		P: make(map[string]uint64),
+		N: make(map[string]uint64),
+	}
+}
+
+func (c *PNCounter) Type() Type { return TypePNCounter }
+
+func (c *PNCounter) init() {
+	if c.P == nil {
+		c.P = make(map[string]uint64)
+	}
+	if c.N == nil {
+		c.N = make(map[string]uint64)
+	}
+}
+
+func (c *PNCounter) Add(writer string, delta int64) {
+	if delta >= 0 {
+		c.P[writer] += uint64(delta)
+	} else {
+		c.N[writer] += uint64(-delta)
+	}
+}
+
+func (c *PNCounter) Value() int64 {
+	var v int64
+	for _, p := range c.P {
+		v += int64(p)
+	}
+	for _, n := range c.N {
+		v -= int64(n)
+	}
+	return v
+}
+
+func (c *PNCounter) Merge(other Value) error {
+	o, ok := other.(*PNCounter)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	for w, p := range o.P {
+		if p > c.P[w] {
+			c.P[w] = p
+		}
+	}
+	for w, n := range o.N {
+		if n > c.N[w] {
+			c.N[w] = n
+		}
+	}
+	return nil
+}
+
+// LWWMap is a map of last-writer-wins registers. An entry is deleted
+// by setting its register to a nil value, so that the deletion wins over
+// older writes.
+type LWWMap struct {
+	Entries map[string]*LWWRegister `json:"entries"`
+}
+
+func NewLWWMap() *LWWMap {
+	return &LWWMap{Entries: make(map[string]*LWWRegister)}
+}
+
+func (m *LWWMap) Type() Type { return TypeLWWMap }
+
+func (m *LWWMap) init() {
+	if m.Entries == nil {
+		m.Entries = make(map[string]*LWWRegister)
+	}
+	for key, r := range m.Entries {
+		if r == nil {
+			m.Entries[key] = &LWWRegister{}
+		}
+	}
+}
+
+// Set sets the entry key to v, deleting it if v is nil.
+func (m *LWWMap) Set(key string, v []byte, ts int64, writer string) {
+	r := m.Entries[key]
+	if r == nil {
+		r = &LWWRegister{}
+		m.Entries[key] = r
+	}
+	r.Set(v, ts, writer)
+}
+
+func (m *LWWMap) Get(key string) ([]byte, bool) {
+	r := m.Entries[key]
+	if r == nil || r.Value == nil {
+		return nil, false
+	}
+	return r.Value, true
+}
+
+func (m *LWWMap) Keys() []string {
+	var keys []string
+	for key, r := range m.Entries {
+		if r.Value != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m *LWWMap) Merge(other Value) error {
+	o, ok := other.(*LWWMap)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	for key, r := range o.Entries {
+		m.Set(key, r.Value, r.Timestamp, r.Writer)
+	}
+	return nil
+}
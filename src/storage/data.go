@@ -1,8 +1,18 @@
 package storage
 
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+)
+
 const (
 	ReplicationFactor = 3
 	MinRedundancy     = 2
+
+	// ScanLimit is the maximum number of records returned by one Scan call.
+	ScanLimit = 1000
 )
 
 type ServiceAddr string
@@ -15,3 +25,212 @@ func (RecordID) BinSize() int {
 func (addr ServiceAddr) BinSize() int {
 	return len(addr)
 }
+
+// LockToken identifies a lease on a record. Holder is a unique id of the
+// lease holder and Fence is a fencing token increasing with every lease
+// acquired on the record.
+type LockToken struct {
+	Holder uint64
+	Fence  uint64
+}
+
+// SequenceKey returns the key of the record whose replicas store
+// the sequence with the given name.
+func SequenceKey(name string) RecordID {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return RecordID(h.Sum32())
+}
+
+// Checksum is a checksum of a value computed by a Hash.
+type Checksum [sha256.Size]byte
+
+// Stats describes records stored by a service. Requests is the number of
+// record operations the service received since it started, so their rate
+// can be told from two samples. Namespaces break Records and Bytes down by
+// the namespaces the service is configured with, records of no namespace
+// are not listed.
+type Stats struct {
+	Records    uint64
+	Bytes      uint64
+	Requests   uint64
+	Namespaces []NamespaceStats `json:",omitempty"`
+}
+
+// NamespaceStats describes records of the namespace with Prefix, see
+// Namespaces.
+type NamespaceStats struct {
+	Prefix  Prefix
+	Records uint64
+	Bytes   uint64
+}
+
+// Add adds o to s, summing stats of namespaces with the same prefixes.
+func (s *Stats) Add(o Stats) {
+	s.Records += o.Records
+	s.Bytes += o.Bytes
+	s.Requests += o.Requests
+	for _, n := range o.Namespaces {
+		i := slices.IndexFunc(s.Namespaces, func(m NamespaceStats) bool { return m.Prefix == n.Prefix })
+		if i < 0 {
+			s.Namespaces = append(s.Namespaces, NamespaceStats{Prefix: n.Prefix})
+			i = len(s.Namespaces) - 1
+		}
+		s.Namespaces[i].Records += n.Records
+		s.Namespaces[i].Bytes += n.Bytes
+	}
+}
+
+// Heartbeat is a heartbeat of a single node sent to a router.
+// Degraded is set if the node reports itself unhealthy. Seq is the sequence
+// number of the last operation applied by the node, so others can tell
+// whether they are behind it.
+type Heartbeat struct {
+	Node     ServiceAddr
+	Degraded bool
+	Seq      uint64
+}
+
+// Op is a single write of a batch: Put of Data with Key or Del of Key
+// if Del is set. Version is the version of the write if it is not 0,
+// see WithVersion.
+type Op struct {
+	Key     RecordID
+	Data    []byte
+	Del     bool
+	Version uint64
+}
+
+// Delta is the latest state of records changed on a node after some
+// sequence number, records deleted since are returned with Deleted set.
+// Seq is the sequence number the changes are up to, and More is set if
+// there are changes after it left.
+type Delta struct {
+	Records []Record
+	Seq     uint64
+	More    bool
+}
+
+// SnapshotPhase is a step of taking a cluster-wide snapshot. Writes are
+// blocked by SnapshotFreeze until SnapshotTake or SnapshotAbort with the
+// same snapshot id, or until the freeze ttl expires. SnapshotDrop removes
+// a taken snapshot.
+type SnapshotPhase int32
+
+const (
+	SnapshotFreeze SnapshotPhase = iota
+	SnapshotTake
+	SnapshotAbort
+	SnapshotDrop
+)
+
+// PaxosPhase is a step of a single-key Paxos round run by a frontend on
+// the replicas of a key to make a lightweight transaction. A replica
+// promises in PaxosPrepare to accept no proposals with lower ballots,
+// accepts a proposal in PaxosAccept unless it promised a higher ballot,
+// and applies it in PaxosCommit.
+type PaxosPhase int32
+
+const (
+	PaxosPrepare PaxosPhase = iota
+	PaxosAccept
+	PaxosCommit
+)
+
+// Proposal is a value proposed for a record in a Paxos round with
+// the given Ballot. The record is deleted if Deleted is set.
+type Proposal struct {
+	Ballot  uint64
+	Data    []byte
+	Deleted bool
+}
+
+// Promise is a reply of a replica to a Paxos phase. Promised is the highest
+// ballot the replica promised, Accepted is the last proposal it accepted
+// but not yet committed with zero Ballot if none, Committed is the ballot
+// of the last committed proposal, and Value and Found are the current
+// value of the record.
+type Promise struct {
+	Promised  uint64
+	Accepted  Proposal
+	Committed uint64
+	Value     []byte
+	Found     bool
+}
+
+// ReadLease is a value of a record returned by a replica granting a read
+// lease on it. Found is unset if there is no record.
+type ReadLease struct {
+	Value []byte
+	Found bool
+}
+
+// Record is a record returned by scans. Deleted is set for records
+// deleted since a snapshot when changes are scanned.
+type Record struct {
+	Key     RecordID
+	Data    []byte
+	Deleted bool
+}
+
+// Cursor is an opaque position in the keyspace ordered by RecordID.
+// An empty Cursor points to the beginning of the keyspace.
+type Cursor []byte
+
+// CursorAfter returns a Cursor pointing to the records with keys greater than k.
+func CursorAfter(k RecordID) Cursor {
+	c := make(Cursor, k.BinSize())
+	binary.LittleEndian.PutUint32(c, uint32(k))
+	return c
+}
+
+// After returns the key after which the records should be scanned.
+// ok is false if the Cursor points to the beginning of the keyspace.
+func (c Cursor) After() (k RecordID, ok bool, err error) {
+	if len(c) == 0 {
+		return 0, false, nil
+	}
+	if len(c) != k.BinSize() {
+		return 0, false, ErrInvalidCursor
+	}
+	return RecordID(binary.LittleEndian.Uint32(c)), true, nil
+}
+
+// ScanLimitOf returns the page size to use for the requested limit.
+func ScanLimitOf(limit int) int {
+	if limit <= 0 || limit > ScanLimit {
+		return ScanLimit
+	}
+	return limit
+}
+
+// Prefix matches keys having the same Len most significant bits as Key.
+type Prefix struct {
+	Key RecordID
+	Len uint
+}
+
+func (p Prefix) mask() RecordID {
+	if p.Len == 0 {
+		return 0
+	}
+	if p.Len >= 32 {
+		return ^RecordID(0)
+	}
+	return ^RecordID(0) << (32 - p.Len)
+}
+
+// Match reports whether k has the prefix.
+func (p Prefix) Match(k RecordID) bool {
+	return k&p.mask() == p.Key&p.mask()
+}
+
+// First returns the smallest key having the prefix.
+func (p Prefix) First() RecordID {
+	return p.Key & p.mask()
+}
+
+// Last returns the largest key having the prefix.
+func (p Prefix) Last() RecordID {
+	return p.Key | ^p.mask()
+}
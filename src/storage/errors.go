@@ -1,30 +1,80 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 var (
-	ErrQuorumNotReached = errors.New("Quorum not reached")
-	ErrNotEnoughDaemons = errors.New("Not Enough Daemons Available")
-	ErrUnknownDaemon    = errors.New("Unknown Daemon")
-	ErrRecordNotFound   = errors.New("Record Not Found")
-	ErrRecordExists     = errors.New("Already have record")
+	ErrQuorumNotReached  = errors.New("Quorum not reached")
+	ErrNotEnoughDaemons  = errors.New("Not Enough Daemons Available")
+	ErrUnknownDaemon     = errors.New("Unknown Daemon")
+	ErrRecordNotFound    = errors.New("Record Not Found")
+	ErrRecordExists      = errors.New("Already have record")
+	ErrPossiblyStale     = errors.New("Quorum not reached, data is possibly stale")
+	ErrInvalidCursor     = errors.New("Invalid cursor")
+	ErrLocked            = errors.New("Record is locked")
+	ErrNotLockHolder     = errors.New("Lock is not held")
+	ErrVersionNotFound   = errors.New("Version Not Found")
+	ErrSnapshotNotFound  = errors.New("Snapshot Not Found")
+	ErrSnapshotActive    = errors.New("Another snapshot is in progress")
+	ErrInvalidKey        = errors.New("Invalid key")
+	ErrValueTooLarge     = errors.New("Value is too large")
+	ErrInvalidValue      = errors.New("Invalid value")
+	ErrQuotaExceeded     = errors.New("Quota exceeded")
+	ErrRouterUnavailable = errors.New("Router is unavailable")
+	ErrRateLimited       = errors.New("Rate limit exceeded")
+	ErrSeqOutOfRange     = errors.New("Sequence number is out of range")
+	ErrChecksumMismatch  = errors.New("Checksum mismatch")
+	ErrBallotRejected    = errors.New("Ballot is rejected")
+	ErrConditionFailed   = errors.New("Condition failed")
+	ErrBadSignature      = errors.New("Bad signature")
+	ErrFenceRejected     = errors.New("Fence is rejected")
 
 	ErrUnknownStatus = errors.New("Error Unknown")
 )
 
+// StatusCode is a numeric code of an error transmitted over the wire.
+// Codes are part of the protocol, so their values never change: new codes
+// are appended after the last one with explicit values, and peers decode
+// codes they don't know as StatusUnknown.
 type StatusCode int32
 
 const (
-	StatusOk StatusCode = iota
-	StatusQuorumNotReached
-	StatusNotEnoughDaemons
-	StatusUnknownDaemon
-	StatusRecordNotFound
-	StatusRecordExists
-
-	StatusUnknown
+	StatusOk                StatusCode = 0
+	StatusQuorumNotReached  StatusCode = 1
+	StatusNotEnoughDaemons  StatusCode = 2
+	StatusUnknownDaemon     StatusCode = 3
+	StatusRecordNotFound    StatusCode = 4
+	StatusRecordExists      StatusCode = 5
+	StatusUnknown           StatusCode = 6
+	StatusPossiblyStale     StatusCode = 7
+	StatusInvalidCursor     StatusCode = 8
+	StatusLocked            StatusCode = 9
+	StatusNotLockHolder     StatusCode = 10
+	StatusVersionNotFound   StatusCode = 11
+	StatusSnapshotNotFound  StatusCode = 12
+	StatusSnapshotActive    StatusCode = 13
+	StatusInvalidKey        StatusCode = 14
+	StatusValueTooLarge     StatusCode = 15
+	StatusInvalidValue      StatusCode = 16
+	StatusQuotaExceeded     StatusCode = 17
+	StatusRouterUnavailable StatusCode = 18
+	StatusRateLimited       StatusCode = 19
+	StatusSeqOutOfRange     StatusCode = 20
+	StatusChecksumMismatch  StatusCode = 21
+	StatusBallotRejected    StatusCode = 22
+	StatusConditionFailed   StatusCode = 23
+	StatusBadSignature      StatusCode = 24
+	StatusDeadlineExceeded  StatusCode = 25
+	StatusFenceRejected     StatusCode = 26
+
+	// statusLast is the last known code, codes above it come from newer
+	// peers.
+	statusLast = StatusFenceRejected
 )
 
 func (s StatusCode) ToError() error {
@@ -41,6 +91,46 @@ func (s StatusCode) ToError() error {
 		return ErrRecordNotFound
 	case StatusRecordExists:
 		return ErrRecordExists
+	case StatusPossiblyStale:
+		return ErrPossiblyStale
+	case StatusInvalidCursor:
+		return ErrInvalidCursor
+	case StatusLocked:
+		return ErrLocked
+	case StatusNotLockHolder:
+		return ErrNotLockHolder
+	case StatusVersionNotFound:
+		return ErrVersionNotFound
+	case StatusSnapshotNotFound:
+		return ErrSnapshotNotFound
+	case StatusSnapshotActive:
+		return ErrSnapshotActive
+	case StatusInvalidKey:
+		return ErrInvalidKey
+	case StatusValueTooLarge:
+		return ErrValueTooLarge
+	case StatusInvalidValue:
+		return ErrInvalidValue
+	case StatusQuotaExceeded:
+		return ErrQuotaExceeded
+	case StatusRouterUnavailable:
+		return ErrRouterUnavailable
+	case StatusRateLimited:
+		return ErrRateLimited
+	case StatusSeqOutOfRange:
+		return ErrSeqOutOfRange
+	case StatusChecksumMismatch:
+		return ErrChecksumMismatch
+	case StatusBallotRejected:
+		return ErrBallotRejected
+	case StatusConditionFailed:
+		return ErrConditionFailed
+	case StatusBadSignature:
+		return ErrBadSignature
+	case StatusDeadlineExceeded:
+		return context.DeadlineExceeded
+	case StatusFenceRejected:
+		return ErrFenceRejected
 	default:
 		return ErrUnknownStatus
 	}
@@ -51,18 +141,139 @@ func ErrToStatus(err error) StatusCode {
 		return StatusOk
 	}
 
-	switch err {
-	case ErrQuorumNotReached:
+	var e Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	switch {
+	case errors.Is(err, ErrQuorumNotReached):
 		return StatusQuorumNotReached
-	case ErrNotEnoughDaemons:
+	case errors.Is(err, ErrNotEnoughDaemons):
 		return StatusNotEnoughDaemons
-	case ErrUnknownDaemon:
+	case errors.Is(err, ErrUnknownDaemon):
 		return StatusUnknownDaemon
-	case ErrRecordNotFound:
+	case errors.Is(err, ErrRecordNotFound):
 		return StatusRecordNotFound
-	case ErrRecordExists:
+	case errors.Is(err, ErrRecordExists):
 		return StatusRecordExists
+	case errors.Is(err, ErrPossiblyStale):
+		return StatusPossiblyStale
+	case errors.Is(err, ErrInvalidCursor):
+		return StatusInvalidCursor
+	case errors.Is(err, ErrLocked):
+		return StatusLocked
+	case errors.Is(err, ErrNotLockHolder):
+		return StatusNotLockHolder
+	case errors.Is(err, ErrVersionNotFound):
+		return StatusVersionNotFound
+	case errors.Is(err, ErrSnapshotNotFound):
+		return StatusSnapshotNotFound
+	case errors.Is(err, ErrSnapshotActive):
+		return StatusSnapshotActive
+	case errors.Is(err, ErrInvalidKey):
+		return StatusInvalidKey
+	case errors.Is(err, ErrValueTooLarge):
+		return StatusValueTooLarge
+	case errors.Is(err, ErrInvalidValue):
+		return StatusInvalidValue
+	case errors.Is(err, ErrQuotaExceeded):
+		return StatusQuotaExceeded
+	case errors.Is(err, ErrRouterUnavailable):
+		return StatusRouterUnavailable
+	case errors.Is(err, ErrRateLimited):
+		return StatusRateLimited
+	case errors.Is(err, ErrSeqOutOfRange):
+		return StatusSeqOutOfRange
+	case errors.Is(err, ErrChecksumMismatch):
+		return StatusChecksumMismatch
+	case errors.Is(err, ErrBallotRejected):
+		return StatusBallotRejected
+	case errors.Is(err, ErrConditionFailed):
+		return StatusConditionFailed
+	case errors.Is(err, ErrBadSignature):
+		return StatusBadSignature
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusDeadlineExceeded
+	case errors.Is(err, ErrFenceRejected):
+		return StatusFenceRejected
 	default:
 		return StatusUnknown
 	}
 }
+
+// Error is an error received over the wire which is not a bare sentinel
+// error, e.g. a wrapped one. It keeps the original message and matches
+// the sentinel error of its code with errors.Is.
+type Error struct {
+	Code    StatusCode
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+func (e Error) Is(target error) bool {
+	return e.Code != StatusUnknown && e.Code.ToError() == target
+}
+
+// RetryAfterError is an error of an overloaded service, e.g. ErrRateLimited,
+// hinting how long clients should wait before retrying, so the service
+// recovers instead of being hammered by immediate retries. The hint is
+// transmitted over the wire as a part of the message.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+// retryAfterSep separates the hint of RetryAfterError in its message.
+const retryAfterSep = ", retry after "
+
+func (e RetryAfterError) Error() string {
+	return fmt.Sprintf("%v%v%v", e.Err, retryAfterSep, e.After)
+}
+
+func (e RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the hint of a RetryAfterError in the chain of err.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e RetryAfterError
+	if errors.As(err, &e) {
+		return e.After, true
+	}
+	return 0, false
+}
+
+// MarshalError converts err to a status code and a message to transmit.
+// The message is empty if err is exactly the sentinel error of the code.
+func MarshalError(err error) (StatusCode, string) {
+	status := ErrToStatus(err)
+	if status == StatusOk || err == status.ToError() {
+		return status, ""
+	}
+	return status, err.Error()
+}
+
+// UnmarshalError restores an error marshaled by MarshalError. Sentinel
+// errors are restored as is, so they can still be compared with ==,
+// hints of RetryAfterError are restored too.
+func UnmarshalError(status StatusCode, msg string) error {
+	if status == StatusOk {
+		return nil
+	}
+	if i := strings.LastIndex(msg, retryAfterSep); i >= 0 {
+		if d, err := time.ParseDuration(msg[i+len(retryAfterSep):]); err == nil && d > 0 {
+			return RetryAfterError{Err: UnmarshalError(status, msg[:i]), After: d}
+		}
+	}
+	if err := status.ToError(); err != ErrUnknownStatus && (msg == "" || msg == err.Error()) {
+		return err
+	}
+	if status < StatusOk || status > statusLast {
+		status = StatusUnknown
+	}
+	return Error{Code: status, Message: msg}
+}
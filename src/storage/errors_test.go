@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMarshalError(t *testing.T) {
+	wrapped := fmt.Errorf("node1: %w", ErrRecordNotFound)
+	for _, err := range []error{
+		nil,
+		ErrRecordNotFound,
+		ErrSnapshotActive,
+		context.DeadlineExceeded,
+		wrapped,
+		fmt.Errorf("frontend: %w", Error{Code: StatusLocked, Message: "node2: Record is locked"}),
+		errors.New("disk is full"),
+	} {
+		status, msg := MarshalError(err)
+		got := UnmarshalError(status, msg)
+
+		if ErrToStatus(err).ToError() == err {
+			if got != err {
+				t.Errorf("UnmarshalError(MarshalError(%v)) got %v, want the same error", err, got)
+			}
+			continue
+		}
+		if got.Error() != err.Error() {
+			t.Errorf("UnmarshalError(MarshalError(%v)) got message %q, want %q", err, got.Error(), err.Error())
+		}
+		if sentinel := ErrToStatus(err).ToError(); sentinel != ErrUnknownStatus && !errors.Is(got, sentinel) {
+			t.Errorf("UnmarshalError(MarshalError(%v)) got %v, which is not %v", err, got, sentinel)
+		}
+	}
+
+	status, msg := MarshalError(RetryAfterError{Err: ErrRateLimited, After: 150 * time.Millisecond})
+	got := UnmarshalError(status, msg)
+	if d, ok := RetryAfter(got); !ok || d != 150*time.Millisecond || !errors.Is(got, ErrRateLimited) {
+		t.Errorf("UnmarshalError(MarshalError()) got %v, want %v with hint 150ms", got, ErrRateLimited)
+	}
+
+	if got := UnmarshalError(StatusUnknown, "disk is full"); errors.Is(got, ErrUnknownStatus) {
+		t.Errorf("UnmarshalError() got %v matching %v", got, ErrUnknownStatus)
+	}
+	if got := UnmarshalError(statusLast+10, "from a newer peer"); ErrToStatus(got) != StatusUnknown {
+		t.Errorf("UnmarshalError() of an unknown code got status %v, want %v", ErrToStatus(got), StatusUnknown)
+	}
+}
+
+func FuzzUnmarshalError(f *testing.F) {
+	f.Add(int32(StatusOk), "")
+	f.Add(int32(StatusRecordNotFound), "")
+	f.Add(int32(StatusRecordNotFound), "node1: Record not found")
+	f.Add(int32(StatusRateLimited), "Rate limited, retry after 150ms")
+	f.Add(int32(StatusRateLimited), ", retry after -1s, retry after 1000ms")
+	f.Add(int32(statusLast+10), "from a newer peer")
+
+	f.Fuzz(func(t *testing.T, code int32, msg string) {
+		err := UnmarshalError(StatusCode(code), msg)
+		if (err == nil) != (StatusCode(code) == StatusOk) {
+			t.Fatalf("UnmarshalError(%v, %q) got %v", code, msg, err)
+		}
+		if err == nil {
+			return
+		}
+		got := UnmarshalError(MarshalError(err))
+		if got.Error() != err.Error() || ErrToStatus(got) != ErrToStatus(err) {
+			t.Fatalf("UnmarshalError(MarshalError(%v)) got %v", err, got)
+		}
+		if d, ok := RetryAfter(err); ok && d <= 0 {
+			t.Fatalf("UnmarshalError(%v, %q) got hint %v", code, msg, d)
+		}
+	})
+}
+
+func TestStatusCode(t *testing.T) {
+	// Peers predating the codes after it send 6 for unknown errors.
+	if got := UnmarshalError(6, "disk is full"); errors.Is(got, ErrPossiblyStale) || ErrToStatus(got) != StatusUnknown {
+		t.Errorf("UnmarshalError(6) got %v of status %v, want %v", got, ErrToStatus(got), StatusUnknown)
+	}
+	for code := StatusOk; code <= statusLast; code++ {
+		if code == StatusUnknown {
+			continue
+		}
+		if got := ErrToStatus(code.ToError()); got != code {
+			t.Errorf("ErrToStatus(%v.ToError()) got %v", code, got)
+		}
+	}
+}
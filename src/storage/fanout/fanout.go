@@ -0,0 +1,115 @@
+// Package fanout runs calls to many nodes in parallel, bounding the number
+// of calls in flight and stopping once the context is done. It is shared by
+// quorum requests, batches and repairs, so they don't each manage their own
+// goroutines, channels and semaphores.
+package fanout
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// Each calls f for every item in parallel, running at most limit calls at
+// once if limit is positive. The context passed to f is canceled once ctx
+// is done or a call fails, and calls not started by then are skipped.
+// Waits for the started calls and returns the first error, or ctx.Err() if
+// some calls were skipped because ctx is done.
+func Each[T any](ctx context.Context, limit int, items []T, f func(ctx context.Context, item T) error) error {
+	gctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var g errgroup.Group
+	lim := newLimiter(limit)
+	started := 0
+	for _, item := range items {
+		if err := lim.acquire(gctx); err != nil {
+			break
+		}
+		started++
+		g.Go(func() error {
+			err := f(gctx, item)
+			if err != nil {
+				// Canceled before the slot is released, so no more
+				// calls are started after a failure.
+				cancel()
+			}
+			lim.release()
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if started < len(items) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Collect calls f for every item in parallel like Each, but doesn't stop at
+// failures: results of the calls are sent to the returned channel as they
+// complete. The channel has room for all of them, so callers may stop
+// reading once they've got enough, e.g. a quorum, without leaking
+// goroutines. Calls not started by the time ctx is done are skipped, so
+// callers waiting for all results should stop at ctx.Done() too.
+func Collect[T, R any](ctx context.Context, limit int, items []T, f func(i int, item T) R) <-chan R {
+	results := make(chan R, len(items))
+	if limit <= 0 || limit >= len(items) {
+		for i, item := range items {
+			go func() {
+				results <- f(i, item)
+			}()
+		}
+		return results
+	}
+	lim := newLimiter(limit)
+	go func() {
+		for i, item := range items {
+			if lim.acquire(ctx) != nil {
+				return
+			}
+			go func() {
+				defer lim.release()
+				results <- f(i, item)
+			}()
+		}
+	}()
+	return results
+}
+
+// limiter bounds the number of calls in flight, or doesn't if it is zero.
+type limiter struct {
+	sem *semaphore.Weighted
+}
+
+func newLimiter(limit int) limiter {
+	if limit <= 0 {
+		return limiter{}
+	}
+	return limiter{sem: semaphore.NewWeighted(int64(limit))}
+}
+
+// acquire waits for a free slot until ctx is done.
+func (l limiter) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.sem == nil {
+		return nil
+	}
+	if err := l.sem.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		l.sem.Release(1)
+		return err
+	}
+	return nil
+}
+
+func (l limiter) release() {
+	if l.sem != nil {
+		l.sem.Release(1)
+	}
+}
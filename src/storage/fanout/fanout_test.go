@@ -0,0 +1,100 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEach(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	var running, peak, sum int32
+	err := Each(context.Background(), 3, items, func(ctx context.Context, item int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&sum, int32(item))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() error: %v", err)
+	}
+	if sum != 36 {
+		t.Errorf("Each() called f for items summing to %d, want 36", sum)
+	}
+	if peak > 3 {
+		t.Errorf("Each() ran %d calls at once, want at most 3", peak)
+	}
+
+	failure := errors.New("unavailable")
+	var calls int32
+	err = Each(context.Background(), 1, items, func(ctx context.Context, item int) error {
+		atomic.AddInt32(&calls, 1)
+		if item == 2 {
+			return failure
+		}
+		return nil
+	})
+	if err != failure {
+		t.Errorf("Each() got error %v, want %v", err, failure)
+	}
+	if calls != 2 {
+		t.Errorf("Each() made %d calls after a failure, want 2", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Each(ctx, 0, items, func(ctx context.Context, item int) error {
+		t.Errorf("Each() called f with a done context")
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Each() with a done context got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	items := []string{"a", "b", "c", "d"}
+	for _, limit := range []int{0, 2} {
+		results := Collect(context.Background(), limit, items, func(i int, item string) string {
+			return items[i] + item
+		})
+		got := make(map[string]bool)
+		for range items {
+			got[<-results] = true
+		}
+		for _, item := range items {
+			if !got[item+item] {
+				t.Errorf("Collect() with limit %d got %v, missing %q", limit, got, item+item)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, len(items))
+	release := make(chan struct{})
+	results := Collect(ctx, 1, items, func(i int, item string) string {
+		started <- struct{}{}
+		<-release
+		return item
+	})
+	<-started
+	cancel()
+	close(release)
+	if r := <-results; r != "a" {
+		t.Errorf("Collect() got %q, want %q", r, "a")
+	}
+	select {
+	case r := <-results:
+		t.Errorf("Collect() after cancel got %q, want no more results", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"hash/fnv"
+)
+
+// Hash is an algorithm computing checksums of values. HashSHA256 is the
+// default one and is cryptographic, so a value can't be forged to match
+// a checksum. HashCRC64 and HashFNV are several times faster but only
+// detect accidental corruption.
+type Hash string
+
+const (
+	HashSHA256 Hash = "sha256"
+	HashCRC64  Hash = "crc64"
+	HashFNV    Hash = "fnv"
+)
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Validate returns an error if h is unknown. An empty Hash is HashSHA256.
+func (h Hash) Validate() error {
+	switch h {
+	case "", HashSHA256, HashCRC64, HashFNV:
+		return nil
+	}
+	return fmt.Errorf("Unknown hash %q, should be one of %q, %q or %q", h, HashSHA256, HashCRC64, HashFNV)
+}
+
+// Size returns the number of significant bytes of checksums computed by h.
+func (h Hash) Size() int {
+	switch h {
+	case HashCRC64, HashFNV:
+		return 8
+	}
+	return sha256.Size
+}
+
+// Sum returns the checksum of d computed by h. Checksums shorter than
+// Checksum are padded with zeroes.
+func (h Hash) Sum(d []byte) Checksum {
+	var sum Checksum
+	switch h {
+	case HashCRC64:
+		binary.BigEndian.PutUint64(sum[:], crc64.Checksum(d, crcTable))
+	case HashFNV:
+		f := fnv.New64a()
+		f.Write(d)
+		f.Sum(sum[:0])
+	default:
+		sum = sha256.Sum256(d)
+	}
+	return sum
+}
+
+// Append returns d followed by its checksum computed by h.
+func (h Hash) Append(d []byte) []byte {
+	sum := h.Sum(d)
+	out := make([]byte, 0, len(d)+h.Size())
+	out = append(out, d...)
+	return append(out, sum[:h.Size()]...)
+}
+
+// Strip verifies the checksum computed by h d is followed by and returns
+// d without it. Returns an error wrapping ErrChecksumMismatch if the checksum
+// is missing or doesn't match.
+func (h Hash) Strip(d []byte) ([]byte, error) {
+	n := h.Size()
+	if len(d) < n {
+		return nil, fmt.Errorf("%w: %d bytes is too short for a checksum", ErrChecksumMismatch, len(d))
+	}
+	d, stored := d[:len(d)-n], d[len(d)-n:]
+	if sum := h.Sum(d); string(sum[:n]) != string(stored) {
+		return nil, fmt.Errorf("%w: stored %x, computed %x", ErrChecksumMismatch, stored, sum[:n])
+	}
+	return d, nil
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Interceptor makes a request to node with call, e.g. delaying or
+// rejecting it and inspecting the returned error.
+type Interceptor func(node ServiceAddr, call func() error) error
+
+// InterceptedClient is a Client passing every request to a node
+// through Intercept.
+type InterceptedClient struct {
+	Client
+	Intercept Interceptor
+}
+
+// WithContext returns a copy of c whose requests are canceled once ctx
+// is done if its Client supports it.
+func (c InterceptedClient) WithContext(ctx context.Context) Client {
+	c.Client = WithContext(c.Client, ctx)
+	return c
+}
+
+func (c InterceptedClient) Put(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Put(node, k, d)
+	})
+}
+
+func (c InterceptedClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
+	var r []byte
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Get(node, k)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Del(node ServiceAddr, k RecordID) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Del(node, k)
+	})
+}
+
+func (c InterceptedClient) Scan(node ServiceAddr, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	var records []Record
+	var next Cursor
+	err := c.Intercept(node, func() (err error) {
+		records, next, err = c.Client.Scan(node, cursor, limit)
+		return err
+	})
+	return records, next, err
+}
+
+func (c InterceptedClient) AcquireLease(node ServiceAddr, k RecordID, holder, fence uint64, ttl time.Duration) (uint64, error) {
+	var r uint64
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.AcquireLease(node, k, holder, fence, ttl)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) LeaseFence(node ServiceAddr, k RecordID) (uint64, error) {
+	var r uint64
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.LeaseFence(node, k)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) ReleaseLease(node ServiceAddr, k RecordID, holder uint64) error {
+	return c.Intercept(node, func() error {
+		return c.Client.ReleaseLease(node, k, holder)
+	})
+}
+
+func (c InterceptedClient) Sequence(node ServiceAddr, name string, floor uint64) (uint64, error) {
+	var r uint64
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Sequence(node, name, floor)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Stats(node ServiceAddr) (Stats, error) {
+	var r Stats
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Stats(node)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) GetVersion(node ServiceAddr, k RecordID, version uint64) ([]byte, error) {
+	var r []byte
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.GetVersion(node, k, version)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) ListVersions(node ServiceAddr, k RecordID) ([]uint64, error) {
+	var r []uint64
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.ListVersions(node, k)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Snapshot(node ServiceAddr, id uint64, phase SnapshotPhase, ttl time.Duration) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Snapshot(node, id, phase, ttl)
+	})
+}
+
+func (c InterceptedClient) ScanSnapshot(node ServiceAddr, id uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	var records []Record
+	var next Cursor
+	err := c.Intercept(node, func() (err error) {
+		records, next, err = c.Client.ScanSnapshot(node, id, cursor, limit)
+		return err
+	})
+	return records, next, err
+}
+
+func (c InterceptedClient) ScanChanges(node ServiceAddr, id, since uint64, cursor Cursor, limit int) ([]Record, Cursor, error) {
+	var records []Record
+	var next Cursor
+	err := c.Intercept(node, func() (err error) {
+		records, next, err = c.Client.ScanChanges(node, id, since, cursor, limit)
+		return err
+	})
+	return records, next, err
+}
+
+func (c InterceptedClient) Reserve(node ServiceAddr, k RecordID, size int64, ttl time.Duration) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Reserve(node, k, size, ttl)
+	})
+}
+
+func (c InterceptedClient) CancelReservation(node ServiceAddr, k RecordID) error {
+	return c.Intercept(node, func() error {
+		return c.Client.CancelReservation(node, k)
+	})
+}
+
+func (c InterceptedClient) MGet(node ServiceAddr, keys []RecordID) ([][]byte, []error, error) {
+	var data [][]byte
+	var errs []error
+	err := c.Intercept(node, func() (err error) {
+		data, errs, err = c.Client.MGet(node, keys)
+		return err
+	})
+	return data, errs, err
+}
+
+func (c InterceptedClient) MPut(node ServiceAddr, keys []RecordID, data [][]byte) ([]error, error) {
+	var r []error
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.MPut(node, keys, data)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) MDel(node ServiceAddr, keys []RecordID) ([]error, error) {
+	var r []error
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.MDel(node, keys)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Delta(node ServiceAddr, since uint64, limit int) (Delta, error) {
+	var r Delta
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Delta(node, since, limit)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Checksum(node ServiceAddr, k RecordID, h Hash) (Checksum, error) {
+	var r Checksum
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Checksum(node, k, h)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Paxos(node ServiceAddr, k RecordID, phase PaxosPhase, p Proposal) (Promise, error) {
+	var r Promise
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.Paxos(node, k, phase, p)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) AcquireReadLease(node ServiceAddr, k RecordID, holder uint64, ttl time.Duration) (ReadLease, error) {
+	var r ReadLease
+	err := c.Intercept(node, func() (err error) {
+		r, err = c.Client.AcquireReadLease(node, k, holder, ttl)
+		return err
+	})
+	return r, err
+}
+
+func (c InterceptedClient) Update(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Update(node, k, d)
+	})
+}
+
+func (c InterceptedClient) Upsert(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Upsert(node, k, d)
+	})
+}
+
+func (c InterceptedClient) Merge(node ServiceAddr, k RecordID, d []byte) error {
+	return c.Intercept(node, func() error {
+		return c.Client.Merge(node, k, d)
+	})
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"iter"
+)
+
+// ScanFunc returns a page of records like Client.Scan.
+type ScanFunc func(cursor Cursor, limit int) ([]Record, Cursor, error)
+
+// Iterator ranges over the keyspace scanned page by page. Deleted records
+// are skipped. Iteration stops at the first error or once the context is
+// done, Err returns the reason then.
+type Iterator struct {
+	ctx   context.Context
+	limit int
+	scan  ScanFunc
+	err   error
+}
+
+// NewIterator creates an Iterator scanning pages of limit records with scan
+// until ctx is done.
+func NewIterator(ctx context.Context, limit int, scan ScanFunc) *Iterator {
+	return &Iterator{ctx: ctx, limit: limit, scan: scan}
+}
+
+// ScanIterator creates an Iterator scanning records of node with c.
+func ScanIterator(ctx context.Context, c Client, node ServiceAddr, limit int) *Iterator {
+	c = WithContext(c, ctx)
+	return NewIterator(ctx, limit, func(cursor Cursor, limit int) ([]Record, Cursor, error) {
+		return c.Scan(node, cursor, limit)
+	})
+}
+
+// All returns an iterator over keys and values of records in ascending
+// order of keys. Ranging over it again starts from the beginning.
+func (it *Iterator) All() iter.Seq2[RecordID, []byte] {
+	return func(yield func(RecordID, []byte) bool) {
+		var cursor Cursor
+		for {
+			if it.err = it.ctx.Err(); it.err != nil {
+				return
+			}
+			records, next, err := it.scan(cursor, it.limit)
+			if err != nil {
+				it.err = err
+				return
+			}
+			for _, r := range records {
+				if it.err = it.ctx.Err(); it.err != nil {
+					return
+				}
+				if !r.Deleted && !yield(r.Key, r.Data) {
+					return
+				}
+			}
+			if next == nil {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// Keys returns an iterator over keys of records in ascending order.
+func (it *Iterator) Keys() iter.Seq[RecordID] {
+	return func(yield func(RecordID) bool) {
+		for k := range it.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error which stopped the last iteration, or nil if it
+// ended with the keyspace or was stopped by the caller.
+func (it *Iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pages returns a ScanFunc scanning records in pages and counting scans.
+func pages(records []Record, scans *int) ScanFunc {
+	return func(cursor Cursor, limit int) ([]Record, Cursor, error) {
+		*scans++
+		after, ok, err := cursor.After()
+		if err != nil {
+			return nil, nil, err
+		}
+		var page []Record
+		for _, r := range records {
+			if ok && r.Key <= after {
+				continue
+			}
+			if len(page) == limit {
+				return page, CursorAfter(page[len(page)-1].Key), nil
+			}
+			page = append(page, r)
+		}
+		return page, nil, nil
+	}
+}
+
+func TestIterator(t *testing.T) {
+	records := []Record{
+		{Key: 1, Data: []byte("a")},
+		{Key: 2, Deleted: true},
+		{Key: 3, Data: []byte("c")},
+		{Key: 4, Data: []byte("d")},
+		{Key: 5, Data: []byte("e")},
+	}
+	scans := 0
+	it := NewIterator(context.Background(), 2, pages(records, &scans))
+
+	var keys []RecordID
+	var data []string
+	for k, d := range it.All() {
+		keys = append(keys, k)
+		data = append(data, string(d))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() got %v", err)
+	}
+	if want := []RecordID{1, 3, 4, 5}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("All() got keys %v, want %v", keys, want)
+	}
+	if want := []string{"a", "c", "d", "e"}; !reflect.DeepEqual(data, want) {
+		t.Errorf("All() got values %q, want %q", data, want)
+	}
+
+	scans = 0
+	for k := range it.Keys() {
+		if k == 3 {
+			break
+		}
+	}
+	if scans != 2 || it.Err() != nil {
+		t.Errorf("Keys() stopped at the second page made %d scans with error %v, want 2 scans", scans, it.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it = NewIterator(ctx, 2, pages(records, &scans))
+	n := 0
+	for range it.All() {
+		n++
+		cancel()
+	}
+	if n != 1 || it.Err() != context.Canceled {
+		t.Errorf("All() after cancel got %d records and error %v, want 1 and %v", n, it.Err(), context.Canceled)
+	}
+
+	failure := errors.New("unavailable")
+	it = NewIterator(context.Background(), 2, func(cursor Cursor, limit int) ([]Record, Cursor, error) {
+		return nil, nil, failure
+	})
+	for range it.All() {
+		t.Errorf("All() of a failed scan got records")
+	}
+	if it.Err() != failure {
+		t.Errorf("Err() got %v, want %v", it.Err(), failure)
+	}
+}
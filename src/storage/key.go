@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// NullRecordID is the zero key. It is a valid key for nodes, frontends
+// reject it only if configured to, as it is usually a sign of a key
+// which was never set.
+const NullRecordID RecordID = 0
+
+// RecordIDFromBytes constructs a key from its 4-byte big-endian form
+// returned by RecordID.Bytes.
+func RecordIDFromBytes(b []byte) (RecordID, error) {
+	if len(b) != RecordID(0).BinSize() {
+		return 0, fmt.Errorf("%w: want %d bytes, got %d", ErrInvalidKey, RecordID(0).BinSize(), len(b))
+	}
+	return RecordID(binary.BigEndian.Uint32(b)), nil
+}
+
+// ParseRecordID parses a key in decimal or in hex with the 0x prefix
+// as returned by RecordID.Hex.
+func ParseRecordID(s string) (RecordID, error) {
+	k, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a uint32 value", ErrInvalidKey, s)
+	}
+	return RecordID(k), nil
+}
+
+// ParseRecordIDBase64 parses a key returned by RecordID.Base64.
+func ParseRecordIDBase64(s string) (RecordID, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not base64: %v", ErrInvalidKey, s, err)
+	}
+	return RecordIDFromBytes(b)
+}
+
+// Bytes returns the 4-byte big-endian form of the key, so byte order
+// of keys matches their numeric order.
+func (k RecordID) Bytes() []byte {
+	b := make([]byte, k.BinSize())
+	binary.BigEndian.PutUint32(b, uint32(k))
+	return b
+}
+
+// Hex formats the key in hex with the 0x prefix, e.g. 0x0000002a.
+func (k RecordID) Hex() string {
+	return fmt.Sprintf("%#08x", uint32(k))
+}
+
+// Base64 formats the key as unpadded URL-safe base64 of its bytes.
+func (k RecordID) Base64() string {
+	return base64.RawURLEncoding.EncodeToString(k.Bytes())
+}
+
+// IsNull reports whether the key is NullRecordID.
+func (k RecordID) IsNull() bool {
+	return k == NullRecordID
+}
+
+// Validate returns the ErrInvalidKey error if the key is null.
+func (k RecordID) Validate() error {
+	if k.IsNull() {
+		return fmt.Errorf("%w: null key", ErrInvalidKey)
+	}
+	return nil
+}
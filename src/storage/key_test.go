@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecordIDFormat(t *testing.T) {
+	for _, k := range []RecordID{0, 1, 42, 1 << 31, ^RecordID(0)} {
+		if got, err := ParseRecordID(k.Hex()); err != nil || got != k {
+			t.Errorf("ParseRecordID(%q) got %v, %v, want %v", k.Hex(), got, err, k)
+		}
+		if got, err := ParseRecordIDBase64(k.Base64()); err != nil || got != k {
+			t.Errorf("ParseRecordIDBase64(%q) got %v, %v, want %v", k.Base64(), got, err, k)
+		}
+		if got, err := RecordIDFromBytes(k.Bytes()); err != nil || got != k {
+			t.Errorf("RecordIDFromBytes(%v) got %v, %v, want %v", k.Bytes(), got, err, k)
+		}
+	}
+	if got := RecordID(42).Hex(); got != "0x0000002a" {
+		t.Errorf("Hex() got %q, want %q", got, "0x0000002a")
+	}
+	if got, err := ParseRecordID("42"); err != nil || got != 42 {
+		t.Errorf("ParseRecordID() of decimal got %v, %v, want 42", got, err)
+	}
+
+	for _, s := range []string{"", "-1", "4294967296", "0xfffffffff", "key"} {
+		if _, err := ParseRecordID(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseRecordID(%q) got error %v, want %v", s, err, ErrInvalidKey)
+		}
+	}
+	for _, s := range []string{"AAAA", "AAAAAAAA", "!!!!!!"} {
+		if _, err := ParseRecordIDBase64(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseRecordIDBase64(%q) got error %v, want %v", s, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestRecordIDValidate(t *testing.T) {
+	if err := NullRecordID.Validate(); !errors.Is(err, ErrInvalidKey) || ErrToStatus(err) != StatusInvalidKey {
+		t.Errorf("Validate() of null key got %v, want %v", err, ErrInvalidKey)
+	}
+	if err := RecordID(1).Validate(); err != nil {
+		t.Errorf("Validate() got error %v", err)
+	}
+}
+
+func FuzzParseRecordID(f *testing.F) {
+	for _, s := range []string{"0", "42", "0x0000002a", "4294967295", "-1", "0xfffffffff", "key", "AAAAKg"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if k, err := ParseRecordID(s); err == nil {
+			if got, err := ParseRecordID(k.Hex()); err != nil || got != k {
+				t.Fatalf("ParseRecordID(%q) got %v, %v, want %v", k.Hex(), got, err, k)
+			}
+		} else if !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("ParseRecordID(%q) got error %v, want %v", s, err, ErrInvalidKey)
+		}
+
+		if k, err := ParseRecordIDBase64(s); err == nil {
+			if got, err := RecordIDFromBytes(k.Bytes()); err != nil || got != k {
+				t.Fatalf("RecordIDFromBytes(%v) got %v, %v, want %v", k.Bytes(), got, err, k)
+			}
+		} else if !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("ParseRecordIDBase64(%q) got error %v, want %v", s, err, ErrInvalidKey)
+		}
+	})
+}
@@ -34,7 +34,7 @@ func (m *GetRequest) Reset()         { *m = GetRequest{} }
 func (m *GetRequest) String() string { return proto.CompactTextString(m) }
 func (*GetRequest) ProtoMessage()    {}
 func (*GetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{0}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{0}
 }
 func (m *GetRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
@@ -74,7 +74,7 @@ func (m *GetReply) Reset()         { *m = GetReply{} }
 func (m *GetReply) String() string { return proto.CompactTextString(m) }
 func (*GetReply) ProtoMessage()    {}
 func (*GetReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{1}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{1}
 }
 func (m *GetReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetReply.Unmarshal(m, b)
@@ -127,7 +127,7 @@ func (m *PutRequest) Reset()         { *m = PutRequest{} }
 func (m *PutRequest) String() string { return proto.CompactTextString(m) }
 func (*PutRequest) ProtoMessage()    {}
 func (*PutRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{2}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{2}
 }
 func (m *PutRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PutRequest.Unmarshal(m, b)
@@ -173,7 +173,7 @@ func (m *PutReply) Reset()         { *m = PutReply{} }
 func (m *PutReply) String() string { return proto.CompactTextString(m) }
 func (*PutReply) ProtoMessage()    {}
 func (*PutReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{3}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{3}
 }
 func (m *PutReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PutReply.Unmarshal(m, b)
@@ -218,7 +218,7 @@ func (m *DelRequest) Reset()         { *m = DelRequest{} }
 func (m *DelRequest) String() string { return proto.CompactTextString(m) }
 func (*DelRequest) ProtoMessage()    {}
 func (*DelRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{4}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{4}
 }
 func (m *DelRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DelRequest.Unmarshal(m, b)
@@ -257,7 +257,7 @@ func (m *DelReply) Reset()         { *m = DelReply{} }
 func (m *DelReply) String() string { return proto.CompactTextString(m) }
 func (*DelReply) ProtoMessage()    {}
 func (*DelReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_fd9dd68e056fbc00, []int{5}
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{5}
 }
 func (m *DelReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DelReply.Unmarshal(m, b)
@@ -291,6 +291,2132 @@ func (m *DelReply) GetError() string {
 	return ""
 }
 
+type Record struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Data                 []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Deleted              bool     `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Record) Reset()         { *m = Record{} }
+func (m *Record) String() string { return proto.CompactTextString(m) }
+func (*Record) ProtoMessage()    {}
+func (*Record) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{6}
+}
+func (m *Record) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Record.Unmarshal(m, b)
+}
+func (m *Record) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Record.Marshal(b, m, deterministic)
+}
+func (dst *Record) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Record.Merge(dst, src)
+}
+func (m *Record) XXX_Size() int {
+	return xxx_messageInfo_Record.Size(m)
+}
+func (m *Record) XXX_DiscardUnknown() {
+	xxx_messageInfo_Record.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Record proto.InternalMessageInfo
+
+func (m *Record) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *Record) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Record) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+type ScanRequest struct {
+	Cursor               []byte   `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit                uint32   `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanRequest) Reset()         { *m = ScanRequest{} }
+func (m *ScanRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanRequest) ProtoMessage()    {}
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{7}
+}
+func (m *ScanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanRequest.Unmarshal(m, b)
+}
+func (m *ScanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanRequest.Marshal(b, m, deterministic)
+}
+func (dst *ScanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanRequest.Merge(dst, src)
+}
+func (m *ScanRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanRequest.Size(m)
+}
+func (m *ScanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanRequest proto.InternalMessageInfo
+
+func (m *ScanRequest) GetCursor() []byte {
+	if m != nil {
+		return m.Cursor
+	}
+	return nil
+}
+
+func (m *ScanRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ScanReply struct {
+	Status               int32     `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*Record `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	Next                 []byte    `protobuf:"bytes,4,opt,name=next,proto3" json:"next,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ScanReply) Reset()         { *m = ScanReply{} }
+func (m *ScanReply) String() string { return proto.CompactTextString(m) }
+func (*ScanReply) ProtoMessage()    {}
+func (*ScanReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{8}
+}
+func (m *ScanReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanReply.Unmarshal(m, b)
+}
+func (m *ScanReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanReply.Marshal(b, m, deterministic)
+}
+func (dst *ScanReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanReply.Merge(dst, src)
+}
+func (m *ScanReply) XXX_Size() int {
+	return xxx_messageInfo_ScanReply.Size(m)
+}
+func (m *ScanReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanReply proto.InternalMessageInfo
+
+func (m *ScanReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ScanReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *ScanReply) GetRecords() []*Record {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+func (m *ScanReply) GetNext() []byte {
+	if m != nil {
+		return m.Next
+	}
+	return nil
+}
+
+type AcquireLeaseRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Holder               uint64   `protobuf:"varint,2,opt,name=holder,proto3" json:"holder,omitempty"`
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Fence                uint64   `protobuf:"varint,4,opt,name=fence,proto3" json:"fence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcquireLeaseRequest) Reset()         { *m = AcquireLeaseRequest{} }
+func (m *AcquireLeaseRequest) String() string { return proto.CompactTextString(m) }
+func (*AcquireLeaseRequest) ProtoMessage()    {}
+func (*AcquireLeaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{9}
+}
+func (m *AcquireLeaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcquireLeaseRequest.Unmarshal(m, b)
+}
+func (m *AcquireLeaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcquireLeaseRequest.Marshal(b, m, deterministic)
+}
+func (dst *AcquireLeaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireLeaseRequest.Merge(dst, src)
+}
+func (m *AcquireLeaseRequest) XXX_Size() int {
+	return xxx_messageInfo_AcquireLeaseRequest.Size(m)
+}
+func (m *AcquireLeaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireLeaseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireLeaseRequest proto.InternalMessageInfo
+
+func (m *AcquireLeaseRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *AcquireLeaseRequest) GetHolder() uint64 {
+	if m != nil {
+		return m.Holder
+	}
+	return 0
+}
+
+func (m *AcquireLeaseRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *AcquireLeaseRequest) GetFence() uint64 {
+	if m != nil {
+		return m.Fence
+	}
+	return 0
+}
+
+type AcquireLeaseReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Fence                uint64   `protobuf:"varint,3,opt,name=fence,proto3" json:"fence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcquireLeaseReply) Reset()         { *m = AcquireLeaseReply{} }
+func (m *AcquireLeaseReply) String() string { return proto.CompactTextString(m) }
+func (*AcquireLeaseReply) ProtoMessage()    {}
+func (*AcquireLeaseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{10}
+}
+func (m *AcquireLeaseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcquireLeaseReply.Unmarshal(m, b)
+}
+func (m *AcquireLeaseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcquireLeaseReply.Marshal(b, m, deterministic)
+}
+func (dst *AcquireLeaseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireLeaseReply.Merge(dst, src)
+}
+func (m *AcquireLeaseReply) XXX_Size() int {
+	return xxx_messageInfo_AcquireLeaseReply.Size(m)
+}
+func (m *AcquireLeaseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireLeaseReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireLeaseReply proto.InternalMessageInfo
+
+func (m *AcquireLeaseReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *AcquireLeaseReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *AcquireLeaseReply) GetFence() uint64 {
+	if m != nil {
+		return m.Fence
+	}
+	return 0
+}
+
+type ReleaseLeaseRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Holder               uint64   `protobuf:"varint,2,opt,name=holder,proto3" json:"holder,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReleaseLeaseRequest) Reset()         { *m = ReleaseLeaseRequest{} }
+func (m *ReleaseLeaseRequest) String() string { return proto.CompactTextString(m) }
+func (*ReleaseLeaseRequest) ProtoMessage()    {}
+func (*ReleaseLeaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{11}
+}
+func (m *ReleaseLeaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReleaseLeaseRequest.Unmarshal(m, b)
+}
+func (m *ReleaseLeaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReleaseLeaseRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReleaseLeaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReleaseLeaseRequest.Merge(dst, src)
+}
+func (m *ReleaseLeaseRequest) XXX_Size() int {
+	return xxx_messageInfo_ReleaseLeaseRequest.Size(m)
+}
+func (m *ReleaseLeaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReleaseLeaseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReleaseLeaseRequest proto.InternalMessageInfo
+
+func (m *ReleaseLeaseRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *ReleaseLeaseRequest) GetHolder() uint64 {
+	if m != nil {
+		return m.Holder
+	}
+	return 0
+}
+
+type ReleaseLeaseReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReleaseLeaseReply) Reset()         { *m = ReleaseLeaseReply{} }
+func (m *ReleaseLeaseReply) String() string { return proto.CompactTextString(m) }
+func (*ReleaseLeaseReply) ProtoMessage()    {}
+func (*ReleaseLeaseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{12}
+}
+func (m *ReleaseLeaseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReleaseLeaseReply.Unmarshal(m, b)
+}
+func (m *ReleaseLeaseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReleaseLeaseReply.Marshal(b, m, deterministic)
+}
+func (dst *ReleaseLeaseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReleaseLeaseReply.Merge(dst, src)
+}
+func (m *ReleaseLeaseReply) XXX_Size() int {
+	return xxx_messageInfo_ReleaseLeaseReply.Size(m)
+}
+func (m *ReleaseLeaseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReleaseLeaseReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReleaseLeaseReply proto.InternalMessageInfo
+
+func (m *ReleaseLeaseReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ReleaseLeaseReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type SequenceRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Floor                uint64   `protobuf:"varint,2,opt,name=floor,proto3" json:"floor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SequenceRequest) Reset()         { *m = SequenceRequest{} }
+func (m *SequenceRequest) String() string { return proto.CompactTextString(m) }
+func (*SequenceRequest) ProtoMessage()    {}
+func (*SequenceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{13}
+}
+func (m *SequenceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SequenceRequest.Unmarshal(m, b)
+}
+func (m *SequenceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SequenceRequest.Marshal(b, m, deterministic)
+}
+func (dst *SequenceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SequenceRequest.Merge(dst, src)
+}
+func (m *SequenceRequest) XXX_Size() int {
+	return xxx_messageInfo_SequenceRequest.Size(m)
+}
+func (m *SequenceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SequenceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SequenceRequest proto.InternalMessageInfo
+
+func (m *SequenceRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SequenceRequest) GetFloor() uint64 {
+	if m != nil {
+		return m.Floor
+	}
+	return 0
+}
+
+type SequenceReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Value                uint64   `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SequenceReply) Reset()         { *m = SequenceReply{} }
+func (m *SequenceReply) String() string { return proto.CompactTextString(m) }
+func (*SequenceReply) ProtoMessage()    {}
+func (*SequenceReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{14}
+}
+func (m *SequenceReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SequenceReply.Unmarshal(m, b)
+}
+func (m *SequenceReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SequenceReply.Marshal(b, m, deterministic)
+}
+func (dst *SequenceReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SequenceReply.Merge(dst, src)
+}
+func (m *SequenceReply) XXX_Size() int {
+	return xxx_messageInfo_SequenceReply.Size(m)
+}
+func (m *SequenceReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SequenceReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SequenceReply proto.InternalMessageInfo
+
+func (m *SequenceReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *SequenceReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SequenceReply) GetValue() uint64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{15}
+}
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (dst *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(dst, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+type StatsReply struct {
+	Status               int32             `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string            `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              uint64            `protobuf:"varint,3,opt,name=records,proto3" json:"records,omitempty"`
+	Bytes                uint64            `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Requests             uint64            `protobuf:"varint,5,opt,name=requests,proto3" json:"requests,omitempty"`
+	Namespaces           []*NamespaceStats `protobuf:"bytes,6,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *StatsReply) Reset()         { *m = StatsReply{} }
+func (m *StatsReply) String() string { return proto.CompactTextString(m) }
+func (*StatsReply) ProtoMessage()    {}
+func (*StatsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{16}
+}
+func (m *StatsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsReply.Unmarshal(m, b)
+}
+func (m *StatsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsReply.Marshal(b, m, deterministic)
+}
+func (dst *StatsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsReply.Merge(dst, src)
+}
+func (m *StatsReply) XXX_Size() int {
+	return xxx_messageInfo_StatsReply.Size(m)
+}
+func (m *StatsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsReply proto.InternalMessageInfo
+
+func (m *StatsReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *StatsReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *StatsReply) GetRecords() uint64 {
+	if m != nil {
+		return m.Records
+	}
+	return 0
+}
+
+func (m *StatsReply) GetBytes() uint64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
+func (m *StatsReply) GetRequests() uint64 {
+	if m != nil {
+		return m.Requests
+	}
+	return 0
+}
+
+func (m *StatsReply) GetNamespaces() []*NamespaceStats {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+type GetVersionRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version              uint64   `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetVersionRequest) Reset()         { *m = GetVersionRequest{} }
+func (m *GetVersionRequest) String() string { return proto.CompactTextString(m) }
+func (*GetVersionRequest) ProtoMessage()    {}
+func (*GetVersionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{17}
+}
+func (m *GetVersionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetVersionRequest.Unmarshal(m, b)
+}
+func (m *GetVersionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetVersionRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetVersionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetVersionRequest.Merge(dst, src)
+}
+func (m *GetVersionRequest) XXX_Size() int {
+	return xxx_messageInfo_GetVersionRequest.Size(m)
+}
+func (m *GetVersionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetVersionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetVersionRequest proto.InternalMessageInfo
+
+func (m *GetVersionRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *GetVersionRequest) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type GetVersionReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetVersionReply) Reset()         { *m = GetVersionReply{} }
+func (m *GetVersionReply) String() string { return proto.CompactTextString(m) }
+func (*GetVersionReply) ProtoMessage()    {}
+func (*GetVersionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{18}
+}
+func (m *GetVersionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetVersionReply.Unmarshal(m, b)
+}
+func (m *GetVersionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetVersionReply.Marshal(b, m, deterministic)
+}
+func (dst *GetVersionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetVersionReply.Merge(dst, src)
+}
+func (m *GetVersionReply) XXX_Size() int {
+	return xxx_messageInfo_GetVersionReply.Size(m)
+}
+func (m *GetVersionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetVersionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetVersionReply proto.InternalMessageInfo
+
+func (m *GetVersionReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *GetVersionReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *GetVersionReply) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type ListVersionsRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListVersionsRequest) Reset()         { *m = ListVersionsRequest{} }
+func (m *ListVersionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListVersionsRequest) ProtoMessage()    {}
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{19}
+}
+func (m *ListVersionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListVersionsRequest.Unmarshal(m, b)
+}
+func (m *ListVersionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListVersionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListVersionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListVersionsRequest.Merge(dst, src)
+}
+func (m *ListVersionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListVersionsRequest.Size(m)
+}
+func (m *ListVersionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListVersionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListVersionsRequest proto.InternalMessageInfo
+
+func (m *ListVersionsRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+type ListVersionsReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Versions             []uint64 `protobuf:"varint,3,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListVersionsReply) Reset()         { *m = ListVersionsReply{} }
+func (m *ListVersionsReply) String() string { return proto.CompactTextString(m) }
+func (*ListVersionsReply) ProtoMessage()    {}
+func (*ListVersionsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{20}
+}
+func (m *ListVersionsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListVersionsReply.Unmarshal(m, b)
+}
+func (m *ListVersionsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListVersionsReply.Marshal(b, m, deterministic)
+}
+func (dst *ListVersionsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListVersionsReply.Merge(dst, src)
+}
+func (m *ListVersionsReply) XXX_Size() int {
+	return xxx_messageInfo_ListVersionsReply.Size(m)
+}
+func (m *ListVersionsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListVersionsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListVersionsReply proto.InternalMessageInfo
+
+func (m *ListVersionsReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ListVersionsReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *ListVersionsReply) GetVersions() []uint64 {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type SnapshotRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Phase                int32    `protobuf:"varint,2,opt,name=phase,proto3" json:"phase,omitempty"`
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotRequest) Reset()         { *m = SnapshotRequest{} }
+func (m *SnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*SnapshotRequest) ProtoMessage()    {}
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{21}
+}
+func (m *SnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotRequest.Unmarshal(m, b)
+}
+func (m *SnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotRequest.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotRequest.Merge(dst, src)
+}
+func (m *SnapshotRequest) XXX_Size() int {
+	return xxx_messageInfo_SnapshotRequest.Size(m)
+}
+func (m *SnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotRequest proto.InternalMessageInfo
+
+func (m *SnapshotRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *SnapshotRequest) GetPhase() int32 {
+	if m != nil {
+		return m.Phase
+	}
+	return 0
+}
+
+func (m *SnapshotRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type SnapshotReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotReply) Reset()         { *m = SnapshotReply{} }
+func (m *SnapshotReply) String() string { return proto.CompactTextString(m) }
+func (*SnapshotReply) ProtoMessage()    {}
+func (*SnapshotReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{22}
+}
+func (m *SnapshotReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotReply.Unmarshal(m, b)
+}
+func (m *SnapshotReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotReply.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotReply.Merge(dst, src)
+}
+func (m *SnapshotReply) XXX_Size() int {
+	return xxx_messageInfo_SnapshotReply.Size(m)
+}
+func (m *SnapshotReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotReply proto.InternalMessageInfo
+
+func (m *SnapshotReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *SnapshotReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ScanSnapshotRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cursor               []byte   `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit                uint32   `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanSnapshotRequest) Reset()         { *m = ScanSnapshotRequest{} }
+func (m *ScanSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanSnapshotRequest) ProtoMessage()    {}
+func (*ScanSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{23}
+}
+func (m *ScanSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanSnapshotRequest.Unmarshal(m, b)
+}
+func (m *ScanSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanSnapshotRequest.Marshal(b, m, deterministic)
+}
+func (dst *ScanSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanSnapshotRequest.Merge(dst, src)
+}
+func (m *ScanSnapshotRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanSnapshotRequest.Size(m)
+}
+func (m *ScanSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanSnapshotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanSnapshotRequest proto.InternalMessageInfo
+
+func (m *ScanSnapshotRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ScanSnapshotRequest) GetCursor() []byte {
+	if m != nil {
+		return m.Cursor
+	}
+	return nil
+}
+
+func (m *ScanSnapshotRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ScanChangesRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Since                uint64   `protobuf:"varint,2,opt,name=since,proto3" json:"since,omitempty"`
+	Cursor               []byte   `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit                uint32   `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanChangesRequest) Reset()         { *m = ScanChangesRequest{} }
+func (m *ScanChangesRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanChangesRequest) ProtoMessage()    {}
+func (*ScanChangesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{24}
+}
+func (m *ScanChangesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanChangesRequest.Unmarshal(m, b)
+}
+func (m *ScanChangesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanChangesRequest.Marshal(b, m, deterministic)
+}
+func (dst *ScanChangesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanChangesRequest.Merge(dst, src)
+}
+func (m *ScanChangesRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanChangesRequest.Size(m)
+}
+func (m *ScanChangesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanChangesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanChangesRequest proto.InternalMessageInfo
+
+func (m *ScanChangesRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ScanChangesRequest) GetSince() uint64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+func (m *ScanChangesRequest) GetCursor() []byte {
+	if m != nil {
+		return m.Cursor
+	}
+	return nil
+}
+
+func (m *ScanChangesRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ReserveRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReserveRequest) Reset()         { *m = ReserveRequest{} }
+func (m *ReserveRequest) String() string { return proto.CompactTextString(m) }
+func (*ReserveRequest) ProtoMessage()    {}
+func (*ReserveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{25}
+}
+func (m *ReserveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReserveRequest.Unmarshal(m, b)
+}
+func (m *ReserveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReserveRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReserveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReserveRequest.Merge(dst, src)
+}
+func (m *ReserveRequest) XXX_Size() int {
+	return xxx_messageInfo_ReserveRequest.Size(m)
+}
+func (m *ReserveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReserveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReserveRequest proto.InternalMessageInfo
+
+func (m *ReserveRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *ReserveRequest) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *ReserveRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type ReserveReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReserveReply) Reset()         { *m = ReserveReply{} }
+func (m *ReserveReply) String() string { return proto.CompactTextString(m) }
+func (*ReserveReply) ProtoMessage()    {}
+func (*ReserveReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{26}
+}
+func (m *ReserveReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReserveReply.Unmarshal(m, b)
+}
+func (m *ReserveReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReserveReply.Marshal(b, m, deterministic)
+}
+func (dst *ReserveReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReserveReply.Merge(dst, src)
+}
+func (m *ReserveReply) XXX_Size() int {
+	return xxx_messageInfo_ReserveReply.Size(m)
+}
+func (m *ReserveReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReserveReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReserveReply proto.InternalMessageInfo
+
+func (m *ReserveReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ReserveReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type CancelReservationRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelReservationRequest) Reset()         { *m = CancelReservationRequest{} }
+func (m *CancelReservationRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelReservationRequest) ProtoMessage()    {}
+func (*CancelReservationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{27}
+}
+func (m *CancelReservationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelReservationRequest.Unmarshal(m, b)
+}
+func (m *CancelReservationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelReservationRequest.Marshal(b, m, deterministic)
+}
+func (dst *CancelReservationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelReservationRequest.Merge(dst, src)
+}
+func (m *CancelReservationRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelReservationRequest.Size(m)
+}
+func (m *CancelReservationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelReservationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelReservationRequest proto.InternalMessageInfo
+
+func (m *CancelReservationRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+type CancelReservationReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelReservationReply) Reset()         { *m = CancelReservationReply{} }
+func (m *CancelReservationReply) String() string { return proto.CompactTextString(m) }
+func (*CancelReservationReply) ProtoMessage()    {}
+func (*CancelReservationReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{28}
+}
+func (m *CancelReservationReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelReservationReply.Unmarshal(m, b)
+}
+func (m *CancelReservationReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelReservationReply.Marshal(b, m, deterministic)
+}
+func (dst *CancelReservationReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelReservationReply.Merge(dst, src)
+}
+func (m *CancelReservationReply) XXX_Size() int {
+	return xxx_messageInfo_CancelReservationReply.Size(m)
+}
+func (m *CancelReservationReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelReservationReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelReservationReply proto.InternalMessageInfo
+
+func (m *CancelReservationReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *CancelReservationReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type MGetRequest struct {
+	Keys                 []uint32 `protobuf:"varint,1,rep,packed,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MGetRequest) Reset()         { *m = MGetRequest{} }
+func (m *MGetRequest) String() string { return proto.CompactTextString(m) }
+func (*MGetRequest) ProtoMessage()    {}
+func (*MGetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{29}
+}
+func (m *MGetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MGetRequest.Unmarshal(m, b)
+}
+func (m *MGetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MGetRequest.Marshal(b, m, deterministic)
+}
+func (dst *MGetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MGetRequest.Merge(dst, src)
+}
+func (m *MGetRequest) XXX_Size() int {
+	return xxx_messageInfo_MGetRequest.Size(m)
+}
+func (m *MGetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MGetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MGetRequest proto.InternalMessageInfo
+
+func (m *MGetRequest) GetKeys() []uint32 {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type MGetReply struct {
+	Status               int32       `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*GetReply `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *MGetReply) Reset()         { *m = MGetReply{} }
+func (m *MGetReply) String() string { return proto.CompactTextString(m) }
+func (*MGetReply) ProtoMessage()    {}
+func (*MGetReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{30}
+}
+func (m *MGetReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MGetReply.Unmarshal(m, b)
+}
+func (m *MGetReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MGetReply.Marshal(b, m, deterministic)
+}
+func (dst *MGetReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MGetReply.Merge(dst, src)
+}
+func (m *MGetReply) XXX_Size() int {
+	return xxx_messageInfo_MGetReply.Size(m)
+}
+func (m *MGetReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MGetReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MGetReply proto.InternalMessageInfo
+
+func (m *MGetReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *MGetReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *MGetReply) GetRecords() []*GetReply {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type MPutRequest struct {
+	Records              []*PutRequest `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *MPutRequest) Reset()         { *m = MPutRequest{} }
+func (m *MPutRequest) String() string { return proto.CompactTextString(m) }
+func (*MPutRequest) ProtoMessage()    {}
+func (*MPutRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{31}
+}
+func (m *MPutRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MPutRequest.Unmarshal(m, b)
+}
+func (m *MPutRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MPutRequest.Marshal(b, m, deterministic)
+}
+func (dst *MPutRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MPutRequest.Merge(dst, src)
+}
+func (m *MPutRequest) XXX_Size() int {
+	return xxx_messageInfo_MPutRequest.Size(m)
+}
+func (m *MPutRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MPutRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MPutRequest proto.InternalMessageInfo
+
+func (m *MPutRequest) GetRecords() []*PutRequest {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type MPutReply struct {
+	Status               int32       `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*PutReply `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *MPutReply) Reset()         { *m = MPutReply{} }
+func (m *MPutReply) String() string { return proto.CompactTextString(m) }
+func (*MPutReply) ProtoMessage()    {}
+func (*MPutReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{32}
+}
+func (m *MPutReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MPutReply.Unmarshal(m, b)
+}
+func (m *MPutReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MPutReply.Marshal(b, m, deterministic)
+}
+func (dst *MPutReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MPutReply.Merge(dst, src)
+}
+func (m *MPutReply) XXX_Size() int {
+	return xxx_messageInfo_MPutReply.Size(m)
+}
+func (m *MPutReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MPutReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MPutReply proto.InternalMessageInfo
+
+func (m *MPutReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *MPutReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *MPutReply) GetRecords() []*PutReply {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type MDelRequest struct {
+	Keys                 []uint32 `protobuf:"varint,1,rep,packed,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MDelRequest) Reset()         { *m = MDelRequest{} }
+func (m *MDelRequest) String() string { return proto.CompactTextString(m) }
+func (*MDelRequest) ProtoMessage()    {}
+func (*MDelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{33}
+}
+func (m *MDelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MDelRequest.Unmarshal(m, b)
+}
+func (m *MDelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MDelRequest.Marshal(b, m, deterministic)
+}
+func (dst *MDelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MDelRequest.Merge(dst, src)
+}
+func (m *MDelRequest) XXX_Size() int {
+	return xxx_messageInfo_MDelRequest.Size(m)
+}
+func (m *MDelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MDelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MDelRequest proto.InternalMessageInfo
+
+func (m *MDelRequest) GetKeys() []uint32 {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type MDelReply struct {
+	Status               int32       `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*DelReply `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *MDelReply) Reset()         { *m = MDelReply{} }
+func (m *MDelReply) String() string { return proto.CompactTextString(m) }
+func (*MDelReply) ProtoMessage()    {}
+func (*MDelReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{34}
+}
+func (m *MDelReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MDelReply.Unmarshal(m, b)
+}
+func (m *MDelReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MDelReply.Marshal(b, m, deterministic)
+}
+func (dst *MDelReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MDelReply.Merge(dst, src)
+}
+func (m *MDelReply) XXX_Size() int {
+	return xxx_messageInfo_MDelReply.Size(m)
+}
+func (m *MDelReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MDelReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MDelReply proto.InternalMessageInfo
+
+func (m *MDelReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *MDelReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *MDelReply) GetRecords() []*DelReply {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type DeltaRequest struct {
+	Since                uint64   `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	Limit                uint32   `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeltaRequest) Reset()         { *m = DeltaRequest{} }
+func (m *DeltaRequest) String() string { return proto.CompactTextString(m) }
+func (*DeltaRequest) ProtoMessage()    {}
+func (*DeltaRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{35}
+}
+func (m *DeltaRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeltaRequest.Unmarshal(m, b)
+}
+func (m *DeltaRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeltaRequest.Marshal(b, m, deterministic)
+}
+func (dst *DeltaRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeltaRequest.Merge(dst, src)
+}
+func (m *DeltaRequest) XXX_Size() int {
+	return xxx_messageInfo_DeltaRequest.Size(m)
+}
+func (m *DeltaRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeltaRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeltaRequest proto.InternalMessageInfo
+
+func (m *DeltaRequest) GetSince() uint64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+func (m *DeltaRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type DeltaReply struct {
+	Status               int32     `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*Record `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	Seq                  uint64    `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	More                 bool      `protobuf:"varint,5,opt,name=more,proto3" json:"more,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *DeltaReply) Reset()         { *m = DeltaReply{} }
+func (m *DeltaReply) String() string { return proto.CompactTextString(m) }
+func (*DeltaReply) ProtoMessage()    {}
+func (*DeltaReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{36}
+}
+func (m *DeltaReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeltaReply.Unmarshal(m, b)
+}
+func (m *DeltaReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeltaReply.Marshal(b, m, deterministic)
+}
+func (dst *DeltaReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeltaReply.Merge(dst, src)
+}
+func (m *DeltaReply) XXX_Size() int {
+	return xxx_messageInfo_DeltaReply.Size(m)
+}
+func (m *DeltaReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeltaReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeltaReply proto.InternalMessageInfo
+
+func (m *DeltaReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *DeltaReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *DeltaReply) GetRecords() []*Record {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+func (m *DeltaReply) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *DeltaReply) GetMore() bool {
+	if m != nil {
+		return m.More
+	}
+	return false
+}
+
+type ChecksumRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Hash                 string   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChecksumRequest) Reset()         { *m = ChecksumRequest{} }
+func (m *ChecksumRequest) String() string { return proto.CompactTextString(m) }
+func (*ChecksumRequest) ProtoMessage()    {}
+func (*ChecksumRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{37}
+}
+func (m *ChecksumRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChecksumRequest.Unmarshal(m, b)
+}
+func (m *ChecksumRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChecksumRequest.Marshal(b, m, deterministic)
+}
+func (dst *ChecksumRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChecksumRequest.Merge(dst, src)
+}
+func (m *ChecksumRequest) XXX_Size() int {
+	return xxx_messageInfo_ChecksumRequest.Size(m)
+}
+func (m *ChecksumRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChecksumRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChecksumRequest proto.InternalMessageInfo
+
+func (m *ChecksumRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *ChecksumRequest) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+type ChecksumReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Sum                  []byte   `protobuf:"bytes,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChecksumReply) Reset()         { *m = ChecksumReply{} }
+func (m *ChecksumReply) String() string { return proto.CompactTextString(m) }
+func (*ChecksumReply) ProtoMessage()    {}
+func (*ChecksumReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{38}
+}
+func (m *ChecksumReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChecksumReply.Unmarshal(m, b)
+}
+func (m *ChecksumReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChecksumReply.Marshal(b, m, deterministic)
+}
+func (dst *ChecksumReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChecksumReply.Merge(dst, src)
+}
+func (m *ChecksumReply) XXX_Size() int {
+	return xxx_messageInfo_ChecksumReply.Size(m)
+}
+func (m *ChecksumReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChecksumReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChecksumReply proto.InternalMessageInfo
+
+func (m *ChecksumReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ChecksumReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *ChecksumReply) GetSum() []byte {
+	if m != nil {
+		return m.Sum
+	}
+	return nil
+}
+
+type Proposal struct {
+	Ballot               uint64   `protobuf:"varint,1,opt,name=ballot,proto3" json:"ballot,omitempty"`
+	Data                 []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Deleted              bool     `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Proposal) Reset()         { *m = Proposal{} }
+func (m *Proposal) String() string { return proto.CompactTextString(m) }
+func (*Proposal) ProtoMessage()    {}
+func (*Proposal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{39}
+}
+func (m *Proposal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Proposal.Unmarshal(m, b)
+}
+func (m *Proposal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Proposal.Marshal(b, m, deterministic)
+}
+func (dst *Proposal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Proposal.Merge(dst, src)
+}
+func (m *Proposal) XXX_Size() int {
+	return xxx_messageInfo_Proposal.Size(m)
+}
+func (m *Proposal) XXX_DiscardUnknown() {
+	xxx_messageInfo_Proposal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Proposal proto.InternalMessageInfo
+
+func (m *Proposal) GetBallot() uint64 {
+	if m != nil {
+		return m.Ballot
+	}
+	return 0
+}
+
+func (m *Proposal) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Proposal) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+type PaxosRequest struct {
+	Key                  uint32    `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Phase                int32     `protobuf:"varint,2,opt,name=phase,proto3" json:"phase,omitempty"`
+	Proposal             *Proposal `protobuf:"bytes,3,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PaxosRequest) Reset()         { *m = PaxosRequest{} }
+func (m *PaxosRequest) String() string { return proto.CompactTextString(m) }
+func (*PaxosRequest) ProtoMessage()    {}
+func (*PaxosRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{40}
+}
+func (m *PaxosRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PaxosRequest.Unmarshal(m, b)
+}
+func (m *PaxosRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PaxosRequest.Marshal(b, m, deterministic)
+}
+func (dst *PaxosRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PaxosRequest.Merge(dst, src)
+}
+func (m *PaxosRequest) XXX_Size() int {
+	return xxx_messageInfo_PaxosRequest.Size(m)
+}
+func (m *PaxosRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PaxosRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PaxosRequest proto.InternalMessageInfo
+
+func (m *PaxosRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *PaxosRequest) GetPhase() int32 {
+	if m != nil {
+		return m.Phase
+	}
+	return 0
+}
+
+func (m *PaxosRequest) GetProposal() *Proposal {
+	if m != nil {
+		return m.Proposal
+	}
+	return nil
+}
+
+type PaxosReply struct {
+	Status               int32     `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Promised             uint64    `protobuf:"varint,3,opt,name=promised,proto3" json:"promised,omitempty"`
+	Accepted             *Proposal `protobuf:"bytes,4,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Committed            uint64    `protobuf:"varint,5,opt,name=committed,proto3" json:"committed,omitempty"`
+	Value                []byte    `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	Found                bool      `protobuf:"varint,7,opt,name=found,proto3" json:"found,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PaxosReply) Reset()         { *m = PaxosReply{} }
+func (m *PaxosReply) String() string { return proto.CompactTextString(m) }
+func (*PaxosReply) ProtoMessage()    {}
+func (*PaxosReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{41}
+}
+func (m *PaxosReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PaxosReply.Unmarshal(m, b)
+}
+func (m *PaxosReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PaxosReply.Marshal(b, m, deterministic)
+}
+func (dst *PaxosReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PaxosReply.Merge(dst, src)
+}
+func (m *PaxosReply) XXX_Size() int {
+	return xxx_messageInfo_PaxosReply.Size(m)
+}
+func (m *PaxosReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PaxosReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PaxosReply proto.InternalMessageInfo
+
+func (m *PaxosReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *PaxosReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *PaxosReply) GetPromised() uint64 {
+	if m != nil {
+		return m.Promised
+	}
+	return 0
+}
+
+func (m *PaxosReply) GetAccepted() *Proposal {
+	if m != nil {
+		return m.Accepted
+	}
+	return nil
+}
+
+func (m *PaxosReply) GetCommitted() uint64 {
+	if m != nil {
+		return m.Committed
+	}
+	return 0
+}
+
+func (m *PaxosReply) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *PaxosReply) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+type AcquireReadLeaseRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Holder               uint64   `protobuf:"varint,2,opt,name=holder,proto3" json:"holder,omitempty"`
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcquireReadLeaseRequest) Reset()         { *m = AcquireReadLeaseRequest{} }
+func (m *AcquireReadLeaseRequest) String() string { return proto.CompactTextString(m) }
+func (*AcquireReadLeaseRequest) ProtoMessage()    {}
+func (*AcquireReadLeaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{42}
+}
+func (m *AcquireReadLeaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcquireReadLeaseRequest.Unmarshal(m, b)
+}
+func (m *AcquireReadLeaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcquireReadLeaseRequest.Marshal(b, m, deterministic)
+}
+func (dst *AcquireReadLeaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireReadLeaseRequest.Merge(dst, src)
+}
+func (m *AcquireReadLeaseRequest) XXX_Size() int {
+	return xxx_messageInfo_AcquireReadLeaseRequest.Size(m)
+}
+func (m *AcquireReadLeaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireReadLeaseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireReadLeaseRequest proto.InternalMessageInfo
+
+func (m *AcquireReadLeaseRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+func (m *AcquireReadLeaseRequest) GetHolder() uint64 {
+	if m != nil {
+		return m.Holder
+	}
+	return 0
+}
+
+func (m *AcquireReadLeaseRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type AcquireReadLeaseReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Found                bool     `protobuf:"varint,4,opt,name=found,proto3" json:"found,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcquireReadLeaseReply) Reset()         { *m = AcquireReadLeaseReply{} }
+func (m *AcquireReadLeaseReply) String() string { return proto.CompactTextString(m) }
+func (*AcquireReadLeaseReply) ProtoMessage()    {}
+func (*AcquireReadLeaseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{43}
+}
+func (m *AcquireReadLeaseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcquireReadLeaseReply.Unmarshal(m, b)
+}
+func (m *AcquireReadLeaseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcquireReadLeaseReply.Marshal(b, m, deterministic)
+}
+func (dst *AcquireReadLeaseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcquireReadLeaseReply.Merge(dst, src)
+}
+func (m *AcquireReadLeaseReply) XXX_Size() int {
+	return xxx_messageInfo_AcquireReadLeaseReply.Size(m)
+}
+func (m *AcquireReadLeaseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcquireReadLeaseReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcquireReadLeaseReply proto.InternalMessageInfo
+
+func (m *AcquireReadLeaseReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *AcquireReadLeaseReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *AcquireReadLeaseReply) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *AcquireReadLeaseReply) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+type LeaseFenceRequest struct {
+	Key                  uint32   `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeaseFenceRequest) Reset()         { *m = LeaseFenceRequest{} }
+func (m *LeaseFenceRequest) String() string { return proto.CompactTextString(m) }
+func (*LeaseFenceRequest) ProtoMessage()    {}
+func (*LeaseFenceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{44}
+}
+func (m *LeaseFenceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeaseFenceRequest.Unmarshal(m, b)
+}
+func (m *LeaseFenceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeaseFenceRequest.Marshal(b, m, deterministic)
+}
+func (dst *LeaseFenceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeaseFenceRequest.Merge(dst, src)
+}
+func (m *LeaseFenceRequest) XXX_Size() int {
+	return xxx_messageInfo_LeaseFenceRequest.Size(m)
+}
+func (m *LeaseFenceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeaseFenceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeaseFenceRequest proto.InternalMessageInfo
+
+func (m *LeaseFenceRequest) GetKey() uint32 {
+	if m != nil {
+		return m.Key
+	}
+	return 0
+}
+
+type LeaseFenceReply struct {
+	Status               int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Fence                uint64   `protobuf:"varint,3,opt,name=fence,proto3" json:"fence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeaseFenceReply) Reset()         { *m = LeaseFenceReply{} }
+func (m *LeaseFenceReply) String() string { return proto.CompactTextString(m) }
+func (*LeaseFenceReply) ProtoMessage()    {}
+func (*LeaseFenceReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{45}
+}
+func (m *LeaseFenceReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeaseFenceReply.Unmarshal(m, b)
+}
+func (m *LeaseFenceReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeaseFenceReply.Marshal(b, m, deterministic)
+}
+func (dst *LeaseFenceReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeaseFenceReply.Merge(dst, src)
+}
+func (m *LeaseFenceReply) XXX_Size() int {
+	return xxx_messageInfo_LeaseFenceReply.Size(m)
+}
+func (m *LeaseFenceReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeaseFenceReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeaseFenceReply proto.InternalMessageInfo
+
+func (m *LeaseFenceReply) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *LeaseFenceReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *LeaseFenceReply) GetFence() uint64 {
+	if m != nil {
+		return m.Fence
+	}
+	return 0
+}
+
+type NamespaceStats struct {
+	Prefix               uint32   `protobuf:"varint,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	PrefixLen            uint32   `protobuf:"varint,2,opt,name=prefix_len,json=prefixLen,proto3" json:"prefix_len,omitempty"`
+	Records              uint64   `protobuf:"varint,3,opt,name=records,proto3" json:"records,omitempty"`
+	Bytes                uint64   `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NamespaceStats) Reset()         { *m = NamespaceStats{} }
+func (m *NamespaceStats) String() string { return proto.CompactTextString(m) }
+func (*NamespaceStats) ProtoMessage()    {}
+func (*NamespaceStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_02aa2e8d30f72089, []int{46}
+}
+func (m *NamespaceStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NamespaceStats.Unmarshal(m, b)
+}
+func (m *NamespaceStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NamespaceStats.Marshal(b, m, deterministic)
+}
+func (dst *NamespaceStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceStats.Merge(dst, src)
+}
+func (m *NamespaceStats) XXX_Size() int {
+	return xxx_messageInfo_NamespaceStats.Size(m)
+}
+func (m *NamespaceStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceStats proto.InternalMessageInfo
+
+func (m *NamespaceStats) GetPrefix() uint32 {
+	if m != nil {
+		return m.Prefix
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetPrefixLen() uint32 {
+	if m != nil {
+		return m.PrefixLen
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetRecords() uint64 {
+	if m != nil {
+		return m.Records
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetBytes() uint64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*GetRequest)(nil), "GetRequest")
 	proto.RegisterType((*GetReply)(nil), "GetReply")
@@ -298,121 +2424,829 @@ func init() {
 	proto.RegisterType((*PutReply)(nil), "PutReply")
 	proto.RegisterType((*DelRequest)(nil), "DelRequest")
 	proto.RegisterType((*DelReply)(nil), "DelReply")
+	proto.RegisterType((*Record)(nil), "Record")
+	proto.RegisterType((*ScanRequest)(nil), "ScanRequest")
+	proto.RegisterType((*ScanReply)(nil), "ScanReply")
+	proto.RegisterType((*AcquireLeaseRequest)(nil), "AcquireLeaseRequest")
+	proto.RegisterType((*AcquireLeaseReply)(nil), "AcquireLeaseReply")
+	proto.RegisterType((*ReleaseLeaseRequest)(nil), "ReleaseLeaseRequest")
+	proto.RegisterType((*ReleaseLeaseReply)(nil), "ReleaseLeaseReply")
+	proto.RegisterType((*SequenceRequest)(nil), "SequenceRequest")
+	proto.RegisterType((*SequenceReply)(nil), "SequenceReply")
+	proto.RegisterType((*StatsRequest)(nil), "StatsRequest")
+	proto.RegisterType((*StatsReply)(nil), "StatsReply")
+	proto.RegisterType((*GetVersionRequest)(nil), "GetVersionRequest")
+	proto.RegisterType((*GetVersionReply)(nil), "GetVersionReply")
+	proto.RegisterType((*ListVersionsRequest)(nil), "ListVersionsRequest")
+	proto.RegisterType((*ListVersionsReply)(nil), "ListVersionsReply")
+	proto.RegisterType((*SnapshotRequest)(nil), "SnapshotRequest")
+	proto.RegisterType((*SnapshotReply)(nil), "SnapshotReply")
+	proto.RegisterType((*ScanSnapshotRequest)(nil), "ScanSnapshotRequest")
+	proto.RegisterType((*ScanChangesRequest)(nil), "ScanChangesRequest")
+	proto.RegisterType((*ReserveRequest)(nil), "ReserveRequest")
+	proto.RegisterType((*ReserveReply)(nil), "ReserveReply")
+	proto.RegisterType((*CancelReservationRequest)(nil), "CancelReservationRequest")
+	proto.RegisterType((*CancelReservationReply)(nil), "CancelReservationReply")
+	proto.RegisterType((*MGetRequest)(nil), "MGetRequest")
+	proto.RegisterType((*MGetReply)(nil), "MGetReply")
+	proto.RegisterType((*MPutRequest)(nil), "MPutRequest")
+	proto.RegisterType((*MPutReply)(nil), "MPutReply")
+	proto.RegisterType((*MDelRequest)(nil), "MDelRequest")
+	proto.RegisterType((*MDelReply)(nil), "MDelReply")
+	proto.RegisterType((*DeltaRequest)(nil), "DeltaRequest")
+	proto.RegisterType((*DeltaReply)(nil), "DeltaReply")
+	proto.RegisterType((*ChecksumRequest)(nil), "ChecksumRequest")
+	proto.RegisterType((*ChecksumReply)(nil), "ChecksumReply")
+	proto.RegisterType((*Proposal)(nil), "Proposal")
+	proto.RegisterType((*PaxosRequest)(nil), "PaxosRequest")
+	proto.RegisterType((*PaxosReply)(nil), "PaxosReply")
+	proto.RegisterType((*AcquireReadLeaseRequest)(nil), "AcquireReadLeaseRequest")
+	proto.RegisterType((*AcquireReadLeaseReply)(nil), "AcquireReadLeaseReply")
+	proto.RegisterType((*LeaseFenceRequest)(nil), "LeaseFenceRequest")
+	proto.RegisterType((*LeaseFenceReply)(nil), "LeaseFenceReply")
+	proto.RegisterType((*NamespaceStats)(nil), "NamespaceStats")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// StorageClient is the client API for Storage service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StorageClient interface {
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error)
+	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error)
+	Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanReply, error)
+	AcquireLease(ctx context.Context, in *AcquireLeaseRequest, opts ...grpc.CallOption) (*AcquireLeaseReply, error)
+	ReleaseLease(ctx context.Context, in *ReleaseLeaseRequest, opts ...grpc.CallOption) (*ReleaseLeaseReply, error)
+	Sequence(ctx context.Context, in *SequenceRequest, opts ...grpc.CallOption) (*SequenceReply, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error)
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionReply, error)
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsReply, error)
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error)
+	ScanSnapshot(ctx context.Context, in *ScanSnapshotRequest, opts ...grpc.CallOption) (*ScanReply, error)
+	ScanChanges(ctx context.Context, in *ScanChangesRequest, opts ...grpc.CallOption) (*ScanReply, error)
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveReply, error)
+	CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationReply, error)
+	MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetReply, error)
+	MPut(ctx context.Context, in *MPutRequest, opts ...grpc.CallOption) (*MPutReply, error)
+	MDel(ctx context.Context, in *MDelRequest, opts ...grpc.CallOption) (*MDelReply, error)
+	Delta(ctx context.Context, in *DeltaRequest, opts ...grpc.CallOption) (*DeltaReply, error)
+	Checksum(ctx context.Context, in *ChecksumRequest, opts ...grpc.CallOption) (*ChecksumReply, error)
+	Paxos(ctx context.Context, in *PaxosRequest, opts ...grpc.CallOption) (*PaxosReply, error)
+	AcquireReadLease(ctx context.Context, in *AcquireReadLeaseRequest, opts ...grpc.CallOption) (*AcquireReadLeaseReply, error)
+	Update(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error)
+	Upsert(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error)
+	LeaseFence(ctx context.Context, in *LeaseFenceRequest, opts ...grpc.CallOption) (*LeaseFenceReply, error)
+	Merge(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error)
+}
+
+type storageClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStorageClient(cc *grpc.ClientConn) StorageClient {
+	return &storageClient{cc}
+}
+
+func (c *storageClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error) {
+	out := new(GetReply)
+	err := c.cc.Invoke(ctx, "/Storage/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error) {
+	out := new(PutReply)
+	err := c.cc.Invoke(ctx, "/Storage/Put", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error) {
+	out := new(DelReply)
+	err := c.cc.Invoke(ctx, "/Storage/Del", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanReply, error) {
+	out := new(ScanReply)
+	err := c.cc.Invoke(ctx, "/Storage/Scan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) AcquireLease(ctx context.Context, in *AcquireLeaseRequest, opts ...grpc.CallOption) (*AcquireLeaseReply, error) {
+	out := new(AcquireLeaseReply)
+	err := c.cc.Invoke(ctx, "/Storage/AcquireLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) ReleaseLease(ctx context.Context, in *ReleaseLeaseRequest, opts ...grpc.CallOption) (*ReleaseLeaseReply, error) {
+	out := new(ReleaseLeaseReply)
+	err := c.cc.Invoke(ctx, "/Storage/ReleaseLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Sequence(ctx context.Context, in *SequenceRequest, opts ...grpc.CallOption) (*SequenceReply, error) {
+	out := new(SequenceReply)
+	err := c.cc.Invoke(ctx, "/Storage/Sequence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error) {
+	out := new(StatsReply)
+	err := c.cc.Invoke(ctx, "/Storage/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionReply, error) {
+	out := new(GetVersionReply)
+	err := c.cc.Invoke(ctx, "/Storage/GetVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsReply, error) {
+	out := new(ListVersionsReply)
+	err := c.cc.Invoke(ctx, "/Storage/ListVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error) {
+	out := new(SnapshotReply)
+	err := c.cc.Invoke(ctx, "/Storage/Snapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) ScanSnapshot(ctx context.Context, in *ScanSnapshotRequest, opts ...grpc.CallOption) (*ScanReply, error) {
+	out := new(ScanReply)
+	err := c.cc.Invoke(ctx, "/Storage/ScanSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) ScanChanges(ctx context.Context, in *ScanChangesRequest, opts ...grpc.CallOption) (*ScanReply, error) {
+	out := new(ScanReply)
+	err := c.cc.Invoke(ctx, "/Storage/ScanChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveReply, error) {
+	out := new(ReserveReply)
+	err := c.cc.Invoke(ctx, "/Storage/Reserve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationReply, error) {
+	out := new(CancelReservationReply)
+	err := c.cc.Invoke(ctx, "/Storage/CancelReservation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetReply, error) {
+	out := new(MGetReply)
+	err := c.cc.Invoke(ctx, "/Storage/MGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) MPut(ctx context.Context, in *MPutRequest, opts ...grpc.CallOption) (*MPutReply, error) {
+	out := new(MPutReply)
+	err := c.cc.Invoke(ctx, "/Storage/MPut", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) MDel(ctx context.Context, in *MDelRequest, opts ...grpc.CallOption) (*MDelReply, error) {
+	out := new(MDelReply)
+	err := c.cc.Invoke(ctx, "/Storage/MDel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Delta(ctx context.Context, in *DeltaRequest, opts ...grpc.CallOption) (*DeltaReply, error) {
+	out := new(DeltaReply)
+	err := c.cc.Invoke(ctx, "/Storage/Delta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Checksum(ctx context.Context, in *ChecksumRequest, opts ...grpc.CallOption) (*ChecksumReply, error) {
+	out := new(ChecksumReply)
+	err := c.cc.Invoke(ctx, "/Storage/Checksum", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Paxos(ctx context.Context, in *PaxosRequest, opts ...grpc.CallOption) (*PaxosReply, error) {
+	out := new(PaxosReply)
+	err := c.cc.Invoke(ctx, "/Storage/Paxos", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) AcquireReadLease(ctx context.Context, in *AcquireReadLeaseRequest, opts ...grpc.CallOption) (*AcquireReadLeaseReply, error) {
+	out := new(AcquireReadLeaseReply)
+	err := c.cc.Invoke(ctx, "/Storage/AcquireReadLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Update(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error) {
+	out := new(PutReply)
+	err := c.cc.Invoke(ctx, "/Storage/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Upsert(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error) {
+	out := new(PutReply)
+	err := c.cc.Invoke(ctx, "/Storage/Upsert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) LeaseFence(ctx context.Context, in *LeaseFenceRequest, opts ...grpc.CallOption) (*LeaseFenceReply, error) {
+	out := new(LeaseFenceReply)
+	err := c.cc.Invoke(ctx, "/Storage/LeaseFence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Merge(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error) {
+	out := new(PutReply)
+	err := c.cc.Invoke(ctx, "/Storage/Merge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StorageServer is the server API for Storage service.
+type StorageServer interface {
+	Get(context.Context, *GetRequest) (*GetReply, error)
+	Put(context.Context, *PutRequest) (*PutReply, error)
+	Del(context.Context, *DelRequest) (*DelReply, error)
+	Scan(context.Context, *ScanRequest) (*ScanReply, error)
+	AcquireLease(context.Context, *AcquireLeaseRequest) (*AcquireLeaseReply, error)
+	ReleaseLease(context.Context, *ReleaseLeaseRequest) (*ReleaseLeaseReply, error)
+	Sequence(context.Context, *SequenceRequest) (*SequenceReply, error)
+	Stats(context.Context, *StatsRequest) (*StatsReply, error)
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionReply, error)
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsReply, error)
+	Snapshot(context.Context, *SnapshotRequest) (*SnapshotReply, error)
+	ScanSnapshot(context.Context, *ScanSnapshotRequest) (*ScanReply, error)
+	ScanChanges(context.Context, *ScanChangesRequest) (*ScanReply, error)
+	Reserve(context.Context, *ReserveRequest) (*ReserveReply, error)
+	CancelReservation(context.Context, *CancelReservationRequest) (*CancelReservationReply, error)
+	MGet(context.Context, *MGetRequest) (*MGetReply, error)
+	MPut(context.Context, *MPutRequest) (*MPutReply, error)
+	MDel(context.Context, *MDelRequest) (*MDelReply, error)
+	Delta(context.Context, *DeltaRequest) (*DeltaReply, error)
+	Checksum(context.Context, *ChecksumRequest) (*ChecksumReply, error)
+	Paxos(context.Context, *PaxosRequest) (*PaxosReply, error)
+	AcquireReadLease(context.Context, *AcquireReadLeaseRequest) (*AcquireReadLeaseReply, error)
+	Update(context.Context, *PutRequest) (*PutReply, error)
+	Upsert(context.Context, *PutRequest) (*PutReply, error)
+	LeaseFence(context.Context, *LeaseFenceRequest) (*LeaseFenceReply, error)
+	Merge(context.Context, *PutRequest) (*PutReply, error)
+}
+
+func RegisterStorageServer(s *grpc.Server, srv StorageServer) {
+	s.RegisterService(&_Storage_serviceDesc, srv)
+}
+
+func _Storage_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Put",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Put(ctx, req.(*PutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Del_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Del(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Del",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Del(ctx, req.(*DelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_AcquireLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).AcquireLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/AcquireLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).AcquireLease(ctx, req.(*AcquireLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_ReleaseLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).ReleaseLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/ReleaseLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).ReleaseLease(ctx, req.(*ReleaseLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Sequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SequenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Sequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Sequence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Sequence(ctx, req.(*SequenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/GetVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/ListVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func _Storage_Snapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Snapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Snapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Snapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func _Storage_ScanSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).ScanSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/ScanSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).ScanSnapshot(ctx, req.(*ScanSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-// StorageClient is the client API for Storage service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type StorageClient interface {
-	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error)
-	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error)
-	Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error)
+func _Storage_ScanChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).ScanChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/ScanChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).ScanChanges(ctx, req.(*ScanChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type storageClient struct {
-	cc *grpc.ClientConn
+func _Storage_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Reserve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Reserve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Reserve(ctx, req.(*ReserveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func NewStorageClient(cc *grpc.ClientConn) StorageClient {
-	return &storageClient{cc}
+func _Storage_CancelReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).CancelReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/CancelReservation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).CancelReservation(ctx, req.(*CancelReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *storageClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error) {
-	out := new(GetReply)
-	err := c.cc.Invoke(ctx, "/Storage/Get", in, out, opts...)
-	if err != nil {
+func _Storage_MGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MGetRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(StorageServer).MGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/MGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).MGet(ctx, req.(*MGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *storageClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutReply, error) {
-	out := new(PutReply)
-	err := c.cc.Invoke(ctx, "/Storage/Put", in, out, opts...)
-	if err != nil {
+func _Storage_MPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MPutRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(StorageServer).MPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/MPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).MPut(ctx, req.(*MPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *storageClient) Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error) {
-	out := new(DelReply)
-	err := c.cc.Invoke(ctx, "/Storage/Del", in, out, opts...)
-	if err != nil {
+func _Storage_MDel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MDelRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(StorageServer).MDel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/MDel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).MDel(ctx, req.(*MDelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// StorageServer is the server API for Storage service.
-type StorageServer interface {
-	Get(context.Context, *GetRequest) (*GetReply, error)
-	Put(context.Context, *PutRequest) (*PutReply, error)
-	Del(context.Context, *DelRequest) (*DelReply, error)
+func _Storage_Delta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeltaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Delta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Delta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Delta(ctx, req.(*DeltaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterStorageServer(s *grpc.Server, srv StorageServer) {
-	s.RegisterService(&_Storage_serviceDesc, srv)
+func _Storage_Checksum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChecksumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Checksum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Checksum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Checksum(ctx, req.(*ChecksumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Storage_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
+func _Storage_Paxos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaxosRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageServer).Get(ctx, in)
+		return srv.(StorageServer).Paxos(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Storage/Get",
+		FullMethod: "/Storage/Paxos",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageServer).Get(ctx, req.(*GetRequest))
+		return srv.(StorageServer).Paxos(ctx, req.(*PaxosRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Storage_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Storage_AcquireReadLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireReadLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).AcquireReadLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/AcquireReadLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).AcquireReadLease(ctx, req.(*AcquireReadLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PutRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageServer).Put(ctx, in)
+		return srv.(StorageServer).Update(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Storage/Put",
+		FullMethod: "/Storage/Update",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageServer).Put(ctx, req.(*PutRequest))
+		return srv.(StorageServer).Update(ctx, req.(*PutRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Storage_Del_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DelRequest)
+func _Storage_Upsert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StorageServer).Del(ctx, in)
+		return srv.(StorageServer).Upsert(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Storage/Del",
+		FullMethod: "/Storage/Upsert",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageServer).Del(ctx, req.(*DelRequest))
+		return srv.(StorageServer).Upsert(ctx, req.(*PutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_LeaseFence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseFenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).LeaseFence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/LeaseFence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).LeaseFence(ctx, req.(*LeaseFenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Merge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Merge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Storage/Merge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Merge(ctx, req.(*PutRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -433,28 +3267,191 @@ var _Storage_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Del",
 			Handler:    _Storage_Del_Handler,
 		},
+		{
+			MethodName: "Scan",
+			Handler:    _Storage_Scan_Handler,
+		},
+		{
+			MethodName: "AcquireLease",
+			Handler:    _Storage_AcquireLease_Handler,
+		},
+		{
+			MethodName: "ReleaseLease",
+			Handler:    _Storage_ReleaseLease_Handler,
+		},
+		{
+			MethodName: "Sequence",
+			Handler:    _Storage_Sequence_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Storage_Stats_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _Storage_GetVersion_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _Storage_ListVersions_Handler,
+		},
+		{
+			MethodName: "Snapshot",
+			Handler:    _Storage_Snapshot_Handler,
+		},
+		{
+			MethodName: "ScanSnapshot",
+			Handler:    _Storage_ScanSnapshot_Handler,
+		},
+		{
+			MethodName: "ScanChanges",
+			Handler:    _Storage_ScanChanges_Handler,
+		},
+		{
+			MethodName: "Reserve",
+			Handler:    _Storage_Reserve_Handler,
+		},
+		{
+			MethodName: "CancelReservation",
+			Handler:    _Storage_CancelReservation_Handler,
+		},
+		{
+			MethodName: "MGet",
+			Handler:    _Storage_MGet_Handler,
+		},
+		{
+			MethodName: "MPut",
+			Handler:    _Storage_MPut_Handler,
+		},
+		{
+			MethodName: "MDel",
+			Handler:    _Storage_MDel_Handler,
+		},
+		{
+			MethodName: "Delta",
+			Handler:    _Storage_Delta_Handler,
+		},
+		{
+			MethodName: "Checksum",
+			Handler:    _Storage_Checksum_Handler,
+		},
+		{
+			MethodName: "Paxos",
+			Handler:    _Storage_Paxos_Handler,
+		},
+		{
+			MethodName: "AcquireReadLease",
+			Handler:    _Storage_AcquireReadLease_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Storage_Update_Handler,
+		},
+		{
+			MethodName: "Upsert",
+			Handler:    _Storage_Upsert_Handler,
+		},
+		{
+			MethodName: "LeaseFence",
+			Handler:    _Storage_LeaseFence_Handler,
+		},
+		{
+			MethodName: "Merge",
+			Handler:    _Storage_Merge_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb.proto",
 }
 
-func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_fd9dd68e056fbc00) }
-
-var fileDescriptor_pb_fd9dd68e056fbc00 = []byte{
-	// 226 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x28, 0x48, 0xd2, 0x2b,
-	0x28, 0xca, 0x2f, 0xc9, 0x57, 0x92, 0xe3, 0xe2, 0x72, 0x4f, 0x2d, 0x09, 0x4a, 0x2d, 0x2c, 0x4d,
-	0x2d, 0x2e, 0x11, 0x12, 0xe0, 0x62, 0xce, 0x4e, 0xad, 0x94, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0d,
-	0x02, 0x31, 0x95, 0x7c, 0xb8, 0x38, 0xc0, 0xf2, 0x05, 0x39, 0x95, 0x42, 0x62, 0x5c, 0x6c, 0xc5,
-	0x25, 0x89, 0x25, 0xa5, 0xc5, 0x60, 0x05, 0xac, 0x41, 0x50, 0x9e, 0x90, 0x08, 0x17, 0x6b, 0x6a,
-	0x51, 0x51, 0x7e, 0x91, 0x04, 0x93, 0x02, 0xa3, 0x06, 0x67, 0x10, 0x84, 0x23, 0x24, 0xc4, 0xc5,
-	0x92, 0x92, 0x58, 0x92, 0x28, 0xc1, 0xac, 0xc0, 0xa8, 0xc1, 0x13, 0x04, 0x66, 0x2b, 0x19, 0x71,
-	0x71, 0x05, 0x94, 0xe2, 0xb6, 0x0d, 0xae, 0x87, 0x09, 0x49, 0x8f, 0x05, 0x17, 0x07, 0x58, 0x0f,
-	0xc9, 0x2e, 0x00, 0xf9, 0xcd, 0x25, 0x35, 0x07, 0xb7, 0xdf, 0x2c, 0xb8, 0x38, 0xc0, 0xf2, 0x24,
-	0x9b, 0x6c, 0x94, 0xc3, 0xc5, 0x1e, 0x5c, 0x92, 0x5f, 0x94, 0x98, 0x9e, 0x2a, 0x24, 0xcf, 0xc5,
-	0xec, 0x9e, 0x5a, 0x22, 0xc4, 0xad, 0x87, 0x08, 0x46, 0x29, 0x4e, 0x3d, 0x58, 0x98, 0x29, 0x31,
-	0x80, 0x14, 0x04, 0x94, 0x82, 0x14, 0x20, 0x7c, 0x2e, 0xc5, 0xa9, 0x07, 0xf3, 0x12, 0x44, 0x81,
-	0x4b, 0x6a, 0x8e, 0x10, 0xb7, 0x1e, 0xc2, 0xb1, 0x52, 0x9c, 0x7a, 0x30, 0x97, 0x29, 0x31, 0x24,
-	0xb1, 0x81, 0xa3, 0xca, 0x18, 0x10, 0x00, 0x00, 0xff, 0xff, 0x38, 0xe5, 0xcc, 0x66, 0xb6, 0x01,
-	0x00, 0x00,
+func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_02aa2e8d30f72089) }
+
+var fileDescriptor_pb_02aa2e8d30f72089 = []byte{
+	// 1364 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x5b, 0x6f, 0xdb, 0xb6,
+	0x17, 0xb7, 0x63, 0xc7, 0x97, 0xe3, 0x6b, 0x98, 0xfc, 0x53, 0xfd, 0x85, 0x5d, 0x52, 0x0e, 0xc1,
+	0x02, 0x6c, 0xe0, 0x86, 0xac, 0xc0, 0x8a, 0x76, 0x43, 0x51, 0xb4, 0xe8, 0x05, 0x48, 0x36, 0x83,
+	0x46, 0xb7, 0xa7, 0x6e, 0x50, 0x64, 0x36, 0x16, 0x2a, 0x5b, 0x8a, 0x28, 0x75, 0xcd, 0x5e, 0xf7,
+	0x8d, 0xf6, 0xb2, 0x8f, 0xb0, 0xaf, 0x35, 0xf0, 0x22, 0x89, 0xb6, 0x64, 0x6f, 0xca, 0xb2, 0x37,
+	0x1e, 0xea, 0xf0, 0xdc, 0x48, 0xfe, 0xce, 0x8f, 0x82, 0x4e, 0x78, 0x41, 0xc2, 0x28, 0x88, 0x03,
+	0xfc, 0x11, 0xc0, 0x73, 0x16, 0x53, 0x76, 0x95, 0x30, 0x1e, 0xa3, 0x31, 0x34, 0xde, 0xb2, 0x6b,
+	0xab, 0x7e, 0x54, 0x3f, 0x19, 0x50, 0x31, 0xc4, 0x67, 0xd0, 0x91, 0xdf, 0x43, 0xff, 0x1a, 0x1d,
+	0x42, 0x8b, 0xc7, 0x4e, 0x9c, 0x70, 0xa9, 0xb0, 0x4b, 0xb5, 0x84, 0x0e, 0x60, 0x97, 0x45, 0x51,
+	0x10, 0x59, 0x3b, 0x47, 0xf5, 0x93, 0x2e, 0x55, 0x02, 0x42, 0xd0, 0x9c, 0x39, 0xb1, 0x63, 0x35,
+	0x8e, 0xea, 0x27, 0x7d, 0x2a, 0xc7, 0xf8, 0x14, 0x60, 0x92, 0x6c, 0xf6, 0x96, 0xad, 0xd9, 0x31,
+	0xd6, 0xdc, 0x87, 0xce, 0x24, 0xb9, 0x49, 0x04, 0x22, 0xb7, 0xa7, 0xcc, 0xdf, 0x9c, 0xdb, 0x7d,
+	0xe8, 0xc8, 0xef, 0xd5, 0x2d, 0xbf, 0x80, 0x16, 0x65, 0x6e, 0x10, 0xcd, 0xfe, 0x59, 0x0e, 0xc8,
+	0x82, 0xf6, 0x8c, 0xf9, 0x2c, 0x66, 0x33, 0x59, 0x8e, 0x0e, 0x4d, 0x45, 0xfc, 0x10, 0x7a, 0x53,
+	0xd7, 0x59, 0xa6, 0x41, 0x1e, 0x42, 0xcb, 0x4d, 0x22, 0x1e, 0x44, 0xd2, 0x62, 0x9f, 0x6a, 0x49,
+	0x84, 0xe1, 0x7b, 0x0b, 0x2f, 0x96, 0x56, 0x07, 0x54, 0x09, 0x38, 0x84, 0xae, 0x5a, 0x5c, 0x7d,
+	0x77, 0xee, 0x42, 0x3b, 0x92, 0x19, 0x70, 0xab, 0x71, 0xd4, 0x38, 0xe9, 0x9d, 0xb6, 0x89, 0xca,
+	0x88, 0xa6, 0xf3, 0x22, 0x91, 0x25, 0x7b, 0x1f, 0x5b, 0x4d, 0x95, 0x88, 0x18, 0xe3, 0x4b, 0xd8,
+	0x7f, 0xec, 0x5e, 0x25, 0x5e, 0xc4, 0xce, 0x98, 0xc3, 0xd9, 0xe6, 0x9d, 0x3c, 0x84, 0xd6, 0x3c,
+	0xf0, 0x67, 0x4c, 0xb9, 0x6d, 0x52, 0x2d, 0x09, 0xcd, 0x38, 0xf6, 0x65, 0x15, 0x1a, 0x54, 0x0c,
+	0x45, 0x7c, 0x6f, 0xd8, 0xd2, 0x65, 0xd2, 0x4f, 0x93, 0x2a, 0x01, 0xff, 0x08, 0x7b, 0xab, 0x8e,
+	0xaa, 0xa7, 0x98, 0x19, 0x6e, 0x98, 0x86, 0x1f, 0xc1, 0x3e, 0x65, 0xbe, 0xb0, 0x79, 0xb3, 0x0c,
+	0xf0, 0x63, 0xd8, 0x5b, 0x35, 0x50, 0xfd, 0xf8, 0x3c, 0x84, 0xd1, 0x54, 0xf8, 0x5d, 0xba, 0x99,
+	0x7f, 0x51, 0x6c, 0x67, 0xc1, 0xe4, 0xf2, 0x2e, 0x95, 0x63, 0x99, 0x80, 0x1f, 0x04, 0x69, 0x00,
+	0x4a, 0xc0, 0x53, 0x18, 0xe4, 0x8b, 0x6f, 0x54, 0x95, 0x77, 0x8e, 0x9f, 0x64, 0x55, 0x91, 0x02,
+	0x1e, 0x42, 0x7f, 0x1a, 0x3b, 0x31, 0xd7, 0xe1, 0xe0, 0x3f, 0xea, 0x00, 0x7a, 0xa2, 0xba, 0x0b,
+	0xcb, 0x3c, 0x5b, 0xc2, 0x49, 0x2a, 0x0a, 0xfd, 0x8b, 0xeb, 0x98, 0xf1, 0x74, 0xaf, 0xa5, 0x80,
+	0x6c, 0xe8, 0x44, 0xca, 0x2f, 0xb7, 0x76, 0xe5, 0x87, 0x4c, 0x46, 0x5f, 0x00, 0x88, 0x5a, 0xf0,
+	0xd0, 0x71, 0x19, 0xb7, 0x5a, 0xf2, 0xa8, 0x8e, 0xc8, 0x77, 0xe9, 0x94, 0x8a, 0xd1, 0x50, 0xc1,
+	0x8f, 0x60, 0xef, 0x39, 0x8b, 0x7f, 0x60, 0x11, 0xf7, 0x82, 0xe5, 0xe6, 0xdd, 0xb5, 0xa0, 0xfd,
+	0x4e, 0xe9, 0xe8, 0xea, 0xa6, 0x22, 0x9e, 0xc2, 0xc8, 0x34, 0x70, 0x3b, 0xc0, 0xf7, 0x29, 0xec,
+	0x9f, 0x79, 0x3c, 0xb5, 0xca, 0x37, 0x63, 0xd2, 0x6b, 0xd8, 0x5b, 0x55, 0xac, 0xee, 0xdf, 0x86,
+	0x8e, 0xce, 0x45, 0xdd, 0xed, 0x26, 0xcd, 0x64, 0xfc, 0x12, 0x46, 0xd3, 0xa5, 0x13, 0xf2, 0x79,
+	0x90, 0xa1, 0xf0, 0x10, 0x76, 0xbc, 0x99, 0x34, 0xdc, 0xa4, 0x3b, 0xde, 0x4c, 0x18, 0x0d, 0xe7,
+	0x0e, 0x67, 0xd2, 0xe8, 0x2e, 0x55, 0x42, 0xf1, 0xde, 0xe2, 0x6f, 0x61, 0x90, 0x9b, 0xaa, 0x7e,
+	0x07, 0xa6, 0xb0, 0x2f, 0xb0, 0xeb, 0xef, 0xa2, 0xc9, 0x01, 0x71, 0xa7, 0x1c, 0x10, 0x1b, 0x26,
+	0x20, 0xce, 0x01, 0x09, 0xa3, 0x4f, 0xe6, 0xce, 0xf2, 0x92, 0xf1, 0x2d, 0x19, 0x72, 0x4f, 0x00,
+	0x83, 0xbe, 0x57, 0x52, 0x30, 0x3c, 0x35, 0xca, 0x3d, 0x35, 0x4d, 0x4f, 0x2f, 0x60, 0x48, 0x19,
+	0x67, 0xd1, 0x3b, 0xb6, 0xb5, 0x9b, 0x71, 0xef, 0x57, 0xe5, 0xa6, 0x41, 0xe5, 0xb8, 0xa4, 0x8e,
+	0xdf, 0x40, 0x3f, 0xb3, 0x54, 0xbd, 0x8c, 0x9f, 0x83, 0xf5, 0xc4, 0x59, 0xba, 0xcc, 0x57, 0x36,
+	0x9c, 0x78, 0xdb, 0xa9, 0xc7, 0xcf, 0xe0, 0xb0, 0x44, 0xbb, 0xba, 0xd7, 0xbb, 0xd0, 0x3b, 0x37,
+	0x68, 0x03, 0x82, 0xe6, 0x5b, 0x76, 0x2d, 0x96, 0x36, 0x4e, 0x06, 0x54, 0x8e, 0xf1, 0x4f, 0xd0,
+	0x3d, 0xbf, 0x21, 0x73, 0xf8, 0x64, 0xbd, 0x37, 0x75, 0x49, 0x6a, 0x29, 0x83, 0x12, 0x7c, 0x0f,
+	0x7a, 0xe7, 0x06, 0x97, 0x38, 0xce, 0xd7, 0xd4, 0xe5, 0x9a, 0x1e, 0xc9, 0xbf, 0xe6, 0xab, 0x44,
+	0x54, 0x93, 0xe4, 0xb6, 0xa2, 0x4a, 0x2d, 0xe5, 0xf6, 0x45, 0x61, 0x0c, 0xce, 0xb1, 0xa9, 0x30,
+	0x37, 0xa3, 0x1d, 0x65, 0x21, 0xa4, 0x96, 0xf2, 0x10, 0x1e, 0x40, 0xff, 0x29, 0xf3, 0x63, 0x27,
+	0x8d, 0x21, 0x3b, 0xed, 0x75, 0xf3, 0xb4, 0x97, 0x13, 0x8a, 0xdf, 0xea, 0x00, 0x7a, 0xf1, 0x7f,
+	0x42, 0x29, 0xc6, 0xd0, 0xe0, 0xec, 0x4a, 0xa3, 0xbf, 0x18, 0x8a, 0x0a, 0x2d, 0x82, 0x88, 0x49,
+	0xdc, 0xef, 0x50, 0x39, 0xc6, 0x5f, 0xc3, 0xe8, 0xc9, 0x9c, 0xb9, 0x6f, 0x79, 0xb2, 0xd8, 0x7a,
+	0xb9, 0xe6, 0x0e, 0x9f, 0xeb, 0x10, 0xe4, 0x18, 0x7f, 0x0f, 0x83, 0x7c, 0x61, 0xf5, 0x04, 0x44,
+	0x74, 0xc9, 0x42, 0x5f, 0x7f, 0x31, 0xc4, 0x13, 0xe8, 0x4c, 0xa2, 0x20, 0x0c, 0xb8, 0xe3, 0x0b,
+	0x5b, 0x17, 0x8e, 0xef, 0x07, 0xb1, 0x2e, 0xa4, 0x96, 0x2a, 0xf2, 0xbd, 0xd7, 0xd0, 0x9f, 0x38,
+	0xef, 0x83, 0xcd, 0x1d, 0x60, 0x03, 0xfe, 0x1e, 0x43, 0x27, 0xd4, 0x91, 0x48, 0x93, 0xf2, 0xf8,
+	0xe9, 0x09, 0x9a, 0x7d, 0xc2, 0x7f, 0xd6, 0x01, 0xb4, 0xfd, 0x1b, 0x35, 0x8e, 0x30, 0x0a, 0x16,
+	0x1e, 0xd7, 0x61, 0x37, 0x69, 0x26, 0x0b, 0xff, 0x8e, 0xeb, 0xb2, 0x50, 0xa4, 0xd4, 0x2c, 0xf8,
+	0x4f, 0x3f, 0xa1, 0x0f, 0xa0, 0xeb, 0x06, 0x8b, 0x85, 0x17, 0x0b, 0x3d, 0xd5, 0xcb, 0xf3, 0x89,
+	0x9c, 0x7b, 0xb4, 0x64, 0xad, 0x94, 0x20, 0x69, 0x4e, 0x90, 0x2c, 0x67, 0x56, 0x5b, 0x96, 0x4a,
+	0x09, 0xf8, 0x15, 0xdc, 0xd1, 0x04, 0x90, 0x32, 0x67, 0x76, 0x5b, 0x6c, 0x13, 0x5f, 0xc1, 0xff,
+	0x8a, 0x66, 0xff, 0x25, 0x8b, 0x2a, 0x66, 0xd2, 0x34, 0x33, 0x39, 0x86, 0x3d, 0xe9, 0xe7, 0x99,
+	0xc9, 0xf7, 0x8a, 0xd8, 0xfc, 0x0a, 0x46, 0xa6, 0xda, 0x6d, 0xf1, 0xdd, 0x5f, 0x60, 0xb8, 0xca,
+	0x96, 0x84, 0xd5, 0x30, 0x62, 0x6f, 0xbc, 0xf7, 0xda, 0xbb, 0x96, 0xd0, 0x87, 0x00, 0x6a, 0xf4,
+	0xb3, 0xcf, 0x96, 0x1a, 0x17, 0xba, 0x6a, 0xe6, 0x8c, 0x2d, 0xab, 0xb2, 0xba, 0xd3, 0xdf, 0xbb,
+	0xd0, 0x9e, 0xc6, 0x41, 0xe4, 0x5c, 0x32, 0xf4, 0x31, 0x34, 0x9e, 0xb3, 0x18, 0xf5, 0x48, 0xde,
+	0x34, 0xec, 0x1c, 0xd4, 0x71, 0x4d, 0x28, 0x4c, 0x12, 0xa1, 0x90, 0x83, 0xb6, 0x9d, 0xe3, 0xab,
+	0x52, 0x78, 0xca, 0x7c, 0xd4, 0x23, 0x39, 0xba, 0xda, 0x39, 0xfa, 0xe1, 0x1a, 0xc2, 0xd0, 0x14,
+	0xad, 0x1f, 0xf5, 0x89, 0xf1, 0x9e, 0xb2, 0x81, 0x64, 0x0f, 0x24, 0x5c, 0x43, 0x0f, 0xa0, 0x6f,
+	0x3e, 0x2a, 0xd0, 0x01, 0x29, 0x79, 0xcc, 0xd8, 0x88, 0x14, 0x5e, 0x1e, 0x6a, 0xad, 0x49, 0xfb,
+	0xd1, 0x01, 0x29, 0x79, 0x46, 0xd8, 0x88, 0x14, 0xde, 0x06, 0xb8, 0x86, 0x08, 0x74, 0x52, 0xca,
+	0x8e, 0xc6, 0x64, 0x8d, 0xfa, 0xdb, 0x43, 0xb2, 0xc2, 0xe7, 0x71, 0x0d, 0x1d, 0xc3, 0xae, 0xda,
+	0xaa, 0x01, 0x31, 0x59, 0xb9, 0xdd, 0x23, 0x39, 0x27, 0xc7, 0x35, 0x74, 0x4f, 0xbe, 0xdd, 0x35,
+	0x55, 0x44, 0x88, 0x14, 0x78, 0xaf, 0x3d, 0x26, 0x6b, 0x54, 0x56, 0x25, 0x62, 0x32, 0x4c, 0x74,
+	0x40, 0x4a, 0x98, 0xa9, 0x8d, 0x48, 0x81, 0x86, 0xea, 0x44, 0x34, 0x61, 0x13, 0x89, 0xac, 0x72,
+	0x37, 0x7b, 0x68, 0xcc, 0x28, 0xfd, 0x53, 0xe8, 0x9b, 0x24, 0x0f, 0x1d, 0x90, 0x12, 0xce, 0xb7,
+	0xb6, 0x49, 0x5f, 0x42, 0xcf, 0xe0, 0x70, 0x68, 0x9f, 0x14, 0x19, 0xdd, 0xda, 0x8a, 0xcf, 0xa0,
+	0xad, 0x19, 0x14, 0x1a, 0x91, 0x55, 0x56, 0x66, 0x0f, 0x88, 0x49, 0xae, 0x70, 0x0d, 0xbd, 0x84,
+	0xbd, 0x02, 0x05, 0x42, 0xff, 0x27, 0x9b, 0x48, 0x94, 0x7d, 0x87, 0x94, 0x33, 0x26, 0x75, 0xe4,
+	0x04, 0xc5, 0x41, 0x7d, 0x62, 0x90, 0x21, 0x1b, 0xc8, 0xb9, 0x71, 0xb0, 0x85, 0x8e, 0x38, 0xd9,
+	0x7d, 0x62, 0xb0, 0x15, 0x1b, 0xb4, 0x94, 0xeb, 0x88, 0xc3, 0xdd, 0x27, 0x06, 0x77, 0xb0, 0x41,
+	0x4b, 0xd9, 0x91, 0x90, 0x8d, 0x19, 0x0d, 0x88, 0xd9, 0xdd, 0xed, 0x1e, 0xc9, 0xfb, 0xb5, 0xda,
+	0xa0, 0xb4, 0x03, 0xa2, 0x31, 0x59, 0xeb, 0xa2, 0xf6, 0x90, 0xac, 0xb4, 0x47, 0x65, 0x56, 0xb6,
+	0x0b, 0x34, 0x20, 0x66, 0x5b, 0xb2, 0x7b, 0x24, 0xef, 0x22, 0xb8, 0x86, 0x9e, 0xc1, 0x78, 0x1d,
+	0x35, 0x91, 0x45, 0x36, 0xe0, 0xb3, 0x7d, 0x48, 0x4a, 0x21, 0x56, 0x66, 0xda, 0x7a, 0x15, 0xce,
+	0x9c, 0x98, 0x6d, 0xb9, 0xe9, 0x52, 0x87, 0xb3, 0x68, 0x1b, 0x1a, 0xdc, 0x03, 0xc8, 0xb1, 0x12,
+	0x21, 0x52, 0xc0, 0x57, 0x7b, 0x4c, 0xd6, 0xc0, 0x14, 0xd7, 0xd0, 0x5d, 0xd8, 0x3d, 0x67, 0xd1,
+	0xe5, 0x16, 0xe7, 0x17, 0x2d, 0xf9, 0x57, 0xec, 0xab, 0xbf, 0x06, 0x00, 0xc7, 0x34, 0x9e, 0xfa,
+	0x21, 0x13, 0x00, 0x00,
 }
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Resolver maps a logical ServiceAddr to a network address to dial,
+// so names of services stay stable while their IPs change.
+type Resolver interface {
+	Resolve(addr ServiceAddr) (string, error)
+}
+
+// ResolverConfig selects a Resolver. Kind is one of "static", "dns",
+// "consul" or "etcd", addresses are dialed as is if it is empty.
+type ResolverConfig struct {
+	Kind string
+	// Static maps names to addresses for the static resolver.
+	Static map[ServiceAddr]string
+	// Addr is an address of the consul agent or the etcd gateway.
+	Addr string
+	// Prefix is prepended to names to build etcd keys.
+	Prefix string
+	// Service and Proto make the dns resolver look up SRV records
+	// _Service._Proto.name instead of A records.
+	Service string
+	Proto   string
+	// TTL is a time to cache resolved addresses for, 0 disables caching.
+	TTL time.Duration
+}
+
+// NewResolver creates a Resolver described by cfg. Returns nil
+// if cfg.Kind is empty.
+func NewResolver(cfg ResolverConfig) (Resolver, error) {
+	var r Resolver
+	switch cfg.Kind {
+	case "":
+		return nil, nil
+	case "static":
+		r = StaticResolver(cfg.Static)
+	case "dns":
+		r = DNSResolver{Service: cfg.Service, Proto: cfg.Proto}
+	case "consul":
+		r = ConsulResolver{Addr: cfg.Addr}
+	case "etcd":
+		r = EtcdResolver{Addr: cfg.Addr, Prefix: cfg.Prefix}
+	default:
+		return nil, fmt.Errorf("Unknown resolver %q", cfg.Kind)
+	}
+	if cfg.TTL > 0 {
+		r = NewCachingResolver(r, cfg.TTL)
+	}
+	return r, nil
+}
+
+// ResolveAddr resolves addr with r or returns it as is if r is nil.
+func ResolveAddr(r Resolver, addr ServiceAddr) (string, error) {
+	if r == nil {
+		return string(addr), nil
+	}
+	target, err := r.Resolve(addr)
+	if err != nil {
+		return "", fmt.Errorf("Error resolving %q: %v", addr, err)
+	}
+	return target, nil
+}
+
+// StaticResolver resolves names using a fixed table. Names missing
+// in the table are returned as is.
+type StaticResolver map[ServiceAddr]string
+
+func (r StaticResolver) Resolve(addr ServiceAddr) (string, error) {
+	if target, ok := r[addr]; ok {
+		return target, nil
+	}
+	return string(addr), nil
+}
+
+// DNSResolver resolves names of the form host:port with A records of host
+// or, if Service is set, names of the form host with SRV records.
+type DNSResolver struct {
+	Service string
+	Proto   string
+}
+
+func (r DNSResolver) Resolve(addr ServiceAddr) (string, error) {
+	if r.Service != "" {
+		_, srvs, err := net.LookupSRV(r.Service, r.Proto, string(addr))
+		if err != nil {
+			return "", err
+		}
+		if len(srvs) == 0 {
+			return "", fmt.Errorf("No SRV records")
+		}
+		return net.JoinHostPort(srvs[0].Target, strconv.Itoa(int(srvs[0].Port))), nil
+	}
+
+	host, port, err := net.SplitHostPort(string(addr))
+	if err != nil {
+		return "", err
+	}
+	ips, err := net.LookupHost(host)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ips[0], port), nil
+}
+
+var httpClient = http.Client{Timeout: Timeout}
+
+// ConsulResolver resolves names as services registered in consul,
+// returning the address of the first healthy instance.
+type ConsulResolver struct {
+	Addr string
+}
+
+func (r ConsulResolver) Resolve(addr ServiceAddr) (string, error) {
+	resp, err := httpClient.Get(fmt.Sprintf("http://%s/v1/health/service/%s?passing", r.Addr, addr))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Consul replied %v", resp.Status)
+	}
+
+	var entries []struct {
+		Node struct {
+			Address string
+		}
+		Service struct {
+			Address string
+			Port    int
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("No healthy instances")
+	}
+
+	host := entries[0].Service.Address
+	if host == "" {
+		host = entries[0].Node.Address
+	}
+	return net.JoinHostPort(host, strconv.Itoa(entries[0].Service.Port)), nil
+}
+
+// EtcdResolver resolves names with values of keys Prefix+name
+// read through the etcd v3 JSON gateway.
+type EtcdResolver struct {
+	Addr   string
+	Prefix string
+}
+
+func (r EtcdResolver) Resolve(addr ServiceAddr) (string, error) {
+	req, err := json.Marshal(map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(r.Prefix + string(addr))),
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Post(fmt.Sprintf("http://%s/v3/kv/range", r.Addr), "application/json", bytes.NewReader(req))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Etcd replied %v", resp.Status)
+	}
+
+	var reply struct {
+		Kvs []struct {
+			Value []byte
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return "", err
+	}
+	if len(reply.Kvs) == 0 {
+		return "", fmt.Errorf("No key %q", r.Prefix+string(addr))
+	}
+	return string(reply.Kvs[0].Value), nil
+}
+
+type cachedAddr struct {
+	target  string
+	expires time.Time
+}
+
+// CachingResolver caches addresses resolved by another Resolver for TTL.
+// Failed resolutions are not cached.
+type CachingResolver struct {
+	r     Resolver
+	ttl   time.Duration
+	cache map[ServiceAddr]cachedAddr
+	lock  sync.Mutex
+}
+
+func NewCachingResolver(r Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		r:     r,
+		ttl:   ttl,
+		cache: make(map[ServiceAddr]cachedAddr),
+	}
+}
+
+func (r *CachingResolver) Resolve(addr ServiceAddr) (string, error) {
+	now := time.Now()
+	r.lock.Lock()
+	cached, ok := r.cache[addr]
+	r.lock.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.target, nil
+	}
+
+	target, err := r.r.Resolve(addr)
+	if err != nil {
+		return "", err
+	}
+	r.lock.Lock()
+	r.cache[addr] = cachedAddr{target: target, expires: now.Add(r.ttl)}
+	r.lock.Unlock()
+	return target, nil
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	n   int
+	err error
+}
+
+func (r *countingResolver) Resolve(addr ServiceAddr) (string, error) {
+	r.n++
+	return "10.0.0.1:7321", r.err
+}
+
+func TestStaticResolver(t *testing.T) {
+	r := StaticResolver{"node1": "10.0.0.1:7321"}
+	for addr, want := range map[ServiceAddr]string{
+		"node1":          "10.0.0.1:7321",
+		"127.0.0.1:7322": "127.0.0.1:7322",
+	} {
+		got, err := r.Resolve(addr)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) got %q, %v, want %q", addr, got, err, want)
+		}
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	cr := &countingResolver{}
+	r := NewCachingResolver(cr, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Resolve("node1"); err != nil {
+			t.Fatalf("Resolve() error: %v", err)
+		}
+	}
+	if cr.n != 1 {
+		t.Errorf("Got %v resolutions, want 1", cr.n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.Resolve("node1")
+	if cr.n != 2 {
+		t.Errorf("Got %v resolutions after TTL, want 2", cr.n)
+	}
+
+	cr.err = errors.New("unavailable")
+	time.Sleep(60 * time.Millisecond)
+	if _, err := r.Resolve("node1"); err != cr.err {
+		t.Errorf("Resolve() got error %v, want %v", err, cr.err)
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	r, err := NewResolver(ResolverConfig{})
+	if r != nil || err != nil {
+		t.Errorf("NewResolver() got %v, %v, want nil resolver", r, err)
+	}
+	if _, err := NewResolver(ResolverConfig{Kind: "zookeeper"}); err == nil {
+		t.Errorf("NewResolver() of unknown kind got no error")
+	}
+	if got, _ := ResolveAddr(nil, "node1"); got != "node1" {
+		t.Errorf("ResolveAddr() with nil resolver got %q, want %q", got, "node1")
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+)
+
+// PutRetryDelay is a delay before the first retry of PutWithRetry, it
+// doubles with every next one unless the error hints another delay.
+const PutRetryDelay = 50 * time.Millisecond
+
+// PutWithRetry puts the record with key k via node like c.Put, retrying
+// up to attempts times in total while the put fails for reasons which may
+// pass, e.g. ErrQuorumNotReached.
+//
+// A failed Put may still have stored d on some replicas, so a plain retry
+// may fail with ErrRecordExists on them or even overall. PutWithRetry
+// reconciles such retries by value: once an attempt fails, the record is
+// read back and the put succeeds if a quorum of replicas holds d. Retries
+// fail with ErrRecordExists only if the record holds another value, i.e.
+// another writer won. So putting the same value twice is idempotent for
+// PutWithRetry, though not for Put.
+func PutWithRetry(c Client, node ServiceAddr, k RecordID, d []byte, attempts int) error {
+	delay := PutRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := c.Put(node, k, d)
+		if err == nil {
+			return nil
+		}
+		exists := errors.Is(err, ErrRecordExists)
+		if exists && attempt == 1 || !exists && !retryablePut(err) {
+			return err
+		}
+		// Earlier attempts might have put d on enough replicas.
+		if got, gerr := c.Get(node, k); gerr == nil {
+			if bytes.Equal(got, d) {
+				return nil
+			}
+			if exists {
+				return err
+			}
+		}
+		if attempt >= attempts {
+			return err
+		}
+		if after, ok := RetryAfter(err); ok {
+			time.Sleep(after)
+		} else {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// retryablePut reports whether a put failed with err may succeed if
+// retried.
+func retryablePut(err error) bool {
+	for _, target := range []error{
+		ErrQuorumNotReached,
+		ErrNotEnoughDaemons,
+		ErrRouterUnavailable,
+		ErrRateLimited,
+		context.DeadlineExceeded,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+)
+
+// scriptedClient answers Put and Get with the given results in turn.
+type scriptedClient struct {
+	Client
+	puts  []error
+	gets  [][]byte
+	calls int
+}
+
+func (c *scriptedClient) Put(node ServiceAddr, k RecordID, d []byte) error {
+	c.calls++
+	err := c.puts[0]
+	c.puts = c.puts[1:]
+	return err
+}
+
+func (c *scriptedClient) Get(node ServiceAddr, k RecordID) ([]byte, error) {
+	d := c.gets[0]
+	c.gets = c.gets[1:]
+	if d == nil {
+		return nil, ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func TestPutWithRetry(t *testing.T) {
+	value := []byte("value")
+	for _, test := range []struct {
+		name  string
+		puts  []error
+		gets  [][]byte
+		err   error
+		calls int
+	}{
+		{
+			name:  "stored despite error",
+			puts:  []error{ErrQuorumNotReached},
+			gets:  [][]byte{value},
+			calls: 1,
+		},
+		{
+			name:  "partially stored",
+			puts:  []error{ErrQuorumNotReached, ErrRecordExists},
+			gets:  [][]byte{nil, value},
+			calls: 2,
+		},
+		{
+			name:  "another writer won",
+			puts:  []error{ErrQuorumNotReached, ErrRecordExists},
+			gets:  [][]byte{nil, []byte("other")},
+			err:   ErrRecordExists,
+			calls: 2,
+		},
+		{
+			name:  "existed before",
+			puts:  []error{ErrRecordExists},
+			err:   ErrRecordExists,
+			calls: 1,
+		},
+		{
+			name:  "not retryable",
+			puts:  []error{ErrValueTooLarge},
+			err:   ErrValueTooLarge,
+			calls: 1,
+		},
+		{
+			name:  "out of attempts",
+			puts:  []error{ErrQuorumNotReached, ErrQuorumNotReached},
+			gets:  [][]byte{nil, nil},
+			err:   ErrQuorumNotReached,
+			calls: 2,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := &scriptedClient{puts: test.puts, gets: test.gets}
+			if err := PutWithRetry(c, "frontend", 1, value, 2); err != test.err {
+				t.Errorf("PutWithRetry() got error %v, want %v", err, test.err)
+			}
+			if c.calls != test.calls {
+				t.Errorf("PutWithRetry() made %d puts, want %d", c.calls, test.calls)
+			}
+		})
+	}
+}
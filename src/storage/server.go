@@ -18,6 +18,72 @@ type Storage interface {
 	Put(k RecordID, d []byte) error
 	Get(k RecordID) ([]byte, error)
 	Del(k RecordID) error
+	Scan(cursor Cursor, limit int) ([]Record, Cursor, error)
+	AcquireLease(k RecordID, holder, fence uint64, ttl time.Duration) (uint64, error)
+	LeaseFence(k RecordID) (uint64, error)
+	ReleaseLease(k RecordID, holder uint64) error
+	Sequence(name string, floor uint64) (uint64, error)
+	Stats() (Stats, error)
+	GetVersion(k RecordID, version uint64) ([]byte, error)
+	ListVersions(k RecordID) ([]uint64, error)
+	Snapshot(id uint64, phase SnapshotPhase, ttl time.Duration) error
+	ScanSnapshot(id uint64, cursor Cursor, limit int) ([]Record, Cursor, error)
+	ScanChanges(id, since uint64, cursor Cursor, limit int) ([]Record, Cursor, error)
+	Reserve(k RecordID, size int64, ttl time.Duration) error
+	CancelReservation(k RecordID) error
+	MGet(keys []RecordID) ([][]byte, []error)
+	MPut(keys []RecordID, data [][]byte) []error
+	MDel(keys []RecordID) []error
+	Checksum(k RecordID, h Hash) (Checksum, error)
+	Paxos(k RecordID, phase PaxosPhase, p Proposal) (Promise, error)
+	AcquireReadLease(k RecordID, holder uint64, ttl time.Duration) (ReadLease, error)
+	Update(k RecordID, d []byte) error
+	Upsert(k RecordID, d []byte) error
+	Merge(k RecordID, d []byte) error
+}
+
+// ContextStorage is a Storage whose Put, Get and Del stop once the context
+// of the request is done, e.g. when the client cancels it. Server calls
+// them instead of the ones of Storage if st implements it, passing the
+// consistency level of the request with the context, see ConsistencyOf,
+// and the version of a write, see VersionOf.
+type ContextStorage interface {
+	PutContext(ctx context.Context, k RecordID, d []byte) error
+	GetContext(ctx context.Context, k RecordID) ([]byte, error)
+	DelContext(ctx context.Context, k RecordID) error
+}
+
+// ContextUpdater is a Storage whose Update and Upsert take the context of
+// the request. Server calls them instead of the ones of Storage if st
+// implements it, passing the consistency level and the version of
+// the write with the context, see ConsistencyOf and VersionOf.
+type ContextUpdater interface {
+	UpdateContext(ctx context.Context, k RecordID, d []byte) error
+	UpsertContext(ctx context.Context, k RecordID, d []byte) error
+}
+
+// ContextBatcher is a Storage whose MGet, MPut and MDel take the context
+// of the request. Server calls them instead of the ones of Storage if st
+// implements it, passing the consistency level and the version of
+// the writes with the context, see ConsistencyOf and VersionOf.
+type ContextBatcher interface {
+	MGetContext(ctx context.Context, keys []RecordID) ([][]byte, []error)
+	MPutContext(ctx context.Context, keys []RecordID, data [][]byte) []error
+	MDelContext(ctx context.Context, keys []RecordID) []error
+}
+
+// DeltaSource is a Storage keeping a log of its operations numbered by
+// sequence numbers, e.g. a node. Server serves Delta with it if st
+// implements it and returns ErrSeqOutOfRange otherwise, so callers fall
+// back to a full scan.
+type DeltaSource interface {
+	Delta(since uint64, limit int) (Delta, error)
+}
+
+// ContextScanner is a Storage whose Scan stops once the context of the
+// request is done. Server calls it instead of Scan if st implements it.
+type ContextScanner interface {
+	ScanContext(ctx context.Context, cursor Cursor, limit int) ([]Record, Cursor, error)
 }
 
 type Server struct {
@@ -26,11 +92,11 @@ type Server struct {
 	srv  *grpc.Server
 }
 
-func NewServer(st Storage, addr string) *Server {
+func NewServer(st Storage, addr string, opts ...grpc.ServerOption) *Server {
 	return &Server{
 		addr: addr,
 		st:   st,
-		srv:  grpc.NewServer(),
+		srv:  grpc.NewServer(opts...),
 	}
 }
 
@@ -53,16 +119,22 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetReply, err
 	key := RecordID(req.Key)
 	log.Printf("GET request: key = %v", key)
 
-	data, err := s.st.Get(key)
-	status := ErrToStatus(err)
+	var data []byte
+	var err error
+	if cs, ok := s.st.(ContextStorage); ok {
+		data, err = cs.GetContext(incomingConsistency(ctx), key)
+	} else {
+		data, err = s.st.Get(key)
+	}
+	status, msg := MarshalError(err)
 
 	reply := pb.GetReply{
 		Status: int32(status),
 		Data:   data,
 	}
 
-	if status == StatusUnknown {
-		reply.Error = err.Error()
+	if msg != "" {
+		reply.Error = msg
 	}
 
 	return &reply, nil
@@ -72,13 +144,18 @@ func (s *Server) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutReply, err
 	key := RecordID(req.Key)
 	log.Printf("PUT request: key = %v", key)
 
-	err := s.st.Put(key, req.Data)
-	status := ErrToStatus(err)
+	var err error
+	if cs, ok := s.st.(ContextStorage); ok {
+		err = cs.PutContext(incomingVersion(incomingConsistency(ctx)), key, req.Data)
+	} else {
+		err = s.st.Put(key, req.Data)
+	}
+	status, msg := MarshalError(err)
 	reply := pb.PutReply{
 		Status: int32(status),
 	}
-	if status == StatusUnknown {
-		reply.Error = err.Error()
+	if msg != "" {
+		reply.Error = msg
 	}
 	return &reply, nil
 }
@@ -87,13 +164,420 @@ func (s *Server) Del(ctx context.Context, req *pb.DelRequest) (*pb.DelReply, err
 	key := RecordID(req.Key)
 	log.Printf("DEL request: key = %v", key)
 
-	err := s.st.Del(key)
-	status := ErrToStatus(err)
+	var err error
+	if cs, ok := s.st.(ContextStorage); ok {
+		err = cs.DelContext(incomingVersion(incomingConsistency(ctx)), key)
+	} else {
+		err = s.st.Del(key)
+	}
+	status, msg := MarshalError(err)
 	reply := pb.DelReply{
 		Status: int32(status),
 	}
-	if status == StatusUnknown {
-		reply.Error = err.Error()
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) Scan(ctx context.Context, req *pb.ScanRequest) (*pb.ScanReply, error) {
+	log.Printf("SCAN request: limit = %v", req.Limit)
+
+	var records []Record
+	var next Cursor
+	var err error
+	if cs, ok := s.st.(ContextScanner); ok {
+		records, next, err = cs.ScanContext(ctx, Cursor(req.Cursor), int(req.Limit))
+	} else {
+		records, next, err = s.st.Scan(Cursor(req.Cursor), int(req.Limit))
+	}
+	return scanReply(records, next, err), nil
+}
+
+func (s *Server) ScanSnapshot(ctx context.Context, req *pb.ScanSnapshotRequest) (*pb.ScanReply, error) {
+	log.Printf("SCAN SNAPSHOT request: id = %v, limit = %v", req.Id, req.Limit)
+
+	records, next, err := s.st.ScanSnapshot(req.Id, Cursor(req.Cursor), int(req.Limit))
+	return scanReply(records, next, err), nil
+}
+
+func (s *Server) ScanChanges(ctx context.Context, req *pb.ScanChangesRequest) (*pb.ScanReply, error) {
+	log.Printf("SCAN CHANGES request: id = %v, since = %v, limit = %v", req.Id, req.Since, req.Limit)
+
+	records, next, err := s.st.ScanChanges(req.Id, req.Since, Cursor(req.Cursor), int(req.Limit))
+	return scanReply(records, next, err), nil
+}
+
+func scanReply(records []Record, next Cursor, err error) *pb.ScanReply {
+	status, msg := MarshalError(err)
+	reply := pb.ScanReply{
+		Status: int32(status),
+		Next:   next,
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	reply.Records = make([]*pb.Record, 0, len(records))
+	for _, r := range records {
+		reply.Records = append(reply.Records, &pb.Record{
+			Key:     uint32(r.Key),
+			Data:    r.Data,
+			Deleted: r.Deleted,
+		})
+	}
+	return &reply
+}
+
+func (s *Server) AcquireLease(ctx context.Context, req *pb.AcquireLeaseRequest) (*pb.AcquireLeaseReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("ACQUIRE LEASE request: key = %v, holder = %v", key, req.Holder)
+
+	fence, err := s.st.AcquireLease(key, req.Holder, req.Fence, time.Duration(req.Ttl))
+	status, msg := MarshalError(err)
+	reply := pb.AcquireLeaseReply{
+		Status: int32(status),
+		Fence:  fence,
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) LeaseFence(ctx context.Context, req *pb.LeaseFenceRequest) (*pb.LeaseFenceReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("LEASE FENCE request: key = %v", key)
+
+	fence, err := s.st.LeaseFence(key)
+	status, msg := MarshalError(err)
+	reply := pb.LeaseFenceReply{
+		Status: int32(status),
+		Fence:  fence,
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) ReleaseLease(ctx context.Context, req *pb.ReleaseLeaseRequest) (*pb.ReleaseLeaseReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("RELEASE LEASE request: key = %v, holder = %v", key, req.Holder)
+
+	err := s.st.ReleaseLease(key, req.Holder)
+	status, msg := MarshalError(err)
+	reply := pb.ReleaseLeaseReply{
+		Status: int32(status),
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) Sequence(ctx context.Context, req *pb.SequenceRequest) (*pb.SequenceReply, error) {
+	log.Printf("SEQUENCE request: name = %q, floor = %v", req.Name, req.Floor)
+
+	value, err := s.st.Sequence(req.Name, req.Floor)
+	status, msg := MarshalError(err)
+	reply := pb.SequenceReply{
+		Status: int32(status),
+		Value:  value,
+	}
+	if msg != "" {
+		reply.Error = msg
 	}
 	return &reply, nil
 }
+
+func (s *Server) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsReply, error) {
+	log.Printf("STATS request")
+
+	stats, err := s.st.Stats()
+	status, msg := MarshalError(err)
+	reply := pb.StatsReply{
+		Status:   int32(status),
+		Records:  stats.Records,
+		Bytes:    stats.Bytes,
+		Requests: stats.Requests,
+	}
+	for _, n := range stats.Namespaces {
+		reply.Namespaces = append(reply.Namespaces, &pb.NamespaceStats{
+			Prefix:    uint32(n.Prefix.Key),
+			PrefixLen: uint32(n.Prefix.Len),
+			Records:   n.Records,
+			Bytes:     n.Bytes,
+		})
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("GET VERSION request: key = %v, version = %v", key, req.Version)
+
+	data, err := s.st.GetVersion(key, req.Version)
+	status, msg := MarshalError(err)
+	reply := pb.GetVersionReply{
+		Status: int32(status),
+		Data:   data,
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) ListVersions(ctx context.Context, req *pb.ListVersionsRequest) (*pb.ListVersionsReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("LIST VERSIONS request: key = %v", key)
+
+	versions, err := s.st.ListVersions(key)
+	status, msg := MarshalError(err)
+	reply := pb.ListVersionsReply{
+		Status:   int32(status),
+		Versions: versions,
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) Snapshot(ctx context.Context, req *pb.SnapshotRequest) (*pb.SnapshotReply, error) {
+	log.Printf("SNAPSHOT request: id = %v, phase = %v", req.Id, req.Phase)
+
+	err := s.st.Snapshot(req.Id, SnapshotPhase(req.Phase), time.Duration(req.Ttl))
+	status, msg := MarshalError(err)
+	reply := pb.SnapshotReply{
+		Status: int32(status),
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) Reserve(ctx context.Context, req *pb.ReserveRequest) (*pb.ReserveReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("RESERVE request: key = %v, size = %v", key, req.Size)
+
+	err := s.st.Reserve(key, req.Size, time.Duration(req.Ttl))
+	status, msg := MarshalError(err)
+	reply := pb.ReserveReply{
+		Status: int32(status),
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) CancelReservation(ctx context.Context, req *pb.CancelReservationRequest) (*pb.CancelReservationReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("CANCEL RESERVATION request: key = %v", key)
+
+	err := s.st.CancelReservation(key)
+	status, msg := MarshalError(err)
+	reply := pb.CancelReservationReply{
+		Status: int32(status),
+	}
+	if msg != "" {
+		reply.Error = msg
+	}
+	return &reply, nil
+}
+
+func (s *Server) MGet(ctx context.Context, req *pb.MGetRequest) (*pb.MGetReply, error) {
+	log.Printf("MGET request: keys = %v", len(req.Keys))
+
+	keys := make([]RecordID, 0, len(req.Keys))
+	for _, k := range req.Keys {
+		keys = append(keys, RecordID(k))
+	}
+	var data [][]byte
+	var errs []error
+	if cb, ok := s.st.(ContextBatcher); ok {
+		data, errs = cb.MGetContext(incomingConsistency(ctx), keys)
+	} else {
+		data, errs = s.st.MGet(keys)
+	}
+	reply := pb.MGetReply{
+		Records: make([]*pb.GetReply, 0, len(keys)),
+	}
+	for i := range keys {
+		status, msg := MarshalError(errs[i])
+		reply.Records = append(reply.Records, &pb.GetReply{
+			Status: int32(status),
+			Error:  msg,
+			Data:   data[i],
+		})
+	}
+	return &reply, nil
+}
+
+func (s *Server) MPut(ctx context.Context, req *pb.MPutRequest) (*pb.MPutReply, error) {
+	log.Printf("MPUT request: keys = %v", len(req.Records))
+
+	keys := make([]RecordID, 0, len(req.Records))
+	data := make([][]byte, 0, len(req.Records))
+	for _, r := range req.Records {
+		keys = append(keys, RecordID(r.Key))
+		data = append(data, r.Data)
+	}
+	var errs []error
+	if cb, ok := s.st.(ContextBatcher); ok {
+		errs = cb.MPutContext(incomingVersion(incomingConsistency(ctx)), keys, data)
+	} else {
+		errs = s.st.MPut(keys, data)
+	}
+	reply := pb.MPutReply{
+		Records: make([]*pb.PutReply, 0, len(keys)),
+	}
+	for _, err := range errs {
+		status, msg := MarshalError(err)
+		reply.Records = append(reply.Records, &pb.PutReply{Status: int32(status), Error: msg})
+	}
+	return &reply, nil
+}
+
+func (s *Server) MDel(ctx context.Context, req *pb.MDelRequest) (*pb.MDelReply, error) {
+	log.Printf("MDEL request: keys = %v", len(req.Keys))
+
+	keys := make([]RecordID, 0, len(req.Keys))
+	for _, k := range req.Keys {
+		keys = append(keys, RecordID(k))
+	}
+	var errs []error
+	if cb, ok := s.st.(ContextBatcher); ok {
+		errs = cb.MDelContext(incomingVersion(incomingConsistency(ctx)), keys)
+	} else {
+		errs = s.st.MDel(keys)
+	}
+	reply := pb.MDelReply{
+		Records: make([]*pb.DelReply, 0, len(keys)),
+	}
+	for _, err := range errs {
+		status, msg := MarshalError(err)
+		reply.Records = append(reply.Records, &pb.DelReply{Status: int32(status), Error: msg})
+	}
+	return &reply, nil
+}
+
+func (s *Server) Delta(ctx context.Context, req *pb.DeltaRequest) (*pb.DeltaReply, error) {
+	log.Printf("DELTA request: since = %v", req.Since)
+
+	var delta Delta
+	err := ErrSeqOutOfRange
+	if ds, ok := s.st.(DeltaSource); ok {
+		delta, err = ds.Delta(req.Since, int(req.Limit))
+	}
+	status, msg := MarshalError(err)
+	reply := pb.DeltaReply{
+		Status:  int32(status),
+		Error:   msg,
+		Records: make([]*pb.Record, 0, len(delta.Records)),
+		Seq:     delta.Seq,
+		More:    delta.More,
+	}
+	for _, r := range delta.Records {
+		reply.Records = append(reply.Records, &pb.Record{Key: uint32(r.Key), Data: r.Data, Deleted: r.Deleted})
+	}
+	return &reply, nil
+}
+
+func (s *Server) Checksum(ctx context.Context, req *pb.ChecksumRequest) (*pb.ChecksumReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("CHECKSUM request: key = %v, hash = %q", key, req.Hash)
+
+	sum, err := s.st.Checksum(key, Hash(req.Hash))
+	status, msg := MarshalError(err)
+	reply := pb.ChecksumReply{
+		Status: int32(status),
+		Error:  msg,
+	}
+	if err == nil {
+		reply.Sum = sum[:]
+	}
+	return &reply, nil
+}
+
+func (s *Server) Paxos(ctx context.Context, req *pb.PaxosRequest) (*pb.PaxosReply, error) {
+	key := RecordID(req.Key)
+	p := proposalFromPb(req.Proposal)
+	log.Printf("PAXOS request: key = %v, phase = %v, ballot = %v", key, req.Phase, p.Ballot)
+
+	promise, err := s.st.Paxos(key, PaxosPhase(req.Phase), p)
+	status, msg := MarshalError(err)
+	return &pb.PaxosReply{
+		Status:    int32(status),
+		Error:     msg,
+		Promised:  promise.Promised,
+		Accepted:  proposalToPb(promise.Accepted),
+		Committed: promise.Committed,
+		Value:     promise.Value,
+		Found:     promise.Found,
+	}, nil
+}
+
+func (s *Server) AcquireReadLease(ctx context.Context, req *pb.AcquireReadLeaseRequest) (*pb.AcquireReadLeaseReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("ACQUIRE READ LEASE request: key = %v, holder = %v, ttl = %v", key, req.Holder, time.Duration(req.Ttl))
+
+	lease, err := s.st.AcquireReadLease(key, req.Holder, time.Duration(req.Ttl))
+	status, msg := MarshalError(err)
+	return &pb.AcquireReadLeaseReply{
+		Status: int32(status),
+		Error:  msg,
+		Value:  lease.Value,
+		Found:  lease.Found,
+	}, nil
+}
+
+func (s *Server) Update(ctx context.Context, req *pb.PutRequest) (*pb.PutReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("UPDATE request: key = %v", key)
+
+	var err error
+	if cu, ok := s.st.(ContextUpdater); ok {
+		err = cu.UpdateContext(incomingVersion(incomingConsistency(ctx)), key, req.Data)
+	} else {
+		err = s.st.Update(key, req.Data)
+	}
+	status, msg := MarshalError(err)
+	return &pb.PutReply{
+		Status: int32(status),
+		Error:  msg,
+	}, nil
+}
+
+func (s *Server) Upsert(ctx context.Context, req *pb.PutRequest) (*pb.PutReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("UPSERT request: key = %v", key)
+
+	var err error
+	if cu, ok := s.st.(ContextUpdater); ok {
+		err = cu.UpsertContext(incomingVersion(incomingConsistency(ctx)), key, req.Data)
+	} else {
+		err = s.st.Upsert(key, req.Data)
+	}
+	status, msg := MarshalError(err)
+	return &pb.PutReply{
+		Status: int32(status),
+		Error:  msg,
+	}, nil
+}
+
+func (s *Server) Merge(ctx context.Context, req *pb.PutRequest) (*pb.PutReply, error) {
+	key := RecordID(req.Key)
+	log.Printf("MERGE request: key = %v", key)
+
+	status, msg := MarshalError(s.st.Merge(key, req.Data))
+	return &pb.PutReply{
+		Status: int32(status),
+		Error:  msg,
+	}, nil
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SignWindow is a default time a signed request is accepted for after
+// or before it was signed.
+const SignWindow = 30 * time.Second
+
+// Metadata keys carrying signatures.
+const (
+	signKeyID = "x-ddsp-key-id"
+	signTime  = "x-ddsp-time"
+	signature = "x-ddsp-signature"
+)
+
+// SigningConfig stores keys to sign requests and replies of nodes and
+// frontends with HMAC-SHA256, so they can't be tampered with or forged
+// where TLS is impractical. Signing is disabled if KeyID is empty.
+// Keys are rotated by adding a new key everywhere, then switching KeyID
+// to it and then removing the old one.
+type SigningConfig struct {
+	// KeyID is an ID of the key in Keys to sign with.
+	KeyID string `yaml:"key_id"`
+	// Keys maps IDs of keys to secrets, signatures made with any of them
+	// are accepted.
+	Keys map[string]string
+	// Window is a time a request is accepted for after or before it was
+	// signed, SignWindow by default. Requests may be replayed within it.
+	Window time.Duration
+}
+
+// Validate checks that cfg is consistent.
+func (cfg SigningConfig) Validate() error {
+	var errs ConfigError
+	_, ok := cfg.Keys[cfg.KeyID]
+	errs.Check(cfg.KeyID == "" || ok, "Keys should have the key %q of KeyID", cfg.KeyID)
+	for id, key := range cfg.Keys {
+		errs.Check(key != "", "Key %q should not be empty", id)
+	}
+	errs.Check(cfg.Window >= 0, "Window should not be negative, got %v", cfg.Window)
+	return errs.Err()
+}
+
+// Signer signs and verifies gRPC requests and replies with the keys of
+// SigningConfig. A nil Signer doesn't sign.
+type Signer struct {
+	clock Clock
+
+	lock sync.RWMutex
+	conf SigningConfig
+}
+
+// NewSigner creates a Signer with cfg telling time with clock. Returns nil
+// if signing is disabled and an error if cfg is invalid.
+func NewSigner(cfg SigningConfig, clock Clock) (*Signer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.KeyID == "" {
+		return nil, nil
+	}
+	if cfg.Window == 0 {
+		cfg.Window = SignWindow
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &Signer{conf: cfg, clock: clock}, nil
+}
+
+// Rotate replaces the keys of s with the ones of cfg, e.g. rotated in
+// a secrets provider, without restarting the service. Signing can't be
+// disabled by rotation, so KeyID should be set.
+func (s *Signer) Rotate(cfg SigningConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.KeyID == "" {
+		return fmt.Errorf("KeyID should be set to rotate keys")
+	}
+	if cfg.Window == 0 {
+		cfg.Window = SignWindow
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conf = cfg
+	return nil
+}
+
+// config returns the current keys of s.
+func (s *Signer) config() SigningConfig {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.conf
+}
+
+// sign computes a signature of msg with the key id of conf tagged by parts.
+func sign(conf SigningConfig, id string, msg interface{}, parts ...string) (string, error) {
+	key, ok := conf.Keys[id]
+	if !ok {
+		return "", fmt.Errorf("unknown key %q", id)
+	}
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if m, ok := msg.(proto.Message); ok && m != nil {
+		if err := buf.Marshal(m); err != nil {
+			return "", err
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	for _, p := range parts {
+		mac.Write([]byte(p))
+		mac.Write([]byte{0})
+	}
+	mac.Write(buf.Bytes())
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verify checks that sig is a signature of msg tagged by parts made with
+// the key id of conf.
+func verify(conf SigningConfig, id, sig string, msg interface{}, parts ...string) bool {
+	want, err := sign(conf, id, msg, parts...)
+	return err == nil && hmac.Equal([]byte(sig), []byte(want))
+}
+
+// DialOption returns an option making a connection sign requests and
+// verify signatures of replies, replies failing verification return
+// ErrBadSignature. Returns nil if s is nil.
+func (s *Signer) DialOption() grpc.DialOption {
+	if s == nil {
+		return nil
+	}
+	return grpc.WithUnaryInterceptor(s.intercept)
+}
+
+func (s *Signer) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	conf := s.config()
+	ts := strconv.FormatInt(s.clock.Now().UnixNano(), 10)
+	sig, err := sign(conf, conf.KeyID, req, "request", method, ts)
+	if err != nil {
+		return err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, signKeyID, conf.KeyID, signTime, ts, signature, sig)
+
+	var trailer metadata.MD
+	err = invoker(ctx, method, req, reply, cc, append(opts, grpc.Trailer(&trailer))...)
+	if status.Code(err) == codes.Unauthenticated {
+		return fmt.Errorf("%w: %v", ErrBadSignature, status.Convert(err).Message())
+	}
+	if err != nil {
+		return err
+	}
+	if !verify(conf, first(trailer, signKeyID), first(trailer, signature), reply, "reply", method, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Server returns an interceptor rejecting requests without valid signatures
+// with codes.Unauthenticated and signing replies. Returns nil if s is nil.
+func (s *Signer) Server() grpc.UnaryServerInterceptor {
+	if s == nil {
+		return nil
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		conf := s.config()
+		md, _ := metadata.FromIncomingContext(ctx)
+		id, ts, sig := first(md, signKeyID), first(md, signTime), first(md, signature)
+		signed, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "request is not signed")
+		}
+		if age := s.clock.Now().Sub(time.Unix(0, signed)); age > conf.Window || age < -conf.Window {
+			return nil, status.Errorf(codes.Unauthenticated, "request signed %v ago is out of the window", age)
+		}
+		if !verify(conf, id, sig, req, "request", info.FullMethod, ts) {
+			return nil, status.Error(codes.Unauthenticated, "bad request signature")
+		}
+
+		reply, err := handler(ctx, req)
+		if err != nil {
+			return reply, err
+		}
+		replySig, err := sign(conf, conf.KeyID, reply, "reply", info.FullMethod, sig)
+		if err != nil {
+			return nil, err
+		}
+		grpc.SetTrailer(ctx, metadata.Pairs(signKeyID, conf.KeyID, signature, replySig))
+		return reply, nil
+	}
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// UnaryInterceptors returns server options applying the given interceptors
+// in order, nil ones are skipped. The vendored gRPC allows a single one.
+func UnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) []grpc.ServerOption {
+	var chain []grpc.UnaryServerInterceptor
+	for _, i := range interceptors {
+		if i != nil {
+			chain = append(chain, i)
+		}
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return chainFrom(chain, info, handler)(ctx, req)
+	})}
+}
+
+// chainFrom returns a handler calling interceptors in order and then
+// handler.
+func chainFrom(interceptors []grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) grpc.UnaryHandler {
+	if len(interceptors) == 0 {
+		return handler
+	}
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptors[0](ctx, req, info, chainFrom(interceptors[1:], info, handler))
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"storage/pb"
+)
+
+// valueStorage serves a value for every key.
+type valueStorage struct {
+	Storage
+}
+
+func (valueStorage) Get(k RecordID) ([]byte, error) {
+	return []byte("value"), nil
+}
+
+// serveSigned serves valueStorage verifying signatures with cfg and returns
+// its address with the signer.
+func serveSigned(t *testing.T, cfg SigningConfig, clock Clock) (ServiceAddr, *Signer) {
+	t.Helper()
+	signer, err := NewSigner(cfg, clock)
+	if err != nil {
+		t.Fatalf("NewSigner() got error %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(UnaryInterceptors(signer.Server())...)
+	pb.RegisterStorageServer(srv, &Server{st: valueStorage{}})
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return ServiceAddr(l.Addr().String()), signer
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1000, 0)
+	old := map[string]string{"old": "secret"}
+	both := map[string]string{"old": "secret", "new": "other secret"}
+	tests := []struct {
+		name           string
+		server, client SigningConfig
+		skew           time.Duration
+		wantErr        error
+	}{
+		{
+			name:   "same key",
+			server: SigningConfig{KeyID: "old", Keys: old},
+			client: SigningConfig{KeyID: "old", Keys: old},
+		},
+		{
+			name:    "unknown key",
+			server:  SigningConfig{KeyID: "old", Keys: old},
+			client:  SigningConfig{KeyID: "new", Keys: map[string]string{"new": "secret"}},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "wrong secret",
+			server:  SigningConfig{KeyID: "old", Keys: old},
+			client:  SigningConfig{KeyID: "old", Keys: map[string]string{"old": "guess"}},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "rotated key",
+			server: SigningConfig{KeyID: "new", Keys: both},
+			client: SigningConfig{KeyID: "old", Keys: both},
+		},
+		{
+			name:    "reply signed with a key unknown to client",
+			server:  SigningConfig{KeyID: "new", Keys: both},
+			client:  SigningConfig{KeyID: "old", Keys: old},
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:   "skew within window",
+			server: SigningConfig{KeyID: "old", Keys: old},
+			client: SigningConfig{KeyID: "old", Keys: old},
+			skew:   SignWindow / 2,
+		},
+		{
+			name:    "expired",
+			server:  SigningConfig{KeyID: "old", Keys: old},
+			client:  SigningConfig{KeyID: "old", Keys: old},
+			skew:    -2 * SignWindow,
+			wantErr: ErrBadSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, _ := serveSigned(t, tt.server, &testClock{now: now})
+			signer, err := NewSigner(tt.client, &testClock{now: now.Add(tt.skew)})
+			if err != nil {
+				t.Fatalf("NewSigner() got error %v", err)
+			}
+			data, err := NewSigningClient(nil, signer).Get(addr, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(data) != "value" {
+				t.Errorf("Get() = %q, want %q", data, "value")
+			}
+		})
+	}
+}
+
+func TestSigner_Unsigned(t *testing.T) {
+	addr, _ := serveSigned(t, SigningConfig{KeyID: "key", Keys: map[string]string{"key": "secret"}}, nil)
+	if _, err := NewClient().Get(addr, 1); err == nil {
+		t.Errorf("Get() without a signature got no error")
+	}
+}
+
+func TestSigningConfig_Validate(t *testing.T) {
+	if s, err := NewSigner(SigningConfig{}, nil); s != nil || err != nil {
+		t.Errorf("NewSigner() of empty config = %v, %v, want nil, nil", s, err)
+	}
+	if _, err := NewSigner(SigningConfig{KeyID: "missing", Keys: map[string]string{"key": "secret"}}, nil); err == nil {
+		t.Errorf("NewSigner() with missing KeyID got no error")
+	}
+}
+
+func TestSigner_Rotate(t *testing.T) {
+	addr, signer := serveSigned(t, SigningConfig{KeyID: "old", Keys: map[string]string{"old": "secret"}}, nil)
+
+	rotated := SigningConfig{KeyID: "new", Keys: map[string]string{"new": "other secret"}}
+	client, err := NewSigner(rotated, nil)
+	if err != nil {
+		t.Fatalf("NewSigner() got error %v", err)
+	}
+	if _, err := NewSigningClient(nil, client).Get(addr, 1); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("Get() with a key unknown to server got error %v, want %v", err, ErrBadSignature)
+	}
+	if err := signer.Rotate(rotated); err != nil {
+		t.Fatalf("Rotate() got error %v", err)
+	}
+	if _, err := NewSigningClient(nil, client).Get(addr, 1); err != nil {
+		t.Errorf("Get() after Rotate() got error %v", err)
+	}
+	if err := signer.Rotate(SigningConfig{}); err == nil {
+		t.Errorf("Rotate() disabling signing got no error")
+	}
+}
@@ -0,0 +1,147 @@
+// Package typed provides Store, a type-safe API over a storage.Client: keys
+// of any type are encoded to record IDs by key encoders and values of any
+// type are marshaled by codecs, so applications don't repeat marshaling
+// code around every request.
+package typed
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"storage"
+)
+
+// KeyEncoder encodes keys of type K to record IDs. Distinct keys should be
+// encoded to distinct IDs, as records of keys encoded to the same ID are
+// the same record.
+type KeyEncoder[K any] func(k K) storage.RecordID
+
+// Integer is a type of integer keys.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// IntKey encodes integer keys to record IDs as is. Keys out of the range
+// of storage.RecordID are truncated.
+func IntKey[K Integer](k K) storage.RecordID {
+	return storage.RecordID(k)
+}
+
+// StringKey encodes string keys to record IDs by their FNV-1a hash, so keys
+// may collide once there are many of them.
+func StringKey(k string) storage.RecordID {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	return storage.RecordID(h.Sum32())
+}
+
+// Codec marshals values of type V to record values and back.
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(d []byte) (V, error)
+}
+
+// JSON is a Codec marshaling values as JSON.
+type JSON[V any] struct{}
+
+func (JSON[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON[V]) Unmarshal(d []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(d, &v)
+	return v, err
+}
+
+// Bytes is a Codec storing byte slices as is.
+type Bytes struct{}
+
+func (Bytes) Marshal(v []byte) ([]byte, error) {
+	return v, nil
+}
+
+func (Bytes) Unmarshal(d []byte) ([]byte, error) {
+	return d, nil
+}
+
+// String is a Codec storing strings as is.
+type String struct{}
+
+func (String) Marshal(v string) ([]byte, error) {
+	return []byte(v), nil
+}
+
+func (String) Unmarshal(d []byte) (string, error) {
+	return string(d), nil
+}
+
+// Store puts and gets records with keys of type K and values of type V
+// via a frontend.
+type Store[K comparable, V any] struct {
+	client storage.Client
+	addr   storage.ServiceAddr
+	key    KeyEncoder[K]
+	codec  Codec[V]
+}
+
+// New creates a Store making requests to the frontend at addr with c,
+// encoding keys with key and values with codec.
+func New[K comparable, V any](c storage.Client, addr storage.ServiceAddr, key KeyEncoder[K], codec Codec[V]) *Store[K, V] {
+	return &Store[K, V]{client: c, addr: addr, key: key, codec: codec}
+}
+
+// Put puts the record with key k and value v. Returns an error if v can't
+// be marshaled.
+func (s *Store[K, V]) Put(k K, v V) error {
+	d, err := s.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal value of key %v: %w", k, err)
+	}
+	return s.client.Put(s.addr, s.key(k), d)
+}
+
+// Get gets the value of the record with key k. Returns an error if it
+// can't be unmarshaled.
+func (s *Store[K, V]) Get(k K) (V, error) {
+	d, err := s.client.Get(s.addr, s.key(k))
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return s.unmarshal(k, d)
+}
+
+// Del deletes the record with key k.
+func (s *Store[K, V]) Del(k K) error {
+	return s.client.Del(s.addr, s.key(k))
+}
+
+// GetMany gets values of records with keys in one request, returning
+// a value and an error for each key.
+func (s *Store[K, V]) GetMany(keys []K) ([]V, []error, error) {
+	ids := make([]storage.RecordID, len(keys))
+	for i, k := range keys {
+		ids[i] = s.key(k)
+	}
+	data, errs, err := s.client.MGet(s.addr, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]V, len(keys))
+	for i, k := range keys {
+		if errs[i] == nil {
+			values[i], errs[i] = s.unmarshal(k, data[i])
+		}
+	}
+	return values, errs, nil
+}
+
+func (s *Store[K, V]) unmarshal(k K, d []byte) (V, error) {
+	v, err := s.codec.Unmarshal(d)
+	if err != nil {
+		return v, fmt.Errorf("Failed to unmarshal value of key %v: %w", k, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,88 @@
+package typed
+
+import (
+	"reflect"
+	"testing"
+
+	"storage"
+)
+
+type fakeClient struct {
+	storage.Client
+	data map[storage.RecordID][]byte
+}
+
+func (f *fakeClient) Put(node storage.ServiceAddr, k storage.RecordID, d []byte) error {
+	f.data[k] = d
+	return nil
+}
+
+func (f *fakeClient) Get(node storage.ServiceAddr, k storage.RecordID) ([]byte, error) {
+	d, ok := f.data[k]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func (f *fakeClient) Del(node storage.ServiceAddr, k storage.RecordID) error {
+	delete(f.data, k)
+	return nil
+}
+
+func (f *fakeClient) MGet(node storage.ServiceAddr, keys []storage.RecordID) ([][]byte, []error, error) {
+	data := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	for i, k := range keys {
+		data[i], errs[i] = f.Get(node, k)
+	}
+	return data, errs, nil
+}
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func TestStore(t *testing.T) {
+	fc := &fakeClient{data: make(map[storage.RecordID][]byte)}
+	users := New[string, user](fc, "frontend", StringKey, JSON[user]{})
+
+	alice := user{Name: "Alice", Age: 30}
+	if err := users.Put("alice", alice); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got, err := users.Get("alice"); err != nil || got != alice {
+		t.Errorf("Get() got %+v, %v, want %+v", got, err, alice)
+	}
+	if _, err := users.Get("bob"); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() of a missing key got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	fc.data[StringKey("broken")] = []byte("{")
+	values, errs, err := users.GetMany([]string{"alice", "bob", "broken"})
+	if err != nil {
+		t.Fatalf("GetMany() error: %v", err)
+	}
+	if values[0] != alice || errs[0] != nil || errs[1] != storage.ErrRecordNotFound || errs[2] == nil {
+		t.Errorf("GetMany() got %+v, %v", values, errs)
+	}
+
+	if err := users.Del("alice"); err != nil {
+		t.Fatalf("Del() error: %v", err)
+	}
+	if _, err := users.Get("alice"); err != storage.ErrRecordNotFound {
+		t.Errorf("Get() after Del() got error %v, want %v", err, storage.ErrRecordNotFound)
+	}
+
+	names := New[uint16, string](fc, "frontend", IntKey[uint16], String{})
+	if err := names.Put(7, "seven"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if !reflect.DeepEqual(fc.data[7], []byte("seven")) {
+		t.Errorf("Put() stored %q under key 7, want %q", fc.data[7], "seven")
+	}
+	if got, err := names.Get(7); err != nil || got != "seven" {
+		t.Errorf("Get() got %q, %v, want %q", got, err, "seven")
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// versionKey is the metadata key passing versions of writes.
+const versionKey = "x-ddsp-version"
+
+type versionContextKey struct{}
+
+// WithVersion returns a copy of ctx making writes made with it create
+// version v of the records they write, see Client.GetVersion. Writers
+// assign versions, e.g. from their clocks, so all replicas of a record
+// number its versions the same. Deletes made with it delete records only if
+// their values are version v, failing with ErrConditionFailed otherwise, so
+// writers can roll back their own writes without deleting newer ones.
+// Clients pass v to nodes along with requests.
+func WithVersion(ctx context.Context, v uint64) context.Context {
+	return context.WithValue(ctx, versionContextKey{}, v)
+}
+
+// VersionOf returns the version of writes made with ctx set with
+// WithVersion, 0 if none is set and nodes number the versions themselves.
+func VersionOf(ctx context.Context) uint64 {
+	v, _ := ctx.Value(versionContextKey{}).(uint64)
+	return v
+}
+
+// outgoingVersion adds the version of ctx to the metadata of requests made
+// with it if it is set.
+func outgoingVersion(ctx context.Context) context.Context {
+	if v := VersionOf(ctx); v != 0 {
+		return metadata.AppendToOutgoingContext(ctx, versionKey, strconv.FormatUint(v, 10))
+	}
+	return ctx
+}
+
+// incomingVersion restores the version passed with the request served with
+// ctx. Malformed versions are ignored.
+func incomingVersion(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v, err := strconv.ParseUint(first(md, versionKey), 10, 64)
+	if err != nil || v == 0 {
+		return ctx
+	}
+	return WithVersion(ctx, v)
+}
@@ -0,0 +1,162 @@
+package tiering
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Timeout is a time a request to the S3 service may take at most.
+//
+// Timeout -- наибольшее время выполнения запроса к сервису S3.
+const Timeout = 30 * time.Second
+
+var httpClient = http.Client{Timeout: Timeout}
+
+// S3 is a Store keeping objects in a bucket of an S3-compatible service
+// addressed in path style, requests are signed with AWS Signature Version 4.
+//
+// S3 -- Store, хранящий объекты в bucket S3-совместимого сервиса,
+// адресуемого по пути, запросы подписываются AWS Signature Version 4.
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+}
+
+// NewS3 creates an S3 store of bucket at endpoint. Region is us-east-1
+// if it is empty.
+//
+// NewS3 создает хранилище S3 для bucket по адресу endpoint. Если region
+// не задан, используется us-east-1.
+func NewS3(endpoint, region, bucket, accessKey, secretKey string) *S3 {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		u = &url.URL{Scheme: "https", Host: endpoint}
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3{endpoint: u, region: region, bucket: bucket, accessKey: accessKey, secretKey: secretKey}
+}
+
+// Put uploads d as the object name.
+func (s *S3) Put(name string, d []byte) error {
+	resp, err := s.do("PUT", name, d)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("S3 replied %v to put of %q", resp.Status, name)
+	}
+	return nil
+}
+
+// Get downloads the object name.
+func (s *S3) Get(name string) ([]byte, error) {
+	resp, err := s.do("GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return nil, fmt.Errorf("S3 replied %v to get of %q", resp.Status, name)
+}
+
+// Delete removes the object name.
+func (s *S3) Delete(name string) error {
+	resp, err := s.do("DELETE", name, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("S3 replied %v to delete of %q", resp.Status, name)
+}
+
+func (s *S3) do(method, name string, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + name
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + "/" + escape(s.bucket) + "/" + escape(name)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now().UTC())
+	return httpClient.Do(req)
+}
+
+// sign adds headers authorizing req with body at now by AWS Signature
+// Version 4.
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+	payload := sha256.Sum256(body)
+	hash := hex.EncodeToString(payload[:])
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+
+	const signed = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + hash,
+		"x-amz-date:" + stamp,
+		"",
+		signed,
+		hash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = mac(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signed, hex.EncodeToString(mac(key, toSign))))
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escape encodes name as required by AWS Signature Version 4: every byte
+// except unreserved characters and slashes is percent-encoded.
+func escape(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
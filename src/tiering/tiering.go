@@ -0,0 +1,178 @@
+// Package tiering provides object stores nodes migrate rarely accessed
+// records to, keeping only a pointer to the object locally, and the policy
+// of such migration. Records are fetched back transparently when read.
+//
+// Package tiering предоставляет хранилища объектов, в которые node
+// переносят редко используемые записи, оставляя у себя только указатель
+// на объект, и политику такого переноса. При чтении записи прозрачно
+// загружаются обратно.
+package tiering
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"storage"
+)
+
+// ErrNotFound is returned by Get if there is no object with the given name.
+//
+// ErrNotFound возвращается Get, если объекта с данным именем нет.
+var ErrNotFound = errors.New("Object not found")
+
+// After is a default time a record should not be accessed for
+// to be migrated to the object store.
+//
+// After -- время по умолчанию, в течение которого к записи не должно быть
+// обращений, чтобы она была перенесена в хранилище объектов.
+const After = 24 * time.Hour
+
+// Store is the common interface of object stores.
+//
+// Store -- общий интерфейс хранилищ объектов.
+type Store interface {
+	// Put stores d as the object name replacing the existing one.
+	Put(name string, d []byte) error
+	// Get returns data of the object name or ErrNotFound.
+	Get(name string) ([]byte, error)
+	// Delete removes the object name, missing objects are not an error.
+	Delete(name string) error
+}
+
+const (
+	KindNone   = ""
+	KindS3     = "s3"
+	KindMemory = "memory"
+)
+
+// Config stores configuration of tiering.
+//
+// Config -- содержит конфигурацию переноса записей.
+type Config struct {
+	// Kind is a kind of the object store: s3 or memory, records are not
+	// migrated if it is empty.
+	// Kind -- вид хранилища объектов: s3 или memory, если не задан, записи
+	// не переносятся.
+	Kind string
+	// Endpoint is a URL of an S3-compatible service, e.g.
+	// https://s3.eu-central-1.amazonaws.com or http://127.0.0.1:9000.
+	// Endpoint -- URL S3-совместимого сервиса, например
+	// https://s3.eu-central-1.amazonaws.com или http://127.0.0.1:9000.
+	Endpoint string
+	// Region is a region requests to the S3 service are signed for.
+	// Region -- регион, для которого подписываются запросы к сервису S3.
+	Region string
+	// Bucket is a bucket to store objects in.
+	// Bucket -- bucket, в котором хранятся объекты.
+	Bucket string
+	// Prefix is prepended to names of objects, so several clusters
+	// can share a bucket.
+	// Prefix -- добавляется в начало имен объектов, так что несколько
+	// кластеров могут использовать один bucket.
+	Prefix string
+	// AccessKey and SecretKey are credentials of the S3 service.
+	// AccessKey и SecretKey -- учетные данные сервиса S3.
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+
+	// Interval is a time interval between migration passes, 0 disables them.
+	// Interval -- интервал между проходами переноса, 0 отключает их.
+	Interval time.Duration
+	// After is a time a record should not be accessed for to be migrated,
+	// After by default.
+	// After -- время, в течение которого к записи не должно быть обращений,
+	// чтобы она была перенесена, по умолчанию After.
+	After time.Duration
+	// MinSize is a size of values from which records are migrated,
+	// smaller ones are cheaper to keep than to fetch.
+	// MinSize -- размер значений, начиная с которого записи переносятся,
+	// меньшие дешевле хранить, чем загружать.
+	MinSize int `yaml:"min_size"`
+}
+
+// Validate checks that cfg is consistent.
+//
+// Validate проверяет, что cfg непротиворечива.
+func (cfg Config) Validate() error {
+	var errs storage.ConfigError
+	switch cfg.Kind {
+	case KindNone, KindMemory:
+	case KindS3:
+		errs.Check(cfg.Endpoint != "", "Endpoint of s3 object store should be set")
+		errs.Check(cfg.Bucket != "", "Bucket of s3 object store should be set")
+		errs.Check(cfg.AccessKey != "" && cfg.SecretKey != "", "AccessKey and SecretKey of s3 object store should be set")
+	default:
+		errs.Check(false, "Unknown object store %q", cfg.Kind)
+	}
+	errs.Check(cfg.Interval >= 0, "Tiering interval should not be negative, got %v", cfg.Interval)
+	errs.Check(cfg.After >= 0, "Tiering after should not be negative, got %v", cfg.After)
+	errs.Check(cfg.MinSize >= 0, "Tiering min size should not be negative, got %v", cfg.MinSize)
+	return errs.Err()
+}
+
+// New creates a Store described by cfg. Returns nil if cfg.Kind is empty.
+//
+// New создает Store, описанный cfg. Возвращает nil, если cfg.Kind не задан.
+func New(cfg Config) (Store, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Kind {
+	case KindS3:
+		return NewS3(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey), nil
+	case KindMemory:
+		return NewMemory(), nil
+	}
+	return nil, nil
+}
+
+// Memory is a Store keeping objects in memory, e.g. for tests.
+//
+// Memory -- Store, хранящий объекты в памяти, например для тестов.
+type Memory struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+// NewMemory creates an empty Memory store.
+//
+// NewMemory создает пустое хранилище Memory.
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string][]byte)}
+}
+
+// Put stores a copy of d as the object name.
+func (m *Memory) Put(name string, d []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.objects[name] = append([]byte(nil), d...)
+	return nil
+}
+
+// Get returns data of the object name.
+func (m *Memory) Get(name string) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	d, ok := m.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return d, nil
+}
+
+// Delete removes the object name.
+func (m *Memory) Delete(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.objects, name)
+	return nil
+}
+
+// Len returns the number of stored objects.
+func (m *Memory) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.objects)
+}
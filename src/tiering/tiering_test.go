@@ -0,0 +1,139 @@
+package tiering
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 implements the parts of S3 API used by S3 and checks signatures
+// of requests as if they were made by signer.
+type fakeS3 struct {
+	t       *testing.T
+	signer  *S3
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	stamp, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		http.Error(w, "bad date", http.StatusBadRequest)
+		return
+	}
+	check := r.Clone(r.Context())
+	check.URL.Host = r.Host
+	check.Header = http.Header{}
+	f.signer.sign(check, body, stamp)
+	if got, want := r.Header.Get("Authorization"), check.Header.Get("Authorization"); got != want {
+		f.t.Errorf("Authorization = %q, want %q", got, want)
+		http.Error(w, "bad signature", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "PUT":
+		f.objects[r.URL.Path] = body
+	case "GET":
+		d, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(d)
+	case "DELETE":
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{t: t, objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	s := NewS3(srv.URL, "eu-central-1", "archive", "AKID", "secret")
+	fake.signer = s
+
+	name := "node-1/42 v:1"
+	if _, err := s.Get(name); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of missing object: %v, want ErrNotFound", err)
+	}
+	if err := s.Put(name, []byte("cold")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := fake.objects["/archive/node-1/42 v:1"]; !ok {
+		t.Fatalf("Objects %v, want /archive/node-1/42 v:1", fake.objects)
+	}
+	d, err := s.Get(name)
+	if err != nil || !bytes.Equal(d, []byte("cold")) {
+		t.Fatalf("Get = %q, %v, want cold", d, err)
+	}
+	if err := s.Delete(name); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(name); err != nil {
+		t.Fatalf("Delete of missing object: %v", err)
+	}
+	if len(fake.objects) != 0 {
+		t.Fatalf("Objects %v left after Delete", fake.objects)
+	}
+}
+
+func TestS3Sign(t *testing.T) {
+	s := NewS3("http://127.0.0.1:9000", "", "b", "AKID", "secret")
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:9000/b/k", nil)
+	s.sign(req, nil, time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC))
+
+	auth := req.Header.Get("Authorization")
+	prefix := "AWS4-HMAC-SHA256 Credential=AKID/20181001/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(auth, prefix) || len(auth) != len(prefix)+64 {
+		t.Fatalf("Authorization = %q, want %q followed by a signature", auth, prefix)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20181001T120000Z" {
+		t.Fatalf("X-Amz-Date = %q", got)
+	}
+
+	other := NewS3("http://127.0.0.1:9000", "", "b", "AKID", "other")
+	req2, _ := http.NewRequest("GET", "http://127.0.0.1:9000/b/k", nil)
+	other.sign(req2, nil, time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC))
+	if req2.Header.Get("Authorization") == auth {
+		t.Fatalf("Signatures with different secret keys are equal")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, tc := range []struct {
+		cfg Config
+		ok  bool
+	}{
+		{Config{}, true},
+		{Config{Kind: KindMemory, Interval: time.Minute}, true},
+		{Config{Kind: KindS3, Endpoint: "http://s3", Bucket: "b", AccessKey: "a", SecretKey: "s"}, true},
+		{Config{Kind: KindS3, Endpoint: "http://s3", Bucket: "b"}, false},
+		{Config{Kind: "tape"}, false},
+		{Config{Kind: KindMemory, After: -time.Second}, false},
+	} {
+		if err := tc.cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tc.cfg, err, tc.ok)
+		}
+	}
+
+	st, err := New(Config{})
+	if err != nil || st != nil {
+		t.Fatalf("New of empty config = %v, %v, want nil", st, err)
+	}
+	if st, err := New(Config{Kind: KindMemory}); err != nil || st == nil {
+		t.Fatalf("New of memory config = %v, %v", st, err)
+	}
+}